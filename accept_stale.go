@@ -0,0 +1,23 @@
+package rapidash
+
+import "time"
+
+// staleFillExpiration shortens expiration of value filled by query accepting stale values,
+// so value filled racing with update in other transaction is served only until staleness bound
+func (tx *Tx) staleFillExpiration(expiration time.Duration) time.Duration {
+	staleFill := tx.staleFill
+	if staleFill < time.Second {
+		// expiration less than a second means no expiration for cache server
+		staleFill = time.Second
+	}
+	if expiration <= 0 || expiration > staleFill {
+		return staleFill
+	}
+	return expiration
+}
+
+// isOldKey returns true if key is invalidated by the transaction
+func (tx *Tx) isOldKey(key string) bool {
+	_, exists := tx.stash.oldKey[key]
+	return exists
+}
@@ -0,0 +1,62 @@
+package rapidash
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAcceptStale(t *testing.T) {
+	r, err := New()
+	NoError(t, err)
+	r.cacheServer = newMemoryCacheServer()
+	slc := NewSecondLevelCache(userLoginType(), r.cacheServer, r.tableOption("user_logins"))
+	slc.primaryKey = NewPrimaryKey(slc.opt, "user_logins", []string{"id"}, slc.typ)
+	slc.indexes["id"] = slc.primaryKey
+	r.secondLevelCaches.set("user_logins", slc)
+
+	key, err := slc.cacheKeyByPrimaryKeyValue(slc.valueFactory.CreateUint64Value(1))
+	NoError(t, err)
+	tx, err := r.Begin(&execRecorder{})
+	NoError(t, err)
+	_, value, err := slc.encode(&UserLogin{ID: 1, UserID: 10, Name: "rapidash"})
+	NoError(t, err)
+	NoError(t, slc.setPrimaryKey(context.Background(), tx, key, value))
+	NoError(t, tx.Commit())
+
+	t.Run("read old key from cache", func(t *testing.T) {
+		tx, err := r.Begin(&execRecorder{})
+		NoError(t, err)
+		defer func() { NoError(t, tx.Rollback()) }()
+		NoError(t, slc.deleteOldKey(context.Background(), tx, key))
+
+		var userLogin UserLogin
+		Error(t, tx.FindByQueryBuilder(NewQueryBuilder("user_logins").Eq("id", uint64(1)), &userLogin))
+		NoError(t, tx.FindByQueryBuilder(NewQueryBuilder("user_logins").Eq("id", uint64(1)).AcceptStale(5*time.Second), &userLogin))
+		Equal(t, userLogin.Name, "rapidash")
+		_, stashed := tx.stash.primaryKeyToValue[key.String()]
+		Equal(t, stashed, false)
+		Equal(t, tx.isOldKey(key.String()), true)
+	})
+	t.Run("fill without lock", func(t *testing.T) {
+		tx, err := r.Begin(&execRecorder{})
+		NoError(t, err)
+		defer func() { NoError(t, tx.Rollback()) }()
+		tx.staleFill = 5 * time.Second
+		NoError(t, slc.setPrimaryKey(context.Background(), tx, key, value))
+		Equal(t, len(tx.lockKeys), 0)
+		tx.staleFill = 0
+		otherKey, err := slc.cacheKeyByPrimaryKeyValue(slc.valueFactory.CreateUint64Value(2))
+		NoError(t, err)
+		NoError(t, slc.setPrimaryKey(context.Background(), tx, otherKey, value))
+		Equal(t, len(tx.lockKeys), 1)
+	})
+	t.Run("stale fill expiration", func(t *testing.T) {
+		tx := &Tx{staleFill: 5 * time.Second}
+		Equal(t, tx.staleFillExpiration(0), 5*time.Second)
+		Equal(t, tx.staleFillExpiration(time.Minute), 5*time.Second)
+		Equal(t, tx.staleFillExpiration(2*time.Second), 2*time.Second)
+		tx.staleFill = time.Millisecond
+		Equal(t, tx.staleFillExpiration(0), time.Second)
+	})
+}
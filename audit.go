@@ -0,0 +1,137 @@
+package rapidash
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+type AuditEventType int
+
+const (
+	AuditEventCreate AuditEventType = iota
+	AuditEventUpdate
+	AuditEventDelete
+)
+
+func (typ AuditEventType) String() string {
+	switch typ {
+	case AuditEventCreate:
+		return "create"
+	case AuditEventUpdate:
+		return "update"
+	case AuditEventDelete:
+		return "delete"
+	}
+	return ""
+}
+
+func (typ AuditEventType) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`"%s"`, typ.String())), nil
+}
+
+// AuditEvent is a record mutated by transaction
+type AuditEvent struct {
+	Type       AuditEventType         `json:"type"`
+	Table      string                 `json:"table"`
+	PrimaryKey map[string]interface{} `json:"primaryKey"`
+	Columns    []string               `json:"columns,omitempty"`
+	TxID       string                 `json:"txId"`
+	Time       time.Time              `json:"time"`
+}
+
+// AuditSink receives events of records mutated by transaction after database commit succeeded.
+// Error returned by Emit doesn't fail the commit, it is only logged
+type AuditSink interface {
+	Emit([]*AuditEvent) error
+}
+
+func (c *SecondLevelCache) auditPrimaryKey(value *StructValue) map[string]interface{} {
+	primaryKey := map[string]interface{}{}
+	for _, column := range c.primaryKey.Columns {
+		if v := value.fields[column]; v != nil {
+			primaryKey[column] = v.RawValue()
+		}
+	}
+	return primaryKey
+}
+
+func (c *SecondLevelCache) audit(tx *Tx, typ AuditEventType, value *StructValue, columns []string) {
+	if tx.r.opt.auditSink == nil {
+		return
+	}
+	tx.auditEvents = append(tx.auditEvents, &AuditEvent{
+		Type:       typ,
+		Table:      c.typ.tableName,
+		PrimaryKey: c.auditPrimaryKey(value),
+		Columns:    columns,
+		TxID:       tx.id,
+		Time:       c.opt.now(),
+	})
+}
+
+func (c *SecondLevelCache) auditCreate(tx *Tx, value *StructValue) {
+	if tx.r.opt.auditSink == nil {
+		return
+	}
+	columns := make([]string, 0, len(value.fields))
+	for column := range value.fields {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	c.audit(tx, AuditEventCreate, value, columns)
+}
+
+func (c *SecondLevelCache) auditUpdate(tx *Tx, values *StructSliceValue, updateMap map[string]interface{}) {
+	if tx.r.opt.auditSink == nil || values == nil {
+		return
+	}
+	columns := make([]string, 0, len(updateMap))
+	for column := range updateMap {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	for _, value := range values.values {
+		c.audit(tx, AuditEventUpdate, value, columns)
+	}
+}
+
+// auditDelete reads primary keys of records deleted by builder before DELETE statement is executed
+func (c *SecondLevelCache) auditDelete(ctx context.Context, tx *Tx, builder *QueryBuilder) (e error) {
+	if tx.r.opt.auditSink == nil {
+		return nil
+	}
+	sql, args := builder.SelectSQL(c.valueFactory, c.typ)
+	rows, err := tx.connection(c).QueryContext(ctx, c.fallbackQuery(sql), args...)
+	if err != nil {
+		return xerrors.Errorf("failed sql %s %v: %w", sql, args, err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			e = xerrors.Errorf("failed to close rows: %w", err)
+		}
+	}()
+	for rows.Next() {
+		scanValues := c.typ.ScanValues(c.valueFactory)
+		if err := rows.Scan(scanValues...); err != nil {
+			return xerrors.Errorf("failed to scan: %w", err)
+		}
+		value := c.typ.StructValue(scanValues)
+		c.audit(tx, AuditEventDelete, value, nil)
+	}
+	return nil
+}
+
+func (tx *Tx) emitAuditEvents() {
+	events := tx.auditEvents
+	tx.auditEvents = nil
+	if len(events) == 0 || tx.r.opt.auditSink == nil {
+		return
+	}
+	if err := tx.r.opt.auditSink.Emit(events); err != nil {
+		log.Warn(fmt.Sprintf("failed to emit audit events of transaction %s: %+v", tx.id, err))
+	}
+}
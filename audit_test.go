@@ -0,0 +1,58 @@
+package rapidash
+
+import (
+	"testing"
+	"time"
+)
+
+type testAuditSink struct {
+	events []*AuditEvent
+}
+
+func (s *testAuditSink) Emit(events []*AuditEvent) error {
+	s.events = append(s.events, events...)
+	return nil
+}
+
+func TestAudit(t *testing.T) {
+	sink := &testAuditSink{}
+	r, err := New(Audit(sink))
+	NoError(t, err)
+	clock := &testClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	opt := r.tableOption("user_logins")
+	opt.clock = clock
+	slc := NewSecondLevelCache(userLoginType(), nil, opt)
+	slc.primaryKey = &Index{Columns: []string{"id"}}
+	value := &StructValue{typ: slc.typ, fields: map[string]*Value{
+		"id":      NewUint64Value(1),
+		"user_id": NewUint64Value(10),
+	}}
+	t.Run("emit after commit", func(t *testing.T) {
+		tx := &Tx{r: r, id: "tx1"}
+		slc.auditCreate(tx, value)
+		values := NewStructSliceValue()
+		values.Append(value)
+		slc.auditUpdate(tx, values, map[string]interface{}{"user_id": uint64(20)})
+		Equal(t, len(sink.events), 0)
+		tx.emitAuditEvents()
+		Equal(t, len(sink.events), 2)
+		Equal(t, sink.events[0], &AuditEvent{
+			Type:       AuditEventCreate,
+			Table:      "user_logins",
+			PrimaryKey: map[string]interface{}{"id": uint64(1)},
+			Columns:    []string{"id", "user_id"},
+			TxID:       "tx1",
+			Time:       clock.Now(),
+		})
+		Equal(t, sink.events[1].Type, AuditEventUpdate)
+		Equal(t, sink.events[1].Columns, []string{"user_id"})
+	})
+	t.Run("discard at rollback", func(t *testing.T) {
+		sink.events = nil
+		tx := &Tx{r: r, id: "tx2"}
+		slc.auditCreate(tx, value)
+		NoError(t, tx.rollbackDB())
+		tx.emitAuditEvents()
+		Equal(t, len(sink.events), 0)
+	})
+}
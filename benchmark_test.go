@@ -2,10 +2,12 @@ package rapidash
 
 import (
 	"database/sql"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/jinzhu/gorm"
+	"go.knocknote.io/rapidash/server"
 )
 
 type A struct {
@@ -632,3 +634,42 @@ func BenchmarkDeleteByPrimaryKey_Rapidash(b *testing.B) {
 		id++
 	}
 }
+
+func BenchmarkLoadValues(b *testing.B) {
+	typ := userLoginType()
+	factory := NewValueFactory()
+	primaryKey := NewPrimaryKey(&TableOption{}, "user_logins", []string{"id"}, typ)
+	key := NewKey(&TableOption{}, "user_logins", []string{"user_id"}, typ)
+	const keyNum = 100
+	queries := NewQueries("user_logins", primaryKey, keyNum)
+	primaryKeys := make([][]server.CacheKey, keyNum)
+	for i := 0; i < keyNum; i++ {
+		query := NewQuery(1)
+		query.Add(&EQCondition{column: "user_id", value: factory.CreateUint64Value(uint64(i))})
+		if err := query.SetIndex(key); err != nil {
+			b.Fatalf("%+v", err)
+		}
+		queries.Add(query)
+		primaryKeys[i] = []server.CacheKey{&CacheKey{key: fmt.Sprintf("r/slc/user_logins/id#%d", i)}}
+	}
+	value := &StructValue{typ: typ, fields: map[string]*Value{}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		idx := 0
+		if _, err := queries.LoadValues(factory, func(_ IndexType, iter *QueryIterator) error {
+			for iter.Next() {
+				iter.SetPrimaryKeys(primaryKeys[idx])
+				idx++
+			}
+			return nil
+		}, func(iter *ValueIterator) error {
+			for iter.Next() {
+				iter.SetValue(value)
+			}
+			return nil
+		}); err != nil {
+			b.Fatalf("%+v", err)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package rapidash
+
+import (
+	"context"
+
+	"github.com/blastrain/vitess-sqlparser/sqlparser"
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+// IndexSpec defines index of table that has no backing database table
+type IndexSpec struct {
+	Type    IndexType
+	Columns []string
+}
+
+func PrimaryKeySpec(columns ...string) IndexSpec {
+	return IndexSpec{Type: IndexTypePrimaryKey, Columns: columns}
+}
+
+func UniqueKeySpec(columns ...string) IndexSpec {
+	return IndexSpec{Type: IndexTypeUniqueKey, Columns: columns}
+}
+
+func KeySpec(columns ...string) IndexSpec {
+	return IndexSpec{Type: IndexTypeKey, Columns: columns}
+}
+
+func (spec IndexSpec) constraint() *sqlparser.Constraint {
+	constraint := &sqlparser.Constraint{}
+	switch spec.Type {
+	case IndexTypePrimaryKey:
+		constraint.Type = sqlparser.ConstraintPrimaryKey
+	case IndexTypeUniqueKey:
+		constraint.Type = sqlparser.ConstraintUniqKey
+	default:
+		constraint.Type = sqlparser.ConstraintKey
+	}
+	for _, column := range spec.Columns {
+		constraint.Keys = append(constraint.Keys, sqlparser.NewColIdent(column))
+	}
+	return constraint
+}
+
+// NewCacheOnlyTable registers table that has no backing database table.
+// Records are created, found, updated and deleted by Tx only against cache servers, so they are lost when cache servers evict them
+func (r *Rapidash) NewCacheOnlyTable(typ *Struct, indexes ...IndexSpec) error {
+	slc := NewSecondLevelCache(typ, r.cacheServer, r.tableOption(typ.tableName))
+	if err := slc.setupCacheOnlyIndexes(indexes); err != nil {
+		return xerrors.Errorf("cannot setup cache only table %s: %w", typ.tableName, err)
+	}
+	r.secondLevelCaches.set(typ.tableName, slc)
+	return nil
+}
+
+func (c *SecondLevelCache) setupCacheOnlyIndexes(indexes []IndexSpec) error {
+	if c.opt.DisableStash() {
+		return xerrors.Errorf("cache only table requires stash: %w", ErrInvalidCacheOnlyTable)
+	}
+	for _, index := range indexes {
+		for _, column := range index.Columns {
+			if _, exists := c.typ.fields[column]; !exists {
+				return xerrors.Errorf("%s.%s: %w", c.typ.tableName, column, ErrUnknownColumnName)
+			}
+		}
+		switch index.Type {
+		case IndexTypePrimaryKey:
+			c.setupPrimaryKey(index.constraint())
+		case IndexTypeUniqueKey:
+			c.setupUniqKey(index.constraint())
+		default:
+			c.setupKey(index.constraint())
+		}
+	}
+	if c.primaryKey == nil {
+		return xerrors.Errorf("primary key is not defined: %w", ErrInvalidCacheOnlyTable)
+	}
+	c.excludeIndexes()
+	c.setupIndexStats()
+	c.cacheOnly = true
+	return nil
+}
+
+func (c *SecondLevelCache) validateCacheOnlyQuery(builder *QueryBuilder) error {
+	if builder.isIgnoreCache || builder.lockOpt != nil || builder.IsUnsupportedCacheQuery() ||
+		c.isClusterKeyQuery(builder) || c.isExcludedIndexQuery(builder) {
+		return xerrors.Errorf("%s: %w", builder.DebugString(), ErrUnsupportedCacheOnlyQuery)
+	}
+	return nil
+}
+
+// findCacheOnlyValues finds values only by cache server. cache miss means that record doesn't exist
+func (c *SecondLevelCache) findCacheOnlyValues(ctx context.Context, tx *Tx, builder *QueryBuilder) (*StructSliceValue, error) {
+	if err := c.validateCacheOnlyQuery(builder); err != nil {
+		return nil, err
+	}
+	queries, err := builder.BuildWithIndex(c.valueFactory, c.indexes, c.typ)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to build query: %w", err)
+	}
+	if queries.Len() == 0 {
+		return nil, nil
+	}
+	foundValues, err := c.findValuesByCache(ctx, tx, builder, queries)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to find values by cache: %w", err)
+	}
+	builder.info.addCacheRows(foundValues.Len())
+	return foundValues, nil
+}
+
+// primaryKeysByKey returns primary keys registered to key of non unique index
+func (c *SecondLevelCache) primaryKeysByKey(ctx context.Context, tx *Tx, key server.CacheKey) ([]server.CacheKey, error) {
+	primaryKeys, cached, err := c.cachedPrimaryKeysByKey(ctx, tx, key)
+	if err != nil {
+		return nil, err
+	}
+	if !cached {
+		return []server.CacheKey{}, nil
+	}
+	return primaryKeys, nil
+}
+
+// cachedPrimaryKeysByKey returns primary keys registered to key of non unique index, and false if key isn't cached
+func (c *SecondLevelCache) cachedPrimaryKeysByKey(ctx context.Context, tx *Tx, key server.CacheKey) ([]server.CacheKey, bool, error) {
+	if _, exists := tx.stash.oldKey[key.String()]; exists {
+		return nil, false, nil
+	}
+	if primaryKeys, exists := tx.stash.keyToPrimaryKeys[key.String()]; exists {
+		return primaryKeys, true, nil
+	}
+	content, err := c.cacheServer.Get(ctx, key)
+	if IsCacheMiss(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, xerrors.Errorf("failed to get primary keys from server: %w", err)
+	}
+	payload, err := c.decodePayload(content.Value)
+	if err != nil {
+		return nil, false, xerrors.Errorf("failed to decode payload: %w", err)
+	}
+	primaryKeys, err := c.decodeMultiplePrimaryKeys(payload, content.Flags)
+	if err != nil {
+		return nil, false, xerrors.Errorf("failed to decode primary keys: %w", err)
+	}
+	tx.stash.casIDs[key.String()] = content.CasID
+	return primaryKeys, true, nil
+}
+
+func (c *SecondLevelCache) addIndexKeys(ctx context.Context, tx *Tx, value *StructValue, primaryKey server.CacheKey) error {
+	for _, index := range c.indexes {
+		if index.Type == IndexTypePrimaryKey {
+			continue
+		}
+		key, err := index.CacheKey(value)
+		if err != nil {
+			return xerrors.Errorf("failed to get cache key: %w", err)
+		}
+		delete(tx.stash.oldKey, key.String())
+		if index.Type == IndexTypeUniqueKey {
+			if err := c.setUniqueKey(ctx, tx, key, primaryKey); err != nil {
+				return xerrors.Errorf("failed to set unique key: %w", err)
+			}
+			continue
+		}
+		primaryKeys, err := c.primaryKeysByKey(ctx, tx, key)
+		if err != nil {
+			return xerrors.Errorf("failed to get primary keys: %w", err)
+		}
+		newPrimaryKeys := make([]server.CacheKey, 0, len(primaryKeys)+1)
+		for _, pk := range primaryKeys {
+			if pk.String() != primaryKey.String() {
+				newPrimaryKeys = append(newPrimaryKeys, pk)
+			}
+		}
+		if err := c.setKey(ctx, tx, key, append(newPrimaryKeys, primaryKey)); err != nil {
+			return xerrors.Errorf("failed to set key: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *SecondLevelCache) removeIndexKeys(ctx context.Context, tx *Tx, value *StructValue, primaryKey server.CacheKey) error {
+	for _, index := range c.indexes {
+		if index.Type == IndexTypePrimaryKey {
+			continue
+		}
+		key, err := index.CacheKey(value)
+		if err != nil {
+			return xerrors.Errorf("failed to get cache key: %w", err)
+		}
+		if index.Type == IndexTypeUniqueKey {
+			if err := c.deleteUniqueKeyOrOldKey(ctx, tx, key); err != nil {
+				return xerrors.Errorf("failed to delete unique key: %w", err)
+			}
+			continue
+		}
+		primaryKeys, err := c.primaryKeysByKey(ctx, tx, key)
+		if err != nil {
+			return xerrors.Errorf("failed to get primary keys: %w", err)
+		}
+		newPrimaryKeys := make([]server.CacheKey, 0, len(primaryKeys))
+		for _, pk := range primaryKeys {
+			if pk.String() != primaryKey.String() {
+				newPrimaryKeys = append(newPrimaryKeys, pk)
+			}
+		}
+		if err := c.setKey(ctx, tx, key, newPrimaryKeys); err != nil {
+			return xerrors.Errorf("failed to set key: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *SecondLevelCache) createCacheOnly(ctx context.Context, tx *Tx, marshaler Marshaler) (int64, error) {
+	_, value, err := c.encode(marshaler)
+	if err != nil {
+		tx.abortByCoderPanic(err)
+		return 0, xerrors.Errorf("failed to encode: %w", err)
+	}
+	id, _, err := c.generatePrimaryKey(ctx, value)
+	if err != nil {
+		return 0, xerrors.Errorf("failed to generate primary key: %w", err)
+	}
+	for _, column := range c.primaryKey.Columns {
+		if value.fields[column] == nil {
+			return 0, xerrors.Errorf("%s.%s: %w", c.typ.tableName, column, ErrCacheOnlyPrimaryKeyRequired)
+		}
+	}
+	builder := c.builderByValue(value, c.primaryKey)
+	defer builder.Release()
+	values, err := c.findCacheOnlyValues(ctx, tx, builder)
+	if err != nil {
+		return 0, xerrors.Errorf("failed to find values by primary key: %w", err)
+	}
+	if values != nil && values.Len() > 0 {
+		return 0, xerrors.Errorf("%s: %w", builder.DebugString(), ErrCacheOnlyDuplicateEntry)
+	}
+	primaryKey, err := c.primaryKey.CacheKey(value)
+	if err != nil {
+		return 0, xerrors.Errorf("failed to get cache key: %w", err)
+	}
+	if err := c.setPrimaryKey(ctx, tx, primaryKey, value); err != nil {
+		return 0, xerrors.Errorf("failed to set primary key: %w", err)
+	}
+	if err := c.addIndexKeys(ctx, tx, value, primaryKey); err != nil {
+		return 0, xerrors.Errorf("failed to add index keys: %w", err)
+	}
+	c.auditCreate(tx, value)
+	return id, nil
+}
+
+func (c *SecondLevelCache) updateCacheOnly(ctx context.Context, tx *Tx, builder *QueryBuilder, updateMap map[string]interface{}) (int64, error) {
+	values, err := c.findValuesByQueryBuilder(ctx, tx, builder)
+	if err != nil {
+		return 0, xerrors.Errorf("failed to find values by query builder: %w", err)
+	}
+	if values == nil {
+		return 0, nil
+	}
+	c.auditUpdate(tx, values, updateMap)
+	for _, value := range values.values {
+		oldPrimaryKey, err := c.primaryKey.CacheKey(value)
+		if err != nil {
+			return 0, xerrors.Errorf("failed to get cache key: %w", err)
+		}
+		if err := c.removeIndexKeys(ctx, tx, value, oldPrimaryKey); err != nil {
+			return 0, xerrors.Errorf("failed to remove index keys by old value: %w", err)
+		}
+		if err := c.updateValue(ctx, tx, value, updateMap); err != nil {
+			return 0, xerrors.Errorf("failed to update value: %w", err)
+		}
+		primaryKey, err := c.primaryKey.CacheKey(value)
+		if err != nil {
+			return 0, xerrors.Errorf("failed to get cache key: %w", err)
+		}
+		if primaryKey.String() != oldPrimaryKey.String() {
+			if err := c.deletePrimaryKey(ctx, tx, oldPrimaryKey); err != nil {
+				return 0, xerrors.Errorf("failed to delete old primary key: %w", err)
+			}
+		}
+		if err := c.updatePrimaryKey(ctx, tx, primaryKey, value); err != nil {
+			return 0, xerrors.Errorf("failed to update primary key: %w", err)
+		}
+		if err := c.addIndexKeys(ctx, tx, value, primaryKey); err != nil {
+			return 0, xerrors.Errorf("failed to add index keys by new value: %w", err)
+		}
+	}
+	return int64(values.Len()), nil
+}
+
+func (c *SecondLevelCache) deleteCacheOnly(ctx context.Context, tx *Tx, builder *QueryBuilder) (int64, error) {
+	values, err := c.findValuesByQueryBuilder(ctx, tx, builder)
+	if err != nil {
+		return 0, xerrors.Errorf("failed to find values by query builder: %w", err)
+	}
+	if values == nil {
+		return 0, nil
+	}
+	for _, value := range values.values {
+		primaryKey, err := c.primaryKey.CacheKey(value)
+		if err != nil {
+			return 0, xerrors.Errorf("failed to get cache key: %w", err)
+		}
+		if err := c.removeIndexKeys(ctx, tx, value, primaryKey); err != nil {
+			return 0, xerrors.Errorf("failed to remove index keys: %w", err)
+		}
+		if err := c.deletePrimaryKey(ctx, tx, primaryKey); err != nil {
+			return 0, xerrors.Errorf("failed to delete primary key: %w", err)
+		}
+		c.audit(tx, AuditEventDelete, value, nil)
+	}
+	return int64(values.Len()), nil
+}
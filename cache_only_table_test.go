@@ -0,0 +1,137 @@
+package rapidash
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+type memoryCacheServer struct {
+	server.CacheServer
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func newMemoryCacheServer() *memoryCacheServer {
+	return &memoryCacheServer{values: map[string][]byte{}}
+}
+
+func (s *memoryCacheServer) Get(ctx context.Context, key server.CacheKey) (*server.CacheGetResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, exists := s.values[key.String()]
+	if !exists {
+		return nil, server.ErrCacheMiss
+	}
+	return &server.CacheGetResponse{Value: value, Flags: key.Hash()}, nil
+}
+
+func (s *memoryCacheServer) GetMulti(ctx context.Context, keys []server.CacheKey) (*server.Iterator, error) {
+	iter := server.NewIterator(keys)
+	for idx, key := range keys {
+		content, err := s.Get(ctx, key)
+		if err != nil {
+			iter.SetError(idx, err)
+			continue
+		}
+		iter.SetContent(idx, content)
+	}
+	return iter, nil
+}
+
+func (s *memoryCacheServer) Set(ctx context.Context, req *server.CacheStoreRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[req.Key.String()] = req.Value
+	return nil
+}
+
+func (s *memoryCacheServer) Add(ctx context.Context, key server.CacheKey, value []byte, expiration time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.values[key.String()]; exists {
+		return xerrors.Errorf("%s is already stored: %w", key, server.ErrMemcacheNotStored)
+	}
+	s.values[key.String()] = value
+	return nil
+}
+
+func (s *memoryCacheServer) Delete(ctx context.Context, key server.CacheKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, key.String())
+	return nil
+}
+
+func TestCacheOnlyTable(t *testing.T) {
+	typ := NewStruct("ephemeral_logins").
+		FieldUint64("id").
+		FieldUint64("user_id").
+		FieldUint64("user_session_id").
+		FieldUint64("login_param_id").
+		FieldString("name").
+		FieldTime("created_at").
+		FieldTime("updated_at")
+	r, err := New()
+	NoError(t, err)
+	r.cacheServer = newMemoryCacheServer()
+	Equal(t, xerrors.Is(r.NewCacheOnlyTable(typ, KeySpec("user_id")), ErrInvalidCacheOnlyTable), true)
+	NoError(t, r.NewCacheOnlyTable(typ, PrimaryKeySpec("id"), UniqueKeySpec("user_session_id"), KeySpec("user_id")))
+
+	ctx := context.Background()
+	tx, err := r.Begin()
+	NoError(t, err)
+	for i := uint64(1); i <= 3; i++ {
+		_, err := tx.CreateByTable("ephemeral_logins", &UserLogin{ID: i, UserID: 10 + i%2, UserSessionID: 100 + i, Name: "rapidash"})
+		NoError(t, err)
+	}
+	_, err = tx.CreateByTable("ephemeral_logins", &UserLogin{UserID: 10})
+	Equal(t, xerrors.Is(err, ErrCacheOnlyPrimaryKeyRequired), true)
+	_, err = tx.CreateByTable("ephemeral_logins", &UserLogin{ID: 1, UserID: 10})
+	Equal(t, xerrors.Is(err, ErrCacheOnlyDuplicateEntry), true)
+	NoError(t, tx.Commit())
+
+	tx, err = r.Begin()
+	NoError(t, err)
+	var logins UserLogins
+	NoError(t, tx.FindByQueryBuilder(NewQueryBuilder("ephemeral_logins").Eq("user_id", uint64(11)), &logins))
+	Equal(t, len(logins), 2)
+	var login UserLogin
+	NoError(t, tx.FindByQueryBuilder(NewQueryBuilder("ephemeral_logins").Eq("user_session_id", uint64(102)), &login))
+	Equal(t, login.ID, uint64(2))
+
+	affected, err := tx.UpdateRowsByQueryBuilderContext(ctx, NewQueryBuilder("ephemeral_logins").Eq("id", uint64(1)), map[string]interface{}{
+		"user_id":         uint64(10),
+		"user_session_id": uint64(201),
+	})
+	NoError(t, err)
+	Equal(t, affected, int64(1))
+	affected, err = tx.DeleteRowsByQueryBuilderContext(ctx, NewQueryBuilder("ephemeral_logins").Eq("id", uint64(3)))
+	NoError(t, err)
+	Equal(t, affected, int64(1))
+	NoError(t, tx.Commit())
+
+	tx, err = r.Begin()
+	NoError(t, err)
+	count, err := tx.CountByQueryBuilder(NewQueryBuilder("ephemeral_logins").Eq("user_id", uint64(11)))
+	NoError(t, err)
+	Equal(t, count, uint64(0))
+	logins = UserLogins{}
+	NoError(t, tx.FindByQueryBuilder(NewQueryBuilder("ephemeral_logins").Eq("user_id", uint64(10)), &logins))
+	Equal(t, len(logins), 2)
+	count, err = tx.CountByQueryBuilder(NewQueryBuilder("ephemeral_logins").Eq("user_session_id", uint64(101)))
+	NoError(t, err)
+	Equal(t, count, uint64(0))
+	login = UserLogin{}
+	NoError(t, tx.FindByQueryBuilder(NewQueryBuilder("ephemeral_logins").Eq("user_session_id", uint64(201)), &login))
+	Equal(t, login.ID, uint64(1))
+	err = tx.FindByQueryBuilder(NewQueryBuilder("ephemeral_logins").Eq("name", "rapidash"), &logins)
+	Equal(t, xerrors.Is(err, ErrNoMatchingIndex), true)
+	err = tx.FindByQueryBuilder(NewQueryBuilder("ephemeral_logins").Eq("id", uint64(1)).ForUpdate(), &login)
+	Equal(t, xerrors.Is(err, ErrUnsupportedCacheOnlyQuery), true)
+	NoError(t, tx.Commit())
+}
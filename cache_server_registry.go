@@ -0,0 +1,65 @@
+package rapidash
+
+import (
+	"sort"
+	"sync"
+
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+// CacheServerOption is passed to factory of cache server registered by RegisterCacheServer.
+// Timeout and max idle connections are set by CacheServer interface after it is created
+type CacheServerOption struct {
+	Addrs    []string
+	SLCAddrs []string
+	LLCAddrs []string
+}
+
+// CacheServerFactory creates cache server for custom backend ( e.g. DynamoDB, in-house cache )
+type CacheServerFactory func(*CacheServerOption) (server.CacheServer, error)
+
+var (
+	cacheServerFactoriesMu sync.RWMutex
+	cacheServerFactories   = map[string]CacheServerFactory{}
+)
+
+// RegisterCacheServer makes cache server available by name for CacheServerName option.
+// If RegisterCacheServer is called twice with the same name or if factory is nil, it panics
+func RegisterCacheServer(name string, factory CacheServerFactory) {
+	cacheServerFactoriesMu.Lock()
+	defer cacheServerFactoriesMu.Unlock()
+	if factory == nil {
+		panic("rapidash: RegisterCacheServer factory is nil")
+	}
+	if _, exists := cacheServerFactories[name]; exists {
+		panic("rapidash: RegisterCacheServer called twice for " + name)
+	}
+	cacheServerFactories[name] = factory
+}
+
+// CacheServers returns sorted names of registered cache servers
+func CacheServers() []string {
+	cacheServerFactoriesMu.RLock()
+	defer cacheServerFactoriesMu.RUnlock()
+	names := make([]string, 0, len(cacheServerFactories))
+	for name := range cacheServerFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newRegisteredCacheServer(name string, opt *CacheServerOption) (server.CacheServer, error) {
+	cacheServerFactoriesMu.RLock()
+	factory, exists := cacheServerFactories[name]
+	cacheServerFactoriesMu.RUnlock()
+	if !exists {
+		return nil, xerrors.Errorf("%s: %w", name, ErrUnknownCacheServer)
+	}
+	cacheServer, err := factory(opt)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create cache server %s: %w", name, err)
+	}
+	return cacheServer, nil
+}
@@ -0,0 +1,65 @@
+package rapidash
+
+import (
+	"testing"
+	"time"
+
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+type customCacheServer struct {
+	*memoryCacheServer
+	opt     *CacheServerOption
+	timeout time.Duration
+}
+
+func (s *customCacheServer) SetTimeout(timeout time.Duration) error {
+	s.timeout = timeout
+	return nil
+}
+
+func (s *customCacheServer) SetMaxIdleConnections(int) error {
+	return nil
+}
+
+func TestRegisterCacheServer(t *testing.T) {
+	RegisterCacheServer("custom_test", func(opt *CacheServerOption) (server.CacheServer, error) {
+		return &customCacheServer{memoryCacheServer: newMemoryCacheServer(), opt: opt}, nil
+	})
+	t.Run("create by name", func(t *testing.T) {
+		r, err := New(CacheServerName("custom_test"), ServerAddrs([]string{"localhost:12345"}), Timeout(time.Second))
+		NoError(t, err)
+		cacheServer, ok := r.cacheServer.(*customCacheServer)
+		Equal(t, ok, true)
+		Equal(t, cacheServer.opt.Addrs, []string{"localhost:12345"})
+		Equal(t, cacheServer.timeout, time.Second)
+	})
+	t.Run("config", func(t *testing.T) {
+		name := "custom_test"
+		cfg := &RuleConfig{
+			Logger:       &LoggerConfig{},
+			Retry:        &RetryConfig{},
+			CacheControl: &CacheControlConfig{},
+			CacheServer:  &name,
+		}
+		r, err := New(cfg.Options()...)
+		NoError(t, err)
+		_, ok := r.cacheServer.(*customCacheServer)
+		Equal(t, ok, true)
+	})
+	t.Run("unknown", func(t *testing.T) {
+		_, err := New(CacheServerName("unknown"))
+		Equal(t, xerrors.Is(err, ErrUnknownCacheServer), true)
+	})
+	t.Run("register twice", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("RegisterCacheServer must panic")
+			}
+		}()
+		RegisterCacheServer("custom_test", func(*CacheServerOption) (server.CacheServer, error) {
+			return nil, nil
+		})
+	})
+}
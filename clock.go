@@ -0,0 +1,30 @@
+package rapidash
+
+import (
+	"time"
+)
+
+// Clock is source of current time used for lock values, expirations and rate limit windows.
+// Replace it to advance time deterministically in tests
+type Clock interface {
+	Now() time.Time
+}
+
+// TTLSource decides expiration of SecondLevelCache values per table ( e.g. by config service ).
+// If ok is false, expiration configured by option is used
+type TTLSource interface {
+	TTL(table string) (ttl time.Duration, ok bool)
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func clockOrDefault(clock Clock) Clock {
+	if clock == nil {
+		return systemClock{}
+	}
+	return clock
+}
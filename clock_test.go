@@ -0,0 +1,61 @@
+package rapidash
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+type testTTLSource map[string]time.Duration
+
+func (s testTTLSource) TTL(table string) (time.Duration, bool) {
+	ttl, exists := s[table]
+	return ttl, exists
+}
+
+func TestClock(t *testing.T) {
+	clock := &testClock{now: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)}
+	t.Run("negative cache", func(t *testing.T) {
+		cache := newNegativeCache(clock, 0)
+		cache.add("r/llc/key", 10*time.Second)
+		Equal(t, cache.exists("r/llc/key"), true)
+		clock.advance(10 * time.Second)
+		Equal(t, cache.exists("r/llc/key"), false)
+	})
+	t.Run("expiration column", func(t *testing.T) {
+		r, err := New(ClockSource(clock), SecondLevelCacheTTLSource(testTTLSource{"events": time.Hour}))
+		NoError(t, err)
+		opt := r.tableOption("events")
+		expirationColumn := "end_at"
+		opt.expirationColumn = &expirationColumn
+		slc := NewSecondLevelCache(NewStruct("events").FieldUint64("id").FieldTime("end_at"), nil, opt)
+		value := &StructValue{fields: map[string]*Value{"end_at": NewTimeValue(clock.Now().Add(2 * time.Hour))}}
+		expiration, expired := slc.expirationByValue(value)
+		Equal(t, expiration, time.Hour)
+		Equal(t, expired, false)
+		clock.advance(90 * time.Minute)
+		expiration, expired = slc.expirationByValue(value)
+		Equal(t, expiration, 30*time.Minute)
+		Equal(t, expired, false)
+		clock.advance(time.Hour)
+		_, expired = slc.expirationByValue(value)
+		Equal(t, expired, true)
+	})
+}
@@ -0,0 +1,516 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/blastrain/vitess-sqlparser/sqlparser"
+	"go.knocknote.io/rapidash"
+	"golang.org/x/xerrors"
+)
+
+// genAnnotation marks struct ( or slice of struct ) to generate coders.
+// table name can be specified like `// rapidash:gen table=user_logins`
+const genAnnotation = "rapidash:gen"
+
+type GenCommand struct {
+	Suffix  string `long:"suffix" default:"_rapidash.go" description:"suffix of generated file name ( e.g. user_login.go generates user_login_rapidash.go )"`
+	DDL     bool   `long:"ddl" description:"read CREATE TABLE statements from SQL files and generate Go structs with coders"`
+	Package string `long:"package" default:"model" description:"package name of generated file for --ddl"`
+}
+
+type genField struct {
+	Name       string
+	Type       string
+	Column     string
+	Method     string
+	Definition string
+	OmitEmpty  bool
+	ZeroCheck  string
+}
+
+type genStruct struct {
+	Name      string
+	TableName string
+	Fields    []*genField
+	Declare   bool
+}
+
+type genSlice struct {
+	Name      string
+	Elem      string
+	IsPtrElem bool
+}
+
+type genFile struct {
+	Package    string
+	ImportTime bool
+	Structs    []*genStruct
+	Slices     []*genSlice
+}
+
+var genScalarTypeIDs = map[string]string{
+	"Int":     "IntType",
+	"Int8":    "Int8Type",
+	"Int16":   "Int16Type",
+	"Int32":   "Int32Type",
+	"Int64":   "Int64Type",
+	"Uint":    "UintType",
+	"Uint8":   "Uint8Type",
+	"Uint16":  "Uint16Type",
+	"Uint32":  "Uint32Type",
+	"Uint64":  "Uint64Type",
+	"Float32": "Float32Type",
+	"Float64": "Float64Type",
+	"Bool":    "BoolType",
+	"String":  "StringType",
+	"Time":    "TimeType",
+}
+
+var genScalarMethods = map[string]string{
+	"int":       "Int",
+	"int8":      "Int8",
+	"int16":     "Int16",
+	"int32":     "Int32",
+	"int64":     "Int64",
+	"uint":      "Uint",
+	"uint8":     "Uint8",
+	"uint16":    "Uint16",
+	"uint32":    "Uint32",
+	"uint64":    "Uint64",
+	"float32":   "Float32",
+	"float64":   "Float64",
+	"bool":      "Bool",
+	"string":    "String",
+	"[]byte":    "Bytes",
+	"time.Time": "Time",
+}
+
+var genTemplate = template.Must(template.New("gen").Parse(`// Code generated by rapidash gen. DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+{{- if .ImportTime }}
+	"time"
+{{ end }}
+	"go.knocknote.io/rapidash"
+	"golang.org/x/xerrors"
+)
+{{ range .Structs }}
+{{- if .Declare }}
+type {{ .Name }} struct {
+{{- range .Fields }}
+	{{ .Name }} {{ .Type }} ` + "`" + `db:"{{ .Column }}"` + "`" + `
+{{- end }}
+}
+{{ end }}
+// Struct returns definition of {{ .TableName }} for {{ .Name }}
+func (*{{ .Name }}) Struct() *rapidash.Struct {
+	return rapidash.NewStruct("{{ .TableName }}"){{ range .Fields }}.
+		{{ .Definition }}{{ end }}
+}
+
+func (v *{{ .Name }}) EncodeRapidash(enc rapidash.Encoder) error {
+{{- range .Fields }}
+{{- if .OmitEmpty }}
+	if {{ .ZeroCheck }} {
+		enc.{{ .Method }}("{{ .Column }}", v.{{ .Name }})
+	}
+{{- else }}
+	enc.{{ .Method }}("{{ .Column }}", v.{{ .Name }})
+{{- end }}
+{{- end }}
+	if err := enc.Error(); err != nil {
+		return xerrors.Errorf("failed to encode: %w", err)
+	}
+	return nil
+}
+
+func (v *{{ .Name }}) DecodeRapidash(dec rapidash.Decoder) error {
+{{- range .Fields }}
+	v.{{ .Name }} = dec.{{ .Method }}("{{ .Column }}")
+{{- end }}
+	if err := dec.Error(); err != nil {
+		return xerrors.Errorf("failed to decode: %w", err)
+	}
+	return nil
+}
+{{ end }}
+{{- range .Slices }}
+func (v *{{ .Name }}) EncodeRapidash(enc rapidash.Encoder) error {
+	for _, value := range *v {
+		if err := value.EncodeRapidash(enc.New()); err != nil {
+			return xerrors.Errorf("failed to encode: %w", err)
+		}
+	}
+	return nil
+}
+
+func (v *{{ .Name }}) DecodeRapidash(dec rapidash.Decoder) error {
+	*v = make({{ .Name }}, dec.Len())
+	for i := 0; i < dec.Len(); i++ {
+		var value {{ .Elem }}
+		if err := value.DecodeRapidash(dec.At(i)); err != nil {
+			return xerrors.Errorf("failed to decode: %w", err)
+		}
+		(*v)[i] = {{ if .IsPtrElem }}&{{ end }}value
+	}
+	return nil
+}
+{{ end }}`))
+
+// Execute generates EncodeRapidash, DecodeRapidash and Struct definition for types annotated by `// rapidash:gen`
+func (gc *GenCommand) Execute(args []string) error {
+	if len(args) == 0 {
+		return xerrors.New("'rapidash gen' command requires Go source files")
+	}
+	generate := gc.generate
+	if gc.DDL {
+		generate = gc.generateFromDDL
+	}
+	for _, path := range args {
+		content, err := generate(path)
+		if err != nil {
+			return xerrors.Errorf("failed to generate from %s: %w", path, err)
+		}
+		if content == nil {
+			continue
+		}
+		output := strings.TrimSuffix(path, filepath.Ext(path)) + gc.Suffix
+		if err := ioutil.WriteFile(output, content, 0644); err != nil {
+			return xerrors.Errorf("failed to write %s: %w", output, err)
+		}
+		fmt.Println(output)
+	}
+	return nil
+}
+
+func (gc *GenCommand) generate(path string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to parse: %w", err)
+	}
+	gen := &genFile{Package: file.Name.Name}
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			doc := typeSpec.Doc
+			if doc == nil && len(genDecl.Specs) == 1 {
+				doc = genDecl.Doc
+			}
+			args, annotated := genAnnotationArgs(doc)
+			if !annotated {
+				continue
+			}
+			switch typ := typeSpec.Type.(type) {
+			case *ast.StructType:
+				s, err := genStructOf(fset, typeSpec.Name.Name, typ, args)
+				if err != nil {
+					return nil, xerrors.Errorf("failed to generate %s: %w", typeSpec.Name.Name, err)
+				}
+				gen.Structs = append(gen.Structs, s)
+			case *ast.ArrayType:
+				s, err := genSliceOf(fset, typeSpec.Name.Name, typ)
+				if err != nil {
+					return nil, xerrors.Errorf("failed to generate %s: %w", typeSpec.Name.Name, err)
+				}
+				gen.Slices = append(gen.Slices, s)
+			default:
+				return nil, xerrors.Errorf("%s: %s must be struct or slice of struct", fset.Position(typeSpec.Pos()), typeSpec.Name.Name)
+			}
+		}
+	}
+	return gen.source()
+}
+
+// generateFromDDL generates Go structs with coders for CREATE TABLE statements in SQL file
+func (gc *GenCommand) generateFromDDL(path string) ([]byte, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read: %w", err)
+	}
+	gen := &genFile{Package: gc.Package}
+	for _, ddl := range strings.Split(string(content), ";") {
+		if strings.TrimSpace(ddl) == "" {
+			continue
+		}
+		stmt, err := sqlparser.Parse(ddl)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot parse ddl %s: %w", ddl, err)
+		}
+		createTable, ok := stmt.(*sqlparser.CreateTable)
+		if !ok {
+			continue
+		}
+		s, err := genStructOfTable(createTable)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to generate %s: %w", createTable.NewName.Name, err)
+		}
+		for _, field := range s.Fields {
+			if strings.TrimPrefix(field.Type, "*") == "time.Time" {
+				gen.ImportTime = true
+			}
+		}
+		gen.Structs = append(gen.Structs, s)
+	}
+	return gen.source()
+}
+
+func (gen *genFile) source() ([]byte, error) {
+	if len(gen.Structs) == 0 && len(gen.Slices) == 0 {
+		return nil, nil
+	}
+	var buf bytes.Buffer
+	if err := genTemplate.Execute(&buf, gen); err != nil {
+		return nil, xerrors.Errorf("failed to execute template: %w", err)
+	}
+	content, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, xerrors.Errorf("failed to format generated source: %w", err)
+	}
+	return content, nil
+}
+
+func genAnnotationArgs(doc *ast.CommentGroup) (map[string]string, bool) {
+	if doc == nil {
+		return nil, false
+	}
+	for _, comment := range doc.List {
+		text := strings.TrimSpace(strings.TrimPrefix(comment.Text, "//"))
+		if !strings.HasPrefix(text, genAnnotation) {
+			continue
+		}
+		args := map[string]string{}
+		for _, arg := range strings.Fields(strings.TrimPrefix(text, genAnnotation)) {
+			kv := strings.SplitN(arg, "=", 2)
+			if len(kv) == 2 {
+				args[kv[0]] = kv[1]
+			}
+		}
+		return args, true
+	}
+	return nil, false
+}
+
+func genStructOf(fset *token.FileSet, name string, typ *ast.StructType, args map[string]string) (*genStruct, error) {
+	tableName := args["table"]
+	if tableName == "" {
+		tableName = rapidash.ToSnakeCase(name) + "s"
+	}
+	s := &genStruct{Name: name, TableName: tableName}
+	for _, field := range typ.Fields.List {
+		if field.Tag == nil || len(field.Names) == 0 {
+			continue
+		}
+		tag := reflect.StructTag(strings.Trim(field.Tag.Value, "`"))
+		column := tag.Get("db")
+		if column == "" || column == "-" {
+			continue
+		}
+		typeName := genTypeName(field.Type)
+		for _, fieldName := range field.Names {
+			if !fieldName.IsExported() {
+				continue
+			}
+			f, err := newGenField(fieldName.Name, typeName, column, tag.Get("rapidash") == "omitempty")
+			if err != nil {
+				return nil, xerrors.Errorf("%s: %w", fset.Position(field.Pos()), err)
+			}
+			s.Fields = append(s.Fields, f)
+		}
+	}
+	return s, nil
+}
+
+func newGenField(name, typeName, column string, omitEmpty bool) (*genField, error) {
+	method, definition, zeroCheck, err := genMethod(typeName)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get method of %s: %w", name, err)
+	}
+	return &genField{
+		Name:       name,
+		Type:       typeName,
+		Column:     column,
+		Method:     method,
+		Definition: fmt.Sprintf(definition, column),
+		OmitEmpty:  omitEmpty,
+		ZeroCheck:  fmt.Sprintf(zeroCheck, "v."+name),
+	}, nil
+}
+
+// genStructOfTable declares struct that has field for each column of table.
+// struct name is singular camel case of table name ( e.g. user_logins is UserLogin ), and nullable column is pointer field
+func genStructOfTable(createTable *sqlparser.CreateTable) (*genStruct, error) {
+	tableName := createTable.NewName.Name.String()
+	s := &genStruct{
+		Name:      genCamelCase(strings.TrimSuffix(tableName, "s")),
+		TableName: tableName,
+		Declare:   true,
+	}
+	for _, column := range createTable.Columns {
+		typeName, err := genColumnTypeName(column)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to get type of %s: %w", column.Name, err)
+		}
+		f, err := newGenField(genCamelCase(column.Name), typeName, column.Name, false)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to generate field of %s: %w", column.Name, err)
+		}
+		s.Fields = append(s.Fields, f)
+	}
+	return s, nil
+}
+
+var genColumnTypeNames = map[string]string{
+	"tinyint":    "int8",
+	"smallint":   "int16",
+	"mediumint":  "int32",
+	"int":        "int32",
+	"integer":    "int32",
+	"bigint":     "int64",
+	"float":      "float32",
+	"double":     "float64",
+	"decimal":    "string",
+	"char":       "string",
+	"varchar":    "string",
+	"tinytext":   "string",
+	"text":       "string",
+	"mediumtext": "string",
+	"longtext":   "string",
+	"enum":       "string",
+	"set":        "string",
+	"json":       "string",
+	"binary":     "[]byte",
+	"varbinary":  "[]byte",
+	"tinyblob":   "[]byte",
+	"blob":       "[]byte",
+	"mediumblob": "[]byte",
+	"longblob":   "[]byte",
+	"bit":        "[]byte",
+	"date":       "time.Time",
+	"datetime":   "time.Time",
+	"timestamp":  "time.Time",
+}
+
+// genColumnTypeName returns Go type that can hold every value of column.
+// tinyint(1) is bool, unsigned integer is uint type, and column without NOT NULL is pointer type
+func genColumnTypeName(column *sqlparser.ColumnDef) (string, error) {
+	columnType := strings.ToLower(column.Type)
+	dataType := columnType
+	if idx := strings.IndexAny(dataType, "( "); idx >= 0 {
+		dataType = dataType[:idx]
+	}
+	typeName, exists := genColumnTypeNames[dataType]
+	if !exists {
+		return "", xerrors.Errorf("unsupported column type %s", column.Type)
+	}
+	if strings.HasPrefix(columnType, "tinyint(1)") {
+		typeName = "bool"
+	} else if strings.HasPrefix(typeName, "int") && strings.Contains(columnType, "unsigned") {
+		typeName = "u" + typeName
+	}
+	for _, option := range column.Options {
+		switch option.Type {
+		case sqlparser.ColumnOptionNotNull, sqlparser.ColumnOptionPrimaryKey:
+			return typeName, nil
+		}
+	}
+	return "*" + typeName, nil
+}
+
+// genInitialisms are written in upper case in Go names ( e.g. user_id is UserID )
+var genInitialisms = map[string]struct{}{
+	"api": {}, "http": {}, "id": {}, "ip": {}, "json": {}, "sql": {}, "url": {}, "uuid": {},
+}
+
+func genCamelCase(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part == "" {
+			continue
+		}
+		if _, exists := genInitialisms[strings.ToLower(part)]; exists {
+			b.WriteString(strings.ToUpper(part))
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+func genSliceOf(fset *token.FileSet, name string, typ *ast.ArrayType) (*genSlice, error) {
+	if typ.Len != nil {
+		return nil, xerrors.Errorf("%s: array is not supported", fset.Position(typ.Pos()))
+	}
+	elem := typ.Elt
+	isPtrElem := false
+	if star, ok := elem.(*ast.StarExpr); ok {
+		elem = star.X
+		isPtrElem = true
+	}
+	ident, ok := elem.(*ast.Ident)
+	if !ok {
+		return nil, xerrors.Errorf("%s: element must be struct defined in the same package", fset.Position(typ.Pos()))
+	}
+	return &genSlice{Name: name, Elem: ident.Name, IsPtrElem: isPtrElem}, nil
+}
+
+func genTypeName(expr ast.Expr) string {
+	switch typ := expr.(type) {
+	case *ast.Ident:
+		return typ.Name
+	case *ast.StarExpr:
+		return "*" + genTypeName(typ.X)
+	case *ast.ArrayType:
+		if typ.Len == nil {
+			return "[]" + genTypeName(typ.Elt)
+		}
+	case *ast.SelectorExpr:
+		return genTypeName(typ.X) + "." + typ.Sel.Name
+	}
+	return fmt.Sprintf("%T", expr)
+}
+
+// genMethod returns method name of Encoder/Decoder, format of field definition of Struct and format of zero check for type
+func genMethod(typeName string) (string, string, string, error) {
+	if method, exists := genScalarMethods[typeName]; exists {
+		definition := "Field" + method + "(%q)"
+		switch method {
+		case "Bool":
+			return method, definition, "%s", nil
+		case "String":
+			return method, definition, `%s != ""`, nil
+		case "Bytes":
+			return method, definition, "len(%s) > 0", nil
+		case "Time":
+			return method, definition, "!%s.IsZero()", nil
+		}
+		return method, definition, "%s != 0", nil
+	}
+	if strings.HasPrefix(typeName, "*") {
+		if method, exists := genScalarMethods[strings.TrimPrefix(typeName, "*")]; exists {
+			return method + "Ptr", "Field" + method + "(%q)", "%s != nil", nil
+		}
+	}
+	if strings.HasPrefix(typeName, "[]") {
+		if method, exists := genScalarMethods[strings.TrimPrefix(typeName, "[]")]; exists && method != "Bytes" {
+			return method + "s", "FieldSlice(%q, rapidash." + genScalarTypeIDs[method] + ")", "len(%s) > 0", nil
+		}
+	}
+	return "", "", "", xerrors.Errorf("unsupported type %s", typeName)
+}
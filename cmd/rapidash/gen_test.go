@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata/gen")
+
+func assertGolden(t *testing.T, name string, actual []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", "gen", name+".golden")
+	if *updateGolden {
+		if err := ioutil.WriteFile(path, actual, 0644); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if string(actual) != string(expected) {
+		t.Fatalf("generated source differs from %s:\n%s", path, actual)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	t.Run("go source", func(t *testing.T) {
+		gc := &GenCommand{}
+		content, err := gc.generate(filepath.Join("testdata", "gen", "user_login.go"))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		assertGolden(t, "user_login", content)
+	})
+	t.Run("ddl", func(t *testing.T) {
+		gc := &GenCommand{DDL: true, Package: "model"}
+		content, err := gc.generateFromDDL(filepath.Join("testdata", "gen", "user_logins.sql"))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		assertGolden(t, "user_logins", content)
+	})
+	t.Run("not annotated", func(t *testing.T) {
+		gc := &GenCommand{}
+		content, err := gc.generate("gen.go")
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if content != nil {
+			t.Fatal("expected no generated source")
+		}
+	})
+	t.Run("unsupported type", func(t *testing.T) {
+		if _, err := newGenField("Values", "map[string]int", "values", false); err == nil {
+			t.Fatal("expected error for unsupported type")
+		}
+	})
+}
+
+func TestGenCamelCase(t *testing.T) {
+	for name, expected := range map[string]string{
+		"user_logins":    "UserLogins",
+		"user_id":        "UserID",
+		"login_url":      "LoginURL",
+		"_created_at":    "CreatedAt",
+		"http_sessions":  "HTTPSessions",
+		"login_param_id": "LoginParamID",
+	} {
+		if actual := genCamelCase(name); actual != expected {
+			t.Fatalf("expected %s but got %s for %s", expected, actual, name)
+		}
+	}
+}
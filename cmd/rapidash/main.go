@@ -2,21 +2,29 @@ package main
 
 import (
 	"bufio"
+	"database/sql"
 	"encoding/json"
+	"fmt"
 	"html/template"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"sort"
 
+	_ "github.com/go-sql-driver/mysql"
 	"github.com/jessevdk/go-flags"
 	"github.com/rakyll/statik/fs"
+	"go.knocknote.io/rapidash"
 	_ "go.knocknote.io/rapidash/static/statik"
 	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v2"
 )
 
 type Option struct {
-	Log LogCommand `description:"generate HTML file for log sequence graph" command:"log"`
+	Log      LogCommand      `description:"generate HTML file for log sequence graph" command:"log"`
+	Validate ValidateCommand `description:"validate Struct definitions against the live schema" command:"validate"`
+	Gen      GenCommand      `description:"generate EncodeRapidash, DecodeRapidash and Struct definition for types annotated by '// rapidash:gen'" command:"gen"`
 }
 
 var opts Option
@@ -112,6 +120,88 @@ func (lc *LogCommand) readFile(fs http.FileSystem, fileName string) ([]byte, err
 	return data, nil
 }
 
+type ValidateCommand struct {
+	DSN    string `long:"dsn" required:"true" description:"data source name of MySQL ( e.g. root:@tcp(localhost:3306)/rapidash )"`
+	Schema string `long:"schema" short:"s" required:"true" description:"YAML file that maps table name to columns and types ( e.g. user_logins: {id: uint64, name: string} )"`
+}
+
+var structFields = map[string]func(*rapidash.Struct, string) *rapidash.Struct{
+	"int":       (*rapidash.Struct).FieldInt,
+	"int8":      (*rapidash.Struct).FieldInt8,
+	"int16":     (*rapidash.Struct).FieldInt16,
+	"int32":     (*rapidash.Struct).FieldInt32,
+	"int64":     (*rapidash.Struct).FieldInt64,
+	"uint":      (*rapidash.Struct).FieldUint,
+	"uint8":     (*rapidash.Struct).FieldUint8,
+	"uint16":    (*rapidash.Struct).FieldUint16,
+	"uint32":    (*rapidash.Struct).FieldUint32,
+	"uint64":    (*rapidash.Struct).FieldUint64,
+	"float32":   (*rapidash.Struct).FieldFloat32,
+	"float64":   (*rapidash.Struct).FieldFloat64,
+	"bool":      (*rapidash.Struct).FieldBool,
+	"string":    (*rapidash.Struct).FieldString,
+	"[]byte":    (*rapidash.Struct).FieldBytes,
+	"bytes":     (*rapidash.Struct).FieldBytes,
+	"time.Time": (*rapidash.Struct).FieldTime,
+	"time":      (*rapidash.Struct).FieldTime,
+}
+
+func (vc *ValidateCommand) structs() ([]*rapidash.Struct, error) {
+	file, err := ioutil.ReadFile(vc.Schema)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read %s: %w", vc.Schema, err)
+	}
+	var schema map[string]map[string]string
+	if err := yaml.Unmarshal(file, &schema); err != nil {
+		return nil, xerrors.Errorf("failed to unmarshal %s: %w", vc.Schema, err)
+	}
+	tableNames := make([]string, 0, len(schema))
+	for tableName := range schema {
+		tableNames = append(tableNames, tableName)
+	}
+	sort.Strings(tableNames)
+	structs := make([]*rapidash.Struct, 0, len(tableNames))
+	for _, tableName := range tableNames {
+		s := rapidash.NewStruct(tableName)
+		for column, typ := range schema[tableName] {
+			field, exists := structFields[typ]
+			if !exists {
+				return nil, xerrors.Errorf("unknown type %s for %s.%s", typ, tableName, column)
+			}
+			field(s, column)
+		}
+		structs = append(structs, s)
+	}
+	return structs, nil
+}
+
+func (vc *ValidateCommand) Execute(args []string) error {
+	structs, err := vc.structs()
+	if err != nil {
+		return xerrors.Errorf("failed to load schema: %w", err)
+	}
+	conn, err := sql.Open("mysql", vc.DSN)
+	if err != nil {
+		return xerrors.Errorf("failed to open %s: %w", vc.DSN, err)
+	}
+	defer conn.Close()
+	mismatchCount := 0
+	for _, s := range structs {
+		mismatches, err := rapidash.ValidateStructSchema(conn, s)
+		if err != nil {
+			return xerrors.Errorf("failed to validate schema: %w", err)
+		}
+		for _, mismatch := range mismatches {
+			fmt.Println(mismatch.String())
+		}
+		mismatchCount += len(mismatches)
+	}
+	if mismatchCount > 0 {
+		return xerrors.Errorf("found %d schema mismatches", mismatchCount)
+	}
+	return nil
+}
+
 func parseErr(err error) error {
 	if err == nil {
 		return nil
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// rapidash:gen
+type UserLogin struct {
+	ID           uint64     `db:"id"`
+	UserID       int64      `db:"user_id"`
+	Name         string     `db:"name" rapidash:"omitempty"`
+	Nickname     *string    `db:"nickname"`
+	Score        *float64   `db:"score" rapidash:"omitempty"`
+	Tags         []string   `db:"tags"`
+	Payload      []byte     `db:"payload"`
+	Active       bool       `db:"active"`
+	LoginAt      *time.Time `db:"login_at"`
+	CreatedAt    time.Time  `db:"created_at"`
+	password     string     `db:"password"`
+	Ignored      string     `db:"-"`
+	NotPersisted string
+}
+
+// rapidash:gen
+type UserLogins []*UserLogin
+
+// rapidash:gen table=http_sessions
+type HTTPSession struct {
+	ID uint64 `db:"id"`
+}
@@ -0,0 +1,18 @@
+CREATE TABLE `user_logins` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint(20) NOT NULL,
+  `login_param_id` int(10) unsigned DEFAULT NULL,
+  `active` tinyint(1) NOT NULL,
+  `name` varchar(255) NOT NULL,
+  `score` double DEFAULT NULL,
+  `payload` blob,
+  `created_at` datetime NOT NULL,
+  `deleted_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `http_sessions` (
+  `id` bigint(20) unsigned NOT NULL,
+  `url` varchar(255) NOT NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
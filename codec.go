@@ -0,0 +1,317 @@
+package rapidash
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// Codec encodes value of table to content stored in cache server, and decodes it.
+// value is read by Decoder methods and decoded content is written by Encoder methods,
+// so other formats ( e.g. protobuf ) can be plugged in like EncodeRapidash/DecodeRapidash of model.
+// All processes sharing cache server must use the same codec for the table
+type Codec interface {
+	Encode(typ *Struct, value Decoder) ([]byte, error)
+	Decode(typ *Struct, content []byte, enc Encoder) error
+}
+
+// MsgpackCodec encodes value as msgpack array of columns in the order of Struct fields. this is the default codec
+type MsgpackCodec struct{}
+
+func (MsgpackCodec) Encode(typ *Struct, value Decoder) ([]byte, error) {
+	v, ok := value.(*StructValue)
+	if !ok {
+		return nil, xerrors.Errorf("msgpack codec cannot encode %T: %w", value, ErrInvalidCodecValue)
+	}
+	content, err := v.encodeValue()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to encode value: %w", err)
+	}
+	return content, nil
+}
+
+func (MsgpackCodec) Decode(typ *Struct, content []byte, enc Encoder) error {
+	e, ok := enc.(*StructEncoder)
+	if !ok {
+		return xerrors.Errorf("msgpack codec cannot decode to %T: %w", enc, ErrInvalidCodecValue)
+	}
+	decoder := NewDecoder(typ, &bytes.Buffer{}, e.valueFactory)
+	decoder.SetBuffer(content)
+	value, err := decoder.Decode()
+	if err != nil {
+		return xerrors.Errorf("failed to decode value: %w", err)
+	}
+	e.value = value
+	return nil
+}
+
+// JSONCodec encodes value as JSON object keyed by column name.
+// []byte is encoded as base64 string and time.Time is encoded as RFC3339 string with nanoseconds
+type JSONCodec struct{}
+
+func (JSONCodec) Encode(typ *Struct, value Decoder) ([]byte, error) {
+	v, ok := value.(*StructValue)
+	if !ok {
+		return nil, xerrors.Errorf("json codec cannot encode %T: %w", value, ErrInvalidCodecValue)
+	}
+	content, err := json.Marshal(jsonObject(v))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to marshal json: %w", err)
+	}
+	return content, nil
+}
+
+func (JSONCodec) Decode(typ *Struct, content []byte, enc Encoder) error {
+	e, ok := enc.(*StructEncoder)
+	if !ok {
+		return xerrors.Errorf("json codec cannot decode to %T: %w", enc, ErrInvalidCodecValue)
+	}
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	var object map[string]interface{}
+	if err := dec.Decode(&object); err != nil {
+		return xerrors.Errorf("failed to unmarshal json: %w", err)
+	}
+	value, err := jsonStructValue(typ, object, e.valueFactory)
+	if err != nil {
+		return xerrors.Errorf("failed to decode json object: %w", err)
+	}
+	e.value = value
+	return nil
+}
+
+func jsonObject(v *StructValue) map[string]interface{} {
+	object := map[string]interface{}{}
+	for _, column := range v.typ.Columns() {
+		value, exists := v.fields[column]
+		if !exists {
+			value = v.typ.fields[column].defaultValue
+		}
+		object[column] = jsonValue(v.typ.fields[column], value)
+	}
+	return object
+}
+
+func jsonValue(field *StructField, v *Value) interface{} {
+	if v == nil || v.IsNil {
+		return nil
+	}
+	switch field.typ {
+	case SliceType:
+		subField := &StructField{typ: field.subtype, column: field.column, subtypeStruct: field.subtypeStruct}
+		values := make([]interface{}, 0, len(v.sliceValue))
+		for _, value := range v.sliceValue {
+			values = append(values, jsonValue(subField, value))
+		}
+		return values
+	case StructType:
+		return jsonObject(v.structValue)
+	}
+	return v.RawValue()
+}
+
+func jsonStructValue(typ *Struct, object map[string]interface{}, factory *ValueFactory) (*StructValue, error) {
+	value := &StructValue{
+		typ:    typ,
+		fields: make(map[string]*Value, len(typ.fields)),
+	}
+	for _, column := range typ.Columns() {
+		v, err := jsonFieldValue(typ.fields[column], object[column], factory)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decode %s: %w", column, err)
+		}
+		value.fields[column] = v
+	}
+	return value, nil
+}
+
+func jsonFieldValue(field *StructField, raw interface{}, factory *ValueFactory) (*Value, error) {
+	if raw == nil {
+		return nilValue, nil
+	}
+	switch field.typ {
+	case SliceType:
+		raws, ok := raw.([]interface{})
+		if !ok {
+			return nil, xerrors.Errorf("%v is not array: %w", raw, ErrInvalidCodecValue)
+		}
+		subField := &StructField{typ: field.subtype, column: field.column, subtypeStruct: field.subtypeStruct}
+		values := make([]*Value, 0, len(raws))
+		for _, raw := range raws {
+			v, err := jsonFieldValue(subField, raw, factory)
+			if err != nil {
+				return nil, xerrors.Errorf("failed to decode element: %w", err)
+			}
+			values = append(values, v)
+		}
+		return ValuesToValue(values), nil
+	case StructType:
+		object, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, xerrors.Errorf("%v is not object: %w", raw, ErrInvalidCodecValue)
+		}
+		v, err := jsonStructValue(field.subtypeStruct, object, factory)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decode struct: %w", err)
+		}
+		return StructValueToValue(v), nil
+	case BoolType:
+		v, ok := raw.(bool)
+		if !ok {
+			return nil, xerrors.Errorf("%v is not bool: %w", raw, ErrInvalidCodecValue)
+		}
+		return factory.CreateBoolValue(v), nil
+	case StringType, BytesType, TimeType:
+		s, ok := raw.(string)
+		if !ok {
+			return nil, xerrors.Errorf("%v is not string: %w", raw, ErrInvalidCodecValue)
+		}
+		return jsonStringValue(field.typ, s, factory)
+	}
+	number, ok := raw.(json.Number)
+	if !ok {
+		return nil, xerrors.Errorf("%v is not number: %w", raw, ErrInvalidCodecValue)
+	}
+	return jsonNumberValue(field.typ, number.String(), factory)
+}
+
+func jsonStringValue(typ TypeID, s string, factory *ValueFactory) (*Value, error) {
+	switch typ {
+	case BytesType:
+		v, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decode base64: %w", err)
+		}
+		return factory.CreateBytesValue(v), nil
+	case TimeType:
+		v, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to parse time: %w", err)
+		}
+		return factory.CreateTimeValue(v), nil
+	}
+	return factory.CreateStringValue(s), nil
+}
+
+func jsonNumberValue(typ TypeID, s string, factory *ValueFactory) (*Value, error) {
+	switch typ {
+	case IntType, Int8Type, Int16Type, Int32Type, Int64Type:
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to parse %s as int: %w", s, err)
+		}
+		switch typ {
+		case IntType:
+			return factory.CreateIntValue(int(v)), nil
+		case Int8Type:
+			return factory.CreateInt8Value(int8(v)), nil
+		case Int16Type:
+			return factory.CreateInt16Value(int16(v)), nil
+		case Int32Type:
+			return factory.CreateInt32Value(int32(v)), nil
+		}
+		return factory.CreateInt64Value(v), nil
+	case UintType, Uint8Type, Uint16Type, Uint32Type, Uint64Type:
+		v, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to parse %s as uint: %w", s, err)
+		}
+		switch typ {
+		case UintType:
+			return factory.CreateUintValue(uint(v)), nil
+		case Uint8Type:
+			return factory.CreateUint8Value(uint8(v)), nil
+		case Uint16Type:
+			return factory.CreateUint16Value(uint16(v)), nil
+		case Uint32Type:
+			return factory.CreateUint32Value(uint32(v)), nil
+		}
+		return factory.CreateUint64Value(v), nil
+	case Float32Type:
+		v, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to parse %s as float32: %w", s, err)
+		}
+		return factory.CreateFloat32Value(float32(v)), nil
+	case Float64Type:
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to parse %s as float64: %w", s, err)
+		}
+		return factory.CreateFloat64Value(v), nil
+	}
+	return nil, xerrors.Errorf("type %d cannot be decoded from number: %w", typ, ErrInvalidCodecValue)
+}
+
+// isDefaultCodec returns true if codec writes the same content as ValueDecoder reads
+func isDefaultCodec(codec Codec) bool {
+	if codec == nil {
+		return true
+	}
+	_, ok := codec.(MsgpackCodec)
+	return ok
+}
+
+// encodeValue encodes value by codec of table
+func (c *SecondLevelCache) encodeValue(value *StructValue) ([]byte, error) {
+	codec := c.opt.Codec()
+	if isDefaultCodec(codec) {
+		return value.encodeValue()
+	}
+	content, err := codec.Encode(c.typ, value)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to encode by codec: %w", err)
+	}
+	return content, nil
+}
+
+// decodeValue decodes payload by codec of table. decoder is used if codec is the default one
+func (c *SecondLevelCache) decodeValue(decoder *ValueDecoder, payload []byte) (*StructValue, error) {
+	codec := c.opt.Codec()
+	if isDefaultCodec(codec) {
+		decoder.SetBuffer(payload)
+		return decoder.Decode()
+	}
+	return decodeValueByCodec(codec, decoder.typ, payload, c.valueFactory)
+}
+
+func decodeValueByCodec(codec Codec, typ *Struct, payload []byte, factory *ValueFactory) (*StructValue, error) {
+	enc := NewStructEncoder(typ, factory)
+	if err := codec.Decode(typ, payload, enc); err != nil {
+		return nil, xerrors.Errorf("failed to decode by codec: %w", err)
+	}
+	if err := enc.Error(); err != nil {
+		return nil, xerrors.Errorf("failed to encode decoded value: %w", err)
+	}
+	return enc.value, nil
+}
+
+// decodeValues decodes values of cluster key by codec of table
+func (c *SecondLevelCache) decodeValues(decoder *ValueDecoder, payload []byte) (*StructSliceValue, error) {
+	codec := c.opt.Codec()
+	decoder.SetBuffer(payload)
+	if isDefaultCodec(codec) {
+		return decoder.DecodeSlice()
+	}
+	var length int
+	if err := decoder.dec.DecodeArrayLength(&length); err != nil {
+		return nil, xerrors.Errorf("failed to decode array length: %w", err)
+	}
+	values := NewStructSliceValueWithCapacity(length)
+	for i := 0; i < length; i++ {
+		var content []byte
+		if err := decoder.dec.DecodeBytes(&content); err != nil {
+			return nil, xerrors.Errorf("failed to decode content of value: %w", err)
+		}
+		value, err := decodeValueByCodec(codec, decoder.typ, content, c.valueFactory)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decode value: %w", err)
+		}
+		values.Append(value)
+	}
+	return values, nil
+}
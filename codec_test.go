@@ -0,0 +1,77 @@
+package rapidash
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestCodec(t *testing.T) {
+	now := time.Unix(1600000000, 123).UTC()
+	for _, codec := range []Codec{MsgpackCodec{}, JSONCodec{}} {
+		r, err := New(SecondLevelCacheTableCodec("user_logins", codec))
+		NoError(t, err)
+		slc := NewSecondLevelCache(userLoginType(), nil, r.tableOption("user_logins"))
+		_, value, err := slc.encode(&UserLogin{ID: 1, UserID: 2, UserSessionID: 3, Name: "rapidash", CreatedAt: &now})
+		NoError(t, err)
+		content, err := slc.encodeValue(value)
+		NoError(t, err)
+		decoder := slc.valueDecoder()
+		decoded, err := slc.decodeValue(decoder, content)
+		slc.releaseValueDecoder(decoder)
+		NoError(t, err)
+		var userLogin UserLogin
+		NoError(t, userLogin.DecodeRapidash(decoded))
+		Equal(t, userLogin.ID, uint64(1))
+		Equal(t, userLogin.UserID, uint64(2))
+		Equal(t, userLogin.Name, "rapidash")
+		Equal(t, userLogin.CreatedAt.Equal(now), true)
+		if userLogin.UpdatedAt != nil {
+			t.Fatal("nil value must be decoded as nil")
+		}
+
+		values := NewStructSliceValue()
+		values.Append(value)
+		values.Append(value)
+		content, err = slc.encodeClusterValues(values)
+		NoError(t, err)
+		decoder = slc.valueDecoder()
+		decodedValues, err := slc.decodeValues(decoder, content)
+		slc.releaseValueDecoder(decoder)
+		NoError(t, err)
+		Equal(t, decodedValues.Len(), 2)
+		Equal(t, decodedValues.At(1).String("name"), "rapidash")
+	}
+	t.Run("json object", func(t *testing.T) {
+		typ := NewStruct("items").
+			FieldInt8("level").
+			FieldFloat64("rate").
+			FieldBool("active").
+			FieldBytes("data").
+			FieldSlice("tags", Uint64Type)
+		r, err := New(SecondLevelCacheTableCodec("items", JSONCodec{}))
+		NoError(t, err)
+		slc := NewSecondLevelCache(typ, nil, r.tableOption("items"))
+		enc := NewStructEncoder(typ, slc.valueFactory)
+		enc.Int8("level", -3)
+		enc.Float64("rate", 0.5)
+		enc.Bool("active", true)
+		enc.Bytes("data", []byte("rapidash"))
+		enc.Uint64s("tags", []uint64{1, 2})
+		content, err := slc.encodeValue(enc.value)
+		NoError(t, err)
+		var object map[string]interface{}
+		NoError(t, json.Unmarshal(content, &object))
+		Equal(t, object["level"], float64(-3))
+		Equal(t, object["tags"], []interface{}{float64(1), float64(2)})
+
+		decoded, err := slc.decodeValue(slc.valueDecoder(), content)
+		NoError(t, err)
+		Equal(t, decoded.Int8("level"), int8(-3))
+		Equal(t, decoded.Float64("rate"), 0.5)
+		Equal(t, decoded.Bool("active"), true)
+		Equal(t, bytes.Equal(decoded.Bytes("data"), []byte("rapidash")), true)
+		Equal(t, decoded.Uint64s("tags"), []uint64{1, 2})
+	})
+}
@@ -2,6 +2,7 @@ package rapidash
 
 import (
 	"bytes"
+	"sort"
 	"time"
 
 	"github.com/blastrain/msgpack"
@@ -435,7 +436,7 @@ type StructCoder struct {
 
 func (c *StructCoder) Encode() ([]byte, error) {
 	enc := NewStructEncoder(c.typ, NewValueFactory())
-	if err := c.value.EncodeRapidash(enc); err != nil {
+	if err := encodeRapidash(c.value, enc); err != nil {
 		return nil, xerrors.Errorf("failed to encode struct: %w", err)
 	}
 	content, err := enc.Encode()
@@ -452,7 +453,7 @@ func (c *StructCoder) Decode(content []byte) error {
 	if err != nil {
 		return xerrors.Errorf("failed to decode struct: %w", err)
 	}
-	if err := c.value.DecodeRapidash(value); err != nil {
+	if err := decodeRapidash(c.value, value); err != nil {
 		return xerrors.Errorf("failed to decode value: %w", err)
 	}
 	return nil
@@ -1018,8 +1019,225 @@ func (c *StructsCoder) Decode(content []byte) error {
 	if err != nil {
 		return xerrors.Errorf("failed to decode slice of struct: %w", err)
 	}
-	if err := c.v.DecodeRapidash(v); err != nil {
+	if err := decodeRapidash(c.v, v); err != nil {
 		return xerrors.Errorf("failed to decode value: %w", err)
 	}
 	return nil
 }
+
+func StringMap(v map[string]string) *StringMapCoder        { return &StringMapCoder{v: &v} }
+func IntMap(v map[string]int) *IntMapCoder                 { return &IntMapCoder{v: &v} }
+func Int64Map(v map[string]int64) *Int64MapCoder           { return &Int64MapCoder{v: &v} }
+func Uint64Map(v map[string]uint64) *Uint64MapCoder        { return &Uint64MapCoder{v: &v} }
+func Float64Map(v map[string]float64) *Float64MapCoder     { return &Float64MapCoder{v: &v} }
+func BoolMap(v map[string]bool) *BoolMapCoder              { return &BoolMapCoder{v: &v} }
+func StringMapPtr(v *map[string]string) *StringMapCoder    { return &StringMapCoder{v: v} }
+func IntMapPtr(v *map[string]int) *IntMapCoder             { return &IntMapCoder{v: v} }
+func Int64MapPtr(v *map[string]int64) *Int64MapCoder       { return &Int64MapCoder{v: v} }
+func Uint64MapPtr(v *map[string]uint64) *Uint64MapCoder    { return &Uint64MapCoder{v: v} }
+func Float64MapPtr(v *map[string]float64) *Float64MapCoder { return &Float64MapCoder{v: v} }
+func BoolMapPtr(v *map[string]bool) *BoolMapCoder          { return &BoolMapCoder{v: v} }
+
+// encodeMap encodes map by sorted keys, so the same map always has the same bytes
+func encodeMap(typ string, keys []string, encodeValue func(*msgpack.Encoder, string) error) ([]byte, error) {
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	if err := msgpack.WriteMapHeader(&buf, len(keys)); err != nil {
+		return nil, xerrors.Errorf("failed to encode length of %s: %w", typ, err)
+	}
+	enc := msgpack.NewEncoder(&buf)
+	for _, key := range keys {
+		if err := enc.EncodeString(key); err != nil {
+			return nil, xerrors.Errorf("failed to encode key of %s: %w", typ, err)
+		}
+		if err := encodeValue(enc, key); err != nil {
+			return nil, xerrors.Errorf("failed to encode %s: %w", typ, err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeMap(typ string, content []byte, init func(int), decodeValue func(*msgpack.Decoder, string) error) error {
+	buf := bytes.NewBuffer(content)
+	dec := msgpack.NewDecoder(buf)
+	var len int
+	if err := dec.DecodeMapLength(&len); err != nil {
+		return xerrors.Errorf("failed to decode length of %s: %w", typ, err)
+	}
+	if len < 0 {
+		len = 0
+	}
+	init(len)
+	for i := 0; i < len; i++ {
+		var key string
+		if err := dec.DecodeString(&key); err != nil {
+			return xerrors.Errorf("failed to decode key of %s: %w", typ, err)
+		}
+		if err := decodeValue(dec, key); err != nil {
+			return xerrors.Errorf("failed to decode %s: %w", typ, err)
+		}
+	}
+	return nil
+}
+
+type StringMapCoder struct {
+	v *map[string]string
+}
+
+func (c *StringMapCoder) Encode() ([]byte, error) {
+	keys := make([]string, 0, len(*c.v))
+	for key := range *c.v {
+		keys = append(keys, key)
+	}
+	return encodeMap("map[string]string", keys, func(enc *msgpack.Encoder, key string) error {
+		return enc.EncodeString((*c.v)[key])
+	})
+}
+
+func (c *StringMapCoder) Decode(content []byte) error {
+	return decodeMap("map[string]string", content, func(len int) {
+		*c.v = make(map[string]string, len)
+	}, func(dec *msgpack.Decoder, key string) error {
+		var v string
+		if err := dec.DecodeString(&v); err != nil {
+			return err
+		}
+		(*c.v)[key] = v
+		return nil
+	})
+}
+
+type IntMapCoder struct {
+	v *map[string]int
+}
+
+func (c *IntMapCoder) Encode() ([]byte, error) {
+	keys := make([]string, 0, len(*c.v))
+	for key := range *c.v {
+		keys = append(keys, key)
+	}
+	return encodeMap("map[string]int", keys, func(enc *msgpack.Encoder, key string) error {
+		return enc.EncodeInt((*c.v)[key])
+	})
+}
+
+func (c *IntMapCoder) Decode(content []byte) error {
+	return decodeMap("map[string]int", content, func(len int) {
+		*c.v = make(map[string]int, len)
+	}, func(dec *msgpack.Decoder, key string) error {
+		var v int
+		if err := dec.DecodeInt(&v); err != nil {
+			return err
+		}
+		(*c.v)[key] = v
+		return nil
+	})
+}
+
+type Int64MapCoder struct {
+	v *map[string]int64
+}
+
+func (c *Int64MapCoder) Encode() ([]byte, error) {
+	keys := make([]string, 0, len(*c.v))
+	for key := range *c.v {
+		keys = append(keys, key)
+	}
+	return encodeMap("map[string]int64", keys, func(enc *msgpack.Encoder, key string) error {
+		return enc.EncodeInt64((*c.v)[key])
+	})
+}
+
+func (c *Int64MapCoder) Decode(content []byte) error {
+	return decodeMap("map[string]int64", content, func(len int) {
+		*c.v = make(map[string]int64, len)
+	}, func(dec *msgpack.Decoder, key string) error {
+		var v int64
+		if err := dec.DecodeInt64(&v); err != nil {
+			return err
+		}
+		(*c.v)[key] = v
+		return nil
+	})
+}
+
+type Uint64MapCoder struct {
+	v *map[string]uint64
+}
+
+func (c *Uint64MapCoder) Encode() ([]byte, error) {
+	keys := make([]string, 0, len(*c.v))
+	for key := range *c.v {
+		keys = append(keys, key)
+	}
+	return encodeMap("map[string]uint64", keys, func(enc *msgpack.Encoder, key string) error {
+		return enc.EncodeUint64((*c.v)[key])
+	})
+}
+
+func (c *Uint64MapCoder) Decode(content []byte) error {
+	return decodeMap("map[string]uint64", content, func(len int) {
+		*c.v = make(map[string]uint64, len)
+	}, func(dec *msgpack.Decoder, key string) error {
+		var v uint64
+		if err := dec.DecodeUint64(&v); err != nil {
+			return err
+		}
+		(*c.v)[key] = v
+		return nil
+	})
+}
+
+type Float64MapCoder struct {
+	v *map[string]float64
+}
+
+func (c *Float64MapCoder) Encode() ([]byte, error) {
+	keys := make([]string, 0, len(*c.v))
+	for key := range *c.v {
+		keys = append(keys, key)
+	}
+	return encodeMap("map[string]float64", keys, func(enc *msgpack.Encoder, key string) error {
+		return enc.EncodeFloat64((*c.v)[key])
+	})
+}
+
+func (c *Float64MapCoder) Decode(content []byte) error {
+	return decodeMap("map[string]float64", content, func(len int) {
+		*c.v = make(map[string]float64, len)
+	}, func(dec *msgpack.Decoder, key string) error {
+		var v float64
+		if err := dec.DecodeFloat64(&v); err != nil {
+			return err
+		}
+		(*c.v)[key] = v
+		return nil
+	})
+}
+
+type BoolMapCoder struct {
+	v *map[string]bool
+}
+
+func (c *BoolMapCoder) Encode() ([]byte, error) {
+	keys := make([]string, 0, len(*c.v))
+	for key := range *c.v {
+		keys = append(keys, key)
+	}
+	return encodeMap("map[string]bool", keys, func(enc *msgpack.Encoder, key string) error {
+		return enc.EncodeBool((*c.v)[key])
+	})
+}
+
+func (c *BoolMapCoder) Decode(content []byte) error {
+	return decodeMap("map[string]bool", content, func(len int) {
+		*c.v = make(map[string]bool, len)
+	}, func(dec *msgpack.Decoder, key string) error {
+		var v bool
+		if err := dec.DecodeBool(&v); err != nil {
+			return err
+		}
+		(*c.v)[key] = v
+		return nil
+	})
+}
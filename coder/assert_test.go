@@ -0,0 +1,18 @@
+package coder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func NoError(t *testing.T, err error) {
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+}
+
+func Equal(t *testing.T, src interface{}, dst interface{}) {
+	if !reflect.DeepEqual(src, dst) {
+		t.Fatalf("not equal %v and %v", src, dst)
+	}
+}
@@ -0,0 +1,485 @@
+// Package coder is stable interface to implement rapidash.Coder outside of rapidash ( e.g. adapter of protobuf messages ).
+//
+// Adapters should depend on this package instead of internals of rapidash.
+// Types and functions of this package keep compatibility in minor versions.
+//
+// Typical adapter walks Fields of rapidash.Struct, then calls Set on encoding and Get on decoding for each field.
+package coder
+
+import (
+	"time"
+
+	"go.knocknote.io/rapidash"
+	"golang.org/x/xerrors"
+)
+
+var (
+	ErrTypeMismatch    = xerrors.New("value doesn't match type of field")
+	ErrUnsupportedType = xerrors.New("unsupported type")
+)
+
+type (
+	Encoder     = rapidash.Encoder
+	Decoder     = rapidash.Decoder
+	Marshaler   = rapidash.Marshaler
+	Unmarshaler = rapidash.Unmarshaler
+	Coder       = rapidash.Coder
+	Struct      = rapidash.Struct
+	Field       = rapidash.StructField
+)
+
+// MarshalerFunc is Marshaler by function
+type MarshalerFunc func(Encoder) error
+
+func (f MarshalerFunc) EncodeRapidash(enc Encoder) error {
+	return f(enc)
+}
+
+// UnmarshalerFunc is Unmarshaler by function
+type UnmarshalerFunc func(Decoder) error
+
+func (f UnmarshalerFunc) DecodeRapidash(dec Decoder) error {
+	return f(dec)
+}
+
+type funcCoder struct {
+	MarshalerFunc
+	UnmarshalerFunc
+}
+
+// New creates Coder by functions
+func New(marshal func(Encoder) error, unmarshal func(Decoder) error) Coder {
+	return &funcCoder{MarshalerFunc: marshal, UnmarshalerFunc: unmarshal}
+}
+
+// Fields returns fields of typ in order of columns
+func Fields(typ *Struct) []*Field {
+	return typ.Fields()
+}
+
+// Set encodes v as value of field. v must be Go type of the field or pointer of it, and nil means NULL.
+// Value of struct field and struct slice field must be Marshaler
+func Set(enc Encoder, field *Field, v interface{}) error {
+	column := field.Column()
+	switch field.Type() {
+	case rapidash.IntType:
+		switch x := v.(type) {
+		case int:
+			enc.Int(column, x)
+			return nil
+		case *int:
+			enc.IntPtr(column, x)
+			return nil
+		case nil:
+			enc.IntPtr(column, nil)
+			return nil
+		}
+	case rapidash.Int8Type:
+		switch x := v.(type) {
+		case int8:
+			enc.Int8(column, x)
+			return nil
+		case *int8:
+			enc.Int8Ptr(column, x)
+			return nil
+		case nil:
+			enc.Int8Ptr(column, nil)
+			return nil
+		}
+	case rapidash.Int16Type:
+		switch x := v.(type) {
+		case int16:
+			enc.Int16(column, x)
+			return nil
+		case *int16:
+			enc.Int16Ptr(column, x)
+			return nil
+		case nil:
+			enc.Int16Ptr(column, nil)
+			return nil
+		}
+	case rapidash.Int32Type:
+		switch x := v.(type) {
+		case int32:
+			enc.Int32(column, x)
+			return nil
+		case *int32:
+			enc.Int32Ptr(column, x)
+			return nil
+		case nil:
+			enc.Int32Ptr(column, nil)
+			return nil
+		}
+	case rapidash.Int64Type:
+		switch x := v.(type) {
+		case int64:
+			enc.Int64(column, x)
+			return nil
+		case *int64:
+			enc.Int64Ptr(column, x)
+			return nil
+		case nil:
+			enc.Int64Ptr(column, nil)
+			return nil
+		}
+	case rapidash.UintType:
+		switch x := v.(type) {
+		case uint:
+			enc.Uint(column, x)
+			return nil
+		case *uint:
+			enc.UintPtr(column, x)
+			return nil
+		case nil:
+			enc.UintPtr(column, nil)
+			return nil
+		}
+	case rapidash.Uint8Type:
+		switch x := v.(type) {
+		case uint8:
+			enc.Uint8(column, x)
+			return nil
+		case *uint8:
+			enc.Uint8Ptr(column, x)
+			return nil
+		case nil:
+			enc.Uint8Ptr(column, nil)
+			return nil
+		}
+	case rapidash.Uint16Type:
+		switch x := v.(type) {
+		case uint16:
+			enc.Uint16(column, x)
+			return nil
+		case *uint16:
+			enc.Uint16Ptr(column, x)
+			return nil
+		case nil:
+			enc.Uint16Ptr(column, nil)
+			return nil
+		}
+	case rapidash.Uint32Type:
+		switch x := v.(type) {
+		case uint32:
+			enc.Uint32(column, x)
+			return nil
+		case *uint32:
+			enc.Uint32Ptr(column, x)
+			return nil
+		case nil:
+			enc.Uint32Ptr(column, nil)
+			return nil
+		}
+	case rapidash.Uint64Type:
+		switch x := v.(type) {
+		case uint64:
+			enc.Uint64(column, x)
+			return nil
+		case *uint64:
+			enc.Uint64Ptr(column, x)
+			return nil
+		case nil:
+			enc.Uint64Ptr(column, nil)
+			return nil
+		}
+	case rapidash.Float32Type:
+		switch x := v.(type) {
+		case float32:
+			enc.Float32(column, x)
+			return nil
+		case *float32:
+			enc.Float32Ptr(column, x)
+			return nil
+		case nil:
+			enc.Float32Ptr(column, nil)
+			return nil
+		}
+	case rapidash.Float64Type:
+		switch x := v.(type) {
+		case float64:
+			enc.Float64(column, x)
+			return nil
+		case *float64:
+			enc.Float64Ptr(column, x)
+			return nil
+		case nil:
+			enc.Float64Ptr(column, nil)
+			return nil
+		}
+	case rapidash.BoolType:
+		switch x := v.(type) {
+		case bool:
+			enc.Bool(column, x)
+			return nil
+		case *bool:
+			enc.BoolPtr(column, x)
+			return nil
+		case nil:
+			enc.BoolPtr(column, nil)
+			return nil
+		}
+	case rapidash.StringType:
+		switch x := v.(type) {
+		case string:
+			enc.String(column, x)
+			return nil
+		case *string:
+			enc.StringPtr(column, x)
+			return nil
+		case nil:
+			enc.StringPtr(column, nil)
+			return nil
+		}
+	case rapidash.BytesType:
+		switch x := v.(type) {
+		case []byte:
+			enc.Bytes(column, x)
+			return nil
+		case *[]byte:
+			enc.BytesPtr(column, x)
+			return nil
+		case nil:
+			enc.BytesPtr(column, nil)
+			return nil
+		}
+	case rapidash.TimeType:
+		switch x := v.(type) {
+		case time.Time:
+			enc.Time(column, x)
+			return nil
+		case *time.Time:
+			enc.TimePtr(column, x)
+			return nil
+		case nil:
+			enc.TimePtr(column, nil)
+			return nil
+		}
+	case rapidash.SliceType:
+		return setSlice(enc, field, v)
+	case rapidash.StructType:
+		if m, ok := v.(Marshaler); ok {
+			enc.Struct(column, m)
+			return nil
+		}
+	default:
+		return xerrors.Errorf("%s of %s: %w", field.Type(), column, ErrUnsupportedType)
+	}
+	return xerrors.Errorf("%T for %s of %s: %w", v, field.Type(), column, ErrTypeMismatch)
+}
+
+func setSlice(enc Encoder, field *Field, v interface{}) error {
+	column := field.Column()
+	switch field.Subtype() {
+	case rapidash.IntType:
+		if x, ok := v.([]int); ok {
+			enc.Ints(column, x)
+			return nil
+		}
+	case rapidash.Int8Type:
+		if x, ok := v.([]int8); ok {
+			enc.Int8s(column, x)
+			return nil
+		}
+	case rapidash.Int16Type:
+		if x, ok := v.([]int16); ok {
+			enc.Int16s(column, x)
+			return nil
+		}
+	case rapidash.Int32Type:
+		if x, ok := v.([]int32); ok {
+			enc.Int32s(column, x)
+			return nil
+		}
+	case rapidash.Int64Type:
+		if x, ok := v.([]int64); ok {
+			enc.Int64s(column, x)
+			return nil
+		}
+	case rapidash.UintType:
+		if x, ok := v.([]uint); ok {
+			enc.Uints(column, x)
+			return nil
+		}
+	case rapidash.Uint8Type:
+		if x, ok := v.([]uint8); ok {
+			enc.Uint8s(column, x)
+			return nil
+		}
+	case rapidash.Uint16Type:
+		if x, ok := v.([]uint16); ok {
+			enc.Uint16s(column, x)
+			return nil
+		}
+	case rapidash.Uint32Type:
+		if x, ok := v.([]uint32); ok {
+			enc.Uint32s(column, x)
+			return nil
+		}
+	case rapidash.Uint64Type:
+		if x, ok := v.([]uint64); ok {
+			enc.Uint64s(column, x)
+			return nil
+		}
+	case rapidash.Float32Type:
+		if x, ok := v.([]float32); ok {
+			enc.Float32s(column, x)
+			return nil
+		}
+	case rapidash.Float64Type:
+		if x, ok := v.([]float64); ok {
+			enc.Float64s(column, x)
+			return nil
+		}
+	case rapidash.BoolType:
+		if x, ok := v.([]bool); ok {
+			enc.Bools(column, x)
+			return nil
+		}
+	case rapidash.StringType:
+		if x, ok := v.([]string); ok {
+			enc.Strings(column, x)
+			return nil
+		}
+	case rapidash.TimeType:
+		if x, ok := v.([]time.Time); ok {
+			enc.Times(column, x)
+			return nil
+		}
+	case rapidash.StructType:
+		if m, ok := v.(Marshaler); ok {
+			enc.Structs(column, m)
+			return nil
+		}
+	default:
+		return xerrors.Errorf("slice of %s of %s: %w", field.Subtype(), column, ErrUnsupportedType)
+	}
+	return xerrors.Errorf("%T for slice of %s of %s: %w", v, field.Subtype(), column, ErrTypeMismatch)
+}
+
+// Get decodes value of field. Scalar value is returned as Go type of the field, or nil if it is NULL.
+// Struct field and struct slice field must be decoded by Decoder.Struct or Decoder.Slice with Unmarshaler
+func Get(dec Decoder, field *Field) (interface{}, error) {
+	column := field.Column()
+	switch field.Type() {
+	case rapidash.IntType:
+		if v := dec.IntPtr(column); v != nil {
+			return *v, nil
+		}
+		return nil, nil
+	case rapidash.Int8Type:
+		if v := dec.Int8Ptr(column); v != nil {
+			return *v, nil
+		}
+		return nil, nil
+	case rapidash.Int16Type:
+		if v := dec.Int16Ptr(column); v != nil {
+			return *v, nil
+		}
+		return nil, nil
+	case rapidash.Int32Type:
+		if v := dec.Int32Ptr(column); v != nil {
+			return *v, nil
+		}
+		return nil, nil
+	case rapidash.Int64Type:
+		if v := dec.Int64Ptr(column); v != nil {
+			return *v, nil
+		}
+		return nil, nil
+	case rapidash.UintType:
+		if v := dec.UintPtr(column); v != nil {
+			return *v, nil
+		}
+		return nil, nil
+	case rapidash.Uint8Type:
+		if v := dec.Uint8Ptr(column); v != nil {
+			return *v, nil
+		}
+		return nil, nil
+	case rapidash.Uint16Type:
+		if v := dec.Uint16Ptr(column); v != nil {
+			return *v, nil
+		}
+		return nil, nil
+	case rapidash.Uint32Type:
+		if v := dec.Uint32Ptr(column); v != nil {
+			return *v, nil
+		}
+		return nil, nil
+	case rapidash.Uint64Type:
+		if v := dec.Uint64Ptr(column); v != nil {
+			return *v, nil
+		}
+		return nil, nil
+	case rapidash.Float32Type:
+		if v := dec.Float32Ptr(column); v != nil {
+			return *v, nil
+		}
+		return nil, nil
+	case rapidash.Float64Type:
+		if v := dec.Float64Ptr(column); v != nil {
+			return *v, nil
+		}
+		return nil, nil
+	case rapidash.BoolType:
+		if v := dec.BoolPtr(column); v != nil {
+			return *v, nil
+		}
+		return nil, nil
+	case rapidash.StringType:
+		if v := dec.StringPtr(column); v != nil {
+			return *v, nil
+		}
+		return nil, nil
+	case rapidash.BytesType:
+		if v := dec.BytesPtr(column); v != nil {
+			return *v, nil
+		}
+		return nil, nil
+	case rapidash.TimeType:
+		if v := dec.TimePtr(column); v != nil {
+			return *v, nil
+		}
+		return nil, nil
+	case rapidash.SliceType:
+		return getSlice(dec, field)
+	}
+	return nil, xerrors.Errorf("%s of %s: %w", field.Type(), column, ErrUnsupportedType)
+}
+
+func getSlice(dec Decoder, field *Field) (interface{}, error) {
+	column := field.Column()
+	switch field.Subtype() {
+	case rapidash.IntType:
+		return dec.Ints(column), nil
+	case rapidash.Int8Type:
+		return dec.Int8s(column), nil
+	case rapidash.Int16Type:
+		return dec.Int16s(column), nil
+	case rapidash.Int32Type:
+		return dec.Int32s(column), nil
+	case rapidash.Int64Type:
+		return dec.Int64s(column), nil
+	case rapidash.UintType:
+		return dec.Uints(column), nil
+	case rapidash.Uint8Type:
+		return dec.Uint8s(column), nil
+	case rapidash.Uint16Type:
+		return dec.Uint16s(column), nil
+	case rapidash.Uint32Type:
+		return dec.Uint32s(column), nil
+	case rapidash.Uint64Type:
+		return dec.Uint64s(column), nil
+	case rapidash.Float32Type:
+		return dec.Float32s(column), nil
+	case rapidash.Float64Type:
+		return dec.Float64s(column), nil
+	case rapidash.BoolType:
+		return dec.Bools(column), nil
+	case rapidash.StringType:
+		return dec.Strings(column), nil
+	case rapidash.TimeType:
+		return dec.Times(column), nil
+	}
+	return nil, xerrors.Errorf("slice of %s of %s: %w", field.Subtype(), column, ErrUnsupportedType)
+}
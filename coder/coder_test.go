@@ -0,0 +1,101 @@
+package coder
+
+import (
+	"bytes"
+	"testing"
+
+	"go.knocknote.io/rapidash"
+	"golang.org/x/xerrors"
+)
+
+// mapCoder is adapter encoding map keyed by column
+type mapCoder struct {
+	typ    *Struct
+	values map[string]interface{}
+}
+
+func (c *mapCoder) EncodeRapidash(enc Encoder) error {
+	for _, field := range Fields(c.typ) {
+		if err := Set(enc, field, c.values[field.Column()]); err != nil {
+			return err
+		}
+	}
+	return enc.Error()
+}
+
+func (c *mapCoder) DecodeRapidash(dec Decoder) error {
+	c.values = map[string]interface{}{}
+	for _, field := range Fields(c.typ) {
+		v, err := Get(dec, field)
+		if err != nil {
+			return err
+		}
+		c.values[field.Column()] = v
+	}
+	return dec.Error()
+}
+
+func TestCoder(t *testing.T) {
+	typ := rapidash.NewStruct("user_logins").
+		FieldUint64("id").
+		FieldString("name").
+		FieldSlice("tags", rapidash.StringType)
+	Equal(t, typ.TableName(), "user_logins")
+	Equal(t, len(Fields(typ)), 3)
+	Equal(t, Fields(typ)[2].Subtype(), rapidash.StringType)
+
+	factory := rapidash.NewValueFactory()
+	encode := func(t *testing.T, values map[string]interface{}) *mapCoder {
+		enc := rapidash.NewStructEncoder(typ, factory)
+		NoError(t, (&mapCoder{typ: typ, values: values}).EncodeRapidash(enc))
+		content, err := enc.Encode()
+		NoError(t, err)
+		dec := rapidash.NewDecoder(typ, &bytes.Buffer{}, factory)
+		dec.SetBuffer(content)
+		value, err := dec.Decode()
+		NoError(t, err)
+		decoded := &mapCoder{typ: typ}
+		NoError(t, decoded.DecodeRapidash(value))
+		return decoded
+	}
+	t.Run("values", func(t *testing.T) {
+		decoded := encode(t, map[string]interface{}{
+			"id":   uint64(1),
+			"name": "rapidash",
+			"tags": []string{"a", "b"},
+		})
+		Equal(t, decoded.values["id"], uint64(1))
+		Equal(t, decoded.values["name"], "rapidash")
+		Equal(t, decoded.values["tags"], []string{"a", "b"})
+	})
+	t.Run("null", func(t *testing.T) {
+		decoded := encode(t, map[string]interface{}{"id": uint64(1), "tags": []string{}})
+		Equal(t, decoded.values["name"], nil)
+	})
+	t.Run("type mismatch", func(t *testing.T) {
+		enc := rapidash.NewStructEncoder(typ, factory)
+		err := Set(enc, Fields(typ)[0], "1")
+		Equal(t, xerrors.Is(err, ErrTypeMismatch), true)
+	})
+	t.Run("func coder", func(t *testing.T) {
+		typ := rapidash.NewStruct("user_logins").FieldUint64("id")
+		var id uint64
+		c := New(func(enc Encoder) error {
+			enc.Uint64("id", 1)
+			return enc.Error()
+		}, func(dec Decoder) error {
+			id = dec.Uint64("id")
+			return dec.Error()
+		})
+		enc := rapidash.NewStructEncoder(typ, factory)
+		NoError(t, c.EncodeRapidash(enc))
+		content, err := enc.Encode()
+		NoError(t, err)
+		dec := rapidash.NewDecoder(typ, &bytes.Buffer{}, factory)
+		dec.SetBuffer(content)
+		value, err := dec.Decode()
+		NoError(t, err)
+		NoError(t, c.DecodeRapidash(value))
+		Equal(t, id, uint64(1))
+	})
+}
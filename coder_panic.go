@@ -0,0 +1,33 @@
+package rapidash
+
+import (
+	"golang.org/x/xerrors"
+)
+
+// encodeRapidash calls EncodeRapidash of user's Marshaler and converts panic in it to ErrCoderPanic
+func encodeRapidash(marshaler Marshaler, enc Encoder) (e error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e = xerrors.Errorf("panic in EncodeRapidash of %T: %v: %w", marshaler, r, ErrCoderPanic)
+		}
+	}()
+	return marshaler.EncodeRapidash(enc)
+}
+
+// decodeRapidash calls DecodeRapidash of user's Unmarshaler and converts panic in it to ErrCoderPanic
+func decodeRapidash(unmarshaler Unmarshaler, dec Decoder) (e error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e = xerrors.Errorf("panic in DecodeRapidash of %T: %v: %w", unmarshaler, r, ErrCoderPanic)
+		}
+	}()
+	return unmarshaler.DecodeRapidash(dec)
+}
+
+// abortByCoderPanic marks tx as aborted if err is caused by panic in user's Coder.
+// aborted transaction can only be rolled back
+func (tx *Tx) abortByCoderPanic(err error) {
+	if tx != nil && xerrors.Is(err, ErrCoderPanic) {
+		tx.aborted = true
+	}
+}
@@ -0,0 +1,46 @@
+package rapidash
+
+import (
+	"testing"
+
+	"golang.org/x/xerrors"
+)
+
+type panicCoder struct{}
+
+func (c *panicCoder) EncodeRapidash(enc Encoder) error {
+	panic("encode")
+}
+
+func (c *panicCoder) DecodeRapidash(dec Decoder) error {
+	panic("decode")
+}
+
+func (c *panicCoder) Struct() *Struct {
+	return NewStruct("panic_coders").FieldInt64("id")
+}
+
+func TestCoderPanic(t *testing.T) {
+	t.Run("encode", func(t *testing.T) {
+		coder := &panicCoder{}
+		_, err := (&StructCoder{typ: coder.Struct(), value: coder}).Encode()
+		Error(t, err)
+		Equal(t, xerrors.Is(err, ErrCoderPanic), true)
+	})
+	t.Run("decode", func(t *testing.T) {
+		coder := &panicCoder{}
+		err := decodeRapidash(coder, NewStructSliceValue())
+		Error(t, err)
+		Equal(t, xerrors.Is(err, ErrCoderPanic), true)
+	})
+	t.Run("abort transaction", func(t *testing.T) {
+		tx := &Tx{r: &Rapidash{opt: defaultOption()}}
+		tx.abortByCoderPanic(xerrors.New("not panic"))
+		Equal(t, tx.aborted, false)
+		tx.abortByCoderPanic(decodeRapidash(&panicCoder{}, NewStructSliceValue()))
+		Equal(t, tx.aborted, true)
+		err := tx.Commit()
+		Error(t, err)
+		Equal(t, xerrors.Is(err, ErrTxAborted), true)
+	})
+}
@@ -0,0 +1,122 @@
+package rapidash
+
+import (
+	"time"
+
+	"github.com/blastrain/msgpack"
+	"golang.org/x/xerrors"
+)
+
+// FieldIntDefault adds int field whose value is v if it isn't set when value is encoded or inserted
+func (s *Struct) FieldIntDefault(column string, v int) *Struct {
+	return s.FieldInt(column).setDefault(column, NewIntValue(v))
+}
+
+// FieldInt8Default adds int8 field whose value is v if it isn't set when value is encoded or inserted
+func (s *Struct) FieldInt8Default(column string, v int8) *Struct {
+	return s.FieldInt8(column).setDefault(column, NewInt8Value(v))
+}
+
+// FieldInt16Default adds int16 field whose value is v if it isn't set when value is encoded or inserted
+func (s *Struct) FieldInt16Default(column string, v int16) *Struct {
+	return s.FieldInt16(column).setDefault(column, NewInt16Value(v))
+}
+
+// FieldInt32Default adds int32 field whose value is v if it isn't set when value is encoded or inserted
+func (s *Struct) FieldInt32Default(column string, v int32) *Struct {
+	return s.FieldInt32(column).setDefault(column, NewInt32Value(v))
+}
+
+// FieldInt64Default adds int64 field whose value is v if it isn't set when value is encoded or inserted
+func (s *Struct) FieldInt64Default(column string, v int64) *Struct {
+	return s.FieldInt64(column).setDefault(column, NewInt64Value(v))
+}
+
+// FieldUintDefault adds uint field whose value is v if it isn't set when value is encoded or inserted
+func (s *Struct) FieldUintDefault(column string, v uint) *Struct {
+	return s.FieldUint(column).setDefault(column, NewUintValue(v))
+}
+
+// FieldUint8Default adds uint8 field whose value is v if it isn't set when value is encoded or inserted
+func (s *Struct) FieldUint8Default(column string, v uint8) *Struct {
+	return s.FieldUint8(column).setDefault(column, NewUint8Value(v))
+}
+
+// FieldUint16Default adds uint16 field whose value is v if it isn't set when value is encoded or inserted
+func (s *Struct) FieldUint16Default(column string, v uint16) *Struct {
+	return s.FieldUint16(column).setDefault(column, NewUint16Value(v))
+}
+
+// FieldUint32Default adds uint32 field whose value is v if it isn't set when value is encoded or inserted
+func (s *Struct) FieldUint32Default(column string, v uint32) *Struct {
+	return s.FieldUint32(column).setDefault(column, NewUint32Value(v))
+}
+
+// FieldUint64Default adds uint64 field whose value is v if it isn't set when value is encoded or inserted
+func (s *Struct) FieldUint64Default(column string, v uint64) *Struct {
+	return s.FieldUint64(column).setDefault(column, NewUint64Value(v))
+}
+
+// FieldFloat32Default adds float32 field whose value is v if it isn't set when value is encoded or inserted
+func (s *Struct) FieldFloat32Default(column string, v float32) *Struct {
+	return s.FieldFloat32(column).setDefault(column, NewFloat32Value(v))
+}
+
+// FieldFloat64Default adds float64 field whose value is v if it isn't set when value is encoded or inserted
+func (s *Struct) FieldFloat64Default(column string, v float64) *Struct {
+	return s.FieldFloat64(column).setDefault(column, NewFloat64Value(v))
+}
+
+// FieldBoolDefault adds bool field whose value is v if it isn't set when value is encoded or inserted
+func (s *Struct) FieldBoolDefault(column string, v bool) *Struct {
+	return s.FieldBool(column).setDefault(column, NewBoolValue(v))
+}
+
+// FieldStringDefault adds string field whose value is v if it isn't set when value is encoded or inserted
+func (s *Struct) FieldStringDefault(column string, v string) *Struct {
+	return s.FieldString(column).setDefault(column, NewStringValue(v))
+}
+
+// FieldBytesDefault adds bytes field whose value is v if it isn't set when value is encoded or inserted
+func (s *Struct) FieldBytesDefault(column string, v []byte) *Struct {
+	return s.FieldBytes(column).setDefault(column, NewBytesValue(v))
+}
+
+// FieldTimeDefault adds time field whose value is v if it isn't set when value is encoded or inserted
+func (s *Struct) FieldTimeDefault(column string, v time.Time) *Struct {
+	return s.FieldTime(column).setDefault(column, NewTimeValue(v))
+}
+
+// setDefault keeps value created without ValueFactory, because values in StructValue are released to the pool
+func (s *Struct) setDefault(column string, v *Value) *Struct {
+	s.fields[column].defaultValue = v
+	return s
+}
+
+// DefaultValue returns default value of column. nil is returned if default isn't declared
+func (s *Struct) DefaultValue(column string) *Value {
+	field, exists := s.fields[s.columnName(column)]
+	if !exists {
+		return nil
+	}
+	return field.defaultValue
+}
+
+// encodeDefault encodes declared default value, or zero value of the type if default isn't declared
+func (f *StructField) encodeDefault(enc *msgpack.Encoder) error {
+	if f.defaultValue == nil {
+		return encodeDefaultValue(f.typ, enc)
+	}
+	if err := f.defaultValue.encode(enc); err != nil {
+		return xerrors.Errorf("failed to encode default value of %s: %w", f.column, err)
+	}
+	return nil
+}
+
+// insertValue returns value inserted to database for omitted column
+func (f *StructField) insertValue() interface{} {
+	if f.defaultValue == nil {
+		return nil
+	}
+	return f.defaultValue.RawValue()
+}
@@ -0,0 +1,54 @@
+package rapidash
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestColumnDefault(t *testing.T) {
+	createdAt := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	typ := NewStruct("user_statuses").
+		FieldUint64("id").
+		FieldStringDefault("status", "active").
+		FieldIntDefault("level", 1).
+		FieldTimeDefault("created_at", createdAt).
+		FieldString("name")
+	Equal(t, typ.DefaultValue("status").RawValue(), "active")
+	if typ.DefaultValue("name") != nil {
+		t.Fatal("name has no default value")
+	}
+	merged := NewStruct("user_statuses").FieldUint64("id").Merge(typ)
+	Equal(t, merged.DefaultValue("status").RawValue(), "active")
+	factory := NewValueFactory()
+	value := &StructValue{typ: typ, fields: map[string]*Value{
+		"id":    factory.CreateUint64Value(1),
+		"level": factory.CreateIntValue(3),
+	}}
+
+	t.Run("encode", func(t *testing.T) {
+		content, err := value.encodeValue()
+		NoError(t, err)
+		decoder := NewDecoder(typ, &bytes.Buffer{}, factory)
+		decoder.SetBuffer(content)
+		decoded, err := decoder.Decode()
+		NoError(t, err)
+		Equal(t, decoded.String("status"), "active")
+		Equal(t, decoded.Int("level"), 3)
+		Equal(t, decoded.Time("created_at").Equal(createdAt), true)
+		Equal(t, decoded.String("name"), "")
+	})
+	t.Run("insert", func(t *testing.T) {
+		slc := NewSecondLevelCache(typ, nil, TableOption{})
+		_, args := slc.insertSQL(value)
+		columns := typ.Columns()
+		argByColumn := map[string]interface{}{}
+		for i, column := range columns {
+			argByColumn[column] = args[i]
+		}
+		Equal(t, argByColumn["status"], "active")
+		Equal(t, argByColumn["level"], 3)
+		Equal(t, argByColumn["created_at"], createdAt)
+		Equal(t, argByColumn["name"], nil)
+	})
+}
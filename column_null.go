@@ -0,0 +1,164 @@
+package rapidash
+
+import (
+	"github.com/blastrain/vitess-sqlparser/sqlparser"
+	"golang.org/x/xerrors"
+)
+
+// FieldIntNotNull adds int field that cannot be NULL
+func (s *Struct) FieldIntNotNull(column string) *Struct {
+	return s.FieldInt(column).setNotNull(column)
+}
+
+// FieldInt8NotNull adds int8 field that cannot be NULL
+func (s *Struct) FieldInt8NotNull(column string) *Struct {
+	return s.FieldInt8(column).setNotNull(column)
+}
+
+// FieldInt16NotNull adds int16 field that cannot be NULL
+func (s *Struct) FieldInt16NotNull(column string) *Struct {
+	return s.FieldInt16(column).setNotNull(column)
+}
+
+// FieldInt32NotNull adds int32 field that cannot be NULL
+func (s *Struct) FieldInt32NotNull(column string) *Struct {
+	return s.FieldInt32(column).setNotNull(column)
+}
+
+// FieldInt64NotNull adds int64 field that cannot be NULL
+func (s *Struct) FieldInt64NotNull(column string) *Struct {
+	return s.FieldInt64(column).setNotNull(column)
+}
+
+// FieldUintNotNull adds uint field that cannot be NULL
+func (s *Struct) FieldUintNotNull(column string) *Struct {
+	return s.FieldUint(column).setNotNull(column)
+}
+
+// FieldUint8NotNull adds uint8 field that cannot be NULL
+func (s *Struct) FieldUint8NotNull(column string) *Struct {
+	return s.FieldUint8(column).setNotNull(column)
+}
+
+// FieldUint16NotNull adds uint16 field that cannot be NULL
+func (s *Struct) FieldUint16NotNull(column string) *Struct {
+	return s.FieldUint16(column).setNotNull(column)
+}
+
+// FieldUint32NotNull adds uint32 field that cannot be NULL
+func (s *Struct) FieldUint32NotNull(column string) *Struct {
+	return s.FieldUint32(column).setNotNull(column)
+}
+
+// FieldUint64NotNull adds uint64 field that cannot be NULL
+func (s *Struct) FieldUint64NotNull(column string) *Struct {
+	return s.FieldUint64(column).setNotNull(column)
+}
+
+// FieldFloat32NotNull adds float32 field that cannot be NULL
+func (s *Struct) FieldFloat32NotNull(column string) *Struct {
+	return s.FieldFloat32(column).setNotNull(column)
+}
+
+// FieldFloat64NotNull adds float64 field that cannot be NULL
+func (s *Struct) FieldFloat64NotNull(column string) *Struct {
+	return s.FieldFloat64(column).setNotNull(column)
+}
+
+// FieldBoolNotNull adds bool field that cannot be NULL
+func (s *Struct) FieldBoolNotNull(column string) *Struct {
+	return s.FieldBool(column).setNotNull(column)
+}
+
+// FieldStringNotNull adds string field that cannot be NULL
+func (s *Struct) FieldStringNotNull(column string) *Struct {
+	return s.FieldString(column).setNotNull(column)
+}
+
+// FieldBytesNotNull adds bytes field that cannot be NULL
+func (s *Struct) FieldBytesNotNull(column string) *Struct {
+	return s.FieldBytes(column).setNotNull(column)
+}
+
+// FieldTimeNotNull adds time field that cannot be NULL
+func (s *Struct) FieldTimeNotNull(column string) *Struct {
+	return s.FieldTime(column).setNotNull(column)
+}
+
+func (s *Struct) setNotNull(column string) *Struct {
+	s.fields[column].notNull = true
+	return s
+}
+
+// IsNullable returns false if column is declared as NOT NULL by FieldXXXNotNull or DDL of warmed up table
+func (s *Struct) IsNullable(column string) bool {
+	field, exists := s.fields[s.columnName(column)]
+	if !exists {
+		return true
+	}
+	return !field.notNull
+}
+
+// validateNotNull returns ErrNullConstraint if value sets NULL to NOT NULL column.
+// Omitted column is also NULL in INSERT unless default value is declared
+func (s *Struct) validateNotNull(value *StructValue) error {
+	for _, field := range s.sortedFields() {
+		if !field.notNull {
+			continue
+		}
+		v := value.fields[field.column]
+		if v == nil && field.defaultValue != nil {
+			continue
+		}
+		if v == nil || v.IsNil {
+			return xerrors.Errorf("%s.%s: %w", s.tableName, field.column, ErrNullConstraint)
+		}
+	}
+	return nil
+}
+
+func (c *SecondLevelCache) validateNotNullUpdateMap(updateMap map[string]interface{}) error {
+	for column, v := range updateMap {
+		field, exists := c.typ.fields[column]
+		if !exists || !field.notNull {
+			continue
+		}
+		if value := c.valueFactory.CreateValue(v); value != nil && value.IsNil {
+			return xerrors.Errorf("%s.%s: %w", c.typ.tableName, column, ErrNullConstraint)
+		}
+	}
+	return nil
+}
+
+// setupNotNull marks fields of NOT NULL columns in ddl.
+// AUTO_INCREMENT column is skipped because NULL is replaced by generated value
+func (c *SecondLevelCache) setupNotNull(ddl string) error {
+	stmt, err := sqlparser.Parse(ddl)
+	if err != nil {
+		return xerrors.Errorf("cannot parse ddl %s: %w", ddl, err)
+	}
+	createTable, ok := stmt.(*sqlparser.CreateTable)
+	if !ok {
+		return xerrors.Errorf("%s is not CREATE TABLE statement", ddl)
+	}
+	for _, column := range createTable.Columns {
+		field, exists := c.typ.fields[column.Name]
+		if !exists {
+			continue
+		}
+		notNull := false
+		autoIncrement := false
+		for _, option := range column.Options {
+			switch option.Type {
+			case sqlparser.ColumnOptionNotNull, sqlparser.ColumnOptionPrimaryKey:
+				notNull = true
+			case sqlparser.ColumnOptionAutoIncrement:
+				autoIncrement = true
+			}
+		}
+		if notNull && !autoIncrement {
+			field.notNull = true
+		}
+	}
+	return nil
+}
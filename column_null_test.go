@@ -0,0 +1,56 @@
+package rapidash
+
+import (
+	"testing"
+
+	"golang.org/x/xerrors"
+)
+
+func TestColumnNotNull(t *testing.T) {
+	t.Run("field", func(t *testing.T) {
+		typ := NewStruct("user_statuses").
+			FieldUint64NotNull("id").
+			FieldStringNotNull("status").
+			FieldIntDefault("level", 1).
+			FieldString("name")
+		Equal(t, typ.IsNullable("id"), false)
+		Equal(t, typ.IsNullable("name"), true)
+		merged := NewStruct("user_statuses").FieldUint64("id").Merge(typ)
+		Equal(t, merged.IsNullable("status"), false)
+		Equal(t, merged.IsNullable("name"), true)
+		typ.setNotNull("level")
+		factory := NewValueFactory()
+		value := &StructValue{typ: typ, fields: map[string]*Value{
+			"id":     factory.CreateUint64Value(1),
+			"status": factory.CreateStringValue("active"),
+		}}
+		NoError(t, typ.validateNotNull(value))
+		value.fields["status"] = nilValue
+		err := typ.validateNotNull(value)
+		Error(t, err)
+		Equal(t, xerrors.Is(err, ErrNullConstraint), true)
+		delete(value.fields, "status")
+		Error(t, typ.validateNotNull(value))
+	})
+	t.Run("ddl", func(t *testing.T) {
+		slc := NewSecondLevelCache(userLoginType(), newMemoryCacheServer(), TableOption{})
+		ddl := "CREATE TABLE `user_logins` (" +
+			"`id` bigint(20) unsigned NOT NULL AUTO_INCREMENT," +
+			"`user_id` bigint(20) unsigned NOT NULL," +
+			"`user_session_id` bigint(20) unsigned NOT NULL," +
+			"`login_param_id` bigint(20) unsigned NOT NULL," +
+			"`name` varchar(255) DEFAULT NULL," +
+			"`created_at` datetime NOT NULL," +
+			"`updated_at` datetime NOT NULL," +
+			"PRIMARY KEY (`id`)" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"
+		NoError(t, slc.setupNotNull(ddl))
+		Equal(t, slc.typ.IsNullable("id"), true)
+		Equal(t, slc.typ.IsNullable("user_id"), false)
+		Equal(t, slc.typ.IsNullable("name"), true)
+		Error(t, slc.validateNotNullUpdateMap(map[string]interface{}{"user_id": nil}))
+		var userID *uint64
+		Error(t, slc.validateNotNullUpdateMap(map[string]interface{}{"user_id": userID}))
+		NoError(t, slc.validateNotNullUpdateMap(map[string]interface{}{"name": nil, "user_id": uint64(1)}))
+	})
+}
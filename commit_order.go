@@ -0,0 +1,97 @@
+package rapidash
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+type CommitOrderType int
+
+const (
+	// CommitOrderDBFirst commits database then cache ( default ).
+	// If process crashes between them, SLC keeps old values until expiration ( use InvalidationOutbox to recover )
+	CommitOrderDBFirst CommitOrderType = iota
+	// CommitOrderCacheFirst commits cache then database.
+	// If database commit fails, SLC has values that don't exist in database until expiration
+	CommitOrderCacheFirst
+	// CommitOrderDeleteCacheBeforeAndAfter deletes SLC keys before database commit, commits database and cache,
+	// then deletes them again after DoubleDeleteDelay.
+	// Old values cached by concurrent readers between the first delete and database commit live at most for the delay
+	CommitOrderDeleteCacheBeforeAndAfter
+)
+
+const defaultDoubleDeleteDelay = 500 * time.Millisecond
+
+func (typ CommitOrderType) String() string {
+	switch typ {
+	case CommitOrderDBFirst:
+		return "db_first"
+	case CommitOrderCacheFirst:
+		return "cache_first"
+	case CommitOrderDeleteCacheBeforeAndAfter:
+		return "delete_cache_before_and_after"
+	}
+	return ""
+}
+
+// modifiedSecondLevelCacheKeys returns SLC keys written by transaction
+func (tx *Tx) modifiedSecondLevelCacheKeys() []server.CacheKey {
+	keys := []server.CacheKey{}
+	for _, key := range tx.sortedPendingQueryKeys() {
+		query := tx.pendingQueries[key]
+		if query.key == nil || query.Type != server.CacheKeyTypeSLC {
+			continue
+		}
+		keys = append(keys, query.key)
+	}
+	return keys
+}
+
+func (tx *Tx) deleteModifiedKeys(ctx context.Context, keys []server.CacheKey) error {
+	for _, key := range keys {
+		if err := tx.r.cacheServer.Delete(ctx, key); err != nil && !IsCacheMiss(err) {
+			return xerrors.Errorf("failed to delete %s: %w", key, err)
+		}
+		tx.r.evictLocalCache(key)
+		tx.logger().Delete(tx.id, SLCServer, key)
+		// value of the key is going to be replaced without cas because it was deleted by itself
+		delete(tx.stash.casIDs, key.String())
+	}
+	return nil
+}
+
+func (tx *Tx) deleteModifiedKeysAfter(keys []server.CacheKey, delay time.Duration) {
+	if len(keys) == 0 {
+		return
+	}
+	r := tx.r
+	time.AfterFunc(delay, func() {
+		for _, key := range keys {
+			if err := r.cacheServer.Delete(context.Background(), key); err != nil && !IsCacheMiss(err) {
+				log.Warn(fmt.Sprintf("failed to delete %s after commit: %+v", key, err))
+				continue
+			}
+			r.evictLocalCache(key)
+			log.Delete("", SLCServer, key)
+		}
+	})
+}
+
+func (tx *Tx) commitByDeleteCacheBeforeAndAfter(ctx context.Context) error {
+	keys := tx.modifiedSecondLevelCacheKeys()
+	if err := tx.deleteModifiedKeys(ctx, keys); err != nil {
+		return xerrors.Errorf("failed to delete keys before commit: %w", err)
+	}
+	if err := tx.commitDB(ctx); err != nil {
+		return xerrors.Errorf("failed to Commit for database: %w", err)
+	}
+	defer tx.deleteModifiedKeysAfter(keys, tx.r.opt.doubleDeleteDelay)
+	if err := tx.commitCache(ctx); err != nil {
+		return xerrors.Errorf("failed to Commit for cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package rapidash
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCommitOrder(t *testing.T) {
+	NoError(t, initUserLoginTable(conn))
+	NoError(t, initCache(conn, CacheServerTypeMemcached))
+	defer func() {
+		cache.opt.commitOrder = CommitOrderDBFirst
+		cache.opt.doubleDeleteDelay = defaultDoubleDeleteDelay
+	}()
+	update := func(name string) *Tx {
+		txConn, err := conn.Begin()
+		NoError(t, err)
+		tx, err := cache.Begin(txConn)
+		NoError(t, err)
+		builder := NewQueryBuilder("user_logins").Eq("id", uint64(1))
+		NoError(t, tx.UpdateByQueryBuilder(builder, map[string]interface{}{"name": name}))
+		return tx
+	}
+	find := func() *UserLogin {
+		tx, err := cache.Begin(conn)
+		NoError(t, err)
+		var userLogin UserLogin
+		NoError(t, tx.FindByQueryBuilder(NewQueryBuilder("user_logins").Eq("id", uint64(1)), &userLogin))
+		NoError(t, tx.Commit())
+		return &userLogin
+	}
+	t.Run("cache first", func(t *testing.T) {
+		cache.opt.commitOrder = CommitOrderCacheFirst
+		NoError(t, update("cache_first").Commit())
+		Equal(t, find().Name, "cache_first")
+	})
+	t.Run("delete cache before and after", func(t *testing.T) {
+		cache.opt.commitOrder = CommitOrderDeleteCacheBeforeAndAfter
+		cache.opt.doubleDeleteDelay = 10 * time.Millisecond
+		tx := update("double_delete")
+		keys := tx.modifiedSecondLevelCacheKeys()
+		if len(keys) == 0 {
+			t.Fatal("modified keys must be found")
+		}
+		NoError(t, tx.Commit())
+		_, err := cache.cacheServer.Get(context.Background(), keys[0])
+		NoError(t, err)
+		time.Sleep(100 * time.Millisecond)
+		if _, err := cache.cacheServer.Get(context.Background(), keys[0]); !IsCacheMiss(err) {
+			t.Fatalf("key must be deleted after delay. err = %+v", err)
+		}
+		Equal(t, find().Name, "double_delete")
+	})
+}
@@ -0,0 +1,70 @@
+package rapidash
+
+import (
+	"bytes"
+	"compress/flate"
+	"io/ioutil"
+
+	"golang.org/x/xerrors"
+)
+
+// Compression compresses cached value by payload codec registered with CodecID if its size is at least MinSize.
+// Compressed value is prefixed by payload marker and CodecID, so values under MinSize and values written before enabling compression are still readable.
+// Codecs like snappy or zstd can be used by registering them with RegisterPayloadCodec
+type Compression struct {
+	CodecID uint8 `yaml:"codec_id"`
+	MinSize int   `yaml:"min_size"`
+}
+
+// FlatePayloadCodec compresses payload by DEFLATE of standard library
+type FlatePayloadCodec struct {
+	Level int
+}
+
+// NewFlatePayloadCodec creates FlatePayloadCodec. level is the same as compress/flate
+func NewFlatePayloadCodec(level int) *FlatePayloadCodec {
+	return &FlatePayloadCodec{Level: level}
+}
+
+func (c *FlatePayloadCodec) Encode(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, c.Level)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create flate writer: %w", err)
+	}
+	if _, err := w.Write(src); err != nil {
+		return nil, xerrors.Errorf("failed to compress: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, xerrors.Errorf("failed to close flate writer: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *FlatePayloadCodec) Decode(src []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(src))
+	defer r.Close()
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decompress: %w", err)
+	}
+	return content, nil
+}
+
+// compress encodes content by codec of compression if content is large enough, otherwise by preferred codec
+func (p *payloadCodecs) compress(content []byte, compression *Compression) ([]byte, error) {
+	if compression == nil || len(content) < compression.MinSize {
+		return p.encode(content)
+	}
+	codec, exists := p.codecs[compression.CodecID]
+	if !exists {
+		return nil, xerrors.Errorf("codec id %d: %w", compression.CodecID, ErrUnknownPayloadCodec)
+	}
+	compressed, err := codec.Encode(content)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to compress payload by codec %d: %w", compression.CodecID, err)
+	}
+	payload := make([]byte, 0, len(compressed)+2)
+	payload = append(payload, payloadMarker, compression.CodecID)
+	return append(payload, compressed...), nil
+}
@@ -0,0 +1,58 @@
+package rapidash
+
+import (
+	"bytes"
+	"compress/flate"
+	"testing"
+)
+
+func TestCompression(t *testing.T) {
+	r, err := New(
+		RegisterPayloadCodec(1, NewFlatePayloadCodec(flate.BestSpeed)),
+		SecondLevelCacheTableCompression("user_logins", 1, 64),
+		LastLevelCacheCompression(1, 64),
+	)
+	NoError(t, err)
+	slc := NewSecondLevelCache(userLoginType(), nil, r.tableOption("user_logins"))
+	t.Run("small value isn't compressed", func(t *testing.T) {
+		content := []byte{0x92, 0x01, 0x02}
+		payload, err := slc.encodePayload(content)
+		NoError(t, err)
+		Equal(t, payload, content)
+	})
+	t.Run("large value is compressed", func(t *testing.T) {
+		content := bytes.Repeat([]byte("rapidash"), 128)
+		payload, err := slc.encodePayload(content)
+		NoError(t, err)
+		Equal(t, payload[:2], []byte{payloadMarker, 1})
+		if len(payload) >= len(content) {
+			t.Fatalf("payload is not compressed: %d bytes", len(payload))
+		}
+		decoded, err := slc.opt.payloadCodecs.decode(payload)
+		NoError(t, err)
+		if !bytes.Equal(decoded, content) {
+			t.Fatal("failed to decompress payload")
+		}
+	})
+	t.Run("last level cache", func(t *testing.T) {
+		content := bytes.Repeat([]byte("rapidash"), 128)
+		payload, err := r.opt.llcOpt.payloadCodecs.compress(content, r.opt.llcOpt.compression)
+		NoError(t, err)
+		Equal(t, payload[:2], []byte{payloadMarker, 1})
+	})
+	t.Run("last level cache stash by update with version", func(t *testing.T) {
+		cacheServer := newMemoryCacheServer()
+		r.cacheServer = cacheServer
+		r.lastLevelCache = NewLastLevelCache(cacheServer, r.opt.llcOpt)
+		content := bytes.Repeat([]byte("rapidash"), 128)
+		tx, err := r.Begin()
+		NoError(t, err)
+		NoError(t, tx.UpdateWithVersion("compressed", Bytes(content), 1))
+		var found []byte
+		NoError(t, tx.Find("compressed", BytesPtr(&found)))
+		if !bytes.Equal(found, content) {
+			t.Fatal("failed to find stashed value")
+		}
+		NoError(t, tx.Commit())
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"time"
 
+	"go.knocknote.io/rapidash/server"
 	"golang.org/x/xerrors"
 	"gopkg.in/yaml.v2"
 )
@@ -21,6 +22,15 @@ type RuleConfig struct {
 	CacheControl      *CacheControlConfig `yaml:"cache_control"`
 	Timeout           *int                `yaml:"timeout"`
 	MaxIdleConnection *int                `yaml:"max_idle_connection"`
+	CommitOrder       *string             `yaml:"commit_order"`
+	DoubleDeleteDelay *time.Duration      `yaml:"double_delete_delay"`
+	CacheKeyHash      *string             `yaml:"cache_key_hash"`
+	ServerRetry       *ServerRetryConfig  `yaml:"server_retry"`
+	LockOwner         *string             `yaml:"lock_owner"`
+	GetMulti          *GetMultiConfig     `yaml:"get_multi"`
+	CacheServer       *string             `yaml:"cache_server"`
+	RedisCluster      *[]string           `yaml:"redis_cluster"`
+	RedisCAS          *bool               `yaml:"redis_cas"`
 }
 
 type LoggerConfig struct {
@@ -33,39 +43,89 @@ type RetryConfig struct {
 	Interval *time.Duration `yaml:"interval"`
 }
 
+type ServerRetryConfig struct {
+	Attempts   *int           `yaml:"attempts"`
+	Backoff    *time.Duration `yaml:"backoff"`
+	MaxBackoff *time.Duration `yaml:"max_backoff"`
+}
+
+type GetMultiConfig struct {
+	MaxKeys  *int `yaml:"max_keys"`
+	MaxBytes *int `yaml:"max_bytes"`
+}
+
 type CacheControlConfig struct {
 	OptimisticLock  *bool `yaml:"optimistic_lock"`
 	PessimisticLock *bool `yaml:"pessimistic_lock"`
 }
 
 type SLCConfig struct {
-	Servers        *[]string                `yaml:"servers"`
-	Tables         *map[string]*TableConfig `yaml:"tables"`
-	Expiration     *time.Duration           `yaml:"expiration"`
-	LockExpiration *time.Duration           `yaml:"lock_expiration"`
+	Servers          *[]string                `yaml:"servers"`
+	Tables           *map[string]*TableConfig `yaml:"tables"`
+	Expiration       *time.Duration           `yaml:"expiration"`
+	LockExpiration   *time.Duration           `yaml:"lock_expiration"`
+	INChunkSize      *int                     `yaml:"in_chunk_size"`
+	SessionVariables *map[string]string       `yaml:"session_variables"`
 }
 
 type TableConfig struct {
-	ShardKey       *string             `yaml:"shard_key"`
-	Server         *string             `yaml:"server"`
-	CacheControl   *CacheControlConfig `yaml:"cache_control"`
-	Expiration     *time.Duration      `yaml:"expiration"`
-	LockExpiration *time.Duration      `yaml:"lock_expiration"`
+	ShardKey         *string             `yaml:"shard_key"`
+	Server           *string             `yaml:"server"`
+	CacheControl     *CacheControlConfig `yaml:"cache_control"`
+	Expiration       *time.Duration      `yaml:"expiration"`
+	LockExpiration   *time.Duration      `yaml:"lock_expiration"`
+	ClusterKey       *string             `yaml:"cluster_key"`
+	ExpirationColumn *string             `yaml:"expiration_column"`
+	KeyRegistrySize  *int                `yaml:"key_registry_size"`
+	DefaultOrder     *[]*OrderConfig     `yaml:"default_order"`
+	INChunkSize      *int                `yaml:"in_chunk_size"`
+	DisableStash     *bool               `yaml:"disable_stash"`
+	SessionVariables *map[string]string  `yaml:"session_variables"`
+	ExcludeIndexes   *[]string           `yaml:"exclude_indexes"`
+	LowSelectivity   *int                `yaml:"low_selectivity_threshold"`
+	IgnoreIndexes    *[]string           `yaml:"ignore_indexes"`
+	IgnoreColumns    *[]string           `yaml:"ignore_index_columns"`
+	RevalidateAfter  *time.Duration      `yaml:"revalidate_after"`
+	ShadowRead       *bool               `yaml:"shadow_read"`
+	TTLTuning        *TTLTuning          `yaml:"ttl_tuning"`
+	MissingPKPolicy  *string             `yaml:"missing_primary_key_policy"`
+	FillLockPolicy   *string             `yaml:"fill_lock_policy"`
+	FillLockExpiry   *time.Duration      `yaml:"fill_lock_expiration"`
+	WriteThrough     *bool               `yaml:"write_through_on_create"`
+	StrictTypes      *bool               `yaml:"strict_types"`
+	ValidateSchema   *bool               `yaml:"validate_schema"`
+	SchemaHashKey    *bool               `yaml:"schema_hash_key"`
+	NoUniqPrefix     *[]string           `yaml:"disable_unique_prefix_keys"`
+	LocalCache       *LocalCacheSize     `yaml:"local_cache"`
+	CountCache       *bool               `yaml:"count_cache"`
+	PageCache        *bool               `yaml:"page_cache"`
+	CompactPKs       *bool               `yaml:"compact_primary_keys"`
+	Codec            *string             `yaml:"codec"`
+	Compression      *Compression        `yaml:"compression"`
+}
+
+type OrderConfig struct {
+	Column string `yaml:"column"`
+	Desc   bool   `yaml:"desc"`
 }
 
 type LLCConfig struct {
-	Servers        *[]string
-	Tags           *map[string]*TagConfig `yaml:"tags"`
-	CacheControl   *CacheControlConfig    `yaml:"cache_control"`
-	Expiration     *time.Duration         `yaml:"expiration"`
-	LockExpiration *time.Duration         `yaml:"lock_expiration"`
+	Servers                 *[]string
+	Tags                    *map[string]*TagConfig `yaml:"tags"`
+	CacheControl            *CacheControlConfig    `yaml:"cache_control"`
+	Expiration              *time.Duration         `yaml:"expiration"`
+	LockExpiration          *time.Duration         `yaml:"lock_expiration"`
+	NegativeCacheExpiration *time.Duration         `yaml:"negative_cache_expiration"`
+	NegativeCacheMaxEntries *int                   `yaml:"negative_cache_max_entries"`
+	Compression             *Compression           `yaml:"compression"`
 }
 
 type TagConfig struct {
-	Server         *string             `yaml:"server"`
-	CacheControl   *CacheControlConfig `yaml:"cache_control"`
-	Expiration     *time.Duration      `yaml:"expiration"`
-	LockExpiration *time.Duration      `yaml:"lock_expiration"`
+	Server                  *string             `yaml:"server"`
+	CacheControl            *CacheControlConfig `yaml:"cache_control"`
+	Expiration              *time.Duration      `yaml:"expiration"`
+	LockExpiration          *time.Duration      `yaml:"lock_expiration"`
+	NegativeCacheExpiration *time.Duration      `yaml:"negative_cache_expiration"`
 }
 
 func NewConfig(path string) (*Config, error) {
@@ -93,6 +153,15 @@ func (cfg *RuleConfig) Options() []OptionFunc {
 	if cfg.Servers != nil {
 		opts = append(opts, ServerAddrs(*cfg.Servers))
 	}
+	if cfg.CacheServer != nil {
+		opts = append(opts, CacheServerName(*cfg.CacheServer))
+	}
+	if cfg.RedisCluster != nil {
+		opts = append(opts, RedisClusterAddrs(*cfg.RedisCluster))
+	}
+	if cfg.RedisCAS != nil {
+		opts = append(opts, RedisCompareAndSwap(*cfg.RedisCAS))
+	}
 	if cfg.MaxIdleConnection != nil {
 		opts = append(opts, MaxIdleConnections(*cfg.MaxIdleConnection))
 	}
@@ -100,6 +169,32 @@ func (cfg *RuleConfig) Options() []OptionFunc {
 	opts = append(opts, cfg.Retry.Options()...)
 	opts = append(opts, cfg.CacheControl.SLCOptions()...)
 	opts = append(opts, cfg.CacheControl.LLCOptions()...)
+	if cfg.CommitOrder != nil {
+		for _, order := range []CommitOrderType{CommitOrderDBFirst, CommitOrderCacheFirst, CommitOrderDeleteCacheBeforeAndAfter} {
+			if order.String() == *cfg.CommitOrder {
+				opts = append(opts, CommitOrder(order))
+			}
+		}
+	}
+	if cfg.DoubleDeleteDelay != nil {
+		opts = append(opts, DoubleDeleteDelay(*cfg.DoubleDeleteDelay))
+	}
+	if cfg.CacheKeyHash != nil {
+		for _, typ := range []CacheKeyHashType{CacheKeyHashCRC32, CacheKeyHashXXHash64, CacheKeyHashFNV1a} {
+			if typ.String() == *cfg.CacheKeyHash {
+				opts = append(opts, CacheKeyHash(typ))
+			}
+		}
+	}
+	if cfg.ServerRetry != nil {
+		opts = append(opts, cfg.ServerRetry.Options()...)
+	}
+	if cfg.LockOwner != nil {
+		opts = append(opts, LockOwner(*cfg.LockOwner))
+	}
+	if cfg.GetMulti != nil {
+		opts = append(opts, cfg.GetMulti.Options()...)
+	}
 	return opts
 }
 
@@ -121,6 +216,32 @@ func (cfg *LoggerConfig) Options() []OptionFunc {
 	return opts
 }
 
+func (cfg *ServerRetryConfig) Options() []OptionFunc {
+	if cfg.Attempts == nil {
+		return nil
+	}
+	policy := server.RetryPolicy{Attempts: *cfg.Attempts}
+	if cfg.Backoff != nil {
+		maxBackoff := *cfg.Backoff
+		if cfg.MaxBackoff != nil {
+			maxBackoff = *cfg.MaxBackoff
+		}
+		policy.Backoff = server.ExponentialBackoff(*cfg.Backoff, maxBackoff)
+	}
+	return []OptionFunc{CacheServerRetryPolicy(policy)}
+}
+
+func (cfg *GetMultiConfig) Options() []OptionFunc {
+	policy := server.GetMultiBatchPolicy{}
+	if cfg.MaxKeys != nil {
+		policy.MaxKeys = *cfg.MaxKeys
+	}
+	if cfg.MaxBytes != nil {
+		policy.MaxBytes = *cfg.MaxBytes
+	}
+	return []OptionFunc{CacheServerGetMultiBatch(policy)}
+}
+
 func (cfg *RetryConfig) Options() []OptionFunc {
 	opts := []OptionFunc{}
 	if cfg.Limit != nil {
@@ -192,6 +313,12 @@ func (cfg *SLCConfig) Options() []OptionFunc {
 	if cfg.LockExpiration != nil {
 		opts = append(opts, SecondLevelCacheLockExpiration(*cfg.LockExpiration))
 	}
+	if cfg.INChunkSize != nil {
+		opts = append(opts, SecondLevelCacheINChunkSize(*cfg.INChunkSize))
+	}
+	if cfg.SessionVariables != nil {
+		opts = append(opts, SecondLevelCacheSessionVariables(*cfg.SessionVariables))
+	}
 	return opts
 }
 
@@ -212,6 +339,105 @@ func (cfg *TableConfig) Options(table string) []OptionFunc {
 	if cfg.CacheControl != nil {
 		opts = append(opts, cfg.CacheControl.TableOptions(table)...)
 	}
+	if cfg.ClusterKey != nil {
+		opts = append(opts, SecondLevelCacheTableClusterKey(table, *cfg.ClusterKey))
+	}
+	if cfg.ExpirationColumn != nil {
+		opts = append(opts, SecondLevelCacheTableExpirationColumn(table, *cfg.ExpirationColumn))
+	}
+	if cfg.KeyRegistrySize != nil {
+		opts = append(opts, SecondLevelCacheTableKeyRegistry(table, *cfg.KeyRegistrySize))
+	}
+	if cfg.DefaultOrder != nil {
+		for _, order := range *cfg.DefaultOrder {
+			opts = append(opts, SecondLevelCacheTableDefaultOrder(table, order.Column, !order.Desc))
+		}
+	}
+	if cfg.INChunkSize != nil {
+		opts = append(opts, SecondLevelCacheTableINChunkSize(table, *cfg.INChunkSize))
+	}
+	if cfg.DisableStash != nil {
+		opts = append(opts, SecondLevelCacheTableDisableStash(table, *cfg.DisableStash))
+	}
+	if cfg.SessionVariables != nil {
+		opts = append(opts, SecondLevelCacheTableSessionVariables(table, *cfg.SessionVariables))
+	}
+	if cfg.ExcludeIndexes != nil {
+		opts = append(opts, SecondLevelCacheTableExcludeIndexes(table, *cfg.ExcludeIndexes...))
+	}
+	if cfg.LowSelectivity != nil {
+		opts = append(opts, SecondLevelCacheTableLowSelectivityThreshold(table, *cfg.LowSelectivity))
+	}
+	if cfg.IgnoreIndexes != nil {
+		opts = append(opts, SecondLevelCacheTableIgnoreIndex(table, *cfg.IgnoreIndexes...))
+	}
+	if cfg.IgnoreColumns != nil {
+		opts = append(opts, SecondLevelCacheTableIgnoreIndexColumns(table, *cfg.IgnoreColumns...))
+	}
+	if cfg.RevalidateAfter != nil {
+		opts = append(opts, SecondLevelCacheTableRevalidateAfter(table, *cfg.RevalidateAfter))
+	}
+	if cfg.ShadowRead != nil {
+		opts = append(opts, SecondLevelCacheTableShadowRead(table, *cfg.ShadowRead))
+	}
+	if cfg.TTLTuning != nil {
+		opts = append(opts, SecondLevelCacheTableTTLTuning(table, *cfg.TTLTuning))
+	}
+	if cfg.MissingPKPolicy != nil {
+		for _, policy := range []MissingPrimaryKeyPolicy{MissingPrimaryKeyPolicyRefetchIndex, MissingPrimaryKeyPolicyRefetchPrimaryKeys} {
+			if policy.String() == *cfg.MissingPKPolicy {
+				opts = append(opts, SecondLevelCacheTableMissingPrimaryKeyPolicy(table, policy))
+			}
+		}
+	}
+	if cfg.FillLockPolicy != nil {
+		for _, policy := range []FillLockPolicy{FillLockPolicyLock, FillLockPolicySkip, FillLockPolicyShortLock} {
+			if policy.String() == *cfg.FillLockPolicy {
+				opts = append(opts, SecondLevelCacheTableFillLockPolicy(table, policy))
+			}
+		}
+	}
+	if cfg.FillLockExpiry != nil {
+		opts = append(opts, SecondLevelCacheTableFillLockExpiration(table, *cfg.FillLockExpiry))
+	}
+	if cfg.WriteThrough != nil {
+		opts = append(opts, SecondLevelCacheTableWriteThroughOnCreate(table, *cfg.WriteThrough))
+	}
+	if cfg.StrictTypes != nil {
+		opts = append(opts, SecondLevelCacheTableStrictTypes(table, *cfg.StrictTypes))
+	}
+	if cfg.ValidateSchema != nil {
+		opts = append(opts, SecondLevelCacheTableValidateSchema(table, *cfg.ValidateSchema))
+	}
+	if cfg.SchemaHashKey != nil {
+		opts = append(opts, SecondLevelCacheTableSchemaHashKey(table, *cfg.SchemaHashKey))
+	}
+	if cfg.NoUniqPrefix != nil {
+		opts = append(opts, SecondLevelCacheTableDisableUniquePrefixKeys(table, *cfg.NoUniqPrefix...))
+	}
+	if cfg.LocalCache != nil {
+		opts = append(opts, SecondLevelCacheTableLocalCache(table, *cfg.LocalCache))
+	}
+	if cfg.CountCache != nil {
+		opts = append(opts, SecondLevelCacheTableCountCache(table, *cfg.CountCache))
+	}
+	if cfg.PageCache != nil {
+		opts = append(opts, SecondLevelCacheTablePageCache(table, *cfg.PageCache))
+	}
+	if cfg.CompactPKs != nil {
+		opts = append(opts, SecondLevelCacheTableCompactPrimaryKeys(table, *cfg.CompactPKs))
+	}
+	if cfg.Codec != nil {
+		switch *cfg.Codec {
+		case "msgpack":
+			opts = append(opts, SecondLevelCacheTableCodec(table, MsgpackCodec{}))
+		case "json":
+			opts = append(opts, SecondLevelCacheTableCodec(table, JSONCodec{}))
+		}
+	}
+	if cfg.Compression != nil {
+		opts = append(opts, SecondLevelCacheTableCompression(table, cfg.Compression.CodecID, cfg.Compression.MinSize))
+	}
 	return opts
 }
 
@@ -231,6 +457,15 @@ func (cfg *LLCConfig) Options() []OptionFunc {
 	if cfg.LockExpiration != nil {
 		opts = append(opts, LastLevelCacheLockExpiration(*cfg.LockExpiration))
 	}
+	if cfg.NegativeCacheExpiration != nil {
+		opts = append(opts, LastLevelCacheNegativeCacheExpiration(*cfg.NegativeCacheExpiration))
+	}
+	if cfg.NegativeCacheMaxEntries != nil {
+		opts = append(opts, LastLevelCacheNegativeCacheMaxEntries(*cfg.NegativeCacheMaxEntries))
+	}
+	if cfg.Compression != nil {
+		opts = append(opts, LastLevelCacheCompression(cfg.Compression.CodecID, cfg.Compression.MinSize))
+	}
 	if cfg.CacheControl != nil {
 		opts = append(opts, cfg.CacheControl.LLCOptions()...)
 	}
@@ -248,6 +483,9 @@ func (cfg *TagConfig) Options(tag string) []OptionFunc {
 	if cfg.LockExpiration != nil {
 		opts = append(opts, LastLevelCacheTagLockExpiration(tag, *cfg.LockExpiration))
 	}
+	if cfg.NegativeCacheExpiration != nil {
+		opts = append(opts, LastLevelCacheTagNegativeCacheExpiration(tag, *cfg.NegativeCacheExpiration))
+	}
 	if cfg.CacheControl != nil {
 		opts = append(opts, cfg.CacheControl.TagOptions(tag)...)
 	}
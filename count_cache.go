@@ -0,0 +1,342 @@
+package rapidash
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/blastrain/msgpack"
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+// isExactIndex returns true if records sharing a key of index are exactly records matched by EQ conditions of its columns.
+// time buckets and key transforms gather several values to one key, so they are not exact
+func (c *SecondLevelCache) isExactIndex(index *Index) bool {
+	if index.Type != IndexTypeKey {
+		return false
+	}
+	for _, column := range index.Columns {
+		if index.timeBucket(column) > 0 || index.keyTransforms[column] != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// countCacheIndex returns index whose columns are exactly columns of EQ conditions of builder.
+// nil is returned if count of builder cannot be cached
+func (c *SecondLevelCache) countCacheIndex(builder *QueryBuilder) *Index {
+	if !c.opt.CountCache() || c.cacheOnly {
+		return nil
+	}
+	if builder.limit != nil || builder.offset > 0 {
+		return nil
+	}
+	return c.indexByEQConditions(builder, c.isExactIndex)
+}
+
+// indexByEQConditions returns index accepted by filter whose columns are exactly columns of EQ conditions of builder
+func (c *SecondLevelCache) indexByEQConditions(builder *QueryBuilder, filter func(*Index) bool) *Index {
+	if builder.isIgnoreCache || builder.lockOpt != nil || builder.sqlCondition != nil || builder.inCondition != nil {
+		return nil
+	}
+	columns := map[string]struct{}{}
+	for _, condition := range builder.conditions.conditions {
+		eq, ok := condition.(*EQCondition)
+		if !ok || eq.rawValue == nil {
+			return nil
+		}
+		columns[eq.column] = struct{}{}
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+	for _, index := range c.indexes {
+		if len(index.Columns) != len(columns) || !filter(index) {
+			continue
+		}
+		matched := true
+		for _, column := range index.Columns {
+			if _, exists := columns[column]; !exists {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return index
+		}
+	}
+	return nil
+}
+
+// countCacheKey returns key of count for values of index columns. false is returned if some values are unknown
+func (c *SecondLevelCache) countCacheKey(index *Index, values map[string]*Value) (server.CacheKey, bool) {
+	subKeys := make([]string, 0, len(index.Columns))
+	for _, column := range index.Columns {
+		v := values[column]
+		if v == nil || v.IsNil {
+			return nil, false
+		}
+		subKeys = append(subKeys, index.createCacheQuery(column, v.String()))
+	}
+	key := fmt.Sprintf("r/slc/%s/cnt/%s", c.keyTableName(), strings.Join(subKeys, CacheKeyQueryDelimiter))
+	if shardKey := c.opt.ShardKey(); shardKey != "" && index.HasColumn(shardKey) {
+		return &CacheKey{key: key, hash: c.opt.cacheKeyHash.hashValue(values[shardKey])}, true
+	}
+	return &CacheKey{key: key, hash: c.opt.cacheKeyHash.hashString(key)}, true
+}
+
+func (c *SecondLevelCache) countCacheKeyByBuilder(index *Index, builder *QueryBuilder) (server.CacheKey, bool) {
+	values := map[string]*Value{}
+	for _, condition := range builder.conditions.conditions {
+		values[condition.Column()] = condition.Value()
+	}
+	return c.countCacheKey(index, values)
+}
+
+func encodeCount(count uint64) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := msgpack.NewEncoder(&buf).EncodeUint64(count); err != nil {
+		return nil, xerrors.Errorf("failed to encode count: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *SecondLevelCache) decodeCount(content []byte) (uint64, error) {
+	payload, err := c.decodePayload(content)
+	if err != nil {
+		return 0, xerrors.Errorf("failed to decode payload: %w", err)
+	}
+	var count uint64
+	if err := msgpack.NewDecoder(bytes.NewBuffer(payload)).DecodeUint64(&count); err != nil {
+		return 0, xerrors.Errorf("failed to decode count: %w", err)
+	}
+	return count, nil
+}
+
+func (c *SecondLevelCache) observeCountKey(hit bool) {
+	if c.opt.stats == nil {
+		return
+	}
+	if hit {
+		c.opt.stats.OnCacheHit(c.typ.tableName, countKeyIndex)
+	} else {
+		c.opt.stats.OnCacheMiss(c.typ.tableName, countKeyIndex)
+	}
+}
+
+// countByCountCache returns cached count of index key. On cache miss, count is read by SELECT COUNT(*) and cached
+func (c *SecondLevelCache) countByCountCache(ctx context.Context, tx *Tx, builder *QueryBuilder, index *Index) (uint64, error) {
+	builder.Build(c.valueFactory)
+	if err := builder.validateCondition(c.typ); err != nil {
+		return 0, xerrors.Errorf("invalid query: %w", err)
+	}
+	key, ok := c.countCacheKeyByBuilder(index, builder)
+	if !ok || tx.isOldKey(key.String()) {
+		count, err := c.countByQueryBuilderWithoutCache(ctx, tx, builder)
+		if err != nil {
+			return 0, xerrors.Errorf("failed to count by query builder without cache: %w", err)
+		}
+		return count, nil
+	}
+	content, err := c.cacheServer.Get(ctx, key)
+	if err != nil && !IsCacheMiss(err) {
+		return 0, xerrors.Errorf("failed to get count from server: %w", err)
+	}
+	if err == nil {
+		count, err := c.decodeCount(content.Value)
+		if err == nil {
+			tx.logger().Get(tx.id, SLCServer, key, LogString(strconv.FormatUint(count, 10)))
+			tx.stash.casIDs[key.String()] = content.CasID
+			builder.info.addCacheRows(1)
+			c.observeRead(1, 0)
+			c.observeCountKey(true)
+			return count, nil
+		}
+		// if failed to decode cached count, rebuild cache by database
+		atomic.AddUint64(&c.readRepairCount, 1)
+	}
+	builder.info.addMissedKeys(1)
+	c.observeRead(0, 1)
+	c.observeCountKey(false)
+	count, err := c.countByQueryBuilderWithoutCache(ctx, tx, builder)
+	if err != nil {
+		return 0, xerrors.Errorf("failed to count by query builder without cache: %w", err)
+	}
+	encoded, err := encodeCount(count)
+	if err != nil {
+		return 0, xerrors.Errorf("failed to encode count: %w", err)
+	}
+	if err := c.setFill(ctx, tx, key, encoded, LogString(strconv.FormatUint(count, 10))); err != nil {
+		return 0, xerrors.Errorf("failed to set count: %w", err)
+	}
+	return count, nil
+}
+
+func (c *SecondLevelCache) countByQueryBuilderWithoutCache(ctx context.Context, tx *Tx, builder *QueryBuilder) (count uint64, e error) {
+	sql, args := builder.CountSQL(c.valueFactory)
+	sql = c.fallbackQuery(sql)
+	queryStart := time.Now()
+	rows, err := tx.connection(c).QueryContext(ctx, sql, args...)
+	c.observeDBQuery(queryStart)
+	if err != nil {
+		return 0, xerrors.Errorf("failed sql %s %v: %w", sql, args, err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			e = xerrors.Errorf("failed to close rows: %w", err)
+		}
+	}()
+	if rows.Next() {
+		if err := rows.Scan(&count); err != nil {
+			return 0, xerrors.Errorf("failed to scan: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, xerrors.Errorf("failed to read rows: %w", err)
+	}
+	tx.logger().GetFromDB(tx.id, sql, args, LogString(strconv.FormatUint(count, 10)))
+	builder.info.addDBRows(1)
+	return count, nil
+}
+
+// adjustCountKeysByValue adds delta to cached counts of all countable index keys of value on commit
+func (c *SecondLevelCache) adjustCountKeysByValue(ctx context.Context, tx *Tx, value *StructValue, delta int64) error {
+	if !c.opt.CountCache() || value == nil {
+		return nil
+	}
+	for _, index := range c.indexes {
+		if !c.isExactIndex(index) {
+			continue
+		}
+		key, ok := c.countCacheKey(index, value.fields)
+		if !ok {
+			continue
+		}
+		if err := c.adjustCount(ctx, tx, key, delta); err != nil {
+			return xerrors.Errorf("failed to adjust count: %w", err)
+		}
+	}
+	return nil
+}
+
+// adjustCount adds delta to count of key on commit. If count isn't cached, nothing is done.
+// If count is filled or deleted in this transaction, it is deleted because base of delta is unknown.
+func (c *SecondLevelCache) adjustCount(ctx context.Context, tx *Tx, key server.CacheKey, delta int64) error {
+	keyStr := key.String()
+	tx.stash.oldKey[keyStr] = struct{}{}
+	_, adjusted := tx.stash.countDeltas[keyStr]
+	tx.stash.countDeltas[keyStr] += delta
+	if _, exists := tx.pendingQueries[keyStr]; exists {
+		if adjusted {
+			return nil
+		}
+		if err := c.delete(ctx, tx, key); err != nil {
+			return xerrors.Errorf("failed to delete count: %w", err)
+		}
+		return nil
+	}
+	if c.opt.PessimisticLock() {
+		if err := c.lockKey(ctx, tx, key); err != nil {
+			return xerrors.Errorf("failed to lock key: %w", err)
+		}
+	}
+	c.observeWrite()
+	tx.pendingQueries[keyStr] = &PendingQuery{
+		QueryLog: &QueryLog{
+			Command: string(SLCCommandUpdate),
+			Key:     keyStr,
+			Hash:    key.Hash(),
+			Type:    server.CacheKeyTypeSLC,
+		},
+		key: key,
+		fn: func(ctx context.Context) error {
+			c.evictLocalCache(key)
+			content, err := c.cacheServer.Get(ctx, key)
+			if IsCacheMiss(err) {
+				return nil
+			}
+			if err != nil {
+				return xerrors.Errorf("failed to get count: %w", err)
+			}
+			if content.CasID == 0 {
+				// without CAS ( e.g. redis without RedisCompareAndSwap ), rewriting count loses deltas of other processes
+				return c.deleteCount(ctx, tx, key)
+			}
+			delta := tx.stash.countDeltas[keyStr]
+			count, err := c.decodeCount(content.Value)
+			if err != nil || (delta < 0 && uint64(-delta) > count) {
+				return c.deleteCount(ctx, tx, key)
+			}
+			count = uint64(int64(count) + delta)
+			tx.logger().Update(tx.id, SLCServer, key, LogString(strconv.FormatUint(count, 10)))
+			value, err := encodeCount(count)
+			if err != nil {
+				return xerrors.Errorf("failed to encode count: %w", err)
+			}
+			value, err = c.encodePayload(value)
+			if err != nil {
+				return xerrors.Errorf("failed to encode payload: %w", err)
+			}
+			if err := c.cacheServer.Set(ctx, &server.CacheStoreRequest{
+				Key:        key,
+				Value:      value,
+				Expiration: c.expiration(),
+				CasID:      content.CasID,
+			}); err != nil {
+				// count is changed by other process at the same time
+				return c.deleteCount(ctx, tx, key)
+			}
+			c.registerKey(key)
+			return nil
+		},
+	}
+	return nil
+}
+
+func (c *SecondLevelCache) deleteCount(ctx context.Context, tx *Tx, key server.CacheKey) error {
+	tx.logger().Delete(tx.id, SLCServer, key)
+	if err := c.cacheServer.Delete(ctx, key); err != nil {
+		return xerrors.Errorf("failed to delete count: %w", err)
+	}
+	c.unregisterKey(key)
+	return nil
+}
+
+// deleteCountKeysByValue deletes counts of index keys of value whose columns are included in columns.
+// all counts of value are deleted if columns is nil
+func (c *SecondLevelCache) deleteCountKeysByValue(ctx context.Context, tx *Tx, value *StructValue, columns map[string]interface{}) error {
+	if !c.opt.CountCache() || value == nil {
+		return nil
+	}
+	for _, index := range c.indexes {
+		if !c.isExactIndex(index) || !isIndexUpdated(index, columns) {
+			continue
+		}
+		key, ok := c.countCacheKey(index, value.fields)
+		if !ok {
+			continue
+		}
+		if err := c.deleteOldKey(ctx, tx, key); err != nil {
+			return xerrors.Errorf("failed to delete old key: %w", err)
+		}
+	}
+	return nil
+}
+
+func isIndexUpdated(index *Index, columns map[string]interface{}) bool {
+	if columns == nil {
+		return true
+	}
+	for _, column := range index.Columns {
+		if _, exists := columns[column]; exists {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package rapidash
+
+import (
+	"context"
+	"testing"
+
+	"go.knocknote.io/rapidash/server"
+)
+
+// casCacheServer returns version of value as CasID and rejects Set by stale CasID
+type casCacheServer struct {
+	*memoryCacheServer
+	versions map[string]uint64
+}
+
+func newCASCacheServer() *casCacheServer {
+	return &casCacheServer{memoryCacheServer: newMemoryCacheServer(), versions: map[string]uint64{}}
+}
+
+func (s *casCacheServer) Get(ctx context.Context, key server.CacheKey) (*server.CacheGetResponse, error) {
+	content, err := s.memoryCacheServer.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	content.CasID = s.versions[key.String()] + 1
+	return content, nil
+}
+
+func (s *casCacheServer) Set(ctx context.Context, req *server.CacheStoreRequest) error {
+	if req.CasID != 0 && req.CasID != s.versions[req.Key.String()]+1 {
+		return server.ErrMemcacheCASConflict
+	}
+	s.versions[req.Key.String()]++
+	return s.memoryCacheServer.Set(ctx, req)
+}
+
+func TestCountCache(t *testing.T) {
+	r, err := New(
+		SecondLevelCacheTableCountCache("user_logins", true),
+		SecondLevelCacheTablePrimaryKeyGenerator("user_logins", NewSequencePrimaryKeyGenerator(100)),
+	)
+	NoError(t, err)
+	cacheServer := newCASCacheServer()
+	r.cacheServer = cacheServer
+	slc := NewSecondLevelCache(userLoginType(), cacheServer, r.tableOption("user_logins"))
+	slc.primaryKey = NewPrimaryKey(slc.opt, "user_logins", []string{"id"}, slc.typ)
+	slc.indexes["id"] = slc.primaryKey
+	slc.indexes["user_session_id"] = NewUniqueKey(slc.opt, "user_logins", []string{"user_session_id"}, slc.typ)
+	slc.indexes["user_id"] = NewKey(slc.opt, "user_logins", []string{"user_id"}, slc.typ)
+	r.secondLevelCaches.set("user_logins", slc)
+	ctx := context.Background()
+
+	setCount := func(t *testing.T, key string, count uint64) {
+		content, err := encodeCount(count)
+		NoError(t, err)
+		payload, err := slc.encodePayload(content)
+		NoError(t, err)
+		NoError(t, cacheServer.Set(ctx, &server.CacheStoreRequest{
+			Key:   &CacheKey{key: key, typ: server.CacheKeyTypeSLC},
+			Value: payload,
+		}))
+	}
+	cachedCount := func(t *testing.T, key string) (uint64, bool) {
+		content, exists := cacheServer.values[key]
+		if !exists {
+			return 0, false
+		}
+		count, err := slc.decodeCount(content)
+		NoError(t, err)
+		return count, true
+	}
+
+	countKey := func(userID uint64) server.CacheKey {
+		builder := NewQueryBuilder("user_logins").Eq("user_id", userID)
+		builder.Build(slc.valueFactory)
+		key, _ := slc.countCacheKeyByBuilder(slc.indexes["user_id"], builder)
+		return key
+	}
+
+	t.Run("index of count", func(t *testing.T) {
+		Equal(t, slc.countCacheIndex(NewQueryBuilder("user_logins").Eq("user_id", uint64(1))), slc.indexes["user_id"])
+		if slc.countCacheIndex(NewQueryBuilder("user_logins").Eq("user_session_id", uint64(1))) != nil {
+			t.Fatal("count of unique key must not be cached")
+		}
+		if slc.countCacheIndex(NewQueryBuilder("user_logins").Gte("user_id", uint64(1))) != nil {
+			t.Fatal("count of range query must not be cached")
+		}
+		if slc.countCacheIndex(NewQueryBuilder("user_logins").Eq("user_id", uint64(1)).Limit(1)) != nil {
+			t.Fatal("count of query with limit must not be cached")
+		}
+	})
+	t.Run("count from cache", func(t *testing.T) {
+		setCount(t, "r/slc/user_logins/cnt/user_id#1", 3)
+		tx, err := r.Begin(&execRecorder{})
+		NoError(t, err)
+		count, err := slc.CountByQueryBuilder(ctx, tx, NewQueryBuilder("user_logins").Eq("user_id", uint64(1)))
+		NoError(t, err)
+		Equal(t, count, uint64(3))
+		NoError(t, tx.Commit())
+	})
+	t.Run("increment cached count by create", func(t *testing.T) {
+		tx, err := r.Begin(&execRecorder{})
+		NoError(t, err)
+		_, err = slc.Create(ctx, tx, &UserLogin{UserID: 1, UserSessionID: 10, Name: "rapidash"})
+		NoError(t, err)
+		_, err = slc.Create(ctx, tx, &UserLogin{UserID: 1, UserSessionID: 11, Name: "rapidash"})
+		NoError(t, err)
+		_, err = slc.Create(ctx, tx, &UserLogin{UserID: 2, UserSessionID: 12, Name: "rapidash"})
+		NoError(t, err)
+		Equal(t, tx.isOldKey(countKey(1).String()), true)
+		NoError(t, tx.Commit())
+
+		count, exists := cachedCount(t, "r/slc/user_logins/cnt/user_id#1")
+		Equal(t, exists, true)
+		Equal(t, count, uint64(5))
+		_, exists = cachedCount(t, "r/slc/user_logins/cnt/user_id#2")
+		Equal(t, exists, false)
+	})
+	t.Run("decrement cached count", func(t *testing.T) {
+		_, value, err := slc.encode(&UserLogin{ID: 100, UserID: 1, UserSessionID: 10, Name: "rapidash"})
+		NoError(t, err)
+		tx, err := r.Begin(&execRecorder{})
+		NoError(t, err)
+		NoError(t, slc.adjustCountKeysByValue(ctx, tx, value, -1))
+		NoError(t, tx.Commit())
+		count, exists := cachedCount(t, "r/slc/user_logins/cnt/user_id#1")
+		Equal(t, exists, true)
+		Equal(t, count, uint64(4))
+	})
+	t.Run("delete count by update of index column", func(t *testing.T) {
+		_, value, err := slc.encode(&UserLogin{ID: 100, UserID: 1, UserSessionID: 10, Name: "rapidash"})
+		NoError(t, err)
+		tx, err := r.Begin(&execRecorder{})
+		NoError(t, err)
+		NoError(t, slc.deleteCountKeysByValue(ctx, tx, value, map[string]interface{}{"name": "updated"}))
+		NoError(t, tx.Commit())
+		_, exists := cachedCount(t, "r/slc/user_logins/cnt/user_id#1")
+		Equal(t, exists, true)
+
+		tx, err = r.Begin(&execRecorder{})
+		NoError(t, err)
+		NoError(t, slc.deleteCountKeysByValue(ctx, tx, value, map[string]interface{}{"user_id": uint64(2)}))
+		NoError(t, tx.Commit())
+		_, exists = cachedCount(t, "r/slc/user_logins/cnt/user_id#1")
+		Equal(t, exists, false)
+	})
+	t.Run("delete count filled in the same transaction", func(t *testing.T) {
+		setCount(t, "r/slc/user_logins/cnt/user_id#3", 1)
+		tx, err := r.Begin(&execRecorder{})
+		NoError(t, err)
+		count, err := slc.CountByQueryBuilder(ctx, tx, NewQueryBuilder("user_logins").Eq("user_id", uint64(3)))
+		NoError(t, err)
+		Equal(t, count, uint64(1))
+		NoError(t, slc.setFill(ctx, tx, countKey(3), []byte{}, LogString("")))
+		_, err = slc.Create(ctx, tx, &UserLogin{UserID: 3, UserSessionID: 13, Name: "rapidash"})
+		NoError(t, err)
+		NoError(t, tx.Commit())
+		_, exists := cachedCount(t, "r/slc/user_logins/cnt/user_id#3")
+		Equal(t, exists, false)
+	})
+	t.Run("delete count without cas", func(t *testing.T) {
+		setCount(t, "r/slc/user_logins/cnt/user_id#4", 1)
+		_, value, err := slc.encode(&UserLogin{ID: 100, UserID: 4, UserSessionID: 10, Name: "rapidash"})
+		NoError(t, err)
+		withoutCAS := NewSecondLevelCache(userLoginType(), cacheServer.memoryCacheServer, r.tableOption("user_logins"))
+		withoutCAS.indexes["user_id"] = slc.indexes["user_id"]
+		tx, err := r.Begin(&execRecorder{})
+		NoError(t, err)
+		NoError(t, withoutCAS.adjustCountKeysByValue(ctx, tx, value, 1))
+		NoError(t, tx.Commit())
+		_, exists := cachedCount(t, "r/slc/user_logins/cnt/user_id#4")
+		Equal(t, exists, false)
+	})
+}
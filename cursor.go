@@ -0,0 +1,404 @@
+package rapidash
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blastrain/msgpack"
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+const cursorPrefix = "page#"
+
+// encodeCursor returns opaque cursor of page
+func encodeCursor(page int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(page)))
+}
+
+// decodeCursor returns page of cursor. empty cursor is the first page
+func decodeCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	content, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, xerrors.Errorf("cannot decode cursor %s: %w", cursor, ErrInvalidCursor)
+	}
+	text := string(content)
+	if !strings.HasPrefix(text, cursorPrefix) {
+		return 0, xerrors.Errorf("unknown cursor %s: %w", cursor, ErrInvalidCursor)
+	}
+	page, err := strconv.Atoi(strings.TrimPrefix(text, cursorPrefix))
+	if err != nil || page < 0 {
+		return 0, xerrors.Errorf("invalid page of cursor %s: %w", cursor, ErrInvalidCursor)
+	}
+	return page, nil
+}
+
+// pageOf slices values of page, and returns cursor of the next page. empty cursor is returned for the last page
+func pageOf(values *StructSliceValue, page, pageSize int) (*StructSliceValue, string) {
+	if values == nil {
+		return nil, ""
+	}
+	start := page * pageSize
+	if start > len(values.values) {
+		start = len(values.values)
+	}
+	end := start + pageSize
+	next := encodeCursor(page + 1)
+	if end >= len(values.values) {
+		end = len(values.values)
+		next = ""
+	}
+	return &StructSliceValue{values: values.values[start:end]}, next
+}
+
+// FindByQueryBuilderWithCursor finds values of page at cursor and returns cursor of the next page.
+// If PageCache is enabled and builder is EQ conditions of a key, primary keys of each page are cached,
+// so only values of the page are read from cache server
+func (c *SecondLevelCache) FindByQueryBuilderWithCursor(ctx context.Context, tx *Tx, builder *QueryBuilder, cursor string, pageSize int, unmarshaler Unmarshaler) (string, error) {
+	defer builder.Release()
+	page, err := decodeCursor(cursor)
+	if err != nil {
+		return "", xerrors.Errorf("failed to decode cursor: %w", err)
+	}
+	if pageSize <= 0 {
+		return "", xerrors.Errorf("invalid page size %d: %w", pageSize, ErrInvalidPageSize)
+	}
+	if err := c.typ.encryptConditions(builder); err != nil {
+		return "", xerrors.Errorf("failed to encrypt conditions: %w", err)
+	}
+	values, next, err := c.findPageByQueryBuilder(ctx, tx, builder, page, pageSize)
+	if err != nil {
+		return "", xerrors.Errorf("failed to find page by query builder: %w", err)
+	}
+	if values != nil && values.Len() > 0 {
+		values, err := c.applyDecodeHook(values)
+		if err != nil {
+			return "", xerrors.Errorf("failed to apply decode hook: %w", err)
+		}
+		if err := decodeRapidash(unmarshaler, values); err != nil {
+			tx.abortByCoderPanic(err)
+			return "", xerrors.Errorf("failed to decode: %w", err)
+		}
+	}
+	return next, nil
+}
+
+func (c *SecondLevelCache) findPageByQueryBuilder(ctx context.Context, tx *Tx, builder *QueryBuilder, page, pageSize int) (*StructSliceValue, string, error) {
+	indexKey := c.pageIndexKey(tx, builder)
+	signature := c.pageSignature(builder, pageSize)
+	if indexKey != nil {
+		values, next, hit, err := c.findPageByPageCache(ctx, tx, indexKey, signature, page)
+		if err != nil {
+			return nil, "", xerrors.Errorf("failed to find page by page cache: %w", err)
+		}
+		if hit {
+			builder.info.addRows(values.Len())
+			builder.info.addCacheRows(values.Len())
+			return values, next, nil
+		}
+	}
+	values, err := c.findValuesByQueryBuilder(ctx, tx, builder)
+	if err != nil {
+		return nil, "", xerrors.Errorf("failed to find values by query builder: %w", err)
+	}
+	values = builder.page(values)
+	if indexKey != nil && values != nil {
+		if err := c.setPages(ctx, tx, indexKey, signature, values, pageSize); err != nil {
+			return nil, "", xerrors.Errorf("failed to set pages: %w", err)
+		}
+	}
+	values, next := pageOf(values, page, pageSize)
+	return values, next, nil
+}
+
+// pageIndexKey returns index key whose pages can be cached for builder.
+// order of values must be decided by columns of the index or primary key, because pages are deleted only when index key is modified
+func (c *SecondLevelCache) pageIndexKey(tx *Tx, builder *QueryBuilder) server.CacheKey {
+	if !c.opt.PageCache() || c.cacheOnly || builder.limit != nil || builder.offset > 0 {
+		return nil
+	}
+	index := c.indexByEQConditions(builder, c.isExactIndex)
+	if index == nil {
+		return nil
+	}
+	for _, orders := range [][]*OrderCondition{builder.orderConditions, c.opt.DefaultOrders()} {
+		for _, order := range orders {
+			if !index.HasColumn(order.column) && !c.primaryKey.HasColumn(order.column) {
+				return nil
+			}
+		}
+	}
+	builder.Build(c.valueFactory)
+	value := &StructValue{typ: c.typ, fields: map[string]*Value{}}
+	for _, condition := range builder.conditions.conditions {
+		value.fields[condition.Column()] = condition.Value()
+	}
+	key, err := index.CacheKey(value)
+	if err != nil || tx.isOldKey(key.String()) || tx.isOldKey(c.pageMetaKey(key).String()) {
+		return nil
+	}
+	return key
+}
+
+// pageSignature identifies page size and order of pages
+func (c *SecondLevelCache) pageSignature(builder *QueryBuilder, pageSize int) string {
+	orders := []string{}
+	for _, order := range append(append([]*OrderCondition{}, builder.orderConditions...), c.opt.DefaultOrders()...) {
+		direction := "desc"
+		if order.isAsc {
+			direction = "asc"
+		}
+		orders = append(orders, order.column+" "+direction)
+	}
+	return fmt.Sprintf("%d/%s", pageSize, strings.Join(orders, ","))
+}
+
+func (c *SecondLevelCache) pageMetaKey(indexKey server.CacheKey) server.CacheKey {
+	return &CacheKey{key: indexKey.String() + "/page", hash: indexKey.Hash()}
+}
+
+func (c *SecondLevelCache) pageKey(indexKey server.CacheKey, page int) server.CacheKey {
+	return &CacheKey{key: fmt.Sprintf("%s/page#%d", indexKey.String(), page), hash: indexKey.Hash()}
+}
+
+// pageMeta is stored at page meta key. pages having other token are regarded as missing
+type pageMeta struct {
+	token     uint64
+	signature string
+	pageCount int
+}
+
+func (m *pageMeta) encode() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	if err := enc.EncodeArrayHeader(3); err != nil {
+		return nil, xerrors.Errorf("failed to encode array header: %w", err)
+	}
+	if err := enc.EncodeUint64(m.token); err != nil {
+		return nil, xerrors.Errorf("failed to encode token: %w", err)
+	}
+	if err := enc.EncodeString(m.signature); err != nil {
+		return nil, xerrors.Errorf("failed to encode signature: %w", err)
+	}
+	if err := enc.EncodeInt(m.pageCount); err != nil {
+		return nil, xerrors.Errorf("failed to encode page count: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (m *pageMeta) decode(content []byte) error {
+	dec := msgpack.NewDecoder(bytes.NewBuffer(content))
+	var length int
+	if err := dec.DecodeArrayLength(&length); err != nil {
+		return xerrors.Errorf("failed to decode array length: %w", err)
+	}
+	if err := dec.DecodeUint64(&m.token); err != nil {
+		return xerrors.Errorf("failed to decode token: %w", err)
+	}
+	if err := dec.DecodeString(&m.signature); err != nil {
+		return xerrors.Errorf("failed to decode signature: %w", err)
+	}
+	if err := dec.DecodeInt(&m.pageCount); err != nil {
+		return xerrors.Errorf("failed to decode page count: %w", err)
+	}
+	return nil
+}
+
+func encodePage(token uint64, primaryKeys []server.CacheKey, compact bool) ([]byte, error) {
+	content, err := encodePrimaryKeys(primaryKeys, compact)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to encode primary keys: %w", err)
+	}
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	if err := enc.EncodeArrayHeader(2); err != nil {
+		return nil, xerrors.Errorf("failed to encode array header: %w", err)
+	}
+	if err := enc.EncodeUint64(token); err != nil {
+		return nil, xerrors.Errorf("failed to encode token: %w", err)
+	}
+	if err := enc.EncodeBytes(content); err != nil {
+		return nil, xerrors.Errorf("failed to encode primary keys: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *SecondLevelCache) decodePage(content []byte, flags uint32) (uint64, []server.CacheKey, error) {
+	dec := msgpack.NewDecoder(bytes.NewBuffer(content))
+	var (
+		length      int
+		token       uint64
+		primaryKeys []byte
+	)
+	if err := dec.DecodeArrayLength(&length); err != nil {
+		return 0, nil, xerrors.Errorf("failed to decode array length: %w", err)
+	}
+	if err := dec.DecodeUint64(&token); err != nil {
+		return 0, nil, xerrors.Errorf("failed to decode token: %w", err)
+	}
+	if err := dec.DecodeBytes(&primaryKeys); err != nil {
+		return 0, nil, xerrors.Errorf("failed to decode primary keys: %w", err)
+	}
+	keys, err := c.decodeMultiplePrimaryKeys(primaryKeys, flags)
+	if err != nil {
+		return 0, nil, xerrors.Errorf("failed to decode multiple primary keys: %w", err)
+	}
+	return token, keys, nil
+}
+
+// findPageByPageCache returns values of cached page. false is returned if page isn't cached or some values of page are missing
+func (c *SecondLevelCache) findPageByPageCache(ctx context.Context, tx *Tx, indexKey server.CacheKey, signature string, page int) (*StructSliceValue, string, bool, error) {
+	metaKey := c.pageMetaKey(indexKey)
+	pageKey := c.pageKey(indexKey, page)
+	iter, err := c.cacheServer.GetMulti(ctx, []server.CacheKey{metaKey, pageKey})
+	if err != nil {
+		return nil, "", false, xerrors.Errorf("failed to get page from server: %w", err)
+	}
+	contents := map[string]*server.CacheGetResponse{}
+	for iter.Next() {
+		if iter.Error() == nil {
+			contents[iter.Key().String()] = iter.Content()
+		}
+	}
+	metaContent, exists := contents[metaKey.String()]
+	if !exists {
+		return nil, "", false, nil
+	}
+	meta := &pageMeta{}
+	payload, err := c.decodePayload(metaContent.Value)
+	if err == nil {
+		err = meta.decode(payload)
+	}
+	if err != nil || meta.signature != signature {
+		return nil, "", false, nil
+	}
+	if page >= meta.pageCount {
+		return NewStructSliceValue(), "", true, nil
+	}
+	pageContent, exists := contents[pageKey.String()]
+	if !exists {
+		return nil, "", false, nil
+	}
+	payload, err = c.decodePayload(pageContent.Value)
+	if err != nil {
+		return nil, "", false, nil
+	}
+	token, primaryKeys, err := c.decodePage(payload, pageContent.Flags)
+	if err != nil || token != meta.token {
+		return nil, "", false, nil
+	}
+	tx.logger().Get(tx.id, SLCServer, pageKey, LogStrings(primaryKeys))
+	values, found, err := c.findValuesByPagePrimaryKeys(ctx, tx, primaryKeys)
+	if err != nil {
+		return nil, "", false, xerrors.Errorf("failed to find values by primary keys of page: %w", err)
+	}
+	if !found {
+		return nil, "", false, nil
+	}
+	next := ""
+	if page+1 < meta.pageCount {
+		next = encodeCursor(page + 1)
+	}
+	return values, next, true, nil
+}
+
+// findValuesByPagePrimaryKeys reads values of primary keys from cache server.
+// false is returned if some values are missing or modified by this transaction, then page is built again by index key
+func (c *SecondLevelCache) findValuesByPagePrimaryKeys(ctx context.Context, tx *Tx, primaryKeys []server.CacheKey) (*StructSliceValue, bool, error) {
+	for _, primaryKey := range primaryKeys {
+		key := primaryKey.String()
+		if _, exists := tx.stash.primaryKeyToValue[key]; exists || tx.isOldKey(key) {
+			return nil, false, nil
+		}
+	}
+	values := NewStructSliceValueWithCapacity(len(primaryKeys))
+	if len(primaryKeys) == 0 {
+		return values, true, nil
+	}
+	iter, err := c.getMultiWithLocalCache(ctx, primaryKeys)
+	if err != nil {
+		return nil, false, xerrors.Errorf("failed to get values from server: %w", err)
+	}
+	decoder := c.valueDecoder()
+	defer c.releaseValueDecoder(decoder)
+	for iter.Next() {
+		if iter.Error() != nil {
+			return nil, false, nil
+		}
+		content := iter.Content()
+		payload, err := c.decodePayload(content.Value)
+		if err != nil || len(payload) == 0 {
+			return nil, false, nil
+		}
+		value, err := c.decodeValue(decoder, payload)
+		if err != nil {
+			return nil, false, nil
+		}
+		c.limitLocalCacheAge(iter.Key(), value)
+		if _, expired := c.expirationByValue(value); expired {
+			return nil, false, nil
+		}
+		tx.stash.casIDs[iter.Key().String()] = content.CasID
+		values.Append(value)
+	}
+	tx.logger().GetMulti(tx.id, SLCServer, primaryKeys, values)
+	return values, true, nil
+}
+
+// setPages sets all pages of values and their meta on commit
+func (c *SecondLevelCache) setPages(ctx context.Context, tx *Tx, indexKey server.CacheKey, signature string, values *StructSliceValue, pageSize int) error {
+	meta := &pageMeta{
+		token:     uint64(c.opt.now().UnixNano()),
+		signature: signature,
+		pageCount: (values.Len() + pageSize - 1) / pageSize,
+	}
+	for page := 0; page < meta.pageCount; page++ {
+		pageValues, _ := pageOf(values, page, pageSize)
+		primaryKeys := make([]server.CacheKey, 0, pageValues.Len())
+		for _, value := range pageValues.values {
+			primaryKey, err := c.primaryKey.CacheKey(value)
+			if err != nil {
+				return xerrors.Errorf("failed to get cache key: %w", err)
+			}
+			primaryKeys = append(primaryKeys, primaryKey)
+		}
+		content, err := encodePage(meta.token, primaryKeys, c.opt.CompactPrimaryKeys())
+		if err != nil {
+			return xerrors.Errorf("failed to encode page: %w", err)
+		}
+		if err := c.setFill(ctx, tx, c.pageKey(indexKey, page), content, LogStrings(primaryKeys)); err != nil {
+			return xerrors.Errorf("failed to set page: %w", err)
+		}
+	}
+	content, err := meta.encode()
+	if err != nil {
+		return xerrors.Errorf("failed to encode page meta: %w", err)
+	}
+	if err := c.setFill(ctx, tx, c.pageMetaKey(indexKey), content, LogString(signature)); err != nil {
+		return xerrors.Errorf("failed to set page meta: %w", err)
+	}
+	return nil
+}
+
+// deletePagesByIndexKey deletes page meta of index key, so all pages of the key are regarded as missing.
+// pages aren't cached again in this transaction because values of the key are modified
+func (c *SecondLevelCache) deletePagesByIndexKey(ctx context.Context, tx *Tx, key server.CacheKey) error {
+	if !c.opt.PageCache() {
+		return nil
+	}
+	prefix := fmt.Sprintf("r/slc/%s/idx/", c.keyTableName())
+	if !strings.HasPrefix(key.String(), prefix) || strings.Contains(strings.TrimPrefix(key.String(), prefix), "/page") {
+		return nil
+	}
+	if err := c.deleteOldKey(ctx, tx, c.pageMetaKey(key)); err != nil {
+		return xerrors.Errorf("failed to delete page meta: %w", err)
+	}
+	return nil
+}
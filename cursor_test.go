@@ -0,0 +1,143 @@
+package rapidash
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+func TestCursor(t *testing.T) {
+	t.Run("encode and decode", func(t *testing.T) {
+		page, err := decodeCursor(encodeCursor(3))
+		NoError(t, err)
+		Equal(t, page, 3)
+		page, err = decodeCursor("")
+		NoError(t, err)
+		Equal(t, page, 0)
+		_, err = decodeCursor("invalid cursor")
+		Equal(t, xerrors.Is(err, ErrInvalidCursor), true)
+	})
+	t.Run("page of values", func(t *testing.T) {
+		values := NewStructSliceValue()
+		for i := 0; i < 5; i++ {
+			values.Append(&StructValue{})
+		}
+		page, next := pageOf(values, 0, 2)
+		Equal(t, page.Len(), 2)
+		Equal(t, next, encodeCursor(1))
+		page, next = pageOf(values, 2, 2)
+		Equal(t, page.Len(), 1)
+		Equal(t, next, "")
+		page, next = pageOf(values, 3, 2)
+		Equal(t, page.Len(), 0)
+		Equal(t, next, "")
+	})
+}
+
+func TestPageCache(t *testing.T) {
+	r, err := New(
+		SecondLevelCacheTablePageCache("user_logins", true),
+		SecondLevelCacheTableWriteThroughOnCreate("user_logins", true),
+		SecondLevelCacheTablePrimaryKeyGenerator("user_logins", NewSequencePrimaryKeyGenerator(100)),
+	)
+	NoError(t, err)
+	cacheServer := newMemoryCacheServer()
+	r.cacheServer = cacheServer
+	slc := NewSecondLevelCache(userLoginType(), cacheServer, r.tableOption("user_logins"))
+	slc.primaryKey = NewPrimaryKey(slc.opt, "user_logins", []string{"id"}, slc.typ)
+	slc.indexes["id"] = slc.primaryKey
+	slc.indexes["user_id"] = NewKey(slc.opt, "user_logins", []string{"user_id"}, slc.typ)
+	r.secondLevelCaches.set("user_logins", slc)
+	ctx := context.Background()
+
+	indexKey, err := slc.indexes["user_id"].CacheKey(&StructValue{
+		typ:    slc.typ,
+		fields: map[string]*Value{"user_id": slc.valueFactory.CreateUint64Value(1)},
+	})
+	NoError(t, err)
+	// index list is deleted by create, so fill it as if primary keys are found by database
+	fillIndexKey := func(t *testing.T, ids ...uint64) {
+		primaryKeys := make([]server.CacheKey, 0, len(ids))
+		for _, id := range ids {
+			primaryKey, err := slc.primaryKey.CacheKey(&StructValue{
+				typ:    slc.typ,
+				fields: map[string]*Value{"id": slc.valueFactory.CreateUint64Value(id)},
+			})
+			NoError(t, err)
+			primaryKeys = append(primaryKeys, primaryKey)
+		}
+		tx, err := r.Begin(&execRecorder{})
+		NoError(t, err)
+		NoError(t, slc.fillKey(ctx, tx, indexKey, primaryKeys))
+		NoError(t, tx.Commit())
+	}
+	tx, err := r.Begin(&execRecorder{})
+	NoError(t, err)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := slc.Create(ctx, tx, &UserLogin{UserID: 1, UserSessionID: uint64(i), Name: "rapidash", CreatedAt: &now, UpdatedAt: &now})
+		NoError(t, err)
+	}
+	NoError(t, tx.Commit())
+	fillIndexKey(t, 100, 101, 102)
+
+	findPage := func(t *testing.T, cursor string) (UserLogins, string) {
+		tx, err := r.Begin(&execRecorder{})
+		NoError(t, err)
+		var userLogins UserLogins
+		next, err := slc.FindByQueryBuilderWithCursor(ctx, tx, NewQueryBuilder("user_logins").Eq("user_id", uint64(1)), cursor, 2, &userLogins)
+		NoError(t, err)
+		NoError(t, tx.Commit())
+		return userLogins, next
+	}
+
+	t.Run("cache pages", func(t *testing.T) {
+		userLogins, next := findPage(t, "")
+		Equal(t, len(userLogins), 2)
+		Equal(t, next, encodeCursor(1))
+		_, exists := cacheServer.values[indexKey.String()+"/page"]
+		Equal(t, exists, true)
+		_, exists = cacheServer.values[indexKey.String()+"/page#1"]
+		Equal(t, exists, true)
+	})
+	t.Run("find page by page cache", func(t *testing.T) {
+		// index list isn't read if page is cached
+		content := cacheServer.values[indexKey.String()]
+		delete(cacheServer.values, indexKey.String())
+		defer func() { cacheServer.values[indexKey.String()] = content }()
+		userLogins, next := findPage(t, encodeCursor(1))
+		Equal(t, len(userLogins), 1)
+		Equal(t, next, "")
+		userLogins, next = findPage(t, encodeCursor(2))
+		Equal(t, len(userLogins), 0)
+		Equal(t, next, "")
+	})
+	t.Run("invalid page size", func(t *testing.T) {
+		tx, err := r.Begin(&execRecorder{})
+		NoError(t, err)
+		var userLogins UserLogins
+		_, err = slc.FindByQueryBuilderWithCursor(ctx, tx, NewQueryBuilder("user_logins").Eq("user_id", uint64(1)), "", 0, &userLogins)
+		Equal(t, xerrors.Is(err, ErrInvalidPageSize), true)
+		NoError(t, tx.Rollback())
+	})
+	t.Run("delete pages by create", func(t *testing.T) {
+		tx, err := r.Begin(&execRecorder{})
+		NoError(t, err)
+		_, err = slc.Create(ctx, tx, &UserLogin{UserID: 1, UserSessionID: 3, Name: "rapidash", CreatedAt: &now, UpdatedAt: &now})
+		NoError(t, err)
+		if slc.pageIndexKey(tx, NewQueryBuilder("user_logins").Eq("user_id", uint64(1))) != nil {
+			t.Fatal("pages must not be cached after modifying index key")
+		}
+		NoError(t, tx.Commit())
+		_, exists := cacheServer.values[indexKey.String()+"/page"]
+		Equal(t, exists, false)
+
+		fillIndexKey(t, 100, 101, 102, 103)
+		userLogins, next := findPage(t, encodeCursor(1))
+		Equal(t, len(userLogins), 2)
+		Equal(t, next, "")
+	})
+}
@@ -0,0 +1,135 @@
+package rapidash
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// PoolStats is usage of internal pool. Size of sync.Pool can't be observed, so it is counted by Get and Put
+type PoolStats struct {
+	Gets   uint64 `json:"gets"`
+	Puts   uint64 `json:"puts"`
+	Allocs uint64 `json:"allocs"` // number of objects allocated because pool was empty
+	InUse  int64  `json:"inUse"`  // number of objects taken from pool but not returned yet
+}
+
+type poolCounter struct {
+	gets   uint64
+	puts   uint64
+	allocs uint64
+}
+
+func (c *poolCounter) get() {
+	atomic.AddUint64(&c.gets, 1)
+}
+
+func (c *poolCounter) put() {
+	atomic.AddUint64(&c.puts, 1)
+}
+
+func (c *poolCounter) alloc() {
+	atomic.AddUint64(&c.allocs, 1)
+}
+
+func (c *poolCounter) snapshot() PoolStats {
+	gets := atomic.LoadUint64(&c.gets)
+	puts := atomic.LoadUint64(&c.puts)
+	return PoolStats{
+		Gets:   gets,
+		Puts:   puts,
+		Allocs: atomic.LoadUint64(&c.allocs),
+		InUse:  int64(gets) - int64(puts),
+	}
+}
+
+// TableDebugInfo is usage of pools of SecondLevelCache for table
+type TableDebugInfo struct {
+	Table            string    `json:"table"`
+	ValueDecoderPool PoolStats `json:"valueDecoderPool"`
+	ValueFactoryPool PoolStats `json:"valueFactoryPool"`
+}
+
+// TxDebugInfo is snapshot of transaction that isn't committed or rolled back yet.
+// Tx isn't goroutine safe, so values are taken at the end of the last operation of the transaction
+type TxDebugInfo struct {
+	ID             string    `json:"id"`
+	StartedAt      time.Time `json:"startedAt"`
+	StashSize      int64     `json:"stashSize"` // approximate bytes of stashed values
+	StashValues    int64     `json:"stashValues"`
+	PendingQueries int64     `json:"pendingQueries"`
+	LockKeys       int64     `json:"lockKeys"`
+}
+
+type txDebugStats struct {
+	stashSize      int64
+	stashValues    int64
+	pendingQueries int64
+	lockKeys       int64
+}
+
+func (tx *Tx) publishDebugStats() {
+	if tx.stash != nil {
+		atomic.StoreInt64(&tx.debugStats.stashSize, int64(tx.stash.size))
+		atomic.StoreInt64(&tx.debugStats.stashValues, int64(len(tx.stash.primaryKeyToValue)))
+	}
+	atomic.StoreInt64(&tx.debugStats.pendingQueries, int64(len(tx.pendingQueries)))
+	atomic.StoreInt64(&tx.debugStats.lockKeys, int64(len(tx.lockKeys)))
+}
+
+// DebugInfo is snapshot of internal pools and active transactions to diagnose memory usage
+type DebugInfo struct {
+	Tables []*TableDebugInfo `json:"tables"`
+	Txs    []*TxDebugInfo    `json:"txs"`
+}
+
+// Debug returns snapshot of internal pools and active transactions
+func (r *Rapidash) Debug() *DebugInfo {
+	info := &DebugInfo{Tables: []*TableDebugInfo{}, Txs: []*TxDebugInfo{}}
+	r.secondLevelCaches.Range(func(key, value interface{}) bool {
+		c := value.(*SecondLevelCache)
+		info.Tables = append(info.Tables, &TableDebugInfo{
+			Table:            c.typ.tableName,
+			ValueDecoderPool: c.decoderStats.snapshot(),
+			ValueFactoryPool: c.valueFactory.PoolStats(),
+		})
+		return true
+	})
+	sort.Slice(info.Tables, func(i, j int) bool {
+		return info.Tables[i].Table < info.Tables[j].Table
+	})
+	r.activeTxs.Range(func(key, value interface{}) bool {
+		info.Txs = append(info.Txs, value.(*Tx).debugInfo())
+		return true
+	})
+	sort.Slice(info.Txs, func(i, j int) bool {
+		if !info.Txs[i].StartedAt.Equal(info.Txs[j].StartedAt) {
+			return info.Txs[i].StartedAt.Before(info.Txs[j].StartedAt)
+		}
+		return info.Txs[i].ID < info.Txs[j].ID
+	})
+	return info
+}
+
+func (tx *Tx) debugInfo() *TxDebugInfo {
+	return &TxDebugInfo{
+		ID:             tx.id,
+		StartedAt:      tx.startedAt,
+		StashSize:      atomic.LoadInt64(&tx.debugStats.stashSize),
+		StashValues:    atomic.LoadInt64(&tx.debugStats.stashValues),
+		PendingQueries: atomic.LoadInt64(&tx.debugStats.pendingQueries),
+		LockKeys:       atomic.LoadInt64(&tx.debugStats.lockKeys),
+	}
+}
+
+// DebugHandler returns http.Handler serving Debug() as JSON
+func (r *Rapidash) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(r.Debug()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package rapidash
+
+import (
+	"fmt"
+	"strings"
+
+	"go.knocknote.io/rapidash/server"
+)
+
+type CacheDiffType int
+
+const (
+	CacheDiffAdded CacheDiffType = iota
+	CacheDiffUpdated
+	CacheDiffDeleted
+)
+
+func (typ CacheDiffType) String() string {
+	switch typ {
+	case CacheDiffAdded:
+		return "+"
+	case CacheDiffUpdated:
+		return "~"
+	case CacheDiffDeleted:
+		return "-"
+	}
+	return "?"
+}
+
+type CacheDiff struct {
+	Type      CacheDiffType
+	Key       string
+	CacheType server.CacheKeyType
+	// Value is decoded value stashed for Key at the time of DebugDiff
+	Value string
+}
+
+func (d *CacheDiff) String() string {
+	if d.Value == "" {
+		return fmt.Sprintf("%s %s", d.Type, d.Key)
+	}
+	return fmt.Sprintf("%s %s => %s", d.Type, d.Key, d.Value)
+}
+
+type TxDiff struct {
+	TxID  string
+	Diffs []*CacheDiff
+}
+
+// String renders diff as lines like "+ r/slc/user_logins/id#1 => {id:1,...}"
+func (d *TxDiff) String() string {
+	lines := make([]string, 0, len(d.Diffs)+1)
+	lines = append(lines, fmt.Sprintf("tx: %s", d.TxID))
+	for _, diff := range d.Diffs {
+		lines = append(lines, diff.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// DebugDiff captures what the transaction will change in cache server at commit.
+// It is intended for tests and development logs, so it is not cheap
+func (tx *Tx) DebugDiff() *TxDiff {
+	diff := &TxDiff{TxID: tx.id, Diffs: []*CacheDiff{}}
+	for _, key := range tx.sortedPendingQueryKeys() {
+		query := tx.pendingQueries[key]
+		var typ CacheDiffType
+		switch query.Command {
+		case string(SLCCommandSet), string(SLCCommandAdd):
+			typ = CacheDiffAdded
+		case string(SLCCommandUpdate):
+			typ = CacheDiffUpdated
+		case string(SLCCommandDelete):
+			typ = CacheDiffDeleted
+		default:
+			continue
+		}
+		d := &CacheDiff{Type: typ, Key: key, CacheType: query.Type}
+		if typ != CacheDiffDeleted {
+			d.Value = tx.stashedValueLog(key)
+		}
+		diff.Diffs = append(diff.Diffs, d)
+	}
+	return diff
+}
+
+func (tx *Tx) stashedValueLog(key string) string {
+	if value, exists := tx.stash.primaryKeyToValue[key]; exists && value != nil {
+		return value.EncodeLog()
+	}
+	if primaryKey, exists := tx.stash.uniqueKeyToPrimaryKey[key]; exists && primaryKey != nil {
+		return primaryKey.String()
+	}
+	if primaryKeys, exists := tx.stash.keyToPrimaryKeys[key]; exists {
+		return fmt.Sprintf("[%s]", LogStrings(primaryKeys).EncodeLog())
+	}
+	if content, exists := tx.stash.lastLevelCacheKeyToBytes[key]; exists {
+		return fmt.Sprintf("(%d bytes)", len(content))
+	}
+	return ""
+}
@@ -0,0 +1,26 @@
+package rapidash
+
+import (
+	"testing"
+)
+
+func TestDebugDiff(t *testing.T) {
+	r, err := New()
+	NoError(t, err)
+	tx, err := r.Begin()
+	NoError(t, err)
+	value := &StructValue{typ: userLoginType(), fields: map[string]*Value{"id": NewUint64Value(1)}}
+	NoError(t, tx.stashValue("r/slc/user_logins/id#1", value))
+	NoError(t, tx.stashBytes("r/llc/key", []byte("value")))
+	tx.pendingQueries["r/slc/user_logins/id#1"] = &PendingQuery{QueryLog: &QueryLog{Command: string(SLCCommandUpdate)}}
+	tx.pendingQueries["r/slc/user_logins/id#2"] = &PendingQuery{QueryLog: &QueryLog{Command: string(SLCCommandDelete)}}
+	tx.pendingQueries["r/llc/key"] = &PendingQuery{QueryLog: &QueryLog{Command: "set"}}
+
+	diff := tx.DebugDiff()
+	Equal(t, len(diff.Diffs), 3)
+	Equal(t, diff.Diffs[0].String(), "+ r/llc/key => (5 bytes)")
+	Equal(t, diff.Diffs[1].Type, CacheDiffUpdated)
+	Equal(t, diff.Diffs[1].Value, value.EncodeLog())
+	Equal(t, diff.Diffs[2].String(), "- r/slc/user_logins/id#2")
+	Equal(t, diff.String(), "tx: "+tx.id+"\n"+diff.Diffs[0].String()+"\n"+diff.Diffs[1].String()+"\n"+diff.Diffs[2].String())
+}
@@ -0,0 +1,57 @@
+package rapidash
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDebug(t *testing.T) {
+	r, err := New()
+	NoError(t, err)
+	r.cacheServer = newMemoryCacheServer()
+	slc := NewSecondLevelCache(userLoginType(), r.cacheServer, r.tableOption("user_logins"))
+	slc.primaryKey = NewPrimaryKey(slc.opt, "user_logins", []string{"id"}, slc.typ)
+	slc.indexes["id"] = slc.primaryKey
+	r.secondLevelCaches.set("user_logins", slc)
+
+	key, err := slc.cacheKeyByPrimaryKeyValue(slc.valueFactory.CreateUint64Value(1))
+	NoError(t, err)
+	tx, err := r.Begin(&execRecorder{})
+	NoError(t, err)
+	_, value, err := slc.encode(&UserLogin{ID: 1, UserID: 10, Name: "rapidash"})
+	NoError(t, err)
+	NoError(t, slc.setPrimaryKey(context.Background(), tx, key, value))
+	NoError(t, tx.Commit())
+
+	tx, err = r.Begin(&execRecorder{})
+	NoError(t, err)
+	var userLogin UserLogin
+	NoError(t, tx.FindByQueryBuilder(NewQueryBuilder("user_logins").Eq("id", uint64(1)), &userLogin))
+
+	t.Run("snapshot", func(t *testing.T) {
+		info := r.Debug()
+		Equal(t, len(info.Tables), 1)
+		Equal(t, info.Tables[0].Table, "user_logins")
+		Equal(t, info.Tables[0].ValueDecoderPool.Gets > 0, true)
+		Equal(t, info.Tables[0].ValueDecoderPool.InUse, int64(0))
+		Equal(t, info.Tables[0].ValueFactoryPool.Gets > 0, true)
+		Equal(t, len(info.Txs), 1)
+		Equal(t, info.Txs[0].ID, tx.id)
+		Equal(t, info.Txs[0].StashValues, int64(1))
+		Equal(t, info.Txs[0].StashSize > 0, true)
+	})
+	t.Run("handler", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.DebugHandler().ServeHTTP(w, httptest.NewRequest("GET", "/debug/rapidash", nil))
+		Equal(t, w.Code, 200)
+		var info DebugInfo
+		NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+		Equal(t, len(info.Txs), 1)
+	})
+	t.Run("finished transaction", func(t *testing.T) {
+		NoError(t, tx.Commit())
+		Equal(t, len(r.Debug().Txs), 0)
+	})
+}
@@ -61,6 +61,22 @@ type Decoder interface {
 	Strings(string) []string
 	Times(string) []time.Time
 	Error() error
+	Errors() []*FieldError
+}
+
+// FieldError is error of column failed to decode
+type FieldError struct {
+	Table  string
+	Column string
+	Err    error
+}
+
+func (e *FieldError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
 }
 
 type PrimaryKeyDecoder struct {
@@ -306,11 +322,11 @@ func (d *ValueDecoder) Decode() (*StructValue, error) {
 }
 
 func (d *ValueDecoder) DecodeSlice() (*StructSliceValue, error) {
-	values := NewStructSliceValue()
 	var len int
 	if err := d.dec.DecodeArrayLength(&len); err != nil {
 		return nil, xerrors.Errorf("failed to decode array length: %w", err)
 	}
+	values := NewStructSliceValueWithCapacity(len)
 	for i := 0; i < len; i++ {
 		value := &StructValue{
 			typ:    d.typ,
@@ -105,6 +105,7 @@ func (e *StructEncoder) Int(column string, v int) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -122,6 +123,7 @@ func (e *StructEncoder) Int8(column string, v int8) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -139,6 +141,7 @@ func (e *StructEncoder) Int16(column string, v int16) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -156,6 +159,7 @@ func (e *StructEncoder) Int32(column string, v int32) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -173,6 +177,7 @@ func (e *StructEncoder) Int64(column string, v int64) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -190,6 +195,7 @@ func (e *StructEncoder) Uint(column string, v uint) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -207,6 +213,7 @@ func (e *StructEncoder) Uint8(column string, v uint8) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -224,6 +231,7 @@ func (e *StructEncoder) Uint16(column string, v uint16) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -241,6 +249,7 @@ func (e *StructEncoder) Uint32(column string, v uint32) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -258,6 +267,7 @@ func (e *StructEncoder) Uint64(column string, v uint64) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -275,6 +285,7 @@ func (e *StructEncoder) Float32(column string, v float32) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -292,6 +303,7 @@ func (e *StructEncoder) Float64(column string, v float64) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -309,6 +321,7 @@ func (e *StructEncoder) String(column string, v string) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -319,6 +332,11 @@ func (e *StructEncoder) String(column string, v string) {
 			e.typ.tableName, column, field.typ, ErrInvalidEncodeType)
 		return
 	}
+	v, err := field.encrypt(e.typ.cipher, v)
+	if err != nil {
+		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, err)
+		return
+	}
 	e.value.fields[column] = e.valueFactory.CreateStringValue(v)
 }
 
@@ -326,6 +344,7 @@ func (e *StructEncoder) Bytes(column string, v []byte) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -343,6 +362,7 @@ func (e *StructEncoder) Bool(column string, v bool) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -360,6 +380,7 @@ func (e *StructEncoder) Time(column string, v time.Time) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -377,6 +398,7 @@ func (e *StructEncoder) IntPtr(column string, v *int) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -394,6 +416,7 @@ func (e *StructEncoder) Int8Ptr(column string, v *int8) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -411,6 +434,7 @@ func (e *StructEncoder) Int16Ptr(column string, v *int16) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -428,6 +452,7 @@ func (e *StructEncoder) Int32Ptr(column string, v *int32) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -445,6 +470,7 @@ func (e *StructEncoder) Int64Ptr(column string, v *int64) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -462,6 +488,7 @@ func (e *StructEncoder) UintPtr(column string, v *uint) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -479,6 +506,7 @@ func (e *StructEncoder) Uint8Ptr(column string, v *uint8) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -496,6 +524,7 @@ func (e *StructEncoder) Uint16Ptr(column string, v *uint16) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -513,6 +542,7 @@ func (e *StructEncoder) Uint32Ptr(column string, v *uint32) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -530,6 +560,7 @@ func (e *StructEncoder) Uint64Ptr(column string, v *uint64) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -547,6 +578,7 @@ func (e *StructEncoder) Float32Ptr(column string, v *float32) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -564,6 +596,7 @@ func (e *StructEncoder) Float64Ptr(column string, v *float64) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -581,6 +614,7 @@ func (e *StructEncoder) StringPtr(column string, v *string) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -591,6 +625,14 @@ func (e *StructEncoder) StringPtr(column string, v *string) {
 			e.typ.tableName, column, field.typ, ErrInvalidEncodeType)
 		return
 	}
+	if v != nil {
+		encrypted, err := field.encrypt(e.typ.cipher, *v)
+		if err != nil {
+			e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, err)
+			return
+		}
+		v = &encrypted
+	}
 	e.value.fields[column] = e.valueFactory.CreateStringPtrValue(v)
 }
 
@@ -598,6 +640,7 @@ func (e *StructEncoder) BytesPtr(column string, v *[]byte) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -615,6 +658,7 @@ func (e *StructEncoder) BoolPtr(column string, v *bool) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -632,6 +676,7 @@ func (e *StructEncoder) TimePtr(column string, v *time.Time) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -649,6 +694,7 @@ func (e *StructEncoder) Struct(column string, v Marshaler) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -675,6 +721,7 @@ func (e *StructEncoder) Ints(column string, v []int) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	if _, exists := e.typ.fields[column]; !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
 		return
@@ -690,6 +737,7 @@ func (e *StructEncoder) Int8s(column string, v []int8) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	if _, exists := e.typ.fields[column]; !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
 		return
@@ -705,6 +753,7 @@ func (e *StructEncoder) Int16s(column string, v []int16) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	if _, exists := e.typ.fields[column]; !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
 		return
@@ -720,6 +769,7 @@ func (e *StructEncoder) Int32s(column string, v []int32) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	if _, exists := e.typ.fields[column]; !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
 		return
@@ -735,6 +785,7 @@ func (e *StructEncoder) Int64s(column string, v []int64) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	if _, exists := e.typ.fields[column]; !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
 		return
@@ -750,6 +801,7 @@ func (e *StructEncoder) Uints(column string, v []uint) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	if _, exists := e.typ.fields[column]; !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
 		return
@@ -765,6 +817,7 @@ func (e *StructEncoder) Uint8s(column string, v []uint8) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	if _, exists := e.typ.fields[column]; !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
 		return
@@ -780,6 +833,7 @@ func (e *StructEncoder) Uint16s(column string, v []uint16) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	if _, exists := e.typ.fields[column]; !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
 		return
@@ -795,6 +849,7 @@ func (e *StructEncoder) Uint32s(column string, v []uint32) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	if _, exists := e.typ.fields[column]; !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
 		return
@@ -810,6 +865,7 @@ func (e *StructEncoder) Uint64s(column string, v []uint64) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	if _, exists := e.typ.fields[column]; !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
 		return
@@ -825,6 +881,7 @@ func (e *StructEncoder) Float32s(column string, v []float32) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	if _, exists := e.typ.fields[column]; !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
 		return
@@ -840,6 +897,7 @@ func (e *StructEncoder) Float64s(column string, v []float64) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	if _, exists := e.typ.fields[column]; !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
 		return
@@ -855,6 +913,7 @@ func (e *StructEncoder) Strings(column string, v []string) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	if _, exists := e.typ.fields[column]; !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
 		return
@@ -870,6 +929,7 @@ func (e *StructEncoder) Bools(column string, v []bool) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	if _, exists := e.typ.fields[column]; !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
 		return
@@ -885,6 +945,7 @@ func (e *StructEncoder) Times(column string, v []time.Time) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	if _, exists := e.typ.fields[column]; !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
 		return
@@ -900,6 +961,7 @@ func (e *StructEncoder) Structs(column string, v Marshaler) {
 	if e.err != nil {
 		return
 	}
+	column = e.typ.columnName(column)
 	field, exists := e.typ.fields[column]
 	if !exists {
 		e.err = xerrors.Errorf("%s.%s: %w", e.typ.tableName, column, ErrUnknownColumnName)
@@ -946,7 +1008,7 @@ func NewStructSliceEncoder(typ *Struct, valueFactory *ValueFactory, coder Coder)
 }
 
 func (e *StructSliceEncoder) Encode() ([]byte, error) {
-	if err := e.coder.EncodeRapidash(e); err != nil {
+	if err := encodeRapidash(e.coder, e); err != nil {
 		return nil, xerrors.Errorf("failed to encode value: %w", err)
 	}
 	columns := e.typ.Columns()
@@ -963,7 +1025,7 @@ func (e *StructSliceEncoder) Encode() ([]byte, error) {
 					return nil, xerrors.Errorf("failed to encode: %w", err)
 				}
 			} else {
-				if err := encodeDefaultValue(e.typ.fields[column].typ, enc); err != nil {
+				if err := e.typ.fields[column].encodeDefault(enc); err != nil {
 					return nil, xerrors.Errorf("failed to encode default value: %w", err)
 				}
 			}
@@ -0,0 +1,254 @@
+package rapidash
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+
+	"golang.org/x/xerrors"
+)
+
+// EncryptionMode decides how value of encrypted column is stored to database and cache server
+type EncryptionMode int
+
+const (
+	EncryptionModeNone EncryptionMode = iota
+	// EncryptionModeDeterministic always produces the same ciphertext for the same value,
+	// so the column can be used by Eq/In conditions and indexes
+	EncryptionModeDeterministic
+	// EncryptionModeRandom produces different ciphertext every time, so the column can't be searched
+	EncryptionModeRandom
+)
+
+func (m EncryptionMode) String() string {
+	switch m {
+	case EncryptionModeDeterministic:
+		return "deterministic"
+	case EncryptionModeRandom:
+		return "random"
+	}
+	return "none"
+}
+
+type columnCipher struct {
+	aead     cipher.AEAD
+	nonceKey []byte
+}
+
+func newColumnCipher(key []byte) (*columnCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create cipher: %w", ErrInvalidEncryptionKey)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create gcm: %w", err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("rapidash column encryption nonce"))
+	return &columnCipher{aead: aead, nonceKey: mac.Sum(nil)}, nil
+}
+
+// nonce of deterministic mode is derived from plain text ( synthetic IV ), so the same value has the same ciphertext
+func (c *columnCipher) nonce(plain string, mode EncryptionMode) ([]byte, error) {
+	if mode == EncryptionModeDeterministic {
+		mac := hmac.New(sha256.New, c.nonceKey)
+		mac.Write([]byte(plain))
+		return mac.Sum(nil)[:c.aead.NonceSize()], nil
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, xerrors.Errorf("failed to read random bytes: %w", err)
+	}
+	return nonce, nil
+}
+
+func (c *columnCipher) encrypt(plain string, mode EncryptionMode) (string, error) {
+	nonce, err := c.nonce(plain, mode)
+	if err != nil {
+		return "", xerrors.Errorf("failed to create nonce: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(c.aead.Seal(nonce, nonce, []byte(plain), nil)), nil
+}
+
+func (c *columnCipher) decrypt(encrypted string) (string, error) {
+	content, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", xerrors.Errorf("failed to decode base64: %w", err)
+	}
+	nonceSize := c.aead.NonceSize()
+	if len(content) < nonceSize {
+		return "", xerrors.Errorf("ciphertext is too short: %w", ErrDecryptColumn)
+	}
+	plain, err := c.aead.Open(nil, content[:nonceSize], content[nonceSize:], nil)
+	if err != nil {
+		return "", xerrors.Errorf("failed to open: %w", ErrDecryptColumn)
+	}
+	return string(plain), nil
+}
+
+// FieldStringEncrypted adds string field stored as base64 encoded ciphertext in database and cache server.
+// Key is set by ColumnEncryptionKey of Rapidash instance warming up the table
+func (s *Struct) FieldStringEncrypted(column string, mode EncryptionMode) *Struct {
+	s.addNewField(column, StringType, StringKind)
+	s.fields[column].encryption = mode
+	return s
+}
+
+// withColumnCipher returns copy of s whose encrypted fields are encrypted by c.
+// s is copied because the same Struct may be warmed up by Rapidash instances with other keys
+func (s *Struct) withColumnCipher(c *columnCipher) *Struct {
+	if c == nil {
+		return s
+	}
+	return s.copyWithColumnCipher(c, map[*Struct]*Struct{})
+}
+
+func (s *Struct) copyWithColumnCipher(c *columnCipher, copied map[*Struct]*Struct) *Struct {
+	if copiedStruct, exists := copied[s]; exists {
+		return copiedStruct
+	}
+	typ := *s
+	typ.cipher = c
+	typ.fields = make(map[string]*StructField, len(s.fields))
+	copied[s] = &typ
+	for column, field := range s.fields {
+		f := *field
+		if f.subtypeStruct != nil {
+			f.subtypeStruct = f.subtypeStruct.copyWithColumnCipher(c, copied)
+		}
+		typ.fields[column] = &f
+	}
+	return &typ
+}
+
+func (f *StructField) encrypt(c *columnCipher, v string) (string, error) {
+	if f.encryption == EncryptionModeNone {
+		return v, nil
+	}
+	if c == nil {
+		return "", xerrors.Errorf("%s: %w", f.column, ErrColumnEncryptionKeyRequired)
+	}
+	encrypted, err := c.encrypt(v, f.encryption)
+	if err != nil {
+		return "", xerrors.Errorf("failed to encrypt %s: %w", f.column, err)
+	}
+	return encrypted, nil
+}
+
+func (f *StructField) decrypt(c *columnCipher, v string) (string, error) {
+	if f.encryption == EncryptionModeNone {
+		return v, nil
+	}
+	if c == nil {
+		return "", xerrors.Errorf("%s: %w", f.column, ErrColumnEncryptionKeyRequired)
+	}
+	decrypted, err := c.decrypt(v)
+	if err != nil {
+		return "", xerrors.Errorf("failed to decrypt %s: %w", f.column, err)
+	}
+	return decrypted, nil
+}
+
+func (s *Struct) encryptedField(column string) (*StructField, bool) {
+	field, exists := s.fields[s.columnName(column)]
+	if !exists || field.encryption == EncryptionModeNone {
+		return nil, false
+	}
+	return field, true
+}
+
+func (f *StructField) encryptCondition(c *columnCipher, rawValue interface{}) (interface{}, error) {
+	if f.encryption == EncryptionModeRandom {
+		return nil, xerrors.Errorf("%s: %w", f.column, ErrEncryptedColumnNotSearchable)
+	}
+	v, ok := rawValue.(string)
+	if !ok {
+		// invalid type is reported by validation of condition
+		return rawValue, nil
+	}
+	return f.encrypt(c, v)
+}
+
+// encryptConditions replaces values of Eq/Neq/In conditions for encrypted columns by ciphertext.
+// range conditions can't be used for encrypted columns
+func (s *Struct) encryptConditions(builder *QueryBuilder) error {
+	if builder.isEncrypted {
+		return nil
+	}
+	builder.isEncrypted = true
+	for _, condition := range builder.conditions.conditions {
+		field, exists := s.encryptedField(condition.Column())
+		if !exists {
+			continue
+		}
+		switch c := condition.(type) {
+		case *EQCondition:
+			encrypted, err := field.encryptCondition(s.cipher, c.rawValue)
+			if err != nil {
+				return xerrors.Errorf("failed to encrypt condition: %w", err)
+			}
+			c.Release()
+			c.rawValue = encrypted
+		case *NEQCondition:
+			encrypted, err := field.encryptCondition(s.cipher, c.rawValue)
+			if err != nil {
+				return xerrors.Errorf("failed to encrypt condition: %w", err)
+			}
+			c.Release()
+			c.rawValue = encrypted
+		case *INCondition:
+			values, ok := c.rawValues.([]string)
+			if !ok {
+				continue
+			}
+			encryptedValues := make([]string, 0, len(values))
+			for _, v := range values {
+				encrypted, err := field.encryptCondition(s.cipher, v)
+				if err != nil {
+					return xerrors.Errorf("failed to encrypt condition: %w", err)
+				}
+				encryptedValues = append(encryptedValues, encrypted.(string))
+			}
+			c.Release()
+			c.rawValues = encryptedValues
+		default:
+			return xerrors.Errorf("%s.%s: %w", s.tableName, field.column, ErrEncryptedColumnNotSearchable)
+		}
+	}
+	return nil
+}
+
+// encryptUpdateMap returns copy of updateMap whose values for encrypted columns are replaced by ciphertext
+func (s *Struct) encryptUpdateMap(updateMap map[string]interface{}) (map[string]interface{}, error) {
+	encryptedMap := make(map[string]interface{}, len(updateMap))
+	for column, value := range updateMap {
+		encryptedMap[column] = value
+		field, exists := s.encryptedField(column)
+		if !exists {
+			continue
+		}
+		var v string
+		switch rv := value.(type) {
+		case string:
+			v = rv
+		case *string:
+			if rv == nil {
+				continue
+			}
+			v = *rv
+		default:
+			continue
+		}
+		encrypted, err := field.encrypt(s.cipher, v)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to encrypt %s.%s: %w", s.tableName, column, err)
+		}
+		encryptedMap[column] = encrypted
+	}
+	return encryptedMap, nil
+}
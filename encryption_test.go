@@ -0,0 +1,91 @@
+package rapidash
+
+import (
+	"testing"
+
+	"golang.org/x/xerrors"
+)
+
+func TestColumnEncryption(t *testing.T) {
+	_, err := New(ColumnEncryptionKey([]byte("short")))
+	Equal(t, xerrors.Is(err, ErrInvalidEncryptionKey), true)
+	r, err := New(ColumnEncryptionKey([]byte("0123456789abcdef")))
+	NoError(t, err)
+
+	plainType := NewStruct("users").
+		FieldUint64("id").
+		FieldStringEncrypted("email", EncryptionModeDeterministic).
+		FieldStringEncrypted("memo", EncryptionModeRandom)
+	typ := NewSecondLevelCache(plainType, nil, r.tableOption("users")).typ
+	encode := func() *StructValue {
+		enc := NewStructEncoder(typ, NewValueFactory())
+		enc.Uint64("id", 1)
+		enc.String("email", "user@example.com")
+		enc.String("memo", "secret")
+		NoError(t, enc.Error())
+		return enc.value
+	}
+	t.Run("encrypt value", func(t *testing.T) {
+		v1 := encode()
+		v2 := encode()
+		if v1.fields["email"].stringValue == "user@example.com" {
+			t.Fatal("email is not encrypted")
+		}
+		Equal(t, v1.fields["email"].stringValue, v2.fields["email"].stringValue)
+		if v1.fields["memo"].stringValue == v2.fields["memo"].stringValue {
+			t.Fatal("random mode produces the same ciphertext")
+		}
+		Equal(t, v1.String("email"), "user@example.com")
+		Equal(t, v2.String("memo"), "secret")
+		NoError(t, v1.Error())
+	})
+	t.Run("encrypt conditions", func(t *testing.T) {
+		v := encode()
+		builder := NewQueryBuilder("users").Eq("email", "user@example.com")
+		NoError(t, typ.encryptConditions(builder))
+		Equal(t, builder.conditions.conditions[0].(*EQCondition).rawValue, v.fields["email"].stringValue)
+		// encrypted only once
+		NoError(t, typ.encryptConditions(builder))
+		Equal(t, builder.conditions.conditions[0].(*EQCondition).rawValue, v.fields["email"].stringValue)
+
+		builder = NewQueryBuilder("users").In("email", []string{"user@example.com"})
+		NoError(t, typ.encryptConditions(builder))
+		Equal(t, builder.inCondition.rawValues, []string{v.fields["email"].stringValue})
+
+		err := typ.encryptConditions(NewQueryBuilder("users").Eq("memo", "secret"))
+		Equal(t, xerrors.Is(err, ErrEncryptedColumnNotSearchable), true)
+		err = typ.encryptConditions(NewQueryBuilder("users").Gt("email", "a"))
+		Equal(t, xerrors.Is(err, ErrEncryptedColumnNotSearchable), true)
+	})
+	t.Run("key of instance", func(t *testing.T) {
+		enc := NewStructEncoder(plainType, NewValueFactory())
+		enc.String("email", "user@example.com")
+		Equal(t, xerrors.Is(enc.Error(), ErrColumnEncryptionKeyRequired), true)
+
+		other, err := New(ColumnEncryptionKey([]byte("fedcba9876543210")))
+		NoError(t, err)
+		otherType := NewSecondLevelCache(plainType, nil, other.tableOption("users")).typ
+		enc = NewStructEncoder(otherType, NewValueFactory())
+		enc.String("email", "user@example.com")
+		NoError(t, enc.Error())
+		if enc.value.fields["email"].stringValue == encode().fields["email"].stringValue {
+			t.Fatal("email must be encrypted by key of each instance")
+		}
+	})
+	t.Run("merged struct", func(t *testing.T) {
+		merged := NewStruct("users").FieldUint64("id").Merge(NewStruct("").FieldStringEncrypted("email", EncryptionModeDeterministic))
+		merged = merged.withColumnCipher(r.opt.columnCipher)
+		enc := NewStructEncoder(merged, NewValueFactory())
+		enc.Uint64("id", 1)
+		enc.String("email", "user@example.com")
+		NoError(t, enc.Error())
+		Equal(t, enc.value.fields["email"].stringValue, encode().fields["email"].stringValue)
+	})
+	t.Run("encrypt update map", func(t *testing.T) {
+		v := encode()
+		updateMap, err := typ.encryptUpdateMap(map[string]interface{}{"id": uint64(2), "email": "user@example.com"})
+		NoError(t, err)
+		Equal(t, updateMap["id"], uint64(2))
+		Equal(t, updateMap["email"], v.fields["email"].stringValue)
+	})
+}
@@ -13,13 +13,42 @@ var (
 	ErrCacheCommit                 = xerrors.New("failed cache commit")
 	ErrCleanUpCache                = xerrors.New("failed clean up cache")
 	ErrRecoverCache                = xerrors.New("failed recover cache")
+	ErrKeyRegistryDisabled         = xerrors.New("key registry is disabled")
+	ErrDeleteCacheByTable          = xerrors.New("failed delete cache by table")
+	ErrVersionConflict             = xerrors.New("value is modified by other process since it was read")
+	ErrInvalidRateLimitWindow      = xerrors.New("invalid rate limit window")
+	ErrConcurrentTxUse             = xerrors.New("transaction is used from multiple goroutines concurrently")
+	ErrStashSizeExceeded           = xerrors.New("transaction stash exceeds max size")
+	ErrTableNotWarmedUp            = xerrors.New("table is not warmed up. call (*Rapidash).WarmUp for the table before use")
+	ErrReadOnlyTransaction         = xerrors.New("transaction is read only")
+	ErrInvalidCacheOnlyTable       = xerrors.New("invalid cache only table")
+	ErrUnsupportedCacheOnlyQuery   = xerrors.New("query is not supported by cache only table")
+	ErrCacheOnlyPrimaryKeyRequired = xerrors.New("value of cache only table requires primary key")
+	ErrCacheOnlyDuplicateEntry     = xerrors.New("duplicate entry for primary key of cache only table")
+	ErrTxExpired                   = xerrors.New("transaction exceeds max duration. call Rollback instead")
+	ErrLogWriterClosed             = xerrors.New("log writer is already closed")
+	ErrTxAborted                   = xerrors.New("transaction is aborted by panic in Coder. call Rollback instead")
+	ErrCoderPanic                  = xerrors.New("panic occurred in EncodeRapidash or DecodeRapidash")
+	ErrUnknownCacheServer          = xerrors.New("cache server is not registered. call RegisterCacheServer")
+	ErrInvalidEncryptionKey        = xerrors.New("column encryption key must be 16, 24 or 32 bytes")
+	ErrColumnEncryptionKeyRequired = xerrors.New("column encryption key is required for encrypted column. set ColumnEncryptionKey")
 )
 
 var (
-	ErrInvalidQuery         = xerrors.New("query builder includes not equal query")
-	ErrLookUpIndexFromQuery = xerrors.New("cannot lookup index from query")
-	ErrMultipleINQueries    = xerrors.New("multiple IN queries are not supported")
-	ErrInvalidColumnType    = xerrors.New("invalid column type")
+	ErrInvalidQuery           = xerrors.New("query builder includes not equal query")
+	ErrNoMatchingIndex        = xerrors.New("no index matches query conditions")
+	ErrMultipleINQueries      = xerrors.New("multiple IN queries are not supported")
+	ErrInvalidColumnType      = xerrors.New("invalid column type")
+	ErrInvalidSessionVariable = xerrors.New("invalid session variable")
+	ErrUnknownIndex           = xerrors.New("unknown index name")
+	ErrNullConstraint         = xerrors.New("cannot set NULL to NOT NULL column")
+	ErrInvalidCursor          = xerrors.New("invalid cursor")
+	ErrInvalidPageSize        = xerrors.New("page size must be positive")
+
+	ErrEncryptedColumnNotSearchable = xerrors.New("encrypted column can be searched only by Eq/Neq/In in deterministic mode")
+
+	// Deprecated: use ErrNoMatchingIndex
+	ErrLookUpIndexFromQuery = ErrNoMatchingIndex
 )
 
 var (
@@ -29,21 +58,31 @@ var (
 
 var (
 	ErrCacheMiss                           = xerrors.New("cache miss hit")
+	ErrNotFound                            = xerrors.New("value is not found")
 	ErrCreatePrimaryKeyCacheBySlice        = xerrors.New("cannot create cache for primary key with slice value")
 	ErrCreateUniqueKeyCacheBySlice         = xerrors.New("cannot create cache for unique key with slice value")
 	ErrCreateCacheKeyAtMultiplePrimaryKeys = xerrors.New("cannot find by primary key because table is set multiple primary keys")
 )
 
 var (
-	ErrScanToNilValue    = xerrors.New("cannot scan to nil value")
-	ErrUnknownColumnType = xerrors.New("unknown column type")
-	ErrUnknownColumnName = xerrors.New("unknown column name")
-	ErrInvalidDecodeType = xerrors.New("invalid decode type")
-	ErrInvalidEncodeType = xerrors.New("invalid encode type")
+	ErrScanToNilValue        = xerrors.New("cannot scan to nil value")
+	ErrUnknownColumnType     = xerrors.New("unknown column type")
+	ErrUnknownColumnName     = xerrors.New("unknown column name")
+	ErrInvalidDecodeType     = xerrors.New("invalid decode type")
+	ErrDecryptColumn         = xerrors.New("failed to decrypt column value")
+	ErrInt64Overflow         = xerrors.New("value overflows int64. use uint64 for unsigned BIGINT column")
+	ErrInvalidEncodeType     = xerrors.New("invalid encode type")
+	ErrInvalidMapUnmarshaler = xerrors.New("map unmarshaler requires pointer to map whose value is pointer to Unmarshaler")
+	ErrInvalidReflectType    = xerrors.New("reflect coder requires pointer to struct or pointer to slice of struct")
+	ErrUnsupportedFieldType  = xerrors.New("unsupported field type")
+	ErrSchemaDrift           = xerrors.New("Struct definition differs from table schema")
 )
 
 var (
-	ErrInvalidCacheKey = xerrors.New("invalid cache key")
+	ErrInvalidCacheKey           = xerrors.New("invalid cache key")
+	ErrUnknownPayloadCodec       = xerrors.New("unknown payload codec")
+	ErrInvalidCodecValue         = xerrors.New("invalid value for codec")
+	ErrInvalidCompactPrimaryKeys = xerrors.New("invalid compact primary keys")
 )
 
 func IsCacheMiss(err error) bool {
@@ -53,6 +92,9 @@ func IsCacheMiss(err error) bool {
 	if xerrors.Is(err, server.ErrCacheMiss) {
 		return true
 	}
+	if xerrors.Is(err, ErrNotFound) {
+		return true
+	}
 	return false
 }
 
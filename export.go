@@ -0,0 +1,116 @@
+package rapidash
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+// exportEntry is a line of exported cache contents. Value is stored payload as it is,
+// and TTL is remaining seconds ( 0 means no expiration )
+type exportEntry struct {
+	Table string `json:"table"`
+	Key   string `json:"key"`
+	Hash  uint32 `json:"hash"`
+	Value []byte `json:"value"`
+	TTL   int64  `json:"ttl,omitempty"`
+}
+
+func (e *exportEntry) cacheKey() server.CacheKey {
+	return &CacheKey{key: e.Key, hash: e.Hash, typ: server.CacheKeyTypeSLC}
+}
+
+// remainingTTL returns remaining expiration of content. it is known only if content is stamped by RevalidateAfter,
+// otherwise table expiration is used. false is returned if content is already expired
+func (c *SecondLevelCache) remainingTTL(content []byte) (time.Duration, bool) {
+	expiration := c.expiration()
+	if expiration <= 0 {
+		return 0, true
+	}
+	stampedAt, ok := payloadStampedAt(content)
+	if !ok {
+		return expiration, true
+	}
+	remaining := expiration - c.opt.now().Sub(stampedAt)
+	// expiration less than a second means no expiration for cache server
+	if remaining < time.Second {
+		return 0, false
+	}
+	return remaining, true
+}
+
+// ExportTable writes cache contents of table registered by SecondLevelCacheTableKeyRegistry to w
+// as JSON lines. Cache server addresses aren't exported, so they can be imported to another cluster
+func (r *Rapidash) ExportTable(ctx context.Context, tableName string, w io.Writer) error {
+	c, exists := r.secondLevelCache(tableName)
+	if !exists {
+		return xerrors.Errorf("unknown table name %s: %w", tableName, ErrTableNotWarmedUp)
+	}
+	registry, err := r.keyRegistry(tableName)
+	if err != nil {
+		return xerrors.Errorf("failed to get key registry: %w", err)
+	}
+	enc := json.NewEncoder(w)
+	for _, key := range registry.Keys() {
+		if err := ctx.Err(); err != nil {
+			return xerrors.Errorf("failed to export %s: %w", tableName, err)
+		}
+		content, err := c.cacheServer.Get(ctx, key)
+		if IsCacheMiss(err) {
+			continue
+		}
+		if err != nil {
+			return xerrors.Errorf("failed to get cache (%s): %w", key, err)
+		}
+		ttl, ok := c.remainingTTL(content.Value)
+		if !ok {
+			continue
+		}
+		if err := enc.Encode(&exportEntry{
+			Table: tableName,
+			Key:   key.String(),
+			Hash:  key.Hash(),
+			Value: content.Value,
+			TTL:   int64(ttl / time.Second),
+		}); err != nil {
+			return xerrors.Errorf("failed to encode entry (%s): %w", key, err)
+		}
+	}
+	return nil
+}
+
+// ImportTable reads cache contents exported by ExportTable and adds them to cache server.
+// Existing values are kept because they may be newer than exported ones
+func (r *Rapidash) ImportTable(ctx context.Context, reader io.Reader) error {
+	dec := json.NewDecoder(reader)
+	for {
+		if err := ctx.Err(); err != nil {
+			return xerrors.Errorf("failed to import: %w", err)
+		}
+		var entry exportEntry
+		if err := dec.Decode(&entry); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return xerrors.Errorf("failed to decode entry: %w", err)
+		}
+		c, exists := r.secondLevelCache(entry.Table)
+		if !exists {
+			return xerrors.Errorf("unknown table name %s: %w", entry.Table, ErrTableNotWarmedUp)
+		}
+		key := entry.cacheKey()
+		if err := c.cacheServer.Add(ctx, key, entry.Value, time.Duration(entry.TTL)*time.Second); err != nil {
+			if xerrors.Is(err, server.ErrMemcacheNotStored) || xerrors.Is(err, server.ErrRedisNotStored) {
+				continue
+			}
+			return xerrors.Errorf("failed to add cache (%s): %w", key, err)
+		}
+		if c.keyRegistry != nil {
+			c.keyRegistry.Add(key)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package rapidash
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+type expirationCacheServer struct {
+	*memoryCacheServer
+	expirations map[string]time.Duration
+}
+
+func (s *expirationCacheServer) Add(ctx context.Context, key server.CacheKey, value []byte, expiration time.Duration) error {
+	if err := s.memoryCacheServer.Add(ctx, key, value, expiration); err != nil {
+		return err
+	}
+	s.expirations[key.String()] = expiration
+	return nil
+}
+
+func newExportRapidash(t *testing.T, cacheServer server.CacheServer, opts ...OptionFunc) *Rapidash {
+	t.Helper()
+	r, err := New(append([]OptionFunc{SecondLevelCacheTableKeyRegistry("user_logins", 10)}, opts...)...)
+	NoError(t, err)
+	slc := NewSecondLevelCache(userLoginType(), cacheServer, r.tableOption("user_logins"))
+	r.secondLevelCaches.set("user_logins", slc)
+	return r
+}
+
+func TestExportTable(t *testing.T) {
+	clock := &testClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	src := newMemoryCacheServer()
+	r := newExportRapidash(t, src,
+		SecondLevelCacheTableExpiration("user_logins", 100*time.Minute),
+		SecondLevelCacheTableRevalidateAfter("user_logins", time.Minute),
+	)
+	slc, _ := r.secondLevelCache("user_logins")
+	slc.opt.clock = clock
+	for _, keyStr := range []string{"r/slc/user_logins/id#1", "r/slc/user_logins/id#2", "r/slc/user_logins/id#3"} {
+		key := &CacheKey{key: keyStr, hash: NewStringValue(keyStr).Hash(), typ: server.CacheKeyTypeSLC}
+		payload, err := slc.encodePayload([]byte(keyStr))
+		NoError(t, err)
+		NoError(t, src.Set(context.Background(), &server.CacheStoreRequest{Key: key, Value: payload}))
+		slc.keyRegistry.Add(key)
+		clock.advance(40 * time.Minute)
+	}
+	// third value is evicted from cache server
+	NoError(t, src.Delete(context.Background(), slc.keyRegistry.Keys()[2]))
+
+	var buf bytes.Buffer
+	NoError(t, r.ExportTable(context.Background(), "user_logins", &buf))
+	// first value is already expired
+	Equal(t, strings.Count(buf.String(), "\n"), 1)
+
+	dst := &expirationCacheServer{memoryCacheServer: newMemoryCacheServer(), expirations: map[string]time.Duration{}}
+	imported := newExportRapidash(t, dst)
+	NoError(t, imported.ImportTable(context.Background(), bytes.NewReader(buf.Bytes())))
+	key := "r/slc/user_logins/id#2"
+	content, err := dst.Get(context.Background(), &CacheKey{key: key})
+	NoError(t, err)
+	Equal(t, bytes.Equal(content.Value, src.values[key]), true)
+	Equal(t, dst.expirations[key], 20*time.Minute)
+	keys, err := imported.CacheKeysByTable("user_logins")
+	NoError(t, err)
+	Equal(t, len(keys), 1)
+
+	t.Run("keep existing value", func(t *testing.T) {
+		NoError(t, imported.ImportTable(context.Background(), bytes.NewReader(buf.Bytes())))
+	})
+	t.Run("unknown table", func(t *testing.T) {
+		err := imported.ImportTable(context.Background(), strings.NewReader(`{"table":"users","key":"k"}`))
+		Equal(t, xerrors.Is(err, ErrTableNotWarmedUp), true)
+	})
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := r.ExportTable(ctx, "user_logins", &bytes.Buffer{})
+		Equal(t, xerrors.Is(err, context.Canceled), true)
+	})
+}
@@ -0,0 +1,61 @@
+package rapidash
+
+import (
+	"context"
+	"time"
+
+	"go.knocknote.io/rapidash/server"
+)
+
+// defaultFillLockExpiration is expiration of lock by FillLockPolicyShortLock if SecondLevelCacheTableFillLockExpiration isn't set
+const defaultFillLockExpiration = time.Second
+
+// FillLockPolicy decides how negative caches and index lists written on cache miss are locked when pessimistic lock is enabled.
+//
+// They are built by reading database, so they don't need the same lock as update of records.
+// Skipping the lock reduces contention with transactions updating the table, but a fill racing with them
+// may write negative cache or index list that is already stale. It remains until it is rewritten by
+// the next update of the index or expires, so tables using FillLockPolicySkip should have short expiration.
+type FillLockPolicy int
+
+const (
+	// FillLockPolicyLock locks negative caches and index lists as well as values of records ( default )
+	FillLockPolicyLock FillLockPolicy = iota
+	// FillLockPolicySkip doesn't lock negative caches and index lists
+	FillLockPolicySkip
+	// FillLockPolicyShortLock locks negative caches and index lists by SecondLevelCacheTableFillLockExpiration,
+	// so lock left by crashed process doesn't block updates for long
+	FillLockPolicyShortLock
+)
+
+func (p FillLockPolicy) String() string {
+	switch p {
+	case FillLockPolicyLock:
+		return "lock"
+	case FillLockPolicySkip:
+		return "skip"
+	case FillLockPolicyShortLock:
+		return "short_lock"
+	}
+	return ""
+}
+
+// lockFillKey locks key of negative cache or index list by FillLockPolicy.
+// cache only table is never rebuilt from database, so its keys are always locked
+func (c *SecondLevelCache) lockFillKey(ctx context.Context, tx *Tx, key server.CacheKey) error {
+	if c.cacheOnly {
+		return c.lockKey(ctx, tx, key)
+	}
+	switch c.opt.FillLockPolicy() {
+	case FillLockPolicySkip:
+		return nil
+	case FillLockPolicyShortLock:
+		return c.lockKeyWithExpiration(ctx, tx, key, c.opt.FillLockExpiration())
+	}
+	return c.lockKey(ctx, tx, key)
+}
+
+// setFill sets negative cache or index list filled on cache miss
+func (c *SecondLevelCache) setFill(ctx context.Context, tx *Tx, key server.CacheKey, value []byte, logenc LogEncoder) error {
+	return c.setWithLock(ctx, tx, key, value, c.expiration(), logenc, c.lockFillKey)
+}
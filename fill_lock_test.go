@@ -0,0 +1,70 @@
+package rapidash
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.knocknote.io/rapidash/server"
+)
+
+func TestFillLockPolicy(t *testing.T) {
+	run := func(t *testing.T, opts ...OptionFunc) (*Tx, *expirationCacheServer) {
+		r, err := New(opts...)
+		NoError(t, err)
+		cacheServer := &expirationCacheServer{memoryCacheServer: newMemoryCacheServer(), expirations: map[string]time.Duration{}}
+		r.cacheServer = cacheServer
+		slc := NewSecondLevelCache(userLoginType(), cacheServer, r.tableOption("user_logins"))
+		slc.primaryKey = NewPrimaryKey(slc.opt, "user_logins", []string{"id"}, slc.typ)
+		r.secondLevelCaches.set("user_logins", slc)
+		tx, err := r.Begin()
+		NoError(t, err)
+
+		negativeKey, err := slc.cacheKeyByPrimaryKeyValue(slc.valueFactory.CreateUint64Value(1))
+		NoError(t, err)
+		NoError(t, slc.setPrimaryKey(context.Background(), tx, negativeKey, nil))
+		indexKey := &CacheKey{key: "r/slc/user_logins/user_id#10", typ: server.CacheKeyTypeSLC}
+		NoError(t, slc.fillKey(context.Background(), tx, indexKey, []server.CacheKey{}))
+		updatedKey, err := slc.cacheKeyByPrimaryKeyValue(slc.valueFactory.CreateUint64Value(2))
+		NoError(t, err)
+		NoError(t, slc.update(context.Background(), tx, updatedKey, []byte("value"), 0, LogString("value")))
+		Equal(t, len(tx.pendingQueries), 3)
+		return tx, cacheServer
+	}
+	t.Run("lock", func(t *testing.T) {
+		tx, _ := run(t)
+		Equal(t, len(tx.lockKeys), 3)
+		NoError(t, tx.Rollback())
+	})
+	t.Run("skip", func(t *testing.T) {
+		tx, _ := run(t, SecondLevelCacheTableFillLockPolicy("user_logins", FillLockPolicySkip))
+		Equal(t, len(tx.lockKeys), 1)
+		Equal(t, tx.lockKeys[0].String(), "r/slc/user_logins/id#2/lock")
+		NoError(t, tx.Rollback())
+	})
+	t.Run("short lock", func(t *testing.T) {
+		tx, cacheServer := run(t,
+			SecondLevelCacheLockExpiration(time.Minute),
+			SecondLevelCacheTableFillLockPolicy("user_logins", FillLockPolicyShortLock),
+			SecondLevelCacheTableFillLockExpiration("user_logins", 2*time.Second),
+		)
+		Equal(t, len(tx.lockKeys), 3)
+		Equal(t, cacheServer.expirations["r/slc/user_logins/id#1/lock"], 2*time.Second)
+		Equal(t, cacheServer.expirations["r/slc/user_logins/user_id#10/lock"], 2*time.Second)
+		Equal(t, cacheServer.expirations["r/slc/user_logins/id#2/lock"], time.Minute)
+		NoError(t, tx.Rollback())
+	})
+	t.Run("config", func(t *testing.T) {
+		policy := "short_lock"
+		expiration := 3 * time.Second
+		cfg := &TableConfig{FillLockPolicy: &policy, FillLockExpiry: &expiration}
+		r, err := New(cfg.Options("user_logins")...)
+		NoError(t, err)
+		opt := r.tableOption("user_logins")
+		Equal(t, opt.FillLockPolicy(), FillLockPolicyShortLock)
+		Equal(t, opt.FillLockExpiration(), 3*time.Second)
+		other := r.tableOption("users")
+		Equal(t, other.FillLockPolicy(), FillLockPolicyLock)
+		Equal(t, other.FillLockExpiration(), defaultFillLockExpiration)
+	})
+}
@@ -87,6 +87,9 @@ func (c *FirstLevelCache) WarmUp(conn *sql.DB) (e error) {
 }
 
 func (c *FirstLevelCache) showCreateTable(conn *sql.DB) (string, error) {
+	if isSQLite(conn) {
+		return showCreateTableSQLite(conn, c.typ.tableName)
+	}
 	var (
 		tbl string
 		ddl string
@@ -214,7 +217,7 @@ func (c *FirstLevelCache) FindByPrimaryKey(key *Value, unmarshaler Unmarshaler)
 		if values.Len() != 1 {
 			return xerrors.Errorf("found duplicate entries ( %d entries ) by primary key", values.Len())
 		}
-		if err := unmarshaler.DecodeRapidash(values); err != nil {
+		if err := decodeRapidash(unmarshaler, values); err != nil {
 			return xerrors.Errorf("failed to decode values: %w", err)
 		}
 	}
@@ -298,21 +301,62 @@ func (c *FirstLevelCache) findByQueryBuilder(builder *QueryBuilder) (*StructSlic
 }
 
 func (c *FirstLevelCache) FindByQueryBuilder(builder *QueryBuilder, unmarshaler Unmarshaler) error {
+	if err := c.typ.encryptConditions(builder); err != nil {
+		return xerrors.Errorf("failed to encrypt conditions: %w", err)
+	}
 	builder.Build(c.valueFactory)
 	defer builder.Release()
 	values, err := c.findByQueryBuilder(builder)
 	if err != nil {
 		return xerrors.Errorf("failed to findByQueryBuilder: %w", err)
 	}
+	values = builder.page(values)
+	if values != nil {
+		builder.info.addRows(values.Len())
+		builder.info.addCacheRows(values.Len())
+	}
 	if values != nil && values.Len() > 0 {
-		if err := unmarshaler.DecodeRapidash(values); err != nil {
+		if err := decodeRapidash(unmarshaler, values); err != nil {
 			return xerrors.Errorf("failed to decode values: %w", err)
 		}
 	}
 	return nil
 }
 
+func (c *FirstLevelCache) FindByQueryBuilderWithCursor(builder *QueryBuilder, cursor string, pageSize int, unmarshaler Unmarshaler) (string, error) {
+	page, err := decodeCursor(cursor)
+	if err != nil {
+		return "", xerrors.Errorf("failed to decode cursor: %w", err)
+	}
+	if pageSize <= 0 {
+		return "", xerrors.Errorf("invalid page size %d: %w", pageSize, ErrInvalidPageSize)
+	}
+	if err := c.typ.encryptConditions(builder); err != nil {
+		return "", xerrors.Errorf("failed to encrypt conditions: %w", err)
+	}
+	builder.Build(c.valueFactory)
+	defer builder.Release()
+	values, err := c.findByQueryBuilder(builder)
+	if err != nil {
+		return "", xerrors.Errorf("failed to findByQueryBuilder: %w", err)
+	}
+	values, next := pageOf(builder.page(values), page, pageSize)
+	if values != nil {
+		builder.info.addRows(values.Len())
+		builder.info.addCacheRows(values.Len())
+	}
+	if values != nil && values.Len() > 0 {
+		if err := decodeRapidash(unmarshaler, values); err != nil {
+			return "", xerrors.Errorf("failed to decode values: %w", err)
+		}
+	}
+	return next, nil
+}
+
 func (c *FirstLevelCache) CountByQueryBuilder(builder *QueryBuilder) (uint64, error) {
+	if err := c.typ.encryptConditions(builder); err != nil {
+		return 0, xerrors.Errorf("failed to encrypt conditions: %w", err)
+	}
 	builder.Build(c.valueFactory)
 	defer builder.Release()
 	values, err := c.findByQueryBuilder(builder)
@@ -332,7 +376,7 @@ func (c *FirstLevelCache) findAll() *StructSliceValue {
 func (c *FirstLevelCache) FindAll(unmarshaler Unmarshaler) error {
 	values := c.findAll()
 	if values != nil && values.Len() > 0 {
-		if err := unmarshaler.DecodeRapidash(values); err != nil {
+		if err := decodeRapidash(unmarshaler, values); err != nil {
 			return xerrors.Errorf("failed to decode values: %w", err)
 		}
 	}
@@ -340,7 +384,13 @@ func (c *FirstLevelCache) FindAll(unmarshaler Unmarshaler) error {
 }
 
 func (c *FirstLevelCache) flatten(leafs []Leaf) *StructSliceValue {
-	values := NewStructSliceValue()
+	size := 0
+	for _, leaf := range leafs {
+		if sliceValue, ok := leaf.(*StructSliceValue); ok {
+			size += sliceValue.Len()
+		}
+	}
+	values := NewStructSliceValueWithCapacity(size)
 	for _, leaf := range leafs {
 		sliceValue, ok := leaf.(*StructSliceValue)
 		if ok {
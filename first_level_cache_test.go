@@ -160,6 +160,12 @@ func TestNEQQuery(t *testing.T) {
 			NoError(t, tx.FindByQueryBuilder(builder, &events))
 			Equal(t, len(events), 3999)
 		}
+		{
+			builder := NewQueryBuilder("events").NotIn("id", []uint64{1, 2})
+			var events EventSlice
+			NoError(t, tx.FindByQueryBuilder(builder, &events))
+			Equal(t, len(events), 3998)
+		}
 	})
 	t.Run("not index column", func(t *testing.T) {
 		{
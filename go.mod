@@ -10,6 +10,7 @@ require (
 	github.com/jessevdk/go-flags v1.4.1-0.20181221193153-c0795c8afcf4
 	github.com/jinzhu/gorm v1.9.9
 	github.com/juju/errors v0.0.0-20190207033735-e65537c515d7 // indirect
+	github.com/prometheus/client_golang v0.9.3-0.20190127221311-3c4408c8b829
 	github.com/rakyll/statik v0.1.6
 	github.com/rs/xid v0.0.0-20180316063648-705291fb2231
 	github.com/rs/zerolog v1.13.0
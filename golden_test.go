@@ -0,0 +1,83 @@
+package rapidash
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata/golden")
+
+func assertGolden(t *testing.T, name, actual string) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name+".golden")
+	if *updateGolden {
+		if err := ioutil.WriteFile(path, []byte(actual), 0644); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	Equal(t, actual, string(expected))
+}
+
+func goldenValue() *StructValue {
+	itemType := NewStruct("items").
+		FieldUint64("id").
+		FieldString("name")
+	typ := NewStruct("users").
+		FieldUint64("id").
+		FieldString("name").
+		FieldInt("level").
+		FieldBool("active").
+		FieldFloat64("score").
+		FieldTime("created_at").
+		FieldSlice("tags", StringType).
+		FieldStruct("profile", itemType).
+		FieldStructSlice("items", itemType).
+		FieldString("nickname").
+		FieldInt("level")
+	factory := NewValueFactory()
+	item := func(id uint64, name string) *StructValue {
+		return &StructValue{typ: itemType, fields: map[string]*Value{
+			"id":   factory.CreateUint64Value(id),
+			"name": factory.CreateStringValue(name),
+		}}
+	}
+	items := NewStructSliceValue()
+	items.Append(item(1, "sword"))
+	items.Append(item(2, "shield"))
+	return &StructValue{typ: typ, fields: map[string]*Value{
+		"id":         factory.CreateUint64Value(1),
+		"name":       factory.CreateStringValue("rapidash"),
+		"level":      factory.CreateIntValue(10),
+		"active":     factory.CreateBoolValue(true),
+		"score":      factory.CreateFloat64Value(1.5),
+		"created_at": factory.CreateTimeValue(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)),
+		"tags":       ValuesToValue([]*Value{factory.CreateStringValue("a"), factory.CreateStringValue("b")}),
+		"profile":    StructValueToValue(item(3, "profile")),
+		"items":      StructSliceValueToValue(items),
+	}}
+}
+
+func TestGoldenColumns(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		assertGolden(t, "columns", strings.Join(goldenValue().typ.Columns(), "\n")+"\n")
+	}
+}
+
+func TestGoldenEncodeLog(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		value := goldenValue()
+		values := NewStructSliceValue()
+		values.Append(value)
+		assertGolden(t, "struct_value_encode_log", value.EncodeLog()+"\n")
+		assertGolden(t, "struct_slice_value_encode_log", values.EncodeLog()+"\n")
+		assertGolden(t, "log_map_encode_log", LogMap{"name": "rapidash", "id": 1, "active": true}.EncodeLog()+"\n")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"time"
 
 	"go.knocknote.io/rapidash/server"
 	"golang.org/x/xerrors"
@@ -64,6 +65,12 @@ type Index struct {
 	Columns          []string
 	ColumnTypeMap    map[string]TypeID
 	cacheKeyTemplate string
+	timeBuckets      map[string]time.Duration
+	keyTransforms    map[string]KeyTransform
+	// schemaHash is set by SecondLevelCacheTableSchemaHashKey option
+	schemaHash string
+	// name is index name in database ( PRIMARY for primary key )
+	name string
 }
 
 func (i *Index) HasColumn(col string) bool {
@@ -119,6 +126,10 @@ func getKeyValueByCacheQuery(query string) (string, string, error) {
 	return keyValuePair[0], keyValuePair[1], nil
 }
 
+func (i *Index) timeBucket(column string) time.Duration {
+	return i.timeBuckets[column]
+}
+
 func (i *Index) createCacheQuery(key, value string) string {
 	return fmt.Sprintf("%s%s%s", key, CacheKeyQueryKeyValueDelimiter, value)
 }
@@ -130,6 +141,15 @@ func (i *Index) subCacheKey(value *StructValue) (string, error) {
 		if indexValue == nil {
 			return "", xerrors.Errorf("failed to get value for %s.%s", i.Table, column)
 		}
+		if bucket := i.timeBucket(column); bucket > 0 && !indexValue.IsNil {
+			bucketValue := NewTimeValue(indexValue.timeValue.Truncate(bucket))
+			subKeys = append(subKeys, i.createCacheQuery(column, bucketValue.String()))
+			continue
+		}
+		if transform := i.keyTransforms[column]; transform != nil && !indexValue.IsNil {
+			subKeys = append(subKeys, i.createCacheQuery(column, transform(indexValue.String())))
+			continue
+		}
 		subKeys = append(subKeys, i.createCacheQuery(column, indexValue.String()))
 	}
 	return strings.Join(subKeys, CacheKeyQueryDelimiter), nil
@@ -140,7 +160,7 @@ func (i *Index) CacheKey(value *StructValue) (*CacheKey, error) {
 	if err != nil {
 		return nil, xerrors.Errorf("cannot get sub cache key: %w", err)
 	}
-	key := fmt.Sprintf(i.cacheKeyTemplate, i.Table, subKey)
+	key := fmt.Sprintf(i.cacheKeyTemplate, i.keyTableName(), subKey)
 	opt := i.Option
 	hash := uint32(0)
 	if opt.shardKey != nil {
@@ -148,9 +168,9 @@ func (i *Index) CacheKey(value *StructValue) (*CacheKey, error) {
 		if !exists {
 			return nil, xerrors.Errorf("cannot find column %s.%s for shard_key", i.Table, opt.ShardKey())
 		}
-		hash = v.Hash()
+		hash = opt.cacheKeyHash.hashValue(v)
 	} else {
-		hash = NewStringValue(key).Hash()
+		hash = opt.cacheKeyHash.hashString(key)
 	}
 	return &CacheKey{key: key, hash: hash}, nil
 }
@@ -194,13 +214,19 @@ func NewUniqueKey(opt *TableOption, tableName string, columns []string, typ *Str
 		Columns:          columns,
 		ColumnTypeMap:    columnTypeMap,
 		cacheKeyTemplate: "r/slc/%s/uq/%s",
+		keyTransforms:    keyTransforms(columns, typ),
 	}
 }
 
 func NewKey(opt *TableOption, tableName string, columns []string, typ *Struct) *Index {
 	columnTypeMap := map[string]TypeID{}
+	timeBuckets := map[string]time.Duration{}
 	for _, column := range columns {
 		columnTypeMap[column] = typ.fields[column].typ
+		// time bucket is available for only key because multiple records share a bucket
+		if bucket := typ.timeBucket(column); bucket > 0 {
+			timeBuckets[column] = bucket
+		}
 	}
 	return &Index{
 		Type:             IndexTypeKey,
@@ -209,5 +235,7 @@ func NewKey(opt *TableOption, tableName string, columns []string, typ *Struct) *
 		Columns:          columns,
 		ColumnTypeMap:    columnTypeMap,
 		cacheKeyTemplate: "r/slc/%s/idx/%s",
+		timeBuckets:      timeBuckets,
+		keyTransforms:    keyTransforms(columns, typ),
 	}
 }
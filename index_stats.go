@@ -0,0 +1,190 @@
+package rapidash
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/blastrain/vitess-sqlparser/sqlparser"
+)
+
+const (
+	defaultLowSelectivityThreshold = 1000
+	indexStatsMinSamples           = 100
+)
+
+// IndexStat is number of primary keys per index key sampled while reading and building cache of index
+type IndexStat struct {
+	Table              string
+	Index              string
+	Samples            uint64
+	AveragePrimaryKeys float64
+	LowSelectivity     bool
+}
+
+type indexStats struct {
+	samples     uint64
+	primaryKeys uint64
+	warned      uint32
+}
+
+func (s *indexStats) average() float64 {
+	samples := atomic.LoadUint64(&s.samples)
+	if samples == 0 {
+		return 0
+	}
+	return float64(atomic.LoadUint64(&s.primaryKeys)) / float64(samples)
+}
+
+func indexName(index *Index) string {
+	return strings.Join(index.Columns, ":")
+}
+
+func (c *SecondLevelCache) setupIndexStats() {
+	c.indexStats = map[string]*indexStats{}
+	for name, index := range c.indexes {
+		if index.Type != IndexTypeKey {
+			continue
+		}
+		c.indexStats[name] = &indexStats{}
+	}
+}
+
+func (c *SecondLevelCache) isLowSelectivity(stats *indexStats) bool {
+	if atomic.LoadUint64(&stats.samples) < indexStatsMinSamples {
+		return false
+	}
+	return stats.average() >= float64(c.opt.LowSelectivityThreshold())
+}
+
+// recordIndexSelectivity samples number of primary keys mapped by a key of index.
+// if index regularly maps to too many records, it warns once to suggest excluding index from cache
+func (c *SecondLevelCache) recordIndexSelectivity(index *Index, primaryKeyNum int) {
+	if index == nil {
+		return
+	}
+	stats, exists := c.indexStats[indexName(index)]
+	if !exists {
+		return
+	}
+	atomic.AddUint64(&stats.samples, 1)
+	atomic.AddUint64(&stats.primaryKeys, uint64(primaryKeyNum))
+	if !c.isLowSelectivity(stats) || !atomic.CompareAndSwapUint32(&stats.warned, 0, 1) {
+		return
+	}
+	log.Warn(fmt.Sprintf(
+		"index (%s) of %s maps to %.1f records per key on average. consider excluding it from cache by SecondLevelCacheTableExcludeIndexes",
+		strings.Replace(indexName(index), ":", ",", -1), c.typ.tableName, stats.average(),
+	))
+}
+
+// IndexStats returns selectivity of non unique indexes
+func (c *SecondLevelCache) IndexStats() []*IndexStat {
+	names := make([]string, 0, len(c.indexStats))
+	for name := range c.indexStats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	stats := make([]*IndexStat, 0, len(names))
+	for _, name := range names {
+		s := c.indexStats[name]
+		stats = append(stats, &IndexStat{
+			Table:              c.typ.tableName,
+			Index:              name,
+			Samples:            atomic.LoadUint64(&s.samples),
+			AveragePrimaryKeys: s.average(),
+			LowSelectivity:     c.isLowSelectivity(s),
+		})
+	}
+	return stats
+}
+
+// excludeIndexes removes indexes excluded by option, so cache entries of them are never built
+func (c *SecondLevelCache) excludeIndexes() {
+	for _, name := range c.opt.ExcludeIndexes() {
+		index, exists := c.indexes[name]
+		if !exists || index.Type == IndexTypePrimaryKey {
+			continue
+		}
+		delete(c.indexes, name)
+		c.addExcludedIndex(name)
+	}
+	for name := range c.excludedIndexes {
+		if _, exists := c.indexes[name]; exists {
+			// registered by other index that isn't ignored
+			delete(c.excludedIndexes, name)
+		}
+	}
+	c.indexColumns = map[string]struct{}{}
+	for _, index := range c.indexes {
+		for _, column := range index.Columns {
+			c.indexColumns[column] = struct{}{}
+		}
+	}
+}
+
+func (c *SecondLevelCache) addExcludedIndex(name string) {
+	if c.excludedIndexes == nil {
+		c.excludedIndexes = map[string]struct{}{}
+	}
+	c.excludedIndexes[name] = struct{}{}
+}
+
+// isIgnoredConstraint returns true if index is ignored by name or one of the columns
+func (c *SecondLevelCache) isIgnoredConstraint(constraint *sqlparser.Constraint) bool {
+	if constraint.Type == sqlparser.ConstraintPrimaryKey {
+		return false
+	}
+	name := strings.Trim(constraint.Name, "`")
+	for _, ignored := range c.opt.IgnoreIndexes() {
+		if name == ignored {
+			return true
+		}
+	}
+	for _, ignored := range c.opt.IgnoreIndexColumns() {
+		for _, key := range constraint.Keys {
+			if key.String() == ignored {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ignoreConstraint regards every index made from constraint as excluded, so queries by them go to database
+func (c *SecondLevelCache) ignoreConstraint(constraint *sqlparser.Constraint) {
+	columns := []string{}
+	for _, key := range constraint.Keys {
+		columns = append(columns, key.String())
+		c.addExcludedIndex(strings.Join(columns, ":"))
+	}
+}
+
+// isExcludedIndexQuery returns true if conditions of builder can be looked up only by excluded index
+func (c *SecondLevelCache) isExcludedIndexQuery(builder *QueryBuilder) bool {
+	if len(c.excludedIndexes) == 0 {
+		return false
+	}
+	columns := map[string]struct{}{}
+	for _, column := range builder.conditions.Columns() {
+		columns[column] = struct{}{}
+	}
+	for name := range c.excludedIndexes {
+		indexColumns := strings.Split(name, ":")
+		if len(indexColumns) != len(columns) {
+			continue
+		}
+		matched := true
+		for _, column := range indexColumns {
+			if _, exists := columns[column]; !exists {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
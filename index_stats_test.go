@@ -0,0 +1,114 @@
+package rapidash
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestIndexStats(t *testing.T) {
+	threshold := 10
+	opt := TableOption{lowSelectivity: &threshold}
+	slc := NewSecondLevelCache(userLoginType(), nil, opt)
+	slc.primaryKey = NewPrimaryKey(slc.opt, "user_logins", []string{"id"}, slc.typ)
+	slc.indexes["id"] = slc.primaryKey
+	slc.indexes["user_id"] = NewKey(slc.opt, "user_logins", []string{"user_id"}, slc.typ)
+	slc.indexes["user_session_id"] = NewKey(slc.opt, "user_logins", []string{"user_session_id"}, slc.typ)
+	slc.setupIndexStats()
+
+	for i := 0; i < indexStatsMinSamples; i++ {
+		slc.recordIndexSelectivity(slc.indexes["user_id"], 2)
+		slc.recordIndexSelectivity(slc.indexes["user_session_id"], 20)
+		slc.recordIndexSelectivity(slc.primaryKey, 1)
+	}
+	stats := slc.IndexStats()
+	Equal(t, len(stats), 2)
+	Equal(t, stats[0].Index, "user_id")
+	Equal(t, stats[0].Samples, uint64(indexStatsMinSamples))
+	Equal(t, stats[0].AveragePrimaryKeys, float64(2))
+	Equal(t, stats[0].LowSelectivity, false)
+	Equal(t, stats[1].Index, "user_session_id")
+	Equal(t, stats[1].AveragePrimaryKeys, float64(20))
+	Equal(t, stats[1].LowSelectivity, true)
+}
+
+func TestExcludeIndexes(t *testing.T) {
+	slc := NewSecondLevelCache(userLoginType(), nil, TableOption{excludeIndexes: []string{"id", "user_session_id"}})
+	slc.primaryKey = NewPrimaryKey(slc.opt, "user_logins", []string{"id"}, slc.typ)
+	slc.indexes["id"] = slc.primaryKey
+	slc.indexes["user_id"] = NewKey(slc.opt, "user_logins", []string{"user_id"}, slc.typ)
+	slc.indexes["user_session_id"] = NewKey(slc.opt, "user_logins", []string{"user_session_id"}, slc.typ)
+	slc.excludeIndexes()
+
+	_, exists := slc.indexes["id"]
+	Equal(t, exists, true)
+	_, exists = slc.indexes["user_session_id"]
+	Equal(t, exists, false)
+	_, exists = slc.indexColumns["user_session_id"]
+	Equal(t, exists, false)
+	Equal(t, slc.isExcludedIndexQuery(NewQueryBuilder("user_logins").Eq("user_session_id", uint64(1))), true)
+	Equal(t, slc.isExcludedIndexQuery(NewQueryBuilder("user_logins").Eq("user_id", uint64(1))), false)
+}
+
+func TestIgnoreIndex(t *testing.T) {
+	ddl := "CREATE TABLE `user_logins` (" +
+		"`id` bigint(20) unsigned NOT NULL," +
+		"`user_id` bigint(20) unsigned NOT NULL," +
+		"`user_session_id` bigint(20) unsigned NOT NULL," +
+		"`login_param_id` bigint(20) unsigned NOT NULL," +
+		"`name` varchar(255) NOT NULL," +
+		"PRIMARY KEY (`id`)," +
+		"UNIQUE KEY `uq_user_session` (`user_id`, `user_session_id`)," +
+		"KEY `idx_login_param` (`login_param_id`)," +
+		"KEY `idx_name` (`name`)" +
+		") ENGINE=InnoDB"
+	slc := NewSecondLevelCache(userLoginType(), nil, TableOption{
+		ignoreIndexes: []string{"idx_login_param"},
+		ignoreColumns: []string{"user_session_id", "id"},
+	})
+	NoError(t, slc.setupIndexes(ddl))
+	names := []string{}
+	for name := range slc.indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	Equal(t, names, []string{"id", "name"})
+	_, exists := slc.indexColumns["login_param_id"]
+	Equal(t, exists, false)
+	Equal(t, slc.isExcludedIndexQuery(NewQueryBuilder("user_logins").Eq("login_param_id", uint64(1))), true)
+	Equal(t, slc.isExcludedIndexQuery(NewQueryBuilder("user_logins").Eq("user_id", uint64(1))), true)
+	Equal(t, slc.isExcludedIndexQuery(NewQueryBuilder("user_logins").Eq("name", "rapidash")), false)
+}
+
+func TestDisableUniquePrefixKeys(t *testing.T) {
+	ddl := "CREATE TABLE `user_logins` (" +
+		"`id` bigint(20) unsigned NOT NULL," +
+		"`user_id` bigint(20) unsigned NOT NULL," +
+		"`user_session_id` bigint(20) unsigned NOT NULL," +
+		"`login_param_id` bigint(20) unsigned NOT NULL," +
+		"`name` varchar(255) NOT NULL," +
+		"PRIMARY KEY (`id`)," +
+		"UNIQUE KEY `uq_user_session` (`user_id`, `user_session_id`)," +
+		"UNIQUE KEY `uq_login_param` (`login_param_id`, `name`)," +
+		"KEY `idx_name` (`name`)" +
+		") ENGINE=InnoDB"
+	slc := NewSecondLevelCache(userLoginType(), nil, TableOption{
+		uniqNoPrefix: []string{"uq_user_session"},
+	})
+	NoError(t, slc.setupIndexes(ddl))
+	names := []string{}
+	for name := range slc.indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	Equal(t, names, []string{"id", "login_param_id", "login_param_id:name", "name", "user_id:user_session_id"})
+	Equal(t, slc.indexes["user_id:user_session_id"].Type, IndexTypeUniqueKey)
+	Equal(t, slc.isExcludedIndexQuery(NewQueryBuilder("user_logins").Eq("user_id", uint64(1))), true)
+	Equal(t, slc.isExcludedIndexQuery(NewQueryBuilder("user_logins").Eq("login_param_id", uint64(1))), false)
+
+	noPrefix := []string{"uq_user_session"}
+	cfg := &TableConfig{NoUniqPrefix: &noPrefix}
+	r, err := New(cfg.Options("user_logins")...)
+	NoError(t, err)
+	opt := r.tableOption("user_logins")
+	Equal(t, opt.UniquePrefixDisabledIndexes(), []string{"uq_user_session"})
+}
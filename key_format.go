@@ -0,0 +1,24 @@
+package rapidash
+
+import (
+	"strconv"
+)
+
+// values of cache key are formatted by strconv with fixed width,
+// so that keys don't depend on platform size of int or fmt rules
+
+func formatIntKey(v int64) string {
+	return strconv.FormatInt(v, 10)
+}
+
+func formatUintKey(v uint64) string {
+	return strconv.FormatUint(v, 10)
+}
+
+func formatFloatKey(v float64, bitSize int) string {
+	return strconv.FormatFloat(v, 'g', -1, bitSize)
+}
+
+func formatBoolKey(v bool) string {
+	return strconv.FormatBool(v)
+}
@@ -0,0 +1,51 @@
+package rapidash
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// keys and hashes below are shared by every process in a fleet. never update them without migration
+func TestCacheKeyFormatGolden(t *testing.T) {
+	values := []struct {
+		value *Value
+		str   string
+		hash  uint32
+	}{
+		{NewIntValue(-1), "-1", 808273962},
+		{NewInt8Value(math.MinInt8), "-128", 2479344567},
+		{NewInt16Value(math.MaxInt16), "32767", 1778696718},
+		{NewInt32Value(math.MinInt32), "-2147483648", 1080688651},
+		{NewInt64Value(math.MinInt64), "-9223372036854775808", 2871333643},
+		{NewUintValue(math.MaxUint32), "4294967295", 1540081739},
+		{NewUint8Value(math.MaxUint8), "255", 741142137},
+		{NewUint16Value(math.MaxUint16), "65535", 2949526720},
+		{NewUint32Value(math.MaxUint32), "4294967295", 1540081739},
+		{NewUint64Value(math.MaxUint64), "18446744073709551615", 3221115162},
+		{NewFloat32Value(0.1), "0.1", 2180199828},
+		{NewFloat64Value(123456789.5), "1.234567895e+08", 2050281523},
+		{NewBoolValue(true), "true", 1},
+		{NewStringValue("rapidash"), `"rapidash"`, 2210141236},
+		{NewTimeValue(time.Date(2019, 1, 2, 3, 4, 5, 6, time.UTC)), "1546398245", 455061650},
+	}
+	for _, v := range values {
+		Equal(t, v.value.String(), v.str)
+		Equal(t, v.value.Hash(), v.hash)
+	}
+
+	typ := NewStruct("user_logins").FieldUint64("id").FieldInt32("user_id").FieldFloat64("score")
+	value := &StructValue{fields: map[string]*Value{
+		"id":      NewUint64Value(math.MaxUint64),
+		"user_id": NewInt32Value(-1),
+		"score":   NewFloat64Value(0.5),
+	}}
+	opt := &TableOption{}
+	key, err := NewPrimaryKey(opt, "user_logins", []string{"id"}, typ).CacheKey(value)
+	NoError(t, err)
+	Equal(t, key.String(), "r/slc/user_logins/id#18446744073709551615")
+	Equal(t, key.Hash(), NewStringValue(key.String()).Hash())
+	key, err = NewKey(opt, "user_logins", []string{"user_id", "score"}, typ).CacheKey(value)
+	NoError(t, err)
+	Equal(t, key.String(), "r/slc/user_logins/idx/user_id#-1&score#0.5")
+}
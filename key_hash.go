@@ -0,0 +1,134 @@
+package rapidash
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"hash/fnv"
+	"math/bits"
+)
+
+// CacheKeyHashType is hash function of cache key used to choose server node
+type CacheKeyHashType int
+
+const (
+	// CacheKeyHashCRC32 is crc32 (IEEE). it is default and compatible with older versions
+	CacheKeyHashCRC32 CacheKeyHashType = iota
+	// CacheKeyHashXXHash64 is xxhash64 folded into 32bit. sequential IDs are distributed well
+	CacheKeyHashXXHash64
+	// CacheKeyHashFNV1a is 32bit FNV-1a
+	CacheKeyHashFNV1a
+)
+
+func (typ CacheKeyHashType) String() string {
+	switch typ {
+	case CacheKeyHashXXHash64:
+		return "xxhash64"
+	case CacheKeyHashFNV1a:
+		return "fnv1a"
+	}
+	return "crc32"
+}
+
+// hashKey is used by (*Value).Hash. It is always crc32 because Value doesn't know Rapidash instance
+func hashKey(key string) uint32 {
+	return hashKeyBytes([]byte(key))
+}
+
+func hashKeyBytes(key []byte) uint32 {
+	return CacheKeyHashCRC32.hash(key)
+}
+
+func (typ CacheKeyHashType) hashString(key string) uint32 {
+	return typ.hash([]byte(key))
+}
+
+// hashValue returns hash of v used as shard key
+func (typ CacheKeyHashType) hashValue(v *Value) uint32 {
+	switch {
+	case typ == CacheKeyHashCRC32:
+		return v.Hash()
+	case v.typ == StringType:
+		return typ.hashString(v.stringValue)
+	case v.typ == BytesType:
+		return typ.hash(v.bytesValue)
+	}
+	return typ.hashString(v.String())
+}
+
+func (typ CacheKeyHashType) hash(key []byte) uint32 {
+	switch typ {
+	case CacheKeyHashXXHash64:
+		h := xxhash64(key)
+		return uint32(h ^ (h >> 32))
+	case CacheKeyHashFNV1a:
+		h := fnv.New32a()
+		h.Write(key)
+		return h.Sum32()
+	}
+	return crc32.ChecksumIEEE(key)
+}
+
+const (
+	xxhPrime1 uint64 = 11400714785074694791
+	xxhPrime2 uint64 = 14029467366897019727
+	xxhPrime3 uint64 = 1609587929392839161
+	xxhPrime4 uint64 = 9650029242287828579
+	xxhPrime5 uint64 = 2870177450012600261
+)
+
+func xxhashRound(acc, input uint64) uint64 {
+	acc += input * xxhPrime2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * xxhPrime1
+}
+
+func xxhashMergeRound(acc, v uint64) uint64 {
+	acc ^= xxhashRound(0, v)
+	return acc*xxhPrime1 + xxhPrime4
+}
+
+// xxhash64 returns XXH64 of b with seed 0
+func xxhash64(b []byte) uint64 {
+	n := len(b)
+	var h uint64
+	if n >= 32 {
+		prime1, prime2 := xxhPrime1, xxhPrime2
+		v1 := prime1 + prime2
+		v2 := prime2
+		v3 := uint64(0)
+		v4 := -prime1
+		for ; len(b) >= 32; b = b[32:] {
+			v1 = xxhashRound(v1, binary.LittleEndian.Uint64(b[0:8]))
+			v2 = xxhashRound(v2, binary.LittleEndian.Uint64(b[8:16]))
+			v3 = xxhashRound(v3, binary.LittleEndian.Uint64(b[16:24]))
+			v4 = xxhashRound(v4, binary.LittleEndian.Uint64(b[24:32]))
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxhashMergeRound(h, v1)
+		h = xxhashMergeRound(h, v2)
+		h = xxhashMergeRound(h, v3)
+		h = xxhashMergeRound(h, v4)
+	} else {
+		h = xxhPrime5
+	}
+	h += uint64(n)
+	for ; len(b) >= 8; b = b[8:] {
+		h ^= xxhashRound(0, binary.LittleEndian.Uint64(b[:8]))
+		h = bits.RotateLeft64(h, 27)*xxhPrime1 + xxhPrime4
+	}
+	if len(b) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(b[:4])) * xxhPrime1
+		h = bits.RotateLeft64(h, 23)*xxhPrime2 + xxhPrime3
+		b = b[4:]
+	}
+	for ; len(b) > 0; b = b[1:] {
+		h ^= uint64(b[0]) * xxhPrime5
+		h = bits.RotateLeft64(h, 11) * xxhPrime1
+	}
+	h ^= h >> 33
+	h *= xxhPrime2
+	h ^= h >> 29
+	h *= xxhPrime3
+	h ^= h >> 32
+	return h
+}
@@ -0,0 +1,45 @@
+package rapidash
+
+import (
+	"hash/crc32"
+	"testing"
+)
+
+func TestXXHash64(t *testing.T) {
+	for _, test := range []struct {
+		src  string
+		hash uint64
+	}{
+		{"", 0xef46db3751d8e999},
+		{"a", 0xd24ec4f1a98c6e5b},
+		{"abc", 0x44bc2cf5ad770999},
+		{"Nobody inspects the spammish repetition", 0xfbcea83c8a378bf1},
+	} {
+		Equal(t, xxhash64([]byte(test.src)), test.hash)
+	}
+}
+
+func TestCacheKeyHash(t *testing.T) {
+	Equal(t, NewUint64Value(12345).Hash(), crc32.ChecksumIEEE([]byte("12345")))
+	r, err := New(CacheKeyHash(CacheKeyHashXXHash64))
+	NoError(t, err)
+	typ := r.tableOption("user_logins").cacheKeyHash
+	Equal(t, typ, CacheKeyHashXXHash64)
+	Equal(t, r.opt.llcOpt.cacheKeyHash, CacheKeyHashXXHash64)
+	Equal(t, typ.hashValue(NewUint64Value(12345)), uint32(xxhash64([]byte("12345"))^(xxhash64([]byte("12345"))>>32)))
+	Equal(t, typ.hashValue(NewBytesValue([]byte("12345"))), typ.hashValue(NewStringValue("12345")))
+	Equal(t, CacheKeyHashFNV1a.hashString(""), uint32(2166136261))
+
+	t.Run("per instance", func(t *testing.T) {
+		defaultHash, err := New()
+		NoError(t, err)
+		Equal(t, defaultHash.tableOption("user_logins").cacheKeyHash, CacheKeyHashCRC32)
+		Equal(t, NewUint64Value(12345).Hash(), crc32.ChecksumIEEE([]byte("12345")))
+		key, err := NewLastLevelCache(nil, r.opt.llcOpt).cacheKey("", "key")
+		NoError(t, err)
+		Equal(t, key.Hash(), typ.hashString("key"))
+		key, err = NewLastLevelCache(nil, defaultHash.opt.llcOpt).cacheKey("", "key")
+		NoError(t, err)
+		Equal(t, key.Hash(), crc32.ChecksumIEEE([]byte("key")))
+	})
+}
@@ -0,0 +1,72 @@
+package rapidash
+
+import (
+	"container/list"
+	"sync"
+
+	"go.knocknote.io/rapidash/server"
+)
+
+// KeyRegistry holds cache keys created by SecondLevelCache up to size.
+// memcached cannot scan keys, so rapidash remembers them by itself.
+// If the number of keys exceeds size, the oldest key is removed from registry.
+type KeyRegistry struct {
+	mu    sync.Mutex
+	size  int
+	keys  *list.List
+	index map[string]*list.Element
+}
+
+func NewKeyRegistry(size int) *KeyRegistry {
+	return &KeyRegistry{
+		size:  size,
+		keys:  list.New(),
+		index: map[string]*list.Element{},
+	}
+}
+
+func (r *KeyRegistry) Add(key server.CacheKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keyStr := key.String()
+	if elem, exists := r.index[keyStr]; exists {
+		elem.Value = key
+		r.keys.MoveToBack(elem)
+		return
+	}
+	r.index[keyStr] = r.keys.PushBack(key)
+	for r.size > 0 && r.keys.Len() > r.size {
+		oldest := r.keys.Front()
+		r.keys.Remove(oldest)
+		delete(r.index, oldest.Value.(server.CacheKey).String())
+	}
+}
+
+func (r *KeyRegistry) Remove(key server.CacheKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keyStr := key.String()
+	elem, exists := r.index[keyStr]
+	if !exists {
+		return
+	}
+	r.keys.Remove(elem)
+	delete(r.index, keyStr)
+}
+
+// Keys returns registered keys in order from oldest
+func (r *KeyRegistry) Keys() []server.CacheKey {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keys := make([]server.CacheKey, 0, r.keys.Len())
+	for elem := r.keys.Front(); elem != nil; elem = elem.Next() {
+		keys = append(keys, elem.Value.(server.CacheKey))
+	}
+	return keys
+}
+
+func (r *KeyRegistry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.keys.Len()
+}
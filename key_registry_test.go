@@ -0,0 +1,22 @@
+package rapidash
+
+import (
+	"testing"
+
+	"go.knocknote.io/rapidash/server"
+)
+
+func TestKeyRegistry(t *testing.T) {
+	registry := NewKeyRegistry(2)
+	keyA := &CacheKey{key: "r/slc/user_logins/id#1"}
+	keyB := &CacheKey{key: "r/slc/user_logins/id#2"}
+	keyC := &CacheKey{key: "r/slc/user_logins/id#3"}
+	registry.Add(keyA)
+	registry.Add(keyB)
+	registry.Add(keyA)
+	registry.Add(keyC)
+	Equal(t, registry.Keys(), []server.CacheKey{keyA, keyC})
+	registry.Remove(keyA)
+	Equal(t, registry.Keys(), []server.CacheKey{keyC})
+	Equal(t, registry.Len(), 1)
+}
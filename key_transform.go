@@ -0,0 +1,53 @@
+package rapidash
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+)
+
+// KeyTransform converts value of column written in cache key of unique key or key.
+// Values of primary key are never transformed because they are parsed from cache key
+type KeyTransform func(value string) string
+
+// HashLongKey returns KeyTransform that replaces value longer than n bytes by its sha1,
+// so cache key built from long string ( like URL or email ) doesn't exceed key length limit of memcached.
+// String value is quoted in cache key, so hashed value never equals value that isn't hashed.
+//
+// Different values can share one cache key only if sha1 collides. Values found by such key are
+// filtered by query condition as time bucket does, so wrong record is never returned,
+// but record whose key is overwritten by the other one is read from database until its key is rebuilt.
+func HashLongKey(n int) KeyTransform {
+	return func(value string) string {
+		if len(value) <= n {
+			return value
+		}
+		sum := sha1.Sum([]byte(value))
+		return "sha1-" + hex.EncodeToString(sum[:])
+	}
+}
+
+// KeyTransform sets transform applied to value of column in cache key of index
+func (s *Struct) KeyTransform(column string, transform KeyTransform) *Struct {
+	if s.keyTransform == nil {
+		s.keyTransform = map[string]KeyTransform{}
+	}
+	s.keyTransform[column] = transform
+	return s
+}
+
+func (s *Struct) keyTransformByColumn(column string) KeyTransform {
+	if s == nil {
+		return nil
+	}
+	return s.keyTransform[column]
+}
+
+func keyTransforms(columns []string, typ *Struct) map[string]KeyTransform {
+	transforms := map[string]KeyTransform{}
+	for _, column := range columns {
+		if transform := typ.keyTransformByColumn(column); transform != nil {
+			transforms[column] = transform
+		}
+	}
+	return transforms
+}
@@ -0,0 +1,54 @@
+package rapidash
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestKeyTransform(t *testing.T) {
+	longName := strings.Repeat("rapidash", 10)
+	typ := userLoginType().KeyTransform("name", HashLongKey(16))
+	slc := NewSecondLevelCache(typ, nil, TableOption{})
+	newValue := func(id uint64, name string) *StructValue {
+		_, value, err := slc.encode(&UserLogin{ID: id, Name: name})
+		NoError(t, err)
+		return value
+	}
+
+	t.Run("hash long value", func(t *testing.T) {
+		transform := HashLongKey(16)
+		Equal(t, transform("rapidash"), "rapidash")
+		hashed := transform(longName)
+		Equal(t, strings.HasPrefix(hashed, "sha1-"), true)
+		Equal(t, len(hashed), len("sha1-")+40)
+		Equal(t, transform(longName), hashed)
+		Equal(t, transform(longName+"a") != hashed, true)
+	})
+	t.Run("cache key", func(t *testing.T) {
+		key, err := NewKey(slc.opt, "user_logins", []string{"name"}, typ).CacheKey(newValue(1, longName))
+		NoError(t, err)
+		Equal(t, key.String(), "r/slc/user_logins/idx/name#"+HashLongKey(16)(strconv.Quote(longName)))
+		key, err = NewUniqueKey(slc.opt, "user_logins", []string{"name"}, typ).CacheKey(newValue(1, "rapidash"))
+		NoError(t, err)
+		Equal(t, key.String(), `r/slc/user_logins/uq/name#"rapidash"`)
+		key, err = NewPrimaryKey(slc.opt, "user_logins", []string{"id", "name"}, typ).CacheKey(newValue(1, longName))
+		NoError(t, err)
+		Equal(t, key.String(), "r/slc/user_logins/id#1&name#"+strconv.Quote(longName))
+	})
+	t.Run("merge", func(t *testing.T) {
+		merged := NewStructFrom(typ, "user_logins")
+		Equal(t, merged.keyTransformByColumn("name") != nil, true)
+		Equal(t, merged.keyTransformByColumn("user_id") == nil, true)
+	})
+	t.Run("filter values sharing key", func(t *testing.T) {
+		values := NewStructSliceValue()
+		values.Append(newValue(1, longName))
+		values.Append(newValue(2, longName+"a"))
+		builder := NewQueryBuilder("user_logins").Eq("name", longName)
+		builder.Build(slc.valueFactory)
+		filtered := slc.filterValuesSharingKey(builder, values)
+		Equal(t, filtered.Len(), 1)
+		Equal(t, filtered.At(0).Uint64("id"), uint64(1))
+	})
+}
@@ -1,7 +1,10 @@
 package rapidash
 
 import (
+	"container/list"
+	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"go.knocknote.io/rapidash/server"
@@ -9,17 +12,104 @@ import (
 )
 
 type LastLevelCache struct {
-	cacheServer server.CacheServer
-	opt         *LastLevelCacheOption
+	cacheServer   server.CacheServer
+	opt           *LastLevelCacheOption
+	negativeCache *negativeCache
 }
 
 func NewLastLevelCache(cacheServer server.CacheServer, opt *LastLevelCacheOption) *LastLevelCache {
 	return &LastLevelCache{
-		cacheServer: cacheServer,
-		opt:         opt,
+		cacheServer:   cacheServer,
+		opt:           opt,
+		negativeCache: newNegativeCache(opt.clock, opt.negativeCacheMaxEntries),
 	}
 }
 
+// defaultNegativeCacheMaxEntries bounds memory of negative cache for keys that are never looked up again
+const defaultNegativeCacheMaxEntries = 10000
+
+// negativeCache keeps keys that were not found in cache server until expiration time.
+// Expired entries are evicted when they are looked up or reach the front of insertion order,
+// and the oldest entry is evicted if entries exceed maxEntries
+type negativeCache struct {
+	mu         sync.Mutex
+	clock      Clock
+	maxEntries int
+	entries    *list.List
+	index      map[string]*list.Element
+}
+
+type negativeCacheEntry struct {
+	key       string
+	expiresAt time.Time
+}
+
+func newNegativeCache(clock Clock, maxEntries int) *negativeCache {
+	return &negativeCache{
+		clock:      clockOrDefault(clock),
+		maxEntries: maxEntries,
+		entries:    list.New(),
+		index:      map[string]*list.Element{},
+	}
+}
+
+func (c *negativeCache) exists(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, exists := c.index[key]
+	if !exists {
+		return false
+	}
+	if c.clock.Now().Before(elem.Value.(*negativeCacheEntry).expiresAt) {
+		return true
+	}
+	c.removeElement(elem)
+	return false
+}
+
+func (c *negativeCache) add(key string, expiration time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.clock.Now()
+	if elem, exists := c.index[key]; exists {
+		c.removeElement(elem)
+	}
+	c.index[key] = c.entries.PushBack(&negativeCacheEntry{key: key, expiresAt: now.Add(expiration)})
+	for c.entries.Len() > 0 {
+		front := c.entries.Front()
+		if now.Before(front.Value.(*negativeCacheEntry).expiresAt) && (c.maxEntries <= 0 || c.entries.Len() <= c.maxEntries) {
+			break
+		}
+		c.removeElement(front)
+	}
+}
+
+func (c *negativeCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, exists := c.index[key]; exists {
+		c.removeElement(elem)
+	}
+}
+
+func (c *negativeCache) removeElement(elem *list.Element) {
+	c.entries.Remove(elem)
+	delete(c.index, elem.Value.(*negativeCacheEntry).key)
+}
+
+func (c *negativeCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries.Len()
+}
+
+func (c *LastLevelCache) negativeCacheExpiration(tag string) time.Duration {
+	if opt, exists := c.opt.tagOpt[tag]; exists && opt.negativeCacheExpiration != nil {
+		return *opt.negativeCacheExpiration
+	}
+	return c.opt.negativeCacheExpiration
+}
+
 func (c *LastLevelCache) cacheKey(tag, key string) (server.CacheKey, error) {
 	cacheKey := &CacheKey{
 		key: fmt.Sprintf("r/llc/%s", key),
@@ -32,27 +122,23 @@ func (c *LastLevelCache) cacheKey(tag, key string) (server.CacheKey, error) {
 		}
 		cacheKey.addr = addr
 	} else if tag != "" {
-		cacheKey.hash = NewStringValue(tag).Hash()
+		cacheKey.hash = c.opt.cacheKeyHash.hashString(tag)
 	} else {
-		cacheKey.hash = NewStringValue(key).Hash()
+		cacheKey.hash = c.opt.cacheKeyHash.hashString(key)
 	}
 	return cacheKey, nil
 }
 
-func (c *LastLevelCache) lockKey(tx *Tx, key server.CacheKey, expiration time.Duration) error {
-	value := &TxValue{
-		id:   tx.id,
-		key:  key.String(),
-		time: time.Now(),
-	}
+func (c *LastLevelCache) lockKey(ctx context.Context, tx *Tx, key server.CacheKey, expiration time.Duration) error {
+	value := tx.newTxValue(key, clockOrDefault(c.opt.clock).Now())
 	bytes, err := value.Marshal()
 	if err != nil {
 		return xerrors.Errorf("cannot marshal value: %w", err)
 	}
 	lockKey := key.LockKey()
-	log.Add(tx.id, lockKey, value)
-	if err := c.cacheServer.Add(lockKey, bytes, expiration); err != nil {
-		content, getErr := c.cacheServer.Get(lockKey)
+	tx.logger().Add(tx.id, lockKey, value)
+	if err := c.cacheServer.Add(ctx, lockKey, bytes, expiration); err != nil {
+		content, getErr := c.cacheServer.Get(ctx, lockKey)
 		if xerrors.Is(getErr, server.ErrCacheMiss) {
 			return xerrors.Errorf("fatal error. cannot add transaction key. but transaction key doesn't exist: %w", err)
 		}
@@ -67,7 +153,7 @@ func (c *LastLevelCache) lockKey(tx *Tx, key server.CacheKey, expiration time.Du
 		}
 		return xerrors.Errorf("lock key (%s) is already added. value is %s: %w", lockKey, value, err)
 	}
-	tx.lockKeys = append(tx.lockKeys, lockKey)
+	tx.addLockKey(lockKey, expiration)
 	return nil
 }
 
@@ -77,7 +163,7 @@ func (c *LastLevelCache) enabledStash(tag string) bool {
 }
 
 func (c *LastLevelCache) shouldPessimisticLock(tag string) bool {
-	opt, exists := c.opt.tagOpt[tag];
+	opt, exists := c.opt.tagOpt[tag]
 	if !exists {
 		return c.opt.pessimisticLock
 	}
@@ -87,7 +173,7 @@ func (c *LastLevelCache) shouldPessimisticLock(tag string) bool {
 	return *opt.pessimisticLock
 }
 func (c *LastLevelCache) shouldOptimisticLock(tag string) bool {
-	opt, exists := c.opt.tagOpt[tag];
+	opt, exists := c.opt.tagOpt[tag]
 	if !exists {
 		return c.opt.optimisticLock
 	}
@@ -97,12 +183,16 @@ func (c *LastLevelCache) shouldOptimisticLock(tag string) bool {
 	return *opt.optimisticLock
 }
 
-func (c *LastLevelCache) set(tx *Tx, tag string, cacheKey server.CacheKey, content []byte, expiration time.Duration) error {
+func (c *LastLevelCache) set(ctx context.Context, tx *Tx, tag string, cacheKey server.CacheKey, content []byte, expiration time.Duration) error {
+	content, err := c.opt.payloadCodecs.compress(content, c.opt.compression)
+	if err != nil {
+		return xerrors.Errorf("failed to encode payload: %w", err)
+	}
 	casID := uint64(0)
 	if c.shouldOptimisticLock(tag) {
 		casID = tx.stash.casIDs[cacheKey.String()]
 	}
-	if err := c.cacheServer.Set(&server.CacheStoreRequest{
+	if err := c.cacheServer.Set(ctx, &server.CacheStoreRequest{
 		Key:        cacheKey,
 		Value:      content,
 		Expiration: expiration,
@@ -110,6 +200,7 @@ func (c *LastLevelCache) set(tx *Tx, tag string, cacheKey server.CacheKey, conte
 	}); err != nil {
 		return xerrors.Errorf("failed to set cache to server: %w", err)
 	}
+	c.negativeCache.remove(cacheKey.String())
 	return nil
 }
 
@@ -123,26 +214,34 @@ func (c *LastLevelCache) existsLockKey(tx *Tx, cacheKey server.CacheKey) bool {
 	return false
 }
 
-func (c *LastLevelCache) Create(tx *Tx, tag, key string, value Type, expiration time.Duration) error {
+func (c *LastLevelCache) Create(ctx context.Context, tx *Tx, tag, key string, value Type, expiration time.Duration) error {
 	cacheKey, err := c.cacheKey(tag, key)
 	if err != nil {
 		return xerrors.Errorf("failed to get cacheKey: %w", err)
 	}
 	content, err := value.Encode()
 	if err != nil {
+		tx.abortByCoderPanic(err)
 		return xerrors.Errorf("failed to encode value: %w", err)
 	}
 	keyStr := cacheKey.String()
+	c.negativeCache.remove(keyStr)
 	if c.enabledStash(tag) {
-		tx.stash.lastLevelCacheKeyToBytes[keyStr] = content
+		if err := tx.stashBytes(keyStr, content); err != nil {
+			return xerrors.Errorf("failed to stash value: %w", err)
+		}
 	}
 	if c.shouldPessimisticLock(tag) {
 		if !c.existsLockKey(tx, cacheKey) {
-			if err := c.lockKey(tx, cacheKey, c.opt.lockExpiration); err != nil {
+			if err := c.lockKey(ctx, tx, cacheKey, c.opt.lockExpiration); err != nil {
 				return xerrors.Errorf("failed to lock key: %w", err)
 			}
 		}
 	}
+	payload, err := c.opt.payloadCodecs.compress(content, c.opt.compression)
+	if err != nil {
+		return xerrors.Errorf("failed to encode payload: %w", err)
+	}
 	var addrStr string
 	if addr := cacheKey.Addr(); addr != nil {
 		addrStr = addr.String()
@@ -156,59 +255,153 @@ func (c *LastLevelCache) Create(tx *Tx, tag, key string, value Type, expiration
 				Type:    server.CacheKeyTypeLLC,
 				Addr:    addrStr,
 			},
-			fn: func() error {
-				if err := c.cacheServer.Add(cacheKey, content, expiration); err != nil {
+			key: cacheKey,
+			fn: func(ctx context.Context) error {
+				if err := c.cacheServer.Add(ctx, cacheKey, payload, expiration); err != nil {
 					return xerrors.Errorf("failed to add cache to server: %w", err)
 				}
+				// other transaction may cache the key as not found until commit
+				c.negativeCache.remove(keyStr)
 				return nil
 			},
 		}
 		return nil
 	}
-	if err := c.cacheServer.Add(cacheKey, content, expiration); err != nil {
+	if err := c.cacheServer.Add(ctx, cacheKey, payload, expiration); err != nil {
 		return xerrors.Errorf("failed to add cache to server: %w", err)
 	}
+	c.negativeCache.remove(keyStr)
 	return nil
 }
 
-func (c *LastLevelCache) Find(tx *Tx, tag, key string, value Type) error {
+func (c *LastLevelCache) Find(ctx context.Context, tx *Tx, tag, key string, value Type) error {
 	cacheKey, err := c.cacheKey(tag, key)
 	if err != nil {
 		return xerrors.Errorf("failed to get cacheKey: %w", err)
 	}
 	if c.enabledStash(tag) {
 		if content, exists := tx.stash.lastLevelCacheKeyToBytes[cacheKey.String()]; exists {
+			tx.reportGet(SLCStash, true, cacheKey)
 			if err := value.Decode(content); err != nil {
+				tx.abortByCoderPanic(err)
 				return xerrors.Errorf("failed to decode value: %w", err)
 			}
 			return nil
 		}
 	}
-	content, err := c.cacheServer.Get(cacheKey)
+	negativeCacheExpiration := c.negativeCacheExpiration(tag)
+	if negativeCacheExpiration > 0 && c.negativeCache.exists(cacheKey.String()) {
+		tx.reportGet(SLCServer, false, cacheKey)
+		return xerrors.Errorf("%s is cached as not found: %w", cacheKey.String(), ErrNotFound)
+	}
+	content, err := c.cacheServer.Get(ctx, cacheKey)
+	tx.reportGet(SLCServer, err == nil, cacheKey)
 	if err != nil {
+		if IsCacheMiss(err) {
+			if negativeCacheExpiration > 0 {
+				c.negativeCache.add(cacheKey.String(), negativeCacheExpiration)
+			}
+			return xerrors.Errorf("failed to get cache from server: %w", ErrNotFound)
+		}
 		return xerrors.Errorf("failed to get cache from server: %w", err)
 	}
 	tx.stash.casIDs[cacheKey.String()] = content.CasID
-	if err := value.Decode(content.Value); err != nil {
+	payload, err := c.opt.payloadCodecs.decode(content.Value)
+	if err != nil {
+		return xerrors.Errorf("failed to decode payload: %w", err)
+	}
+	if err := value.Decode(payload); err != nil {
+		tx.abortByCoderPanic(err)
 		return xerrors.Errorf("failed to decode value: %w", err)
 	}
 	return nil
 }
 
-func (c *LastLevelCache) Update(tx *Tx, tag, key string, value Type, expiration time.Duration) error {
+// FindWithVersion finds value from cache server ( not stash ) and returns the version of it
+func (c *LastLevelCache) FindWithVersion(ctx context.Context, tx *Tx, tag, key string, value Type) (uint64, error) {
+	cacheKey, err := c.cacheKey(tag, key)
+	if err != nil {
+		return 0, xerrors.Errorf("failed to get cacheKey: %w", err)
+	}
+	content, err := c.cacheServer.Get(ctx, cacheKey)
+	if err != nil {
+		return 0, xerrors.Errorf("failed to get cache from server: %w", err)
+	}
+	tx.stash.casIDs[cacheKey.String()] = content.CasID
+	payload, err := c.opt.payloadCodecs.decode(content.Value)
+	if err != nil {
+		return 0, xerrors.Errorf("failed to decode payload: %w", err)
+	}
+	if err := value.Decode(payload); err != nil {
+		tx.abortByCoderPanic(err)
+		return 0, xerrors.Errorf("failed to decode value: %w", err)
+	}
+	return content.CasID, nil
+}
+
+// UpdateWithVersion sets value to cache server immediately only if the version isn't changed since it was read
+func (c *LastLevelCache) UpdateWithVersion(ctx context.Context, tx *Tx, tag, key string, value Type, version uint64, expiration time.Duration) error {
+	if version == 0 {
+		return xerrors.Errorf("version must be 1 or more: %w", ErrVersionConflict)
+	}
 	content, err := value.Encode()
 	if err != nil {
+		tx.abortByCoderPanic(err)
 		return xerrors.Errorf("failed to encode value: %w", err)
 	}
+	payload, err := c.opt.payloadCodecs.compress(content, c.opt.compression)
+	if err != nil {
+		return xerrors.Errorf("failed to encode payload: %w", err)
+	}
 	cacheKey, err := c.cacheKey(tag, key)
 	if err != nil {
 		return xerrors.Errorf("failed to get cacheKey: %w", err)
 	}
 	keyStr := cacheKey.String()
+	if err := c.cacheServer.Set(ctx, &server.CacheStoreRequest{
+		Key:        cacheKey,
+		Value:      payload,
+		Expiration: expiration,
+		CasID:      version,
+	}); err != nil {
+		if xerrors.Is(err, server.ErrMemcacheCASConflict) ||
+			xerrors.Is(err, server.ErrMemcacheNotStored) ||
+			xerrors.Is(err, server.ErrMemcacheCacheMiss) ||
+			xerrors.Is(err, server.ErrRedisCASConflict) ||
+			xerrors.Is(err, server.ErrRedisCacheMiss) {
+			return xerrors.Errorf("failed to set cache by version %d: %w", version, ErrVersionConflict)
+		}
+		return xerrors.Errorf("failed to set cache to server: %w", err)
+	}
+	c.negativeCache.remove(keyStr)
+	// pending update for the same key must not overwrite this value at commit
+	delete(tx.pendingQueries, keyStr)
+	delete(tx.stash.casIDs, keyStr)
+	if c.enabledStash(tag) {
+		if err := tx.stashBytes(keyStr, content); err != nil {
+			// value is already written to server, so it can be read from there instead
+			tx.unstashBytes(keyStr)
+		}
+	}
+	return nil
+}
+
+func (c *LastLevelCache) Update(ctx context.Context, tx *Tx, tag, key string, value Type, expiration time.Duration) error {
+	content, err := value.Encode()
+	if err != nil {
+		tx.abortByCoderPanic(err)
+		return xerrors.Errorf("failed to encode value: %w", err)
+	}
+	cacheKey, err := c.cacheKey(tag, key)
+	if err != nil {
+		return xerrors.Errorf("failed to get cacheKey: %w", err)
+	}
+	keyStr := cacheKey.String()
+	c.negativeCache.remove(keyStr)
 
 	if c.shouldPessimisticLock(tag) {
 		if !c.existsLockKey(tx, cacheKey) {
-			if err := c.lockKey(tx, cacheKey, c.opt.lockExpiration); err != nil {
+			if err := c.lockKey(ctx, tx, cacheKey, c.opt.lockExpiration); err != nil {
 				return xerrors.Errorf("failed to lock key: %w", err)
 			}
 		}
@@ -218,7 +411,9 @@ func (c *LastLevelCache) Update(tx *Tx, tag, key string, value Type, expiration
 		addrStr = addr.String()
 	}
 	if c.enabledStash(tag) {
-		tx.stash.lastLevelCacheKeyToBytes[keyStr] = content
+		if err := tx.stashBytes(keyStr, content); err != nil {
+			return xerrors.Errorf("failed to stash value: %w", err)
+		}
 		tx.pendingQueries[keyStr] = &PendingQuery{
 			QueryLog: &QueryLog{
 				Command: "set",
@@ -227,8 +422,9 @@ func (c *LastLevelCache) Update(tx *Tx, tag, key string, value Type, expiration
 				Type:    server.CacheKeyTypeLLC,
 				Addr:    addrStr,
 			},
-			fn: func() error {
-				if err := c.set(tx, tag, cacheKey, content, expiration); err != nil {
+			key: cacheKey,
+			fn: func(ctx context.Context) error {
+				if err := c.set(ctx, tx, tag, cacheKey, content, expiration); err != nil {
 					return xerrors.Errorf("failed to set: %w", err)
 				}
 				return nil
@@ -236,20 +432,20 @@ func (c *LastLevelCache) Update(tx *Tx, tag, key string, value Type, expiration
 		}
 		return nil
 	}
-	if err := c.set(tx, tag, cacheKey, content, expiration); err != nil {
+	if err := c.set(ctx, tx, tag, cacheKey, content, expiration); err != nil {
 		return xerrors.Errorf("failed to set: %w", err)
 	}
 	return nil
 }
 
-func (c *LastLevelCache) Delete(tx *Tx, tag, key string) error {
+func (c *LastLevelCache) Delete(ctx context.Context, tx *Tx, tag, key string) error {
 	cacheKey, err := c.cacheKey(tag, key)
 	if err != nil {
 		return xerrors.Errorf("failed to get cacheKey: %w", err)
 	}
 	keyStr := cacheKey.String()
 	if c.enabledStash(tag) {
-		delete(tx.stash.lastLevelCacheKeyToBytes, keyStr)
+		tx.unstashBytes(keyStr)
 	}
 	var addrStr string
 	if addr := cacheKey.Addr(); addr != nil {
@@ -264,8 +460,9 @@ func (c *LastLevelCache) Delete(tx *Tx, tag, key string) error {
 				Type:    server.CacheKeyTypeLLC,
 				Addr:    addrStr,
 			},
-			fn: func() error {
-				if err := c.cacheServer.Delete(cacheKey); err != nil {
+			key: cacheKey,
+			fn: func(ctx context.Context) error {
+				if err := c.cacheServer.Delete(ctx, cacheKey); err != nil {
 					return xerrors.Errorf("failed to delete cache from server: %w", err)
 				}
 				return nil
@@ -273,7 +470,7 @@ func (c *LastLevelCache) Delete(tx *Tx, tag, key string) error {
 		}
 		return nil
 	}
-	if err := c.cacheServer.Delete(cacheKey); err != nil {
+	if err := c.cacheServer.Delete(ctx, cacheKey); err != nil {
 		return xerrors.Errorf("failed to delete cache from server: %w", err)
 	}
 	return nil
@@ -1039,6 +1039,41 @@ func TestLLCStringSlice(t *testing.T) {
 	}
 }
 
+func TestLLCStringMap(t *testing.T) {
+	tx, err := cache.Begin()
+	NoError(t, err)
+	defer func() {
+		NoError(t, tx.Rollback())
+	}()
+	NoError(t, tx.Create("string_map", StringMap(map[string]string{"hello": "world", "foo": "bar"})))
+	var v map[string]string
+	NoError(t, tx.Find("string_map", StringMapPtr(&v)))
+	Equal(t, v, map[string]string{"hello": "world", "foo": "bar"})
+}
+
+func TestMapCoder(t *testing.T) {
+	t.Run("sorted keys", func(t *testing.T) {
+		a, err := Int64Map(map[string]int64{"a": 1, "b": 2, "c": 3}).Encode()
+		NoError(t, err)
+		b, err := Int64Map(map[string]int64{"c": 3, "b": 2, "a": 1}).Encode()
+		NoError(t, err)
+		Equal(t, a, b)
+	})
+	t.Run("round trip", func(t *testing.T) {
+		content, err := Uint64Map(map[string]uint64{"x": 1, "y": 1 << 40}).Encode()
+		NoError(t, err)
+		var v map[string]uint64
+		NoError(t, Uint64MapPtr(&v).Decode(content))
+		Equal(t, v, map[string]uint64{"x": 1, "y": 1 << 40})
+
+		content, err = BoolMap(map[string]bool{}).Encode()
+		NoError(t, err)
+		var b map[string]bool
+		NoError(t, BoolMapPtr(&b).Decode(content))
+		Equal(t, len(b), 0)
+	})
+}
+
 func TestLLCBoolSlice(t *testing.T) {
 	tx, err := cache.Begin()
 	NoError(t, err)
@@ -1334,3 +1369,116 @@ func TestLLC_IgnoreStash(t *testing.T) {
 	}
 	NoError(t, tx.Commit())
 }
+
+func TestLLC_UpdateWithVersion(t *testing.T) {
+	NoError(t, cache.Flush())
+	tx, err := cache.Begin()
+	NoError(t, err)
+	NoError(t, tx.Create("versioned", Int(1)))
+	NoError(t, tx.Commit())
+
+	tx1, err := cache.Begin()
+	NoError(t, err)
+	tx2, err := cache.Begin()
+	NoError(t, err)
+	var v1, v2 int
+	version1, err := tx1.FindWithVersion("versioned", IntPtr(&v1))
+	NoError(t, err)
+	version2, err := tx2.FindWithVersion("versioned", IntPtr(&v2))
+	NoError(t, err)
+	Equal(t, version1, version2)
+	NoError(t, tx1.UpdateWithVersion("versioned", Int(v1+1), version1))
+	if err := tx2.UpdateWithVersion("versioned", Int(v2+1), version2); !xerrors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected version conflict. but got %+v", err)
+	}
+	NoError(t, tx1.Commit())
+	NoError(t, tx2.Rollback())
+
+	tx, err = cache.Begin()
+	NoError(t, err)
+	var v int
+	NoError(t, tx.Find("versioned", IntPtr(&v)))
+	Equal(t, v, 2)
+	NoError(t, tx.Commit())
+}
+
+func TestLLC_NegativeCache(t *testing.T) {
+	cache, err := New(
+		ServerType(CacheServerTypeMemcached),
+		ServerAddrs([]string{"localhost:11211"}),
+		LastLevelCacheNegativeCacheExpiration(time.Minute),
+	)
+	NoError(t, err)
+	NoError(t, cache.Flush())
+	tx, err := cache.Begin()
+	NoError(t, err)
+	var v int
+	if err := tx.Find("negative", IntPtr(&v)); !xerrors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found error. but got %+v", err)
+	}
+	Equal(t, cache.lastLevelCache.negativeCache.exists("r/llc/negative"), true)
+	NoError(t, tx.Create("negative", Int(1)))
+	NoError(t, tx.Commit())
+
+	tx, err = cache.Begin()
+	NoError(t, err)
+	NoError(t, tx.Find("negative", IntPtr(&v)))
+	Equal(t, v, 1)
+	NoError(t, tx.Commit())
+}
+
+func TestLLC_NegativeCacheEviction(t *testing.T) {
+	clock := &testClock{now: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)}
+	t.Run("expired entries are swept by add", func(t *testing.T) {
+		cache := newNegativeCache(clock, 0)
+		cache.add("r/llc/a", time.Second)
+		cache.add("r/llc/b", time.Second)
+		clock.advance(time.Second)
+		cache.add("r/llc/c", time.Second)
+		Equal(t, cache.len(), 1)
+		Equal(t, cache.exists("r/llc/c"), true)
+	})
+	t.Run("max entries", func(t *testing.T) {
+		cache := newNegativeCache(clock, 2)
+		cache.add("r/llc/a", time.Minute)
+		cache.add("r/llc/b", time.Minute)
+		cache.add("r/llc/a", time.Minute)
+		cache.add("r/llc/c", time.Minute)
+		Equal(t, cache.len(), 2)
+		Equal(t, cache.exists("r/llc/b"), false)
+		Equal(t, cache.exists("r/llc/a"), true)
+		Equal(t, cache.exists("r/llc/c"), true)
+	})
+	t.Run("miss before commit of create", func(t *testing.T) {
+		r, err := New(LastLevelCacheNegativeCacheExpiration(time.Minute))
+		NoError(t, err)
+		cacheServer := newMemoryCacheServer()
+		r.cacheServer = cacheServer
+		r.lastLevelCache = NewLastLevelCache(cacheServer, r.opt.llcOpt)
+		tx, err := r.Begin()
+		NoError(t, err)
+		NoError(t, tx.Create("created", String("rapidash")))
+		other, err := r.Begin()
+		NoError(t, err)
+		var value string
+		Error(t, other.Find("created", StringPtr(&value)))
+		NoError(t, other.Commit())
+		NoError(t, tx.Commit())
+
+		tx, err = r.Begin()
+		NoError(t, err)
+		NoError(t, tx.Find("created", StringPtr(&value)))
+		Equal(t, value, "rapidash")
+		NoError(t, tx.Commit())
+	})
+	t.Run("option", func(t *testing.T) {
+		maxEntries := 1
+		cfg := &LLCConfig{NegativeCacheMaxEntries: &maxEntries}
+		r, err := New(cfg.Options()...)
+		NoError(t, err)
+		Equal(t, r.opt.llcOpt.negativeCacheMaxEntries, 1)
+		r, err = New()
+		NoError(t, err)
+		Equal(t, r.opt.llcOpt.negativeCacheMaxEntries, defaultNegativeCacheMaxEntries)
+	})
+}
@@ -0,0 +1,236 @@
+package rapidash
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+// LocalCacheSize is limit of in-process cache. Zero means unlimited, but at least one of MaxBytes and MaxEntries should be set.
+// MaxAge bounds how long changes committed by other processes are unnoticed
+type LocalCacheSize struct {
+	MaxBytes   int           `yaml:"max_bytes"`
+	MaxEntries int           `yaml:"max_entries"`
+	MaxAge     time.Duration `yaml:"max_age"`
+}
+
+// localCache is in-process LRU cache of primary key contents in front of cache server.
+// Entries are evicted when this process writes the key at commit,
+// but changes committed by other processes aren't noticed until the entry is evicted by LRU or expired.
+// Entry expires by MaxAge, or earlier by expiration of the table or the row.
+// Eviction is recorded by generation, so content read from cache server before eviction isn't written back after it
+type localCache struct {
+	mu         sync.Mutex
+	maxBytes   int
+	maxEntries int
+	maxAge     time.Duration
+	clock      Clock
+	bytes      int
+	entries    *list.List
+	index      map[string]*list.Element
+	gen        uint64
+	evictedGen map[string]uint64
+	minGen     uint64
+}
+
+// maxLocalCacheEvictedKeys bounds evicted keys remembered by local cache.
+// If it is exceeded, they are forgotten and contents read before are never written back
+const maxLocalCacheEvictedKeys = 10000
+
+type localCacheEntry struct {
+	key       string
+	content   *server.CacheGetResponse
+	expiresAt time.Time
+}
+
+func (e *localCacheEntry) isExpired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+func newLocalCache(size LocalCacheSize, clock Clock) *localCache {
+	return &localCache{
+		maxBytes:   size.MaxBytes,
+		maxEntries: size.MaxEntries,
+		maxAge:     size.MaxAge,
+		clock:      clockOrDefault(clock),
+		entries:    list.New(),
+		index:      map[string]*list.Element{},
+		evictedGen: map[string]uint64{},
+	}
+}
+
+// generation returns current generation. It should be taken before reading content to set from cache server
+func (c *localCache) generation() uint64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+func (c *localCache) get(key string) (*server.CacheGetResponse, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, exists := c.index[key]
+	if !exists {
+		return nil, false
+	}
+	if elem.Value.(*localCacheEntry).isExpired(c.clock.Now()) {
+		c.removeElement(elem)
+		return nil, false
+	}
+	c.entries.MoveToBack(elem)
+	return elem.Value.(*localCacheEntry).content, true
+}
+
+// set stores content read at generation gen. It is ignored if key has been evicted since gen, because content may be stale
+func (c *localCache) set(key string, content *server.CacheGetResponse, gen uint64) {
+	if c == nil {
+		return
+	}
+	size := len(key) + len(content.Value)
+	if c.maxBytes > 0 && size > c.maxBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen < c.minGen || c.evictedGen[key] > gen {
+		return
+	}
+	if elem, exists := c.index[key]; exists {
+		c.removeElement(elem)
+	}
+	entry := &localCacheEntry{key: key, content: content}
+	if c.maxAge > 0 {
+		entry.expiresAt = c.clock.Now().Add(c.maxAge)
+	}
+	c.index[key] = c.entries.PushBack(entry)
+	c.bytes += size
+	for (c.maxEntries > 0 && c.entries.Len() > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes) {
+		c.removeElement(c.entries.Front())
+	}
+}
+
+// limitAge shortens remaining age of entry to age ( e.g. expiration of the row ). zero age means no limit
+func (c *localCache) limitAge(key string, age time.Duration) {
+	if c == nil || age <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, exists := c.index[key]
+	if !exists {
+		return
+	}
+	entry := elem.Value.(*localCacheEntry)
+	if expiresAt := c.clock.Now().Add(age); entry.expiresAt.IsZero() || expiresAt.Before(entry.expiresAt) {
+		entry.expiresAt = expiresAt
+	}
+}
+
+func (c *localCache) remove(key string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, exists := c.index[key]; exists {
+		c.removeElement(elem)
+	}
+	c.gen++
+	if len(c.evictedGen) >= maxLocalCacheEvictedKeys {
+		c.evictedGen = map[string]uint64{}
+		c.minGen = c.gen
+	}
+	c.evictedGen[key] = c.gen
+}
+
+func (c *localCache) removeElement(elem *list.Element) {
+	entry := elem.Value.(*localCacheEntry)
+	c.entries.Remove(elem)
+	delete(c.index, entry.key)
+	c.bytes -= len(entry.key) + len(entry.content.Value)
+}
+
+func (c *localCache) len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries.Len()
+}
+
+// getMultiWithLocalCache gets contents of keys from local cache, and only missing keys from cache server.
+// If local entry is stale, cas id of it makes update by optimistic lock fail as conflict
+func (c *SecondLevelCache) getMultiWithLocalCache(ctx context.Context, keys []server.CacheKey) (*server.Iterator, error) {
+	if c.localCache == nil {
+		return c.cacheServer.GetMulti(ctx, keys)
+	}
+	gen := c.localCache.generation()
+	iter := server.NewIterator(keys)
+	missingKeys := []server.CacheKey{}
+	missingIndexes := map[string]int{}
+	for idx, key := range keys {
+		if content, exists := c.localCache.get(key.String()); exists {
+			iter.SetContent(idx, content)
+			continue
+		}
+		iter.SetError(idx, server.ErrCacheMiss)
+		missingKeys = append(missingKeys, key)
+		missingIndexes[key.String()] = idx
+	}
+	if len(missingKeys) == 0 {
+		return iter, nil
+	}
+	serverIter, err := c.cacheServer.GetMulti(ctx, missingKeys)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get from cache server: %w", err)
+	}
+	for serverIter.Next() {
+		key := serverIter.Key().String()
+		idx := missingIndexes[key]
+		if err := serverIter.Error(); err != nil {
+			iter.SetError(idx, err)
+			continue
+		}
+		content := serverIter.Content()
+		iter.SetContent(idx, content)
+		iter.SetError(idx, nil)
+		c.localCache.set(key, content, gen)
+	}
+	return iter, nil
+}
+
+// limitLocalCacheAge makes local entry of value expire no later than value in cache server
+func (c *SecondLevelCache) limitLocalCacheAge(key server.CacheKey, value *StructValue) {
+	if c.localCache == nil || value == nil {
+		return
+	}
+	expiration, expired := c.expirationByValue(value)
+	if expired {
+		c.evictLocalCache(key)
+		return
+	}
+	c.localCache.limitAge(key.String(), expiration)
+}
+
+func (c *SecondLevelCache) evictLocalCache(key server.CacheKey) {
+	c.localCache.remove(key.String())
+}
+
+// evictLocalCache evicts key from local cache of every table
+func (r *Rapidash) evictLocalCache(key server.CacheKey) {
+	r.secondLevelCaches.Range(func(_, cache interface{}) bool {
+		cache.(*SecondLevelCache).evictLocalCache(key)
+		return true
+	})
+}
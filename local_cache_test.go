@@ -0,0 +1,180 @@
+package rapidash
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.knocknote.io/rapidash/server"
+)
+
+type countingCacheServer struct {
+	*memoryCacheServer
+	getMultiKeys int
+}
+
+func (s *countingCacheServer) GetMulti(ctx context.Context, keys []server.CacheKey) (*server.Iterator, error) {
+	s.getMultiKeys += len(keys)
+	return s.memoryCacheServer.GetMulti(ctx, keys)
+}
+
+func TestLocalCache(t *testing.T) {
+	t.Run("max entries", func(t *testing.T) {
+		cache := newLocalCache(LocalCacheSize{MaxEntries: 2}, nil)
+		cache.set("a", &server.CacheGetResponse{Value: []byte("1")}, cache.generation())
+		cache.set("b", &server.CacheGetResponse{Value: []byte("2")}, cache.generation())
+		_, exists := cache.get("a")
+		Equal(t, exists, true)
+		cache.set("c", &server.CacheGetResponse{Value: []byte("3")}, cache.generation())
+		_, exists = cache.get("b")
+		Equal(t, exists, false)
+		Equal(t, cache.len(), 2)
+		cache.remove("a")
+		Equal(t, cache.len(), 1)
+	})
+	t.Run("max bytes", func(t *testing.T) {
+		cache := newLocalCache(LocalCacheSize{MaxBytes: 8}, nil)
+		cache.set("a", &server.CacheGetResponse{Value: []byte("123")}, cache.generation())
+		cache.set("b", &server.CacheGetResponse{Value: []byte("456")}, cache.generation())
+		Equal(t, cache.len(), 2)
+		cache.set("c", &server.CacheGetResponse{Value: []byte("789")}, cache.generation())
+		_, exists := cache.get("a")
+		Equal(t, exists, false)
+		cache.set("d", &server.CacheGetResponse{Value: []byte("too large")}, cache.generation())
+		_, exists = cache.get("d")
+		Equal(t, exists, false)
+		Equal(t, cache.bytes, 8)
+	})
+	t.Run("max age", func(t *testing.T) {
+		clock := &testClock{now: time.Unix(1600000000, 0)}
+		cache := newLocalCache(LocalCacheSize{MaxEntries: 10, MaxAge: time.Minute}, clock)
+		cache.set("a", &server.CacheGetResponse{Value: []byte("1")}, cache.generation())
+		cache.set("b", &server.CacheGetResponse{Value: []byte("2")}, cache.generation())
+		cache.limitAge("b", 10*time.Second)
+		cache.limitAge("a", 2*time.Minute)
+		clock.advance(30 * time.Second)
+		_, exists := cache.get("a")
+		Equal(t, exists, true)
+		_, exists = cache.get("b")
+		Equal(t, exists, false)
+		clock.advance(30 * time.Second)
+		_, exists = cache.get("a")
+		Equal(t, exists, false)
+		Equal(t, cache.len(), 0)
+		Equal(t, cache.bytes, 0)
+	})
+	t.Run("stale content read before eviction", func(t *testing.T) {
+		cache := newLocalCache(LocalCacheSize{MaxEntries: 10}, nil)
+		gen := cache.generation()
+		cache.remove("a")
+		cache.set("a", &server.CacheGetResponse{Value: []byte("stale")}, gen)
+		_, exists := cache.get("a")
+		Equal(t, exists, false)
+		cache.set("b", &server.CacheGetResponse{Value: []byte("2")}, gen)
+		_, exists = cache.get("b")
+		Equal(t, exists, true)
+		cache.set("a", &server.CacheGetResponse{Value: []byte("1")}, cache.generation())
+		_, exists = cache.get("a")
+		Equal(t, exists, true)
+	})
+	t.Run("forget evicted keys", func(t *testing.T) {
+		cache := newLocalCache(LocalCacheSize{MaxEntries: 10}, nil)
+		gen := cache.generation()
+		for i := 0; i <= maxLocalCacheEvictedKeys; i++ {
+			cache.remove(fmt.Sprint(i))
+		}
+		Equal(t, len(cache.evictedGen), 1)
+		cache.set("a", &server.CacheGetResponse{Value: []byte("1")}, gen)
+		_, exists := cache.get("a")
+		Equal(t, exists, false)
+	})
+}
+
+func TestSecondLevelCacheLocalCache(t *testing.T) {
+	r, err := New(SecondLevelCacheTableLocalCache("user_logins", LocalCacheSize{MaxEntries: 10}))
+	NoError(t, err)
+	cacheServer := &countingCacheServer{memoryCacheServer: newMemoryCacheServer()}
+	r.cacheServer = cacheServer
+	slc := NewSecondLevelCache(userLoginType(), cacheServer, r.tableOption("user_logins"))
+	slc.primaryKey = NewPrimaryKey(slc.opt, "user_logins", []string{"id"}, slc.typ)
+	slc.indexes["id"] = slc.primaryKey
+	r.secondLevelCaches.set("user_logins", slc)
+
+	key, err := slc.cacheKeyByPrimaryKeyValue(slc.valueFactory.CreateUint64Value(1))
+	NoError(t, err)
+	tx, err := r.Begin(&execRecorder{})
+	NoError(t, err)
+	_, value, err := slc.encode(&UserLogin{ID: 1, UserID: 10, Name: "rapidash"})
+	NoError(t, err)
+	NoError(t, slc.setPrimaryKey(context.Background(), tx, key, value))
+	NoError(t, tx.Commit())
+
+	find := func(t *testing.T) *UserLogin {
+		tx, err := r.Begin(&execRecorder{})
+		NoError(t, err)
+		var userLogin UserLogin
+		NoError(t, tx.FindByQueryBuilder(NewQueryBuilder("user_logins").Eq("id", uint64(1)), &userLogin))
+		NoError(t, tx.Commit())
+		return &userLogin
+	}
+	t.Run("second read is served by local cache", func(t *testing.T) {
+		Equal(t, find(t).Name, "rapidash")
+		Equal(t, cacheServer.getMultiKeys, 1)
+		Equal(t, find(t).Name, "rapidash")
+		Equal(t, cacheServer.getMultiKeys, 1)
+	})
+	t.Run("evict on commit", func(t *testing.T) {
+		tx, err := r.Begin(&execRecorder{})
+		NoError(t, err)
+		_, value, err := slc.encode(&UserLogin{ID: 1, UserID: 10, Name: "updated"})
+		NoError(t, err)
+		NoError(t, slc.updatePrimaryKey(context.Background(), tx, key, value))
+		Equal(t, slc.localCache.len(), 1)
+		NoError(t, tx.Commit())
+		Equal(t, slc.localCache.len(), 0)
+		Equal(t, find(t).Name, "updated")
+		Equal(t, cacheServer.getMultiKeys, 2)
+	})
+}
+
+func TestLocalCacheExpiration(t *testing.T) {
+	clock := &testClock{now: time.Unix(1600000000, 0)}
+	r, err := New(
+		ClockSource(clock),
+		SecondLevelCacheTableLocalCache("user_logins", LocalCacheSize{MaxEntries: 10, MaxAge: time.Hour}),
+		SecondLevelCacheTableExpirationColumn("user_logins", "updated_at"),
+	)
+	NoError(t, err)
+	cacheServer := &countingCacheServer{memoryCacheServer: newMemoryCacheServer()}
+	r.cacheServer = cacheServer
+	slc := NewSecondLevelCache(userLoginType(), cacheServer, r.tableOption("user_logins"))
+	slc.primaryKey = NewPrimaryKey(slc.opt, "user_logins", []string{"id"}, slc.typ)
+	slc.indexes["id"] = slc.primaryKey
+	r.secondLevelCaches.set("user_logins", slc)
+
+	key, err := slc.cacheKeyByPrimaryKeyValue(slc.valueFactory.CreateUint64Value(1))
+	NoError(t, err)
+	tx, err := r.Begin(&execRecorder{})
+	NoError(t, err)
+	expiresAt := clock.Now().Add(time.Minute)
+	_, value, err := slc.encode(&UserLogin{ID: 1, UserID: 10, Name: "rapidash", UpdatedAt: &expiresAt})
+	NoError(t, err)
+	NoError(t, slc.setPrimaryKey(context.Background(), tx, key, value))
+	NoError(t, tx.Commit())
+
+	tx, err = r.Begin(&execRecorder{})
+	NoError(t, err)
+	var userLogin UserLogin
+	NoError(t, tx.FindByQueryBuilder(NewQueryBuilder("user_logins").Eq("id", uint64(1)), &userLogin))
+	NoError(t, tx.Commit())
+	Equal(t, slc.localCache.len(), 1)
+
+	clock.advance(30 * time.Second)
+	_, exists := slc.localCache.get(key.String())
+	Equal(t, exists, true)
+	// entry expires by the row before MaxAge
+	clock.advance(time.Minute)
+	_, exists = slc.localCache.get(key.String())
+	Equal(t, exists, false)
+}
@@ -0,0 +1,84 @@
+package rapidash
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+var (
+	lockHostname, _ = os.Hostname()
+	lockPID         = os.Getpid()
+)
+
+// LockInfo is lock record of key held by transaction
+type LockInfo struct {
+	TxID     string
+	Key      string
+	Time     time.Time
+	Hostname string
+	PID      int
+	Owner    string
+}
+
+func (tx *Tx) newTxValue(key server.CacheKey, now time.Time) *TxValue {
+	value := &TxValue{
+		id:       tx.id,
+		key:      key.String(),
+		time:     now,
+		hostname: lockHostname,
+		pid:      lockPID,
+	}
+	if tx.r != nil {
+		value.owner = tx.r.opt.lockOwner
+	}
+	return value
+}
+
+func (v *TxValue) lockInfo() *LockInfo {
+	return &LockInfo{
+		TxID:     v.id,
+		Key:      v.key,
+		Time:     v.time,
+		Hostname: v.hostname,
+		PID:      v.pid,
+		Owner:    v.owner,
+	}
+}
+
+// ListLocks returns locks held for registered cache keys of table. SecondLevelCacheTableKeyRegistry option is required
+func (r *Rapidash) ListLocks(tableName string) ([]*LockInfo, error) {
+	locks, err := r.ListLocksContext(context.Background(), tableName)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to ListLocksContext: %w", err)
+	}
+	return locks, nil
+}
+
+// ListLocksContext returns locks held for registered cache keys of table. ctx is used by operations to cache server
+func (r *Rapidash) ListLocksContext(ctx context.Context, tableName string) ([]*LockInfo, error) {
+	registry, err := r.keyRegistry(tableName)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get key registry: %w", err)
+	}
+	locks := []*LockInfo{}
+	for _, key := range registry.Keys() {
+		lockKey := key.LockKey()
+		content, err := r.cacheServer.Get(ctx, lockKey)
+		if IsCacheMiss(err) {
+			continue
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("failed to get lock key (%s): %w", lockKey, err)
+		}
+		value := &TxValue{}
+		if err := value.Unmarshal(content.Value); err != nil {
+			return nil, xerrors.Errorf("failed to unmarshal lock key (%s): %w", lockKey, err)
+		}
+		locks = append(locks, value.lockInfo())
+	}
+	return locks, nil
+}
@@ -0,0 +1,68 @@
+package rapidash
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blastrain/msgpack"
+	"go.knocknote.io/rapidash/server"
+)
+
+type lockCacheServer struct {
+	server.CacheServer
+	values map[string][]byte
+}
+
+func (s *lockCacheServer) Get(ctx context.Context, key server.CacheKey) (*server.CacheGetResponse, error) {
+	value, exists := s.values[key.String()]
+	if !exists {
+		return nil, server.ErrCacheMiss
+	}
+	return &server.CacheGetResponse{Value: value}, nil
+}
+
+func TestTxValueOwner(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	r, err := New(LockOwner("batch"))
+	NoError(t, err)
+	tx, err := r.Begin()
+	NoError(t, err)
+	t.Run("marshal", func(t *testing.T) {
+		content, err := tx.newTxValue(StringCacheKey("key"), now).Marshal()
+		NoError(t, err)
+		value := &TxValue{}
+		NoError(t, value.Unmarshal(content))
+		Equal(t, value.id, tx.id)
+		Equal(t, value.time.Equal(now), true)
+		Equal(t, value.hostname, lockHostname)
+		Equal(t, value.pid, lockPID)
+		Equal(t, value.owner, "batch")
+	})
+	t.Run("lock record of older version", func(t *testing.T) {
+		var buf bytes.Buffer
+		NoError(t, (&TxValue{id: "id", key: "key", time: now}).encodeLockRecord(msgpack.NewEncoder(&buf)))
+		value := &TxValue{}
+		NoError(t, value.Unmarshal(buf.Bytes()))
+		Equal(t, value.id, "id")
+		Equal(t, value.key, "key")
+		Equal(t, value.owner, "")
+	})
+	t.Run("list locks", func(t *testing.T) {
+		content, err := tx.newTxValue(StringCacheKey("key1"), now).Marshal()
+		NoError(t, err)
+		registry := NewKeyRegistry(10)
+		registry.Add(StringCacheKey("key1"))
+		registry.Add(StringCacheKey("key2"))
+		slc := NewSecondLevelCache(userLoginType(), nil, TableOption{})
+		slc.keyRegistry = registry
+		r.cacheServer = &lockCacheServer{values: map[string][]byte{"key1/lock": content}}
+		r.secondLevelCaches.set("user_logins", slc)
+		locks, err := r.ListLocks("user_logins")
+		NoError(t, err)
+		Equal(t, len(locks), 1)
+		Equal(t, locks[0].Key, "key1")
+		Equal(t, locks[0].Owner, "batch")
+	})
+}
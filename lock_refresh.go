@@ -0,0 +1,179 @@
+package rapidash
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+const minLockRefreshInterval = 10 * time.Millisecond
+
+type refreshableLock struct {
+	key        server.CacheKey
+	expiration time.Duration
+}
+
+// lockRefresher extends expiration of lock keys held by Tx until Tx finishes or TxOption.MaxDuration elapses
+type lockRefresher struct {
+	mu    sync.Mutex
+	locks []*refreshableLock
+	added chan struct{}
+	stop  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newLockRefresher() *lockRefresher {
+	return &lockRefresher{
+		added: make(chan struct{}, 1),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+}
+
+func (r *lockRefresher) add(key server.CacheKey, expiration time.Duration) {
+	if expiration <= 0 {
+		// lock without expiration never needs refresh
+		return
+	}
+	r.mu.Lock()
+	r.locks = append(r.locks, &refreshableLock{key: key, expiration: expiration})
+	r.mu.Unlock()
+	select {
+	case r.added <- struct{}{}:
+	default:
+	}
+}
+
+func (r *lockRefresher) snapshot() []*refreshableLock {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	locks := make([]*refreshableLock, len(r.locks))
+	copy(locks, r.locks)
+	return locks
+}
+
+// interval returns half of the shortest lock expiration. it returns 0 if there is no lock to refresh
+func (r *lockRefresher) interval() time.Duration {
+	interval := time.Duration(0)
+	for _, lock := range r.snapshot() {
+		if half := lock.expiration / 2; interval == 0 || half < interval {
+			interval = half
+		}
+	}
+	if interval > 0 && interval < minLockRefreshInterval {
+		return minLockRefreshInterval
+	}
+	return interval
+}
+
+func (r *lockRefresher) close() {
+	r.once.Do(func() {
+		close(r.stop)
+	})
+	<-r.done
+}
+
+func (tx *Tx) startLockRefresh(ctx context.Context) {
+	refresher := newLockRefresher()
+	tx.lockRefresher = refresher
+	clock := clockOrDefault(tx.r.opt.clock)
+	deadline := tx.startedAt.Add(tx.opt.MaxDuration)
+	go func() {
+		defer close(refresher.done)
+		refreshedAt := clock.Now()
+		for refresher.wait(ctx, clock, refreshedAt, deadline) {
+			refreshedAt = clock.Now()
+			for _, lock := range refresher.snapshot() {
+				if err := tx.refreshLock(ctx, lock); err != nil {
+					log.Warn(fmt.Sprintf("failed to refresh lock of transaction %s: %+v", tx.id, err))
+				}
+			}
+		}
+	}()
+}
+
+// wait returns true when locks should be refreshed, and false when refresher should finish.
+// Elapsed time is measured by clock, so deadline follows clock given by ClockSource
+func (r *lockRefresher) wait(ctx context.Context, clock Clock, refreshedAt, deadline time.Time) bool {
+	for {
+		now := clock.Now()
+		if !now.Before(deadline) {
+			return false
+		}
+		wait := deadline.Sub(now)
+		refresh := false
+		if interval := r.interval(); interval > 0 {
+			if next := interval - now.Sub(refreshedAt); next < wait {
+				wait = next
+				refresh = true
+			}
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-r.stop:
+			timer.Stop()
+			return false
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-r.added:
+			// recalculate interval by new lock
+			timer.Stop()
+		case <-timer.C:
+			if refresh {
+				return true
+			}
+			// check deadline again by clock
+		}
+	}
+}
+
+func (tx *Tx) stopLockRefresh() {
+	if tx.lockRefresher != nil {
+		tx.lockRefresher.close()
+	}
+}
+
+func (tx *Tx) addLockKey(key server.CacheKey, expiration time.Duration) {
+	tx.lockKeys = append(tx.lockKeys, key)
+	if tx.lockRefresher != nil {
+		tx.lockRefresher.add(key, expiration)
+	}
+}
+
+// refreshLock extends expiration of lock by CAS, so it never overwrites lock taken by other transaction after expiration
+func (tx *Tx) refreshLock(ctx context.Context, lock *refreshableLock) error {
+	content, err := tx.r.cacheServer.Get(ctx, lock.key)
+	if err != nil {
+		return xerrors.Errorf("failed to get lock key (%s): %w", lock.key, err)
+	}
+	value := &TxValue{}
+	if err := value.Unmarshal(content.Value); err != nil {
+		return xerrors.Errorf("failed to unmarshal lock key (%s): %w", lock.key, err)
+	}
+	if value.id != tx.id {
+		return xerrors.Errorf("lock key (%s) is taken by other transaction. value is %s", lock.key, value)
+	}
+	if err := tx.r.cacheServer.Set(ctx, &server.CacheStoreRequest{
+		Key:        lock.key,
+		Value:      content.Value,
+		Expiration: lock.expiration,
+		CasID:      content.CasID,
+	}); err != nil {
+		return xerrors.Errorf("failed to set lock key (%s): %w", lock.key, err)
+	}
+	return nil
+}
+
+// isExpired returns whether TxOption.MaxDuration has elapsed since Begin
+func (tx *Tx) isExpired() bool {
+	if tx.opt.MaxDuration <= 0 {
+		return false
+	}
+	return clockOrDefault(tx.r.opt.clock).Now().Sub(tx.startedAt) > tx.opt.MaxDuration
+}
@@ -0,0 +1,112 @@
+package rapidash
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+type refreshCacheServer struct {
+	server.CacheServer
+	mu       sync.Mutex
+	values   map[string][]byte
+	requests []*server.CacheStoreRequest
+}
+
+func (s *refreshCacheServer) Get(ctx context.Context, key server.CacheKey) (*server.CacheGetResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, exists := s.values[key.String()]
+	if !exists {
+		return nil, server.ErrCacheMiss
+	}
+	return &server.CacheGetResponse{Value: value, CasID: 1}, nil
+}
+
+func (s *refreshCacheServer) Set(ctx context.Context, req *server.CacheStoreRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+	return nil
+}
+
+func (s *refreshCacheServer) Delete(ctx context.Context, key server.CacheKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, key.String())
+	return nil
+}
+
+func (s *refreshCacheServer) refreshCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.requests)
+}
+
+func TestTxMaxDuration(t *testing.T) {
+	t.Run("expired", func(t *testing.T) {
+		clock := &testClock{now: time.Unix(1600000000, 0)}
+		r, err := New(ClockSource(clock))
+		NoError(t, err)
+		r.cacheServer = &refreshCacheServer{values: map[string][]byte{}}
+		tx, err := r.BeginWithOption(TxOption{MaxDuration: time.Minute})
+		NoError(t, err)
+		NoError(t, tx.enter())
+		tx.leave()
+		clock.advance(2 * time.Minute)
+		Equal(t, xerrors.Is(tx.enter(), ErrTxExpired), true)
+		Equal(t, xerrors.Is(tx.CommitDBOnly(), ErrTxExpired), true)
+		Equal(t, xerrors.Is(tx.Commit(), ErrTxExpired), true)
+		NoError(t, tx.Rollback())
+	})
+	t.Run("expired after commit of database", func(t *testing.T) {
+		clock := &testClock{now: time.Unix(1600000000, 0)}
+		r, err := New(ClockSource(clock))
+		NoError(t, err)
+		cacheServer := &refreshCacheServer{values: map[string][]byte{}}
+		r.cacheServer = cacheServer
+		tx, err := r.BeginWithOption(TxOption{MaxDuration: time.Minute})
+		NoError(t, err)
+		key := StringCacheKey("key").LockKey()
+		content, err := tx.newTxValue(key, clock.Now()).Marshal()
+		NoError(t, err)
+		cacheServer.values[key.String()] = content
+		tx.addLockKey(key, time.Minute)
+		NoError(t, tx.CommitDBOnly())
+		clock.advance(2 * time.Minute)
+		NoError(t, tx.CommitCacheOnly())
+		if _, exists := cacheServer.values[key.String()]; exists {
+			t.Fatal("lock key must be unlocked")
+		}
+		if _, exists := r.activeTxs.Load(tx.ID()); exists {
+			t.Fatal("tx must be finished")
+		}
+	})
+	t.Run("refresh lock", func(t *testing.T) {
+		r, err := New()
+		NoError(t, err)
+		cacheServer := &refreshCacheServer{values: map[string][]byte{}}
+		r.cacheServer = cacheServer
+		tx, err := r.BeginWithOption(TxOption{MaxDuration: time.Minute})
+		NoError(t, err)
+		key := StringCacheKey("key").LockKey()
+		content, err := tx.newTxValue(key, time.Now()).Marshal()
+		NoError(t, err)
+		cacheServer.values[key.String()] = content
+		tx.addLockKey(key, 40*time.Millisecond)
+		time.Sleep(100 * time.Millisecond)
+		NoError(t, tx.Rollback())
+		count := cacheServer.refreshCount()
+		if count == 0 {
+			t.Fatal("lock must be refreshed")
+		}
+		Equal(t, cacheServer.requests[0].CasID, uint64(1))
+		Equal(t, cacheServer.requests[0].Expiration, 40*time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		Equal(t, cacheServer.refreshCount(), count)
+	})
+}
@@ -3,7 +3,7 @@ package rapidash
 import (
 	"encoding/json"
 	"fmt"
-	"os"
+	"io"
 	"strings"
 
 	zerolog "github.com/rs/zerolog"
@@ -25,6 +25,17 @@ type Logger interface {
 	DeleteFromDB(string, string)
 }
 
+// RowsAffectedLogger is optionally implemented by Logger to record number of rows changed by UPDATE/DELETE
+type RowsAffectedLogger interface {
+	RowsAffected(string, string, SLCCommandType, int64)
+}
+
+func logRowsAffected(id, sql string, cmd SLCCommandType, count int64) {
+	if l, ok := log.(RowsAffectedLogger); ok {
+		l.RowsAffected(id, sql, cmd, count)
+	}
+}
+
 var (
 	log         Logger = &DefaultLogger{}
 	isNopLogger        = false
@@ -64,15 +75,15 @@ func setNopLogger() {
 	isNopLogger = true
 }
 
-func setConsoleLogger() {
+func setConsoleLogger(out io.Writer) {
 	log = &DefaultLogger{isConsole: true}
-	zlog.Logger = zlog.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	zlog.Logger = zlog.Output(zerolog.ConsoleWriter{Out: out})
 	isNopLogger = false
 }
 
-func setJSONLogger() {
+func setJSONLogger(out io.Writer) {
 	log = &DefaultLogger{}
-	zlog.Logger = zerolog.New(os.Stderr).With().Timestamp().Logger()
+	zlog.Logger = zerolog.New(out).With().Timestamp().Logger()
 	isNopLogger = false
 }
 
@@ -275,6 +286,16 @@ func (dl *DefaultLogger) DeleteFromDB(id, sql string) {
 		Msg(dl.msg(SLCCommandDelete, "---delete-----[stash]---->[db]"))
 }
 
+func (dl *DefaultLogger) RowsAffected(id, sql string, cmd SLCCommandType, count int64) {
+	zlog.Info().
+		Str("id", id).
+		Str("command", string(cmd)).
+		Str("type", string(SLCDB)).
+		Str("key", sql).
+		Int64("rows_affected", count).
+		Msg(dl.msg(cmd, fmt.Sprintf("---%s-----[db] %d rows affected", cmd, count)))
+}
+
 type NopLogger struct{}
 
 func (*NopLogger) Warn(msg string)                                                          {}
@@ -0,0 +1,180 @@
+package rapidash
+
+import (
+	"io"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+// LogStats has counters of log entries that weren't written
+type LogStats struct {
+	// Dropped is the number of entries dropped because buffer of async logger was full
+	Dropped uint64
+	// Sampled is the number of entries skipped by sampling
+	Sampled uint64
+}
+
+// AsyncLogWriter writes log entries by background goroutine.
+// If buffer is full, the entry is dropped instead of blocking caller.
+// Entries written after Close are dropped with ErrLogWriterClosed.
+type AsyncLogWriter struct {
+	out     io.Writer
+	ch      chan []byte
+	done    chan struct{}
+	dropped uint64
+	mu      sync.RWMutex
+	closed  bool
+}
+
+func NewAsyncLogWriter(out io.Writer, bufferSize int) *AsyncLogWriter {
+	w := &AsyncLogWriter{
+		out:  out,
+		ch:   make(chan []byte, bufferSize),
+		done: make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *AsyncLogWriter) run() {
+	defer close(w.done)
+	for entry := range w.ch {
+		_, _ = w.out.Write(entry)
+	}
+}
+
+func (w *AsyncLogWriter) Write(p []byte) (int, error) {
+	// zerolog reuses p after Write returns
+	entry := make([]byte, len(p))
+	copy(entry, p)
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		atomic.AddUint64(&w.dropped, 1)
+		return 0, xerrors.Errorf("failed to write log: %w", ErrLogWriterClosed)
+	}
+	select {
+	case w.ch <- entry:
+	default:
+		atomic.AddUint64(&w.dropped, 1)
+	}
+	return len(p), nil
+}
+
+func (w *AsyncLogWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Close writes buffered entries and stops background goroutine
+func (w *AsyncLogWriter) Close() error {
+	// Write holds read lock while sending to channel, so channel is never closed during sending
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.ch)
+	}
+	w.mu.Unlock()
+	<-w.done
+	return nil
+}
+
+type samplingLogger struct {
+	Logger
+	rates   map[SLCCommandType]float64
+	sampled uint64
+}
+
+func (l *samplingLogger) skip(cmd SLCCommandType) bool {
+	rate, exists := l.rates[cmd]
+	if !exists || rate >= 1 {
+		return false
+	}
+	if rate > 0 && rand.Float64() < rate {
+		return false
+	}
+	atomic.AddUint64(&l.sampled, 1)
+	return true
+}
+
+func (l *samplingLogger) Add(id string, key server.CacheKey, value LogEncoder) {
+	if l.skip(SLCCommandAdd) {
+		return
+	}
+	l.Logger.Add(id, key, value)
+}
+
+func (l *samplingLogger) Get(id string, typ SLCType, key server.CacheKey, value LogEncoder) {
+	if l.skip(SLCCommandGet) {
+		return
+	}
+	l.Logger.Get(id, typ, key, value)
+}
+
+func (l *samplingLogger) GetFromDB(id, sql string, args interface{}, value LogEncoder) {
+	if l.skip(SLCCommandGet) {
+		return
+	}
+	l.Logger.GetFromDB(id, sql, args, value)
+}
+
+func (l *samplingLogger) GetMulti(id string, typ SLCType, keys []server.CacheKey, value LogEncoder) {
+	if l.skip(SLCCommandGetMulti) {
+		return
+	}
+	l.Logger.GetMulti(id, typ, keys, value)
+}
+
+func (l *samplingLogger) Set(id string, typ SLCType, key server.CacheKey, value LogEncoder) {
+	if l.skip(SLCCommandSet) {
+		return
+	}
+	l.Logger.Set(id, typ, key, value)
+}
+
+func (l *samplingLogger) InsertIntoDB(id, sql string, args interface{}, value LogEncoder) {
+	if l.skip(SLCCommandSet) {
+		return
+	}
+	l.Logger.InsertIntoDB(id, sql, args, value)
+}
+
+func (l *samplingLogger) Update(id string, typ SLCType, key server.CacheKey, value LogEncoder) {
+	if l.skip(SLCCommandUpdate) {
+		return
+	}
+	l.Logger.Update(id, typ, key, value)
+}
+
+func (l *samplingLogger) UpdateForDB(id, sql string, args interface{}, value LogEncoder) {
+	if l.skip(SLCCommandUpdate) {
+		return
+	}
+	l.Logger.UpdateForDB(id, sql, args, value)
+}
+
+func (l *samplingLogger) Delete(id string, typ SLCType, key server.CacheKey) {
+	if l.skip(SLCCommandDelete) {
+		return
+	}
+	l.Logger.Delete(id, typ, key)
+}
+
+func (l *samplingLogger) DeleteFromDB(id, sql string) {
+	if l.skip(SLCCommandDelete) {
+		return
+	}
+	l.Logger.DeleteFromDB(id, sql)
+}
+
+func (l *samplingLogger) RowsAffected(id, sql string, cmd SLCCommandType, count int64) {
+	if l.skip(cmd) {
+		return
+	}
+	if rl, ok := l.Logger.(RowsAffectedLogger); ok {
+		rl.RowsAffected(id, sql, cmd, count)
+	}
+}
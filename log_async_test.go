@@ -0,0 +1,90 @@
+package rapidash
+
+import (
+	"bytes"
+	"io/ioutil"
+	"sync"
+	"testing"
+
+	"golang.org/x/xerrors"
+)
+
+type blockingWriter struct {
+	buf     bytes.Buffer
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return w.buf.Write(p)
+}
+
+func TestAsyncLogWriter(t *testing.T) {
+	t.Run("write all entries", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := NewAsyncLogWriter(&buf, 10)
+		for i := 0; i < 3; i++ {
+			if _, err := w.Write([]byte("log\n")); err != nil {
+				t.Fatalf("%+v", err)
+			}
+		}
+		NoError(t, w.Close())
+		Equal(t, buf.String(), "log\nlog\nlog\n")
+		Equal(t, w.Dropped(), uint64(0))
+	})
+	t.Run("drop entries if buffer is full", func(t *testing.T) {
+		out := &blockingWriter{release: make(chan struct{})}
+		w := NewAsyncLogWriter(out, 1)
+		for i := 0; i < 10; i++ {
+			if _, err := w.Write([]byte("log\n")); err != nil {
+				t.Fatalf("%+v", err)
+			}
+		}
+		close(out.release)
+		NoError(t, w.Close())
+		if w.Dropped() == 0 {
+			t.Fatal("expected dropped entries")
+		}
+		Equal(t, uint64(len(out.buf.String())/4)+w.Dropped(), uint64(10))
+	})
+	t.Run("write after close", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := NewAsyncLogWriter(&buf, 10)
+		NoError(t, w.Close())
+		if _, err := w.Write([]byte("log\n")); !xerrors.Is(err, ErrLogWriterClosed) {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		NoError(t, w.Close())
+		Equal(t, buf.String(), "")
+		Equal(t, w.Dropped(), uint64(1))
+	})
+	t.Run("write concurrently with close", func(t *testing.T) {
+		w := NewAsyncLogWriter(ioutil.Discard, 10)
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					_, _ = w.Write([]byte("log\n"))
+				}
+			}()
+		}
+		NoError(t, w.Close())
+		wg.Wait()
+	})
+}
+
+func TestSamplingLogger(t *testing.T) {
+	logger := &samplingLogger{
+		Logger: &NopLogger{},
+		rates: map[SLCCommandType]float64{
+			SLCCommandGet: 0,
+		},
+	}
+	for i := 0; i < 5; i++ {
+		logger.Get("", SLCServer, nil, nil)
+		logger.Set("", SLCServer, nil, nil)
+	}
+	Equal(t, logger.sampled, uint64(5))
+}
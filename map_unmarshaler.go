@@ -0,0 +1,83 @@
+package rapidash
+
+import (
+	"reflect"
+
+	"golang.org/x/xerrors"
+)
+
+var unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+
+// MapUnmarshaler decodes found values into map keyed by value of column.
+// Map must be like map[uint64]*UserLogin whose value implements Unmarshaler
+type MapUnmarshaler struct {
+	column string
+	m      interface{}
+}
+
+// NewMapUnmarshaler creates MapUnmarshaler that stores values to m ( pointer to map ) by value of column
+func NewMapUnmarshaler(column string, m interface{}) *MapUnmarshaler {
+	return &MapUnmarshaler{column: column, m: m}
+}
+
+func (u *MapUnmarshaler) DecodeRapidash(dec Decoder) error {
+	ptr := reflect.ValueOf(u.m)
+	if ptr.Kind() != reflect.Ptr || ptr.Elem().Kind() != reflect.Map {
+		return xerrors.Errorf("%T: %w", u.m, ErrInvalidMapUnmarshaler)
+	}
+	m := ptr.Elem()
+	valueType := m.Type().Elem()
+	if valueType.Kind() != reflect.Ptr || !valueType.Implements(unmarshalerType) {
+		return xerrors.Errorf("%T: %w", u.m, ErrInvalidMapUnmarshaler)
+	}
+	if m.IsNil() {
+		m.Set(reflect.MakeMapWithSize(m.Type(), dec.Len()))
+	}
+	for i := 0; i < dec.Len(); i++ {
+		d := dec.At(i)
+		key, err := u.key(d, m.Type().Key())
+		if err != nil {
+			return xerrors.Errorf("failed to decode key: %w", err)
+		}
+		value := reflect.New(valueType.Elem())
+		if err := value.Interface().(Unmarshaler).DecodeRapidash(d); err != nil {
+			return xerrors.Errorf("failed to decode value: %w", err)
+		}
+		m.SetMapIndex(key, value)
+	}
+	return nil
+}
+
+func (u *MapUnmarshaler) key(dec Decoder, typ reflect.Type) (reflect.Value, error) {
+	var key interface{}
+	switch typ.Kind() {
+	case reflect.Int:
+		key = dec.Int(u.column)
+	case reflect.Int8:
+		key = dec.Int8(u.column)
+	case reflect.Int16:
+		key = dec.Int16(u.column)
+	case reflect.Int32:
+		key = dec.Int32(u.column)
+	case reflect.Int64:
+		key = dec.Int64(u.column)
+	case reflect.Uint:
+		key = dec.Uint(u.column)
+	case reflect.Uint8:
+		key = dec.Uint8(u.column)
+	case reflect.Uint16:
+		key = dec.Uint16(u.column)
+	case reflect.Uint32:
+		key = dec.Uint32(u.column)
+	case reflect.Uint64:
+		key = dec.Uint64(u.column)
+	case reflect.String:
+		key = dec.String(u.column)
+	default:
+		return reflect.Value{}, xerrors.Errorf("key type %s: %w", typ, ErrInvalidDecodeType)
+	}
+	if err := dec.Error(); err != nil {
+		return reflect.Value{}, xerrors.Errorf("failed to decode %s: %w", u.column, err)
+	}
+	return reflect.ValueOf(key).Convert(typ), nil
+}
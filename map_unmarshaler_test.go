@@ -0,0 +1,59 @@
+package rapidash
+
+import (
+	"testing"
+
+	"golang.org/x/xerrors"
+)
+
+func TestMapUnmarshaler(t *testing.T) {
+	typ := NewStruct("ephemeral_logins").
+		FieldUint64("id").
+		FieldUint64("user_id").
+		FieldUint64("user_session_id").
+		FieldUint64("login_param_id").
+		FieldString("name").
+		FieldTime("created_at").
+		FieldTime("updated_at")
+	r, err := New()
+	NoError(t, err)
+	r.cacheServer = newMemoryCacheServer()
+	NoError(t, r.NewCacheOnlyTable(typ, PrimaryKeySpec("id"), KeySpec("user_id")))
+	tx, err := r.Begin()
+	NoError(t, err)
+	for i := uint64(1); i <= 3; i++ {
+		_, err := tx.CreateByTable("ephemeral_logins", &UserLogin{ID: i, UserID: 10, Name: "rapidash"})
+		NoError(t, err)
+	}
+	builder := func() *QueryBuilder {
+		return NewQueryBuilder("ephemeral_logins").Eq("user_id", uint64(10))
+	}
+	t.Run("keyed by primary key", func(t *testing.T) {
+		var logins map[uint64]*UserLogin
+		NoError(t, tx.FindByQueryBuilder(builder(), NewMapUnmarshaler("id", &logins)))
+		Equal(t, len(logins), 3)
+		Equal(t, logins[2].ID, uint64(2))
+		Equal(t, logins[3].Name, "rapidash")
+	})
+	t.Run("keyed by named type", func(t *testing.T) {
+		type loginID uint64
+		logins := map[loginID]*UserLogin{}
+		NoError(t, tx.FindByQueryBuilder(builder(), NewMapUnmarshaler("id", &logins)))
+		Equal(t, len(logins), 3)
+		Equal(t, logins[loginID(1)].ID, uint64(1))
+	})
+	t.Run("invalid map", func(t *testing.T) {
+		var logins map[uint64]UserLogin
+		err := tx.FindByQueryBuilder(builder(), NewMapUnmarshaler("id", &logins))
+		Equal(t, xerrors.Is(err, ErrInvalidMapUnmarshaler), true)
+		var slice []*UserLogin
+		err = tx.FindByQueryBuilder(builder(), NewMapUnmarshaler("id", &slice))
+		Equal(t, xerrors.Is(err, ErrInvalidMapUnmarshaler), true)
+	})
+	t.Run("unknown column", func(t *testing.T) {
+		var logins map[uint64]*UserLogin
+		err := tx.FindByQueryBuilder(builder(), NewMapUnmarshaler("unknown", &logins))
+		Equal(t, xerrors.Is(err, ErrUnknownColumnName), true)
+	})
+	NoError(t, tx.Commit())
+}
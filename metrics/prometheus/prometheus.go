@@ -0,0 +1,156 @@
+// Package prometheus exports metrics of rapidash to Prometheus.
+//
+// Collector implements rapidash.StatsCollector, so pass it to rapidash.Stats and register it to prometheus.Registerer.
+//
+//	collector := prometheus.NewCollector()
+//	prom.MustRegister(collector)
+//	cache, err := rapidash.New(rapidash.Stats(collector))
+package prometheus
+
+import (
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"go.knocknote.io/rapidash"
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+const (
+	resultSuccess = "success"
+	resultMiss    = "miss"
+	resultError   = "error"
+)
+
+var _ rapidash.StatsCollector = &Collector{}
+
+// Collector is rapidash.StatsCollector and prometheus.Collector
+type Collector struct {
+	cacheHits       *prom.CounterVec
+	cacheMisses     *prom.CounterVec
+	dbQueryDuration *prom.HistogramVec
+	serverOps       *prom.CounterVec
+	serverDuration  *prom.HistogramVec
+}
+
+// Option is option of Collector
+type Option func(*options)
+
+type options struct {
+	namespace string
+	buckets   []float64
+}
+
+// Namespace set prefix of metric names
+func Namespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// Buckets set buckets of duration histograms in seconds. Default is prometheus.DefBuckets
+func Buckets(buckets []float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+// NewCollector creates Collector. Metrics are
+//
+//	slc_cache_hits_total{table,index}
+//	slc_cache_misses_total{table,index}
+//	slc_db_query_duration_seconds{table}
+//	llc_ops_total{op,result}
+//	llc_op_duration_seconds{op}
+//
+// result is success, miss or error.
+func NewCollector(opts ...Option) *Collector {
+	o := &options{buckets: prom.DefBuckets}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Collector{
+		cacheHits: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: o.namespace,
+			Name:      "slc_cache_hits_total",
+			Help:      "Number of queries of second level cache found in cache server.",
+		}, []string{"table", "index"}),
+		cacheMisses: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: o.namespace,
+			Name:      "slc_cache_misses_total",
+			Help:      "Number of queries of second level cache not found in cache server.",
+		}, []string{"table", "index"}),
+		dbQueryDuration: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: o.namespace,
+			Name:      "slc_db_query_duration_seconds",
+			Help:      "Duration of queries to the database by second level cache.",
+			Buckets:   o.buckets,
+		}, []string{"table"}),
+		serverOps: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: o.namespace,
+			Name:      "llc_ops_total",
+			Help:      "Number of operations to cache server.",
+		}, []string{"op", "result"}),
+		serverDuration: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: o.namespace,
+			Name:      "llc_op_duration_seconds",
+			Help:      "Duration of operations to cache server.",
+			Buckets:   o.buckets,
+		}, []string{"op"}),
+	}
+}
+
+func (c *Collector) collectors() []prom.Collector {
+	return []prom.Collector{
+		c.cacheHits,
+		c.cacheMisses,
+		c.dbQueryDuration,
+		c.serverOps,
+		c.serverDuration,
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prom.Desc) {
+	for _, collector := range c.collectors() {
+		collector.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prom.Metric) {
+	for _, collector := range c.collectors() {
+		collector.Collect(ch)
+	}
+}
+
+// OnCacheHit implements rapidash.StatsCollector
+func (c *Collector) OnCacheHit(table, index string) {
+	c.cacheHits.WithLabelValues(table, index).Inc()
+}
+
+// OnCacheMiss implements rapidash.StatsCollector
+func (c *Collector) OnCacheMiss(table, index string) {
+	c.cacheMisses.WithLabelValues(table, index).Inc()
+}
+
+// OnDBQuery implements rapidash.StatsCollector
+func (c *Collector) OnDBQuery(table string, duration time.Duration) {
+	c.dbQueryDuration.WithLabelValues(table).Observe(duration.Seconds())
+}
+
+// OnCacheServerOp implements rapidash.StatsCollector
+func (c *Collector) OnCacheServerOp(op string, duration time.Duration, err error) {
+	c.serverOps.WithLabelValues(op, result(err)).Inc()
+	c.serverDuration.WithLabelValues(op).Observe(duration.Seconds())
+}
+
+func result(err error) string {
+	if err == nil {
+		return resultSuccess
+	}
+	if xerrors.Is(err, server.ErrCacheMiss) {
+		return resultMiss
+	}
+	return resultError
+}
@@ -0,0 +1,55 @@
+package prometheus
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+func Equal(t *testing.T, src interface{}, dst interface{}) {
+	if !reflect.DeepEqual(src, dst) {
+		t.Fatalf("not equal %v and %v", src, dst)
+	}
+}
+
+func TestCollector(t *testing.T) {
+	c := NewCollector(Namespace("app"))
+	registry := prom.NewRegistry()
+	if err := registry.Register(c); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	c.OnCacheHit("user_logins", "user_id")
+	c.OnCacheHit("user_logins", "user_id")
+	c.OnCacheMiss("user_logins", "user_id")
+	c.OnDBQuery("user_logins", 10*time.Millisecond)
+	c.OnCacheServerOp("get", time.Millisecond, nil)
+	c.OnCacheServerOp("get", time.Millisecond, xerrors.Errorf("failed to get: %w", server.ErrCacheMiss))
+	c.OnCacheServerOp("set", time.Millisecond, xerrors.New("timeout"))
+
+	Equal(t, testutil.ToFloat64(c.cacheHits.WithLabelValues("user_logins", "user_id")), float64(2))
+	Equal(t, testutil.ToFloat64(c.cacheMisses.WithLabelValues("user_logins", "user_id")), float64(1))
+	Equal(t, testutil.ToFloat64(c.serverOps.WithLabelValues("get", resultSuccess)), float64(1))
+	Equal(t, testutil.ToFloat64(c.serverOps.WithLabelValues("get", resultMiss)), float64(1))
+	Equal(t, testutil.ToFloat64(c.serverOps.WithLabelValues("set", resultError)), float64(1))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	names := []string{}
+	for _, family := range families {
+		names = append(names, family.GetName())
+	}
+	Equal(t, names, []string{
+		"app_llc_op_duration_seconds",
+		"app_llc_ops_total",
+		"app_slc_cache_hits_total",
+		"app_slc_cache_misses_total",
+		"app_slc_db_query_duration_seconds",
+	})
+}
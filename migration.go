@@ -0,0 +1,116 @@
+package rapidash
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+// StructMigration converts cached value decoded by previous Struct version to current version
+type StructMigration func(old Decoder, new Encoder) error
+
+type structMigration struct {
+	typ     *Struct
+	migrate StructMigration
+}
+
+func (c *SecondLevelCache) migrationDecoder() (*ValueDecoder, bool) {
+	m := c.opt.structMigration
+	if m == nil {
+		return nil, false
+	}
+	return NewDecoder(m.typ, &bytes.Buffer{}, c.valueFactory), true
+}
+
+func (c *SecondLevelCache) migrateStructValue(old *StructValue) (*StructValue, error) {
+	enc := NewStructEncoder(c.typ, c.valueFactory)
+	if err := c.opt.structMigration.migrate(old, enc); err != nil {
+		return nil, xerrors.Errorf("failed to migrate %s: %w", c.typ.tableName, err)
+	}
+	if err := enc.Error(); err != nil {
+		return nil, xerrors.Errorf("failed to encode migrated value: %w", err)
+	}
+	return enc.value, nil
+}
+
+// migrateValue decodes content by previous Struct version and rewrites it by current version.
+// rewrite uses cas id of read value, so value updated by others is never overwritten
+func (c *SecondLevelCache) migrateValue(ctx context.Context, key server.CacheKey, content *server.CacheGetResponse, payload []byte) (*StructValue, error) {
+	decoder, exists := c.migrationDecoder()
+	if !exists {
+		return nil, xerrors.Errorf("previous version of %s is not registered", c.typ.tableName)
+	}
+	old, err := c.decodeValue(decoder, payload)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decode by previous version: %w", err)
+	}
+	value, err := c.migrateStructValue(old)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to migrate value: %w", err)
+	}
+	expiration, expired := c.expirationByValue(value)
+	if expired {
+		return value, nil
+	}
+	encoded, err := c.encodeValue(value)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to encode migrated value: %w", err)
+	}
+	c.rewriteMigratedValue(ctx, key, content.CasID, encoded, expiration)
+	return value, nil
+}
+
+func (c *SecondLevelCache) migrateSliceValue(ctx context.Context, key server.CacheKey, content *server.CacheGetResponse, payload []byte) (*StructSliceValue, error) {
+	decoder, exists := c.migrationDecoder()
+	if !exists {
+		return nil, xerrors.Errorf("previous version of %s is not registered", c.typ.tableName)
+	}
+	olds, err := c.decodeValues(decoder, payload)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decode by previous version: %w", err)
+	}
+	values := NewStructSliceValueWithCapacity(olds.Len())
+	expiration := c.expiration()
+	expired := false
+	for _, old := range olds.values {
+		value, err := c.migrateStructValue(old)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to migrate value: %w", err)
+		}
+		values.Append(value)
+		valueExpiration, valueExpired := c.expirationByValue(value)
+		expired = expired || valueExpired
+		if expiration == 0 || (valueExpiration != 0 && valueExpiration < expiration) {
+			expiration = valueExpiration
+		}
+	}
+	if expired {
+		return values, nil
+	}
+	encoded, err := c.encodeClusterValues(values)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to encode migrated values: %w", err)
+	}
+	c.rewriteMigratedValue(ctx, key, content.CasID, encoded, expiration)
+	return values, nil
+}
+
+func (c *SecondLevelCache) rewriteMigratedValue(ctx context.Context, key server.CacheKey, casID uint64, encoded []byte, expiration time.Duration) {
+	encoded, err := c.encodePayload(encoded)
+	if err != nil {
+		log.Warn(fmt.Sprintf("failed to encode migrated value of %s: %+v", key, err))
+		return
+	}
+	if err := c.cacheServer.Set(ctx, &server.CacheStoreRequest{
+		Key:        key,
+		Value:      encoded,
+		Expiration: expiration,
+		CasID:      casID,
+	}); err != nil {
+		log.Warn(fmt.Sprintf("failed to rewrite migrated value of %s: %+v", key, err))
+	}
+}
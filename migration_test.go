@@ -0,0 +1,132 @@
+package rapidash
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"go.knocknote.io/rapidash/server"
+)
+
+type recordingCacheServer struct {
+	server.CacheServer
+	requests []*server.CacheStoreRequest
+}
+
+func (s *recordingCacheServer) Set(ctx context.Context, req *server.CacheStoreRequest) error {
+	s.requests = append(s.requests, req)
+	return nil
+}
+
+func migratePassword(old Decoder, enc Encoder) error {
+	password, err := strconv.ParseUint(old.String("password"), 10, 64)
+	if err != nil {
+		return err
+	}
+	enc.Uint64("id", old.Uint64("id"))
+	enc.String("name", old.String("name"))
+	enc.Uint64("password", password)
+	return old.Error()
+}
+
+func TestStructMigration(t *testing.T) {
+	oldType := NewStruct("user_logins").FieldUint64("id").FieldString("name").FieldString("password")
+	newType := NewStruct("user_logins").FieldUint64("id").FieldString("name").FieldUint64("password")
+	cacheServer := &recordingCacheServer{}
+	slc := NewSecondLevelCache(newType, cacheServer, TableOption{
+		structMigration: &structMigration{typ: oldType, migrate: migratePassword},
+	})
+	enc := NewStructEncoder(oldType, slc.valueFactory)
+	enc.Uint64("id", 1)
+	enc.String("name", "rapidash")
+	enc.String("password", "100")
+	content, err := enc.Encode()
+	NoError(t, err)
+
+	decoder := slc.valueDecoder()
+	decoder.SetBuffer(content)
+	_, err = decoder.Decode()
+	Error(t, err)
+	slc.releaseValueDecoder(decoder)
+
+	key := server.StringCacheKey("r/slc/user_logins/id#1")
+	value, err := slc.migrateValue(context.Background(), key, &server.CacheGetResponse{Value: content, CasID: 3}, content)
+	NoError(t, err)
+	Equal(t, value.Uint64("password"), uint64(100))
+	Equal(t, value.String("name"), "rapidash")
+
+	Equal(t, len(cacheServer.requests), 1)
+	Equal(t, cacheServer.requests[0].CasID, uint64(3))
+	decoder = slc.valueDecoder()
+	defer slc.releaseValueDecoder(decoder)
+	decoder.SetBuffer(cacheServer.requests[0].Value)
+	rewritten, err := decoder.Decode()
+	NoError(t, err)
+	Equal(t, rewritten.Uint64("password"), uint64(100))
+}
+
+func TestStructMigrationWithCacheServer(t *testing.T) {
+	NoError(t, initUserLoginTable(conn))
+	NoError(t, initCache(conn, CacheServerTypeMemcached))
+	oldType := NewStruct("user_logins").
+		FieldUint64("id").
+		FieldString("user_id").
+		FieldUint64("user_session_id").
+		FieldUint64("login_param_id").
+		FieldString("name").
+		FieldTime("created_at").
+		FieldTime("updated_at")
+	slc := NewSecondLevelCache(userLoginType(), cache.cacheServer, TableOption{
+		structMigration: &structMigration{
+			typ: oldType,
+			migrate: func(old Decoder, enc Encoder) error {
+				userID, err := strconv.ParseUint(old.String("user_id"), 10, 64)
+				if err != nil {
+					return err
+				}
+				enc.Uint64("id", old.Uint64("id"))
+				enc.Uint64("user_id", userID)
+				enc.Uint64("user_session_id", old.Uint64("user_session_id"))
+				enc.Uint64("login_param_id", old.Uint64("login_param_id"))
+				enc.String("name", old.String("name"))
+				enc.TimePtr("created_at", old.TimePtr("created_at"))
+				enc.TimePtr("updated_at", old.TimePtr("updated_at"))
+				return old.Error()
+			},
+		},
+	})
+	NoError(t, slc.cacheServer.Flush(context.Background()))
+	NoError(t, slc.WarmUp(conn))
+
+	userLogin := defaultUserLogin()
+	enc := NewStructEncoder(oldType, slc.valueFactory)
+	enc.Uint64("id", userLogin.ID)
+	enc.String("user_id", "1")
+	enc.Uint64("user_session_id", userLogin.UserSessionID)
+	enc.Uint64("login_param_id", userLogin.LoginParamID)
+	enc.String("name", userLogin.Name)
+	enc.TimePtr("created_at", userLogin.CreatedAt)
+	enc.TimePtr("updated_at", userLogin.UpdatedAt)
+	content, err := enc.Encode()
+	NoError(t, err)
+	key := "r/slc/user_logins/id#1"
+	cacheKey := &CacheKey{key: key, hash: NewStringValue(key).Hash()}
+	NoError(t, slc.cacheServer.Set(context.Background(), &server.CacheStoreRequest{Key: cacheKey, Value: content}))
+
+	tx, err := cache.Begin(conn)
+	NoError(t, err)
+	var found UserLogin
+	builder := NewQueryBuilder("user_logins").Eq("id", uint64(1))
+	NoError(t, slc.FindByQueryBuilder(context.Background(), tx, builder, &found))
+	Equal(t, found.UserID, uint64(1))
+	NoError(t, tx.Commit())
+	Equal(t, slc.ReadRepairCount(), uint64(0))
+
+	rewritten, err := slc.cacheServer.Get(context.Background(), cacheKey)
+	NoError(t, err)
+	decoder := slc.valueDecoder()
+	defer slc.releaseValueDecoder(decoder)
+	decoder.SetBuffer(rewritten.Value)
+	_, err = decoder.Decode()
+	NoError(t, err)
+}
@@ -0,0 +1,143 @@
+package rapidash
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+// MissingPrimaryKeyPolicy decides how values are read when index key references primary keys whose values are evicted from cache
+type MissingPrimaryKeyPolicy int
+
+const (
+	// MissingPrimaryKeyPolicyRefetchIndex reads all values of the index from database
+	// if values of other index keys are also missing ( default )
+	MissingPrimaryKeyPolicyRefetchIndex MissingPrimaryKeyPolicy = iota
+	// MissingPrimaryKeyPolicyRefetchPrimaryKeys reads only missing values by primary keys with IN(),
+	// writes them to cache again, and deletes index key if it references records that don't exist anymore
+	MissingPrimaryKeyPolicyRefetchPrimaryKeys
+)
+
+func (p MissingPrimaryKeyPolicy) String() string {
+	switch p {
+	case MissingPrimaryKeyPolicyRefetchIndex:
+		return "refetch_index"
+	case MissingPrimaryKeyPolicyRefetchPrimaryKeys:
+		return "refetch_primary_keys"
+	}
+	return ""
+}
+
+// missingPrimaryKey is query by primary key whose value is missing in cache.
+// indexQuery is query of index key that referenced the primary key
+type missingPrimaryKey struct {
+	query      *Query
+	indexQuery *Query
+}
+
+func (m *missingPrimaryKey) indexKey() server.CacheKey {
+	if m.indexQuery == nil || m.indexQuery.index == nil || m.indexQuery.index.Type == IndexTypePrimaryKey {
+		return nil
+	}
+	return m.indexQuery.cacheKey
+}
+
+// MissingPrimaryKeyCount returns number of primary keys referenced by index keys whose values were missing in cache.
+// It is counted when MissingPrimaryKeyPolicyRefetchPrimaryKeys is set
+func (c *SecondLevelCache) MissingPrimaryKeyCount() uint64 {
+	return atomic.LoadUint64(&c.missingPrimaryKeyCount)
+}
+
+// IndexRepairCount returns number of index keys deleted because they referenced records that don't exist
+func (c *SecondLevelCache) IndexRepairCount() uint64 {
+	return atomic.LoadUint64(&c.indexRepairCount)
+}
+
+// refetchMissingPrimaryKeys reads values missing in cache by primary keys, and appends them to foundValues
+func (c *SecondLevelCache) refetchMissingPrimaryKeys(ctx context.Context, tx *Tx, builder *QueryBuilder, queries *Queries, foundValues *StructSliceValue) (e error) {
+	if len(queries.missingPrimaryKeys) == 0 {
+		return nil
+	}
+	atomic.AddUint64(&c.missingPrimaryKeyCount, uint64(len(queries.missingPrimaryKeys)))
+	primaryKeyQueries := NewQueries(c.typ.tableName, c.primaryKey, len(queries.missingPrimaryKeys))
+	primaryKeyQueries.annotation = queries.annotation
+	cacheMissQueryMap := map[*Query][]*StructValue{}
+	for _, missing := range queries.missingPrimaryKeys {
+		primaryKeyQueries.cacheMissQueries = append(primaryKeyQueries.cacheMissQueries, missing.query)
+		cacheMissQueryMap[missing.query] = []*StructValue{}
+	}
+	query, values := primaryKeyQueries.CacheMissQueriesToSQL(c.typ)
+	query = c.fallbackQuery(query)
+	rows, err := tx.connection(c).QueryContext(ctx, query, values...)
+	if err != nil {
+		return xerrors.Errorf("failed sql %s %v: %w", query, values, err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			e = xerrors.Errorf("failed to close rows: %w", err)
+		}
+	}()
+	alreadyFoundValues := map[string]struct{}{}
+	for _, value := range foundValues.values {
+		alreadyFoundValues[c.primaryKeyStringByStructValue(value)] = struct{}{}
+	}
+	var dbValues *StructSliceValue
+	if !isNopLogger {
+		dbValues = NewStructSliceValue()
+	}
+	for rows.Next() {
+		scanValues := c.typ.ScanValues(c.valueFactory)
+		if err := rows.Scan(scanValues...); err != nil {
+			return xerrors.Errorf("failed to scan: %w", err)
+		}
+		value := c.typ.StructValue(scanValues)
+		pkStr := c.primaryKeyStringByStructValue(value)
+		if _, exists := alreadyFoundValues[pkStr]; !exists {
+			alreadyFoundValues[pkStr] = struct{}{}
+			builder.info.addDBRows(1)
+			foundValues.Append(value)
+			if !isNopLogger {
+				dbValues.Append(value)
+			}
+		}
+		if cacheMissQuery := primaryKeyQueries.FindCacheMissQueryByStructValue(value); cacheMissQuery != nil {
+			cacheMissQueryMap[cacheMissQuery] = append(cacheMissQueryMap[cacheMissQuery], value)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return xerrors.Errorf("failed to read rows: %w", err)
+	}
+	tx.logger().GetFromDB(tx.id, query, values, dbValues)
+	if err := c.createCacheByCacheMissQueryMap(ctx, tx, cacheMissQueryMap); err != nil {
+		return xerrors.Errorf("failed to create cache by cache miss query map: %w", err)
+	}
+	if err := c.repairIndexKeys(ctx, tx, queries.missingPrimaryKeys, cacheMissQueryMap); err != nil {
+		return xerrors.Errorf("failed to repair index keys: %w", err)
+	}
+	return nil
+}
+
+// repairIndexKeys deletes index keys referencing records that don't exist in database, so they are rebuilt by the next read
+func (c *SecondLevelCache) repairIndexKeys(ctx context.Context, tx *Tx, missingPrimaryKeys []*missingPrimaryKey, cacheMissQueryMap map[*Query][]*StructValue) error {
+	repairedKeys := map[string]struct{}{}
+	for _, missing := range missingPrimaryKeys {
+		if len(cacheMissQueryMap[missing.query]) > 0 {
+			continue
+		}
+		indexKey := missing.indexKey()
+		if indexKey == nil {
+			continue
+		}
+		if _, exists := repairedKeys[indexKey.String()]; exists {
+			continue
+		}
+		repairedKeys[indexKey.String()] = struct{}{}
+		if err := c.delete(ctx, tx, indexKey); err != nil {
+			return xerrors.Errorf("failed to delete index key %s: %w", indexKey, err)
+		}
+		atomic.AddUint64(&c.indexRepairCount, 1)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package rapidash
+
+import (
+	"context"
+	"testing"
+
+	"go.knocknote.io/rapidash/server"
+)
+
+func TestMissingPrimaryKey(t *testing.T) {
+	r, err := New(SecondLevelCacheTableMissingPrimaryKeyPolicy("user_logins", MissingPrimaryKeyPolicyRefetchPrimaryKeys))
+	NoError(t, err)
+	r.cacheServer = newMemoryCacheServer()
+	slc := NewSecondLevelCache(userLoginType(), r.cacheServer, r.tableOption("user_logins"))
+	slc.primaryKey = NewPrimaryKey(slc.opt, "user_logins", []string{"id"}, slc.typ)
+	slc.indexes["id"] = slc.primaryKey
+	slc.indexes["user_id"] = NewKey(slc.opt, "user_logins", []string{"user_id"}, slc.typ)
+	r.secondLevelCaches.set("user_logins", slc)
+	Equal(t, slc.opt.MissingPrimaryKeyPolicy(), MissingPrimaryKeyPolicyRefetchPrimaryKeys)
+
+	primaryKeys := []server.CacheKey{}
+	for i := uint64(1); i <= 3; i++ {
+		key, err := slc.cacheKeyByPrimaryKeyValue(slc.valueFactory.CreateUint64Value(i))
+		NoError(t, err)
+		primaryKeys = append(primaryKeys, key)
+	}
+	builder := NewQueryBuilder("user_logins").In("user_id", []uint64{10, 11})
+	queries, err := builder.BuildWithIndex(slc.valueFactory, slc.indexes, slc.typ)
+	NoError(t, err)
+	queries.refetchPrimaryKeys = true
+	values, err := queries.LoadValues(slc.valueFactory, func(indexType IndexType, iter *QueryIterator) error {
+		// user_id = 10 is missing in cache, and user_id = 11 references values that are partially evicted
+		iter.Next()
+		iter.SetError(server.ErrCacheMiss)
+		iter.Next()
+		iter.SetPrimaryKeys(primaryKeys)
+		return nil
+	}, func(iter *ValueIterator) error {
+		for iter.Next() {
+			if iter.PrimaryKey() == primaryKeys[0] {
+				iter.SetValue(&StructValue{typ: slc.typ, fields: map[string]*Value{}})
+				continue
+			}
+			iter.SetError(server.ErrCacheMiss)
+		}
+		return nil
+	})
+	NoError(t, err)
+	Equal(t, values.Len(), 1)
+	Equal(t, len(queries.CacheMissQueries()), 1)
+	Equal(t, len(queries.missingPrimaryKeys), 2)
+	indexKey := queries.missingPrimaryKeys[0].indexKey()
+	Equal(t, indexKey, queries.At(1).cacheKey)
+	Equal(t, queries.missingPrimaryKeys[0].query.cacheKey.String(), primaryKeys[1].String())
+
+	t.Run("repair index key referencing deleted record", func(t *testing.T) {
+		tx, err := r.Begin()
+		NoError(t, err)
+		cacheMissQueryMap := map[*Query][]*StructValue{
+			queries.missingPrimaryKeys[0].query: {},
+			queries.missingPrimaryKeys[1].query: {{typ: slc.typ, fields: map[string]*Value{}}},
+		}
+		NoError(t, slc.repairIndexKeys(context.Background(), tx, queries.missingPrimaryKeys, cacheMissQueryMap))
+		Equal(t, slc.IndexRepairCount(), uint64(1))
+		Equal(t, r.IndexRepairCount(), uint64(1))
+		query, exists := tx.pendingQueries[indexKey.String()]
+		Equal(t, exists, true)
+		Equal(t, query.Command, string(SLCCommandDelete))
+		NoError(t, tx.Rollback())
+	})
+}
+
+func TestMissingPrimaryKeyPolicyConfig(t *testing.T) {
+	policy := "refetch_primary_keys"
+	cfg := &TableConfig{MissingPKPolicy: &policy}
+	r, err := New(cfg.Options("user_logins")...)
+	NoError(t, err)
+	opt := r.tableOption("user_logins")
+	Equal(t, opt.MissingPrimaryKeyPolicy(), MissingPrimaryKeyPolicyRefetchPrimaryKeys)
+	other := r.tableOption("users")
+	Equal(t, other.MissingPrimaryKeyPolicy(), MissingPrimaryKeyPolicyRefetchIndex)
+}
@@ -0,0 +1,78 @@
+package rapidash
+
+import (
+	"database/sql"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// boundConnection is connection used by tables warmed up with db instead of connection passed to Begin
+type boundConnection struct {
+	db   *sql.DB
+	conn Connection
+}
+
+// BindConnection binds conn to tables warmed up with db.
+// Tables of other databases keep using connection passed to Begin,
+// so single transaction can read and write tables of several databases.
+// Bound transactions are committed one by one after connection passed to Begin,
+// so they aren't atomic across databases
+func (tx *Tx) BindConnection(db *sql.DB, conn Connection) {
+	for _, bound := range tx.boundConns {
+		if bound.db == db {
+			bound.conn = conn
+			return
+		}
+	}
+	tx.boundConns = append(tx.boundConns, &boundConnection{db: db, conn: conn})
+}
+
+// connection returns connection for database of c
+func (tx *Tx) connection(c *SecondLevelCache) Connection {
+	if c.db == nil {
+		return tx.conn
+	}
+	for _, bound := range tx.boundConns {
+		if bound.db == c.db {
+			return bound.conn
+		}
+	}
+	return tx.conn
+}
+
+func (tx *Tx) commitBoundConnections() error {
+	for _, bound := range tx.boundConns {
+		if bound.conn == tx.conn {
+			continue
+		}
+		txConn, ok := bound.conn.(TxConnection)
+		if !ok {
+			continue
+		}
+		if err := txConn.Commit(); err != nil {
+			return xerrors.Errorf("failed to Commit for database: %w", err)
+		}
+	}
+	return nil
+}
+
+func (tx *Tx) rollbackBoundConnections() error {
+	errs := []string{}
+	for _, bound := range tx.boundConns {
+		if bound.conn == tx.conn {
+			continue
+		}
+		txConn, ok := bound.conn.(TxConnection)
+		if !ok {
+			continue
+		}
+		if err := txConn.Rollback(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return xerrors.Errorf("failed to Rollback for database: %s", strings.Join(errs, ","))
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package rapidash
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+type txConnRecorder struct {
+	execRecorder
+	committed  bool
+	rolledBack bool
+}
+
+func (c *txConnRecorder) Commit() error {
+	c.committed = true
+	return nil
+}
+
+func (c *txConnRecorder) Rollback() error {
+	c.rolledBack = true
+	return nil
+}
+
+func TestBindConnection(t *testing.T) {
+	r, err := New(SecondLevelCacheTablePrimaryKeyGenerator("user_logins", NewSequencePrimaryKeyGenerator(100)))
+	NoError(t, err)
+	slc := NewSecondLevelCache(userLoginType(), nil, r.tableOption("user_logins"))
+	slc.primaryKey = NewPrimaryKey(slc.opt, "user_logins", []string{"id"}, slc.typ)
+	slc.db = &sql.DB{}
+	ctx := context.Background()
+
+	t.Run("use bound connection for database of table", func(t *testing.T) {
+		conn := &txConnRecorder{}
+		boundConn := &txConnRecorder{}
+		tx, err := r.Begin(conn)
+		NoError(t, err)
+		tx.BindConnection(&sql.DB{}, &txConnRecorder{})
+		tx.BindConnection(slc.db, boundConn)
+		Equal(t, len(tx.boundConns), 2)
+		_, value, err := slc.encode(&UserLogin{UserID: 1})
+		NoError(t, err)
+		_, err = slc.insertValue(ctx, tx, value)
+		NoError(t, err)
+		Equal(t, len(conn.args), 0)
+		Equal(t, len(boundConn.args), 1)
+		NoError(t, tx.Commit())
+		Equal(t, conn.committed, true)
+		Equal(t, boundConn.committed, true)
+	})
+	t.Run("rebind connection", func(t *testing.T) {
+		boundConn := &txConnRecorder{}
+		tx, err := r.Begin()
+		NoError(t, err)
+		tx.BindConnection(slc.db, &txConnRecorder{})
+		tx.BindConnection(slc.db, boundConn)
+		Equal(t, len(tx.boundConns), 1)
+		Equal(t, tx.connection(slc), Connection(boundConn))
+		NoError(t, tx.Rollback())
+		Equal(t, boundConn.rolledBack, true)
+	})
+	t.Run("fallback to connection of Begin", func(t *testing.T) {
+		conn := &txConnRecorder{}
+		tx, err := r.Begin(conn)
+		NoError(t, err)
+		tx.BindConnection(&sql.DB{}, &txConnRecorder{})
+		Equal(t, tx.connection(slc), Connection(conn))
+		NoError(t, tx.Rollback())
+		Equal(t, conn.rolledBack, true)
+	})
+}
@@ -1,7 +1,11 @@
 package rapidash
 
 import (
+	"database/sql"
+	"fmt"
 	"time"
+
+	"go.knocknote.io/rapidash/server"
 )
 
 type OptionFunc func(*Rapidash)
@@ -12,12 +16,29 @@ func ServerType(typ CacheServerType) OptionFunc {
 	}
 }
 
+// CacheServerName uses cache server registered by RegisterCacheServer instead of memcached or redis
+func CacheServerName(name string) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.serverType = CacheServerTypeCustom
+		r.opt.serverName = name
+	}
+}
+
 func ServerAddrs(addrs []string) OptionFunc {
 	return func(r *Rapidash) {
 		r.opt.serverAddrs = addrs
 	}
 }
 
+// RedisClusterAddrs uses Redis Cluster discovered from addrs as cache server.
+// Keys are routed by hash slot, and MOVED/ASK redirects are followed
+func RedisClusterAddrs(addrs []string) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.serverType = CacheServerTypeRedis
+		r.opt.redisClusterAddrs = addrs
+	}
+}
+
 func SecondLevelCacheServerAddrs(addrs []string) OptionFunc {
 	return func(r *Rapidash) {
 		r.opt.slcServerAddrs = addrs
@@ -42,6 +63,36 @@ func MaxIdleConnections(cons int) OptionFunc {
 	}
 }
 
+// CacheServerRetryPolicy retries Get/GetMulti/Set/Delete of cache server failed by transient errors like EOF or timeout
+func CacheServerRetryPolicy(policy server.RetryPolicy) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.serverRetryPolicy = &policy
+	}
+}
+
+// CacheServerCredentialsProvider authenticates new connections to redis by credentials from provider.
+// Credentials are fetched again and connections are reestablished if server returns AUTH error
+func CacheServerCredentialsProvider(provider server.CredentialsProvider) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.serverCredentialsProvider = provider
+	}
+}
+
+// CacheServerGetMultiBatch splits GetMulti into batches for each cache server node by policy
+func CacheServerGetMultiBatch(policy server.GetMultiBatchPolicy) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.serverGetMultiBatch = &policy
+	}
+}
+
+// RedisCompareAndSwap enables compare-and-swap of redis by hash of value ( e.g. for OptimisticLock and LastLevelCache UpdateWithVersion ).
+// redis has no cas unique, so write that changes value and changes it back to the same bytes isn't detected as conflict
+func RedisCompareAndSwap(enabled bool) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.redisCompareAndSwap = enabled
+	}
+}
+
 func MaxRetryCount(cnt int) OptionFunc {
 	return func(r *Rapidash) {
 		r.opt.maxRetryCount = cnt
@@ -54,6 +105,137 @@ func RetryInterval(interval time.Duration) OptionFunc {
 	}
 }
 
+// CommitPipelineEnabled groups pending queries of SLC and LLC by cache node at commit,
+// and sends each group concurrently.
+func CommitPipelineEnabled(enabled bool) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.commitPipelineEnabled = enabled
+	}
+}
+
+// RateLimitWindow set window type used by (*Rapidash).Allow
+func RateLimitWindow(typ RateLimitWindowType) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.rateLimitWindowType = typ
+	}
+}
+
+// MaxStashSize set approximate max bytes of values stashed per transaction. 0 means unlimited
+func MaxStashSize(size int) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.maxStashSize = size
+	}
+}
+
+// StashOverflowPolicy set behavior when stash exceeds MaxStashSize.
+// StashOverflowPolicyEvict still returns ErrStashSizeExceeded if values modified by transaction alone exceed it
+func StashOverflowPolicy(policy StashOverflowPolicyType) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.stashOverflowPolicy = policy
+	}
+}
+
+// CacheKeyHash set hash function of cache key used to choose server node.
+// Changing it moves most keys to other nodes and cached primary keys keep old hash, so flush cache servers when switching
+func CacheKeyHash(typ CacheKeyHashType) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.cacheKeyHash = typ
+		r.opt.llcOpt.cacheKeyHash = typ
+	}
+}
+
+// ClockSource replaces clock used for lock values, expirations and rate limit windows
+func ClockSource(clock Clock) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.clock = clockOrDefault(clock)
+		r.opt.llcOpt.clock = r.opt.clock
+	}
+}
+
+// RegisterPayloadCodec register codec to decode cached value written with id.
+// id 0 is reserved for raw value, and 255 is reserved for value stamped with written time
+func RegisterPayloadCodec(id uint8, codec PayloadCodec) OptionFunc {
+	return func(r *Rapidash) {
+		if id == 0 || id == stampedPayloadID || codec == nil {
+			return
+		}
+		r.opt.payloadCodecs.codecs[id] = codec
+	}
+}
+
+// PreferredPayloadCodec set codec id used to encode cached value. 0 writes raw value
+func PreferredPayloadCodec(id uint8) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.payloadCodecs.preferred = id
+	}
+}
+
+// SecondLevelCacheTTLSource set source of expiration consulted before table expiration
+func SecondLevelCacheTTLSource(source TTLSource) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.ttlSource = source
+	}
+}
+
+// InvalidationOutbox records SLC keys changed by transaction into table within the database transaction.
+// The record is removed after cache commit, and remaining records are processed by (*Rapidash).ProcessInvalidationOutbox.
+// Schema of table is InvalidationOutboxDDL
+func InvalidationOutbox(conn *sql.DB, table string) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.invalidationOutbox = &invalidationOutboxOption{conn: conn, table: table}
+	}
+}
+
+// Audit set sink receiving events of records created, updated or deleted by committed transaction
+func Audit(sink AuditSink) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.auditSink = sink
+	}
+}
+
+// Stats set collector receiving cache hit/miss and latency of database and cache server
+func Stats(collector StatsCollector) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.stats = collector
+	}
+}
+
+// LockOwner set label of application recorded to lock keys. It is shown in lock conflict errors and ListLocks
+func LockOwner(owner string) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.lockOwner = owner
+	}
+}
+
+// SecondLevelCacheTableNameResolver set resolver to share warmed up table with physical tables ( e.g. table per tenant )
+func SecondLevelCacheTableNameResolver(resolver TableNameResolver) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.tableNameResolver = resolver
+	}
+}
+
+// ColumnEncryptionKey set AES key ( 16, 24 or 32 bytes ) for columns added by FieldStringEncrypted.
+// It is used by tables warmed up by this instance
+func ColumnEncryptionKey(key []byte) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.columnEncryptionKey = key
+	}
+}
+
+// CommitOrder set order of database and cache commit at (*Tx).Commit
+func CommitOrder(order CommitOrderType) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.commitOrder = order
+	}
+}
+
+// DoubleDeleteDelay set delay of the second delete for CommitOrderDeleteCacheBeforeAndAfter
+func DoubleDeleteDelay(delay time.Duration) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.doubleDeleteDelay = delay
+	}
+}
+
 func LogMode(mode LogModeType) OptionFunc {
 	return func(r *Rapidash) {
 		r.opt.logMode = mode
@@ -66,6 +248,28 @@ func LogEnabled(enabled bool) OptionFunc {
 	}
 }
 
+func TxReportEnabled(enabled bool) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.txReportEnabled = enabled
+	}
+}
+
+func LogBufferSize(size int) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.logBufferSize = size
+	}
+}
+
+// LogSamplingRate set rate of logging for command. Warn log is always written
+func LogSamplingRate(command SLCCommandType, rate float64) OptionFunc {
+	return func(r *Rapidash) {
+		if r.opt.logSamplingRates == nil {
+			r.opt.logSamplingRates = map[SLCCommandType]float64{}
+		}
+		r.opt.logSamplingRates[command] = rate
+	}
+}
+
 func LogServerAddr(addr string) OptionFunc {
 	return func(r *Rapidash) {
 		r.opt.logServerAddr = addr
@@ -84,6 +288,31 @@ func SecondLevelCacheExpiration(expiration time.Duration) OptionFunc {
 	}
 }
 
+// SecondLevelCacheINChunkSize splits IN query that has more than size values into multiple cache batches and SQL statements
+func SecondLevelCacheINChunkSize(size int) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.slcINChunkSize = size
+	}
+}
+
+// SecondLevelCacheSessionVariables set system variables ( e.g. max_execution_time ) for SELECT statements executed for cache miss.
+// They are passed by SET_VAR optimizer hint ( MySQL 8.0.3 or later ) because connection is owned by application.
+func SecondLevelCacheSessionVariables(vars map[string]string) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.slcSessionVariables = vars
+	}
+}
+
+// SecondLevelCacheMaxExecutionTime set max_execution_time for SELECT statements executed for cache miss
+func SecondLevelCacheMaxExecutionTime(timeout time.Duration) OptionFunc {
+	return func(r *Rapidash) {
+		if r.opt.slcSessionVariables == nil {
+			r.opt.slcSessionVariables = map[string]string{}
+		}
+		r.opt.slcSessionVariables["max_execution_time"] = fmt.Sprint(int64(timeout / time.Millisecond))
+	}
+}
+
 func SecondLevelCacheOptimisticLock(enabled bool) OptionFunc {
 	return func(r *Rapidash) {
 		r.opt.slcOptimisticLock = enabled
@@ -144,6 +373,306 @@ func SecondLevelCacheTablePessimisticLock(table string, enabled bool) OptionFunc
 	}
 }
 
+func SecondLevelCacheTableClusterKey(table string, column string) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.clusterKey = &column
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+func SecondLevelCacheTableExpirationColumn(table string, column string) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.expirationColumn = &column
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableDecodeHook set hook function called before Unmarshaler decodes found values
+func SecondLevelCacheTableDecodeHook(table string, hook func(*StructValue) error) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.decodeHook = hook
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableEncodeHook set hook function called before writing encoded value to database and cache.
+// At UpdateByQueryBuilder, value has only columns in update map ( ValueByColumn returns nil for other columns )
+func SecondLevelCacheTableEncodeHook(table string, hook func(*StructValue) error) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.encodeHook = hook
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+func SecondLevelCacheTableKeyRegistry(table string, size int) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.keyRegistrySize = &size
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableDefaultOrder sorts found values by column so that cached results have the same order as database.
+// Multiple calls are applied in declared order.
+func SecondLevelCacheTableDefaultOrder(table string, column string, isAsc bool) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.defaultOrders = append(opt.defaultOrders, &OrderCondition{column: column, isAsc: isAsc})
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+func SecondLevelCacheTableINChunkSize(table string, size int) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.inChunkSize = &size
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableDisableStash always consults cache server instead of reusing values read in the same transaction.
+// values modified by the transaction itself are still reused.
+func SecondLevelCacheTableDisableStash(table string, disabled bool) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.disableStash = &disabled
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+func SecondLevelCacheTableSessionVariables(table string, vars map[string]string) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.sessionVariables = vars
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableMigration register previous Struct version of table.
+// cached value which can be decoded only by previous version is converted by migrate and rewritten
+func SecondLevelCacheTableMigration(table string, old *Struct, migrate StructMigration) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.structMigration = &structMigration{typ: old, migrate: migrate}
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableExcludeIndexes stops building cache entries of indexes. index is specified by columns joined by colon ( e.g. user_id:status )
+func SecondLevelCacheTableExcludeIndexes(table string, indexes ...string) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.excludeIndexes = append(opt.excludeIndexes, indexes...)
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableIgnoreIndex ignores indexes by name at WarmUp ( e.g. indexes only for background jobs )
+func SecondLevelCacheTableIgnoreIndex(table string, names ...string) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.ignoreIndexes = append(opt.ignoreIndexes, names...)
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableDisableUniquePrefixKeys stops registering prefix columns of composite unique indexes as keys.
+// Lists of prefix keys aren't refreshed when only the rest of unique key columns are updated,
+// so queries by prefix columns go to database instead
+func SecondLevelCacheTableDisableUniquePrefixKeys(table string, names ...string) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.uniqNoPrefix = append(opt.uniqNoPrefix, names...)
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableLocalCache caches values found by primary key in process up to size in front of cache server.
+// Entries are evicted when this process commits the key, so it fits tables updated rarely or only by one process
+func SecondLevelCacheTableLocalCache(table string, size LocalCacheSize) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.localCache = &size
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableIgnoreIndexColumns ignores indexes that contain one of columns at WarmUp
+func SecondLevelCacheTableIgnoreIndexColumns(table string, columns ...string) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.ignoreColumns = append(opt.ignoreColumns, columns...)
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableLowSelectivityThreshold set average number of records per index key to warn low selectivity
+func SecondLevelCacheTableLowSelectivityThreshold(table string, threshold int) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.lowSelectivity = &threshold
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableRevalidateAfter serves cached value older than d as it is,
+// and re-reads it from database in background to refresh cache. d should be shorter than expiration
+func SecondLevelCacheTableRevalidateAfter(table string, d time.Duration) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.revalidateAfter = &d
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableShadowRead always serves values from database, and looks up cache in background to compare with them.
+// Results are reported by (*Rapidash).ShadowReadStats to validate cache before enabling it
+func SecondLevelCacheTableShadowRead(table string, enabled bool) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.shadowRead = &enabled
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableTTLTuning adjusts expiration of table within bounds of tuning every interval.
+// Expiration is lengthened for read heavy tables and shortened for write heavy tables. Chosen values are reported by (*Rapidash).TTLStats
+func SecondLevelCacheTableTTLTuning(table string, tuning TTLTuning) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.ttlTuning = &tuning
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTablePrimaryKeyGenerator set generator of primary key used by Create instead of LAST_INSERT_ID()
+func SecondLevelCacheTablePrimaryKeyGenerator(table string, generator PrimaryKeyGenerator) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.pkGenerator = generator
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableMissingPrimaryKeyPolicy set how values are read when index key references values evicted from cache
+func SecondLevelCacheTableMissingPrimaryKeyPolicy(table string, policy MissingPrimaryKeyPolicy) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.missingPKPolicy = &policy
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableFillLockPolicy set how negative caches and index lists written on cache miss are locked
+func SecondLevelCacheTableFillLockPolicy(table string, policy FillLockPolicy) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.fillLockPolicy = &policy
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableFillLockExpiration set expiration of lock by FillLockPolicyShortLock
+func SecondLevelCacheTableFillLockExpiration(table string, expiration time.Duration) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.fillLockExpiry = &expiration
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableWriteThroughOnCreate sets inserted value and its index keys to cache on commit instead of deleting them,
+// so the first read after Create doesn't miss
+func SecondLevelCacheTableWriteThroughOnCreate(table string, enabled bool) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.writeThrough = &enabled
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableCountCache caches number of records per index key for CountByQueryBuilder.
+// cached count is incremented by Create, decremented by Delete and deleted by Update changing index columns.
+// Counts are rewritten by CAS, so they are deleted instead on redis without RedisCompareAndSwap
+func SecondLevelCacheTableCountCache(table string, enabled bool) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.countCache = &enabled
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTablePageCache caches pages of primary keys per index key for FindByQueryBuilderWithCursor.
+// pages are deleted together with index key, so reading a page doesn't load whole list of the index key
+func SecondLevelCacheTablePageCache(table string, enabled bool) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.pageCache = &enabled
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableCompactPrimaryKeys encodes 64 or more integer primary keys of index key by delta varint.
+// Older versions can't decode this format, so enable it after all processes reading the table are updated
+func SecondLevelCacheTableCompactPrimaryKeys(table string, enabled bool) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.compactPKs = &enabled
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableCodec set codec to encode cached values of table.
+// Changing codec makes values cached by previous codec undecodable, so cache of the table must be flushed
+func SecondLevelCacheTableCodec(table string, codec Codec) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.codec = codec
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableCompression compresses cached values of table whose size is at least minSize by payload codec registered with codecID
+func SecondLevelCacheTableCompression(table string, codecID uint8, minSize int) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.compression = &Compression{CodecID: codecID, MinSize: minSize}
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableStrictTypes makes WarmUp fail if type of Struct field cannot hold its column type
+func SecondLevelCacheTableStrictTypes(table string, enabled bool) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.strictTypes = &enabled
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableValidateSchema makes WarmUp fail with *SchemaDriftError if Struct differs from table schema
+func SecondLevelCacheTableValidateSchema(table string, enabled bool) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.validateSchema = &enabled
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
+// SecondLevelCacheTableSchemaHashKey puts hash of Struct definition and columns into cache keys ( e.g. r/slc/user_logins/1a2b3c4d/id#1 ).
+// After Struct or table is changed, values cached by previous schema are never read
+func SecondLevelCacheTableSchemaHashKey(table string, enabled bool) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.slcTableOpt[table]
+		opt.schemaHashKey = &enabled
+		r.opt.slcTableOpt[table] = opt
+	}
+}
+
 func LastLevelCacheLockExpiration(expiration time.Duration) OptionFunc {
 	return func(r *Rapidash) {
 		r.opt.llcOpt.lockExpiration = expiration
@@ -168,6 +697,27 @@ func LastLevelCachePessimisticLock(enabled bool) OptionFunc {
 	}
 }
 
+// LastLevelCacheNegativeCacheExpiration caches not found result of Find in process during expiration
+func LastLevelCacheNegativeCacheExpiration(expiration time.Duration) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.llcOpt.negativeCacheExpiration = expiration
+	}
+}
+
+// LastLevelCacheNegativeCacheMaxEntries set max number of keys kept by negative cache. Zero means unlimited
+func LastLevelCacheNegativeCacheMaxEntries(maxEntries int) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.llcOpt.negativeCacheMaxEntries = maxEntries
+	}
+}
+
+// LastLevelCacheCompression compresses cached values whose size is at least minSize by payload codec registered with codecID
+func LastLevelCacheCompression(codecID uint8, minSize int) OptionFunc {
+	return func(r *Rapidash) {
+		r.opt.llcOpt.compression = &Compression{CodecID: codecID, MinSize: minSize}
+	}
+}
+
 func LastLevelCacheTagServerAddr(tag string, serverAddr string) OptionFunc {
 	return func(r *Rapidash) {
 		opt := r.opt.llcOpt.tagOpt[tag]
@@ -215,3 +765,11 @@ func LastLevelCacheTagPessimisticLock(tag string, enabled bool) OptionFunc {
 		r.opt.llcOpt.tagOpt[tag] = opt
 	}
 }
+
+func LastLevelCacheTagNegativeCacheExpiration(tag string, expiration time.Duration) OptionFunc {
+	return func(r *Rapidash) {
+		opt := r.opt.llcOpt.tagOpt[tag]
+		opt.negativeCacheExpiration = &expiration
+		r.opt.llcOpt.tagOpt[tag] = opt
+	}
+}
@@ -0,0 +1,182 @@
+package rapidash
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+const invalidationOutboxBatchSize = 100
+
+// InvalidationOutboxDDL returns schema of outbox table used by InvalidationOutbox option
+func InvalidationOutboxDDL(table string) string {
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS `%s` ("+
+		"`id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,"+
+		"`tx_id` varchar(32) NOT NULL,"+
+		"`cache_keys` mediumtext NOT NULL,"+
+		"`created_at` datetime NOT NULL,"+
+		"PRIMARY KEY (`id`),"+
+		"KEY `idx_created_at` (`created_at`)"+
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4", table)
+}
+
+type invalidationOutboxOption struct {
+	conn  *sql.DB
+	table string
+}
+
+type outboxKey struct {
+	Key  string `json:"key"`
+	Hash uint32 `json:"hash"`
+	Addr string `json:"addr,omitempty"`
+}
+
+func (k *outboxKey) cacheKey() (server.CacheKey, error) {
+	key := &CacheKey{key: k.Key, hash: k.Hash, typ: server.CacheKeyTypeSLC}
+	if k.Addr != "" {
+		addr, err := getAddr(k.Addr)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to get addr: %w", err)
+		}
+		key.addr = addr
+	}
+	return key, nil
+}
+
+// invalidationKeys returns SLC keys changed by transaction. LLC keys are excluded because cache server is the only storage for them
+func (tx *Tx) invalidationKeys() []*outboxKey {
+	keys := []*outboxKey{}
+	for _, key := range tx.modifiedSecondLevelCacheKeys() {
+		k := &outboxKey{Key: key.String(), Hash: key.Hash()}
+		if addr := key.Addr(); addr != nil {
+			k.Addr = addr.String()
+		}
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// writeInvalidationOutbox inserts keys in the database transaction before commit
+func (tx *Tx) writeInvalidationOutbox(ctx context.Context) error {
+	outbox := tx.r.opt.invalidationOutbox
+	if outbox == nil {
+		return nil
+	}
+	keys := tx.invalidationKeys()
+	if len(keys) == 0 {
+		return nil
+	}
+	content, err := json.Marshal(keys)
+	if err != nil {
+		return xerrors.Errorf("failed to marshal invalidation keys: %w", err)
+	}
+	query := fmt.Sprintf("INSERT INTO `%s` (`tx_id`,`cache_keys`,`created_at`) VALUES (?,?,?)", outbox.table)
+	result, err := tx.conn.ExecContext(ctx, query, tx.id, string(content), tx.r.opt.clock.Now())
+	if err != nil {
+		return xerrors.Errorf("failed sql %s: %w", query, err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return xerrors.Errorf("failed to get id of invalidation outbox: %w", err)
+	}
+	tx.invalidationOutboxID = id
+	return nil
+}
+
+// removeInvalidationOutbox deletes record of transaction because cache commit has finished
+func (tx *Tx) removeInvalidationOutbox(ctx context.Context) error {
+	outbox := tx.r.opt.invalidationOutbox
+	if outbox == nil || tx.invalidationOutboxID == 0 {
+		return nil
+	}
+	query := fmt.Sprintf("DELETE FROM `%s` WHERE `id` = ?", outbox.table)
+	if _, err := outbox.conn.ExecContext(ctx, query, tx.invalidationOutboxID); err != nil {
+		return xerrors.Errorf("failed sql %s: %w", query, err)
+	}
+	tx.invalidationOutboxID = 0
+	return nil
+}
+
+// ProcessInvalidationOutbox deletes cache keys recorded by transactions that committed database but not cache.
+// Records newer than delay are skipped because their transaction may be still committing cache.
+// Broken records are logged and deleted, so they don't block following records.
+// It returns number of processed records
+func (r *Rapidash) ProcessInvalidationOutbox(ctx context.Context, delay time.Duration) (int, error) {
+	outbox := r.opt.invalidationOutbox
+	if outbox == nil {
+		return 0, nil
+	}
+	query := fmt.Sprintf("SELECT `id`,`cache_keys` FROM `%s` WHERE `created_at` < ? ORDER BY `id` LIMIT %d",
+		outbox.table, invalidationOutboxBatchSize)
+	rows, err := outbox.conn.QueryContext(ctx, query, r.opt.clock.Now().Add(-delay))
+	if err != nil {
+		return 0, xerrors.Errorf("failed sql %s: %w", query, err)
+	}
+	type record struct {
+		id   uint64
+		keys string
+	}
+	records := []*record{}
+	for rows.Next() {
+		var rec record
+		if err := rows.Scan(&rec.id, &rec.keys); err != nil {
+			rows.Close()
+			return 0, xerrors.Errorf("failed to scan invalidation outbox: %w", err)
+		}
+		records = append(records, &rec)
+	}
+	if err := rows.Close(); err != nil {
+		return 0, xerrors.Errorf("failed to close rows: %w", err)
+	}
+	deleteQuery := fmt.Sprintf("DELETE FROM `%s` WHERE `id` = ?", outbox.table)
+	for idx, rec := range records {
+		var keys []*outboxKey
+		if err := json.Unmarshal([]byte(rec.keys), &keys); err != nil {
+			log.Warn(fmt.Sprintf("failed to unmarshal invalidation keys of %d. delete it without invalidation: %s: %+v", rec.id, rec.keys, err))
+			keys = nil
+		}
+		for _, key := range keys {
+			cacheKey, err := key.cacheKey()
+			if err != nil {
+				return idx, xerrors.Errorf("failed to get cache key: %w", err)
+			}
+			if err := r.cacheServer.Delete(ctx, cacheKey); err != nil && !IsCacheMiss(err) {
+				return idx, xerrors.Errorf("failed to delete %s: %w", cacheKey, err)
+			}
+			r.evictLocalCache(cacheKey)
+			log.Delete("", SLCServer, cacheKey)
+		}
+		if _, err := outbox.conn.ExecContext(ctx, deleteQuery, rec.id); err != nil {
+			return idx, xerrors.Errorf("failed sql %s: %w", deleteQuery, err)
+		}
+	}
+	return len(records), nil
+}
+
+// RunInvalidationOutbox calls ProcessInvalidationOutbox every interval until ctx is done
+func (r *Rapidash) RunInvalidationOutbox(ctx context.Context, interval, delay time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for {
+			processed, err := r.ProcessInvalidationOutbox(ctx, delay)
+			if err != nil {
+				log.Warn(fmt.Sprintf("failed to process invalidation outbox: %+v", err))
+				break
+			}
+			if processed < invalidationOutboxBatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package rapidash
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestInvalidationOutbox(t *testing.T) {
+	const table = "rapidash_invalidation_outbox"
+	_, err := conn.Exec(InvalidationOutboxDDL(table))
+	NoError(t, err)
+	_, err = conn.Exec("TRUNCATE TABLE " + table)
+	NoError(t, err)
+	NoError(t, initUserLoginTable(conn))
+	NoError(t, initCache(conn, CacheServerTypeMemcached))
+
+	clock := &testClock{now: time.Now()}
+	cache.opt.clock = clock
+	cache.opt.invalidationOutbox = &invalidationOutboxOption{conn: conn, table: table}
+	defer func() {
+		cache.opt.clock = systemClock{}
+		cache.opt.invalidationOutbox = nil
+	}()
+	countOutbox := func() int {
+		var count int
+		NoError(t, conn.QueryRow("SELECT COUNT(*) FROM "+table).Scan(&count))
+		return count
+	}
+	update := func(name string) *Tx {
+		txConn, err := conn.Begin()
+		NoError(t, err)
+		tx, err := cache.Begin(txConn)
+		NoError(t, err)
+		builder := NewQueryBuilder("user_logins").Eq("id", uint64(1))
+		NoError(t, tx.UpdateByQueryBuilder(builder, map[string]interface{}{"name": name}))
+		return tx
+	}
+
+	t.Run("remove after cache commit", func(t *testing.T) {
+		tx := update("outbox")
+		NoError(t, tx.Commit())
+		Equal(t, countOutbox(), 0)
+	})
+	t.Run("process after crash", func(t *testing.T) {
+		tx := update("crashed")
+		NoError(t, tx.CommitDBOnly())
+		Equal(t, countOutbox(), 1)
+		NoError(t, tx.RollbackCacheOnly())
+
+		processed, err := cache.ProcessInvalidationOutbox(context.Background(), time.Minute)
+		NoError(t, err)
+		Equal(t, processed, 0)
+		clock.advance(2 * time.Minute)
+		processed, err = cache.ProcessInvalidationOutbox(context.Background(), time.Minute)
+		NoError(t, err)
+		Equal(t, processed, 1)
+		Equal(t, countOutbox(), 0)
+
+		tx, err = cache.Begin(conn)
+		NoError(t, err)
+		var userLogin UserLogin
+		NoError(t, tx.FindByQueryBuilder(NewQueryBuilder("user_logins").Eq("id", uint64(1)), &userLogin))
+		Equal(t, userLogin.Name, "crashed")
+	})
+	t.Run("delete broken record", func(t *testing.T) {
+		_, err := conn.Exec("INSERT INTO "+table+" (`tx_id`,`cache_keys`,`created_at`) VALUES (?,?,?)", "broken", "{", clock.Now())
+		NoError(t, err)
+		tx := update("after broken")
+		NoError(t, tx.CommitDBOnly())
+		NoError(t, tx.RollbackCacheOnly())
+		Equal(t, countOutbox(), 2)
+
+		clock.advance(2 * time.Minute)
+		processed, err := cache.ProcessInvalidationOutbox(context.Background(), time.Minute)
+		NoError(t, err)
+		Equal(t, processed, 2)
+		Equal(t, countOutbox(), 0)
+	})
+}
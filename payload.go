@@ -0,0 +1,120 @@
+package rapidash
+
+import (
+	"encoding/binary"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// payloadMarker is the first byte of value encoded by PayloadCodec.
+// 0xc1 is never used by msgpack, so raw values written by older versions are distinguishable.
+// flags of cache server can't be used for this because they keep hash of shard key
+const payloadMarker byte = 0xc1
+
+// rawPayloadID is codec id of raw value. It is written only if raw value begins with payloadMarker
+const rawPayloadID uint8 = 0
+
+// stampedPayloadID is codec id reserved for payload prefixed by unix time in nanoseconds when it was written
+const stampedPayloadID uint8 = 0xff
+
+const stampedPayloadHeaderSize = 10
+
+// PayloadCodec transforms encoded value ( e.g. compression ) before it is written to cache server
+type PayloadCodec interface {
+	Encode([]byte) ([]byte, error)
+	Decode([]byte) ([]byte, error)
+}
+
+// payloadCodecs decodes values by every registered codec and encodes values by preferred one.
+// To change codec without cache flush, deploy registration first, then switch preferred codec
+type payloadCodecs struct {
+	preferred uint8
+	codecs    map[uint8]PayloadCodec
+}
+
+func newPayloadCodecs() *payloadCodecs {
+	return &payloadCodecs{codecs: map[uint8]PayloadCodec{}}
+}
+
+// configured returns true if any codec is registered.
+// Marker of payload is parsed only in that case, because raw values of last level cache may begin with payloadMarker
+func (p *payloadCodecs) configured() bool {
+	return p != nil && len(p.codecs) > 0
+}
+
+func (p *payloadCodecs) encode(content []byte) ([]byte, error) {
+	if len(content) == 0 {
+		return content, nil
+	}
+	if p == nil || p.preferred == rawPayloadID {
+		if p.configured() && content[0] == payloadMarker {
+			// escape raw value, otherwise it is decoded as encoded payload
+			payload := make([]byte, 0, len(content)+2)
+			payload = append(payload, payloadMarker, rawPayloadID)
+			return append(payload, content...), nil
+		}
+		return content, nil
+	}
+	codec, exists := p.codecs[p.preferred]
+	if !exists {
+		return nil, xerrors.Errorf("codec id %d: %w", p.preferred, ErrUnknownPayloadCodec)
+	}
+	encoded, err := codec.Encode(content)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to encode payload by codec %d: %w", p.preferred, err)
+	}
+	payload := make([]byte, 0, len(encoded)+2)
+	payload = append(payload, payloadMarker, p.preferred)
+	return append(payload, encoded...), nil
+}
+
+func (p *payloadCodecs) decode(content []byte) ([]byte, error) {
+	if !p.configured() || len(content) == 0 || content[0] != payloadMarker {
+		return content, nil
+	}
+	if len(content) < 2 {
+		return nil, xerrors.Errorf("payload has no codec id: %w", ErrUnknownPayloadCodec)
+	}
+	id := content[1]
+	if id == rawPayloadID {
+		return content[2:], nil
+	}
+	codec := p.codecs[id]
+	if codec == nil {
+		return nil, xerrors.Errorf("codec id %d: %w", id, ErrUnknownPayloadCodec)
+	}
+	decoded, err := codec.Decode(content[2:])
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decode payload by codec %d: %w", id, err)
+	}
+	return decoded, nil
+}
+
+// stampPayload prefixes payload by the time it is written
+func stampPayload(payload []byte, at time.Time) []byte {
+	stamped := make([]byte, stampedPayloadHeaderSize, stampedPayloadHeaderSize+len(payload))
+	stamped[0] = payloadMarker
+	stamped[1] = stampedPayloadID
+	binary.BigEndian.PutUint64(stamped[2:], uint64(at.UnixNano()))
+	return append(stamped, payload...)
+}
+
+// unstampPayload removes the time prefixed by stampPayload
+func unstampPayload(content []byte) ([]byte, error) {
+	if len(content) < 2 || content[0] != payloadMarker || content[1] != stampedPayloadID {
+		return content, nil
+	}
+	if len(content) < stampedPayloadHeaderSize {
+		return nil, xerrors.Errorf("stamped payload is too short: %w", ErrUnknownPayloadCodec)
+	}
+	return content[stampedPayloadHeaderSize:], nil
+}
+
+// payloadStampedAt returns the time when payload was written. false if payload isn't stamped
+func payloadStampedAt(content []byte) (time.Time, bool) {
+	if len(content) < stampedPayloadHeaderSize || content[0] != payloadMarker || content[1] != stampedPayloadID {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(content[2:stampedPayloadHeaderSize]))), true
+}
@@ -0,0 +1,107 @@
+package rapidash
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"golang.org/x/xerrors"
+)
+
+type reverseCodec struct{}
+
+func (reverseCodec) reverse(src []byte) []byte {
+	dst := make([]byte, len(src))
+	for i, b := range src {
+		dst[len(src)-1-i] = b
+	}
+	return dst
+}
+
+func (c reverseCodec) Encode(src []byte) ([]byte, error) { return c.reverse(src), nil }
+func (c reverseCodec) Decode(src []byte) ([]byte, error) { return c.reverse(src), nil }
+
+func TestPayloadCodec(t *testing.T) {
+	content := []byte{0x92, 0x01, 0x02}
+	t.Run("raw value is readable after registration", func(t *testing.T) {
+		r, err := New(RegisterPayloadCodec(1, reverseCodec{}))
+		NoError(t, err)
+		encoded, err := r.opt.payloadCodecs.encode(content)
+		NoError(t, err)
+		Equal(t, encoded, content)
+		decoded, err := r.opt.payloadCodecs.decode(encoded)
+		NoError(t, err)
+		Equal(t, decoded, content)
+	})
+	t.Run("encode by preferred codec", func(t *testing.T) {
+		r, err := New(RegisterPayloadCodec(1, reverseCodec{}), PreferredPayloadCodec(1))
+		NoError(t, err)
+		encoded, err := r.tableOption("users").payloadCodecs.encode(content)
+		NoError(t, err)
+		Equal(t, encoded[:2], []byte{payloadMarker, 1})
+		decoded, err := r.opt.llcOpt.payloadCodecs.decode(encoded)
+		NoError(t, err)
+		if !bytes.Equal(decoded, content) {
+			t.Fatalf("failed to decode payload: %v", decoded)
+		}
+	})
+	t.Run("unknown codec", func(t *testing.T) {
+		r, err := New(RegisterPayloadCodec(1, reverseCodec{}), PreferredPayloadCodec(1))
+		NoError(t, err)
+		encoded, err := r.opt.payloadCodecs.encode(content)
+		NoError(t, err)
+		old, err := New(RegisterPayloadCodec(2, reverseCodec{}))
+		NoError(t, err)
+		_, err = old.opt.payloadCodecs.decode(encoded)
+		if !xerrors.Is(err, ErrUnknownPayloadCodec) {
+			t.Fatalf("unexpected error %+v", err)
+		}
+	})
+	t.Run("raw value beginning with marker", func(t *testing.T) {
+		raw := []byte{payloadMarker, 1, 0x02}
+		r, err := New()
+		NoError(t, err)
+		encoded, err := r.opt.payloadCodecs.encode(raw)
+		NoError(t, err)
+		Equal(t, encoded, raw)
+		decoded, err := r.opt.payloadCodecs.decode(encoded)
+		NoError(t, err)
+		Equal(t, decoded, raw)
+
+		r, err = New(RegisterPayloadCodec(1, reverseCodec{}))
+		NoError(t, err)
+		encoded, err = r.opt.payloadCodecs.encode(raw)
+		NoError(t, err)
+		Equal(t, encoded[:2], []byte{payloadMarker, rawPayloadID})
+		decoded, err = r.opt.payloadCodecs.decode(encoded)
+		NoError(t, err)
+		Equal(t, decoded, raw)
+	})
+}
+
+func TestPayloadCodecWithSecondLevelCache(t *testing.T) {
+	NoError(t, initUserLoginTable(conn))
+	NoError(t, initCache(conn, CacheServerTypeMemcached))
+	codecs := newPayloadCodecs()
+	codecs.codecs[1] = reverseCodec{}
+	codecs.preferred = 1
+	slc := NewSecondLevelCache(userLoginType(), cache.cacheServer, TableOption{payloadCodecs: codecs})
+	NoError(t, slc.cacheServer.Flush(context.Background()))
+	NoError(t, slc.WarmUp(conn))
+	find := func() {
+		tx, err := cache.Begin(conn)
+		NoError(t, err)
+		var userLogin UserLogin
+		builder := NewQueryBuilder("user_logins").Eq("id", uint64(1))
+		NoError(t, slc.FindByQueryBuilder(context.Background(), tx, builder, &userLogin))
+		Equal(t, userLogin.ID, uint64(1))
+		NoError(t, tx.Commit())
+	}
+	find()
+	key := "r/slc/user_logins/id#1"
+	content, err := slc.cacheServer.Get(context.Background(), &CacheKey{key: key, hash: NewStringValue(key).Hash()})
+	NoError(t, err)
+	Equal(t, content.Value[:2], []byte{payloadMarker, 1})
+	find()
+	Equal(t, slc.ReadRepairCount(), uint64(0))
+}
@@ -0,0 +1,61 @@
+package rapidash
+
+import (
+	"context"
+	"sync/atomic"
+
+	"golang.org/x/xerrors"
+)
+
+// PrimaryKeyGenerator generates primary key of created value instead of LAST_INSERT_ID()
+// ( e.g. database sequence, client generated ID or deterministic ID for tests )
+type PrimaryKeyGenerator interface {
+	GeneratePrimaryKey(ctx context.Context, table string) (int64, error)
+}
+
+// PrimaryKeyGeneratorFunc is PrimaryKeyGenerator by function
+type PrimaryKeyGeneratorFunc func(ctx context.Context, table string) (int64, error)
+
+func (f PrimaryKeyGeneratorFunc) GeneratePrimaryKey(ctx context.Context, table string) (int64, error) {
+	return f(ctx, table)
+}
+
+// SequencePrimaryKeyGenerator generates sequential primary keys from start.
+type SequencePrimaryKeyGenerator struct {
+	next int64
+}
+
+func NewSequencePrimaryKeyGenerator(start int64) *SequencePrimaryKeyGenerator {
+	return &SequencePrimaryKeyGenerator{next: start - 1}
+}
+
+func (g *SequencePrimaryKeyGenerator) GeneratePrimaryKey(ctx context.Context, table string) (int64, error) {
+	return atomic.AddInt64(&g.next, 1), nil
+}
+
+// hasPrimaryKey returns false if some of primary key columns isn't set to value
+func (c *SecondLevelCache) hasPrimaryKey(value *StructValue) bool {
+	for _, column := range c.primaryKey.Columns {
+		if value.fields[column] == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// generatePrimaryKey sets primary key generated by PrimaryKeyGenerator to value.
+// false is returned if generator isn't set or value already has primary key
+func (c *SecondLevelCache) generatePrimaryKey(ctx context.Context, value *StructValue) (int64, bool, error) {
+	generator := c.opt.PrimaryKeyGenerator()
+	if generator == nil || c.hasPrimaryKey(value) {
+		return 0, false, nil
+	}
+	id, err := generator.GeneratePrimaryKey(ctx, c.typ.tableName)
+	if err != nil {
+		return 0, false, xerrors.Errorf("failed to generate primary key of %s: %w", c.typ.tableName, err)
+	}
+	if err := c.setLastInsertID(value, id); err != nil {
+		return 0, false, xerrors.Errorf("failed to set generated primary key: %w", err)
+	}
+	return id, true, nil
+}
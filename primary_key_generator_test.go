@@ -0,0 +1,95 @@
+package rapidash
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"golang.org/x/xerrors"
+)
+
+// execRecorder is Connection that records arguments of executed queries.
+// LastInsertId of result isn't supported like some drivers
+type execRecorder struct {
+	args [][]interface{}
+}
+
+func (c *execRecorder) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	return nil, xerrors.New("query is not supported")
+}
+
+func (c *execRecorder) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	c.args = append(c.args, args)
+	return driver.RowsAffected(1), nil
+}
+
+func TestPrimaryKeyGenerator(t *testing.T) {
+	r, err := New(SecondLevelCacheTablePrimaryKeyGenerator("user_logins", NewSequencePrimaryKeyGenerator(100)))
+	NoError(t, err)
+	slc := NewSecondLevelCache(userLoginType(), nil, r.tableOption("user_logins"))
+	slc.primaryKey = NewPrimaryKey(slc.opt, "user_logins", []string{"id"}, slc.typ)
+	conn := &execRecorder{}
+	tx, err := r.Begin(conn)
+	NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("generate primary key", func(t *testing.T) {
+		_, value, err := slc.encode(&UserLogin{UserID: 1})
+		NoError(t, err)
+		id, err := slc.insertValue(ctx, tx, value)
+		NoError(t, err)
+		Equal(t, id, int64(100))
+		Equal(t, value.fields["id"].RawValue(), uint64(100))
+		Equal(t, conn.args[0][0], uint64(100))
+	})
+	t.Run("value has primary key", func(t *testing.T) {
+		_, value, err := slc.encode(&UserLogin{ID: 5, UserID: 1})
+		NoError(t, err)
+		id, err := slc.insertValue(ctx, tx, value)
+		NoError(t, err)
+		Equal(t, id, int64(0))
+		Equal(t, conn.args[1][0], uint64(5))
+	})
+	t.Run("failed to generate", func(t *testing.T) {
+		slc.opt.pkGenerator = PrimaryKeyGeneratorFunc(func(context.Context, string) (int64, error) {
+			return 0, xerrors.New("sequence is exhausted")
+		})
+		_, value, err := slc.encode(&UserLogin{UserID: 1})
+		NoError(t, err)
+		_, err = slc.insertValue(ctx, tx, value)
+		Error(t, err)
+		Equal(t, len(conn.args), 2)
+	})
+}
+
+func TestPrimaryKeyGeneratorForCacheOnlyTable(t *testing.T) {
+	typ := NewStruct("ephemeral_logins").
+		FieldUint64("id").
+		FieldUint64("user_id").
+		FieldUint64("user_session_id").
+		FieldUint64("login_param_id").
+		FieldString("name").
+		FieldTime("created_at").
+		FieldTime("updated_at")
+	r, err := New(SecondLevelCacheTablePrimaryKeyGenerator("ephemeral_logins", NewSequencePrimaryKeyGenerator(1)))
+	NoError(t, err)
+	r.cacheServer = newMemoryCacheServer()
+	NoError(t, r.NewCacheOnlyTable(typ, PrimaryKeySpec("id"), KeySpec("user_id")))
+
+	tx, err := r.Begin()
+	NoError(t, err)
+	for i := int64(1); i <= 2; i++ {
+		id, err := tx.CreateByTable("ephemeral_logins", &UserLogin{UserID: 10})
+		NoError(t, err)
+		Equal(t, id, i)
+	}
+	NoError(t, tx.Commit())
+
+	tx, err = r.Begin()
+	NoError(t, err)
+	var login UserLogin
+	NoError(t, tx.FindByQueryBuilder(NewQueryBuilder("ephemeral_logins").Eq("id", uint64(2)), &login))
+	Equal(t, login.UserID, uint64(10))
+	NoError(t, tx.Commit())
+}
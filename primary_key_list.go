@@ -0,0 +1,156 @@
+package rapidash
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strconv"
+
+	"github.com/blastrain/msgpack"
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+// compactPrimaryKeysMarker is the first byte of primary keys encoded by delta varint.
+// It is msgpack ext8 header, so it is never confused with array of primary keys
+const compactPrimaryKeysMarker byte = 0xc7
+
+// compactPrimaryKeysThreshold is the minimum number of primary keys encoded by delta varint.
+// Fewer primary keys are encoded as msgpack array of strings
+const compactPrimaryKeysThreshold = 64
+
+// integerPrimaryKey splits primary key like r/slc/user_logins/id#123 into prefix and integer suffix
+func integerPrimaryKey(key string) (string, int64, bool) {
+	idx := len(key)
+	for idx > 0 && key[idx-1] >= '0' && key[idx-1] <= '9' {
+		idx--
+	}
+	if idx > 0 && key[idx-1] == '-' {
+		idx--
+	}
+	if idx == len(key) {
+		return "", 0, false
+	}
+	v, err := strconv.ParseInt(key[idx:], 10, 64)
+	if err != nil || strconv.FormatInt(v, 10) != key[idx:] {
+		// keep keys that aren't restored by FormatInt ( e.g. leading zero )
+		return "", 0, false
+	}
+	return key[:idx], v, true
+}
+
+// encodeCompactPrimaryKeys encodes primary keys that have the same prefix and integer suffix
+// as prefix and differences of the suffixes. false is returned if primary keys can't be encoded by this format
+func encodeCompactPrimaryKeys(primaryKeys []server.CacheKey) ([]byte, bool) {
+	if len(primaryKeys) < compactPrimaryKeysThreshold {
+		return nil, false
+	}
+	prefix, _, ok := integerPrimaryKey(primaryKeys[0].String())
+	if !ok {
+		return nil, false
+	}
+	content := make([]byte, 0, 1+binary.MaxVarintLen64*(len(primaryKeys)+2)+len(prefix))
+	content = append(content, compactPrimaryKeysMarker)
+	content = appendUvarint(content, uint64(len(prefix)))
+	content = append(content, prefix...)
+	content = appendUvarint(content, uint64(len(primaryKeys)))
+	prev := int64(0)
+	buf := make([]byte, binary.MaxVarintLen64)
+	for _, primaryKey := range primaryKeys {
+		p, v, ok := integerPrimaryKey(primaryKey.String())
+		if !ok || p != prefix {
+			return nil, false
+		}
+		n := binary.PutVarint(buf, v-prev)
+		content = append(content, buf[:n]...)
+		prev = v
+	}
+	return content, true
+}
+
+func appendUvarint(content []byte, v uint64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, v)
+	return append(content, buf[:n]...)
+}
+
+func decodeCompactPrimaryKeys(content []byte) ([]string, error) {
+	reader := bytes.NewReader(content[1:])
+	prefixLen, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read prefix length: %w", err)
+	}
+	if prefixLen > uint64(reader.Len()) {
+		return nil, xerrors.Errorf("prefix length %d is too long: %w", prefixLen, ErrInvalidCompactPrimaryKeys)
+	}
+	prefix := make([]byte, prefixLen)
+	if _, err := reader.Read(prefix); err != nil {
+		return nil, xerrors.Errorf("failed to read prefix: %w", err)
+	}
+	count, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read count: %w", err)
+	}
+	// each primary key needs at least a byte
+	if count > uint64(reader.Len()) {
+		return nil, xerrors.Errorf("count %d is too large: %w", count, ErrInvalidCompactPrimaryKeys)
+	}
+	primaryKeys := make([]string, count)
+	prev := int64(0)
+	for i := range primaryKeys {
+		delta, err := binary.ReadVarint(reader)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to read primary key: %w", err)
+		}
+		prev += delta
+		primaryKeys[i] = string(prefix) + strconv.FormatInt(prev, 10)
+	}
+	return primaryKeys, nil
+}
+
+// encodePrimaryKeys encodes primary keys of index key. Integer primary keys are encoded by delta varint
+// if compact is true and there are many of them, otherwise they are encoded as msgpack array of strings
+func encodePrimaryKeys(primaryKeys []server.CacheKey, compact bool) ([]byte, error) {
+	if !compact {
+		return encodePrimaryKeysArray(primaryKeys)
+	}
+	if content, ok := encodeCompactPrimaryKeys(primaryKeys); ok {
+		return content, nil
+	}
+	return encodePrimaryKeysArray(primaryKeys)
+}
+
+func encodePrimaryKeysArray(primaryKeys []server.CacheKey) ([]byte, error) {
+	var writer bytes.Buffer
+	enc := msgpack.NewEncoder(&writer)
+	if err := enc.EncodeArrayHeader(len(primaryKeys)); err != nil {
+		return nil, xerrors.Errorf("failed to encode array header: %w", err)
+	}
+	for _, primaryKey := range primaryKeys {
+		if err := enc.EncodeString(primaryKey.String()); err != nil {
+			return nil, xerrors.Errorf("failed to encode primary key: %w", err)
+		}
+	}
+	return writer.Bytes(), nil
+}
+
+func decodePrimaryKeys(content []byte) ([]string, error) {
+	if len(content) > 0 && content[0] == compactPrimaryKeysMarker {
+		primaryKeys, err := decodeCompactPrimaryKeys(content)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decode compact primary keys: %w", err)
+		}
+		return primaryKeys, nil
+	}
+	dec := msgpack.NewDecoder(bytes.NewBuffer(content))
+	var len int
+	if err := dec.DecodeArrayLength(&len); err != nil {
+		return nil, xerrors.Errorf("failed to decode array length: %w", err)
+	}
+	primaryKeys := make([]string, len)
+	for i := 0; i < len; i++ {
+		if err := dec.DecodeString(&primaryKeys[i]); err != nil {
+			return nil, xerrors.Errorf("failed to decode string: %w", err)
+		}
+	}
+	return primaryKeys, nil
+}
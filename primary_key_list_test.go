@@ -0,0 +1,102 @@
+package rapidash
+
+import (
+	"fmt"
+	"testing"
+
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+func primaryKeysByFormat(format string, ids []int64) []server.CacheKey {
+	keys := make([]server.CacheKey, 0, len(ids))
+	for _, id := range ids {
+		key := fmt.Sprintf(format, id)
+		keys = append(keys, &CacheKey{key: key, hash: NewStringValue(key).Hash()})
+	}
+	return keys
+}
+
+func TestEncodePrimaryKeys(t *testing.T) {
+	ids := make([]int64, 0, 1000)
+	for i := int64(0); i < 1000; i++ {
+		// not sorted and includes negative value
+		ids = append(ids, (i*7919)%1000-10)
+	}
+	slc := NewSecondLevelCache(userLoginType(), nil, TableOption{})
+	t.Run("compact", func(t *testing.T) {
+		primaryKeys := primaryKeysByFormat("r/slc/user_logins/id#%d", ids)
+		content, err := encodePrimaryKeys(primaryKeys, true)
+		NoError(t, err)
+		Equal(t, content[0], compactPrimaryKeysMarker)
+		decoded, err := slc.decodeMultiplePrimaryKeys(content, 0)
+		NoError(t, err)
+		Equal(t, len(decoded), len(primaryKeys))
+		for i := range primaryKeys {
+			Equal(t, decoded[i].String(), primaryKeys[i].String())
+			Equal(t, decoded[i].Hash(), primaryKeys[i].Hash())
+		}
+		var legacySize int
+		for _, key := range primaryKeys {
+			legacySize += len(key.String())
+		}
+		if len(content) > legacySize/5 {
+			t.Fatalf("compact primary keys are too large: %d bytes", len(content))
+		}
+	})
+	t.Run("few primary keys", func(t *testing.T) {
+		primaryKeys := primaryKeysByFormat("r/slc/user_logins/id#%d", ids[:compactPrimaryKeysThreshold-1])
+		content, err := encodePrimaryKeys(primaryKeys, true)
+		NoError(t, err)
+		if content[0] == compactPrimaryKeysMarker {
+			t.Fatal("few primary keys must be encoded by msgpack")
+		}
+		decoded, err := slc.decodeMultiplePrimaryKeys(content, 0)
+		NoError(t, err)
+		Equal(t, len(decoded), len(primaryKeys))
+		Equal(t, decoded[1].String(), primaryKeys[1].String())
+	})
+	t.Run("not integer primary keys", func(t *testing.T) {
+		primaryKeys := primaryKeysByFormat("r/slc/user_logins/id#%d", ids)
+		primaryKeys[10] = &CacheKey{key: "r/slc/user_logins/id#007"}
+		content, err := encodePrimaryKeys(primaryKeys, true)
+		NoError(t, err)
+		if content[0] == compactPrimaryKeysMarker {
+			t.Fatal("primary key with leading zero must be encoded by msgpack")
+		}
+		decoded, err := decodePrimaryKeys(content)
+		NoError(t, err)
+		Equal(t, decoded[10], "r/slc/user_logins/id#007")
+	})
+	t.Run("different prefix", func(t *testing.T) {
+		primaryKeys := primaryKeysByFormat("r/slc/user_logins/id#%d", ids)
+		primaryKeys[10] = &CacheKey{key: "r/slc/user_logins/user_id#10"}
+		content, err := encodePrimaryKeys(primaryKeys, true)
+		NoError(t, err)
+		if content[0] == compactPrimaryKeysMarker {
+			t.Fatal("primary keys with different prefix must be encoded by msgpack")
+		}
+	})
+	t.Run("disabled", func(t *testing.T) {
+		primaryKeys := primaryKeysByFormat("r/slc/user_logins/id#%d", ids)
+		content, err := encodePrimaryKeys(primaryKeys, false)
+		NoError(t, err)
+		if content[0] == compactPrimaryKeysMarker {
+			t.Fatal("primary keys must be encoded by msgpack unless compact format is enabled")
+		}
+		enabled := true
+		cfg := &TableConfig{CompactPKs: &enabled}
+		r, err := New(cfg.Options("user_logins")...)
+		NoError(t, err)
+		opt := r.tableOption("user_logins")
+		Equal(t, opt.CompactPrimaryKeys(), true)
+		other := r.tableOption("users")
+		Equal(t, other.CompactPrimaryKeys(), false)
+	})
+	t.Run("broken content", func(t *testing.T) {
+		_, err := decodePrimaryKeys([]byte{compactPrimaryKeysMarker, 0x01, 'a', 0x7f})
+		Equal(t, xerrors.Is(err, ErrInvalidCompactPrimaryKeys), true)
+		_, err = decodePrimaryKeys([]byte{compactPrimaryKeysMarker, 0x10})
+		Equal(t, xerrors.Is(err, ErrInvalidCompactPrimaryKeys), true)
+	})
+}
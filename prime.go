@@ -0,0 +1,120 @@
+package rapidash
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+// Prime loads rows matched by builder from database and sets them to cache without decoding them,
+// so background jobs can warm up hot records before they are requested.
+// Index lists are built only for indexes whose columns are the same as conditions of builder,
+// because rows of other indexes may not be loaded completely.
+// Keys are written together when tx is committed
+func (c *SecondLevelCache) Prime(ctx context.Context, tx *Tx, builder *QueryBuilder) error {
+	defer builder.Release()
+	if c.cacheOnly {
+		return nil
+	}
+	if err := c.typ.encryptConditions(builder); err != nil {
+		return xerrors.Errorf("failed to encrypt conditions: %w", err)
+	}
+	values, err := c.findValuesByQueryBuilderWithoutCache(ctx, tx, builder)
+	if err != nil {
+		return xerrors.Errorf("failed to find values by query builder without cache: %w", err)
+	}
+	indexLists := map[string][]server.CacheKey{}
+	indexKeys := map[string]server.CacheKey{}
+	for _, value := range values.values {
+		primaryKey, err := c.primaryKey.CacheKey(value)
+		if err != nil {
+			return xerrors.Errorf("failed to get cache key: %w", err)
+		}
+		if err := c.setPrimaryKey(ctx, tx, primaryKey, value); err != nil {
+			return xerrors.Errorf("failed to set primary key: %w", err)
+		}
+		for _, index := range c.indexes {
+			switch index.Type {
+			case IndexTypePrimaryKey:
+				continue
+			case IndexTypeUniqueKey:
+				key, err := index.CacheKey(value)
+				if err != nil {
+					return xerrors.Errorf("failed to get cache key: %w", err)
+				}
+				if err := c.setUniqueKey(ctx, tx, key, primaryKey); err != nil {
+					return xerrors.Errorf("failed to set unique key: %w", err)
+				}
+			default:
+				if !c.isPrimedIndex(builder, index) {
+					continue
+				}
+				key, err := index.CacheKey(value)
+				if err != nil {
+					return xerrors.Errorf("failed to get cache key: %w", err)
+				}
+				indexKeys[key.String()] = key
+				indexLists[key.String()] = append(indexLists[key.String()], primaryKey)
+			}
+		}
+	}
+	keys := make([]string, 0, len(indexKeys))
+	for key := range indexKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := c.fillKey(ctx, tx, indexKeys[key], indexLists[key]); err != nil {
+			return xerrors.Errorf("failed to fill key: %w", err)
+		}
+	}
+	return nil
+}
+
+// isPrimedIndex returns true if every record of index key is loaded by builder
+func (c *SecondLevelCache) isPrimedIndex(builder *QueryBuilder, index *Index) bool {
+	if !builder.AvailableCache() || builder.sqlCondition != nil {
+		return false
+	}
+	columns := builder.conditions.Columns()
+	if len(columns) != len(index.Columns) {
+		return false
+	}
+	sortedColumns := append([]string{}, columns...)
+	sort.Strings(sortedColumns)
+	indexColumns := append([]string{}, index.Columns...)
+	sort.Strings(indexColumns)
+	return strings.Join(sortedColumns, ":") == strings.Join(indexColumns, ":")
+}
+
+// PrimeByQueryBuilder sets rows matched by builder to cache. See SecondLevelCache.Prime
+func (tx *Tx) PrimeByQueryBuilder(builder *QueryBuilder) error {
+	if err := tx.PrimeByQueryBuilderContext(context.Background(), builder); err != nil {
+		return xerrors.Errorf("failed to PrimeByQueryBuilderContext: %w", err)
+	}
+	return nil
+}
+
+func (tx *Tx) PrimeByQueryBuilderContext(ctx context.Context, builder *QueryBuilder) error {
+	if tx.IsCommitted() {
+		return ErrAlreadyCommittedTransaction
+	}
+	if err := tx.enter(); err != nil {
+		return err
+	}
+	defer tx.leave()
+	c, exists := tx.r.secondLevelCache(builder.tableName)
+	if !exists {
+		return xerrors.Errorf("unknown table name %s: %w", builder.tableName, ErrTableNotWarmedUp)
+	}
+	if tx.connection(c) == nil && !c.cacheOnly {
+		return ErrConnectionOfTransaction
+	}
+	if err := c.Prime(ctx, tx, builder); err != nil {
+		return xerrors.Errorf("failed to Prime of SecondLevelCache: %w", err)
+	}
+	return nil
+}
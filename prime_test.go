@@ -0,0 +1,41 @@
+package rapidash
+
+import (
+	"testing"
+)
+
+func TestPrime(t *testing.T) {
+	r, err := New()
+	NoError(t, err)
+	cacheServer := newMemoryCacheServer()
+	r.cacheServer = cacheServer
+	slc := NewSecondLevelCache(userLoginType(), cacheServer, r.tableOption("user_logins"))
+	NoError(t, slc.WarmUp(conn))
+	r.secondLevelCaches.set("user_logins", slc)
+
+	tx, err := r.Begin(conn)
+	NoError(t, err)
+	NoError(t, tx.PrimeByQueryBuilder(NewQueryBuilder("user_logins").In("user_id", []uint64{1, 2})))
+	NoError(t, tx.Commit())
+
+	for _, key := range []string{
+		"r/slc/user_logins/id#1",
+		"r/slc/user_logins/id#2",
+		"r/slc/user_logins/uq/user_id#1&user_session_id#1",
+		"r/slc/user_logins/idx/user_id#1",
+		"r/slc/user_logins/idx/user_id#2",
+	} {
+		_, exists := cacheServer.values[key]
+		Equal(t, exists, true)
+	}
+	_, exists := cacheServer.values["r/slc/user_logins/idx/user_id#1&login_param_id#1"]
+	Equal(t, exists, false)
+}
+
+func TestIsPrimedIndex(t *testing.T) {
+	slc := NewSecondLevelCache(userLoginType(), nil, TableOption{})
+	index := NewKey(slc.opt, "user_logins", []string{"user_id", "login_param_id"}, slc.typ)
+	Equal(t, slc.isPrimedIndex(NewQueryBuilder("user_logins").Eq("login_param_id", uint64(1)).In("user_id", []uint64{1, 2}), index), true)
+	Equal(t, slc.isPrimedIndex(NewQueryBuilder("user_logins").Eq("user_id", uint64(1)), index), false)
+	Equal(t, slc.isPrimedIndex(NewQueryBuilder("user_logins").Eq("user_id", uint64(1)).Gte("login_param_id", uint64(1)), index), false)
+}
@@ -2,7 +2,11 @@ package rapidash
 
 import (
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/blastrain/vitess-sqlparser/sqlparser"
 	"go.knocknote.io/rapidash/server"
@@ -49,6 +53,22 @@ func (q *Query) Field(column string) *Value {
 	return q.value.fields[column]
 }
 
+func (q *Query) timeBucket(column string) time.Duration {
+	if q.index == nil {
+		return 0
+	}
+	return q.index.timeBucket(column)
+}
+
+// equalField compares column value of query with v. If column has time bucket, compares by bucket
+func (q *Query) equalField(column string, v *Value) bool {
+	value := q.value.fields[column]
+	if bucket := q.timeBucket(column); bucket > 0 && v != nil && !value.IsNil && !v.IsNil {
+		return value.timeValue.Truncate(bucket).Equal(v.timeValue.Truncate(bucket))
+	}
+	return value.EQ(v)
+}
+
 type QueryResult struct {
 	query       *Query
 	primaryKeys []server.CacheKey
@@ -135,26 +155,76 @@ func (i *QueryIterator) Reset() {
 	i.currentIndex = -1
 }
 
+var (
+	queryIteratorPool = sync.Pool{
+		New: func() interface{} {
+			return &QueryIterator{
+				keyToIndexMap:        map[server.CacheKey]int{},
+				primaryKeyToQueryMap: map[server.CacheKey]*Query{},
+			}
+		},
+	}
+	valueIteratorPool = sync.Pool{
+		New: func() interface{} {
+			return &ValueIterator{
+				keyToIndexMap: map[server.CacheKey]int{},
+			}
+		},
+	}
+)
+
 func NewQueryIterator(queries []*Query) *QueryIterator {
-	keys := make([]server.CacheKey, len(queries))
+	iter := &QueryIterator{
+		keyToIndexMap:        map[server.CacheKey]int{},
+		primaryKeyToQueryMap: map[server.CacheKey]*Query{},
+	}
+	iter.init(queries)
+	return iter
+}
+
+// acquireQueryIterator gets QueryIterator from pool. It must be released by Release()
+func acquireQueryIterator(queries []*Query) *QueryIterator {
+	iter := queryIteratorPool.Get().(*QueryIterator)
+	iter.init(queries)
+	return iter
+}
+
+func (i *QueryIterator) init(queries []*Query) {
+	i.currentIndex = -1
+	if cap(i.results) >= len(queries) {
+		i.results = i.results[:len(queries)]
+	} else {
+		i.results = append(i.results[:cap(i.results)], make([]*QueryResult, len(queries)-cap(i.results))...)
+	}
 	for idx, query := range queries {
-		keys[idx] = query.cacheKey
+		i.keys = append(i.keys, query.cacheKey)
+		i.keyToIndexMap[query.cacheKey] = idx
+		if i.results[idx] == nil {
+			i.results[idx] = &QueryResult{}
+		}
+		i.results[idx].query = query
+	}
+}
+
+// Release clears iterator and puts it back to pool
+func (i *QueryIterator) Release() {
+	for key := range i.keyToIndexMap {
+		delete(i.keyToIndexMap, key)
 	}
-	keyToIndexMap := map[server.CacheKey]int{}
-	for idx, key := range keys {
-		keyToIndexMap[key] = idx
+	for key := range i.primaryKeyToQueryMap {
+		delete(i.primaryKeyToQueryMap, key)
 	}
-	results := make([]*QueryResult, len(keys))
-	for idx := range results {
-		results[idx] = &QueryResult{query: queries[idx]}
+	for idx := range i.keys {
+		i.keys[idx] = nil
 	}
-	return &QueryIterator{
-		currentIndex:         -1,
-		keys:                 keys,
-		keyToIndexMap:        keyToIndexMap,
-		primaryKeyToQueryMap: map[server.CacheKey]*Query{},
-		results:              results,
+	i.keys = i.keys[:0]
+	for _, result := range i.results {
+		result.query = nil
+		result.primaryKeys = nil
+		result.err = nil
 	}
+	i.results = i.results[:0]
+	queryIteratorPool.Put(i)
 }
 
 type ValueIterator struct {
@@ -231,17 +301,58 @@ func (i *ValueIterator) Reset() {
 }
 
 func NewValueIterator(keys []server.CacheKey) *ValueIterator {
-	keyToIndexMap := map[server.CacheKey]int{}
-	for idx, key := range keys {
-		keyToIndexMap[key] = idx
+	iter := &ValueIterator{
+		keyToIndexMap: map[server.CacheKey]int{},
+	}
+	iter.keys = keys
+	iter.init()
+	return iter
+}
+
+// acquireValueIterator gets empty ValueIterator from pool.
+// Keys are added by AddKeys, and Build must be called before iteration.
+func acquireValueIterator() *ValueIterator {
+	return valueIteratorPool.Get().(*ValueIterator)
+}
+
+func (i *ValueIterator) AddKeys(keys ...server.CacheKey) {
+	i.keys = append(i.keys, keys...)
+}
+
+func (i *ValueIterator) Build() {
+	i.init()
+}
+
+func (i *ValueIterator) init() {
+	i.currentIndex = -1
+	if cap(i.values) >= len(i.keys) {
+		i.values = i.values[:len(i.keys)]
+		i.errs = i.errs[:len(i.keys)]
+	} else {
+		i.values = make([]*StructValue, len(i.keys))
+		i.errs = make([]error, len(i.keys))
+	}
+	for idx, key := range i.keys {
+		i.keyToIndexMap[key] = idx
+	}
+}
+
+// Release clears iterator and puts it back to pool
+func (i *ValueIterator) Release() {
+	for key := range i.keyToIndexMap {
+		delete(i.keyToIndexMap, key)
+	}
+	for idx := range i.keys {
+		i.keys[idx] = nil
 	}
-	return &ValueIterator{
-		currentIndex:  -1,
-		keys:          keys,
-		values:        make([]*StructValue, len(keys)),
-		errs:          make([]error, len(keys)),
-		keyToIndexMap: keyToIndexMap,
+	for idx := range i.values {
+		i.values[idx] = nil
+		i.errs[idx] = nil
 	}
+	i.keys = i.keys[:0]
+	i.values = i.values[:0]
+	i.errs = i.errs[:0]
+	valueIteratorPool.Put(i)
 }
 
 type Queries struct {
@@ -253,6 +364,12 @@ type Queries struct {
 	rawSQLValues     []interface{}
 	lockOpt          *LockingReadOption
 	isAllSQL         bool
+	annotation       *sqlAnnotation
+	indexHint        string
+	// if refetchPrimaryKeys is true, queries for values evicted from cache are kept in missingPrimaryKeys
+	// instead of cacheMissQueries
+	refetchPrimaryKeys bool
+	missingPrimaryKeys []*missingPrimaryKey
 }
 
 func NewQueries(tableName string, primaryIndex *Index, queryNum int) *Queries {
@@ -290,14 +407,15 @@ func (q *Queries) Each(iter func(*Query) error) error {
 }
 
 func (q *Queries) LoadValues(factory *ValueFactory, primaryKeyLoader func(IndexType, *QueryIterator) error, valueLoader func(*ValueIterator) error) (*StructSliceValue, error) {
-	queryIter := NewQueryIterator(q.queries)
+	queryIter := acquireQueryIterator(q.queries)
+	defer queryIter.Release()
 	if err := primaryKeyLoader(q.queries[0].index.Type, queryIter); err != nil {
 		return nil, xerrors.Errorf("failed to load primary key: %w", err)
 	}
 	queryIter.Reset()
 
-	foundValues := NewStructSliceValue()
-	findPrimaryKeys := []server.CacheKey{}
+	valueIter := acquireValueIterator()
+	defer valueIter.Release()
 	for queryIter.Next() {
 		if err := queryIter.Error(); err != nil {
 			if IsCacheMiss(err) {
@@ -306,9 +424,10 @@ func (q *Queries) LoadValues(factory *ValueFactory, primaryKeyLoader func(IndexT
 			}
 			return nil, xerrors.Errorf("failed to cache: %w", err)
 		}
-		findPrimaryKeys = append(findPrimaryKeys, queryIter.PrimaryKeys()...)
+		valueIter.AddKeys(queryIter.PrimaryKeys()...)
 	}
-	valueIter := NewValueIterator(findPrimaryKeys)
+	valueIter.Build()
+	foundValues := NewStructSliceValueWithCapacity(len(valueIter.keys))
 	if err := valueLoader(valueIter); err != nil {
 		return nil, xerrors.Errorf("failed to load value: %w", err)
 	}
@@ -322,6 +441,17 @@ func (q *Queries) LoadValues(factory *ValueFactory, primaryKeyLoader func(IndexT
 	for valueIter.Next() {
 		if err := valueIter.Error(); err != nil {
 			if IsCacheMiss(err) {
+				if q.refetchPrimaryKeys {
+					query, err := valueIter.QueryByPrimaryKey(factory, q.primaryIndex)
+					if err != nil {
+						return nil, xerrors.Errorf("failed to get query by primary key: %w", err)
+					}
+					q.missingPrimaryKeys = append(q.missingPrimaryKeys, &missingPrimaryKey{
+						query:      query,
+						indexQuery: queryIter.QueryByPrimaryKey(valueIter.PrimaryKey()),
+					})
+					continue
+				}
 				if existsFirstPhaseCacheMissQuery {
 					query := queryIter.QueryByPrimaryKey(valueIter.PrimaryKey())
 					if _, exists := alreadyAddedCacheMissQueryMap[query]; !exists {
@@ -357,7 +487,7 @@ func (q *Queries) FindCacheMissQueryByStructValue(value *StructValue) *Query {
 		}
 		allEqualColumn := true
 		for _, column := range query.columns {
-			if !query.value.fields[column].EQ(value.fields[column]) {
+			if !query.equalField(column, value.fields[column]) {
 				allEqualColumn = false
 				break
 			}
@@ -370,21 +500,11 @@ func (q *Queries) FindCacheMissQueryByStructValue(value *StructValue) *Query {
 }
 
 func (q *Queries) CacheMissQueriesToSQL(typ *Struct) (string, []interface{}) {
-	escapedColumns := []string{}
-	for _, column := range typ.Columns() {
-		escapedColumns = append(escapedColumns, fmt.Sprintf("`%s`", column))
-	}
+	prefix := typ.selectPrefix(q.tableName)
 	if q.rawSQL != "" {
-		return fmt.Sprintf("SELECT %s FROM `%s` %s",
-			strings.Join(escapedColumns, ","),
-			q.tableName,
-			q.rawSQL,
-		), q.rawSQLValues
+		return q.annotation.apply(prefix + " " + q.rawSQL), q.rawSQLValues
 	} else if q.isAllSQL {
-		return fmt.Sprintf("SELECT %s FROM `%s`",
-			strings.Join(escapedColumns, ","),
-			q.tableName,
-		), nil
+		return q.annotation.apply(prefix), nil
 	}
 	if len(q.cacheMissQueries) == 0 {
 		return "", nil
@@ -396,10 +516,21 @@ func (q *Queries) CacheMissQueriesToSQL(typ *Struct) (string, []interface{}) {
 		}
 	}
 	query := q.cacheMissQueries[0]
-	conditions := []string{}
+	shapes := make([]conditionShape, 0, len(query.columns))
 	queryArgs := []interface{}{}
 	for _, column := range query.columns {
 		values := columnMap[column]
+		if bucket := query.timeBucket(column); bucket > 0 {
+			args, existsNil := timeBucketArgs(bucket, values)
+			shapes = append(shapes, conditionShape{
+				column:    column,
+				kind:      conditionShapeTimeBucket,
+				count:     len(args) / 2,
+				existsNil: existsNil,
+			})
+			queryArgs = append(queryArgs, args...)
+			continue
+		}
 		value := values[0]
 		isINQuery := false
 		for _, v := range values {
@@ -409,39 +540,35 @@ func (q *Queries) CacheMissQueriesToSQL(typ *Struct) (string, []interface{}) {
 			}
 			value = v
 		}
-		var condition string
 		if isINQuery {
-			placeholders := []string{}
 			for _, v := range values {
 				if v.IsNil {
 					queryArgs = append(queryArgs, nil)
 				} else {
 					queryArgs = append(queryArgs, v.RawValue())
 				}
-				placeholders = append(placeholders, "?")
 			}
-			condition = fmt.Sprintf("`%s` IN (%s)", column, strings.Join(placeholders, ","))
+			shapes = append(shapes, conditionShape{column: column, kind: conditionShapeIN, count: len(values)})
+		} else if !value.IsNil {
+			queryArgs = append(queryArgs, value.RawValue())
+			shapes = append(shapes, conditionShape{column: column, kind: conditionShapeEQ})
 		} else {
-			if !value.IsNil {
-				queryArgs = append(queryArgs, value.RawValue())
-				condition = fmt.Sprintf("`%s` = ?", column)
-			} else {
-				condition = fmt.Sprintf("`%s` IS NULL", column)
-			}
-
+			shapes = append(shapes, conditionShape{column: column, kind: conditionShapeNull})
 		}
-		conditions = append(conditions, condition)
 	}
-	lockOpt := q.lockOpt.String()
-	if lockOpt != "" {
-		lockOpt = " " + lockOpt
+	return q.annotation.apply(withOptimizerHint(prefix+typ.whereTemplate(shapes, q.lockOpt.String()), q.indexHint)), queryArgs
+}
+
+// timeBucketCondition builds condition to find all records in the buckets of values
+func timeBucketCondition(column string, bucket time.Duration, values []*Value) (string, []interface{}) {
+	args, existsNil := timeBucketArgs(bucket, values)
+	shape := conditionShape{
+		column:    column,
+		kind:      conditionShapeTimeBucket,
+		count:     len(args) / 2,
+		existsNil: existsNil,
 	}
-	return fmt.Sprintf("SELECT %s FROM `%s` WHERE %s%s",
-		strings.Join(escapedColumns, ","),
-		q.tableName,
-		strings.Join(conditions, " AND "),
-		lockOpt,
-	), queryArgs
+	return shape.condition(), args
 }
 
 type Condition interface {
@@ -521,11 +648,70 @@ func (c *Conditions) Queries() []string {
 }
 
 func (b *QueryBuilder) AvailableIndex() bool {
-	condition := b.conditions.currentWithoutProgress()
-	if _, ok := condition.(*NEQCondition); ok {
+	return !isNegativeCondition(b.conditions.currentWithoutProgress())
+}
+
+func isNegativeCondition(condition Condition) bool {
+	switch condition.(type) {
+	case *NEQCondition, *NOTINCondition:
+		return true
+	}
+	return false
+}
+
+// sqlNegativeCondition compares value by != or NOT IN as database does.
+// NULL never matches them, and != NULL means IS NOT NULL
+type sqlNegativeCondition struct {
+	Condition
+}
+
+func (c *sqlNegativeCondition) Compare(value *Value) bool {
+	if neq, ok := c.Condition.(*NEQCondition); ok && neq.value.IsNil {
+		return value != nil && !value.IsNil
+	}
+	if notIn, ok := c.Condition.(*NOTINCondition); ok && len(notIn.values) == 0 {
+		return true
+	}
+	if value == nil || value.IsNil {
 		return false
 	}
-	return true
+	return c.Condition.Compare(value)
+}
+
+// splitNegativeConditions returns builder that has conditions except != and NOT IN, and the excluded conditions.
+// Returned builder shares conditions with b, so it must not be released.
+// nil is returned if b has no negative condition or other conditions aren't available for cache
+func (b *QueryBuilder) splitNegativeConditions() (*QueryBuilder, []Condition) {
+	if b.sqlCondition != nil || b.isIgnoreCache || b.lockOpt != nil {
+		return nil, nil
+	}
+	builder := &QueryBuilder{
+		tableName: b.tableName,
+		conditions: &Conditions{
+			conditions: make([]Condition, 0, b.conditions.Len()),
+		},
+		inCondition:     b.inCondition,
+		orderConditions: b.orderConditions,
+		isEncrypted:     b.isEncrypted,
+		annotation:      b.annotation,
+		acceptStale:     b.acceptStale,
+		useIndex:        b.useIndex,
+	}
+	negativeConditions := []Condition{}
+	for _, condition := range b.conditions.conditions {
+		switch condition.(type) {
+		case *EQCondition, *INCondition:
+			builder.conditions.Append(condition)
+		case *NEQCondition, *NOTINCondition:
+			negativeConditions = append(negativeConditions, &sqlNegativeCondition{Condition: condition})
+		default:
+			return nil, nil
+		}
+	}
+	if len(negativeConditions) == 0 || builder.conditions.Len() == 0 {
+		return nil, nil
+	}
+	return builder, negativeConditions
 }
 
 type QueryBuilder struct {
@@ -534,10 +720,49 @@ type QueryBuilder struct {
 	inCondition     *INCondition
 	sqlCondition    *SQLCondition
 	orderConditions []*OrderCondition
+	limit           *int
+	offset          int
 	lockOpt         *LockingReadOption
 	err             error
 	isIgnoreCache   bool
+	isEncrypted     bool
 	cachedQueries   *Queries
+	annotation      *sqlAnnotation
+	info            *QueryInfo
+	acceptStale     time.Duration
+	useIndex        string
+}
+
+// QueryInfo reports where values found by QueryBuilder came from. counts are added on every find
+type QueryInfo struct {
+	Rows       int // number of values returned
+	CacheRows  int // number of values found by cache or stash
+	DBRows     int // number of values loaded from database
+	MissedKeys int // number of cache keys that weren't found
+}
+
+func (i *QueryInfo) addRows(n int) {
+	if i != nil {
+		i.Rows += n
+	}
+}
+
+func (i *QueryInfo) addCacheRows(n int) {
+	if i != nil {
+		i.CacheRows += n
+	}
+}
+
+func (i *QueryInfo) addDBRows(n int) {
+	if i != nil {
+		i.DBRows += n
+	}
+}
+
+func (i *QueryInfo) addMissedKeys(n int) {
+	if i != nil {
+		i.MissedKeys += n
+	}
 }
 
 func NewQueryBuilder(tableName string) *QueryBuilder {
@@ -600,6 +825,86 @@ func (b *QueryBuilder) indexes() []string {
 	return sortedIndexes
 }
 
+var (
+	sessionVariableNamePattern  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	sessionVariableValuePattern = regexp.MustCompile(`^('[^'*]*'|[A-Za-z0-9_.\-]+)$`)
+)
+
+func validateSessionVariables(vars map[string]string) error {
+	for name, value := range vars {
+		if !sessionVariableNamePattern.MatchString(name) {
+			return xerrors.Errorf("name %q: %w", name, ErrInvalidSessionVariable)
+		}
+		if !sessionVariableValuePattern.MatchString(value) {
+			return xerrors.Errorf("value %q of %s: %w", value, name, ErrInvalidSessionVariable)
+		}
+	}
+	return nil
+}
+
+// sessionVariableHint builds optimizer hint that sets vars only while executing the statement
+func sessionVariableHint(vars map[string]string) string {
+	if len(vars) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	hints := make([]string, 0, len(names))
+	for _, name := range names {
+		hints = append(hints, fmt.Sprintf("SET_VAR(%s=%s)", name, vars[name]))
+	}
+	return strings.Join(hints, " ")
+}
+
+// withOptimizerHint puts hint into optimizer hint block of SELECT/UPDATE/DELETE statement.
+// If query already has the block, hint is merged into it because MySQL reads only the first one
+func withOptimizerHint(query string, hint string) string {
+	if hint == "" {
+		return query
+	}
+	leading := ""
+	if strings.HasPrefix(query, "/* ") {
+		if end := strings.Index(query, " */ "); end >= 0 {
+			leading, query = query[:end+len(" */ ")], query[end+len(" */ "):]
+		}
+	}
+	for _, verb := range []string{"SELECT ", "UPDATE ", "DELETE "} {
+		if !strings.HasPrefix(query, verb) {
+			continue
+		}
+		body := strings.TrimPrefix(query, verb)
+		if strings.HasPrefix(body, "/*+ ") {
+			return fmt.Sprintf("%s%s/*+ %s %s", leading, verb, hint, strings.TrimPrefix(body, "/*+ "))
+		}
+		return fmt.Sprintf("%s%s/*+ %s */ %s", leading, verb, hint, body)
+	}
+	return leading + query
+}
+
+// sqlAnnotation is comment and optimizer hints written to generated SQL
+type sqlAnnotation struct {
+	comment string
+	hints   []string
+}
+
+func escapeSQLComment(text string) string {
+	return strings.Replace(text, "*/", "* /", -1)
+}
+
+func (a *sqlAnnotation) apply(query string) string {
+	if a == nil {
+		return query
+	}
+	query = withOptimizerHint(query, strings.Join(a.hints, " "))
+	if a.comment != "" {
+		query = fmt.Sprintf("/* %s */ %s", a.comment, query)
+	}
+	return query
+}
+
 func (b *QueryBuilder) SelectSQL(factory *ValueFactory, typ *Struct) (string, []interface{}) {
 	b.Build(factory)
 	where := []string{}
@@ -608,20 +913,26 @@ func (b *QueryBuilder) SelectSQL(factory *ValueFactory, typ *Struct) (string, []
 		where = append(where, condition.Query())
 		args = append(args, condition.QueryArgs()...)
 	}
-	escapedColumns := []string{}
-	for _, column := range typ.Columns() {
-		escapedColumns = append(escapedColumns, fmt.Sprintf("`%s`", column))
-	}
 	lockOpt := b.lockOpt.String()
 	if lockOpt != "" {
 		lockOpt = " " + lockOpt
 	}
-	return fmt.Sprintf("SELECT %s FROM `%s` WHERE %s%s",
-		strings.Join(escapedColumns, ","),
-		b.tableName,
+	return b.annotation.apply(fmt.Sprintf("%s WHERE %s%s",
+		typ.selectPrefix(b.tableName),
 		strings.Join(where, " AND "),
 		lockOpt,
-	), args
+	)), args
+}
+
+func (b *QueryBuilder) CountSQL(factory *ValueFactory) (string, []interface{}) {
+	b.Build(factory)
+	where := []string{}
+	args := []interface{}{}
+	for _, condition := range b.conditions.conditions {
+		where = append(where, condition.Query())
+		args = append(args, condition.QueryArgs()...)
+	}
+	return b.annotation.apply(fmt.Sprintf("SELECT COUNT(*) FROM `%s` WHERE %s", b.tableName, strings.Join(where, " AND "))), args
 }
 
 func (b *QueryBuilder) UpdateSQL(factory *ValueFactory, updateMap map[string]interface{}) (string, []interface{}) {
@@ -639,7 +950,7 @@ func (b *QueryBuilder) UpdateSQL(factory *ValueFactory, updateMap map[string]int
 		values = append(values, v)
 	}
 	values = append(values, args...)
-	return fmt.Sprintf("UPDATE `%s` SET %s WHERE %s", b.tableName, strings.Join(setList, ","), strings.Join(where, " AND ")), values
+	return b.annotation.apply(fmt.Sprintf("UPDATE `%s` SET %s WHERE %s", b.tableName, strings.Join(setList, ","), strings.Join(where, " AND "))), values
 }
 
 func (b *QueryBuilder) DeleteSQL(factory *ValueFactory) (string, []interface{}) {
@@ -650,7 +961,7 @@ func (b *QueryBuilder) DeleteSQL(factory *ValueFactory) (string, []interface{})
 		where = append(where, condition.Query())
 		args = append(args, condition.QueryArgs()...)
 	}
-	return fmt.Sprintf("DELETE FROM `%s` WHERE %s", b.tableName, strings.Join(where, " AND ")), args
+	return b.annotation.apply(fmt.Sprintf("DELETE FROM `%s` WHERE %s", b.tableName, strings.Join(where, " AND "))), args
 }
 
 func (b *QueryBuilder) Release() {
@@ -686,10 +997,11 @@ func (b *QueryBuilder) buildINQueryWithIndex(indexes map[string]*Index) (*Querie
 			}
 		}
 	}
-	index, exists := indexes[strings.Join(queries.At(0).columns, ":")]
-	if !exists {
-		return nil, ErrLookUpIndexFromQuery
+	index, err := b.indexByColumns(queries.At(0).columns, indexes)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get index: %w", err)
 	}
+	queries.indexHint = b.indexHint(index)
 	for _, query := range queries.queries {
 		if err := query.SetIndex(index); err != nil {
 			return nil, xerrors.Errorf("failed to set index: %w", err)
@@ -699,6 +1011,110 @@ func (b *QueryBuilder) buildINQueryWithIndex(indexes map[string]*Index) (*Querie
 	return queries, nil
 }
 
+func (b *QueryBuilder) isINChunkRequired(factory *ValueFactory, size int) bool {
+	if size <= 0 || b.err != nil || b.inCondition == nil || b.sqlCondition != nil {
+		return false
+	}
+	b.inCondition.Build(factory)
+	return len(b.inCondition.values) > size
+}
+
+// chunkedBuilders splits unique IN values into builders that have at most size values.
+// Values are shared with b, so chunked builders must not be released.
+// clone returns builder that has the same conditions without sharing values built by ValueFactory,
+// so it can be used by other goroutine after b is released. it returns nil if b has unknown condition
+func (b *QueryBuilder) clone() *QueryBuilder {
+	if b.sqlCondition != nil {
+		return nil
+	}
+	builder := &QueryBuilder{
+		tableName: b.tableName,
+		conditions: &Conditions{
+			conditions: make([]Condition, 0, b.conditions.Len()),
+		},
+		orderConditions: b.orderConditions,
+		limit:           b.limit,
+		offset:          b.offset,
+		lockOpt:         b.lockOpt,
+		isIgnoreCache:   b.isIgnoreCache,
+		isEncrypted:     b.isEncrypted,
+		annotation:      b.annotation,
+		err:             b.err,
+		acceptStale:     b.acceptStale,
+		useIndex:        b.useIndex,
+	}
+	for _, condition := range b.conditions.conditions {
+		switch c := condition.(type) {
+		case *EQCondition:
+			builder.conditions.Append(&EQCondition{column: c.column, rawValue: c.rawValue})
+		case *NEQCondition:
+			builder.conditions.Append(&NEQCondition{column: c.column, rawValue: c.rawValue})
+		case *GTCondition:
+			builder.conditions.Append(&GTCondition{column: c.column, rawValue: c.rawValue})
+		case *GTECondition:
+			builder.conditions.Append(&GTECondition{column: c.column, rawValue: c.rawValue})
+		case *LTCondition:
+			builder.conditions.Append(&LTCondition{column: c.column, rawValue: c.rawValue})
+		case *LTECondition:
+			builder.conditions.Append(&LTECondition{column: c.column, rawValue: c.rawValue})
+		case *NOTINCondition:
+			if c.rawValues == nil {
+				return nil
+			}
+			builder.conditions.Append(&NOTINCondition{column: c.column, rawValues: c.rawValues})
+		case *INCondition:
+			if c.rawValues == nil {
+				return nil
+			}
+			in := &INCondition{column: c.column, rawValues: c.rawValues}
+			if condition == b.inCondition {
+				builder.inCondition = in
+			}
+			builder.conditions.Append(in)
+		default:
+			return nil
+		}
+	}
+	return builder
+}
+
+func (b *QueryBuilder) chunkedBuilders(factory *ValueFactory, size int) []*QueryBuilder {
+	b.conditions.Build(factory)
+	values := b.inCondition.values
+	builders := make([]*QueryBuilder, 0, (len(values)+size-1)/size)
+	for start := 0; start < len(values); start += size {
+		end := start + size
+		if end > len(values) {
+			end = len(values)
+		}
+		chunk := &INCondition{column: b.inCondition.column, values: values[start:end]}
+		builder := &QueryBuilder{
+			tableName: b.tableName,
+			conditions: &Conditions{
+				conditions: make([]Condition, 0, b.conditions.Len()),
+			},
+			inCondition:     chunk,
+			orderConditions: b.orderConditions,
+			lockOpt:         b.lockOpt,
+			isIgnoreCache:   b.isIgnoreCache,
+			isEncrypted:     b.isEncrypted,
+			annotation:      b.annotation,
+			info:            b.info,
+			acceptStale:     b.acceptStale,
+			useIndex:        b.useIndex,
+		}
+		for _, condition := range b.conditions.conditions {
+			if condition == b.inCondition {
+				builder.conditions.Append(chunk)
+			} else {
+				builder.conditions.Append(condition)
+			}
+		}
+		builders = append(builders, builder)
+	}
+	return builders
+}
+
 func (b *QueryBuilder) buildAllQuery() *Queries {
 	b.isIgnoreCache = true
 	return &Queries{
@@ -728,6 +1144,54 @@ func (b *QueryBuilder) buildRawQuery() (*Queries, error) {
 	}, nil
 }
 
+func (b *QueryBuilder) noMatchingIndexError(columns []string, indexes map[string]*Index) error {
+	knownIndexes := make([]string, 0, len(indexes))
+	for index := range indexes {
+		knownIndexes = append(knownIndexes, fmt.Sprintf("(%s)", strings.Replace(index, ":", ",", -1)))
+	}
+	sort.Strings(knownIndexes)
+	return xerrors.Errorf("%s has no index for (%s). known indexes are %s: %w",
+		b.tableName, strings.Join(columns, ","), strings.Join(knownIndexes, " "), ErrNoMatchingIndex)
+}
+
+// indexByColumns returns index used for cache key of query having columns.
+// If UseIndex is set, the named index is used if it has the same columns regardless of order
+func (b *QueryBuilder) indexByColumns(columns []string, indexes map[string]*Index) (*Index, error) {
+	if b.useIndex == "" {
+		index, exists := indexes[strings.Join(columns, ":")]
+		if !exists {
+			return nil, b.noMatchingIndexError(columns, indexes)
+		}
+		return index, nil
+	}
+	index, exists := indexes[b.useIndex]
+	if !exists {
+		return nil, xerrors.Errorf("%s has no index (%s): %w", b.tableName, strings.Replace(b.useIndex, ":", ",", -1), ErrUnknownIndex)
+	}
+	if len(index.Columns) != len(columns) {
+		return nil, b.indexMismatchError(columns)
+	}
+	for _, column := range columns {
+		if !index.HasColumn(column) {
+			return nil, b.indexMismatchError(columns)
+		}
+	}
+	return index, nil
+}
+
+func (b *QueryBuilder) indexMismatchError(columns []string) error {
+	return xerrors.Errorf("index (%s) of %s can't be used for (%s): %w",
+		strings.Replace(b.useIndex, ":", ",", -1), b.tableName, strings.Join(columns, ","), ErrNoMatchingIndex)
+}
+
+// indexHint returns optimizer hint making database use the same index as cache if it is forced by UseIndex
+func (b *QueryBuilder) indexHint(index *Index) string {
+	if b.useIndex == "" || index.name == "" {
+		return ""
+	}
+	return fmt.Sprintf("INDEX(%s %s)", b.tableName, index.name)
+}
+
 func (b *QueryBuilder) primaryIndexFromIndexes(indexes map[string]*Index) *Index {
 	for _, index := range indexes {
 		if index.Type == IndexTypePrimaryKey {
@@ -742,8 +1206,15 @@ func (b *QueryBuilder) validateCondition(typ *Struct) error {
 		column := condition.Column()
 		field, exists := typ.fields[column]
 		if !exists {
+			if suggestion := typ.similarColumn(column); suggestion != "" {
+				return xerrors.Errorf("%s.%s is not found. did you mean %s ?: %w", b.tableName, column, suggestion, ErrUnknownColumnName)
+			}
 			return xerrors.Errorf("%s.%s is not found: %w", b.tableName, column, ErrUnknownColumnName)
 		}
+		if notIn, ok := condition.(*NOTINCondition); ok && len(notIn.values) == 0 {
+			// there is no value to validate type
+			continue
+		}
 		value := condition.Value()
 		if value == nil {
 			return xerrors.Errorf("%s.%s type is invalid: %w", b.tableName, column, ErrInvalidColumnType)
@@ -756,10 +1227,64 @@ func (b *QueryBuilder) validateCondition(typ *Struct) error {
 				b.tableName, column, field.kind, value.kind, ErrInvalidColumnType)
 		}
 	}
+	for _, order := range b.orderConditions {
+		if _, exists := typ.fields[order.column]; !exists {
+			return xerrors.Errorf("%s.%s for order is not found: %w", b.tableName, order.column, ErrUnknownColumnName)
+		}
+	}
 	return nil
 }
 
-func (b *QueryBuilder) BuildWithIndex(factory *ValueFactory, indexes map[string]*Index, typ *Struct) (*Queries, error) {
+// similarColumn returns the closest column name to typo'd column, or empty string if there is no similar column
+func (s *Struct) similarColumn(column string) string {
+	similar := ""
+	minDistance := len(column)/3 + 1
+	columns := make([]string, 0, len(s.fields))
+	for c := range s.fields {
+		columns = append(columns, c)
+	}
+	sort.Strings(columns)
+	for _, c := range columns {
+		if distance := editDistance(strings.ToLower(column), c); distance < minDistance {
+			similar = c
+			minDistance = distance
+		}
+	}
+	return similar
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func (b *QueryBuilder) BuildWithIndex(factory *ValueFactory, indexes map[string]*Index, typ *Struct) (q *Queries, e error) {
+	defer func() {
+		if q != nil {
+			q.annotation = b.annotation
+		}
+	}()
 	if b.err != nil {
 		return nil, xerrors.Errorf("failed to build query: %w", b.err)
 	}
@@ -798,10 +1323,11 @@ func (b *QueryBuilder) BuildWithIndex(factory *ValueFactory, indexes map[string]
 		b.cachedQueries = queries
 		return queries, nil
 	}
-	index, exists := indexes[strings.Join(query.columns, ":")]
-	if !exists {
-		return nil, ErrLookUpIndexFromQuery
+	index, err := b.indexByColumns(query.columns, indexes)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get index: %w", err)
 	}
+	queries.indexHint = b.indexHint(index)
 	if err := query.SetIndex(index); err != nil {
 		return nil, xerrors.Errorf("failed to set index: %w", err)
 	}
@@ -809,6 +1335,70 @@ func (b *QueryBuilder) BuildWithIndex(factory *ValueFactory, indexes map[string]
 	return queries, nil
 }
 
+// DebugString returns query with interpolated values for diagnostics. condition values are built if not yet
+func (b *QueryBuilder) DebugString() string {
+	if b.sqlCondition != nil {
+		return fmt.Sprintf("table:%s sql:%s args:%v", b.tableName, b.sqlCondition.stmt, b.sqlCondition.rawValues)
+	}
+	b.conditions.Build(debugValueFactory)
+	where := make([]string, 0, b.conditions.Len())
+	for _, condition := range b.conditions.conditions {
+		query := condition.Query()
+		for _, arg := range condition.QueryArgs() {
+			query = strings.Replace(query, "?", debugQueryArg(arg), 1)
+		}
+		where = append(where, query)
+	}
+	orders := make([]string, 0, len(b.orderConditions))
+	for _, order := range b.orderConditions {
+		if order.isAsc {
+			orders = append(orders, fmt.Sprintf("`%s` ASC", order.column))
+		} else {
+			orders = append(orders, fmt.Sprintf("`%s` DESC", order.column))
+		}
+	}
+	debug := fmt.Sprintf("table:%s where:[%s]", b.tableName, strings.Join(where, " AND "))
+	if len(orders) > 0 {
+		debug += fmt.Sprintf(" order:[%s]", strings.Join(orders, ", "))
+	}
+	if b.limit != nil {
+		debug += fmt.Sprintf(" limit:%d", *b.limit)
+	}
+	if b.offset > 0 {
+		debug += fmt.Sprintf(" offset:%d", b.offset)
+	}
+	if lockOpt := b.lockOpt.String(); lockOpt != "" {
+		debug += fmt.Sprintf(" lock:%s", lockOpt)
+	}
+	if b.isIgnoreCache {
+		debug += " ignore_cache:true"
+	}
+	if b.acceptStale > 0 {
+		debug += fmt.Sprintf(" accept_stale:%s", b.acceptStale)
+	}
+	if b.useIndex != "" {
+		debug += fmt.Sprintf(" use_index:%s", b.useIndex)
+	}
+	if b.err != nil {
+		debug += fmt.Sprintf(" err:%s", b.err)
+	}
+	return debug
+}
+
+var debugValueFactory = NewValueFactory()
+
+func debugQueryArg(arg interface{}) string {
+	switch v := arg.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case []byte:
+		return fmt.Sprintf("%q", v)
+	case time.Time:
+		return fmt.Sprintf("%q", v.Format(time.RFC3339Nano))
+	}
+	return fmt.Sprint(arg)
+}
+
 func (b *QueryBuilder) Query() string {
 	queries := b.conditions.Queries()
 	return strings.Join(queries, " AND ")
@@ -855,6 +1445,13 @@ func (b *QueryBuilder) In(column string, values interface{}) *QueryBuilder {
 	return b
 }
 
+// NotIn adds `column NOT IN (values)` condition. values is slice like In.
+// Empty values matches every row because no value is excluded
+func (b *QueryBuilder) NotIn(column string, values interface{}) *QueryBuilder {
+	b.conditions.Append(&NOTINCondition{column: column, rawValues: values})
+	return b
+}
+
 type SQLCondition struct {
 	stmt      string
 	rawValues []interface{}
@@ -906,6 +1503,45 @@ func (b *QueryBuilder) OrderDesc(column string) *QueryBuilder {
 	return b
 }
 
+// Limit returns at most n values. Values are sliced in memory after they are found,
+// so index cache is still used and database is read only for cache miss
+func (b *QueryBuilder) Limit(n int) *QueryBuilder {
+	b.limit = &n
+	return b
+}
+
+// Offset skips first n values after they are sorted by order conditions
+func (b *QueryBuilder) Offset(n int) *QueryBuilder {
+	b.offset = n
+	return b
+}
+
+// page sorts values by order conditions, then slices them by offset and limit
+func (b *QueryBuilder) page(values *StructSliceValue) *StructSliceValue {
+	if values == nil {
+		return nil
+	}
+	values.Sort(b.orderConditions)
+	if b.limit == nil && b.offset <= 0 {
+		return values
+	}
+	start := b.offset
+	if start < 0 {
+		start = 0
+	}
+	if start > len(values.values) {
+		start = len(values.values)
+	}
+	end := len(values.values)
+	if b.limit != nil && start+*b.limit < end {
+		end = start + *b.limit
+		if end < start {
+			end = start
+		}
+	}
+	return &StructSliceValue{values: values.values[start:end]}
+}
+
 type LockingReadOption struct {
 	isSharedLock    bool // LOCK IN SHARE MODE
 	isExclusiveLock bool // FOR UPDATE
@@ -924,6 +1560,45 @@ func (o *LockingReadOption) String() string {
 	return ""
 }
 
+// WithInfo set info that receives rows returned and how many of them came from cache or database
+func (b *QueryBuilder) WithInfo(info *QueryInfo) *QueryBuilder {
+	b.info = info
+	return b
+}
+
+// Comment writes leading comment to generated SQL so that load can be attributed to call site
+func (b *QueryBuilder) Comment(comment string) *QueryBuilder {
+	if b.annotation == nil {
+		b.annotation = &sqlAnnotation{}
+	}
+	b.annotation.comment = escapeSQLComment(comment)
+	return b
+}
+
+// Hint adds optimizer hint ( e.g. MAX_EXECUTION_TIME(500) ) to generated SQL
+func (b *QueryBuilder) Hint(hint string) *QueryBuilder {
+	if b.annotation == nil {
+		b.annotation = &sqlAnnotation{}
+	}
+	b.annotation.hints = append(b.annotation.hints, escapeSQLComment(hint))
+	return b
+}
+
+// UseIndex forces index used for cache key and SQL instead of the index matching conditions in order.
+// name is columns of registered index joined by colon ( e.g. "user_id:login_param_id" )
+func (b *QueryBuilder) UseIndex(name string) *QueryBuilder {
+	b.useIndex = name
+	return b
+}
+
+// AcceptStale tolerates values stale up to d to reduce latency on contended rows.
+// Keys invalidated by the transaction are read from cache instead of database,
+// and values filled on cache miss aren't locked but expire after d ( at least a second )
+func (b *QueryBuilder) AcceptStale(d time.Duration) *QueryBuilder {
+	b.acceptStale = d
+	return b
+}
+
 func (b *QueryBuilder) LockInShareMode() *QueryBuilder {
 	b.lockOpt = &LockingReadOption{isSharedLock: true}
 	return b
@@ -1293,3 +1968,77 @@ func (c *INCondition) Release() {
 	}
 	c.values = nil
 }
+
+type NOTINCondition struct {
+	column    string
+	rawValues interface{}
+	values    []*Value
+}
+
+func (c *NOTINCondition) Column() string {
+	return c.column
+}
+
+func (c *NOTINCondition) Value() *Value {
+	if len(c.values) == 0 {
+		return nil
+	}
+	return c.values[0]
+}
+
+func (c *NOTINCondition) Query() string {
+	if len(c.values) == 0 {
+		// `NOT IN ()` is syntax error
+		return "1 = 1"
+	}
+	placeholders := make([]string, len(c.values))
+	for i := 0; i < len(c.values); i++ {
+		placeholders[i] = "?"
+	}
+	return fmt.Sprintf("`%s` NOT IN (%s)", c.column, strings.Join(placeholders, ","))
+}
+
+func (c *NOTINCondition) QueryArgs() []interface{} {
+	args := make([]interface{}, len(c.values))
+	for i := 0; i < len(c.values); i++ {
+		args[i] = c.values[i].RawValue()
+	}
+	return args
+}
+
+func (c *NOTINCondition) Compare(value *Value) bool {
+	if len(c.values) == 0 {
+		return true
+	}
+	if value == nil {
+		return false
+	}
+	for _, v := range c.values {
+		if value.EQ(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *NOTINCondition) Search(tree *BTree) []Leaf {
+	log.Warn("not support not in search")
+	return nil
+}
+
+func (c *NOTINCondition) Build(factory *ValueFactory) {
+	if c.values != nil {
+		return
+	}
+	c.values = factory.CreateUniqueValues(c.rawValues)
+}
+
+func (c *NOTINCondition) Release() {
+	if c.values == nil {
+		return
+	}
+	for _, value := range c.values {
+		value.Release()
+	}
+	c.values = nil
+}
@@ -0,0 +1,128 @@
+package rapidash
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// sqlTemplates keeps generated parts of SELECT statement, so only arguments are built for each cache miss.
+// WHERE clause is cached per shape of conditions ( columns, operators and number of placeholders ).
+type sqlTemplates struct {
+	prefix atomic.Value
+	where  sync.Map
+}
+
+type selectPrefix struct {
+	tableName  string
+	fieldCount int
+	sql        string
+}
+
+// selectPrefix returns `SELECT columns FROM table`. fields are only appended to Struct, so number of fields detects change of it
+func (s *Struct) selectPrefix(tableName string) string {
+	if cached, ok := s.templates.prefix.Load().(*selectPrefix); ok &&
+		cached.tableName == tableName && cached.fieldCount == len(s.fields) {
+		return cached.sql
+	}
+	columns := s.Columns()
+	escapedColumns := make([]string, 0, len(columns))
+	for _, column := range columns {
+		escapedColumns = append(escapedColumns, "`"+column+"`")
+	}
+	sql := "SELECT " + strings.Join(escapedColumns, ",") + " FROM `" + tableName + "`"
+	s.templates.prefix.Store(&selectPrefix{tableName: tableName, fieldCount: len(columns), sql: sql})
+	return sql
+}
+
+type conditionShapeKind int
+
+const (
+	conditionShapeEQ conditionShapeKind = iota
+	conditionShapeNull
+	conditionShapeIN
+	conditionShapeTimeBucket
+)
+
+type conditionShape struct {
+	column    string
+	kind      conditionShapeKind
+	count     int
+	existsNil bool
+}
+
+func (c conditionShape) writeKey(b *strings.Builder) {
+	b.WriteString(c.column)
+	b.WriteByte(':')
+	b.WriteString(strconv.Itoa(int(c.kind)))
+	b.WriteByte(':')
+	b.WriteString(strconv.Itoa(c.count))
+	if c.existsNil {
+		b.WriteString(":nil")
+	}
+	b.WriteByte(';')
+}
+
+func (c conditionShape) condition() string {
+	column := "`" + c.column + "`"
+	switch c.kind {
+	case conditionShapeNull:
+		return column + " IS NULL"
+	case conditionShapeIN:
+		return column + " IN (" + strings.TrimSuffix(strings.Repeat("?,", c.count), ",") + ")"
+	case conditionShapeTimeBucket:
+		conditions := make([]string, 0, c.count+1)
+		for i := 0; i < c.count; i++ {
+			conditions = append(conditions, "("+column+" >= ? AND "+column+" < ?)")
+		}
+		if c.existsNil {
+			conditions = append(conditions, column+" IS NULL")
+		}
+		return "(" + strings.Join(conditions, " OR ") + ")"
+	}
+	return column + " = ?"
+}
+
+// whereTemplate returns `WHERE conditions [lock option]` for shapes
+func (s *Struct) whereTemplate(shapes []conditionShape, lockOpt string) string {
+	var key strings.Builder
+	for _, shape := range shapes {
+		shape.writeKey(&key)
+	}
+	key.WriteString(lockOpt)
+	if cached, exists := s.templates.where.Load(key.String()); exists {
+		return cached.(string)
+	}
+	conditions := make([]string, 0, len(shapes))
+	for _, shape := range shapes {
+		conditions = append(conditions, shape.condition())
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+	if lockOpt != "" {
+		where += " " + lockOpt
+	}
+	s.templates.where.Store(key.String(), where)
+	return where
+}
+
+// timeBucketArgs returns begin and end of the buckets of values
+func timeBucketArgs(bucket time.Duration, values []*Value) ([]interface{}, bool) {
+	args := []interface{}{}
+	existsNil := false
+	existsBegin := map[time.Time]struct{}{}
+	for _, v := range values {
+		if v.IsNil {
+			existsNil = true
+			continue
+		}
+		begin := v.timeValue.Truncate(bucket)
+		if _, exists := existsBegin[begin]; exists {
+			continue
+		}
+		existsBegin[begin] = struct{}{}
+		args = append(args, begin, begin.Add(bucket))
+	}
+	return args, existsNil
+}
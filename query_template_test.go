@@ -0,0 +1,69 @@
+package rapidash
+
+import (
+	"testing"
+)
+
+func cacheMissQueries(userIDs []uint64, sessionID uint64) *Queries {
+	queries := NewQueries("user_logins", nil, len(userIDs))
+	for _, userID := range userIDs {
+		query := NewQuery(2)
+		query.columns = append(query.columns, "user_id", "user_session_id")
+		query.value.fields["user_id"] = NewUint64Value(userID)
+		query.value.fields["user_session_id"] = NewUint64Value(sessionID)
+		queries.cacheMissQueries = append(queries.cacheMissQueries, query)
+	}
+	return queries
+}
+
+func TestCacheMissQueriesToSQLTemplate(t *testing.T) {
+	typ := userLoginType()
+	prefix := "SELECT `id`,`user_id`,`user_session_id`,`login_param_id`,`name`,`created_at`,`updated_at` FROM `user_logins`"
+	t.Run("reuse template for the same shape", func(t *testing.T) {
+		query, args := cacheMissQueries([]uint64{1, 2, 3}, 1).CacheMissQueriesToSQL(typ)
+		Equal(t, query, prefix+" WHERE `user_id` IN (?,?,?) AND `user_session_id` = ?")
+		Equal(t, args, []interface{}{uint64(1), uint64(2), uint64(3), uint64(1)})
+		query, args = cacheMissQueries([]uint64{4, 5, 6}, 2).CacheMissQueriesToSQL(typ)
+		Equal(t, query, prefix+" WHERE `user_id` IN (?,?,?) AND `user_session_id` = ?")
+		Equal(t, args, []interface{}{uint64(4), uint64(5), uint64(6), uint64(2)})
+	})
+	t.Run("different shape", func(t *testing.T) {
+		query, _ := cacheMissQueries([]uint64{1}, 1).CacheMissQueriesToSQL(typ)
+		Equal(t, query, prefix+" WHERE `user_id` = ? AND `user_session_id` = ?")
+		queries := cacheMissQueries([]uint64{1, 2}, 1)
+		queries.lockOpt = &LockingReadOption{isSharedLock: true}
+		query, _ = queries.CacheMissQueriesToSQL(typ)
+		Equal(t, query, prefix+" WHERE `user_id` IN (?,?) AND `user_session_id` = ? LOCK IN SHARE MODE")
+	})
+	t.Run("prefix is rebuilt after adding field", func(t *testing.T) {
+		typ := userLoginType()
+		Equal(t, typ.selectPrefix("user_logins"), prefix)
+		typ.FieldString("password")
+		Equal(t, typ.selectPrefix("user_logins"),
+			"SELECT `id`,`user_id`,`user_session_id`,`login_param_id`,`name`,`created_at`,`updated_at`,`password` FROM `user_logins`")
+	})
+}
+
+func BenchmarkCacheMissQueriesToSQL(b *testing.B) {
+	typ := userLoginType()
+	userIDs := make([]uint64, 100)
+	for i := range userIDs {
+		userIDs[i] = uint64(i + 1)
+	}
+	queries := cacheMissQueries(userIDs, 1)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		queries.CacheMissQueriesToSQL(typ)
+	}
+}
+
+func BenchmarkCacheMissQueriesToSQLSingle(b *testing.B) {
+	typ := userLoginType()
+	queries := cacheMissQueries([]uint64{1}, 1)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		queries.CacheMissQueriesToSQL(typ)
+	}
+}
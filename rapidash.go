@@ -3,9 +3,13 @@ package rapidash
 import (
 	"context"
 	"database/sql"
+	"io"
 	"net"
+	"os"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rs/xid"
@@ -33,6 +37,10 @@ type Rapidash struct {
 	secondLevelCaches *SecondLevelCacheMap
 	lastLevelCache    *LastLevelCache
 	opt               Option
+	asyncLogWriter    *AsyncLogWriter
+	samplingLogger    *samplingLogger
+	cacheDisabled     int32
+	activeTxs         sync.Map
 }
 
 type Selectors struct {
@@ -46,6 +54,8 @@ const (
 	CacheServerTypeMemcached CacheServerType = iota
 	CacheServerTypeRedis
 	CacheServerTypeOnMemory
+	// CacheServerTypeCustom uses cache server registered by RegisterCacheServer
+	CacheServerTypeCustom
 
 	// DefaultTimeout is the default socket read/write timeout.
 	DefaultTimeout = 100 * time.Millisecond
@@ -63,12 +73,50 @@ const (
 )
 
 type TableOption struct {
-	shardKey        *string
-	server          *string
-	expiration      *time.Duration
-	lockExpiration  *time.Duration
-	optimisticLock  *bool
-	pessimisticLock *bool
+	shardKey         *string
+	server           *string
+	expiration       *time.Duration
+	lockExpiration   *time.Duration
+	optimisticLock   *bool
+	pessimisticLock  *bool
+	clusterKey       *string
+	expirationColumn *string
+	decodeHook       func(*StructValue) error
+	encodeHook       func(*StructValue) error
+	keyRegistrySize  *int
+	defaultOrders    []*OrderCondition
+	inChunkSize      *int
+	disableStash     *bool
+	sessionVariables map[string]string
+	clock            Clock
+	ttlSource        TTLSource
+	payloadCodecs    *payloadCodecs
+	stats            StatsCollector
+	columnCipher     *columnCipher
+	cacheKeyHash     CacheKeyHashType
+	structMigration  *structMigration
+	excludeIndexes   []string
+	lowSelectivity   *int
+	ignoreIndexes    []string
+	ignoreColumns    []string
+	revalidateAfter  *time.Duration
+	shadowRead       *bool
+	ttlTuning        *TTLTuning
+	pkGenerator      PrimaryKeyGenerator
+	missingPKPolicy  *MissingPrimaryKeyPolicy
+	fillLockPolicy   *FillLockPolicy
+	fillLockExpiry   *time.Duration
+	writeThrough     *bool
+	strictTypes      *bool
+	validateSchema   *bool
+	schemaHashKey    *bool
+	uniqNoPrefix     []string
+	localCache       *LocalCacheSize
+	countCache       *bool
+	pageCache        *bool
+	compactPKs       *bool
+	codec            Codec
+	compression      *Compression
 }
 
 func (o *TableOption) ShardKey() string {
@@ -113,21 +161,226 @@ func (o *TableOption) PessimisticLock() bool {
 	return *o.pessimisticLock
 }
 
+func (o *TableOption) ClusterKey() string {
+	if o.clusterKey == nil {
+		return ""
+	}
+	return *o.clusterKey
+}
+
+func (o *TableOption) ExpirationColumn() string {
+	if o.expirationColumn == nil {
+		return ""
+	}
+	return *o.expirationColumn
+}
+
+func (o *TableOption) DecodeHook() func(*StructValue) error {
+	return o.decodeHook
+}
+
+func (o *TableOption) EncodeHook() func(*StructValue) error {
+	return o.encodeHook
+}
+
+func (o *TableOption) KeyRegistrySize() int {
+	if o.keyRegistrySize == nil {
+		return 0
+	}
+	return *o.keyRegistrySize
+}
+
+// DefaultOrders returns order conditions applied to multi-row results regardless of data source
+func (o *TableOption) DefaultOrders() []*OrderCondition {
+	return o.defaultOrders
+}
+
+// INChunkSize returns max number of IN values executed at once. 0 means no chunking
+func (o *TableOption) INChunkSize() int {
+	if o.inChunkSize == nil {
+		return 0
+	}
+	return *o.inChunkSize
+}
+
+// DisableStash returns whether values read from cache server are kept out of transaction stash
+func (o *TableOption) DisableStash() bool {
+	if o.disableStash == nil {
+		return false
+	}
+	return *o.disableStash
+}
+
+func (o *TableOption) now() time.Time {
+	return clockOrDefault(o.clock).Now()
+}
+
+// SessionVariables returns variables applied to SELECT statements executed for cache miss
+func (o *TableOption) SessionVariables() map[string]string {
+	return o.sessionVariables
+}
+
+// ExcludeIndexes returns indexes ( columns joined by colon ) which aren't used for cache
+func (o *TableOption) ExcludeIndexes() []string {
+	return o.excludeIndexes
+}
+
+// IgnoreIndexes returns names of indexes which aren't registered at WarmUp
+func (o *TableOption) IgnoreIndexes() []string {
+	return o.ignoreIndexes
+}
+
+// UniquePrefixDisabledIndexes returns names of composite unique indexes whose prefix columns aren't registered as keys
+func (o *TableOption) UniquePrefixDisabledIndexes() []string {
+	return o.uniqNoPrefix
+}
+
+func (o *TableOption) LocalCache() *LocalCacheSize {
+	return o.localCache
+}
+
+// IgnoreIndexColumns returns columns whose indexes aren't registered at WarmUp
+func (o *TableOption) IgnoreIndexColumns() []string {
+	return o.ignoreColumns
+}
+
+// LowSelectivityThreshold returns average number of records per index key regarded as low selectivity
+func (o *TableOption) LowSelectivityThreshold() int {
+	if o.lowSelectivity == nil {
+		return defaultLowSelectivityThreshold
+	}
+	return *o.lowSelectivity
+}
+
+// RevalidateAfter returns age of cached value to revalidate it against database in background. 0 means never
+func (o *TableOption) RevalidateAfter() time.Duration {
+	if o.revalidateAfter == nil {
+		return 0
+	}
+	return *o.revalidateAfter
+}
+
+// ShadowRead returns whether values are always read from database while cache is looked up in background to compare
+func (o *TableOption) ShadowRead() bool {
+	if o.shadowRead == nil {
+		return false
+	}
+	return *o.shadowRead
+}
+
+func (o *TableOption) TTLTuning() *TTLTuning {
+	return o.ttlTuning
+}
+
+func (o *TableOption) PrimaryKeyGenerator() PrimaryKeyGenerator {
+	return o.pkGenerator
+}
+
+func (o *TableOption) MissingPrimaryKeyPolicy() MissingPrimaryKeyPolicy {
+	if o.missingPKPolicy == nil {
+		return MissingPrimaryKeyPolicyRefetchIndex
+	}
+	return *o.missingPKPolicy
+}
+
+func (o *TableOption) FillLockPolicy() FillLockPolicy {
+	if o.fillLockPolicy == nil {
+		return FillLockPolicyLock
+	}
+	return *o.fillLockPolicy
+}
+
+func (o *TableOption) FillLockExpiration() time.Duration {
+	if o.fillLockExpiry == nil {
+		return defaultFillLockExpiration
+	}
+	return *o.fillLockExpiry
+}
+
+func (o *TableOption) WriteThroughOnCreate() bool {
+	if o.writeThrough == nil {
+		return false
+	}
+	return *o.writeThrough
+}
+
+// CountCache returns whether number of records per index key is cached for CountByQueryBuilder
+func (o *TableOption) CountCache() bool {
+	if o.countCache == nil {
+		return false
+	}
+	return *o.countCache
+}
+
+// CompactPrimaryKeys returns whether many integer primary keys of index key are encoded by delta varint
+func (o *TableOption) CompactPrimaryKeys() bool {
+	if o.compactPKs == nil {
+		return false
+	}
+	return *o.compactPKs
+}
+
+// PageCache returns whether pages of primary keys found by FindByQueryBuilderWithCursor are cached
+func (o *TableOption) PageCache() bool {
+	if o.pageCache == nil {
+		return false
+	}
+	return *o.pageCache
+}
+
+// Codec returns codec of cached values. nil means MsgpackCodec
+func (o *TableOption) Codec() Codec {
+	return o.codec
+}
+
+// Compression returns compression of cached values. nil means values aren't compressed
+func (o *TableOption) Compression() *Compression {
+	return o.compression
+}
+
+func (o *TableOption) StrictTypes() bool {
+	if o.strictTypes == nil {
+		return false
+	}
+	return *o.strictTypes
+}
+
+func (o *TableOption) ValidateSchema() bool {
+	if o.validateSchema == nil {
+		return false
+	}
+	return *o.validateSchema
+}
+
+func (o *TableOption) SchemaHashKey() bool {
+	if o.schemaHashKey == nil {
+		return false
+	}
+	return *o.schemaHashKey
+}
+
 type LastLevelCacheOption struct {
-	lockExpiration  time.Duration
-	expiration      time.Duration
-	optimisticLock  bool
-	pessimisticLock bool
-	tagOpt          map[string]TagOption
+	lockExpiration          time.Duration
+	expiration              time.Duration
+	optimisticLock          bool
+	pessimisticLock         bool
+	negativeCacheExpiration time.Duration
+	negativeCacheMaxEntries int
+	tagOpt                  map[string]TagOption
+	clock                   Clock
+	payloadCodecs           *payloadCodecs
+	compression             *Compression
+	cacheKeyHash            CacheKeyHashType
 }
 
 type TagOption struct {
-	server          string
-	expiration      time.Duration
-	lockExpiration  time.Duration
-	ignoreStash     bool
-	optimisticLock  *bool
-	pessimisticLock *bool
+	server                  string
+	expiration              time.Duration
+	lockExpiration          time.Duration
+	ignoreStash             bool
+	optimisticLock          *bool
+	pessimisticLock         *bool
+	negativeCacheExpiration *time.Duration
 }
 
 type QueryLog struct {
@@ -140,31 +393,63 @@ type QueryLog struct {
 
 type Option struct {
 	serverType                 CacheServerType
+	serverName                 string
 	serverAddrs                []string
+	redisClusterAddrs          []string
 	timeout                    time.Duration
 	maxIdleConnections         int
+	serverRetryPolicy          *server.RetryPolicy
+	serverCredentialsProvider  server.CredentialsProvider
+	serverGetMultiBatch        *server.GetMultiBatchPolicy
+	redisCompareAndSwap        bool
 	maxRetryCount              int
 	retryInterval              time.Duration
+	commitPipelineEnabled      bool
+	rateLimitWindowType        RateLimitWindowType
+	clock                      Clock
+	ttlSource                  TTLSource
+	invalidationOutbox         *invalidationOutboxOption
+	commitOrder                CommitOrderType
+	payloadCodecs              *payloadCodecs
+	doubleDeleteDelay          time.Duration
+	maxStashSize               int
+	stashOverflowPolicy        StashOverflowPolicyType
 	logMode                    LogModeType
 	logEnabled                 bool
 	logServerAddr              string
+	txReportEnabled            bool
+	logBufferSize              int
+	logSamplingRates           map[SLCCommandType]float64
 	slcServerAddrs             []string
 	slcLockExpiration          time.Duration
 	slcExpiration              time.Duration
 	slcOptimisticLock          bool
 	slcPessimisticLock         bool
 	slcIgnoreNewerCache        bool
+	slcINChunkSize             int
+	slcSessionVariables        map[string]string
 	slcTableOpt                map[string]TableOption
 	llcOpt                     *LastLevelCacheOption
 	llcServerAddrs             []string
 	beforeCommitCallback       func(*Tx, []*QueryLog) error
 	afterCommitSuccessCallback func(*Tx) error
 	afterCommitFailureCallback func(*Tx, []*QueryLog) error
+	auditSink                  AuditSink
+	stats                      StatsCollector
+	tableNameResolver          TableNameResolver
+	lockOwner                  string
+	columnEncryptionKey        []byte
+	columnCipher               *columnCipher
+	cacheKeyHash               CacheKeyHashType
 }
 
 func defaultOption() Option {
+	payloadCodecs := newPayloadCodecs()
 	return Option{
 		serverType:          CacheServerTypeMemcached,
+		payloadCodecs:       payloadCodecs,
+		clock:               systemClock{},
+		doubleDeleteDelay:   defaultDoubleDeleteDelay,
 		timeout:             DefaultTimeout,
 		maxIdleConnections:  DefaultMaxIdleConns,
 		maxRetryCount:       3,
@@ -178,9 +463,11 @@ func defaultOption() Option {
 		slcIgnoreNewerCache: true,
 		slcTableOpt:         map[string]TableOption{},
 		llcOpt: &LastLevelCacheOption{
-			tagOpt:          map[string]TagOption{},
-			optimisticLock:  true,
-			pessimisticLock: true,
+			tagOpt:                  map[string]TagOption{},
+			optimisticLock:          true,
+			pessimisticLock:         true,
+			negativeCacheMaxEntries: defaultNegativeCacheMaxEntries,
+			payloadCodecs:           payloadCodecs,
 		},
 	}
 }
@@ -198,7 +485,8 @@ type TxConnection interface {
 
 type PendingQuery struct {
 	*QueryLog
-	fn func() error
+	key server.CacheKey
+	fn  func(context.Context) error
 }
 
 type Tx struct {
@@ -213,6 +501,41 @@ type Tx struct {
 	beforeCommitCallback       func([]*QueryLog) error
 	afterCommitSuccessCallback func() error
 	afterCommitFailureCallback func([]*QueryLog) error
+	report                     *txReport
+	opt                        TxOption
+	guard                      txGuard
+	invalidationOutboxID       int64
+	aborted                    bool
+	auditEvents                []*AuditEvent
+	snapshot                   bool
+	startedAt                  time.Time
+	lockRefresher              *lockRefresher
+	// staleFill is expiration of values filled by query accepting stale values. they aren't locked while it is set
+	staleFill time.Duration
+	// boundConns are connections bound by BindConnection for tables of other databases
+	boundConns []*boundConnection
+	// debugStats is published at the end of each operation, so Debug can read it from other goroutine
+	debugStats txDebugStats
+}
+
+// IsolationAdaptation controls whether values stashed in transaction are reused by subsequent reads
+type IsolationAdaptation int
+
+const (
+	// IsolationAdaptationRepeatableRead reuses stashed values in the same transaction ( default )
+	IsolationAdaptationRepeatableRead IsolationAdaptation = iota
+	// IsolationAdaptationReadCommitted re-validates stashed values against cache server ( or database )
+	// except values modified by the transaction itself
+	IsolationAdaptationReadCommitted
+)
+
+type TxOption struct {
+	IsolationAdaptation IsolationAdaptation
+	// ReadOnly makes write operations return ErrReadOnlyTransaction
+	ReadOnly bool
+	// MaxDuration makes operations after it elapsed return ErrTxExpired.
+	// Lock keys are refreshed automatically until then
+	MaxDuration time.Duration
 }
 
 type Stash struct {
@@ -220,8 +543,12 @@ type Stash struct {
 	uniqueKeyToPrimaryKey    map[string]server.CacheKey
 	keyToPrimaryKeys         map[string][]server.CacheKey
 	primaryKeyToValue        map[string]*StructValue
+	valueSizes               map[string]int
 	lastLevelCacheKeyToBytes map[string][]byte
 	casIDs                   map[string]uint64
+	countDeltas              map[string]int64
+	size                     int
+	evicted                  int
 }
 
 func NewStash() *Stash {
@@ -230,12 +557,54 @@ func NewStash() *Stash {
 		uniqueKeyToPrimaryKey:    map[string]server.CacheKey{},
 		keyToPrimaryKeys:         map[string][]server.CacheKey{},
 		primaryKeyToValue:        map[string]*StructValue{},
+		valueSizes:               map[string]int{},
 		lastLevelCacheKeyToBytes: map[string][]byte{},
 		casIDs:                   map[string]uint64{},
+		countDeltas:              map[string]int64{},
 	}
 }
 
 func (r *Rapidash) Begin(conns ...Connection) (*Tx, error) {
+	tx, err := r.BeginWithOption(TxOption{}, conns...)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to begin transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// ReadSnapshot runs fn with read only Tx over REPEATABLE READ transaction of database.
+// Tables of SecondLevelCache are read from the database snapshot instead of cache server,
+// so fn sees consistent point-in-time view across tables
+func (r *Rapidash) ReadSnapshot(ctx context.Context, conn *sql.DB, fn func(*Tx) error) (e error) {
+	dbTx, err := conn.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return xerrors.Errorf("failed to begin database transaction: %w", err)
+	}
+	tx, err := r.BeginWithOption(TxOption{ReadOnly: true}, dbTx)
+	if err != nil {
+		if rerr := dbTx.Rollback(); rerr != nil {
+			return xerrors.Errorf("failed to rollback database transaction ( %s ): %w", err.Error(), rerr)
+		}
+		return xerrors.Errorf("failed to begin transaction: %w", err)
+	}
+	tx.snapshot = true
+	defer func() {
+		if err := tx.Rollback(); err != nil && e == nil {
+			e = xerrors.Errorf("failed to finish snapshot: %w", err)
+		}
+	}()
+	if err := fn(tx); err != nil {
+		return xerrors.Errorf("failed to read snapshot: %w", err)
+	}
+	return nil
+}
+
+func (r *Rapidash) BeginWithOption(opt TxOption, conns ...Connection) (*Tx, error) {
+	return r.BeginWithOptionContext(context.Background(), opt, conns...)
+}
+
+// BeginWithOptionContext begins transaction. ctx is used by refreshing lock keys until TxOption.MaxDuration elapses
+func (r *Rapidash) BeginWithOptionContext(ctx context.Context, opt TxOption, conns ...Connection) (*Tx, error) {
 	if len(conns) > 1 {
 		return nil, ErrBeginTransaction
 	}
@@ -243,20 +612,132 @@ func (r *Rapidash) Begin(conns ...Connection) (*Tx, error) {
 	if len(conns) == 1 {
 		conn = conns[0]
 	}
-	return &Tx{
+	tx := &Tx{
 		r:              r,
 		conn:           conn,
 		stash:          NewStash(),
 		id:             xid.New().String(),
 		pendingQueries: map[string]*PendingQuery{},
 		lockKeys:       []server.CacheKey{},
-	}, nil
+		opt:            opt,
+		startedAt:      clockOrDefault(r.opt.clock).Now(),
+	}
+	if opt.MaxDuration > 0 {
+		tx.startLockRefresh(ctx)
+	}
+	if r.opt.txReportEnabled {
+		tx.report = newTxReport(r.opt.clock)
+	}
+	r.activeTxs.Store(tx.id, tx)
+	return tx, nil
 }
 
 func (tx *Tx) ID() string {
 	return tx.id
 }
 
+// Report returns operations performed in transaction in order.
+// It returns nil if TxReportEnabled option isn't enabled.
+func (tx *Tx) Report() []*TxOperation {
+	if tx.report == nil {
+		return nil
+	}
+	return tx.report.list()
+}
+
+// isStashAvailable returns whether stashed value for key can be reused without re-validation
+func (tx *Tx) isStashAvailable(key string) bool {
+	if tx.opt.IsolationAdaptation != IsolationAdaptationReadCommitted {
+		return true
+	}
+	return tx.isModifiedKey(key)
+}
+
+// isModifiedKey returns whether value for key is modified by transaction itself
+func (tx *Tx) isModifiedKey(key string) bool {
+	query, exists := tx.pendingQueries[key]
+	if !exists {
+		return false
+	}
+	// set command is registered by reading value ( cache miss ), so it isn't modified by transaction
+	return query.Command != string(SLCCommandSet)
+}
+
+// txGuard detects use of Tx from multiple goroutines.
+// Tx is only reentered while it runs callback ( e.g. BeforeCommitCallback ) synchronously in the goroutine that is using it
+type txGuard struct {
+	mu       sync.Mutex
+	depth    int
+	callback int
+}
+
+func (g *txGuard) acquire() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.depth > 0 && g.callback == 0 {
+		return false
+	}
+	g.depth++
+	return true
+}
+
+// release calls last before the outermost use finishes
+func (g *txGuard) release(last func()) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.depth == 1 {
+		last()
+	}
+	g.depth--
+}
+
+// runCallback allows callback to use Tx while it runs
+func (g *txGuard) runCallback(callback func() error) error {
+	g.mu.Lock()
+	g.callback++
+	g.mu.Unlock()
+	defer func() {
+		g.mu.Lock()
+		g.callback--
+		g.mu.Unlock()
+	}()
+	return callback()
+}
+
+func (g *txGuard) inUse() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.depth > 0
+}
+
+// enter marks tx as in use. Tx isn't goroutine safe, so overlapping calls from other goroutines returns ErrConcurrentTxUse
+func (tx *Tx) enter() error {
+	if err := tx.use(); err != nil {
+		return err
+	}
+	if tx.isExpired() {
+		tx.leave()
+		return ErrTxExpired
+	}
+	return nil
+}
+
+func (tx *Tx) use() error {
+	if !tx.guard.acquire() {
+		return ErrConcurrentTxUse
+	}
+	return nil
+}
+
+func (tx *Tx) leave() {
+	tx.guard.release(tx.publishDebugStats)
+}
+
+// finish removes tx from active transactions
+func (tx *Tx) finish() {
+	tx.r.activeTxs.Delete(tx.id)
+}
+
 func (tx *Tx) BeforeCommitCallback(callback func([]*QueryLog) error) {
 	tx.beforeCommitCallback = callback
 }
@@ -268,6 +749,14 @@ func (tx *Tx) AfterCommitCallback(
 	tx.afterCommitFailureCallback = failureCallback
 }
 
+// CreateContext creates value. ctx is used by operations to cache server
+func (tx *Tx) CreateContext(ctx context.Context, key string, value Type) error {
+	if err := tx.CreateWithTagAndExpirationContext(ctx, "", key, value, 0); err != nil {
+		return xerrors.Errorf("failed to CreateWithTagAndExpirationContext: %w", err)
+	}
+	return nil
+}
+
 func (tx *Tx) Create(key string, value Type) error {
 	if err := tx.CreateWithExpiration(key, value, 0); err != nil {
 		return xerrors.Errorf("failed to CreateWithExpiration: %w", err)
@@ -290,15 +779,40 @@ func (tx *Tx) CreateWithExpiration(key string, value Type, expiration time.Durat
 }
 
 func (tx *Tx) CreateWithTagAndExpiration(tag, key string, value Type, expiration time.Duration) error {
+	if err := tx.CreateWithTagAndExpirationContext(context.Background(), tag, key, value, expiration); err != nil {
+		return xerrors.Errorf("failed to CreateWithTagAndExpirationContext: %w", err)
+	}
+	return nil
+}
+
+func (tx *Tx) CreateWithTagAndExpirationContext(ctx context.Context, tag, key string, value Type, expiration time.Duration) error {
 	if tx.IsCommitted() {
 		return ErrAlreadyCommittedTransaction
 	}
-	if err := tx.r.lastLevelCache.Create(tx, tag, key, value, expiration); err != nil {
+	if err := tx.enter(); err != nil {
+		return err
+	}
+	defer tx.leave()
+	if tx.opt.ReadOnly {
+		return ErrReadOnlyTransaction
+	}
+	if !tx.r.IsCacheEnabled() {
+		return nil
+	}
+	if err := tx.r.lastLevelCache.Create(ctx, tx, tag, key, value, expiration); err != nil {
 		return xerrors.Errorf("failed to Create: %w", err)
 	}
 	return nil
 }
 
+// FindContext finds value. ctx is used by operations to cache server
+func (tx *Tx) FindContext(ctx context.Context, key string, value Type) error {
+	if err := tx.FindWithTagContext(ctx, "", key, value); err != nil {
+		return xerrors.Errorf("failed to FindWithTagContext: %w", err)
+	}
+	return nil
+}
+
 func (tx *Tx) Find(key string, value Type) error {
 	if err := tx.FindWithTag("", key, value); err != nil {
 		return xerrors.Errorf("failed to FindWithTag: %w", err)
@@ -307,15 +821,125 @@ func (tx *Tx) Find(key string, value Type) error {
 }
 
 func (tx *Tx) FindWithTag(tag, key string, value Type) error {
+	if err := tx.FindWithTagContext(context.Background(), tag, key, value); err != nil {
+		return xerrors.Errorf("failed to FindWithTagContext: %w", err)
+	}
+	return nil
+}
+
+func (tx *Tx) FindWithTagContext(ctx context.Context, tag, key string, value Type) error {
 	if tx.IsCommitted() {
 		return ErrAlreadyCommittedTransaction
 	}
-	if err := tx.r.lastLevelCache.Find(tx, tag, key, value); err != nil {
+	if err := tx.enter(); err != nil {
+		return err
+	}
+	defer tx.leave()
+	if !tx.r.IsCacheEnabled() {
+		return xerrors.Errorf("cache is disabled: %w", ErrNotFound)
+	}
+	if err := tx.r.lastLevelCache.Find(ctx, tx, tag, key, value); err != nil {
 		return xerrors.Errorf("failed to Find: %w", err)
 	}
 	return nil
 }
 
+// FindWithVersionContext finds value from cache server and returns the version for UpdateWithVersionContext
+func (tx *Tx) FindWithVersionContext(ctx context.Context, key string, value Type) (uint64, error) {
+	version, err := tx.FindWithTagAndVersionContext(ctx, "", key, value)
+	if err != nil {
+		return 0, xerrors.Errorf("failed to FindWithTagAndVersionContext: %w", err)
+	}
+	return version, nil
+}
+
+// FindWithVersion finds value from cache server and returns the version for UpdateWithVersion
+func (tx *Tx) FindWithVersion(key string, value Type) (uint64, error) {
+	version, err := tx.FindWithTagAndVersion("", key, value)
+	if err != nil {
+		return 0, xerrors.Errorf("failed to FindWithTagAndVersion: %w", err)
+	}
+	return version, nil
+}
+
+func (tx *Tx) FindWithTagAndVersion(tag, key string, value Type) (uint64, error) {
+	version, err := tx.FindWithTagAndVersionContext(context.Background(), tag, key, value)
+	if err != nil {
+		return 0, xerrors.Errorf("failed to FindWithTagAndVersionContext: %w", err)
+	}
+	return version, nil
+}
+
+func (tx *Tx) FindWithTagAndVersionContext(ctx context.Context, tag, key string, value Type) (uint64, error) {
+	if tx.IsCommitted() {
+		return 0, ErrAlreadyCommittedTransaction
+	}
+	if err := tx.enter(); err != nil {
+		return 0, err
+	}
+	defer tx.leave()
+	if !tx.r.IsCacheEnabled() {
+		return 0, xerrors.Errorf("cache is disabled: %w", ErrNotFound)
+	}
+	version, err := tx.r.lastLevelCache.FindWithVersion(ctx, tx, tag, key, value)
+	if err != nil {
+		return 0, xerrors.Errorf("failed to FindWithVersion: %w", err)
+	}
+	return version, nil
+}
+
+// UpdateWithVersionContext updates value only if it isn't modified since FindWithVersionContext
+func (tx *Tx) UpdateWithVersionContext(ctx context.Context, key string, value Type, version uint64) error {
+	if err := tx.UpdateWithTagAndVersionContext(ctx, "", key, value, version, 0); err != nil {
+		return xerrors.Errorf("failed to UpdateWithTagAndVersionContext: %w", err)
+	}
+	return nil
+}
+
+// UpdateWithVersion updates value only if it isn't modified since FindWithVersion.
+// Unlike Update, the value is written immediately and ErrVersionConflict is returned on conflict.
+func (tx *Tx) UpdateWithVersion(key string, value Type, version uint64) error {
+	if err := tx.UpdateWithTagAndVersion("", key, value, version, 0); err != nil {
+		return xerrors.Errorf("failed to UpdateWithTagAndVersion: %w", err)
+	}
+	return nil
+}
+
+func (tx *Tx) UpdateWithTagAndVersion(tag, key string, value Type, version uint64, expiration time.Duration) error {
+	if err := tx.UpdateWithTagAndVersionContext(context.Background(), tag, key, value, version, expiration); err != nil {
+		return xerrors.Errorf("failed to UpdateWithTagAndVersionContext: %w", err)
+	}
+	return nil
+}
+
+func (tx *Tx) UpdateWithTagAndVersionContext(ctx context.Context, tag, key string, value Type, version uint64, expiration time.Duration) error {
+	if tx.IsCommitted() {
+		return ErrAlreadyCommittedTransaction
+	}
+	if err := tx.enter(); err != nil {
+		return err
+	}
+	defer tx.leave()
+	if tx.opt.ReadOnly {
+		return ErrReadOnlyTransaction
+	}
+	if !tx.r.IsCacheEnabled() {
+		return nil
+	}
+	if err := tx.r.lastLevelCache.UpdateWithVersion(ctx, tx, tag, key, value, version, expiration); err != nil {
+		return xerrors.Errorf("failed to UpdateWithVersion: %w", err)
+	}
+	return nil
+}
+
+// UpdateContext updates value. ctx is used by operations to cache server
+func (tx *Tx) UpdateContext(ctx context.Context, key string, value Type) error {
+	if err := tx.UpdateWithTagAndExpirationContext(ctx, "", key, value, 0); err != nil {
+		return xerrors.Errorf("failed to UpdateWithTagAndExpirationContext: %w", err)
+	}
+	return nil
+}
+
 func (tx *Tx) Update(key string, value Type) error {
 	if err := tx.UpdateWithExpiration(key, value, 0); err != nil {
 		return xerrors.Errorf("failed to UpdateWithExpiration: %w", err)
@@ -338,15 +962,40 @@ func (tx *Tx) UpdateWithExpiration(key string, value Type, expiration time.Durat
 }
 
 func (tx *Tx) UpdateWithTagAndExpiration(tag, key string, value Type, expiration time.Duration) error {
+	if err := tx.UpdateWithTagAndExpirationContext(context.Background(), tag, key, value, expiration); err != nil {
+		return xerrors.Errorf("failed to UpdateWithTagAndExpirationContext: %w", err)
+	}
+	return nil
+}
+
+func (tx *Tx) UpdateWithTagAndExpirationContext(ctx context.Context, tag, key string, value Type, expiration time.Duration) error {
 	if tx.IsCommitted() {
 		return ErrAlreadyCommittedTransaction
 	}
-	if err := tx.r.lastLevelCache.Update(tx, tag, key, value, expiration); err != nil {
+	if err := tx.enter(); err != nil {
+		return err
+	}
+	defer tx.leave()
+	if tx.opt.ReadOnly {
+		return ErrReadOnlyTransaction
+	}
+	if !tx.r.IsCacheEnabled() {
+		return nil
+	}
+	if err := tx.r.lastLevelCache.Update(ctx, tx, tag, key, value, expiration); err != nil {
 		return xerrors.Errorf("failed to Update: %w", err)
 	}
 	return nil
 }
 
+// DeleteContext deletes value. ctx is used by operations to cache server
+func (tx *Tx) DeleteContext(ctx context.Context, key string) error {
+	if err := tx.DeleteWithTagContext(ctx, "", key); err != nil {
+		return xerrors.Errorf("failed to DeleteWithTagContext: %w", err)
+	}
+	return nil
+}
+
 func (tx *Tx) Delete(key string) error {
 	if err := tx.DeleteWithTag("", key); err != nil {
 		return xerrors.Errorf("failed to DeleteWithTag: %w", err)
@@ -355,21 +1004,49 @@ func (tx *Tx) Delete(key string) error {
 }
 
 func (tx *Tx) DeleteWithTag(tag, key string) error {
+	if err := tx.DeleteWithTagContext(context.Background(), tag, key); err != nil {
+		return xerrors.Errorf("failed to DeleteWithTagContext: %w", err)
+	}
+	return nil
+}
+
+func (tx *Tx) DeleteWithTagContext(ctx context.Context, tag, key string) error {
 	if tx.IsCommitted() {
 		return ErrAlreadyCommittedTransaction
 	}
-	if err := tx.r.lastLevelCache.Delete(tx, tag, key); err != nil {
+	if err := tx.enter(); err != nil {
+		return err
+	}
+	defer tx.leave()
+	if tx.opt.ReadOnly {
+		return ErrReadOnlyTransaction
+	}
+	if !tx.r.IsCacheEnabled() {
+		return nil
+	}
+	if err := tx.r.lastLevelCache.Delete(ctx, tx, tag, key); err != nil {
 		return xerrors.Errorf("failed to Delete: %w", err)
 	}
 	return nil
 }
 
 func (tx *Tx) enabledIgnoreCacheIfExistsTable(builder *QueryBuilder) {
-	if _, exists := tx.r.ignoreCaches[builder.tableName]; exists {
+	if tx.isIgnoreCacheTable(builder.tableName) {
 		builder.isIgnoreCache = true
 	}
 }
 
+func (tx *Tx) isIgnoreCacheTable(tableName string) bool {
+	if !tx.r.IsCacheEnabled() || tx.snapshot {
+		return true
+	}
+	if _, exists := tx.r.ignoreCaches[tableName]; exists {
+		return true
+	}
+	_, exists := tx.r.ignoreCaches[tx.r.resolveTableName(tableName)]
+	return exists
+}
+
 func (tx *Tx) CreateByTable(tableName string, marshaler Marshaler) (int64, error) {
 	id, err := tx.CreateByTableContext(context.Background(), tableName, marshaler)
 	if err != nil {
@@ -378,17 +1055,54 @@ func (tx *Tx) CreateByTable(tableName string, marshaler Marshaler) (int64, error
 	return id, nil
 }
 
-func (tx *Tx) CreateByTableContext(ctx context.Context, tableName string, marshaler Marshaler) (id int64, e error) {
+func (tx *Tx) CreateByTableContext(ctx context.Context, tableName string, marshaler Marshaler) (int64, error) {
+	id, err := tx.createByTable(ctx, tableName, marshaler)
+	if err != nil {
+		return id, err
+	}
+	if id < 0 {
+		return id, xerrors.Errorf("last_insert_id() of %s is %d. use CreateByTableUint64 instead: %w", tableName, uint64(id), ErrInt64Overflow)
+	}
+	return id, nil
+}
+
+// CreateByTableUint64 is same as CreateByTable, but returns id of unsigned BIGINT column beyond math.MaxInt64 correctly
+func (tx *Tx) CreateByTableUint64(tableName string, marshaler Marshaler) (uint64, error) {
+	id, err := tx.CreateByTableUint64Context(context.Background(), tableName, marshaler)
+	if err != nil {
+		return id, xerrors.Errorf("failed to CreateByTableUint64Context: %w", err)
+	}
+	return id, nil
+}
+
+func (tx *Tx) CreateByTableUint64Context(ctx context.Context, tableName string, marshaler Marshaler) (uint64, error) {
+	id, err := tx.createByTable(ctx, tableName, marshaler)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
+
+func (tx *Tx) createByTable(ctx context.Context, tableName string, marshaler Marshaler) (id int64, e error) {
 	if tx.IsCommitted() {
 		e = ErrAlreadyCommittedTransaction
 		return
 	}
+	if err := tx.enter(); err != nil {
+		e = err
+		return
+	}
+	defer tx.leave()
+	if tx.opt.ReadOnly {
+		e = ErrReadOnlyTransaction
+		return
+	}
 	if _, exists := tx.r.firstLevelCaches.get(tableName); exists {
 		e = xerrors.Errorf("%s is read only table. it doesn't support write query", tableName)
 		return
 	}
-	if c, exists := tx.r.secondLevelCaches.get(tableName); exists {
-		if _, exists := tx.r.ignoreCaches[tableName]; exists {
+	if c, exists := tx.r.secondLevelCache(tableName); exists {
+		if tx.isIgnoreCacheTable(tableName) {
 			lastInsertID, err := c.CreateWithoutCache(ctx, tx, marshaler)
 			if err != nil {
 				e = xerrors.Errorf("failed to CreateWithoutCache: %w", err)
@@ -404,7 +1118,7 @@ func (tx *Tx) CreateByTableContext(ctx context.Context, tableName string, marsha
 		id = lastInsertID
 		return
 	}
-	e = xerrors.Errorf("unknown table name %s", tableName)
+	e = xerrors.Errorf("unknown table name %s: %w", tableName, ErrTableNotWarmedUp)
 	return
 }
 
@@ -419,6 +1133,10 @@ func (tx *Tx) FindByQueryBuilderContext(ctx context.Context, builder *QueryBuild
 	if tx.IsCommitted() {
 		return ErrAlreadyCommittedTransaction
 	}
+	if err := tx.enter(); err != nil {
+		return err
+	}
+	defer tx.leave()
 	tx.enabledIgnoreCacheIfExistsTable(builder)
 	if c, exists := tx.r.firstLevelCaches.get(builder.tableName); exists {
 		if err := c.FindByQueryBuilder(builder, unmarshaler); err != nil {
@@ -426,8 +1144,8 @@ func (tx *Tx) FindByQueryBuilderContext(ctx context.Context, builder *QueryBuild
 		}
 		return nil
 	}
-	if c, exists := tx.r.secondLevelCaches.get(builder.tableName); exists {
-		if tx.conn == nil {
+	if c, exists := tx.r.secondLevelCache(builder.tableName); exists {
+		if tx.connection(c) == nil && !c.cacheOnly {
 			return ErrConnectionOfTransaction
 		}
 		if err := c.FindByQueryBuilder(ctx, tx, builder, unmarshaler); err != nil {
@@ -435,7 +1153,46 @@ func (tx *Tx) FindByQueryBuilderContext(ctx context.Context, builder *QueryBuild
 		}
 		return nil
 	}
-	return xerrors.Errorf("unknown table name %s", builder.tableName)
+	return xerrors.Errorf("unknown table name %s: %w", builder.tableName, ErrTableNotWarmedUp)
+}
+
+// FindByQueryBuilderWithCursor finds values of page at cursor and returns cursor of the next page.
+// empty cursor means the first page, and empty cursor is returned for the last page
+func (tx *Tx) FindByQueryBuilderWithCursor(builder *QueryBuilder, cursor string, pageSize int, unmarshaler Unmarshaler) (string, error) {
+	next, err := tx.FindByQueryBuilderWithCursorContext(context.Background(), builder, cursor, pageSize, unmarshaler)
+	if err != nil {
+		return "", xerrors.Errorf("failed to FindByQueryBuilderWithCursorContext: %w", err)
+	}
+	return next, nil
+}
+
+func (tx *Tx) FindByQueryBuilderWithCursorContext(ctx context.Context, builder *QueryBuilder, cursor string, pageSize int, unmarshaler Unmarshaler) (string, error) {
+	if tx.IsCommitted() {
+		return "", ErrAlreadyCommittedTransaction
+	}
+	if err := tx.enter(); err != nil {
+		return "", err
+	}
+	defer tx.leave()
+	tx.enabledIgnoreCacheIfExistsTable(builder)
+	if c, exists := tx.r.firstLevelCaches.get(builder.tableName); exists {
+		next, err := c.FindByQueryBuilderWithCursor(builder, cursor, pageSize, unmarshaler)
+		if err != nil {
+			return "", xerrors.Errorf("failed to FindByQueryBuilderWithCursor of FirstLevelCache: %w", err)
+		}
+		return next, nil
+	}
+	if c, exists := tx.r.secondLevelCache(builder.tableName); exists {
+		if tx.connection(c) == nil && !c.cacheOnly {
+			return "", ErrConnectionOfTransaction
+		}
+		next, err := c.FindByQueryBuilderWithCursor(ctx, tx, builder, cursor, pageSize, unmarshaler)
+		if err != nil {
+			return "", xerrors.Errorf("failed to FindByQueryBuilderWithCursor of SecondLevelCache: %w", err)
+		}
+		return next, nil
+	}
+	return "", xerrors.Errorf("unknown table name %s: %w", builder.tableName, ErrTableNotWarmedUp)
 }
 
 func (tx *Tx) CountByQueryBuilder(builder *QueryBuilder) (uint64, error) {
@@ -447,6 +1204,10 @@ func (tx *Tx) CountByQueryBuilder(builder *QueryBuilder) (uint64, error) {
 }
 
 func (tx *Tx) CountByQueryBuilderContext(ctx context.Context, builder *QueryBuilder) (uint64, error) {
+	if err := tx.enter(); err != nil {
+		return 0, err
+	}
+	defer tx.leave()
 	if c, exists := tx.r.firstLevelCaches.get(builder.tableName); exists {
 		count, err := c.CountByQueryBuilder(builder)
 		if err != nil {
@@ -454,14 +1215,14 @@ func (tx *Tx) CountByQueryBuilderContext(ctx context.Context, builder *QueryBuil
 		}
 		return count, nil
 	}
-	if c, exists := tx.r.secondLevelCaches.get(builder.tableName); exists {
+	if c, exists := tx.r.secondLevelCache(builder.tableName); exists {
 		count, err := c.CountByQueryBuilder(ctx, tx, builder)
 		if err != nil {
 			return 0, xerrors.Errorf("failed to CountByQueryBuilder of SecondLevelCache: %w", err)
 		}
 		return count, nil
 	}
-	return 0, xerrors.Errorf("unknown table name %s", builder.tableName)
+	return 0, xerrors.Errorf("unknown table name %s: %w", builder.tableName, ErrTableNotWarmedUp)
 }
 
 func (tx *Tx) FindAllByTable(tableName string, unmarshaler Unmarshaler) error {
@@ -471,71 +1232,172 @@ func (tx *Tx) FindAllByTable(tableName string, unmarshaler Unmarshaler) error {
 		}
 		return nil
 	}
-	return xerrors.Errorf("unknown table name %s", tableName)
+	return xerrors.Errorf("unknown table name %s: %w", tableName, ErrTableNotWarmedUp)
 }
 
 func (tx *Tx) UpdateByQueryBuilder(builder *QueryBuilder, updateMap map[string]interface{}) error {
-	if err := tx.UpdateByQueryBuilderContext(context.Background(), builder, updateMap); err != nil {
-		return xerrors.Errorf("failed to UpdateByQueryBuilderContext: %w", err)
+	if _, err := tx.UpdateRowsByQueryBuilderContext(context.Background(), builder, updateMap); err != nil {
+		return xerrors.Errorf("failed to UpdateRowsByQueryBuilderContext: %w", err)
 	}
 	return nil
 }
 
 func (tx *Tx) UpdateByQueryBuilderContext(ctx context.Context, builder *QueryBuilder, updateMap map[string]interface{}) error {
+	if _, err := tx.UpdateRowsByQueryBuilderContext(ctx, builder, updateMap); err != nil {
+		return xerrors.Errorf("failed to UpdateRowsByQueryBuilderContext: %w", err)
+	}
+	return nil
+}
+
+// UpdateRowsByQueryBuilder returns number of rows affected by UPDATE statement
+func (tx *Tx) UpdateRowsByQueryBuilder(builder *QueryBuilder, updateMap map[string]interface{}) (int64, error) {
+	affected, err := tx.UpdateRowsByQueryBuilderContext(context.Background(), builder, updateMap)
+	if err != nil {
+		return 0, xerrors.Errorf("failed to UpdateRowsByQueryBuilderContext: %w", err)
+	}
+	return affected, nil
+}
+
+func (tx *Tx) UpdateRowsByQueryBuilderContext(ctx context.Context, builder *QueryBuilder, updateMap map[string]interface{}) (int64, error) {
 	if tx.IsCommitted() {
-		return ErrAlreadyCommittedTransaction
+		return 0, ErrAlreadyCommittedTransaction
+	}
+	if err := tx.enter(); err != nil {
+		return 0, err
+	}
+	defer tx.leave()
+	if tx.opt.ReadOnly {
+		return 0, ErrReadOnlyTransaction
 	}
 	tx.enabledIgnoreCacheIfExistsTable(builder)
 	if _, exists := tx.r.firstLevelCaches.get(builder.tableName); exists {
-		return xerrors.Errorf("%s is read only table. it doesn't support write query", builder.tableName)
+		return 0, xerrors.Errorf("%s is read only table. it doesn't support write query", builder.tableName)
 	}
-	if c, exists := tx.r.secondLevelCaches.get(builder.tableName); exists {
-		if tx.conn == nil {
-			return ErrConnectionOfTransaction
+	if c, exists := tx.r.secondLevelCache(builder.tableName); exists {
+		if tx.connection(c) == nil && !c.cacheOnly {
+			return 0, ErrConnectionOfTransaction
 		}
-		if err := c.UpdateByQueryBuilder(ctx, tx, builder, updateMap); err != nil {
-			return xerrors.Errorf("failed to UpdateByQueryBuilder: %w", err)
+		affected, err := c.UpdateRowsByQueryBuilder(ctx, tx, builder, updateMap)
+		if err != nil {
+			return 0, xerrors.Errorf("failed to UpdateRowsByQueryBuilder: %w", err)
 		}
-		return nil
+		return affected, nil
 	}
-	return xerrors.Errorf("unknown table name %s", builder.tableName)
+	return 0, xerrors.Errorf("unknown table name %s: %w", builder.tableName, ErrTableNotWarmedUp)
 }
 
 func (tx *Tx) DeleteByQueryBuilder(builder *QueryBuilder) error {
-	if err := tx.DeleteByQueryBuilderContext(context.Background(), builder); err != nil {
-		return xerrors.Errorf("failed to DeleteByQueryBuilderContext: %w", err)
+	if _, err := tx.DeleteRowsByQueryBuilderContext(context.Background(), builder); err != nil {
+		return xerrors.Errorf("failed to DeleteRowsByQueryBuilderContext: %w", err)
 	}
 	return nil
 }
 
 func (tx *Tx) DeleteByQueryBuilderContext(ctx context.Context, builder *QueryBuilder) error {
+	if _, err := tx.DeleteRowsByQueryBuilderContext(ctx, builder); err != nil {
+		return xerrors.Errorf("failed to DeleteRowsByQueryBuilderContext: %w", err)
+	}
+	return nil
+}
+
+// DeleteRowsByQueryBuilder returns number of rows affected by DELETE statement
+func (tx *Tx) DeleteRowsByQueryBuilder(builder *QueryBuilder) (int64, error) {
+	affected, err := tx.DeleteRowsByQueryBuilderContext(context.Background(), builder)
+	if err != nil {
+		return 0, xerrors.Errorf("failed to DeleteRowsByQueryBuilderContext: %w", err)
+	}
+	return affected, nil
+}
+
+func (tx *Tx) DeleteRowsByQueryBuilderContext(ctx context.Context, builder *QueryBuilder) (int64, error) {
 	if tx.IsCommitted() {
-		return ErrAlreadyCommittedTransaction
+		return 0, ErrAlreadyCommittedTransaction
+	}
+	if err := tx.enter(); err != nil {
+		return 0, err
+	}
+	defer tx.leave()
+	if tx.opt.ReadOnly {
+		return 0, ErrReadOnlyTransaction
 	}
 	tx.enabledIgnoreCacheIfExistsTable(builder)
 	if _, exists := tx.r.firstLevelCaches.get(builder.tableName); exists {
-		return xerrors.Errorf("%s is read only table. it doesn't support write query", builder.tableName)
+		return 0, xerrors.Errorf("%s is read only table. it doesn't support write query", builder.tableName)
 	}
-	if c, exists := tx.r.secondLevelCaches.get(builder.tableName); exists {
-		if tx.conn == nil {
-			return ErrConnectionOfTransaction
+	if c, exists := tx.r.secondLevelCache(builder.tableName); exists {
+		if tx.connection(c) == nil && !c.cacheOnly {
+			return 0, ErrConnectionOfTransaction
 		}
-		if err := c.DeleteByQueryBuilder(ctx, tx, builder); err != nil {
-			return xerrors.Errorf("failed to DeleteByQueryBuilder: %w", err)
+		affected, err := c.DeleteRowsByQueryBuilder(ctx, tx, builder)
+		if err != nil {
+			return 0, xerrors.Errorf("failed to DeleteRowsByQueryBuilder: %w", err)
 		}
-		return nil
+		return affected, nil
 	}
-	return xerrors.Errorf("unknown table name %s", builder.tableName)
+	return 0, xerrors.Errorf("unknown table name %s: %w", builder.tableName, ErrTableNotWarmedUp)
 }
 
 func (tx *Tx) IsCommitted() bool {
 	return tx.isDBCommitted || tx.isCacheCommitted
 }
 
-func (tx *Tx) execQuery(queries []*PendingQuery) []*PendingQuery {
+func (tx *Tx) execQuery(ctx context.Context, queries []*PendingQuery) []*PendingQuery {
+	if tx.r.opt.commitPipelineEnabled {
+		return tx.execQueryByPipeline(ctx, queries)
+	}
+	return tx.execQuerySerially(ctx, queries)
+}
+
+// execQueryByPipeline runs queries grouped by cache node concurrently.
+// The same key is always assigned to the same node, so the order of queries per key is preserved.
+func (tx *Tx) execQueryByPipeline(ctx context.Context, queries []*PendingQuery) []*PendingQuery {
+	pipelines := tx.pipelinesByNode(queries)
+	if len(pipelines) <= 1 {
+		return tx.execQuerySerially(ctx, queries)
+	}
+	failedQueriesByNode := make([][]*PendingQuery, len(pipelines))
+	var wg sync.WaitGroup
+	for idx, pipeline := range pipelines {
+		wg.Add(1)
+		go func(idx int, pipeline []*PendingQuery) {
+			defer wg.Done()
+			failedQueriesByNode[idx] = tx.execQuerySerially(ctx, pipeline)
+		}(idx, pipeline)
+	}
+	wg.Wait()
+	failedQueries := []*PendingQuery{}
+	for _, queries := range failedQueriesByNode {
+		failedQueries = append(failedQueries, queries...)
+	}
+	return failedQueries
+}
+
+func (tx *Tx) pipelinesByNode(queries []*PendingQuery) [][]*PendingQuery {
+	client := tx.r.cacheServer.GetClient()
+	nodeToIndex := map[string]int{}
+	pipelines := [][]*PendingQuery{}
+	for _, query := range queries {
+		var node string
+		if query.key != nil && client != nil {
+			if addr, err := client.PickServer(query.key); err == nil {
+				node = addr.String()
+			}
+		}
+		idx, exists := nodeToIndex[node]
+		if !exists {
+			idx = len(pipelines)
+			nodeToIndex[node] = idx
+			pipelines = append(pipelines, []*PendingQuery{})
+		}
+		pipelines[idx] = append(pipelines[idx], query)
+	}
+	return pipelines
+}
+
+func (tx *Tx) execQuerySerially(ctx context.Context, queries []*PendingQuery) []*PendingQuery {
 	failedQueries := []*PendingQuery{}
 	for _, query := range queries {
-		if err := query.fn(); err != nil {
+		if err := query.fn(ctx); err != nil {
 			failedQueries = append(failedQueries, query)
 		}
 	}
@@ -553,11 +1415,12 @@ func (tx *Tx) sortedPendingQueryKeys() []string {
 	return keys
 }
 
-func (tx *Tx) unlockAllKeys() error {
+func (tx *Tx) unlockAllKeys(ctx context.Context) error {
+	tx.stopLockRefresh()
 	mergedErr := []string{}
 	for _, key := range tx.lockKeys {
-		log.Delete(tx.id, SLCServer, key)
-		if err := tx.r.cacheServer.Delete(key); err != nil {
+		tx.logger().Delete(tx.id, SLCServer, key)
+		if err := tx.r.cacheServer.Delete(ctx, key); err != nil {
 			mergedErr = append(mergedErr, err.Error())
 		}
 	}
@@ -568,10 +1431,12 @@ func (tx *Tx) unlockAllKeys() error {
 }
 
 func (tx *Tx) releaseValues() {
-	for _, value := range tx.stash.primaryKeyToValue {
+	for key, value := range tx.stash.primaryKeyToValue {
+		tx.stash.size -= tx.stash.valueSizes[key]
 		value.Release()
 	}
 	tx.stash.primaryKeyToValue = make(map[string]*StructValue)
+	tx.stash.valueSizes = make(map[string]int)
 }
 
 func (tx *Tx) commitBeforeProcess(queries []*PendingQuery) error {
@@ -580,7 +1445,7 @@ func (tx *Tx) commitBeforeProcess(queries []*PendingQuery) error {
 		for idx, query := range queries {
 			totalQueries[idx] = query.QueryLog
 		}
-		if err := tx.beforeCommitCallback(totalQueries); err != nil {
+		if err := tx.guard.runCallback(func() error { return tx.beforeCommitCallback(totalQueries) }); err != nil {
 			return xerrors.Errorf("failed to callback for BeforeCommit: %w", err)
 		}
 	} else if tx.r.opt.beforeCommitCallback != nil {
@@ -588,17 +1453,17 @@ func (tx *Tx) commitBeforeProcess(queries []*PendingQuery) error {
 		for idx, query := range queries {
 			totalQueries[idx] = query.QueryLog
 		}
-		if err := tx.r.opt.beforeCommitCallback(tx, totalQueries); err != nil {
+		if err := tx.guard.runCallback(func() error { return tx.r.opt.beforeCommitCallback(tx, totalQueries) }); err != nil {
 			return xerrors.Errorf("failed to callback for BeforeCommit: %w", err)
 		}
 	}
 	return nil
 }
 
-func (tx *Tx) commitAfterProcess(queries []*PendingQuery) error {
+func (tx *Tx) commitAfterProcess(ctx context.Context, queries []*PendingQuery) error {
 	tx.isCacheCommitted = true
 	errs := []string{}
-	if err := tx.unlockAllKeys(); err != nil {
+	if err := tx.unlockAllKeys(ctx); err != nil {
 		errs = append(errs, err.Error())
 	}
 	if len(queries) == 0 {
@@ -636,13 +1501,22 @@ func (tx *Tx) commitAfterProcess(queries []*PendingQuery) error {
 	return nil
 }
 
-func (tx *Tx) commitCache() (e error) {
+func (tx *Tx) commitCache(ctx context.Context) (e error) {
+	if tx.aborted {
+		return ErrTxAborted
+	}
+	// expiration is checked before commit of database, so cache must follow database even if tx is expired here
+	if err := tx.use(); err != nil {
+		return err
+	}
 	queries := []*PendingQuery{}
 	tx.releaseValues()
 	defer func() {
-		if err := tx.commitAfterProcess(queries); err != nil {
+		tx.leave()
+		if err := tx.commitAfterProcess(ctx, queries); err != nil {
 			e = xerrors.Errorf("failed to run commit after process: %w", err)
 		}
+		tx.finish()
 	}()
 	keys := tx.sortedPendingQueryKeys()
 	for _, key := range keys {
@@ -652,7 +1526,7 @@ func (tx *Tx) commitCache() (e error) {
 		return xerrors.Errorf("failed to run commit before process: %w", err)
 	}
 	for i := 0; i < tx.r.opt.maxRetryCount-1; i++ {
-		queries = tx.execQuery(queries)
+		queries = tx.execQuery(ctx, queries)
 		if len(queries) == 0 {
 			return nil
 		}
@@ -660,7 +1534,7 @@ func (tx *Tx) commitCache() (e error) {
 	}
 	errs := []string{}
 	for _, query := range queries {
-		if err := query.fn(); err != nil {
+		if err := query.fn(ctx); err != nil {
 			errs = append(errs, err.Error())
 		}
 	}
@@ -670,69 +1544,137 @@ func (tx *Tx) commitCache() (e error) {
 	return nil
 }
 
-func (tx *Tx) commitDB() error {
-	if tx.conn == nil {
-		return nil
+func (tx *Tx) commitDB(ctx context.Context) error {
+	if tx.aborted {
+		return ErrTxAborted
 	}
-	txConn, ok := tx.conn.(TxConnection)
-	if !ok {
-		return nil
+	// cache only tables don't need connection, and queries of connection that isn't TxConnection are already committed by auto commit
+	if txConn, ok := tx.conn.(TxConnection); ok {
+		if err := tx.writeInvalidationOutbox(ctx); err != nil {
+			return xerrors.Errorf("failed to write invalidation outbox: %w", err)
+		}
+		if err := txConn.Commit(); err != nil {
+			return xerrors.Errorf("failed to Commit for database: %w", err)
+		}
+		tx.isDBCommitted = true
 	}
-	if err := txConn.Commit(); err != nil {
-		return xerrors.Errorf("failed to Commit for database: %w", err)
+	if err := tx.commitBoundConnections(); err != nil {
+		return xerrors.Errorf("failed to commit bound connections: %w", err)
 	}
-	tx.isDBCommitted = true
+	tx.emitAuditEvents()
 	return nil
 }
 
 func (tx *Tx) CommitCacheOnly() error {
-	if err := tx.commitCache(); err != nil {
+	if err := tx.CommitCacheOnlyContext(context.Background()); err != nil {
+		return xerrors.Errorf("failed to CommitCacheOnlyContext: %w", err)
+	}
+	return nil
+}
+
+// CommitCacheOnlyContext commits transaction for cache server. ctx is used by operations to cache server
+func (tx *Tx) CommitCacheOnlyContext(ctx context.Context) error {
+	if err := tx.commitCache(ctx); err != nil {
 		return xerrors.Errorf("failed to Commit for cache: %w", err)
 	}
+	if err := tx.removeInvalidationOutbox(ctx); err != nil {
+		return xerrors.Errorf("failed to remove invalidation outbox: %w", err)
+	}
 	return nil
 }
 
 func (tx *Tx) CommitDBOnly() error {
-	if err := tx.commitDB(); err != nil {
+	if err := tx.CommitDBOnlyContext(context.Background()); err != nil {
+		return xerrors.Errorf("failed to CommitDBOnlyContext: %w", err)
+	}
+	return nil
+}
+
+// CommitDBOnlyContext commits transaction for database. ctx is used by writing invalidation outbox
+func (tx *Tx) CommitDBOnlyContext(ctx context.Context) error {
+	if tx.isExpired() {
+		return ErrTxExpired
+	}
+	if err := tx.commitDB(ctx); err != nil {
 		return xerrors.Errorf("failed to Commit for database: %w", err)
 	}
 	return nil
 }
 
 func (tx *Tx) Commit() error {
-	if err := tx.commitDB(); err != nil {
-		return xerrors.Errorf("failed to Commit for database: %w", err)
+	if err := tx.CommitContext(context.Background()); err != nil {
+		return xerrors.Errorf("failed to CommitContext: %w", err)
 	}
-	if err := tx.commitCache(); err != nil {
-		return xerrors.Errorf("failed to Commit for cache: %w", err)
+	return nil
+}
+
+// CommitContext commits transaction. ctx is used by operations to cache server
+func (tx *Tx) CommitContext(ctx context.Context) error {
+	if tx.isExpired() {
+		return ErrTxExpired
+	}
+	switch tx.r.opt.commitOrder {
+	case CommitOrderCacheFirst:
+		if err := tx.commitCache(ctx); err != nil {
+			return xerrors.Errorf("failed to Commit for cache: %w", err)
+		}
+		if err := tx.commitDB(ctx); err != nil {
+			return xerrors.Errorf("failed to Commit for database: %w", err)
+		}
+	case CommitOrderDeleteCacheBeforeAndAfter:
+		if err := tx.commitByDeleteCacheBeforeAndAfter(ctx); err != nil {
+			return xerrors.Errorf("failed to commit by deleting cache before and after: %w", err)
+		}
+	default:
+		if err := tx.commitDB(ctx); err != nil {
+			return xerrors.Errorf("failed to Commit for database: %w", err)
+		}
+		if err := tx.commitCache(ctx); err != nil {
+			return xerrors.Errorf("failed to Commit for cache: %w", err)
+		}
+	}
+	if err := tx.removeInvalidationOutbox(ctx); err != nil {
+		return xerrors.Errorf("failed to remove invalidation outbox: %w", err)
 	}
 	return nil
 }
 
-func (tx *Tx) rollbackCache() error {
+func (tx *Tx) rollbackCache(ctx context.Context) error {
+	if err := tx.use(); err != nil {
+		return err
+	}
+	defer tx.leave()
 	tx.releaseValues()
-	if err := tx.unlockAllKeys(); err != nil {
+	defer tx.finish()
+	if err := tx.unlockAllKeys(ctx); err != nil {
 		return xerrors.Errorf("failed to unlock for all keys: %w", err)
 	}
 	return nil
 }
 
 func (tx *Tx) rollbackDB() error {
-	if tx.conn == nil {
-		return nil
-	}
-	txConn, ok := tx.conn.(TxConnection)
-	if !ok {
-		return nil
+	tx.auditEvents = nil
+	if txConn, ok := tx.conn.(TxConnection); ok {
+		if err := txConn.Rollback(); err != nil {
+			return xerrors.Errorf("failed to Rollback for database: %w", err)
+		}
 	}
-	if err := txConn.Rollback(); err != nil {
-		return xerrors.Errorf("failed to Rollback for database: %w", err)
+	if err := tx.rollbackBoundConnections(); err != nil {
+		return xerrors.Errorf("failed to rollback bound connections: %w", err)
 	}
 	return nil
 }
 
 func (tx *Tx) RollbackCacheOnly() error {
-	if err := tx.rollbackCache(); err != nil {
+	if err := tx.RollbackCacheOnlyContext(context.Background()); err != nil {
+		return xerrors.Errorf("failed to RollbackCacheOnlyContext: %w", err)
+	}
+	return nil
+}
+
+// RollbackCacheOnlyContext rolls back transaction for cache server. ctx is used to unlock keys on cache server
+func (tx *Tx) RollbackCacheOnlyContext(ctx context.Context) error {
+	if err := tx.rollbackCache(ctx); err != nil {
 		return xerrors.Errorf("failed to Rollback for cache: %w", err)
 	}
 	return nil
@@ -746,10 +1688,18 @@ func (tx *Tx) RollbackDBOnly() error {
 }
 
 func (tx *Tx) Rollback() error {
+	if err := tx.RollbackContext(context.Background()); err != nil {
+		return xerrors.Errorf("failed to RollbackContext: %w", err)
+	}
+	return nil
+}
+
+// RollbackContext rolls back transaction. ctx is used to unlock keys on cache server
+func (tx *Tx) RollbackContext(ctx context.Context) error {
 	if err := tx.rollbackDB(); err != nil {
 		return xerrors.Errorf("failed to Rollback for database: %w", err)
 	}
-	if err := tx.rollbackCache(); err != nil {
+	if err := tx.rollbackCache(ctx); err != nil {
 		return xerrors.Errorf("failed to Rollback for cache: %w", err)
 	}
 	return nil
@@ -757,7 +1707,7 @@ func (tx *Tx) Rollback() error {
 
 func (tx *Tx) RollbackCacheOnlyUnlessCommitted() error {
 	if !tx.isCacheCommitted {
-		if err := tx.rollbackCache(); err != nil {
+		if err := tx.rollbackCache(context.Background()); err != nil {
 			return xerrors.Errorf("failed to rollback: %w", err)
 		}
 		return nil
@@ -786,6 +1736,14 @@ func (tx *Tx) RollbackUnlessCommitted() error {
 }
 
 func (r *Rapidash) Recover(queries []*QueryLog) error {
+	if err := r.RecoverContext(context.Background(), queries); err != nil {
+		return xerrors.Errorf("failed to RecoverContext: %w", err)
+	}
+	return nil
+}
+
+// RecoverContext deletes cache keys of queries. ctx is used by operations to cache server
+func (r *Rapidash) RecoverContext(ctx context.Context, queries []*QueryLog) error {
 	mergedErr := []string{}
 	for _, query := range queries {
 		var serverAddr net.Addr
@@ -802,7 +1760,8 @@ func (r *Rapidash) Recover(queries []*QueryLog) error {
 			typ:  query.Type,
 			addr: serverAddr,
 		}
-		if err := r.cacheServer.Delete(cacheKey); err != nil {
+		r.evictLocalCache(cacheKey)
+		if err := r.cacheServer.Delete(ctx, cacheKey); err != nil {
 			mergedErr = append(mergedErr, err.Error())
 		}
 	}
@@ -823,6 +1782,21 @@ func (r *Rapidash) AfterCommitCallback(
 	r.opt.afterCommitFailureCallback = failureCallback
 }
 
+// SetCacheEnabled switches cache access of all tables at runtime.
+// While disabled, SLC reads/writes go to database directly, LLC reads are regarded as not found and LLC writes are ignored.
+// Cache isn't invalidated while disabled, so flush cache servers or wait for expiration before enabling again
+func (r *Rapidash) SetCacheEnabled(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&r.cacheDisabled, 0)
+	} else {
+		atomic.StoreInt32(&r.cacheDisabled, 1)
+	}
+}
+
+func (r *Rapidash) IsCacheEnabled() bool {
+	return atomic.LoadInt32(&r.cacheDisabled) == 0
+}
+
 // Ignore read/write to database without cache access
 func (r *Rapidash) Ignore(conn *sql.DB, typ *Struct) error {
 	r.ignoreCaches[typ.tableName] = struct{}{}
@@ -846,7 +1820,7 @@ func (r *Rapidash) WarmUp(conn *sql.DB, typ *Struct, isReadOnly bool) error {
 }
 
 func (r *Rapidash) WarmUpFirstLevelCache(conn *sql.DB, typ *Struct) error {
-	flc := NewFirstLevelCache(typ)
+	flc := NewFirstLevelCache(typ.withColumnCipher(r.opt.columnCipher))
 	if err := flc.WarmUp(conn); err != nil {
 		return xerrors.Errorf("cannot warm up FirstLevelCache. table is %s: %w", typ.tableName, err)
 	}
@@ -868,6 +1842,18 @@ func (r *Rapidash) tableOption(tableName string) TableOption {
 	if opt.pessimisticLock == nil {
 		opt.pessimisticLock = &r.opt.slcPessimisticLock
 	}
+	if opt.inChunkSize == nil {
+		opt.inChunkSize = &r.opt.slcINChunkSize
+	}
+	if opt.sessionVariables == nil {
+		opt.sessionVariables = r.opt.slcSessionVariables
+	}
+	opt.clock = r.opt.clock
+	opt.ttlSource = r.opt.ttlSource
+	opt.payloadCodecs = r.opt.payloadCodecs
+	opt.stats = r.opt.stats
+	opt.columnCipher = r.opt.columnCipher
+	opt.cacheKeyHash = r.opt.cacheKeyHash
 	return opt
 }
 
@@ -880,6 +1866,102 @@ func (r *Rapidash) WarmUpSecondLevelCache(conn *sql.DB, typ *Struct) error {
 	return nil
 }
 
+// ReadRepairCount returns number of SecondLevelCache values deleted because they couldn't be decoded
+func (r *Rapidash) ReadRepairCount() uint64 {
+	count := uint64(0)
+	r.secondLevelCaches.Range(func(key, value interface{}) bool {
+		count += value.(*SecondLevelCache).ReadRepairCount()
+		return true
+	})
+	return count
+}
+
+// MissingPrimaryKeyCount returns number of primary keys referenced by index keys whose values were missing in cache
+func (r *Rapidash) MissingPrimaryKeyCount() uint64 {
+	count := uint64(0)
+	r.secondLevelCaches.Range(func(key, value interface{}) bool {
+		count += value.(*SecondLevelCache).MissingPrimaryKeyCount()
+		return true
+	})
+	return count
+}
+
+// IndexRepairCount returns number of index keys deleted because they referenced records that don't exist
+func (r *Rapidash) IndexRepairCount() uint64 {
+	count := uint64(0)
+	r.secondLevelCaches.Range(func(key, value interface{}) bool {
+		count += value.(*SecondLevelCache).IndexRepairCount()
+		return true
+	})
+	return count
+}
+
+// IndexStats returns selectivity of non unique indexes of all tables
+func (r *Rapidash) IndexStats() []*IndexStat {
+	stats := []*IndexStat{}
+	r.secondLevelCaches.Range(func(key, value interface{}) bool {
+		stats = append(stats, value.(*SecondLevelCache).IndexStats()...)
+		return true
+	})
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Table != stats[j].Table {
+			return stats[i].Table < stats[j].Table
+		}
+		return stats[i].Index < stats[j].Index
+	})
+	return stats
+}
+
+func (r *Rapidash) keyRegistry(tableName string) (*KeyRegistry, error) {
+	c, exists := r.secondLevelCache(tableName)
+	if !exists {
+		return nil, xerrors.Errorf("unknown table name %s: %w", tableName, ErrTableNotWarmedUp)
+	}
+	if c.keyRegistry == nil {
+		return nil, xerrors.Errorf("%s: %w", tableName, ErrKeyRegistryDisabled)
+	}
+	return c.keyRegistry, nil
+}
+
+// CacheKeysByTable returns registered cache keys for table. SecondLevelCacheTableKeyRegistry option is required
+func (r *Rapidash) CacheKeysByTable(tableName string) ([]server.CacheKey, error) {
+	registry, err := r.keyRegistry(tableName)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get key registry: %w", err)
+	}
+	return registry.Keys(), nil
+}
+
+// DeleteCacheByTable deletes all registered cache keys for table from cache server.
+func (r *Rapidash) DeleteCacheByTable(tableName string) error {
+	if err := r.DeleteCacheByTableContext(context.Background(), tableName); err != nil {
+		return xerrors.Errorf("failed to DeleteCacheByTableContext: %w", err)
+	}
+	return nil
+}
+
+// DeleteCacheByTableContext deletes all registered cache keys for table from cache server.
+// ctx is used by operations to cache server
+func (r *Rapidash) DeleteCacheByTableContext(ctx context.Context, tableName string) error {
+	registry, err := r.keyRegistry(tableName)
+	if err != nil {
+		return xerrors.Errorf("failed to get key registry: %w", err)
+	}
+	errs := []string{}
+	for _, key := range registry.Keys() {
+		if err := r.cacheServer.Delete(ctx, key); err != nil && !IsCacheMiss(err) {
+			errs = append(errs, err.Error())
+			continue
+		}
+		r.evictLocalCache(key)
+		registry.Remove(key)
+	}
+	if len(errs) > 0 {
+		return xerrors.Errorf("%s: %w", strings.Join(errs, ","), ErrDeleteCacheByTable)
+	}
+	return nil
+}
+
 func (r *Rapidash) RemoveServers(servers ...string) error {
 	client := r.cacheServer.GetClient()
 	if err := client.RemoveSecondLevelCacheServers(servers...); err != nil {
@@ -934,13 +2016,42 @@ func (r *Rapidash) AddLastLevelCacheServer(servers ...string) error {
 	return nil
 }
 
+// NodeForKey returns address of cache server that holds key. nil is returned if it can't be determined
+// ( e.g. no server is assigned )
+func (r *Rapidash) NodeForKey(key server.CacheKey) net.Addr {
+	client := r.cacheServer.GetClient()
+	if client == nil {
+		return nil
+	}
+	addr, err := client.PickServer(key)
+	if err != nil {
+		return nil
+	}
+	return addr
+}
+
 func (r *Rapidash) Flush() error {
-	if err := r.cacheServer.Flush(); err != nil {
+	if err := r.FlushContext(context.Background()); err != nil {
+		return xerrors.Errorf("failed to FlushContext: %w", err)
+	}
+	return nil
+}
+
+// FlushContext flushes all values of cache server. ctx is used by operations to cache server
+func (r *Rapidash) FlushContext(ctx context.Context) error {
+	if err := r.cacheServer.Flush(ctx); err != nil {
 		return xerrors.Errorf("failed to flush cache server: %w", err)
 	}
 	return nil
 }
 
+func (r *Rapidash) withRetryPolicy(s server.CacheServer) server.CacheServer {
+	if r.opt.serverRetryPolicy == nil {
+		return s
+	}
+	return server.NewRetryCacheServer(s, *r.opt.serverRetryPolicy)
+}
+
 func (r *Rapidash) setServer() error {
 	switch r.opt.serverType {
 	case CacheServerTypeMemcached:
@@ -949,15 +2060,35 @@ func (r *Rapidash) setServer() error {
 			return xerrors.Errorf("failed to set cache server selector: %w", err)
 		}
 		memcached := server.NewMemcachedBySelectors(s.slcSelector, s.llcSelector)
-		r.cacheServer = memcached
+		if r.opt.serverGetMultiBatch != nil {
+			memcached.GetClient().SetGetMultiBatchPolicy(*r.opt.serverGetMultiBatch)
+		}
+		r.cacheServer = r.withStats(r.withRetryPolicy(memcached))
 		r.lastLevelCache = NewLastLevelCache(r.cacheServer, r.opt.llcOpt)
 	case CacheServerTypeRedis:
-		s := &Selectors{}
-		if err := s.setSelector(r.opt.serverAddrs, r.opt.slcServerAddrs, r.opt.llcServerAddrs); err != nil {
-			return xerrors.Errorf("failed to set cache server selector: %w", err)
+		redis, err := r.newRedis()
+		if err != nil {
+			return xerrors.Errorf("failed to create redis client: %w", err)
 		}
-		redis := server.NewRedisBySelectors(s.slcSelector, s.llcSelector)
-		r.cacheServer = redis
+		if r.opt.serverCredentialsProvider != nil {
+			redis.GetClient().SetCredentialsProvider(r.opt.serverCredentialsProvider)
+		}
+		if r.opt.serverGetMultiBatch != nil {
+			redis.GetClient().SetGetMultiBatchPolicy(*r.opt.serverGetMultiBatch)
+		}
+		redis.GetClient().SetRedisCompareAndSwap(r.opt.redisCompareAndSwap)
+		r.cacheServer = r.withStats(r.withRetryPolicy(redis))
+		r.lastLevelCache = NewLastLevelCache(r.cacheServer, r.opt.llcOpt)
+	case CacheServerTypeCustom:
+		cacheServer, err := newRegisteredCacheServer(r.opt.serverName, &CacheServerOption{
+			Addrs:    r.opt.serverAddrs,
+			SLCAddrs: r.opt.slcServerAddrs,
+			LLCAddrs: r.opt.llcServerAddrs,
+		})
+		if err != nil {
+			return xerrors.Errorf("failed to create cache server: %w", err)
+		}
+		r.cacheServer = r.withStats(r.withRetryPolicy(cacheServer))
 		r.lastLevelCache = NewLastLevelCache(r.cacheServer, r.opt.llcOpt)
 	case CacheServerTypeOnMemory:
 	}
@@ -973,17 +2104,68 @@ func (r *Rapidash) setServer() error {
 func (r *Rapidash) setLogger() {
 	if !r.opt.logEnabled {
 		setNopLogger()
-		return
+	} else {
+		r.setLoggerByMode()
+		if len(r.opt.logSamplingRates) > 0 {
+			base := log
+			if sampling, ok := base.(*samplingLogger); ok {
+				// logger isn't replaced by LogModeServerDebug, so it must not be wrapped again
+				base = sampling.Logger
+			}
+			r.samplingLogger = &samplingLogger{Logger: base, rates: r.opt.logSamplingRates}
+			log = r.samplingLogger
+		}
+	}
+}
+
+func (r *Rapidash) setLoggerByMode() {
+	var out io.Writer = os.Stderr
+	if r.opt.logBufferSize > 0 {
+		r.asyncLogWriter = NewAsyncLogWriter(out, r.opt.logBufferSize)
+		out = r.asyncLogWriter
 	}
 	switch r.opt.logMode {
 	case LogModeConsole:
-		setConsoleLogger()
+		setConsoleLogger(out)
 	case LogModeJSON:
-		setJSONLogger()
+		setJSONLogger(out)
 	case LogModeServerDebug:
 	}
 }
 
+func (r *Rapidash) LogStats() LogStats {
+	var stats LogStats
+	if r.asyncLogWriter != nil {
+		stats.Dropped = r.asyncLogWriter.Dropped()
+	}
+	if r.samplingLogger != nil {
+		stats.Sampled = atomic.LoadUint64(&r.samplingLogger.sampled)
+	}
+	return stats
+}
+
+// CloseLog writes all buffered log entries if async logger is enabled
+func (r *Rapidash) CloseLog() error {
+	if r.asyncLogWriter == nil {
+		return nil
+	}
+	if err := r.asyncLogWriter.Close(); err != nil {
+		return xerrors.Errorf("failed to close async log writer: %w", err)
+	}
+	return nil
+}
+
+func (r *Rapidash) newRedis() (server.CacheServer, error) {
+	if len(r.opt.redisClusterAddrs) > 0 {
+		return server.NewRedisCluster(r.opt.redisClusterAddrs...)
+	}
+	s := &Selectors{}
+	if err := s.setSelector(r.opt.serverAddrs, r.opt.slcServerAddrs, r.opt.llcServerAddrs); err != nil {
+		return nil, xerrors.Errorf("failed to set cache server selector: %w", err)
+	}
+	return server.NewRedisBySelectors(s.slcSelector, s.llcSelector), nil
+}
+
 func (s *Selectors) setSelector(serverAddrs, slcServerAddrs, llcServerAddrs []string) error {
 	if len(serverAddrs) > 0 {
 		slcSelector, err := server.NewSelector(serverAddrs...)
@@ -1024,6 +2206,13 @@ func New(opts ...OptionFunc) (*Rapidash, error) {
 	for _, opt := range opts {
 		opt(r)
 	}
+	if r.opt.columnEncryptionKey != nil {
+		c, err := newColumnCipher(r.opt.columnEncryptionKey)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to set column encryption key: %w", err)
+		}
+		r.opt.columnCipher = c
+	}
 	if err := r.setServer(); err != nil {
 		return nil, xerrors.Errorf("failed to set server: %w", err)
 	}
@@ -1,6 +1,7 @@
 package rapidash
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -56,6 +57,24 @@ func TestServerChanging(t *testing.T) {
 	})
 }
 
+func TestNodeForKey(t *testing.T) {
+	cache, err := New(ServerAddrs([]string{"127.0.0.1:11211", "127.0.0.1:11212"}))
+	NoError(t, err)
+	client := cache.cacheServer.GetClient()
+	for i := 0; i < 10; i++ {
+		key := &CacheKey{key: fmt.Sprintf("r/slc/user_logins/id#%d", i), hash: uint32(i), typ: server.CacheKeyTypeSLC}
+		expected, err := client.PickServer(key)
+		NoError(t, err)
+		Equal(t, cache.NodeForKey(key).String(), expected.String())
+	}
+	t.Run("no server", func(t *testing.T) {
+		cache, err := New(ServerAddrs([]string{"127.0.0.1:11211"}))
+		NoError(t, err)
+		NoError(t, cache.RemoveServers("127.0.0.1:11211"))
+		Equal(t, cache.NodeForKey(&CacheKey{key: "r/slc/user_logins/id#1", typ: server.CacheKeyTypeSLC}), nil)
+	})
+}
+
 func TestRecover(t *testing.T) {
 	txConn, err := conn.Begin()
 	NoError(t, err)
@@ -246,3 +265,116 @@ func TestRollbackUnlessCommitted(t *testing.T) {
 	NoError(t, tx.RollbackCacheOnlyUnlessCommitted())
 	Error(t, tx.RollbackDBOnlyUnlessCommitted())
 }
+
+func TestTxReport(t *testing.T) {
+	cache, err := New(ServerAddrs([]string{"localhost:11211"}), TxReportEnabled(true))
+	NoError(t, err)
+	NoError(t, cache.Flush())
+	tx, err := cache.Begin()
+	NoError(t, err)
+	NoError(t, tx.Create("report", Int(1)))
+	var v int
+	NoError(t, tx.Find("report", IntPtr(&v)))
+	NoError(t, tx.Commit())
+	report := tx.Report()
+	if len(report) == 0 {
+		t.Fatal("cannot get report")
+	}
+	last := report[len(report)-1]
+	if last.Command != SLCCommandDelete {
+		t.Fatalf("invalid last command %s", last.Command)
+	}
+}
+
+func TestTxReportHit(t *testing.T) {
+	newCache := func() *Rapidash {
+		r, err := New(TxReportEnabled(true))
+		NoError(t, err)
+		cacheServer := newMemoryCacheServer()
+		r.cacheServer = cacheServer
+		r.lastLevelCache = NewLastLevelCache(cacheServer, r.opt.llcOpt)
+		return r
+	}
+	r := newCache()
+	other := newCache()
+	if _, ok := log.(*reportLogger); ok {
+		t.Fatal("global logger must not be wrapped")
+	}
+
+	tx, err := r.Begin()
+	NoError(t, err)
+	var v int
+	Error(t, tx.Find("report", IntPtr(&v)))
+	NoError(t, tx.Create("report", Int(1)))
+	NoError(t, tx.Commit())
+	report := tx.Report()
+	Equal(t, report[0].Command, SLCCommandGet)
+	Equal(t, report[0].Hit, false)
+
+	tx, err = r.Begin()
+	NoError(t, err)
+	NoError(t, tx.Find("report", IntPtr(&v)))
+	NoError(t, tx.Commit())
+	report = tx.Report()
+	Equal(t, len(report), 1)
+	Equal(t, report[0].Hit, true)
+
+	otherTx, err := other.Begin()
+	NoError(t, err)
+	Error(t, otherTx.Find("report", IntPtr(&v)))
+	NoError(t, otherTx.Commit())
+	Equal(t, len(otherTx.Report()), 1)
+}
+
+func TestCommitPipeline(t *testing.T) {
+	cache, err := New(ServerAddrs([]string{"localhost:11211", "localhost:11212"}), CommitPipelineEnabled(true))
+	NoError(t, err)
+	tx, err := cache.Begin()
+	NoError(t, err)
+	queries := []*PendingQuery{}
+	for i := 0; i < 10; i++ {
+		keyStr := fmt.Sprintf("r/slc/user_logins/id#%d", i)
+		key := &CacheKey{key: keyStr, hash: NewStringValue(keyStr).Hash(), typ: server.CacheKeyTypeSLC}
+		failed := i%2 == 0
+		queries = append(queries, &PendingQuery{
+			QueryLog: &QueryLog{Command: string(SLCCommandSet), Key: keyStr},
+			key:      key,
+			fn: func(context.Context) error {
+				if failed {
+					return xerrors.New("failed to set")
+				}
+				return nil
+			},
+		})
+	}
+	pipelines := tx.pipelinesByNode(queries)
+	Equal(t, len(pipelines), 2)
+	Equal(t, len(pipelines[0])+len(pipelines[1]), 10)
+	Equal(t, len(tx.execQuery(context.Background(), queries)), 5)
+}
+
+func TestAllow(t *testing.T) {
+	for _, typ := range []RateLimitWindowType{RateLimitWindowFixed, RateLimitWindowSliding} {
+		cache, err := New(ServerAddrs([]string{"localhost:11211"}), RateLimitWindow(typ))
+		NoError(t, err)
+		NoError(t, cache.Flush())
+		for i := 0; i < 3; i++ {
+			allowed, remaining, err := cache.Allow(context.Background(), "api", 3, time.Minute)
+			NoError(t, err)
+			Equal(t, allowed, true)
+			Equal(t, remaining, 2-i)
+		}
+		allowed, remaining, err := cache.Allow(context.Background(), "api", 3, time.Minute)
+		NoError(t, err)
+		Equal(t, allowed, false)
+		Equal(t, remaining, 0)
+	}
+}
+
+func TestAllowWithoutIncr(t *testing.T) {
+	cache, err := New()
+	NoError(t, err)
+	cache.cacheServer = newMemoryCacheServer()
+	_, _, err = cache.Allow(context.Background(), "api", 3, time.Minute)
+	Equal(t, xerrors.Is(err, server.ErrIncrNotSupported), true)
+}
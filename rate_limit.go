@@ -0,0 +1,69 @@
+package rapidash
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/xid"
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+type RateLimitWindowType int
+
+const (
+	// RateLimitWindowFixed counts requests per fixed time window
+	RateLimitWindowFixed RateLimitWindowType = iota
+	// RateLimitWindowSliding weights count of previous window by elapsed time of current window
+	RateLimitWindowSliding
+)
+
+func (r *Rapidash) rateLimitCacheKey(key string, window time.Duration, idx int64) server.CacheKey {
+	return &CacheKey{
+		key:  fmt.Sprintf("r/rl/%s/%d/%d", key, int64(window/time.Second), idx),
+		hash: r.opt.cacheKeyHash.hashString(key),
+		typ:  server.CacheKeyTypeLLC,
+	}
+}
+
+// Allow counts up request for key and returns whether it is allowed within limit per window.
+// Counter is shared by all processes using the same cache servers.
+func (r *Rapidash) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, int, error) {
+	if err := ctx.Err(); err != nil {
+		return false, 0, xerrors.Errorf("context is done: %w", err)
+	}
+	if window < time.Second {
+		return false, 0, xerrors.Errorf("window must be 1 second or more: %w", ErrInvalidRateLimitWindow)
+	}
+	now := r.opt.clock.Now()
+	idx := now.UnixNano() / int64(window)
+	cacheKey := r.rateLimitCacheKey(key, window, idx)
+	// keep counter until next window finishes for sliding window
+	count, err := server.Incr(ctx, r.cacheServer, cacheKey, 1, 2*window)
+	if err != nil {
+		return false, 0, xerrors.Errorf("failed to increment counter: %w", err)
+	}
+	log.Update(xid.New().String(), SLCServer, cacheKey, LogString(fmt.Sprint(count)))
+	total := float64(count)
+	if r.opt.rateLimitWindowType == RateLimitWindowSliding {
+		prevKey := r.rateLimitCacheKey(key, window, idx-1)
+		content, err := r.cacheServer.Get(ctx, prevKey)
+		if err != nil && !IsCacheMiss(err) {
+			return false, 0, xerrors.Errorf("failed to get counter of previous window: %w", err)
+		}
+		if err == nil {
+			var prev uint64
+			if _, err := fmt.Sscan(string(content.Value), &prev); err != nil {
+				return false, 0, xerrors.Errorf("failed to parse counter of previous window: %w", err)
+			}
+			elapsed := float64(now.UnixNano()%int64(window)) / float64(window)
+			total += float64(prev) * (1 - elapsed)
+		}
+	}
+	remaining := limit - int(total)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return int(total) <= limit, remaining, nil
+}
@@ -0,0 +1,379 @@
+package rapidash
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/xerrors"
+)
+
+// reflectScalar is Go type of column and names of Encoder/Decoder methods for it.
+// pointer and slice methods are named by suffix Ptr and s ( e.g. Int, IntPtr, Ints )
+type reflectScalar struct {
+	typ    TypeID
+	kind   TypeKind
+	goType reflect.Type
+	method string
+}
+
+var reflectScalars = []*reflectScalar{
+	{typ: IntType, kind: IntKind, goType: reflect.TypeOf(int(0)), method: "Int"},
+	{typ: Int8Type, kind: IntKind, goType: reflect.TypeOf(int8(0)), method: "Int8"},
+	{typ: Int16Type, kind: IntKind, goType: reflect.TypeOf(int16(0)), method: "Int16"},
+	{typ: Int32Type, kind: IntKind, goType: reflect.TypeOf(int32(0)), method: "Int32"},
+	{typ: Int64Type, kind: IntKind, goType: reflect.TypeOf(int64(0)), method: "Int64"},
+	{typ: UintType, kind: IntKind, goType: reflect.TypeOf(uint(0)), method: "Uint"},
+	{typ: Uint8Type, kind: IntKind, goType: reflect.TypeOf(uint8(0)), method: "Uint8"},
+	{typ: Uint16Type, kind: IntKind, goType: reflect.TypeOf(uint16(0)), method: "Uint16"},
+	{typ: Uint32Type, kind: IntKind, goType: reflect.TypeOf(uint32(0)), method: "Uint32"},
+	{typ: Uint64Type, kind: IntKind, goType: reflect.TypeOf(uint64(0)), method: "Uint64"},
+	{typ: Float32Type, kind: FloatKind, goType: reflect.TypeOf(float32(0)), method: "Float32"},
+	{typ: Float64Type, kind: FloatKind, goType: reflect.TypeOf(float64(0)), method: "Float64"},
+	{typ: BoolType, kind: BoolKind, goType: reflect.TypeOf(false), method: "Bool"},
+	{typ: StringType, kind: StringKind, goType: reflect.TypeOf(""), method: "String"},
+}
+
+var (
+	bytesScalar = &reflectScalar{typ: BytesType, kind: BytesKind, goType: reflect.TypeOf([]byte{}), method: "Bytes"}
+	timeScalar  = &reflectScalar{typ: TimeType, kind: TimeKind, goType: timeType, method: "Time"}
+)
+
+func reflectScalarOf(t reflect.Type) *reflectScalar {
+	if t.Kind() == reflect.Struct && t.ConvertibleTo(timeType) {
+		return timeScalar
+	}
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+		return bytesScalar
+	}
+	for _, scalar := range reflectScalars {
+		if scalar.goType.Kind() == t.Kind() {
+			return scalar
+		}
+	}
+	return nil
+}
+
+// reflectField is field of Go struct tagged by db:"column".
+// omitempty of rapidash tag ( e.g. rapidash:"omitempty" ) skips encoding zero value like auto increment id
+type reflectField struct {
+	column     string
+	index      []int
+	scalar     *reflectScalar
+	isPtr      bool
+	isSlice    bool
+	structType reflect.Type
+	omitEmpty  bool
+}
+
+var reflectFieldsCache sync.Map
+
+func reflectFieldsOf(t reflect.Type) ([]*reflectField, error) {
+	if fields, exists := reflectFieldsCache.Load(t); exists {
+		return fields.([]*reflectField), nil
+	}
+	fields := []*reflectField{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		column := f.Tag.Get("db")
+		if column == "" || column == "-" || f.PkgPath != "" {
+			continue
+		}
+		field := &reflectField{
+			column:    column,
+			index:     f.Index,
+			omitEmpty: f.Tag.Get("rapidash") == "omitempty",
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			field.isPtr = true
+			ft = ft.Elem()
+		} else if ft.Kind() == reflect.Slice && ft.Elem().Kind() != reflect.Uint8 {
+			field.isSlice = true
+			ft = ft.Elem()
+			if ft.Kind() == reflect.Ptr && ft.Elem().Kind() == reflect.Struct {
+				ft = ft.Elem()
+			}
+		}
+		field.scalar = reflectScalarOf(ft)
+		if field.scalar == nil && ft.Kind() == reflect.Struct {
+			field.structType = ft
+		}
+		if (field.scalar == nil && field.structType == nil) || (field.isSlice && field.scalar == bytesScalar) {
+			return nil, xerrors.Errorf("%s.%s type is %s: %w", t.Name(), f.Name, f.Type, ErrUnsupportedFieldType)
+		}
+		fields = append(fields, field)
+	}
+	reflectFieldsCache.Store(t, fields)
+	return fields, nil
+}
+
+type tableNamer interface {
+	TableName() string
+}
+
+// StructOf creates Struct by fields of Go struct tagged by db:"column".
+// Table name is returned by TableName() if v implements it, otherwise it is plural snake case of type name ( e.g. UserLogin is user_logins ).
+// Fields of pointer type are nullable, and fields of struct or slice of struct are nested Struct
+func StructOf(v interface{}) (*Struct, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, xerrors.Errorf("%T: %w", v, ErrInvalidReflectType)
+	}
+	tableName := ToSnakeCase(t.Name()) + "s"
+	if namer, ok := v.(tableNamer); ok {
+		tableName = namer.TableName()
+	} else if namer, ok := reflect.New(t).Interface().(tableNamer); ok {
+		tableName = namer.TableName()
+	}
+	s, err := structOf(t, tableName, map[reflect.Type]struct{}{})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create struct of %s: %w", t, err)
+	}
+	return s, nil
+}
+
+func structOf(t reflect.Type, tableName string, visited map[reflect.Type]struct{}) (*Struct, error) {
+	fields, err := reflectFieldsOf(t)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get fields: %w", err)
+	}
+	visited[t] = struct{}{}
+	defer delete(visited, t)
+	s := NewStruct(tableName)
+	for _, field := range fields {
+		if field.scalar != nil {
+			if field.isSlice {
+				s.FieldSlice(field.column, field.scalar.typ)
+			} else {
+				s.addNewField(field.column, field.scalar.typ, field.scalar.kind)
+			}
+			continue
+		}
+		if field.structType == t {
+			if field.isSlice {
+				s.FieldSelfStructSlice(field.column)
+			} else {
+				s.FieldSelfStruct(field.column)
+			}
+			continue
+		}
+		if _, exists := visited[field.structType]; exists {
+			return nil, xerrors.Errorf("%s.%s refers struct recursively: %w", t.Name(), field.column, ErrUnsupportedFieldType)
+		}
+		subtype, err := structOf(field.structType, field.column, visited)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to create struct of %s: %w", field.column, err)
+		}
+		if field.isSlice {
+			s.FieldStructSlice(field.column, subtype)
+		} else {
+			s.FieldStruct(field.column, subtype)
+		}
+	}
+	return s, nil
+}
+
+// ToSnakeCase converts name of Go type to snake case used for default table name ( e.g. UserLogin is user_login )
+func ToSnakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// split before upper case following lower case ( UserLogin ), or before the last upper case of acronym ( HTTPServer )
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// ReflectCoder encodes and decodes Go struct tagged by db:"column" without EncodeRapidash/DecodeRapidash.
+// v must be pointer to struct, or pointer to slice of struct or pointer to struct
+type ReflectCoder struct {
+	v       interface{}
+	decoded bool
+}
+
+// NewReflectCoder creates ReflectCoder for v. v is used as Marshaler of Create and Unmarshaler of Find
+func NewReflectCoder(v interface{}) *ReflectCoder {
+	return &ReflectCoder{v: v}
+}
+
+func (c *ReflectCoder) target() (reflect.Value, error) {
+	rv := reflect.ValueOf(c.v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return reflect.Value{}, xerrors.Errorf("%T: %w", c.v, ErrInvalidReflectType)
+	}
+	rv = rv.Elem()
+	if rv.Kind() == reflect.Struct {
+		return rv, nil
+	}
+	if rv.Kind() == reflect.Slice {
+		elem := rv.Type().Elem()
+		if elem.Kind() == reflect.Struct || (elem.Kind() == reflect.Ptr && elem.Elem().Kind() == reflect.Struct) {
+			return rv, nil
+		}
+	}
+	return reflect.Value{}, xerrors.Errorf("%T: %w", c.v, ErrInvalidReflectType)
+}
+
+func (c *ReflectCoder) EncodeRapidash(enc Encoder) error {
+	rv, err := c.target()
+	if err != nil {
+		return xerrors.Errorf("invalid target: %w", err)
+	}
+	if rv.Kind() == reflect.Struct {
+		if err := encodeReflectStruct(enc, rv); err != nil {
+			return xerrors.Errorf("failed to encode: %w", err)
+		}
+		return nil
+	}
+	for i := 0; i < rv.Len(); i++ {
+		elem := rv.Index(i)
+		if elem.Kind() == reflect.Ptr {
+			if elem.IsNil() {
+				continue
+			}
+			elem = elem.Elem()
+		}
+		if err := encodeReflectStruct(enc.New(), elem); err != nil {
+			return xerrors.Errorf("failed to encode: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *ReflectCoder) DecodeRapidash(dec Decoder) error {
+	c.decoded = true
+	rv, err := c.target()
+	if err != nil {
+		return xerrors.Errorf("invalid target: %w", err)
+	}
+	if rv.Kind() == reflect.Struct {
+		if err := decodeReflectStruct(dec, rv); err != nil {
+			return xerrors.Errorf("failed to decode: %w", err)
+		}
+		return nil
+	}
+	length := dec.Len()
+	slice := reflect.MakeSlice(rv.Type(), length, length)
+	for i := 0; i < length; i++ {
+		elem := slice.Index(i)
+		if elem.Kind() == reflect.Ptr {
+			elem.Set(reflect.New(elem.Type().Elem()))
+			elem = elem.Elem()
+		}
+		if err := decodeReflectStruct(dec.At(i), elem); err != nil {
+			return xerrors.Errorf("failed to decode: %w", err)
+		}
+	}
+	rv.Set(slice)
+	return nil
+}
+
+func isZeroReflectValue(v reflect.Value) bool {
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
+
+func encodeReflectStruct(enc Encoder, rv reflect.Value) error {
+	fields, err := reflectFieldsOf(rv.Type())
+	if err != nil {
+		return xerrors.Errorf("failed to get fields: %w", err)
+	}
+	method := reflect.ValueOf(enc).MethodByName
+	for _, field := range fields {
+		fv := rv.FieldByIndex(field.index)
+		if field.omitEmpty && isZeroReflectValue(fv) {
+			continue
+		}
+		column := reflect.ValueOf(field.column)
+		switch {
+		case field.structType != nil && field.isSlice:
+			enc.Structs(field.column, NewReflectCoder(fv.Addr().Interface()))
+		case field.structType != nil && field.isPtr:
+			if !fv.IsNil() {
+				enc.Struct(field.column, NewReflectCoder(fv.Interface()))
+			}
+		case field.structType != nil:
+			enc.Struct(field.column, NewReflectCoder(fv.Addr().Interface()))
+		case field.isSlice:
+			slice := reflect.Zero(reflect.SliceOf(field.scalar.goType))
+			if !fv.IsNil() {
+				slice = reflect.MakeSlice(slice.Type(), fv.Len(), fv.Len())
+				for i := 0; i < fv.Len(); i++ {
+					slice.Index(i).Set(fv.Index(i).Convert(field.scalar.goType))
+				}
+			}
+			method(field.scalar.method + "s").Call([]reflect.Value{column, slice})
+		case field.isPtr:
+			ptr := reflect.Zero(reflect.PtrTo(field.scalar.goType))
+			if !fv.IsNil() {
+				ptr = reflect.New(field.scalar.goType)
+				ptr.Elem().Set(fv.Elem().Convert(field.scalar.goType))
+			}
+			method(field.scalar.method + "Ptr").Call([]reflect.Value{column, ptr})
+		default:
+			method(field.scalar.method).Call([]reflect.Value{column, fv.Convert(field.scalar.goType)})
+		}
+	}
+	if err := enc.Error(); err != nil {
+		return xerrors.Errorf("failed to encode: %w", err)
+	}
+	return nil
+}
+
+func decodeReflectStruct(dec Decoder, rv reflect.Value) error {
+	fields, err := reflectFieldsOf(rv.Type())
+	if err != nil {
+		return xerrors.Errorf("failed to get fields: %w", err)
+	}
+	method := reflect.ValueOf(dec).MethodByName
+	for _, field := range fields {
+		fv := rv.FieldByIndex(field.index)
+		column := reflect.ValueOf(field.column)
+		switch {
+		case field.structType != nil && field.isSlice:
+			dec.Slice(field.column, NewReflectCoder(fv.Addr().Interface()))
+		case field.structType != nil && field.isPtr:
+			ptr := reflect.New(field.structType)
+			coder := NewReflectCoder(ptr.Interface())
+			dec.Struct(field.column, coder)
+			if coder.decoded {
+				fv.Set(ptr)
+			}
+		case field.structType != nil:
+			dec.Struct(field.column, NewReflectCoder(fv.Addr().Interface()))
+		case field.isSlice:
+			slice := method(field.scalar.method + "s").Call([]reflect.Value{column})[0]
+			if slice.IsNil() {
+				fv.Set(reflect.Zero(fv.Type()))
+				continue
+			}
+			converted := reflect.MakeSlice(fv.Type(), slice.Len(), slice.Len())
+			for i := 0; i < slice.Len(); i++ {
+				converted.Index(i).Set(slice.Index(i).Convert(fv.Type().Elem()))
+			}
+			fv.Set(converted)
+		case field.isPtr:
+			ptr := method(field.scalar.method + "Ptr").Call([]reflect.Value{column})[0]
+			if ptr.IsNil() {
+				fv.Set(reflect.Zero(fv.Type()))
+				continue
+			}
+			converted := reflect.New(fv.Type().Elem())
+			converted.Elem().Set(ptr.Elem().Convert(fv.Type().Elem()))
+			fv.Set(converted)
+		default:
+			fv.Set(method(field.scalar.method).Call([]reflect.Value{column})[0].Convert(fv.Type()))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package rapidash
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+type reflectItem struct {
+	ID    uint64 `db:"id"`
+	Label string `db:"label"`
+}
+
+type reflectUserLogin struct {
+	ID        uint64         `db:"id" rapidash:"omitempty"`
+	UserID    uint64         `db:"user_id"`
+	Level     int8           `db:"level"`
+	Name      string         `db:"name"`
+	Score     *float64       `db:"score"`
+	Tags      []string       `db:"tags"`
+	Data      []byte         `db:"data"`
+	Item      reflectItem    `db:"item"`
+	Items     []*reflectItem `db:"items"`
+	CreatedAt *time.Time     `db:"created_at"`
+	Ignored   string
+}
+
+type reflectNamedTable struct {
+	ID uint64 `db:"id"`
+}
+
+func (*reflectNamedTable) TableName() string { return "named_tables" }
+
+func TestReflectCoder(t *testing.T) {
+	t.Run("struct of", func(t *testing.T) {
+		s, err := StructOf(&reflectUserLogin{})
+		NoError(t, err)
+		Equal(t, s.tableName, "reflect_user_logins")
+		Equal(t, s.Columns(), []string{"id", "user_id", "level", "name", "score", "tags", "data", "item", "items", "created_at"})
+		Equal(t, s.fields["level"].typ, Int8Type)
+		Equal(t, s.fields["tags"].subtype, StringType)
+		Equal(t, s.fields["items"].subtypeStruct.Columns(), []string{"id", "label"})
+
+		named, err := StructOf(reflectNamedTable{})
+		NoError(t, err)
+		Equal(t, named.tableName, "named_tables")
+
+		_, err = StructOf(&struct {
+			Values map[string]int `db:"values"`
+		}{})
+		Equal(t, xerrors.Is(err, ErrUnsupportedFieldType), true)
+		_, err = StructOf(1)
+		Equal(t, xerrors.Is(err, ErrInvalidReflectType), true)
+	})
+	t.Run("encode and decode", func(t *testing.T) {
+		s, err := StructOf(&reflectUserLogin{})
+		NoError(t, err)
+		score := 1.5
+		now := time.Unix(1600000000, 0)
+		src := &reflectUserLogin{
+			UserID:    2,
+			Level:     -1,
+			Name:      "rapidash",
+			Score:     &score,
+			Tags:      []string{"a", "b"},
+			Data:      []byte("data"),
+			Item:      reflectItem{ID: 3, Label: "item"},
+			Items:     []*reflectItem{{ID: 4}, {ID: 5}},
+			CreatedAt: &now,
+		}
+		enc := NewStructEncoder(s, NewValueFactory())
+		NoError(t, NewReflectCoder(src).EncodeRapidash(enc))
+		if _, exists := enc.value.fields["id"]; exists {
+			t.Fatal("zero value of omitempty field must not be encoded")
+		}
+		enc.Uint64("id", 1)
+
+		var dst reflectUserLogin
+		NoError(t, NewReflectCoder(&dst).DecodeRapidash(enc.value))
+		NoError(t, enc.value.Error())
+		src.ID = 1
+		Equal(t, dst, *src)
+
+		var dsts []*reflectUserLogin
+		values := NewStructSliceValue()
+		values.Append(enc.value)
+		NoError(t, NewReflectCoder(&dsts).DecodeRapidash(values))
+		Equal(t, len(dsts), 1)
+		Equal(t, *dsts[0], *src)
+	})
+}
@@ -0,0 +1,143 @@
+package rapidash
+
+import (
+	"sync"
+	"time"
+
+	"go.knocknote.io/rapidash/server"
+)
+
+// TxOperation is an operation performed in transaction.
+// It has the same information as log but can be used programmatically.
+type TxOperation struct {
+	Command SLCCommandType
+	Type    SLCType
+	Keys    []string
+	SQL     string
+	Args    interface{}
+	// Hit is true if value is found from stash or cache server by get command
+	Hit  bool
+	Time time.Time
+	// Duration is elapsed time from previous operation ( or beginning of transaction )
+	Duration time.Duration
+}
+
+type txReport struct {
+	mu         sync.Mutex
+	clock      Clock
+	lastTime   time.Time
+	operations []*TxOperation
+}
+
+func newTxReport(clock Clock) *txReport {
+	clock = clockOrDefault(clock)
+	return &txReport{
+		clock:      clock,
+		lastTime:   clock.Now(),
+		operations: []*TxOperation{},
+	}
+}
+
+func (r *txReport) add(op *TxOperation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.clock.Now()
+	op.Time = now
+	op.Duration = now.Sub(r.lastTime)
+	r.lastTime = now
+	r.operations = append(r.operations, op)
+}
+
+func (r *txReport) list() []*TxOperation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	operations := make([]*TxOperation, len(r.operations))
+	copy(operations, r.operations)
+	return operations
+}
+
+// reportLogger records operations to report of transaction in addition to logging them.
+// It is created for each transaction by Tx.logger, so global logger is never wrapped
+type reportLogger struct {
+	Logger
+	report *txReport
+}
+
+// logger returns logger of tx. It records operations if TxReportEnabled option is enabled
+func (tx *Tx) logger() Logger {
+	if tx.report == nil {
+		return log
+	}
+	return &reportLogger{Logger: log, report: tx.report}
+}
+
+// reportGet records get command that isn't logged ( e.g. cache miss ) to report
+func (tx *Tx) reportGet(typ SLCType, hit bool, keys ...server.CacheKey) {
+	if tx.report == nil {
+		return
+	}
+	keyStrs := make([]string, len(keys))
+	for idx, key := range keys {
+		keyStrs[idx] = key.String()
+	}
+	tx.report.add(&TxOperation{Command: SLCCommandGet, Type: typ, Keys: keyStrs, Hit: hit})
+}
+
+func (l *reportLogger) record(id string, op *TxOperation) {
+	l.report.add(op)
+}
+
+func (l *reportLogger) Add(id string, key server.CacheKey, value LogEncoder) {
+	l.record(id, &TxOperation{Command: SLCCommandAdd, Type: SLCServer, Keys: []string{key.String()}})
+	l.Logger.Add(id, key, value)
+}
+
+// Get is logged only if value is found, so it is recorded as hit
+func (l *reportLogger) Get(id string, typ SLCType, key server.CacheKey, value LogEncoder) {
+	l.record(id, &TxOperation{Command: SLCCommandGet, Type: typ, Keys: []string{key.String()}, Hit: true})
+	l.Logger.Get(id, typ, key, value)
+}
+
+func (l *reportLogger) GetFromDB(id, sql string, args interface{}, value LogEncoder) {
+	l.record(id, &TxOperation{Command: SLCCommandGet, Type: SLCDB, SQL: sql, Args: args})
+	l.Logger.GetFromDB(id, sql, args, value)
+}
+
+func (l *reportLogger) GetMulti(id string, typ SLCType, keys []server.CacheKey, value LogEncoder) {
+	keyStrs := make([]string, len(keys))
+	for idx, key := range keys {
+		keyStrs[idx] = key.String()
+	}
+	l.record(id, &TxOperation{Command: SLCCommandGetMulti, Type: typ, Keys: keyStrs})
+	l.Logger.GetMulti(id, typ, keys, value)
+}
+
+func (l *reportLogger) Set(id string, typ SLCType, key server.CacheKey, value LogEncoder) {
+	l.record(id, &TxOperation{Command: SLCCommandSet, Type: typ, Keys: []string{key.String()}})
+	l.Logger.Set(id, typ, key, value)
+}
+
+func (l *reportLogger) InsertIntoDB(id, sql string, args interface{}, value LogEncoder) {
+	l.record(id, &TxOperation{Command: SLCCommandSet, Type: SLCDB, SQL: sql, Args: args})
+	l.Logger.InsertIntoDB(id, sql, args, value)
+}
+
+func (l *reportLogger) Update(id string, typ SLCType, key server.CacheKey, value LogEncoder) {
+	l.record(id, &TxOperation{Command: SLCCommandUpdate, Type: typ, Keys: []string{key.String()}})
+	l.Logger.Update(id, typ, key, value)
+}
+
+func (l *reportLogger) UpdateForDB(id, sql string, args interface{}, value LogEncoder) {
+	l.record(id, &TxOperation{Command: SLCCommandUpdate, Type: SLCDB, SQL: sql, Args: args})
+	l.Logger.UpdateForDB(id, sql, args, value)
+}
+
+func (l *reportLogger) Delete(id string, typ SLCType, key server.CacheKey) {
+	l.record(id, &TxOperation{Command: SLCCommandDelete, Type: typ, Keys: []string{key.String()}})
+	l.Logger.Delete(id, typ, key)
+}
+
+func (l *reportLogger) DeleteFromDB(id, sql string) {
+	l.record(id, &TxOperation{Command: SLCCommandDelete, Type: SLCDB, SQL: sql})
+	l.Logger.DeleteFromDB(id, sql)
+}
@@ -0,0 +1,106 @@
+package rapidash
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+// backgroundTimeout is timeout of revalidation and shadow read.
+// They run after the request returns, so context of the request can't be used
+const backgroundTimeout = 30 * time.Second
+
+// shouldRevalidate returns true if cached content is older than RevalidateAfter
+func (c *SecondLevelCache) shouldRevalidate(content []byte) bool {
+	revalidateAfter := c.opt.RevalidateAfter()
+	if revalidateAfter <= 0 || c.db == nil {
+		return false
+	}
+	stampedAt, ok := payloadStampedAt(content)
+	if !ok {
+		return false
+	}
+	return c.opt.now().Sub(stampedAt) >= revalidateAfter
+}
+
+// revalidate re-reads value from database in background and refreshes cache by CAS.
+// Only one revalidation runs for each key at the same time
+func (c *SecondLevelCache) revalidate(key server.CacheKey, value *StructValue, casID uint64) {
+	keyStr := key.String()
+	if _, loaded := c.revalidatingKeys.LoadOrStore(keyStr, struct{}{}); loaded {
+		return
+	}
+	builder := NewQueryBuilder(c.typ.tableName)
+	for _, column := range c.primaryKey.Columns {
+		builder.Eq(column, value.fields[column].RawValue())
+	}
+	go func() {
+		defer c.revalidatingKeys.Delete(keyStr)
+		defer c.evictLocalCache(key)
+		ctx, cancel := context.WithTimeout(context.Background(), backgroundTimeout)
+		defer cancel()
+		if err := c.refreshValue(ctx, key, builder, casID); err != nil {
+			log.Warn(fmt.Sprintf("failed to revalidate %s: %+v", keyStr, err))
+		}
+	}()
+}
+
+func (c *SecondLevelCache) refreshValue(ctx context.Context, key server.CacheKey, builder *QueryBuilder, casID uint64) (e error) {
+	sql, args := builder.SelectSQL(c.valueFactory, c.typ)
+	rows, err := c.db.QueryContext(ctx, c.fallbackQuery(sql), args...)
+	if err != nil {
+		return xerrors.Errorf("failed sql %s %v: %w", sql, args, err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			e = xerrors.Errorf("failed to close rows: %w", err)
+		}
+	}()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return xerrors.Errorf("failed to read rows: %w", err)
+		}
+		if err := c.cacheServer.Delete(ctx, key); err != nil && !IsCacheMiss(err) {
+			return xerrors.Errorf("failed to delete cache of deleted record: %w", err)
+		}
+		return nil
+	}
+	scanValues := c.typ.ScanValues(c.valueFactory)
+	if err := rows.Scan(scanValues...); err != nil {
+		return xerrors.Errorf("failed to scan: %w", err)
+	}
+	value := c.typ.StructValue(scanValues)
+	encoded, err := c.encodeValue(value)
+	if err != nil {
+		return xerrors.Errorf("failed to encode value: %w", err)
+	}
+	payload, err := c.encodePayload(encoded)
+	if err != nil {
+		return xerrors.Errorf("failed to encode payload: %w", err)
+	}
+	expiration, expired := c.expirationByValue(value)
+	if expired {
+		if err := c.cacheServer.Delete(ctx, key); err != nil && !IsCacheMiss(err) {
+			return xerrors.Errorf("failed to delete expired cache: %w", err)
+		}
+		return nil
+	}
+	if err := c.cacheServer.Set(ctx, &server.CacheStoreRequest{
+		Key:        key,
+		Value:      payload,
+		Expiration: expiration,
+		CasID:      casID,
+	}); err != nil {
+		if xerrors.Is(err, server.ErrMemcacheCASConflict) ||
+			xerrors.Is(err, server.ErrMemcacheNotStored) ||
+			xerrors.Is(err, server.ErrRedisCASConflict) {
+			// value is already updated by other process
+			return nil
+		}
+		return xerrors.Errorf("failed to set cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package rapidash
+
+import (
+	"bytes"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestRevalidateAfter(t *testing.T) {
+	content := []byte{0x92, 0x01, 0x02}
+	clock := &testClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	r, err := New(
+		RegisterPayloadCodec(1, reverseCodec{}),
+		PreferredPayloadCodec(1),
+		SecondLevelCacheTableRevalidateAfter("user_logins", time.Minute),
+	)
+	NoError(t, err)
+	opt := r.tableOption("user_logins")
+	opt.clock = clock
+	slc := NewSecondLevelCache(userLoginType(), nil, opt)
+	slc.db = &sql.DB{}
+	t.Run("stamp payload", func(t *testing.T) {
+		payload, err := slc.encodePayload(content)
+		NoError(t, err)
+		stampedAt, ok := payloadStampedAt(payload)
+		Equal(t, ok, true)
+		Equal(t, stampedAt.Equal(clock.Now()), true)
+		decoded, err := slc.decodePayload(payload)
+		NoError(t, err)
+		if !bytes.Equal(decoded, content) {
+			t.Fatalf("failed to decode stamped payload: %v", decoded)
+		}
+	})
+	t.Run("revalidate old value", func(t *testing.T) {
+		payload, err := slc.encodePayload(content)
+		NoError(t, err)
+		Equal(t, slc.shouldRevalidate(payload), false)
+		clock.advance(time.Minute)
+		Equal(t, slc.shouldRevalidate(payload), true)
+	})
+	t.Run("value without stamp", func(t *testing.T) {
+		Equal(t, slc.shouldRevalidate(content), false)
+	})
+}
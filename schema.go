@@ -0,0 +1,309 @@
+package rapidash
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+type SchemaMismatchType int
+
+const (
+	// SchemaMismatchMissingTable means table of Struct is not found in database
+	SchemaMismatchMissingTable SchemaMismatchType = iota
+	// SchemaMismatchMissingColumn means column of Struct is not found in database
+	SchemaMismatchMissingColumn
+	// SchemaMismatchColumnType means type of Struct field cannot hold column type safely
+	SchemaMismatchColumnType
+	// SchemaMismatchUnknownColumn means column exists in database but not defined in Struct
+	SchemaMismatchUnknownColumn
+)
+
+func (t SchemaMismatchType) String() string {
+	switch t {
+	case SchemaMismatchMissingTable:
+		return "missing table"
+	case SchemaMismatchMissingColumn:
+		return "missing column"
+	case SchemaMismatchColumnType:
+		return "type mismatch"
+	case SchemaMismatchUnknownColumn:
+		return "unknown column"
+	}
+	return "unknown"
+}
+
+type SchemaMismatch struct {
+	Table    string
+	Column   string
+	Type     SchemaMismatchType
+	Expected string
+	Actual   string
+}
+
+func (m *SchemaMismatch) String() string {
+	switch m.Type {
+	case SchemaMismatchMissingTable:
+		return fmt.Sprintf("%s: %s", m.Table, m.Type)
+	case SchemaMismatchColumnType:
+		return fmt.Sprintf("%s.%s: %s. Struct field is %s but column is %s", m.Table, m.Column, m.Type, m.Expected, m.Actual)
+	}
+	return fmt.Sprintf("%s.%s: %s", m.Table, m.Column, m.Type)
+}
+
+// SchemaDriftError reports all differences between Struct definition and the live table
+type SchemaDriftError struct {
+	Table      string
+	Mismatches []*SchemaMismatch
+}
+
+func (e *SchemaDriftError) Error() string {
+	reports := make([]string, 0, len(e.Mismatches))
+	for _, mismatch := range e.Mismatches {
+		reports = append(reports, mismatch.String())
+	}
+	return fmt.Sprintf("%s: %s", ErrSchemaDrift, strings.Join(reports, ", "))
+}
+
+func (e *SchemaDriftError) Unwrap() error {
+	return ErrSchemaDrift
+}
+
+func (e *SchemaDriftError) columns(typ SchemaMismatchType) []string {
+	columns := []string{}
+	for _, mismatch := range e.Mismatches {
+		if mismatch.Type == typ {
+			columns = append(columns, mismatch.Column)
+		}
+	}
+	return columns
+}
+
+// MissingColumns returns columns defined in Struct but not found in table
+func (e *SchemaDriftError) MissingColumns() []string {
+	return e.columns(SchemaMismatchMissingColumn)
+}
+
+// ExtraColumns returns columns found in table but not defined in Struct
+func (e *SchemaDriftError) ExtraColumns() []string {
+	return e.columns(SchemaMismatchUnknownColumn)
+}
+
+// TypeMismatches returns fields whose type cannot hold column type
+func (e *SchemaDriftError) TypeMismatches() []*SchemaMismatch {
+	mismatches := []*SchemaMismatch{}
+	for _, mismatch := range e.Mismatches {
+		if mismatch.Type == SchemaMismatchColumnType {
+			mismatches = append(mismatches, mismatch)
+		}
+	}
+	return mismatches
+}
+
+type columnSchema struct {
+	name       string
+	dataType   string
+	columnType string
+}
+
+func (c *columnSchema) isUnsigned() bool {
+	return strings.Contains(c.columnType, "unsigned")
+}
+
+var (
+	intColumnSizes = map[string]int{
+		"tinyint":   8,
+		"smallint":  16,
+		"mediumint": 24,
+		"int":       32,
+		"integer":   32,
+		"bigint":    64,
+	}
+	stringDataTypes = map[string]struct{}{
+		"char": {}, "varchar": {}, "tinytext": {}, "text": {}, "mediumtext": {}, "longtext": {},
+		"enum": {}, "set": {}, "json": {}, "decimal": {},
+	}
+	bytesDataTypes = map[string]struct{}{
+		"binary": {}, "varbinary": {}, "tinyblob": {}, "blob": {}, "mediumblob": {}, "longblob": {}, "bit": {},
+	}
+	timeDataTypes = map[string]struct{}{
+		"date": {}, "datetime": {}, "timestamp": {},
+	}
+)
+
+func isCompatibleIntColumn(column *columnSchema, size int, isUnsigned bool) bool {
+	columnSize, exists := intColumnSizes[column.dataType]
+	if !exists {
+		return false
+	}
+	if column.isUnsigned() == isUnsigned {
+		return columnSize <= size
+	}
+	// unsigned column fits into wider signed field ( e.g. int unsigned into int64 ), but signed column never fits into unsigned field
+	return !isUnsigned && columnSize < size
+}
+
+// isCompatibleColumn reports whether field can hold every value of column without overflow or truncation
+func isCompatibleColumn(field *StructField, column *columnSchema) bool {
+	switch field.typ {
+	case IntType, Int64Type:
+		return isCompatibleIntColumn(column, 64, false)
+	case Int8Type:
+		return isCompatibleIntColumn(column, 8, false)
+	case Int16Type:
+		return isCompatibleIntColumn(column, 16, false)
+	case Int32Type:
+		return isCompatibleIntColumn(column, 32, false)
+	case UintType, Uint64Type:
+		return isCompatibleIntColumn(column, 64, true)
+	case Uint8Type:
+		return isCompatibleIntColumn(column, 8, true)
+	case Uint16Type:
+		return isCompatibleIntColumn(column, 16, true)
+	case Uint32Type:
+		return isCompatibleIntColumn(column, 32, true)
+	case Float32Type:
+		return column.dataType == "float"
+	case Float64Type:
+		return column.dataType == "float" || column.dataType == "double" || column.dataType == "decimal"
+	case BoolType:
+		return column.dataType == "tinyint" || column.dataType == "bit"
+	case StringType:
+		_, exists := stringDataTypes[column.dataType]
+		return exists
+	case BytesType:
+		if _, exists := bytesDataTypes[column.dataType]; exists {
+			return true
+		}
+		_, exists := stringDataTypes[column.dataType]
+		return exists
+	case TimeType:
+		_, exists := timeDataTypes[column.dataType]
+		return exists
+	}
+	// slice and struct fields are encoded by application
+	return true
+}
+
+func showColumns(conn *sql.DB, tableName string) ([]*columnSchema, error) {
+	rows, err := conn.Query(
+		"SELECT COLUMN_NAME, DATA_TYPE, COLUMN_TYPE FROM information_schema.COLUMNS "+
+			"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION",
+		tableName,
+	)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get columns of %s: %w", tableName, err)
+	}
+	defer rows.Close()
+	columns := []*columnSchema{}
+	for rows.Next() {
+		var column columnSchema
+		if err := rows.Scan(&column.name, &column.dataType, &column.columnType); err != nil {
+			return nil, xerrors.Errorf("failed to scan column of %s: %w", tableName, err)
+		}
+		column.dataType = strings.ToLower(column.dataType)
+		column.columnType = strings.ToLower(column.columnType)
+		columns = append(columns, &column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, xerrors.Errorf("failed to get columns of %s: %w", tableName, err)
+	}
+	return columns, nil
+}
+
+// fieldMismatches reports fields of typ that are missing in columns or cannot hold values of the column
+func fieldMismatches(typ *Struct, columns []*columnSchema) []*SchemaMismatch {
+	columnMap := map[string]*columnSchema{}
+	for _, column := range columns {
+		columnMap[column.name] = column
+	}
+	mismatches := []*SchemaMismatch{}
+	for _, field := range typ.sortedFields() {
+		column, exists := columnMap[field.column]
+		if !exists {
+			mismatches = append(mismatches, &SchemaMismatch{
+				Table:    typ.tableName,
+				Column:   field.column,
+				Type:     SchemaMismatchMissingColumn,
+				Expected: field.typ.String(),
+			})
+			continue
+		}
+		if !isCompatibleColumn(field, column) {
+			mismatches = append(mismatches, &SchemaMismatch{
+				Table:    typ.tableName,
+				Column:   field.column,
+				Type:     SchemaMismatchColumnType,
+				Expected: field.typ.String(),
+				Actual:   column.columnType,
+			})
+		}
+	}
+	return mismatches
+}
+
+// ValidateStructSchema compares columns of typ with the live schema
+func ValidateStructSchema(conn *sql.DB, typ *Struct) ([]*SchemaMismatch, error) {
+	columns, err := showColumns(conn, typ.tableName)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to show columns: %w", err)
+	}
+	if len(columns) == 0 {
+		return []*SchemaMismatch{{Table: typ.tableName, Type: SchemaMismatchMissingTable}}, nil
+	}
+	mismatches := fieldMismatches(typ, columns)
+	for _, column := range columns {
+		if _, exists := typ.fields[column.name]; exists {
+			continue
+		}
+		mismatches = append(mismatches, &SchemaMismatch{
+			Table:  typ.tableName,
+			Column: column.name,
+			Type:   SchemaMismatchUnknownColumn,
+			Actual: column.columnType,
+		})
+	}
+	return mismatches, nil
+}
+
+// ValidateSchema compares Struct of table with the live schema and returns *SchemaDriftError if they differ
+func (c *SecondLevelCache) ValidateSchema(conn *sql.DB) error {
+	mismatches, err := ValidateStructSchema(conn, c.typ)
+	if err != nil {
+		return xerrors.Errorf("failed to validate schema of %s: %w", c.typ.tableName, err)
+	}
+	if len(mismatches) == 0 {
+		return nil
+	}
+	return &SchemaDriftError{Table: c.typ.tableName, Mismatches: mismatches}
+}
+
+// ValidateSchema compares every warmed up Struct with the live schema
+func (r *Rapidash) ValidateSchema(conn *sql.DB) ([]*SchemaMismatch, error) {
+	typeMap := map[string]*Struct{}
+	r.firstLevelCaches.Range(func(key, value interface{}) bool {
+		typeMap[key.(string)] = value.(*FirstLevelCache).typ
+		return true
+	})
+	r.secondLevelCaches.Range(func(key, value interface{}) bool {
+		typeMap[key.(string)] = value.(*SecondLevelCache).typ
+		return true
+	})
+	tableNames := make([]string, 0, len(typeMap))
+	for tableName := range typeMap {
+		tableNames = append(tableNames, tableName)
+	}
+	sort.Strings(tableNames)
+	mismatches := []*SchemaMismatch{}
+	for _, tableName := range tableNames {
+		found, err := ValidateStructSchema(conn, typeMap[tableName])
+		if err != nil {
+			return nil, xerrors.Errorf("failed to validate schema of %s: %w", tableName, err)
+		}
+		mismatches = append(mismatches, found...)
+	}
+	return mismatches, nil
+}
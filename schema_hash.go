@@ -0,0 +1,93 @@
+package rapidash
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+
+	"golang.org/x/xerrors"
+)
+
+// schemaHash returns hash of Struct fields and column definitions of ddl.
+// Options of table ( e.g. AUTO_INCREMENT ) are ignored, so the hash changes only if layout of columns changes
+func schemaHash(typ *Struct, ddl string) (string, error) {
+	columns, err := columnSchemasFromDDL(ddl)
+	if err != nil {
+		return "", xerrors.Errorf("failed to get columns: %w", err)
+	}
+	h := fnv.New32a()
+	writeStructSchema(h, typ)
+	for _, column := range columns {
+		fmt.Fprintf(h, "%s %s;", column.name, column.columnType)
+	}
+	return fmt.Sprintf("%08x", h.Sum32()), nil
+}
+
+func writeStructSchema(w io.Writer, typ *Struct) {
+	writeStructSchemaWithParents(w, typ, nil)
+}
+
+// writeStructSchemaWithParents writes reference to parent like {^1} instead of recursing into it,
+// because Struct defined by FieldSelfStruct contains itself
+func writeStructSchemaWithParents(w io.Writer, typ *Struct, parents []*Struct) {
+	parents = append(parents, typ)
+	for _, field := range typ.sortedFields() {
+		fmt.Fprintf(w, "%s:%s", field.column, field.typ)
+		if field.typ == SliceType {
+			fmt.Fprintf(w, "<%s>", field.subtype)
+		}
+		if field.subtypeStruct != nil {
+			io.WriteString(w, "{")
+			if depth := structDepth(parents, field.subtypeStruct); depth > 0 {
+				fmt.Fprintf(w, "^%d", depth)
+			} else {
+				writeStructSchemaWithParents(w, field.subtypeStruct, parents)
+			}
+			io.WriteString(w, "}")
+		}
+		io.WriteString(w, ";")
+	}
+	io.WriteString(w, "|")
+}
+
+// structDepth returns distance from the last of parents to typ. 0 if typ isn't contained in parents
+func structDepth(parents []*Struct, typ *Struct) int {
+	for i := len(parents) - 1; i >= 0; i-- {
+		if parents[i] == typ {
+			return len(parents) - i
+		}
+	}
+	return 0
+}
+
+// setupSchemaHash puts hash of schema into cache keys of all indexes,
+// so values cached by other Struct definition or columns are never read
+func (c *SecondLevelCache) setupSchemaHash(ddl string) error {
+	hash, err := schemaHash(c.typ, ddl)
+	if err != nil {
+		return xerrors.Errorf("failed to get schema hash of %s: %w", c.typ.tableName, err)
+	}
+	c.schemaHash = hash
+	for _, index := range c.indexes {
+		index.schemaHash = hash
+	}
+	if c.primaryKey != nil {
+		c.primaryKey.schemaHash = hash
+	}
+	return nil
+}
+
+// keyTableName returns table part of cache keys like user_logins or user_logins/1a2b3c4d with schema hash
+func (c *SecondLevelCache) keyTableName() string {
+	if c.schemaHash == "" {
+		return c.typ.tableName
+	}
+	return c.typ.tableName + "/" + c.schemaHash
+}
+
+func (i *Index) keyTableName() string {
+	if i.schemaHash == "" {
+		return i.Table
+	}
+	return i.Table + "/" + i.schemaHash
+}
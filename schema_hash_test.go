@@ -0,0 +1,80 @@
+package rapidash
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSchemaHashKey(t *testing.T) {
+	ddl := func(nameType string) string {
+		return "CREATE TABLE `user_logins` (" +
+			"`id` bigint(20) unsigned NOT NULL AUTO_INCREMENT," +
+			"`user_id` bigint(20) unsigned NOT NULL," +
+			"`user_session_id` bigint(20) unsigned NOT NULL," +
+			"`login_param_id` bigint(20) unsigned NOT NULL," +
+			"`name` " + nameType + " NOT NULL," +
+			"`created_at` datetime NOT NULL," +
+			"`updated_at` datetime NOT NULL," +
+			"PRIMARY KEY (`id`)," +
+			"KEY `idx_user_id` (`user_id`)" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"
+	}
+	t.Run("hash", func(t *testing.T) {
+		hash, err := schemaHash(userLoginType(), ddl("varchar(255)"))
+		NoError(t, err)
+		Equal(t, len(hash), 8)
+		sameHash, err := schemaHash(userLoginType(), strings.Replace(ddl("varchar(255)"), "InnoDB", "InnoDB AUTO_INCREMENT=100", 1))
+		NoError(t, err)
+		Equal(t, sameHash, hash)
+		alteredHash, err := schemaHash(userLoginType(), ddl("varchar(64)"))
+		NoError(t, err)
+		if alteredHash == hash {
+			t.Fatal("hash must be changed by ALTER TABLE")
+		}
+		addedHash, err := schemaHash(userLoginType().FieldString("password"), ddl("varchar(255)"))
+		NoError(t, err)
+		if addedHash == hash {
+			t.Fatal("hash must be changed by Struct definition")
+		}
+	})
+	t.Run("self struct", func(t *testing.T) {
+		hash, err := schemaHash(userLoginType().FieldSelfStruct("parent"), ddl("varchar(255)"))
+		NoError(t, err)
+		sliceHash, err := schemaHash(userLoginType().FieldSelfStructSlice("parent"), ddl("varchar(255)"))
+		NoError(t, err)
+		if sliceHash == hash {
+			t.Fatal("hash must be changed by type of self struct field")
+		}
+	})
+	t.Run("cache key", func(t *testing.T) {
+		r, err := New(SecondLevelCacheTableSchemaHashKey("user_logins", true))
+		NoError(t, err)
+		opt := r.tableOption("user_logins")
+		Equal(t, opt.SchemaHashKey(), true)
+		slc := NewSecondLevelCache(userLoginType(), newMemoryCacheServer(), opt)
+		NoError(t, slc.setupIndexes(ddl("varchar(255)")))
+		NoError(t, slc.setupSchemaHash(ddl("varchar(255)")))
+		hash, err := schemaHash(userLoginType(), ddl("varchar(255)"))
+		NoError(t, err)
+
+		_, value, err := slc.encode(&UserLogin{ID: 1, UserID: 2})
+		NoError(t, err)
+		key, err := slc.primaryKey.CacheKey(value)
+		NoError(t, err)
+		Equal(t, key.String(), "r/slc/user_logins/"+hash+"/id#1")
+		key, err = slc.indexes["user_id"].CacheKey(value)
+		NoError(t, err)
+		Equal(t, key.String(), "r/slc/user_logins/"+hash+"/idx/user_id#2")
+		Equal(t, slc.WithTableName("user_logins_1").keyTableName(), "user_logins_1/"+hash)
+	})
+	t.Run("config", func(t *testing.T) {
+		enabled := true
+		cfg := &TableConfig{SchemaHashKey: &enabled}
+		r, err := New(cfg.Options("user_logins")...)
+		NoError(t, err)
+		opt := r.tableOption("user_logins")
+		Equal(t, opt.SchemaHashKey(), true)
+		other := r.tableOption("users")
+		Equal(t, other.SchemaHashKey(), false)
+	})
+}
@@ -0,0 +1,108 @@
+package rapidash
+
+import (
+	"testing"
+
+	"golang.org/x/xerrors"
+)
+
+func TestIsCompatibleColumn(t *testing.T) {
+	unsignedBigint := &columnSchema{name: "id", dataType: "bigint", columnType: "bigint(20) unsigned"}
+	signedInt := &columnSchema{name: "id", dataType: "int", columnType: "int(11)"}
+	Equal(t, isCompatibleColumn(&StructField{typ: Uint64Type}, unsignedBigint), true)
+	Equal(t, isCompatibleColumn(&StructField{typ: Int64Type}, unsignedBigint), false)
+	Equal(t, isCompatibleColumn(&StructField{typ: Uint64Type}, signedInt), false)
+	Equal(t, isCompatibleColumn(&StructField{typ: Int64Type}, signedInt), true)
+	Equal(t, isCompatibleColumn(&StructField{typ: Int16Type}, signedInt), false)
+	Equal(t, isCompatibleColumn(&StructField{typ: StringType}, &columnSchema{dataType: "varchar"}), true)
+	Equal(t, isCompatibleColumn(&StructField{typ: TimeType}, &columnSchema{dataType: "varchar"}), false)
+}
+
+func TestValidateStructSchema(t *testing.T) {
+	NoError(t, initUserLoginTable(conn))
+	t.Run("valid", func(t *testing.T) {
+		mismatches, err := ValidateStructSchema(conn, userLoginType())
+		NoError(t, err)
+		Equal(t, len(mismatches), 0)
+	})
+	t.Run("drift", func(t *testing.T) {
+		typ := NewStruct("user_logins").
+			FieldInt64("id").
+			FieldUint64("user_id").
+			FieldUint64("user_session_id").
+			FieldUint64("login_param_id").
+			FieldTime("created_at").
+			FieldTime("updated_at").
+			FieldString("password")
+		mismatches, err := ValidateStructSchema(conn, typ)
+		NoError(t, err)
+		messages := []string{}
+		for _, mismatch := range mismatches {
+			messages = append(messages, mismatch.String())
+		}
+		Equal(t, messages, []string{
+			"user_logins.id: type mismatch. Struct field is int64 but column is bigint(20) unsigned",
+			"user_logins.password: missing column",
+			"user_logins.name: unknown column",
+		})
+	})
+	t.Run("missing table", func(t *testing.T) {
+		mismatches, err := ValidateStructSchema(conn, NewStruct("unknown_table").FieldUint64("id"))
+		NoError(t, err)
+		Equal(t, len(mismatches), 1)
+		Equal(t, mismatches[0].Type, SchemaMismatchMissingTable)
+	})
+}
+
+func TestSchemaDriftError(t *testing.T) {
+	var err error = &SchemaDriftError{
+		Table: "user_logins",
+		Mismatches: []*SchemaMismatch{
+			{Table: "user_logins", Column: "id", Type: SchemaMismatchColumnType, Expected: "int64", Actual: "bigint(20) unsigned"},
+			{Table: "user_logins", Column: "password", Type: SchemaMismatchMissingColumn, Expected: "string"},
+			{Table: "user_logins", Column: "name", Type: SchemaMismatchUnknownColumn, Actual: "varchar(255)"},
+		},
+	}
+	err = xerrors.Errorf("failed to warm up: %w", err)
+	Equal(t, xerrors.Is(err, ErrSchemaDrift), true)
+	var drift *SchemaDriftError
+	Equal(t, xerrors.As(err, &drift), true)
+	Equal(t, drift.MissingColumns(), []string{"password"})
+	Equal(t, drift.ExtraColumns(), []string{"name"})
+	Equal(t, len(drift.TypeMismatches()), 1)
+	Equal(t, drift.TypeMismatches()[0].Column, "id")
+	Equal(t, drift.Error(), "Struct definition differs from table schema: "+
+		"user_logins.id: type mismatch. Struct field is int64 but column is bigint(20) unsigned, "+
+		"user_logins.password: missing column, "+
+		"user_logins.name: unknown column")
+}
+
+func TestSecondLevelCacheValidateSchema(t *testing.T) {
+	NoError(t, initUserLoginTable(conn))
+	r, err := New(SecondLevelCacheTableValidateSchema("user_logins", true))
+	NoError(t, err)
+	opt := r.tableOption("user_logins")
+	t.Run("valid", func(t *testing.T) {
+		slc := NewSecondLevelCache(userLoginType(), newMemoryCacheServer(), opt)
+		NoError(t, slc.ValidateSchema(conn))
+		NoError(t, slc.WarmUp(conn))
+	})
+	t.Run("drift", func(t *testing.T) {
+		slc := NewSecondLevelCache(userLoginType().FieldString("password"), newMemoryCacheServer(), opt)
+		err := slc.WarmUp(conn)
+		Error(t, err)
+		var drift *SchemaDriftError
+		Equal(t, xerrors.As(err, &drift), true)
+		Equal(t, drift.MissingColumns(), []string{"password"})
+	})
+	t.Run("config", func(t *testing.T) {
+		enabled := true
+		cfg := &TableConfig{ValidateSchema: &enabled}
+		r, err := New(cfg.Options("user_logins")...)
+		NoError(t, err)
+		opt := r.tableOption("user_logins")
+		Equal(t, opt.ValidateSchema(), true)
+		other := r.tableOption("users")
+		Equal(t, other.ValidateSchema(), false)
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/blastrain/msgpack"
@@ -36,36 +37,71 @@ func NewSecondLevelCacheMap() *SecondLevelCacheMap {
 }
 
 type SecondLevelCache struct {
-	typ                   *Struct
-	opt                   *TableOption
-	indexes               map[string]*Index
-	primaryKey            *Index
-	indexColumns          map[string]struct{}
-	cacheServer           server.CacheServer
-	valueDecoderPool      sync.Pool
-	primaryKeyDecoderPool sync.Pool
-	valueFactory          *ValueFactory
+	typ                    *Struct
+	opt                    *TableOption
+	indexes                map[string]*Index
+	primaryKey             *Index
+	indexColumns           map[string]struct{}
+	cacheServer            server.CacheServer
+	valueDecoderPool       sync.Pool
+	primaryKeyDecoderPool  sync.Pool
+	valueFactory           *ValueFactory
+	keyRegistry            *KeyRegistry
+	readRepairCount        uint64
+	missingPrimaryKeyCount uint64
+	indexRepairCount       uint64
+	indexStats             map[string]*indexStats
+	excludedIndexes        map[string]struct{}
+	schemaHash             string
+	db                     *sql.DB
+	revalidatingKeys       sync.Map
+	cacheOnly              bool
+	shadowReads            shadowReadCounter
+	ttlTuner               *ttlTuner
+	decoderStats           poolCounter
+	localCache             *localCache
 }
 
 type TxValue struct {
-	id   string
-	key  string
-	time time.Time
+	id       string
+	key      string
+	time     time.Time
+	hostname string
+	pid      int
+	owner    string
 }
 
+// Marshal encodes owner metadata ( hostname, pid, owner ) after id, key and time,
+// so that older versions reading only id, key and time can still decode it
 func (v *TxValue) Marshal() ([]byte, error) {
 	var buf bytes.Buffer
 	enc := msgpack.NewEncoder(&buf)
+	if err := v.encodeLockRecord(enc); err != nil {
+		return nil, xerrors.Errorf("failed to encode lock record: %w", err)
+	}
+	if err := enc.EncodeString(v.hostname); err != nil {
+		return nil, xerrors.Errorf("failed to encode tx.hostname: %w", err)
+	}
+	if err := enc.EncodeInt(v.pid); err != nil {
+		return nil, xerrors.Errorf("failed to encode tx.pid: %w", err)
+	}
+	if err := enc.EncodeString(v.owner); err != nil {
+		return nil, xerrors.Errorf("failed to encode tx.owner: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (v *TxValue) encodeLockRecord(enc *msgpack.Encoder) error {
 	if err := enc.EncodeString(v.id); err != nil {
-		return nil, xerrors.Errorf("failed to encode tx.id: %w", err)
+		return xerrors.Errorf("failed to encode tx.id: %w", err)
 	}
 	if err := enc.EncodeString(v.key); err != nil {
-		return nil, xerrors.Errorf("failed to encode tx.key: %w", err)
+		return xerrors.Errorf("failed to encode tx.key: %w", err)
 	}
 	if err := enc.EncodeTime(v.time); err != nil {
-		return nil, xerrors.Errorf("failed to encode tx.time: %w", err)
+		return xerrors.Errorf("failed to encode tx.time: %w", err)
 	}
-	return buf.Bytes(), nil
+	return nil
 }
 
 func (v *TxValue) Unmarshal(content []byte) error {
@@ -80,11 +116,31 @@ func (v *TxValue) Unmarshal(content []byte) error {
 	if err := dec.DecodeTime(&v.time); err != nil {
 		return xerrors.Errorf("failed to decode tx.time: %w", err)
 	}
+	// decoder reads ahead content, so size of id, key and time is calculated by encoding them again
+	var recordBuf bytes.Buffer
+	if err := v.encodeLockRecord(msgpack.NewEncoder(&recordBuf)); err != nil {
+		return xerrors.Errorf("failed to encode lock record: %w", err)
+	}
+	if len(content) <= recordBuf.Len() {
+		// lock record of older version doesn't have owner metadata
+		return nil
+	}
+	dec = msgpack.NewDecoder(bytes.NewBuffer(content[recordBuf.Len():]))
+	if err := dec.DecodeString(&v.hostname); err != nil {
+		return xerrors.Errorf("failed to decode tx.hostname: %w", err)
+	}
+	if err := dec.DecodeInt(&v.pid); err != nil {
+		return xerrors.Errorf("failed to decode tx.pid: %w", err)
+	}
+	if err := dec.DecodeString(&v.owner); err != nil {
+		return xerrors.Errorf("failed to decode tx.owner: %w", err)
+	}
 	return nil
 }
 
 func (v *TxValue) String() string {
-	return fmt.Sprintf(`{ "id": %s, "key": %s, "time": %s }`, v.id, v.key, v.time)
+	return fmt.Sprintf(`{ "id": %s, "key": %s, "time": %s, "hostname": %s, "pid": %d, "owner": %s }`,
+		v.id, v.key, v.time, v.hostname, v.pid, v.owner)
 }
 
 func (v *TxValue) EncodeLog() string {
@@ -93,31 +149,48 @@ func (v *TxValue) EncodeLog() string {
 
 func NewSecondLevelCache(s *Struct, server server.CacheServer, opt TableOption) *SecondLevelCache {
 	valueFactory := NewValueFactory()
-	return &SecondLevelCache{
-		typ:          s,
+	var keyRegistry *KeyRegistry
+	if size := opt.KeyRegistrySize(); size > 0 {
+		keyRegistry = NewKeyRegistry(size)
+	}
+	var tuner *ttlTuner
+	if tuning := opt.TTLTuning(); tuning != nil {
+		tuner = newTTLTuner(*tuning, opt.Expiration(), opt.now())
+	}
+	var local *localCache
+	if size := opt.LocalCache(); size != nil {
+		local = newLocalCache(*size, opt.clock)
+	}
+	c := &SecondLevelCache{
+		typ:          s.withColumnCipher(opt.columnCipher),
 		opt:          &opt,
 		cacheServer:  server,
 		indexes:      map[string]*Index{},
 		indexColumns: map[string]struct{}{},
-		valueDecoderPool: sync.Pool{
-			New: func() interface{} {
-				return NewDecoder(s, &bytes.Buffer{}, valueFactory)
-			},
-		},
 		primaryKeyDecoderPool: sync.Pool{
 			New: func() interface{} {
 				return NewPrimaryKeyDecoder(&bytes.Buffer{})
 			},
 		},
 		valueFactory: valueFactory,
+		keyRegistry:  keyRegistry,
+		ttlTuner:     tuner,
+		localCache:   local,
+	}
+	c.valueDecoderPool.New = func() interface{} {
+		c.decoderStats.alloc()
+		return NewDecoder(s, &bytes.Buffer{}, valueFactory)
 	}
+	return c
 }
 
 func (c *SecondLevelCache) valueDecoder() *ValueDecoder {
+	c.decoderStats.get()
 	return c.valueDecoderPool.Get().(*ValueDecoder)
 }
 
 func (c *SecondLevelCache) releaseValueDecoder(decoder *ValueDecoder) {
+	c.decoderStats.put()
 	c.valueDecoderPool.Put(decoder)
 }
 
@@ -130,15 +203,63 @@ func (c *SecondLevelCache) releasePrimaryKeyDecoder(decoder *PrimaryKeyDecoder)
 }
 
 func (c *SecondLevelCache) WarmUp(conn *sql.DB) error {
+	c.db = conn
 	ddl, err := c.showCreateTable(conn)
 	if err != nil {
 		return xerrors.Errorf("failed show create table %s: %w", ddl, err)
 	}
+	if err := c.setupIndexes(ddl); err != nil {
+		return xerrors.Errorf("failed to setup indexes: %w", err)
+	}
+	if err := c.setupNotNull(ddl); err != nil {
+		return xerrors.Errorf("failed to setup not null columns: %w", err)
+	}
+	if c.opt.SchemaHashKey() {
+		if err := c.setupSchemaHash(ddl); err != nil {
+			return xerrors.Errorf("failed to setup schema hash: %w", err)
+		}
+	}
+	if c.opt.StrictTypes() {
+		if err := c.validateStrictTypes(ddl); err != nil {
+			return xerrors.Errorf("failed to validate types of %s: %w", c.typ.tableName, err)
+		}
+	}
+	if c.opt.ValidateSchema() {
+		if err := c.ValidateSchema(conn); err != nil {
+			return xerrors.Errorf("failed to validate schema of %s: %w", c.typ.tableName, err)
+		}
+	}
+	if column := c.opt.ExpirationColumn(); column != "" {
+		field, exists := c.typ.fields[column]
+		if !exists {
+			return xerrors.Errorf("%s.%s for expiration_column: %w", c.typ.tableName, column, ErrUnknownColumnName)
+		}
+		if field.typ != TimeType {
+			return xerrors.Errorf("%s.%s type is %s but expiration_column requires time: %w",
+				c.typ.tableName, column, field.typ, ErrInvalidColumnType)
+		}
+	}
+	for _, order := range c.opt.DefaultOrders() {
+		if _, exists := c.typ.fields[order.column]; !exists {
+			return xerrors.Errorf("%s.%s for default_order: %w", c.typ.tableName, order.column, ErrUnknownColumnName)
+		}
+	}
+	if err := validateSessionVariables(c.opt.SessionVariables()); err != nil {
+		return xerrors.Errorf("invalid session_variables of %s: %w", c.typ.tableName, err)
+	}
+	return nil
+}
+
+func (c *SecondLevelCache) setupIndexes(ddl string) error {
 	stmt, err := sqlparser.Parse(ddl)
 	if err != nil {
 		return xerrors.Errorf("cannot parse ddl %s: %w", ddl, err)
 	}
 	for _, constraint := range (stmt.(*sqlparser.CreateTable)).Constraints {
+		if c.isIgnoredConstraint(constraint) {
+			c.ignoreConstraint(constraint)
+			continue
+		}
 		switch constraint.Type {
 		case sqlparser.ConstraintPrimaryKey:
 			c.setupPrimaryKey(constraint)
@@ -148,10 +269,20 @@ func (c *SecondLevelCache) WarmUp(conn *sql.DB) error {
 			c.setupKey(constraint)
 		}
 	}
+	c.excludeIndexes()
+	c.setupIndexStats()
 	return nil
 }
 
+// fallbackQuery applies session variables to SELECT statement executed for cache miss
+func (c *SecondLevelCache) fallbackQuery(query string) string {
+	return withOptimizerHint(query, sessionVariableHint(c.opt.SessionVariables()))
+}
+
 func (c *SecondLevelCache) showCreateTable(conn *sql.DB) (string, error) {
+	if isSQLite(conn) {
+		return showCreateTableSQLite(conn, c.typ.tableName)
+	}
 	var (
 		tbl string
 		ddl string
@@ -186,9 +317,12 @@ func (c *SecondLevelCache) setupPrimaryKey(constraint *sqlparser.Constraint) {
 		}
 		if index == primaryKey {
 			c.primaryKey = NewPrimaryKey(c.opt, c.typ.tableName, subColumns, c.typ)
+			c.primaryKey.name = "PRIMARY"
 			c.indexes[strings.Join(subColumns, ":")] = c.primaryKey
 		} else {
-			c.indexes[strings.Join(subColumns, ":")] = NewKey(c.opt, c.typ.tableName, subColumns, c.typ)
+			key := NewKey(c.opt, c.typ.tableName, subColumns, c.typ)
+			key.name = "PRIMARY"
+			c.indexes[strings.Join(subColumns, ":")] = key
 		}
 	}
 }
@@ -200,6 +334,7 @@ func (c *SecondLevelCache) setupUniqKey(constraint *sqlparser.Constraint) {
 		uniqKeys = append(uniqKeys, key.String())
 	}
 	uniqKey := strings.Join(uniqKeys, ":")
+	disablePrefix := c.isUniquePrefixDisabled(constraint)
 	for idx := range constraint.Keys {
 		subKeys := constraint.Keys[:idx+1]
 		if len(subKeys) == 0 {
@@ -210,12 +345,27 @@ func (c *SecondLevelCache) setupUniqKey(constraint *sqlparser.Constraint) {
 			columns = append(columns, key.String())
 		}
 		index := strings.Join(columns, ":")
+		if index != uniqKey && disablePrefix {
+			c.addExcludedIndex(index)
+			continue
+		}
 		if index == uniqKey {
 			c.indexes[index] = NewUniqueKey(c.opt, c.typ.tableName, columns, c.typ)
 		} else {
 			c.indexes[index] = NewKey(c.opt, c.typ.tableName, columns, c.typ)
 		}
+		c.indexes[index].name = constraint.Name
+	}
+}
+
+func (c *SecondLevelCache) isUniquePrefixDisabled(constraint *sqlparser.Constraint) bool {
+	name := strings.Trim(constraint.Name, "`")
+	for _, disabled := range c.opt.UniquePrefixDisabledIndexes() {
+		if name == disabled {
+			return true
+		}
 	}
+	return false
 }
 
 func (c *SecondLevelCache) setupKey(constraint *sqlparser.Constraint) {
@@ -231,23 +381,38 @@ func (c *SecondLevelCache) setupKey(constraint *sqlparser.Constraint) {
 		}
 		index := strings.Join(columns, ":")
 		c.indexes[index] = NewKey(c.opt, c.typ.tableName, columns, c.typ)
+		c.indexes[index].name = constraint.Name
 	}
 }
 
-func (c *SecondLevelCache) lockKey(tx *Tx, key server.CacheKey) error {
-	value := &TxValue{
-		id:   tx.id,
-		key:  key.String(),
-		time: time.Now(),
+func (c *SecondLevelCache) registerKey(key server.CacheKey) {
+	if c.keyRegistry == nil {
+		return
 	}
+	c.keyRegistry.Add(key)
+}
+
+func (c *SecondLevelCache) unregisterKey(key server.CacheKey) {
+	if c.keyRegistry == nil {
+		return
+	}
+	c.keyRegistry.Remove(key)
+}
+
+func (c *SecondLevelCache) lockKey(ctx context.Context, tx *Tx, key server.CacheKey) error {
+	return c.lockKeyWithExpiration(ctx, tx, key, c.opt.LockExpiration())
+}
+
+func (c *SecondLevelCache) lockKeyWithExpiration(ctx context.Context, tx *Tx, key server.CacheKey, expiration time.Duration) error {
+	value := tx.newTxValue(key, c.opt.now())
 	bytes, err := value.Marshal()
 	if err != nil {
 		return xerrors.Errorf("failed to marshal tx: %w", err)
 	}
 	lockKey := key.LockKey()
-	log.Add(tx.id, lockKey, value)
-	if err := c.cacheServer.Add(lockKey, bytes, c.opt.LockExpiration()); err != nil {
-		content, getErr := c.cacheServer.Get(lockKey)
+	tx.logger().Add(tx.id, lockKey, value)
+	if err := c.cacheServer.Add(ctx, lockKey, bytes, expiration); err != nil {
+		content, getErr := c.cacheServer.Get(ctx, lockKey)
 		if IsCacheMiss(getErr) {
 			return xerrors.Errorf("fatal error. cannot add transaction key. but transaction key doesn't exist: %w", err)
 		}
@@ -262,15 +427,51 @@ func (c *SecondLevelCache) lockKey(tx *Tx, key server.CacheKey) error {
 		}
 		return xerrors.Errorf("lock key (%s) is already added. value is %s: %w", lockKey, value, err)
 	}
-	tx.lockKeys = append(tx.lockKeys, lockKey)
+	tx.addLockKey(lockKey, expiration)
 	return nil
 }
 
-func (c *SecondLevelCache) set(tx *Tx, key server.CacheKey, value []byte, logenc LogEncoder) error {
+func (c *SecondLevelCache) set(ctx context.Context, tx *Tx, key server.CacheKey, value []byte, logenc LogEncoder) error {
+	return c.setWithExpiration(ctx, tx, key, value, c.expiration(), logenc)
+}
+
+// encodePayload encodes value by payload codec, and stamps the time if value is revalidated
+func (c *SecondLevelCache) encodePayload(value []byte) ([]byte, error) {
+	payload, err := c.opt.payloadCodecs.compress(value, c.opt.Compression())
+	if err != nil {
+		return nil, err
+	}
+	if c.opt.RevalidateAfter() > 0 {
+		return stampPayload(payload, c.opt.now()), nil
+	}
+	return payload, nil
+}
+
+// decodePayload removes the time stamped by encodePayload and decodes value by payload codec.
+// Values of second level cache are encoded by msgpack, so stamp is distinguishable even if RevalidateAfter is disabled
+func (c *SecondLevelCache) decodePayload(content []byte) ([]byte, error) {
+	payload, err := unstampPayload(content)
+	if err != nil {
+		return nil, err
+	}
+	return c.opt.payloadCodecs.decode(payload)
+}
+
+func (c *SecondLevelCache) setWithExpiration(ctx context.Context, tx *Tx, key server.CacheKey, value []byte, expiration time.Duration, logenc LogEncoder) error {
+	return c.setWithLock(ctx, tx, key, value, expiration, logenc, c.lockKey)
+}
+
+func (c *SecondLevelCache) setWithLock(ctx context.Context, tx *Tx, key server.CacheKey, value []byte, expiration time.Duration, logenc LogEncoder, lock func(context.Context, *Tx, server.CacheKey) error) error {
+	value, err := c.encodePayload(value)
+	if err != nil {
+		return xerrors.Errorf("failed to encode payload: %w", err)
+	}
 	keyStr := key.String()
-	if c.opt.PessimisticLock() {
+	if tx.staleFill > 0 {
+		expiration = tx.staleFillExpiration(expiration)
+	} else if c.opt.PessimisticLock() {
 		if _, exists := tx.pendingQueries[keyStr]; !exists {
-			if err := c.lockKey(tx, key); err != nil {
+			if err := lock(ctx, tx, key); err != nil {
 				return xerrors.Errorf("failed to lock key: %w", err)
 			}
 		}
@@ -282,47 +483,83 @@ func (c *SecondLevelCache) set(tx *Tx, key server.CacheKey, value []byte, logenc
 			Hash:    key.Hash(),
 			Type:    server.CacheKeyTypeSLC,
 		},
-		fn: func() error {
-			log.Set(tx.id, SLCServer, key, logenc)
+		key: key,
+		fn: func(ctx context.Context) error {
+			tx.logger().Set(tx.id, SLCServer, key, logenc)
 			casID := uint64(0)
 			if c.opt.OptimisticLock() {
 				casID = tx.stash.casIDs[key.String()]
 			}
-			if err := c.cacheServer.Set(&server.CacheStoreRequest{
+			c.evictLocalCache(key)
+			if err := c.cacheServer.Set(ctx, &server.CacheStoreRequest{
 				Key:        key,
 				Value:      value,
-				Expiration: c.opt.Expiration(),
+				Expiration: expiration,
 				CasID:      casID,
 			}); err != nil {
 				return xerrors.Errorf("failed to set cache: %w", err)
 			}
+			c.registerKey(key)
 			return nil
 		},
 	}
 	return nil
 }
 
-func (c *SecondLevelCache) setPrimaryKey(tx *Tx, key server.CacheKey, value *StructValue) error {
+func (c *SecondLevelCache) setPrimaryKey(ctx context.Context, tx *Tx, key server.CacheKey, value *StructValue) error {
+	return c.setPrimaryKeyBy(ctx, tx, key, value, false)
+}
+
+// fillPrimaryKey sets value built by reading database on cache miss
+func (c *SecondLevelCache) fillPrimaryKey(ctx context.Context, tx *Tx, key server.CacheKey, value *StructValue) error {
+	return c.setPrimaryKeyBy(ctx, tx, key, value, true)
+}
+
+// shouldStash returns whether value set to cache is stashed. value written by transaction is always stashed
+// because reading it must not return old value in cache server even if DisableStash option is enabled
+func (c *SecondLevelCache) shouldStash(fill bool) bool {
+	return !fill || !c.opt.DisableStash()
+}
+
+func (c *SecondLevelCache) setPrimaryKeyBy(ctx context.Context, tx *Tx, key server.CacheKey, value *StructValue, fill bool) error {
 	if value == nil {
-		log.Set(tx.id, SLCStash, key, value)
-		if err := c.set(tx, key, nil, value); err != nil {
+		tx.logger().Set(tx.id, SLCStash, key, value)
+		if err := c.setFill(ctx, tx, key, nil, value); err != nil {
 			return xerrors.Errorf("failed to set primary key: %w", err)
 		}
 		return nil
 	}
-	content, err := value.encodeValue()
+	content, err := c.encodeValue(value)
 	if err != nil {
 		return xerrors.Errorf("failed to encode value: %w", err)
 	}
-	log.Set(tx.id, SLCStash, key, value)
-	tx.stash.primaryKeyToValue[key.String()] = value
-	if err := c.set(tx, key, content, value); err != nil {
+	tx.logger().Set(tx.id, SLCStash, key, value)
+	if c.shouldStash(fill) {
+		if err := tx.stashValue(key.String(), value); err != nil {
+			return xerrors.Errorf("failed to stash value: %w", err)
+		}
+	}
+	expiration, expired := c.expirationByValue(value)
+	if expired {
+		// expired record must not be cached
+		return nil
+	}
+	if err := c.setWithExpiration(ctx, tx, key, content, expiration, value); err != nil {
 		return xerrors.Errorf("failed to set value: %w", err)
 	}
 	return nil
 }
 
-func (c *SecondLevelCache) setUniqueKey(tx *Tx, uniqueKey, primaryKey server.CacheKey) error {
+func (c *SecondLevelCache) setUniqueKey(ctx context.Context, tx *Tx, uniqueKey, primaryKey server.CacheKey) error {
+	return c.setUniqueKeyBy(ctx, tx, uniqueKey, primaryKey, false)
+}
+
+// fillUniqueKey sets primary key built by reading database on cache miss
+func (c *SecondLevelCache) fillUniqueKey(ctx context.Context, tx *Tx, uniqueKey, primaryKey server.CacheKey) error {
+	return c.setUniqueKeyBy(ctx, tx, uniqueKey, primaryKey, true)
+}
+
+func (c *SecondLevelCache) setUniqueKeyBy(ctx context.Context, tx *Tx, uniqueKey, primaryKey server.CacheKey, fill bool) error {
 	var writer bytes.Buffer
 	enc := msgpack.NewEncoder(&writer)
 	var primaryKeyText string
@@ -334,42 +571,65 @@ func (c *SecondLevelCache) setUniqueKey(tx *Tx, uniqueKey, primaryKey server.Cac
 	if err := enc.EncodeString(primaryKeyText); err != nil {
 		return xerrors.Errorf("failed to encode primary key: %w", err)
 	}
-	log.Set(tx.id, SLCStash, uniqueKey, LogString(primaryKeyText))
-	tx.stash.uniqueKeyToPrimaryKey[uniqueKey.String()] = primaryKey
-	if err := c.set(tx, uniqueKey, writer.Bytes(), LogString(primaryKeyText)); err != nil {
+	tx.logger().Set(tx.id, SLCStash, uniqueKey, LogString(primaryKeyText))
+	if c.shouldStash(fill) {
+		if err := tx.stashPrimaryKey(uniqueKey.String(), primaryKey); err != nil {
+			return xerrors.Errorf("failed to stash primary key: %w", err)
+		}
+	}
+	set := c.set
+	if primaryKey == nil {
+		set = c.setFill
+	}
+	if err := set(ctx, tx, uniqueKey, writer.Bytes(), LogString(primaryKeyText)); err != nil {
 		return xerrors.Errorf("failed to set cache by unique key: %w", err)
 	}
 	return nil
 }
 
-func (c *SecondLevelCache) setKey(tx *Tx, key server.CacheKey, primaryKeys []server.CacheKey) error {
-	var writer bytes.Buffer
-	enc := msgpack.NewEncoder(&writer)
-	if err := enc.EncodeArrayHeader(len(primaryKeys)); err != nil {
-		return xerrors.Errorf("failed to encode array header: %w", err)
+func (c *SecondLevelCache) setKey(ctx context.Context, tx *Tx, key server.CacheKey, primaryKeys []server.CacheKey) error {
+	if err := c.deletePagesByIndexKey(ctx, tx, key); err != nil {
+		return xerrors.Errorf("failed to delete pages: %w", err)
+	}
+	return c.setKeyBy(ctx, tx, key, primaryKeys, c.set, false)
+}
+
+// fillKey sets index list built by reading database on cache miss
+func (c *SecondLevelCache) fillKey(ctx context.Context, tx *Tx, key server.CacheKey, primaryKeys []server.CacheKey) error {
+	return c.setKeyBy(ctx, tx, key, primaryKeys, c.setFill, true)
+}
+
+func (c *SecondLevelCache) setKeyBy(ctx context.Context, tx *Tx, key server.CacheKey, primaryKeys []server.CacheKey, set func(context.Context, *Tx, server.CacheKey, []byte, LogEncoder) error, fill bool) error {
+	content, err := encodePrimaryKeys(primaryKeys, c.opt.CompactPrimaryKeys())
+	if err != nil {
+		return xerrors.Errorf("failed to encode primary keys: %w", err)
 	}
-	for _, primaryKey := range primaryKeys {
-		if err := enc.EncodeString(primaryKey.String()); err != nil {
-			return xerrors.Errorf("failed to encode primary key: %w", err)
+	tx.logger().Set(tx.id, SLCStash, key, LogStrings(primaryKeys))
+	if c.shouldStash(fill) {
+		if err := tx.stashPrimaryKeys(key.String(), primaryKeys); err != nil {
+			return xerrors.Errorf("failed to stash primary keys: %w", err)
 		}
 	}
-	log.Set(tx.id, SLCStash, key, LogStrings(primaryKeys))
-	tx.stash.keyToPrimaryKeys[key.String()] = primaryKeys
-	if err := c.set(tx, key, writer.Bytes(), LogStrings(primaryKeys)); err != nil {
+	if err := set(ctx, tx, key, content, LogStrings(primaryKeys)); err != nil {
 		return xerrors.Errorf("failed to set cache by key: %w", err)
 	}
 	return nil
 }
 
-func (c *SecondLevelCache) update(tx *Tx, key server.CacheKey, value []byte, logenc LogEncoder) error {
+func (c *SecondLevelCache) update(ctx context.Context, tx *Tx, key server.CacheKey, value []byte, expiration time.Duration, logenc LogEncoder) error {
+	value, err := c.encodePayload(value)
+	if err != nil {
+		return xerrors.Errorf("failed to encode payload: %w", err)
+	}
 	keyStr := key.String()
 	if c.opt.PessimisticLock() {
 		if _, exists := tx.pendingQueries[keyStr]; !exists {
-			if err := c.lockKey(tx, key); err != nil {
+			if err := c.lockKey(ctx, tx, key); err != nil {
 				return xerrors.Errorf("failed to lock key: %w", err)
 			}
 		}
 	}
+	c.observeWrite()
 	tx.pendingQueries[keyStr] = &PendingQuery{
 		QueryLog: &QueryLog{
 			Command: string(SLCCommandUpdate),
@@ -377,48 +637,61 @@ func (c *SecondLevelCache) update(tx *Tx, key server.CacheKey, value []byte, log
 			Hash:    key.Hash(),
 			Type:    server.CacheKeyTypeSLC,
 		},
-		fn: func() error {
-			log.Update(tx.id, SLCServer, key, logenc)
+		key: key,
+		fn: func(ctx context.Context) error {
+			tx.logger().Update(tx.id, SLCServer, key, logenc)
 			casID := uint64(0)
 			if c.opt.OptimisticLock() {
 				casID = tx.stash.casIDs[key.String()]
 			}
-			if err := c.cacheServer.Set(&server.CacheStoreRequest{
+			c.evictLocalCache(key)
+			if err := c.cacheServer.Set(ctx, &server.CacheStoreRequest{
 				Key:        key,
 				Value:      value,
-				Expiration: c.opt.Expiration(),
+				Expiration: expiration,
 				CasID:      casID,
 			}); err != nil {
 				return xerrors.Errorf("failed to update cache: %w", err)
 			}
+			c.registerKey(key)
 			return nil
 		},
 	}
 	return nil
 }
 
-func (c *SecondLevelCache) updatePrimaryKey(tx *Tx, key server.CacheKey, value *StructValue) error {
-	log.Update(tx.id, SLCStash, key, value)
-	tx.stash.primaryKeyToValue[key.String()] = value
-	content, err := value.encodeValue()
+func (c *SecondLevelCache) updatePrimaryKey(ctx context.Context, tx *Tx, key server.CacheKey, value *StructValue) error {
+	tx.logger().Update(tx.id, SLCStash, key, value)
+	if err := tx.stashValue(key.String(), value); err != nil {
+		return xerrors.Errorf("failed to stash value: %w", err)
+	}
+	content, err := c.encodeValue(value)
 	if err != nil {
 		return xerrors.Errorf("failed to encode value: %w", err)
 	}
-	if err := c.update(tx, key, content, value); err != nil {
+	expiration, expired := c.expirationByValue(value)
+	if expired {
+		if err := c.delete(ctx, tx, key); err != nil {
+			return xerrors.Errorf("failed to delete expired value: %w", err)
+		}
+		return nil
+	}
+	if err := c.update(ctx, tx, key, content, expiration, value); err != nil {
 		return xerrors.Errorf("failed to update value: %w", err)
 	}
 	return nil
 }
 
-func (c *SecondLevelCache) delete(tx *Tx, key server.CacheKey) error {
+func (c *SecondLevelCache) delete(ctx context.Context, tx *Tx, key server.CacheKey) error {
 	keyStr := key.String()
 	if c.opt.PessimisticLock() {
 		if _, exists := tx.pendingQueries[keyStr]; !exists {
-			if err := c.lockKey(tx, key); err != nil {
+			if err := c.lockKey(ctx, tx, key); err != nil {
 				return xerrors.Errorf("failed to lock key: %w", err)
 			}
 		}
 	}
+	c.observeWrite()
 	tx.pendingQueries[keyStr] = &PendingQuery{
 		QueryLog: &QueryLog{
 			Command: string(SLCCommandDelete),
@@ -426,50 +699,111 @@ func (c *SecondLevelCache) delete(tx *Tx, key server.CacheKey) error {
 			Hash:    key.Hash(),
 			Type:    server.CacheKeyTypeSLC,
 		},
-		fn: func() error {
-			log.Delete(tx.id, SLCServer, key)
-			if err := c.cacheServer.Delete(key); err != nil {
+		key: key,
+		fn: func(ctx context.Context) error {
+			tx.logger().Delete(tx.id, SLCServer, key)
+			c.evictLocalCache(key)
+			if err := c.cacheServer.Delete(ctx, key); err != nil {
 				return xerrors.Errorf("failed to delete cache: %w", err)
 			}
+			c.unregisterKey(key)
 			return nil
 		},
 	}
 	return nil
 }
 
-func (c *SecondLevelCache) deletePrimaryKey(tx *Tx, key server.CacheKey) error {
-	log.Delete(tx.id, SLCStash, key)
-	tx.stash.primaryKeyToValue[key.String()] = nil
-	if err := c.delete(tx, key); err != nil {
+func (c *SecondLevelCache) deletePrimaryKey(ctx context.Context, tx *Tx, key server.CacheKey) error {
+	tx.logger().Delete(tx.id, SLCStash, key)
+	if err := tx.stashValue(key.String(), nil); err != nil {
+		return xerrors.Errorf("failed to stash value: %w", err)
+	}
+	if err := c.delete(ctx, tx, key); err != nil {
 		return xerrors.Errorf("failed to delete primary key: %w", err)
 	}
 	return nil
 }
 
-func (c *SecondLevelCache) deleteUniqueKeyOrOldKey(tx *Tx, key server.CacheKey) error {
-	log.Delete(tx.id, SLCStash, key)
-	tx.stash.uniqueKeyToPrimaryKey[key.String()] = nil
+func (c *SecondLevelCache) deleteUniqueKeyOrOldKey(ctx context.Context, tx *Tx, key server.CacheKey) error {
+	tx.logger().Delete(tx.id, SLCStash, key)
+	if err := tx.stashPrimaryKey(key.String(), nil); err != nil {
+		return xerrors.Errorf("failed to stash primary key: %w", err)
+	}
 	tx.stash.oldKey[key.String()] = struct{}{}
-	if err := c.delete(tx, key); err != nil {
+	if err := c.delete(ctx, tx, key); err != nil {
 		return xerrors.Errorf("failed to delete unique key or old key: %w", err)
 	}
 	return nil
 }
 
-func (c *SecondLevelCache) deleteOldKey(tx *Tx, key server.CacheKey) error {
-	log.Delete(tx.id, SLCStash, key)
+func (c *SecondLevelCache) deleteOldKey(ctx context.Context, tx *Tx, key server.CacheKey) error {
+	tx.logger().Delete(tx.id, SLCStash, key)
 	tx.stash.oldKey[key.String()] = struct{}{}
-	if err := c.delete(tx, key); err != nil {
+	if err := c.delete(ctx, tx, key); err != nil {
 		return xerrors.Errorf("failed to delete old key: %w", err)
 	}
+	if err := c.deletePagesByIndexKey(ctx, tx, key); err != nil {
+		return xerrors.Errorf("failed to delete pages: %w", err)
+	}
 	return nil
 }
 
+// expiration returns table expiration. TTLSource has priority over option
+func (c *SecondLevelCache) expiration() time.Duration {
+	if c.opt.ttlSource != nil {
+		if ttl, ok := c.opt.ttlSource.TTL(c.typ.tableName); ok {
+			return ttl
+		}
+	}
+	if c.ttlTuner != nil {
+		return c.ttlTuner.expiration(c.opt.now())
+	}
+	return c.opt.Expiration()
+}
+
+// maxRelativeExpiration is max expiration memcached regards as relative.
+// Longer expiration is regarded as unix time, so value is stored already expired
+const maxRelativeExpiration = 30 * 24 * time.Hour
+
+// expirationByValue returns expiration time for value.
+// If ExpirationColumn is specified, it is shorter one of table expiration and the time until the column value.
+func (c *SecondLevelCache) expirationByValue(value *StructValue) (time.Duration, bool) {
+	expiration := c.expiration()
+	column := c.opt.ExpirationColumn()
+	if column == "" || value == nil {
+		return expiration, false
+	}
+	v, exists := value.fields[column]
+	if !exists || v == nil || v.IsNil || v.typ != TimeType {
+		return expiration, false
+	}
+	remaining := v.timeValue.Sub(c.opt.now())
+	if remaining < time.Second {
+		// cache server treats zero as no expiration, so it regards less than a second as expired
+		return 0, true
+	}
+	if expiration == 0 || remaining < expiration {
+		if remaining > maxRelativeExpiration {
+			return maxRelativeExpiration, false
+		}
+		return remaining, false
+	}
+	return expiration, false
+}
+
 func (c *SecondLevelCache) encode(marshaler Marshaler) ([]byte, *StructValue, error) {
 	enc := NewStructEncoder(c.typ, c.valueFactory)
-	if err := marshaler.EncodeRapidash(enc); err != nil {
+	if err := encodeRapidash(marshaler, enc); err != nil {
 		return nil, nil, xerrors.Errorf("failed to encode: %w", err)
 	}
+	if hook := c.opt.EncodeHook(); hook != nil {
+		if err := hook(enc.value); err != nil {
+			return nil, nil, xerrors.Errorf("failed to call encode hook for %s: %w", c.typ.tableName, err)
+		}
+	}
+	if err := c.typ.validateNotNull(enc.value); err != nil {
+		return nil, nil, xerrors.Errorf("invalid value: %w", err)
+	}
 	content, err := enc.Encode()
 	if err != nil {
 		return nil, nil, xerrors.Errorf("failed to encode: %w", err)
@@ -495,9 +829,10 @@ func (c *SecondLevelCache) cacheKeyByPrimaryKeyValue(v *Value) (server.CacheKey,
 	return key, nil
 }
 
-func (c *SecondLevelCache) UpdateByPrimaryKey(tx *Tx, marshaler Marshaler) error {
+func (c *SecondLevelCache) UpdateByPrimaryKey(ctx context.Context, tx *Tx, marshaler Marshaler) error {
 	_, value, err := c.encode(marshaler)
 	if err != nil {
+		tx.abortByCoderPanic(err)
 		return xerrors.Errorf("failed to encode: %w", err)
 	}
 	defer value.Release()
@@ -505,18 +840,42 @@ func (c *SecondLevelCache) UpdateByPrimaryKey(tx *Tx, marshaler Marshaler) error
 	if err != nil {
 		return xerrors.Errorf("failed to get cache key: %w", err)
 	}
-	if err := c.updatePrimaryKey(tx, key, value); err != nil {
+	if err := c.deleteClusterKeyByPrimaryKey(ctx, tx, key, value); err != nil {
+		return xerrors.Errorf("failed to delete cluster key by primary key: %w", err)
+	}
+	if err := c.deleteClusterKeyByValue(ctx, tx, value); err != nil {
+		return xerrors.Errorf("failed to delete cluster key by value: %w", err)
+	}
+	if err := c.deleteCountKeysByPrimaryKey(ctx, tx, key, value); err != nil {
+		return xerrors.Errorf("failed to delete count keys by primary key: %w", err)
+	}
+	if err := c.deleteCountKeysByValue(ctx, tx, value, nil); err != nil {
+		return xerrors.Errorf("failed to delete count keys by value: %w", err)
+	}
+	if err := c.updatePrimaryKey(ctx, tx, key, value); err != nil {
 		return xerrors.Errorf("failed to update primary key: %w", err)
 	}
 	return nil
 }
 
-func (c *SecondLevelCache) DeleteByPrimaryKey(tx *Tx, v *Value) error {
+func (c *SecondLevelCache) DeleteByPrimaryKey(ctx context.Context, tx *Tx, v *Value) error {
 	key, err := c.cacheKeyByPrimaryKeyValue(v)
 	if err != nil {
 		return xerrors.Errorf("failed to get cache key: %w", err)
 	}
-	if err := c.deletePrimaryKey(tx, key); err != nil {
+	if c.opt.ClusterKey() != "" || c.opt.CountCache() {
+		primaryKeyValue := &StructValue{
+			typ:    c.typ,
+			fields: map[string]*Value{c.primaryKey.Columns[0]: v},
+		}
+		if err := c.deleteClusterKeyByPrimaryKey(ctx, tx, key, primaryKeyValue); err != nil {
+			return xerrors.Errorf("failed to delete cluster key by primary key: %w", err)
+		}
+		if err := c.deleteCountKeysByPrimaryKey(ctx, tx, key, primaryKeyValue); err != nil {
+			return xerrors.Errorf("failed to delete count keys by primary key: %w", err)
+		}
+	}
+	if err := c.deletePrimaryKey(ctx, tx, key); err != nil {
 		return xerrors.Errorf("failed to delete primary key: %w", err)
 	}
 	return nil
@@ -534,44 +893,67 @@ func (c *SecondLevelCache) decodePrimaryKey(content []byte, flags uint32) (serve
 	}
 	hash := flags
 	if c.opt.shardKey == nil {
-		hash = NewStringValue(primaryKey).Hash()
+		hash = c.opt.cacheKeyHash.hashString(primaryKey)
 	}
 	return &CacheKey{key: primaryKey, hash: hash}, nil
 }
 
 func (c *SecondLevelCache) decodeMultiplePrimaryKeys(content []byte, flags uint32) ([]server.CacheKey, error) {
-	buf := bytes.NewBuffer(content)
-	dec := msgpack.NewDecoder(buf)
-	var len int
-	if err := dec.DecodeArrayLength(&len); err != nil {
-		return nil, xerrors.Errorf("failed to decode array length: %w", err)
+	keys, err := decodePrimaryKeys(content)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decode primary keys: %w", err)
 	}
-	primaryKeys := make([]server.CacheKey, len)
-	for i := 0; i < len; i++ {
-		var v string
-		if err := dec.DecodeString(&v); err != nil {
-			return nil, xerrors.Errorf("failed to decode string: %w", err)
-		}
+	primaryKeys := make([]server.CacheKey, len(keys))
+	for i, v := range keys {
 		hash := flags
 		if c.opt.shardKey == nil {
-			hash = NewStringValue(v).Hash()
+			hash = c.opt.cacheKeyHash.hashString(v)
 		}
 		primaryKeys[i] = &CacheKey{key: v, hash: hash}
 	}
 	return primaryKeys, nil
 }
 
-func (c *SecondLevelCache) findByPrimaryKeys(tx *Tx, valueIter *ValueIterator) error {
+func (c *SecondLevelCache) isStashAvailable(tx *Tx, key string) bool {
+	if c.opt.DisableStash() {
+		// only values written by transaction itself are stashed
+		return true
+	}
+	return tx.isStashAvailable(key)
+}
+
+// repairKey deletes cached value that cannot be decoded so that following reads don't fall back to database repeatedly.
+// Index keys referring to the value are kept because they are still valid after the value is rebuilt from database
+func (c *SecondLevelCache) repairKey(ctx context.Context, tx *Tx, key server.CacheKey, cause error) {
+	atomic.AddUint64(&c.readRepairCount, 1)
+	log.Warn(fmt.Sprintf("delete %s because cached value cannot be decoded: %s", key, cause))
+	if err := c.cacheServer.Delete(ctx, key); err != nil && !IsCacheMiss(err) {
+		log.Warn(fmt.Sprintf("failed to delete %s for read repair: %+v", key, err))
+		return
+	}
+	tx.logger().Delete(tx.id, SLCServer, key)
+}
+
+// ReadRepairCount returns number of cached values that couldn't be decoded
+func (c *SecondLevelCache) ReadRepairCount() uint64 {
+	return atomic.LoadUint64(&c.readRepairCount)
+}
+
+func (c *SecondLevelCache) findByPrimaryKeys(ctx context.Context, tx *Tx, valueIter *ValueIterator, acceptStale bool) error {
 	requestKeys := []server.CacheKey{}
 	for valueIter.Next() {
 		if _, exists := tx.stash.oldKey[valueIter.PrimaryKey().String()]; exists {
+			if acceptStale {
+				requestKeys = append(requestKeys, valueIter.PrimaryKey())
+				continue
+			}
 			// need lookup db
 			valueIter.SetErrorWithKey(valueIter.PrimaryKey(), server.ErrCacheMiss)
 			continue
 		}
 		value, exists := tx.stash.primaryKeyToValue[valueIter.PrimaryKey().String()]
-		if exists {
-			log.Get(tx.id, SLCStash, valueIter.PrimaryKey(), value)
+		if exists && c.isStashAvailable(tx, valueIter.PrimaryKey().String()) {
+			tx.logger().Get(tx.id, SLCStash, valueIter.PrimaryKey(), value)
 			valueIter.SetValue(value)
 		} else {
 			requestKeys = append(requestKeys, valueIter.PrimaryKey())
@@ -580,7 +962,7 @@ func (c *SecondLevelCache) findByPrimaryKeys(tx *Tx, valueIter *ValueIterator) e
 	if len(requestKeys) == 0 {
 		return nil
 	}
-	iter, err := c.cacheServer.GetMulti(requestKeys)
+	iter, err := c.getMultiWithLocalCache(ctx, requestKeys)
 	if err != nil {
 		return xerrors.Errorf("failed to get primary keys from server: %w", err)
 	}
@@ -592,44 +974,68 @@ func (c *SecondLevelCache) findByPrimaryKeys(tx *Tx, valueIter *ValueIterator) e
 	defer c.releaseValueDecoder(decoder)
 	for iter.Next() {
 		if err := iter.Error(); err != nil {
+			tx.reportGet(SLCServer, false, iter.Key())
 			valueIter.SetErrorWithKey(iter.Key(), xerrors.Errorf("set error: %w", err))
 			continue
 		}
 		content := iter.Content()
+		payload, err := c.decodePayload(content.Value)
+		if err != nil {
+			c.repairKey(ctx, tx, iter.Key(), err)
+			valueIter.SetErrorWithKey(iter.Key(), xerrors.Errorf("%s: %w", err.Error(), server.ErrCacheMiss))
+			continue
+		}
 		var value *StructValue
-		if len(content.Value) > 0 {
-			decoder.SetBuffer(content.Value)
+		if len(payload) > 0 {
 			var err error
-			value, err = decoder.Decode()
+			value, err = c.decodeValue(decoder, payload)
+			if err != nil && c.opt.structMigration != nil {
+				value, err = c.migrateValue(ctx, iter.Key(), content, payload)
+			}
 			if err != nil {
+				c.repairKey(ctx, tx, iter.Key(), err)
 				valueIter.SetErrorWithKey(iter.Key(), xerrors.Errorf("%s: %w", err.Error(), server.ErrCacheMiss))
 				continue
 			}
 		}
+		if value != nil && c.shouldRevalidate(content.Value) {
+			c.revalidate(iter.Key(), value, content.CasID)
+		}
+		c.limitLocalCacheAge(iter.Key(), value)
 		key := iter.Key().String()
-		tx.stash.primaryKeyToValue[key] = value
-		tx.stash.casIDs[key] = content.CasID
+		if !tx.isOldKey(key) {
+			if !c.opt.DisableStash() {
+				if err := tx.stashValue(key, value); err != nil {
+					return xerrors.Errorf("failed to stash value: %w", err)
+				}
+			}
+			tx.stash.casIDs[key] = content.CasID
+		}
 		valueIter.SetValueWithKey(iter.Key(), value)
 		if !isNopLogger {
 			values.Append(value)
 		}
 	}
-	log.GetMulti(tx.id, SLCServer, requestKeys, values)
+	tx.logger().GetMulti(tx.id, SLCServer, requestKeys, values)
 	return nil
 }
 
-func (c *SecondLevelCache) setPrimaryKeysByUniqueKeys(tx *Tx, queryIter *QueryIterator) error {
+func (c *SecondLevelCache) setPrimaryKeysByUniqueKeys(ctx context.Context, tx *Tx, queryIter *QueryIterator, acceptStale bool) error {
 	requestKeys := []server.CacheKey{}
 	defer queryIter.Reset()
 	for queryIter.Next() {
 		uniqueKey := queryIter.Key()
 		if _, exists := tx.stash.oldKey[uniqueKey.String()]; exists {
+			if acceptStale {
+				requestKeys = append(requestKeys, uniqueKey)
+				continue
+			}
 			// need lookup db
 			queryIter.SetErrorWithKey(uniqueKey, server.ErrCacheMiss)
 			continue
 		}
 		primaryKey, exists := tx.stash.uniqueKeyToPrimaryKey[uniqueKey.String()]
-		if exists {
+		if exists && c.isStashAvailable(tx, uniqueKey.String()) {
 			queryIter.SetPrimaryKey(primaryKey)
 		} else {
 			requestKeys = append(requestKeys, uniqueKey)
@@ -638,7 +1044,7 @@ func (c *SecondLevelCache) setPrimaryKeysByUniqueKeys(tx *Tx, queryIter *QueryIt
 	if len(requestKeys) == 0 {
 		return nil
 	}
-	iter, err := c.cacheServer.GetMulti(requestKeys)
+	iter, err := c.cacheServer.GetMulti(ctx, requestKeys)
 	if err != nil {
 		return xerrors.Errorf("failed to get primary keys from server: %w", err)
 	}
@@ -652,35 +1058,54 @@ func (c *SecondLevelCache) setPrimaryKeysByUniqueKeys(tx *Tx, queryIter *QueryIt
 			continue
 		}
 		content := iter.Content()
-		primaryKey, err := c.decodePrimaryKey(content.Value, content.Flags)
+		var primaryKey server.CacheKey
+		payload, err := c.decodePayload(content.Value)
+		if err == nil {
+			primaryKey, err = c.decodePrimaryKey(payload, content.Flags)
+		}
 		if err != nil {
-			queryIter.SetErrorWithKey(iter.Key(), xerrors.Errorf("set error: %w", err))
+			c.repairKey(ctx, tx, iter.Key(), err)
+			queryIter.SetErrorWithKey(iter.Key(), xerrors.Errorf("%s: %w", err.Error(), server.ErrCacheMiss))
 		} else {
 			if !isNopLogger {
 				values = append(values, primaryKey)
 			}
 			key := iter.Key().String()
-			tx.stash.uniqueKeyToPrimaryKey[key] = primaryKey
-			tx.stash.casIDs[key] = content.CasID
+			if !tx.isOldKey(key) {
+				if !c.opt.DisableStash() {
+					if err := tx.stashPrimaryKey(key, primaryKey); err != nil {
+						return xerrors.Errorf("failed to stash primary key: %w", err)
+					}
+				}
+				tx.stash.casIDs[key] = content.CasID
+			}
 			queryIter.SetPrimaryKeyWithKey(iter.Key(), primaryKey)
 		}
 	}
-	log.GetMulti(tx.id, SLCServer, requestKeys, LogStrings(values))
+	tx.logger().GetMulti(tx.id, SLCServer, requestKeys, LogStrings(values))
 	return nil
 }
 
-func (c *SecondLevelCache) setPrimaryKeysByKeys(tx *Tx, queryIter *QueryIterator) error {
+func (c *SecondLevelCache) setPrimaryKeysByKeys(ctx context.Context, tx *Tx, queryIter *QueryIterator, acceptStale bool) error {
 	requestKeys := []server.CacheKey{}
 	defer queryIter.Reset()
+	var index *Index
 	for queryIter.Next() {
 		key := queryIter.Key()
+		if query := queryIter.Query(); query != nil {
+			index = query.Index()
+		}
 		if _, exists := tx.stash.oldKey[key.String()]; exists {
+			if acceptStale {
+				requestKeys = append(requestKeys, key)
+				continue
+			}
 			// need lookup db
 			queryIter.SetErrorWithKey(key, server.ErrCacheMiss)
 			continue
 		}
 		primaryKeys, exists := tx.stash.keyToPrimaryKeys[key.String()]
-		if exists {
+		if exists && c.isStashAvailable(tx, key.String()) {
 			queryIter.SetPrimaryKeys(primaryKeys)
 		} else {
 			requestKeys = append(requestKeys, key)
@@ -690,7 +1115,7 @@ func (c *SecondLevelCache) setPrimaryKeysByKeys(tx *Tx, queryIter *QueryIterator
 		return nil
 	}
 
-	iter, err := c.cacheServer.GetMulti(requestKeys)
+	iter, err := c.cacheServer.GetMulti(ctx, requestKeys)
 	if err != nil {
 		return xerrors.Errorf("failed to get primary keys from server: %w", err)
 	}
@@ -701,22 +1126,34 @@ func (c *SecondLevelCache) setPrimaryKeysByKeys(tx *Tx, queryIter *QueryIterator
 			continue
 		}
 		content := iter.Content()
-		primaryKeys, err := c.decodeMultiplePrimaryKeys(content.Value, content.Flags)
+		var primaryKeys []server.CacheKey
+		payload, err := c.decodePayload(content.Value)
+		if err == nil {
+			primaryKeys, err = c.decodeMultiplePrimaryKeys(payload, content.Flags)
+		}
 		if err != nil {
-			queryIter.SetErrorWithKey(iter.Key(), xerrors.Errorf("set error: %w", err))
+			c.repairKey(ctx, tx, iter.Key(), err)
+			queryIter.SetErrorWithKey(iter.Key(), xerrors.Errorf("%s: %w", err.Error(), server.ErrCacheMiss))
 		} else {
+			c.recordIndexSelectivity(index, len(primaryKeys))
 			values = append(values, primaryKeys...)
 			queryIter.SetPrimaryKeysWithKey(iter.Key(), primaryKeys)
 			key := iter.Key().String()
-			tx.stash.keyToPrimaryKeys[key] = primaryKeys
-			tx.stash.casIDs[key] = content.CasID
+			if !tx.isOldKey(key) {
+				if !c.opt.DisableStash() {
+					if err := tx.stashPrimaryKeys(key, primaryKeys); err != nil {
+						return xerrors.Errorf("failed to stash primary keys: %w", err)
+					}
+				}
+				tx.stash.casIDs[key] = content.CasID
+			}
 		}
 	}
-	log.GetMulti(tx.id, SLCServer, requestKeys, LogStrings(values))
+	tx.logger().GetMulti(tx.id, SLCServer, requestKeys, LogStrings(values))
 	return nil
 }
 
-func (c *SecondLevelCache) findValuesByCache(tx *Tx, builder *QueryBuilder, queries *Queries) (*StructSliceValue, error) {
+func (c *SecondLevelCache) findValuesByCache(ctx context.Context, tx *Tx, builder *QueryBuilder, queries *Queries) (*StructSliceValue, error) {
 	if builder.isIgnoreCache || builder.lockOpt != nil {
 		queries.cacheMissQueries = queries.queries
 		return NewStructSliceValue(), nil
@@ -728,17 +1165,17 @@ func (c *SecondLevelCache) findValuesByCache(tx *Tx, builder *QueryBuilder, quer
 				iter.SetPrimaryKey(iter.Key())
 			}
 		case IndexTypeUniqueKey:
-			if err := c.setPrimaryKeysByUniqueKeys(tx, iter); err != nil {
+			if err := c.setPrimaryKeysByUniqueKeys(ctx, tx, iter, builder.acceptStale > 0); err != nil {
 				return xerrors.Errorf("failed to set primary keys by unique keys: %w", err)
 			}
 		case IndexTypeKey:
-			if err := c.setPrimaryKeysByKeys(tx, iter); err != nil {
+			if err := c.setPrimaryKeysByKeys(ctx, tx, iter, builder.acceptStale > 0); err != nil {
 				return xerrors.Errorf("failed to set primary keys by keys: %w", err)
 			}
 		}
 		return nil
 	}, func(valueIter *ValueIterator) error {
-		if err := c.findByPrimaryKeys(tx, valueIter); err != nil {
+		if err := c.findByPrimaryKeys(ctx, tx, valueIter, builder.acceptStale > 0); err != nil {
 			return xerrors.Errorf("failed to find by primary keys: %w", err)
 		}
 		return nil
@@ -749,18 +1186,18 @@ func (c *SecondLevelCache) findValuesByCache(tx *Tx, builder *QueryBuilder, quer
 	return values, nil
 }
 
-func (c *SecondLevelCache) createCacheByCacheMissQueryMap(tx *Tx, cacheMissQueryMap map[*Query][]*StructValue) error {
+func (c *SecondLevelCache) createCacheByCacheMissQueryMap(ctx context.Context, tx *Tx, cacheMissQueryMap map[*Query][]*StructValue) error {
 	for cacheMissQuery, values := range cacheMissQueryMap {
 		if len(values) == 0 {
-			if err := c.createNegativeCacheByQuery(tx, cacheMissQuery); err != nil {
+			if err := c.createNegativeCacheByQuery(ctx, tx, cacheMissQuery); err != nil {
 				return xerrors.Errorf("failed to create negative cache by query: %w", err)
 			}
 		} else if len(values) == 1 {
-			if err := c.createByQueryWithValue(tx, cacheMissQuery, values[0]); err != nil {
+			if err := c.createByQueryWithValue(ctx, tx, cacheMissQuery, values[0]); err != nil {
 				return xerrors.Errorf("failed to create cache by single value: %w", err)
 			}
 		} else {
-			if err := c.createByQueryWithValues(tx, cacheMissQuery, values); err != nil {
+			if err := c.createByQueryWithValues(ctx, tx, cacheMissQuery, values); err != nil {
 				return xerrors.Errorf("failed to create cache by multiple values: %w", err)
 			}
 		}
@@ -776,7 +1213,51 @@ func (c *SecondLevelCache) primaryKeyStringByStructValue(value *StructValue) str
 	return strings.Join(primaryKeys, ":")
 }
 
+func (c *SecondLevelCache) findValuesByChunkedQueryBuilder(ctx context.Context, tx *Tx, builder *QueryBuilder) (*StructSliceValue, error) {
+	// IN values are unique, so values found by each chunk never overlap
+	foundValues := NewStructSliceValue()
+	for _, chunk := range builder.chunkedBuilders(c.valueFactory, c.opt.INChunkSize()) {
+		values, err := c.findValuesByQueryBuilder(ctx, tx, chunk)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to find values by chunked query builder: %w", err)
+		}
+		if values == nil {
+			continue
+		}
+		foundValues.AppendSlice(values)
+	}
+	foundValues.Sort(c.opt.DefaultOrders())
+	return foundValues, nil
+}
+
 func (c *SecondLevelCache) findValuesByQueryBuilder(ctx context.Context, tx *Tx, builder *QueryBuilder) (ssv *StructSliceValue, e error) {
+	if builder.isINChunkRequired(c.valueFactory, c.opt.INChunkSize()) {
+		foundValues, err := c.findValuesByChunkedQueryBuilder(ctx, tx, builder)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to find values by chunked query builder: %w", err)
+		}
+		return foundValues, nil
+	}
+	defer func() {
+		if e == nil {
+			ssv = c.filterValuesSharingKey(builder, ssv)
+			if ssv != nil {
+				ssv.Sort(c.opt.DefaultOrders())
+				builder.info.addRows(ssv.Len())
+			}
+		}
+	}()
+	if c.cacheOnly {
+		foundValues, err := c.findCacheOnlyValues(ctx, tx, builder)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to find values of cache only table: %w", err)
+		}
+		return foundValues, nil
+	}
+	if builder.acceptStale > 0 && tx.staleFill == 0 {
+		tx.staleFill = builder.acceptStale
+		defer func() { tx.staleFill = 0 }()
+	}
 	if builder.IsUnsupportedCacheQuery() {
 		foundValues, err := c.findValuesByQueryBuilderWithoutCache(ctx, tx, builder)
 		if err != nil {
@@ -785,6 +1266,39 @@ func (c *SecondLevelCache) findValuesByQueryBuilder(ctx context.Context, tx *Tx,
 		return foundValues, nil
 	}
 
+	if positiveBuilder, negativeConditions := c.splitNegativeConditions(builder); positiveBuilder != nil {
+		foundValues, err := c.findValuesByNegativeConditions(ctx, tx, builder, positiveBuilder, negativeConditions)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to find values by negative conditions: %w", err)
+		}
+		return foundValues, nil
+	}
+
+	if !builder.AvailableCache() && builder.sqlCondition == nil && builder.conditions.Len() != 0 {
+		// conditions like != and > can't be found by cache
+		foundValues, err := c.findValuesByQueryBuilderWithoutCache(ctx, tx, builder)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to find values by query builder without cache: %w", err)
+		}
+		return foundValues, nil
+	}
+
+	if c.isClusterKeyQuery(builder) {
+		foundValues, err := c.findValuesByClusterKey(ctx, tx, builder)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to find values by cluster key: %w", err)
+		}
+		return foundValues, nil
+	}
+
+	if c.isExcludedIndexQuery(builder) {
+		foundValues, err := c.findValuesByQueryBuilderWithoutCache(ctx, tx, builder)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to find values by query builder without cache: %w", err)
+		}
+		return foundValues, nil
+	}
+
 	queries, err := builder.BuildWithIndex(c.valueFactory, c.indexes, c.typ)
 	if err != nil {
 		return nil, xerrors.Errorf("failed to build query: %w", err)
@@ -792,17 +1306,28 @@ func (c *SecondLevelCache) findValuesByQueryBuilder(ctx context.Context, tx *Tx,
 	if queries.Len() == 0 {
 		return nil, nil
 	}
+	queries.refetchPrimaryKeys = c.opt.MissingPrimaryKeyPolicy() == MissingPrimaryKeyPolicyRefetchPrimaryKeys
 
-	foundValues, err := c.findValuesByCache(tx, builder, queries)
+	foundValues, err := c.findValuesByCache(ctx, tx, builder, queries)
 	if err != nil {
 		return nil, xerrors.Errorf("failed to find values by cache: %w", err)
 	}
+	if err := c.refetchMissingPrimaryKeys(ctx, tx, builder, queries, foundValues); err != nil {
+		return nil, xerrors.Errorf("failed to refetch missing primary keys: %w", err)
+	}
+	builder.info.addCacheRows(foundValues.Len())
+	builder.info.addMissedKeys(len(queries.CacheMissQueries()))
+	c.observeRead(foundValues.Len(), len(queries.CacheMissQueries()))
+	c.observeQueries(queries)
 	query, values := queries.CacheMissQueriesToSQL(c.typ)
 	if query == "" {
 		return foundValues, nil
 	}
+	query = c.fallbackQuery(query)
 
-	rows, err := tx.conn.QueryContext(ctx, query, values...)
+	queryStart := time.Now()
+	rows, err := tx.connection(c).QueryContext(ctx, query, values...)
+	c.observeDBQuery(queryStart)
 	if err != nil {
 		return nil, xerrors.Errorf("failed sql %s %v: %w", query, values, err)
 	}
@@ -812,7 +1337,15 @@ func (c *SecondLevelCache) findValuesByQueryBuilder(ctx context.Context, tx *Tx,
 		}
 	}()
 	cacheMissQueryMap := map[*Query][]*StructValue{}
+	cacheMissKeys := map[string]struct{}{}
 	for _, cacheMissQuery := range queries.CacheMissQueries() {
+		if cacheMissQuery.cacheKey != nil {
+			// queries in the same time bucket share cache key
+			if _, exists := cacheMissKeys[cacheMissQuery.cacheKey.String()]; exists {
+				continue
+			}
+			cacheMissKeys[cacheMissQuery.cacheKey.String()] = struct{}{}
+		}
 		cacheMissQueryMap[cacheMissQuery] = []*StructValue{}
 	}
 	var dbValues *StructSliceValue
@@ -833,6 +1366,7 @@ func (c *SecondLevelCache) findValuesByQueryBuilder(ctx context.Context, tx *Tx,
 		pkStr := c.primaryKeyStringByStructValue(value)
 		if _, exists := alreadyFoundValues[pkStr]; !exists {
 			alreadyFoundValues[pkStr] = struct{}{}
+			builder.info.addDBRows(1)
 			foundValues.Append(value)
 			if !isNopLogger {
 				dbValues.Append(value)
@@ -842,34 +1376,161 @@ func (c *SecondLevelCache) findValuesByQueryBuilder(ctx context.Context, tx *Tx,
 		if cacheMissQuery == nil {
 			continue
 		}
+		if _, exists := cacheMissQueryMap[cacheMissQuery]; !exists {
+			continue
+		}
 		cacheMissQueryMap[cacheMissQuery] = append(cacheMissQueryMap[cacheMissQuery], value)
 	}
 
-	log.GetFromDB(tx.id, query, values, dbValues)
+	tx.logger().GetFromDB(tx.id, query, values, dbValues)
 	if builder.isIgnoreCache {
 		return foundValues, nil
 	}
-	if err := c.createCacheByCacheMissQueryMap(tx, cacheMissQueryMap); err != nil {
+	if err := c.createCacheByCacheMissQueryMap(ctx, tx, cacheMissQueryMap); err != nil {
 		return nil, xerrors.Errorf("failed to create cache by cache miss query map: %w", err)
 	}
 	return foundValues, nil
 }
 
+// filterValuesSharingKey removes values found by the same time bucket or transformed key but not matched to condition
+func (c *SecondLevelCache) filterValuesSharingKey(builder *QueryBuilder, values *StructSliceValue) *StructSliceValue {
+	if values == nil || (len(c.typ.timeBuckets) == 0 && len(c.typ.keyTransform) == 0) {
+		return values
+	}
+	conditions := []Condition{}
+	for _, condition := range builder.conditions.conditions {
+		column := condition.Column()
+		if c.typ.timeBucket(column) > 0 || c.typ.keyTransformByColumn(column) != nil {
+			conditions = append(conditions, condition)
+		}
+	}
+	if len(conditions) == 0 {
+		return values
+	}
+	filteredValues := NewStructSliceValue()
+	for _, value := range values.values {
+		matched := true
+		for _, condition := range conditions {
+			v := value.fields[condition.Column()]
+			if v == nil || !condition.Compare(v) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			filteredValues.Append(value)
+		}
+	}
+	return filteredValues
+}
+
+// splitNegativeConditions returns builder without != and NOT IN conditions if it is available for cache.
+// nil is returned if negative conditions must be processed by database
+func (c *SecondLevelCache) splitNegativeConditions(builder *QueryBuilder) (*QueryBuilder, []Condition) {
+	positiveBuilder, negativeConditions := builder.splitNegativeConditions()
+	if positiveBuilder == nil {
+		return nil, nil
+	}
+	builder.conditions.Build(c.valueFactory)
+	if err := builder.validateCondition(c.typ); err != nil {
+		// invalid conditions are reported by building queries
+		return nil, nil
+	}
+	for _, condition := range negativeConditions {
+		// cached value of encrypted column can't be compared with condition
+		if _, exists := c.typ.encryptedField(condition.Column()); exists {
+			return nil, nil
+		}
+	}
+	if c.isClusterKeyQuery(positiveBuilder) {
+		return positiveBuilder, negativeConditions
+	}
+	if c.isExcludedIndexQuery(positiveBuilder) {
+		return nil, nil
+	}
+	if _, err := positiveBuilder.indexByColumns(positiveBuilder.conditions.Columns(), c.indexes); err != nil {
+		return nil, nil
+	}
+	return positiveBuilder, negativeConditions
+}
+
+// findValuesByNegativeConditions finds values of indexed set from cache, then filters them by != and NOT IN conditions
+func (c *SecondLevelCache) findValuesByNegativeConditions(ctx context.Context, tx *Tx, builder, positiveBuilder *QueryBuilder, negativeConditions []Condition) (*StructSliceValue, error) {
+	if builder.info != nil {
+		positiveBuilder.info = &QueryInfo{}
+	}
+	values, err := c.findValuesByQueryBuilder(ctx, tx, positiveBuilder)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to find values by query builder without negative conditions: %w", err)
+	}
+	if info := positiveBuilder.info; info != nil {
+		builder.info.addCacheRows(info.CacheRows)
+		builder.info.addDBRows(info.DBRows)
+		builder.info.addMissedKeys(info.MissedKeys)
+	}
+	if values == nil {
+		return nil, nil
+	}
+	for _, condition := range negativeConditions {
+		values = values.Filter(condition)
+	}
+	return values, nil
+}
+
 func (c *SecondLevelCache) FindByQueryBuilder(ctx context.Context, tx *Tx, builder *QueryBuilder, unmarshaler Unmarshaler) error {
 	defer builder.Release()
+	if err := c.typ.encryptConditions(builder); err != nil {
+		return xerrors.Errorf("failed to encrypt conditions: %w", err)
+	}
+	var shadowBuilder *QueryBuilder
+	if c.isShadowRead(tx, builder) {
+		// response is always read from database, and cache is read by cloned builder in background
+		shadowBuilder = builder.clone()
+		builder.isIgnoreCache = shadowBuilder != nil
+	}
 	foundValues, err := c.findValuesByQueryBuilder(ctx, tx, builder)
 	if err != nil {
 		return xerrors.Errorf("failed to find values by query builder: %w", err)
 	}
+	if shadowBuilder != nil {
+		c.shadowRead(tx, shadowBuilder, foundValues)
+	}
+	foundValues = builder.page(foundValues)
 	if foundValues != nil && foundValues.Len() > 0 {
-		if err := unmarshaler.DecodeRapidash(foundValues); err != nil {
+		values, err := c.applyDecodeHook(foundValues)
+		if err != nil {
+			return xerrors.Errorf("failed to apply decode hook: %w", err)
+		}
+		if err := decodeRapidash(unmarshaler, values); err != nil {
+			tx.abortByCoderPanic(err)
 			return xerrors.Errorf("failed to decode: %w", err)
 		}
 	}
 	return nil
 }
 
-func (c *SecondLevelCache) deleteCacheKeyByOldValue(tx *Tx, column string, value *StructValue) error {
+func (c *SecondLevelCache) applyDecodeHook(values *StructSliceValue) (*StructSliceValue, error) {
+	hook := c.opt.DecodeHook()
+	if hook == nil {
+		return values, nil
+	}
+	hookedValues := NewStructSliceValue()
+	for _, value := range values.values {
+		if value == nil {
+			hookedValues.Append(value)
+			continue
+		}
+		// found values may be shared with stash, so hook must be called with copied value
+		copiedValue := value.copy()
+		if err := hook(copiedValue); err != nil {
+			return nil, xerrors.Errorf("failed to call decode hook for %s: %w", c.typ.tableName, err)
+		}
+		hookedValues.Append(copiedValue)
+	}
+	return hookedValues, nil
+}
+
+func (c *SecondLevelCache) deleteCacheKeyByOldValue(ctx context.Context, tx *Tx, column string, value *StructValue) error {
 	for _, index := range c.indexes {
 		if !index.HasColumn(column) {
 			continue
@@ -879,14 +1540,14 @@ func (c *SecondLevelCache) deleteCacheKeyByOldValue(tx *Tx, column string, value
 		if err != nil {
 			return xerrors.Errorf("failed to get cache key: %w", err)
 		}
-		if err := c.deleteUniqueKeyOrOldKey(tx, cacheKey); err != nil {
+		if err := c.deleteUniqueKeyOrOldKey(ctx, tx, cacheKey); err != nil {
 			return xerrors.Errorf("failed to delete unique key or old key: %w", err)
 		}
 	}
 	return nil
 }
 
-func (c *SecondLevelCache) updateOrDeleteCacheKeyByNewValue(tx *Tx, column string, value *StructValue) error {
+func (c *SecondLevelCache) updateOrDeleteCacheKeyByNewValue(ctx context.Context, tx *Tx, column string, value *StructValue) error {
 	for _, index := range c.indexes {
 		if index.Type == IndexTypePrimaryKey {
 			continue
@@ -905,7 +1566,7 @@ func (c *SecondLevelCache) updateOrDeleteCacheKeyByNewValue(tx *Tx, column strin
 			if err != nil {
 				return xerrors.Errorf("failed to get cache key: %w", err)
 			}
-			if err := c.setUniqueKey(tx, cacheKey, primaryKey); err != nil {
+			if err := c.setUniqueKey(ctx, tx, cacheKey, primaryKey); err != nil {
 				return xerrors.Errorf("failed to set unique key: %w", err)
 			}
 		case IndexTypeKey:
@@ -913,7 +1574,7 @@ func (c *SecondLevelCache) updateOrDeleteCacheKeyByNewValue(tx *Tx, column strin
 			if err != nil {
 				return xerrors.Errorf("failed to get cache key: %w", err)
 			}
-			if err := c.deleteOldKey(tx, cacheKey); err != nil {
+			if err := c.deleteOldKey(ctx, tx, cacheKey); err != nil {
 				return xerrors.Errorf("failed to delete old key: %w", err)
 			}
 		}
@@ -921,7 +1582,7 @@ func (c *SecondLevelCache) updateOrDeleteCacheKeyByNewValue(tx *Tx, column strin
 	return nil
 }
 
-func (c *SecondLevelCache) updateValue(tx *Tx, target *StructValue, updateMap map[string]interface{}) error {
+func (c *SecondLevelCache) updateValue(ctx context.Context, tx *Tx, target *StructValue, updateMap map[string]interface{}) error {
 	for k, v := range updateMap {
 		field, exists := target.fields[k]
 		if !exists {
@@ -944,34 +1605,91 @@ func (c *SecondLevelCache) updateValue(tx *Tx, target *StructValue, updateMap ma
 		}
 
 		// remove cache key by old unique key or old key
-		if err := c.deleteCacheKeyByOldValue(tx, k, target); err != nil {
+		if err := c.deleteCacheKeyByOldValue(ctx, tx, k, target); err != nil {
 			return xerrors.Errorf("failed to delete cache key by value before updating")
 		}
 
 		target.fields[k] = value // update indexed value
 
 		// remove cache key by new key
-		if err := c.updateOrDeleteCacheKeyByNewValue(tx, k, target); err != nil {
+		if err := c.updateOrDeleteCacheKeyByNewValue(ctx, tx, k, target); err != nil {
 			return xerrors.Errorf("failed to delete cache key by value after updating")
 		}
 	}
 	return nil
 }
 
-func (c *SecondLevelCache) UpdateByQueryBuilder(ctx context.Context, tx *Tx, builder *QueryBuilder, updateMap map[string]interface{}) (e error) {
+func (c *SecondLevelCache) applyEncodeHookToUpdateMap(updateMap map[string]interface{}) (map[string]interface{}, error) {
+	hook := c.opt.EncodeHook()
+	if hook == nil {
+		return updateMap, nil
+	}
+	value := &StructValue{
+		typ:    c.typ,
+		fields: map[string]*Value{},
+	}
+	for k, v := range updateMap {
+		value.fields[k] = c.valueFactory.CreateValue(v)
+	}
+	if err := hook(value); err != nil {
+		return nil, xerrors.Errorf("failed to call encode hook for %s: %w", c.typ.tableName, err)
+	}
+	hookedUpdateMap := map[string]interface{}{}
+	for k, v := range value.fields {
+		if v == nil || v.IsNil {
+			hookedUpdateMap[k] = nil
+			continue
+		}
+		hookedUpdateMap[k] = v.RawValue()
+	}
+	return hookedUpdateMap, nil
+}
+
+func (c *SecondLevelCache) UpdateByQueryBuilder(ctx context.Context, tx *Tx, builder *QueryBuilder, updateMap map[string]interface{}) error {
+	if _, err := c.UpdateRowsByQueryBuilder(ctx, tx, builder, updateMap); err != nil {
+		return xerrors.Errorf("failed to update rows by query builder: %w", err)
+	}
+	return nil
+}
+
+func (c *SecondLevelCache) UpdateRowsByQueryBuilder(ctx context.Context, tx *Tx, builder *QueryBuilder, updateMap map[string]interface{}) (affected int64, e error) {
 	defer builder.Release()
+	if err := c.typ.encryptConditions(builder); err != nil {
+		return 0, xerrors.Errorf("failed to encrypt conditions: %w", err)
+	}
+	updateMap, err := c.typ.encryptUpdateMap(updateMap)
+	if err != nil {
+		return 0, xerrors.Errorf("failed to encrypt update map: %w", err)
+	}
+	updateMap, err = c.applyEncodeHookToUpdateMap(updateMap)
+	if err != nil {
+		return 0, xerrors.Errorf("failed to apply encode hook: %w", err)
+	}
+	if err := c.validateNotNullUpdateMap(updateMap); err != nil {
+		return 0, xerrors.Errorf("invalid update map: %w", err)
+	}
+	if c.cacheOnly {
+		affected, err := c.updateCacheOnly(ctx, tx, builder, updateMap)
+		if err != nil {
+			return 0, xerrors.Errorf("failed to update values of cache only table: %w", err)
+		}
+		return affected, nil
+	}
 	var foundValues *StructSliceValue
 	if builder.AvailableCache() {
 		values, err := c.findValuesByQueryBuilder(ctx, tx, builder)
 		if err != nil {
-			return xerrors.Errorf("failed to find values by query builder: %w", err)
+			return 0, xerrors.Errorf("failed to find values by query builder: %w", err)
 		}
 		foundValues = values
 	} else {
 		sql, args := builder.SelectSQL(c.valueFactory, c.typ)
-		rows, err := tx.conn.QueryContext(ctx, sql, args...)
+		sql = c.fallbackQuery(sql)
+		queryStart := time.Now()
+		rows, err := tx.connection(c).QueryContext(ctx, sql, args...)
+		c.observeDBQuery(queryStart)
 		if err != nil {
-			return xerrors.Errorf("failed sql %s %v: %w", sql, args, err)
+			return 0, xerrors.Errorf("failed sql %s %v: %w", sql, args, err)
 		}
 		defer func() {
 			if err := rows.Close(); err != nil {
@@ -982,43 +1700,64 @@ func (c *SecondLevelCache) UpdateByQueryBuilder(ctx context.Context, tx *Tx, bui
 		for rows.Next() {
 			scanValues := c.typ.ScanValues(c.valueFactory)
 			if err := rows.Scan(scanValues...); err != nil {
-				return xerrors.Errorf("failed to scan: %w", err)
+				return 0, xerrors.Errorf("failed to scan: %w", err)
 			}
 			value := c.typ.StructValue(scanValues)
 			foundValues.Append(value)
-			log.GetFromDB(tx.id, sql, "", value)
+			tx.logger().GetFromDB(tx.id, sql, "", value)
 		}
 	}
 	sql, values := builder.UpdateSQL(c.valueFactory, updateMap)
-	if _, err := tx.conn.ExecContext(ctx, sql, values...); err != nil {
-		return xerrors.Errorf("failed update sql %s %v: %w", sql, values, err)
+	queryStart := time.Now()
+	result, err := tx.connection(c).ExecContext(ctx, sql, values...)
+	c.observeDBQuery(queryStart)
+	if err != nil {
+		return 0, xerrors.Errorf("failed update sql %s %v: %w", sql, values, err)
 	}
-	log.UpdateForDB(tx.id, sql, values, LogMap(updateMap))
+	affected, err = result.RowsAffected()
+	if err != nil {
+		return 0, xerrors.Errorf("failed to get rows affected: %w", err)
+	}
+	tx.logger().UpdateForDB(tx.id, sql, values, LogMap(updateMap))
+	logRowsAffected(tx.id, sql, SLCCommandUpdate, affected)
+	c.auditUpdate(tx, foundValues, updateMap)
 	if builder.isIgnoreCache {
-		return nil
+		return affected, nil
 	}
 	queries, err := builder.BuildWithIndex(c.valueFactory, c.indexes, c.typ)
 	if err != nil {
-		return xerrors.Errorf("failed to build query: %w", err)
+		return 0, xerrors.Errorf("failed to build query: %w", err)
 	}
 	for idx, value := range foundValues.values {
-		if err := c.updateValue(tx, value, updateMap); err != nil {
-			return xerrors.Errorf("faield to update value: %w", err)
+		if err := c.deleteClusterKeyByValue(ctx, tx, value); err != nil {
+			return 0, xerrors.Errorf("failed to delete cluster key by old value: %w", err)
+		}
+		if err := c.deleteCountKeysByValue(ctx, tx, value, updateMap); err != nil {
+			return 0, xerrors.Errorf("failed to delete count keys by old value: %w", err)
+		}
+		if err := c.updateValue(ctx, tx, value, updateMap); err != nil {
+			return 0, xerrors.Errorf("faield to update value: %w", err)
+		}
+		if err := c.deleteClusterKeyByValue(ctx, tx, value); err != nil {
+			return 0, xerrors.Errorf("failed to delete cluster key by new value: %w", err)
+		}
+		if err := c.deleteCountKeysByValue(ctx, tx, value, updateMap); err != nil {
+			return 0, xerrors.Errorf("failed to delete count keys by new value: %w", err)
 		}
 		if builder.AvailableCache() {
-			if err := c.updateByQueryWithValue(tx, queries.At(idx), value); err != nil {
-				return xerrors.Errorf("failed to update by query with value: %w", err)
+			if err := c.updateByQueryWithValue(ctx, tx, queries.At(idx), value); err != nil {
+				return 0, xerrors.Errorf("failed to update by query with value: %w", err)
 			}
 		} else {
-			if err := c.updateByValue(tx, value, updateMap); err != nil {
-				return xerrors.Errorf("failed to update by value: %w", err)
+			if err := c.updateByValue(ctx, tx, value, updateMap); err != nil {
+				return 0, xerrors.Errorf("failed to update by value: %w", err)
 			}
 		}
 	}
-	return nil
+	return affected, nil
 }
 
-func (c *SecondLevelCache) updateByValue(tx *Tx, value *StructValue, updateMap map[string]interface{}) error {
+func (c *SecondLevelCache) updateByValue(ctx context.Context, tx *Tx, value *StructValue, updateMap map[string]interface{}) error {
 	for _, index := range c.indexes {
 		builder := c.updateBuilderByValue(value, index, updateMap)
 		if builder == nil {
@@ -1029,7 +1768,7 @@ func (c *SecondLevelCache) updateByValue(tx *Tx, value *StructValue, updateMap m
 			return xerrors.Errorf("failed to build query: %w", err)
 		}
 		for i := 0; i < queries.Len(); i++ {
-			if err := c.updateByQueryWithValue(tx, queries.At(i), value); err != nil {
+			if err := c.updateByQueryWithValue(ctx, tx, queries.At(i), value); err != nil {
 				return xerrors.Errorf("failed to update by query with value: %w", err)
 			}
 		}
@@ -1037,12 +1776,12 @@ func (c *SecondLevelCache) updateByValue(tx *Tx, value *StructValue, updateMap m
 	return nil
 }
 
-func (c *SecondLevelCache) updateByQueryWithValue(tx *Tx, query *Query, value *StructValue) error {
+func (c *SecondLevelCache) updateByQueryWithValue(ctx context.Context, tx *Tx, query *Query, value *StructValue) error {
 	cacheKey := query.cacheKey
 	index := query.Index()
 	switch index.Type {
 	case IndexTypePrimaryKey:
-		if err := c.updatePrimaryKey(tx, cacheKey, value); err != nil {
+		if err := c.updatePrimaryKey(ctx, tx, cacheKey, value); err != nil {
 			return xerrors.Errorf("failed to update primary key", err)
 		}
 	case IndexTypeUniqueKey:
@@ -1055,11 +1794,11 @@ func (c *SecondLevelCache) updateByQueryWithValue(tx *Tx, query *Query, value *S
 			return xerrors.Errorf("failed to get cache key: %w", err)
 		}
 		if cacheKey != newCacheKey {
-			if err := c.setUniqueKey(tx, newCacheKey, primaryKey); err != nil {
+			if err := c.setUniqueKey(ctx, tx, newCacheKey, primaryKey); err != nil {
 				return xerrors.Errorf("failed to set unique key: %w", err)
 			}
 		}
-		if err := c.updatePrimaryKey(tx, primaryKey, value); err != nil {
+		if err := c.updatePrimaryKey(ctx, tx, primaryKey, value); err != nil {
 			return xerrors.Errorf("failed to update primary key: %w", err)
 		}
 	case IndexTypeKey:
@@ -1072,42 +1811,43 @@ func (c *SecondLevelCache) updateByQueryWithValue(tx *Tx, query *Query, value *S
 			return xerrors.Errorf("failed to get cache key: %w", err)
 		}
 		if cacheKey != newCacheKey {
-			if err := c.deleteOldKey(tx, newCacheKey); err != nil {
+			if err := c.deleteOldKey(ctx, tx, newCacheKey); err != nil {
 				return xerrors.Errorf("failed to delete old key: %w", err)
 			}
 		}
-		if err := c.updatePrimaryKey(tx, primaryKey, value); err != nil {
+		if err := c.updatePrimaryKey(ctx, tx, primaryKey, value); err != nil {
 			return xerrors.Errorf("failed to update primary key: %w", err)
 		}
 	}
 	return nil
 }
 
-func (c *SecondLevelCache) createNegativeCacheByQuery(tx *Tx, query *Query) error {
+func (c *SecondLevelCache) createNegativeCacheByQuery(ctx context.Context, tx *Tx, query *Query) error {
 	cacheKey := query.cacheKey
 	switch query.Index().Type {
 	case IndexTypePrimaryKey:
-		if err := c.setPrimaryKey(tx, cacheKey, nil); err != nil {
+		if err := c.fillPrimaryKey(ctx, tx, cacheKey, nil); err != nil {
 			return xerrors.Errorf("failed to set primary key: %w", err)
 		}
 	case IndexTypeUniqueKey:
-		if err := c.setUniqueKey(tx, cacheKey, nil); err != nil {
+		if err := c.fillUniqueKey(ctx, tx, cacheKey, nil); err != nil {
 			return xerrors.Errorf("failed to set unique key: %w", err)
 		}
 	case IndexTypeKey:
-		if err := c.setKey(tx, cacheKey, []server.CacheKey{}); err != nil {
+		c.recordIndexSelectivity(query.Index(), 0)
+		if err := c.fillKey(ctx, tx, cacheKey, []server.CacheKey{}); err != nil {
 			return xerrors.Errorf("failed to set key: %w", err)
 		}
 	}
 	return nil
 }
 
-func (c *SecondLevelCache) createByQueryWithValue(tx *Tx, query *Query, value *StructValue) error {
+func (c *SecondLevelCache) createByQueryWithValue(ctx context.Context, tx *Tx, query *Query, value *StructValue) error {
 	cacheKey := query.cacheKey
 	index := query.Index()
 	switch index.Type {
 	case IndexTypePrimaryKey:
-		if err := c.setPrimaryKey(tx, cacheKey, value); err != nil {
+		if err := c.fillPrimaryKey(ctx, tx, cacheKey, value); err != nil {
 			return xerrors.Errorf("failed to set primary key: %w", err)
 		}
 	case IndexTypeUniqueKey:
@@ -1115,10 +1855,10 @@ func (c *SecondLevelCache) createByQueryWithValue(tx *Tx, query *Query, value *S
 		if err != nil {
 			return xerrors.Errorf("failed to get cache key: %w", err)
 		}
-		if err := c.setUniqueKey(tx, cacheKey, primaryKey); err != nil {
+		if err := c.fillUniqueKey(ctx, tx, cacheKey, primaryKey); err != nil {
 			return xerrors.Errorf("failed to set unique key: %w", err)
 		}
-		if err := c.setPrimaryKey(tx, primaryKey, value); err != nil {
+		if err := c.fillPrimaryKey(ctx, tx, primaryKey, value); err != nil {
 			return xerrors.Errorf("failed to set primary key: %w", err)
 		}
 	case IndexTypeKey:
@@ -1126,17 +1866,18 @@ func (c *SecondLevelCache) createByQueryWithValue(tx *Tx, query *Query, value *S
 		if err != nil {
 			return xerrors.Errorf("failed to get cache key: %w", err)
 		}
-		if err := c.setKey(tx, cacheKey, []server.CacheKey{primaryKey}); err != nil {
+		c.recordIndexSelectivity(index, 1)
+		if err := c.fillKey(ctx, tx, cacheKey, []server.CacheKey{primaryKey}); err != nil {
 			return xerrors.Errorf("failed to set key: %w", err)
 		}
-		if err := c.setPrimaryKey(tx, primaryKey, value); err != nil {
+		if err := c.fillPrimaryKey(ctx, tx, primaryKey, value); err != nil {
 			return xerrors.Errorf("failed to set primary key: %w", err)
 		}
 	}
 	return nil
 }
 
-func (c *SecondLevelCache) createByQueryWithValues(tx *Tx, query *Query, values []*StructValue) error {
+func (c *SecondLevelCache) createByQueryWithValues(ctx context.Context, tx *Tx, query *Query, values []*StructValue) error {
 	cacheKey := query.cacheKey
 	index := query.Index()
 	switch index.Type {
@@ -1153,11 +1894,12 @@ func (c *SecondLevelCache) createByQueryWithValues(tx *Tx, query *Query, values
 			}
 			primaryKeys = append(primaryKeys, primaryKey)
 		}
-		if err := c.setKey(tx, cacheKey, primaryKeys); err != nil {
+		c.recordIndexSelectivity(index, len(primaryKeys))
+		if err := c.fillKey(ctx, tx, cacheKey, primaryKeys); err != nil {
 			return xerrors.Errorf("failed to set key: %w", err)
 		}
 		for idx, primaryKey := range primaryKeys {
-			if err := c.setPrimaryKey(tx, primaryKey, values[idx]); err != nil {
+			if err := c.fillPrimaryKey(ctx, tx, primaryKey, values[idx]); err != nil {
 				return xerrors.Errorf("failed to set primary key: %w", err)
 			}
 		}
@@ -1173,7 +1915,7 @@ func (c *SecondLevelCache) insertSQL(value *StructValue) (string, []interface{})
 		escapedColumns = append(escapedColumns, fmt.Sprintf("`%s`", column))
 		placeholders = append(placeholders, "?")
 		if value.fields[column] == nil {
-			values = append(values, nil)
+			values = append(values, value.typ.fields[column].insertValue())
 		} else {
 			values = append(values, value.fields[column].RawValue())
 		}
@@ -1186,70 +1928,125 @@ func (c *SecondLevelCache) insertSQL(value *StructValue) (string, []interface{})
 }
 
 func (c *SecondLevelCache) Create(ctx context.Context, tx *Tx, marshaler Marshaler) (id int64, e error) {
+	if c.cacheOnly {
+		id, e = c.createCacheOnly(ctx, tx, marshaler)
+		if e != nil {
+			e = xerrors.Errorf("failed to create value of cache only table: %w", e)
+		}
+		return
+	}
 	_, value, err := c.encode(marshaler)
 	if err != nil {
+		tx.abortByCoderPanic(err)
 		e = xerrors.Errorf("failed to encode: %w", err)
 		return
 	}
-	defer value.Release()
-	sql, values := c.insertSQL(value)
-	result, err := tx.conn.ExecContext(ctx, sql, values...)
+	releaseValue := true
+	defer func() {
+		if releaseValue {
+			value.Release()
+		}
+	}()
+	id, err = c.insertValue(ctx, tx, value)
 	if err != nil {
-		e = xerrors.Errorf("failed sql %s %v: %w", sql, values, err)
+		e = xerrors.Errorf("failed to insert value: %w", err)
 		return
 	}
-	lastInsertID, err := result.LastInsertId()
-	if err != nil {
-		e = xerrors.Errorf("failed to get last_insert_id(): %w", err)
+	c.auditCreate(tx, value)
+	if c.isWriteThroughOnCreate(value) {
+		// value is referenced by stash and pending queries until the end of transaction
+		releaseValue = false
+		if err := c.writeThroughOnCreate(ctx, tx, value); err != nil {
+			e = xerrors.Errorf("failed to write through on create: %w", err)
+			return
+		}
+	} else if err := c.deleteKeyByValue(ctx, tx, value); err != nil {
+		e = xerrors.Errorf("failed to delete key by value: %w", err)
 		return
 	}
-	id = lastInsertID
-	for _, column := range c.primaryKey.Columns {
-		if value.fields[column] == nil {
-			// if value for primary key is not defined,
-			// rapidash assume that result.LastInsertId() can use alternatively.
-			value.fields[column] = c.valueFactory.CreateInt64Value(lastInsertID)
-		}
+	if err := c.deleteClusterKeyByValue(ctx, tx, value); err != nil {
+		e = xerrors.Errorf("failed to delete cluster key by value: %w", err)
+		return
 	}
-	log.InsertIntoDB(tx.id, sql, values, value)
-	if err := c.deleteKeyByValue(tx, value); err != nil {
-		e = xerrors.Errorf("failed to delete key by value: %w", err)
+	if err := c.adjustCountKeysByValue(ctx, tx, value, 1); err != nil {
+		e = xerrors.Errorf("failed to increment count keys by value: %w", err)
 		return
 	}
 	return id, nil
 }
 
-func (c *SecondLevelCache) CreateWithoutCache(ctx context.Context, tx *Tx, marshaler Marshaler) (id int64, e error) {
-	_, value, err := c.encode(marshaler)
+// insertValue inserts value to database and returns its primary key.
+// If PrimaryKeyGenerator is set, primary key is generated before insert instead of LAST_INSERT_ID()
+func (c *SecondLevelCache) insertValue(ctx context.Context, tx *Tx, value *StructValue) (int64, error) {
+	generatedID, _, err := c.generatePrimaryKey(ctx, value)
 	if err != nil {
-		e = xerrors.Errorf("failed to encode: %w", err)
-		return
+		return 0, xerrors.Errorf("failed to generate primary key: %w", err)
 	}
-	defer value.Release()
 	sql, values := c.insertSQL(value)
-	result, err := tx.conn.ExecContext(ctx, sql, values...)
+	queryStart := time.Now()
+	result, err := tx.connection(c).ExecContext(ctx, sql, values...)
+	c.observeDBQuery(queryStart)
 	if err != nil {
-		e = xerrors.Errorf("failed sql %s %v: %w", sql, values, err)
-		return
+		return 0, xerrors.Errorf("failed sql %s %v: %w", sql, values, err)
+	}
+	if c.opt.PrimaryKeyGenerator() != nil {
+		tx.logger().InsertIntoDB(tx.id, sql, values, value)
+		return generatedID, nil
 	}
 	lastInsertID, err := result.LastInsertId()
 	if err != nil {
-		e = xerrors.Errorf("failed to get last_insert_id(): %w", err)
-		return
+		return 0, xerrors.Errorf("failed to get last_insert_id(): %w", err)
+	}
+	if err := c.setLastInsertID(value, lastInsertID); err != nil {
+		return 0, xerrors.Errorf("failed to set last_insert_id(): %w", err)
 	}
-	id = lastInsertID
+	tx.logger().InsertIntoDB(tx.id, sql, values, value)
+	return lastInsertID, nil
+}
+
+// setLastInsertID sets lastInsertID to primary key columns that value doesn't define.
+// driver returns unsigned BIGINT beyond math.MaxInt64 as negative lastInsertID, so it is restored for uint64 column
+func (c *SecondLevelCache) setLastInsertID(value *StructValue, lastInsertID int64) error {
 	for _, column := range c.primaryKey.Columns {
-		if value.fields[column] == nil {
-			// if value for primary key is not defined,
-			// rapidash assume that result.LastInsertId() can use alternatively.
-			value.fields[column] = c.valueFactory.CreateInt64Value(lastInsertID)
+		if value.fields[column] != nil {
+			continue
+		}
+		// if value for primary key is not defined,
+		// rapidash assume that result.LastInsertId() can use alternatively.
+		if field, exists := c.typ.fields[column]; exists && field.typ == Uint64Type {
+			value.fields[column] = c.valueFactory.CreateUint64Value(uint64(lastInsertID))
+			continue
+		}
+		if lastInsertID < 0 {
+			return xerrors.Errorf("%s.%s is %d: %w", c.typ.tableName, column, uint64(lastInsertID), ErrInt64Overflow)
 		}
+		value.fields[column] = c.valueFactory.CreateInt64Value(lastInsertID)
+	}
+	return nil
+}
+
+func (c *SecondLevelCache) CreateWithoutCache(ctx context.Context, tx *Tx, marshaler Marshaler) (id int64, e error) {
+	if c.cacheOnly {
+		e = xerrors.Errorf("%s can't be created without cache: %w", c.typ.tableName, ErrUnsupportedCacheOnlyQuery)
+		return
+	}
+	_, value, err := c.encode(marshaler)
+	if err != nil {
+		tx.abortByCoderPanic(err)
+		e = xerrors.Errorf("failed to encode: %w", err)
+		return
 	}
-	log.InsertIntoDB(tx.id, sql, values, value)
+	defer value.Release()
+	id, err = c.insertValue(ctx, tx, value)
+	if err != nil {
+		e = xerrors.Errorf("failed to insert value: %w", err)
+		return
+	}
+	c.auditCreate(tx, value)
 	return id, nil
 }
 
-func (c *SecondLevelCache) deleteKeyByQueryBuilder(tx *Tx, builder *QueryBuilder) error {
+func (c *SecondLevelCache) deleteKeyByQueryBuilder(ctx context.Context, tx *Tx, builder *QueryBuilder) error {
 	queries, err := builder.BuildWithIndex(c.valueFactory, c.indexes, c.typ)
 	if err != nil {
 		return xerrors.Errorf("failed to build query: %w", err)
@@ -1258,19 +2055,19 @@ func (c *SecondLevelCache) deleteKeyByQueryBuilder(tx *Tx, builder *QueryBuilder
 		switch indexType {
 		case IndexTypePrimaryKey:
 			for iter.Next() {
-				if err := c.deleteOldKey(tx, iter.Key()); err != nil {
+				if err := c.deleteOldKey(ctx, tx, iter.Key()); err != nil {
 					return xerrors.Errorf("failed to delete old key: %w", err)
 				}
 			}
 		case IndexTypeUniqueKey:
 			for iter.Next() {
-				if err := c.deleteOldKey(tx, iter.Key()); err != nil {
+				if err := c.deleteOldKey(ctx, tx, iter.Key()); err != nil {
 					return xerrors.Errorf("failed to delete old key: %w", err)
 				}
 			}
 		case IndexTypeKey:
 			for iter.Next() {
-				if err := c.deleteOldKey(tx, iter.Key()); err != nil {
+				if err := c.deleteOldKey(ctx, tx, iter.Key()); err != nil {
 					return xerrors.Errorf("failed to delete old key: %w", err)
 				}
 			}
@@ -1298,8 +2095,11 @@ func (c *SecondLevelCache) isUsedPrimaryKeyBuilder(queries *Queries) bool {
 
 func (c *SecondLevelCache) deleteCacheFromSQL(ctx context.Context, tx *Tx, builder *QueryBuilder) (e error) {
 	sql, args := builder.SelectSQL(c.valueFactory, c.typ)
+	sql = c.fallbackQuery(sql)
 
-	rows, err := tx.conn.QueryContext(ctx, sql, args...)
+	queryStart := time.Now()
+	rows, err := tx.connection(c).QueryContext(ctx, sql, args...)
+	c.observeDBQuery(queryStart)
 	if err != nil {
 		return xerrors.Errorf("failed sql %s %v: %w", sql, args, err)
 	}
@@ -1318,50 +2118,91 @@ func (c *SecondLevelCache) deleteCacheFromSQL(ctx context.Context, tx *Tx, build
 		if err != nil {
 			return xerrors.Errorf("failed to get cache key: %w", err)
 		}
-		if err := c.deletePrimaryKey(tx, primaryKey); err != nil {
+		if err := c.deletePrimaryKey(ctx, tx, primaryKey); err != nil {
 			return xerrors.Errorf("failed to delete primary key: %w", err)
 		}
+		if err := c.deleteClusterKeyByValue(ctx, tx, value); err != nil {
+			return xerrors.Errorf("failed to delete cluster key by value: %w", err)
+		}
+		if err := c.adjustCountKeysByValue(ctx, tx, value, -1); err != nil {
+			return xerrors.Errorf("failed to decrement count keys by value: %w", err)
+		}
 	}
 	return nil
 }
 
 func (c *SecondLevelCache) DeleteByQueryBuilder(ctx context.Context, tx *Tx, builder *QueryBuilder) error {
+	if _, err := c.DeleteRowsByQueryBuilder(ctx, tx, builder); err != nil {
+		return xerrors.Errorf("failed to delete rows by query builder: %w", err)
+	}
+	return nil
+}
+
+func (c *SecondLevelCache) DeleteRowsByQueryBuilder(ctx context.Context, tx *Tx, builder *QueryBuilder) (int64, error) {
 	defer builder.Release()
+	if err := c.typ.encryptConditions(builder); err != nil {
+		return 0, xerrors.Errorf("failed to encrypt conditions: %w", err)
+	}
+	if c.cacheOnly {
+		affected, err := c.deleteCacheOnly(ctx, tx, builder)
+		if err != nil {
+			return 0, xerrors.Errorf("failed to delete values of cache only table: %w", err)
+		}
+		return affected, nil
+	}
+	if err := c.auditDelete(ctx, tx, builder); err != nil {
+		return 0, xerrors.Errorf("failed to read records to audit: %w", err)
+	}
 	if !builder.AvailableCache() {
 		if !builder.isIgnoreCache {
 			if err := c.deleteCacheFromSQL(ctx, tx, builder); err != nil {
-				return xerrors.Errorf("failed to delete cache by SQL: %w", err)
+				return 0, xerrors.Errorf("failed to delete cache by SQL: %w", err)
 			}
 		}
-		sql, args := builder.DeleteSQL(c.valueFactory)
-		if _, err := tx.conn.ExecContext(ctx, sql, args...); err != nil {
-			return xerrors.Errorf("failed sql %s %v: %w", sql, args, err)
+		affected, err := c.execDeleteSQL(ctx, tx, builder)
+		if err != nil {
+			return 0, xerrors.Errorf("failed to exec delete sql: %w", err)
 		}
-		log.DeleteFromDB(tx.id, sql)
-		return nil
+		return affected, nil
 	}
 	queries, err := builder.BuildWithIndex(c.valueFactory, c.indexes, c.typ)
 	if err != nil {
-		return xerrors.Errorf("failed to build query: %w", err)
+		return 0, xerrors.Errorf("failed to build query: %w", err)
 	}
-	if !c.isUsedPrimaryKeyBuilder(queries) {
+	if !c.isUsedPrimaryKeyBuilder(queries) || c.opt.ClusterKey() != "" || c.opt.CountCache() {
 		if err := c.deleteCacheFromSQL(ctx, tx, builder); err != nil {
-			return xerrors.Errorf("failed to delete cache by SQL: %w", err)
+			return 0, xerrors.Errorf("failed to delete cache by SQL: %w", err)
 		}
 	} else {
 		for i := 0; i < queries.Len(); i++ {
 			cacheKey := queries.At(i).cacheKey
-			if err := c.deletePrimaryKey(tx, cacheKey); err != nil {
-				return xerrors.Errorf("failed to delete primary key: %w", err)
+			if err := c.deletePrimaryKey(ctx, tx, cacheKey); err != nil {
+				return 0, xerrors.Errorf("failed to delete primary key: %w", err)
 			}
 		}
 	}
+	affected, err := c.execDeleteSQL(ctx, tx, builder)
+	if err != nil {
+		return 0, xerrors.Errorf("failed to exec delete sql: %w", err)
+	}
+	return affected, nil
+}
+
+func (c *SecondLevelCache) execDeleteSQL(ctx context.Context, tx *Tx, builder *QueryBuilder) (int64, error) {
 	sql, args := builder.DeleteSQL(c.valueFactory)
-	if _, err := tx.conn.ExecContext(ctx, sql, args...); err != nil {
-		return xerrors.Errorf("failed sql %s %v: %w", sql, args, err)
+	queryStart := time.Now()
+	result, err := tx.connection(c).ExecContext(ctx, sql, args...)
+	c.observeDBQuery(queryStart)
+	if err != nil {
+		return 0, xerrors.Errorf("failed sql %s %v: %w", sql, args, err)
 	}
-	log.DeleteFromDB(tx.id, sql)
-	return nil
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, xerrors.Errorf("failed to get rows affected: %w", err)
+	}
+	tx.logger().DeleteFromDB(tx.id, sql)
+	logRowsAffected(tx.id, sql, SLCCommandDelete, affected)
+	return affected, nil
 }
 
 func (c *SecondLevelCache) builderByValue(value *StructValue, index *Index) *QueryBuilder {
@@ -1396,13 +2237,13 @@ func (c *SecondLevelCache) updateBuilderByValue(value *StructValue, index *Index
 	return nil
 }
 
-func (c *SecondLevelCache) deleteKeyByValue(tx *Tx, value *StructValue) error {
+func (c *SecondLevelCache) deleteKeyByValue(ctx context.Context, tx *Tx, value *StructValue) error {
 	for _, index := range c.indexes {
 		builder := c.builderByValue(value, index)
 		if builder == nil {
 			continue
 		}
-		if err := c.deleteKeyByQueryBuilder(tx, builder); err != nil {
+		if err := c.deleteKeyByQueryBuilder(ctx, tx, builder); err != nil {
 			return xerrors.Errorf("failed to delete key by query builder: %w", err)
 		}
 	}
@@ -1411,7 +2252,10 @@ func (c *SecondLevelCache) deleteKeyByValue(tx *Tx, value *StructValue) error {
 
 func (c *SecondLevelCache) findValuesByQueryBuilderWithoutCache(ctx context.Context, tx *Tx, builder *QueryBuilder) (ssv *StructSliceValue, e error) {
 	sql, args := builder.SelectSQL(c.valueFactory, c.typ)
-	rows, err := tx.conn.QueryContext(ctx, sql, args...)
+	sql = c.fallbackQuery(sql)
+	queryStart := time.Now()
+	rows, err := tx.connection(c).QueryContext(ctx, sql, args...)
+	c.observeDBQuery(queryStart)
 	if err != nil {
 		return nil, xerrors.Errorf("failed sql %s %v: %w", sql, args, err)
 	}
@@ -1428,13 +2272,212 @@ func (c *SecondLevelCache) findValuesByQueryBuilderWithoutCache(ctx context.Cont
 		}
 		value := c.typ.StructValue(scanValues)
 		foundValues.Append(value)
-		log.GetFromDB(tx.id, sql, "", value)
+		tx.logger().GetFromDB(tx.id, sql, "", value)
 	}
+	builder.info.addDBRows(foundValues.Len())
 	return foundValues, nil
 }
 
+func (c *SecondLevelCache) clusterCacheKey(value *Value) server.CacheKey {
+	column := c.opt.ClusterKey()
+	key := fmt.Sprintf("r/slc/%s/cluster/%s%s%s", c.keyTableName(), column, CacheKeyQueryKeyValueDelimiter, value.String())
+	if c.opt.ShardKey() == column {
+		return &CacheKey{key: key, hash: c.opt.cacheKeyHash.hashValue(value)}
+	}
+	return &CacheKey{key: key, hash: c.opt.cacheKeyHash.hashString(key)}
+}
+
+func (c *SecondLevelCache) isClusterKeyQuery(builder *QueryBuilder) bool {
+	column := c.opt.ClusterKey()
+	if column == "" {
+		return false
+	}
+	if builder.isIgnoreCache || builder.lockOpt != nil || builder.sqlCondition != nil {
+		return false
+	}
+	if builder.conditions.Len() != 1 {
+		return false
+	}
+	condition, ok := builder.conditions.conditions[0].(*EQCondition)
+	if !ok {
+		return false
+	}
+	return condition.column == column && condition.rawValue != nil
+}
+
+func (c *SecondLevelCache) encodeClusterValues(values *StructSliceValue) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	if err := enc.EncodeArrayHeader(values.Len()); err != nil {
+		return nil, xerrors.Errorf("failed to encode array header: %w", err)
+	}
+	codec := c.opt.Codec()
+	for _, value := range values.values {
+		if isDefaultCodec(codec) {
+			if err := value.encode(enc); err != nil {
+				return nil, xerrors.Errorf("failed to encode value: %w", err)
+			}
+			continue
+		}
+		content, err := codec.Encode(c.typ, value)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to encode value by codec: %w", err)
+		}
+		if err := enc.EncodeBytes(content); err != nil {
+			return nil, xerrors.Errorf("failed to encode content of value: %w", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *SecondLevelCache) findValuesByClusterKey(ctx context.Context, tx *Tx, builder *QueryBuilder) (*StructSliceValue, error) {
+	builder.Build(c.valueFactory)
+	if err := builder.validateCondition(c.typ); err != nil {
+		return nil, xerrors.Errorf("invalid query: %w", err)
+	}
+	key := c.clusterCacheKey(builder.conditions.conditions[0].Value())
+	if _, exists := tx.stash.oldKey[key.String()]; exists {
+		// need lookup db
+		values, err := c.findValuesByQueryBuilderWithoutCache(ctx, tx, builder)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to find values by query builder without cache: %w", err)
+		}
+		return values, nil
+	}
+	content, err := c.cacheServer.Get(ctx, key)
+	if err != nil && !IsCacheMiss(err) {
+		return nil, xerrors.Errorf("failed to get cluster values from server: %w", err)
+	}
+	if err == nil {
+		decoder := c.valueDecoder()
+		defer c.releaseValueDecoder(decoder)
+		var values *StructSliceValue
+		payload, err := c.decodePayload(content.Value)
+		if err == nil {
+			values, err = c.decodeValues(decoder, payload)
+			if err != nil && c.opt.structMigration != nil {
+				values, err = c.migrateSliceValue(ctx, key, content, payload)
+			}
+		}
+		if err == nil {
+			tx.logger().Get(tx.id, SLCServer, key, values)
+			tx.stash.casIDs[key.String()] = content.CasID
+			builder.info.addCacheRows(values.Len())
+			c.observeRead(1, 0)
+			c.observeClusterKey(true)
+			return values, nil
+		}
+		// if failed to decode cached values ( e.g. changed schema ), rebuild cache by database records.
+		atomic.AddUint64(&c.readRepairCount, 1)
+	}
+	builder.info.addMissedKeys(1)
+	c.observeRead(0, 1)
+	c.observeClusterKey(false)
+	values, err := c.findValuesByQueryBuilderWithoutCache(ctx, tx, builder)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to find values by query builder without cache: %w", err)
+	}
+	expiration := c.expiration()
+	for _, value := range values.values {
+		valueExpiration, expired := c.expirationByValue(value)
+		if expired {
+			return values, nil
+		}
+		if expiration == 0 || (valueExpiration != 0 && valueExpiration < expiration) {
+			expiration = valueExpiration
+		}
+	}
+	bytes, err := c.encodeClusterValues(values)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to encode cluster values: %w", err)
+	}
+	if err := c.setWithExpiration(ctx, tx, key, bytes, expiration, values); err != nil {
+		return nil, xerrors.Errorf("failed to set cluster values: %w", err)
+	}
+	return values, nil
+}
+
+func (c *SecondLevelCache) deleteClusterKeyByValue(ctx context.Context, tx *Tx, value *StructValue) error {
+	column := c.opt.ClusterKey()
+	if column == "" || value == nil {
+		return nil
+	}
+	v, exists := value.fields[column]
+	if !exists || v == nil || v.IsNil {
+		return nil
+	}
+	if err := c.deleteOldKey(ctx, tx, c.clusterCacheKey(v)); err != nil {
+		return xerrors.Errorf("failed to delete old key: %w", err)
+	}
+	return nil
+}
+
+func (c *SecondLevelCache) deleteClusterKeyByPrimaryKey(ctx context.Context, tx *Tx, key server.CacheKey, value *StructValue) error {
+	if c.opt.ClusterKey() == "" {
+		return nil
+	}
+	values, err := c.currentValuesByPrimaryKey(ctx, tx, key, value)
+	if err != nil {
+		return xerrors.Errorf("failed to get current values by primary key: %w", err)
+	}
+	for _, v := range values {
+		if err := c.deleteClusterKeyByValue(ctx, tx, v); err != nil {
+			return xerrors.Errorf("failed to delete cluster key by value: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *SecondLevelCache) deleteCountKeysByPrimaryKey(ctx context.Context, tx *Tx, key server.CacheKey, value *StructValue) error {
+	if !c.opt.CountCache() {
+		return nil
+	}
+	values, err := c.currentValuesByPrimaryKey(ctx, tx, key, value)
+	if err != nil {
+		return xerrors.Errorf("failed to get current values by primary key: %w", err)
+	}
+	for _, v := range values {
+		if err := c.deleteCountKeysByValue(ctx, tx, v, nil); err != nil {
+			return xerrors.Errorf("failed to delete count keys by value: %w", err)
+		}
+	}
+	return nil
+}
+
+// currentValuesByPrimaryKey returns value of key before this operation from stash or database
+func (c *SecondLevelCache) currentValuesByPrimaryKey(ctx context.Context, tx *Tx, key server.CacheKey, value *StructValue) ([]*StructValue, error) {
+	if v, exists := tx.stash.primaryKeyToValue[key.String()]; exists && v != nil {
+		return []*StructValue{v}, nil
+	}
+	// lookup current record from database because index columns may be changed by this operation
+	builder := NewQueryBuilder(c.typ.tableName)
+	defer builder.Release()
+	for _, column := range c.primaryKey.Columns {
+		v, exists := value.fields[column]
+		if !exists || v == nil {
+			return nil, xerrors.Errorf("failed to get value for %s.%s", c.typ.tableName, column)
+		}
+		builder.Eq(column, v.RawValue())
+	}
+	values, err := c.findValuesByQueryBuilderWithoutCache(ctx, tx, builder)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to find values by query builder without cache: %w", err)
+	}
+	return values.values, nil
+}
+
 func (c *SecondLevelCache) CountByQueryBuilder(ctx context.Context, tx *Tx, builder *QueryBuilder) (uint64, error) {
 	defer builder.Release()
+	if err := c.typ.encryptConditions(builder); err != nil {
+		return 0, xerrors.Errorf("failed to encrypt conditions: %w", err)
+	}
+	if index := c.countCacheIndex(builder); index != nil {
+		count, err := c.countByCountCache(ctx, tx, builder, index)
+		if err != nil {
+			return 0, xerrors.Errorf("failed to count by count cache: %w", err)
+		}
+		return count, nil
+	}
 	values, err := c.findValuesByQueryBuilder(ctx, tx, builder)
 	if err != nil {
 		return 0, xerrors.Errorf("failed to count by query builder: %w", err)
@@ -3,8 +3,10 @@ package rapidash
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"fmt"
 	"net"
+	"strings"
 	"testing"
 	"time"
 
@@ -224,7 +226,7 @@ func testSimpleRead(t *testing.T, typ CacheServerType) {
 	NoError(t, initCache(conn, typ))
 	userLogin := defaultUserLogin()
 	slc := NewSecondLevelCache(userLoginType(), cache.cacheServer, TableOption{})
-	NoError(t, slc.cacheServer.Flush())
+	NoError(t, slc.cacheServer.Flush(context.Background()))
 	NoError(t, slc.WarmUp(conn))
 
 	t.Run("found value from db", func(t *testing.T) {
@@ -403,7 +405,7 @@ func testSimpleReadWithPessimisticLock(t *testing.T, typ CacheServerType) {
 		lockExpiration:  &lockExpiration,
 		expiration:      &expiration,
 	})
-	NoError(t, slc.cacheServer.Flush())
+	NoError(t, slc.cacheServer.Flush(context.Background()))
 	NoError(t, slc.WarmUp(conn))
 
 	txConn, err := conn.Begin()
@@ -440,7 +442,7 @@ func testSimpleCreate(t *testing.T, typ CacheServerType) {
 	NoError(t, initCache(conn, typ))
 	userLogin := defaultUserLogin()
 	slc := NewSecondLevelCache(userLoginType(), cache.cacheServer, TableOption{})
-	NoError(t, slc.cacheServer.Flush())
+	NoError(t, slc.cacheServer.Flush(context.Background()))
 	NoError(t, slc.WarmUp(conn))
 
 	txConn, err := conn.Begin()
@@ -492,7 +494,7 @@ func testSimpleUpdate(t *testing.T, typ CacheServerType) {
 
 	newName := "rapidash_2"
 	v.Name = newName
-	NoError(t, slc.UpdateByPrimaryKey(tx, &v))
+	NoError(t, slc.UpdateByPrimaryKey(context.Background(), tx, &v))
 
 	var v2 UserLogin
 	NoError(t, slc.FindByQueryBuilder(context.Background(), tx, builder, &v2))
@@ -529,7 +531,7 @@ func testSimpleDelete(t *testing.T, typ CacheServerType) {
 	if v.ID != userLogin.ID {
 		t.Fatal("cannot read uint64 value")
 	}
-	NoError(t, slc.DeleteByPrimaryKey(tx, NewUint64Value(1)))
+	NoError(t, slc.DeleteByPrimaryKey(context.Background(), tx, NewUint64Value(1)))
 	NoError(t, tx.Commit())
 }
 
@@ -545,7 +547,7 @@ func testCreateWithoutCache(t *testing.T, typ CacheServerType) {
 
 	userLogin := defaultUserLogin()
 	slc := NewSecondLevelCache(userLoginType(), cache.cacheServer, TableOption{})
-	NoError(t, slc.cacheServer.Flush())
+	NoError(t, slc.cacheServer.Flush(context.Background()))
 	NoError(t, slc.WarmUp(conn))
 
 	txConn, err := conn.Begin()
@@ -631,7 +633,7 @@ func testFindByQueryBuilder(t *testing.T, typ CacheServerType) {
 		NoError(t, initUserLoginTable(conn))
 		NoError(t, initCache(conn, typ))
 		slc := NewSecondLevelCache(userLoginType(), cache.cacheServer, TableOption{})
-		NoError(t, slc.cacheServer.Flush())
+		NoError(t, slc.cacheServer.Flush(context.Background()))
 		NoError(t, slc.WarmUp(conn))
 
 		builder := NewQueryBuilder("user_logins").
@@ -691,7 +693,7 @@ func testFindByQueryBuilder(t *testing.T, typ CacheServerType) {
 		NoError(t, initUserLoginTable(conn))
 		NoError(t, initCache(conn, typ))
 		slc := NewSecondLevelCache(userLoginType(), cache.cacheServer, TableOption{})
-		NoError(t, slc.cacheServer.Flush())
+		NoError(t, slc.cacheServer.Flush(context.Background()))
 		NoError(t, slc.WarmUp(conn))
 
 		builder := NewQueryBuilder("user_logins").
@@ -721,7 +723,7 @@ func testFindByQueryBuilder(t *testing.T, typ CacheServerType) {
 			defer func() { NoError(t, txConn.Rollback()) }()
 			tx, err := cache.Begin(txConn)
 			NoError(t, err)
-			NoError(t, slc.DeleteByPrimaryKey(tx, NewUint64Value(2)))
+			NoError(t, slc.DeleteByPrimaryKey(context.Background(), tx, NewUint64Value(2)))
 			NoError(t, tx.CommitCacheOnly())
 		}
 
@@ -805,9 +807,9 @@ func testFindByQueryBuilder(t *testing.T, typ CacheServerType) {
 			defer func() { NoError(t, txConn.Rollback()) }()
 			tx, err := cache.Begin(txConn)
 			NoError(t, err)
-			NoError(t, slc.DeleteByPrimaryKey(tx, NewUint64Value(5)))
-			NoError(t, slc.DeleteByPrimaryKey(tx, NewUint64Value(1001)))
-			NoError(t, slc.delete(tx, &CacheKey{key: "r/slc/user_logins/idx/user_id#5&login_param_id#2"}))
+			NoError(t, slc.DeleteByPrimaryKey(context.Background(), tx, NewUint64Value(5)))
+			NoError(t, slc.DeleteByPrimaryKey(context.Background(), tx, NewUint64Value(1001)))
+			NoError(t, slc.delete(context.Background(), tx, &CacheKey{key: "r/slc/user_logins/idx/user_id#5&login_param_id#2"}))
 			NoError(t, tx.CommitCacheOnly())
 		}
 
@@ -830,7 +832,7 @@ func testFindByQueryBuilder(t *testing.T, typ CacheServerType) {
 		NoError(t, initUserLoginTable(conn))
 		NoError(t, initCache(conn, typ))
 		slc := NewSecondLevelCache(userLoginType(), cache.cacheServer, TableOption{})
-		NoError(t, slc.cacheServer.Flush())
+		NoError(t, slc.cacheServer.Flush(context.Background()))
 		NoError(t, slc.WarmUp(conn))
 
 		txConn, err := conn.Begin()
@@ -983,7 +985,7 @@ func testUpdateUniqueKeyColumn(t *testing.T, typ CacheServerType) {
 	NoError(t, initUserLoginTable(conn))
 	NoError(t, initCache(conn, typ))
 	slc := NewSecondLevelCache(userLoginType(), cache.cacheServer, TableOption{})
-	NoError(t, slc.cacheServer.Flush())
+	NoError(t, slc.cacheServer.Flush(context.Background()))
 	NoError(t, slc.WarmUp(conn))
 
 	builder := NewQueryBuilder("user_logins").
@@ -1020,7 +1022,7 @@ func testUpdateKeyColumn(t *testing.T, typ CacheServerType) {
 	NoError(t, initUserLoginTable(conn))
 	NoError(t, initCache(conn, typ))
 	slc := NewSecondLevelCache(userLoginType(), cache.cacheServer, TableOption{})
-	NoError(t, slc.cacheServer.Flush())
+	NoError(t, slc.cacheServer.Flush(context.Background()))
 	NoError(t, slc.WarmUp(conn))
 	builder := NewQueryBuilder("user_logins").
 		In("user_id", []uint64{1, 2, 3, 4, 5}).
@@ -1050,7 +1052,7 @@ func TestUniqueIndexColumnUpdateByPrimaryKey(t *testing.T) {
 	NoError(t, initUserLoginTable(conn))
 	NoError(t, initCache(conn, CacheServerTypeMemcached))
 	slc := NewSecondLevelCache(userLoginType(), cache.cacheServer, TableOption{})
-	NoError(t, slc.cacheServer.Flush())
+	NoError(t, slc.cacheServer.Flush(context.Background()))
 	NoError(t, slc.WarmUp(conn))
 
 	// set unique index cache
@@ -1139,7 +1141,7 @@ func TestIndexColumnUpdateByPrimaryKey(t *testing.T) {
 	NoError(t, initUserLoginTable(conn))
 	NoError(t, initCache(conn, CacheServerTypeMemcached))
 	slc := NewSecondLevelCache(userLoginType(), cache.cacheServer, TableOption{})
-	NoError(t, slc.cacheServer.Flush())
+	NoError(t, slc.cacheServer.Flush(context.Background()))
 	NoError(t, slc.WarmUp(conn))
 
 	// set index cache
@@ -1228,7 +1230,7 @@ func TestLockingRead(t *testing.T) {
 	NoError(t, initUserLoginTable(conn))
 	NoError(t, initCache(conn, CacheServerTypeMemcached))
 	slc := NewSecondLevelCache(userLoginType(), cache.cacheServer, TableOption{})
-	NoError(t, slc.cacheServer.Flush())
+	NoError(t, slc.cacheServer.Flush(context.Background()))
 	NoError(t, slc.WarmUp(conn))
 
 	txConn, err := conn.Begin()
@@ -1858,7 +1860,7 @@ func BenchmarkSLCIN_SimpleMemcachedAccess(b *testing.B) {
 	}
 	b.ResetTimer()
 	for n := 0; n < b.N; n++ {
-		if _, err := slc.cacheServer.GetMulti([]server.CacheKey{
+		if _, err := slc.cacheServer.GetMulti(context.Background(), []server.CacheKey{
 			StringCacheKey("r/slc/user_logins/uq/user_id#1&user_session_id#1"),
 			StringCacheKey("r/slc/user_logins/uq/user_id#2&user_session_id#1"),
 			StringCacheKey("r/slc/user_logins/uq/user_id#3&user_session_id#1"),
@@ -1867,7 +1869,7 @@ func BenchmarkSLCIN_SimpleMemcachedAccess(b *testing.B) {
 		}); err != nil {
 			panic(err)
 		}
-		if _, err := slc.cacheServer.GetMulti([]server.CacheKey{
+		if _, err := slc.cacheServer.GetMulti(context.Background(), []server.CacheKey{
 			StringCacheKey("r/slc/user_logins/id#1"),
 			StringCacheKey("r/slc/user_logins/id#2"),
 			StringCacheKey("r/slc/user_logins/id#3"),
@@ -1904,7 +1906,7 @@ func BenchmarkSLCIN_SimpleRedisAccess(b *testing.B) {
 	}
 	b.ResetTimer()
 	for n := 0; n < b.N; n++ {
-		if _, err := slc.cacheServer.GetMulti([]server.CacheKey{
+		if _, err := slc.cacheServer.GetMulti(context.Background(), []server.CacheKey{
 			StringCacheKey("r/slc/user_logins/uq/user_id#1&user_session_id#1"),
 			StringCacheKey("r/slc/user_logins/uq/user_id#2&user_session_id#1"),
 			StringCacheKey("r/slc/user_logins/uq/user_id#3&user_session_id#1"),
@@ -1913,7 +1915,7 @@ func BenchmarkSLCIN_SimpleRedisAccess(b *testing.B) {
 		}); err != nil {
 			panic(err)
 		}
-		if _, err := slc.cacheServer.GetMulti([]server.CacheKey{
+		if _, err := slc.cacheServer.GetMulti(context.Background(), []server.CacheKey{
 			StringCacheKey("r/slc/user_logins/id#1"),
 			StringCacheKey("r/slc/user_logins/id#2"),
 			StringCacheKey("r/slc/user_logins/id#3"),
@@ -2203,3 +2205,754 @@ func TestWarmUp(t *testing.T) {
 		}
 	})
 }
+
+func TestClusterKey(t *testing.T) {
+	for cacheServerType := range []CacheServerType{CacheServerTypeMemcached, CacheServerTypeRedis} {
+		testClusterKey(t, CacheServerType(cacheServerType))
+	}
+}
+
+func testClusterKey(t *testing.T, typ CacheServerType) {
+	NoError(t, initUserLoginTable(conn))
+	NoError(t, initCache(conn, typ))
+	clusterKey := "user_id"
+	slc := NewSecondLevelCache(userLoginType(), cache.cacheServer, TableOption{clusterKey: &clusterKey})
+	NoError(t, slc.cacheServer.Flush(context.Background()))
+	NoError(t, slc.WarmUp(conn))
+
+	t.Run("create bundle", func(t *testing.T) {
+		txConn, err := conn.Begin()
+		NoError(t, err)
+		tx, err := cache.Begin(txConn)
+		NoError(t, err)
+		userLogin := defaultUserLogin()
+		userLogin.ID = 0
+		userLogin.UserSessionID = 2
+		_, err = slc.Create(context.Background(), tx, userLogin)
+		NoError(t, err)
+		var userLogins UserLogins
+		builder := NewQueryBuilder("user_logins").Eq("user_id", uint64(1))
+		NoError(t, slc.FindByQueryBuilder(context.Background(), tx, builder, &userLogins))
+		if len(userLogins) != 2 {
+			t.Fatalf("invalid user logins length %d", len(userLogins))
+		}
+		NoError(t, tx.Commit())
+	})
+	t.Run("find by bundle", func(t *testing.T) {
+		txConn, err := conn.Begin()
+		NoError(t, err)
+		tx, err := cache.Begin(txConn)
+		NoError(t, err)
+		var userLogins UserLogins
+		builder := NewQueryBuilder("user_logins").Eq("user_id", uint64(1))
+		NoError(t, slc.FindByQueryBuilder(context.Background(), tx, builder, &userLogins))
+		if len(userLogins) != 2 {
+			t.Fatalf("invalid user logins length %d", len(userLogins))
+		}
+		NoError(t, tx.Commit())
+	})
+	t.Run("invalidate bundle", func(t *testing.T) {
+		txConn, err := conn.Begin()
+		NoError(t, err)
+		tx, err := cache.Begin(txConn)
+		NoError(t, err)
+		builder := NewQueryBuilder("user_logins").Eq("id", uint64(1))
+		NoError(t, slc.UpdateByQueryBuilder(context.Background(), tx, builder, map[string]interface{}{
+			"name": "rapidash_cluster",
+		}))
+		var userLogins UserLogins
+		NoError(t, slc.FindByQueryBuilder(context.Background(), tx, NewQueryBuilder("user_logins").Eq("user_id", uint64(1)), &userLogins))
+		if len(userLogins) != 2 {
+			t.Fatalf("invalid user logins length %d", len(userLogins))
+		}
+		NoError(t, tx.Commit())
+
+		txConn, err = conn.Begin()
+		NoError(t, err)
+		tx, err = cache.Begin(txConn)
+		NoError(t, err)
+		userLogins = UserLogins{}
+		NoError(t, slc.FindByQueryBuilder(context.Background(), tx, NewQueryBuilder("user_logins").Eq("user_id", uint64(1)), &userLogins))
+		for _, userLogin := range userLogins {
+			if userLogin.ID == 1 && userLogin.Name != "rapidash_cluster" {
+				t.Fatal("cannot invalidate bundle")
+			}
+		}
+		NoError(t, tx.Commit())
+	})
+}
+
+type queryContextRecorder struct {
+	execRecorder
+	ctx context.Context
+}
+
+func (c *queryContextRecorder) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	c.ctx = ctx
+	return c.execRecorder.QueryContext(ctx, query, args...)
+}
+
+func TestClusterKeyContext(t *testing.T) {
+	clusterKey := "user_id"
+	r, err := New()
+	NoError(t, err)
+	slc := NewSecondLevelCache(userLoginType(), newMemoryCacheServer(), TableOption{clusterKey: &clusterKey})
+	slc.primaryKey = NewPrimaryKey(slc.opt, "user_logins", []string{"id"}, slc.typ)
+	conn := &queryContextRecorder{}
+	tx, err := r.Begin(conn)
+	NoError(t, err)
+	defer func() { NoError(t, tx.Rollback()) }()
+
+	_, value, err := slc.encode(&UserLogin{ID: 1, UserID: 10})
+	NoError(t, err)
+	key, err := slc.primaryKey.CacheKey(value)
+	NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	Error(t, slc.deleteClusterKeyByPrimaryKey(ctx, tx, key, value))
+	if conn.ctx != ctx {
+		t.Fatal("caller's context must be used to lookup current record")
+	}
+}
+
+func TestExpirationColumn(t *testing.T) {
+	expiration := time.Hour
+	column := "updated_at"
+	slc := NewSecondLevelCache(userLoginType(), nil, TableOption{
+		expiration:       &expiration,
+		expirationColumn: &column,
+	})
+	newValue := func(updatedAt time.Time) *StructValue {
+		enc := NewStructEncoder(userLoginType(), slc.valueFactory)
+		userLogin := defaultUserLogin()
+		userLogin.UpdatedAt = &updatedAt
+		NoError(t, userLogin.EncodeRapidash(enc))
+		return enc.value
+	}
+	t.Run("shorter than table expiration", func(t *testing.T) {
+		exp, expired := slc.expirationByValue(newValue(time.Now().Add(10 * time.Minute)))
+		if expired {
+			t.Fatal("value is expired")
+		}
+		if exp > 10*time.Minute || exp < 9*time.Minute {
+			t.Fatalf("invalid expiration %s", exp)
+		}
+	})
+	t.Run("longer than table expiration", func(t *testing.T) {
+		exp, expired := slc.expirationByValue(newValue(time.Now().Add(2 * time.Hour)))
+		if expired {
+			t.Fatal("value is expired")
+		}
+		Equal(t, exp, time.Hour)
+	})
+	t.Run("already expired", func(t *testing.T) {
+		_, expired := slc.expirationByValue(newValue(time.Now().Add(-time.Minute)))
+		if !expired {
+			t.Fatal("value is not expired")
+		}
+	})
+	t.Run("longer than max relative expiration", func(t *testing.T) {
+		unlimited := NewSecondLevelCache(userLoginType(), nil, TableOption{expirationColumn: &column})
+		exp, expired := unlimited.expirationByValue(newValue(time.Now().Add(365 * 24 * time.Hour)))
+		Equal(t, expired, false)
+		Equal(t, exp, maxRelativeExpiration)
+	})
+}
+
+func TestDefaultOrder(t *testing.T) {
+	NoError(t, initUserLoginTable(conn))
+	NoError(t, initCache(conn, CacheServerTypeMemcached))
+	slc := NewSecondLevelCache(userLoginType(), cache.cacheServer, TableOption{
+		defaultOrders: []*OrderCondition{{column: "user_id", isAsc: false}},
+	})
+	NoError(t, slc.cacheServer.Flush(context.Background()))
+	NoError(t, slc.WarmUp(conn))
+
+	builder := func() *QueryBuilder {
+		return NewQueryBuilder("user_logins").
+			In("user_id", []uint64{3, 1, 5, 2, 4}).
+			Eq("login_param_id", uint64(1))
+	}
+	for _, source := range []string{"db", "cache"} {
+		t.Run(source, func(t *testing.T) {
+			txConn, err := conn.Begin()
+			NoError(t, err)
+			tx, err := cache.Begin(txConn)
+			NoError(t, err)
+			var userLogins UserLogins
+			NoError(t, slc.FindByQueryBuilder(context.Background(), tx, builder(), &userLogins))
+			Equal(t, len(userLogins), 5)
+			for idx, userLogin := range userLogins {
+				Equal(t, userLogin.UserID, uint64(5-idx))
+			}
+			NoError(t, tx.Commit())
+		})
+	}
+
+	t.Run("unknown column", func(t *testing.T) {
+		slc := NewSecondLevelCache(userLoginType(), cache.cacheServer, TableOption{
+			defaultOrders: []*OrderCondition{{column: "unknown", isAsc: true}},
+		})
+		Error(t, slc.WarmUp(conn))
+	})
+}
+
+func TestDisableStash(t *testing.T) {
+	NoError(t, initUserLoginTable(conn))
+	NoError(t, initCache(conn, CacheServerTypeMemcached))
+	disabled := true
+	slc := NewSecondLevelCache(userLoginType(), cache.cacheServer, TableOption{
+		disableStash: &disabled,
+	})
+	NoError(t, slc.cacheServer.Flush(context.Background()))
+	NoError(t, slc.WarmUp(conn))
+
+	txConn, err := conn.Begin()
+	NoError(t, err)
+	tx, err := cache.Begin(txConn)
+	NoError(t, err)
+	for i := 0; i < 2; i++ {
+		var userLogin UserLogin
+		builder := NewQueryBuilder("user_logins").Eq("id", uint64(1))
+		NoError(t, slc.FindByQueryBuilder(context.Background(), tx, builder, &userLogin))
+		Equal(t, userLogin.ID, uint64(1))
+		Equal(t, len(tx.stash.primaryKeyToValue), 0)
+	}
+	NoError(t, tx.Commit())
+
+	txConn, err = conn.Begin()
+	NoError(t, err)
+	tx, err = cache.Begin(txConn)
+	NoError(t, err)
+	builder := NewQueryBuilder("user_logins").Eq("id", uint64(1))
+	NoError(t, slc.UpdateByQueryBuilder(context.Background(), tx, builder, map[string]interface{}{
+		"name": "disable_stash",
+	}))
+	var userLogin UserLogin
+	NoError(t, slc.FindByQueryBuilder(context.Background(), tx, NewQueryBuilder("user_logins").Eq("id", uint64(1)), &userLogin))
+	Equal(t, userLogin.Name, "disable_stash")
+	NoError(t, tx.Commit())
+}
+
+func TestDisableStashReadAfterWrite(t *testing.T) {
+	r, err := New(SecondLevelCacheTableDisableStash("user_logins", true))
+	NoError(t, err)
+	cacheServer := newMemoryCacheServer()
+	r.cacheServer = cacheServer
+	slc := NewSecondLevelCache(userLoginType(), cacheServer, r.tableOption("user_logins"))
+	slc.primaryKey = NewPrimaryKey(slc.opt, "user_logins", []string{"id"}, slc.typ)
+	slc.indexes["id"] = slc.primaryKey
+	slc.indexes["user_session_id"] = NewUniqueKey(slc.opt, "user_logins", []string{"user_session_id"}, slc.typ)
+	slc.indexColumns["user_session_id"] = struct{}{}
+	r.secondLevelCaches.set("user_logins", slc)
+	ctx := context.Background()
+
+	uniqueKey := func(userSessionID uint64) server.CacheKey {
+		key, err := slc.indexes["user_session_id"].CacheKey(&StructValue{
+			typ:    slc.typ,
+			fields: map[string]*Value{"user_session_id": slc.valueFactory.CreateUint64Value(userSessionID)},
+		})
+		NoError(t, err)
+		return key
+	}
+	_, value, err := slc.encode(&UserLogin{ID: 1, UserSessionID: 10, Name: "rapidash"})
+	NoError(t, err)
+	primaryKey, err := slc.primaryKey.CacheKey(value)
+	NoError(t, err)
+	tx, err := r.Begin(&execRecorder{})
+	NoError(t, err)
+	NoError(t, slc.fillPrimaryKey(ctx, tx, primaryKey, value))
+	NoError(t, slc.fillUniqueKey(ctx, tx, uniqueKey(10), primaryKey))
+	NoError(t, slc.fillUniqueKey(ctx, tx, uniqueKey(20), nil))
+	Equal(t, len(tx.stash.primaryKeyToValue), 0)
+	Equal(t, len(tx.stash.uniqueKeyToPrimaryKey), 0)
+	NoError(t, tx.Commit())
+
+	tx, err = r.Begin(&execRecorder{})
+	NoError(t, err)
+	NoError(t, slc.UpdateByQueryBuilder(ctx, tx, NewQueryBuilder("user_logins").Eq("id", uint64(1)), map[string]interface{}{
+		"user_session_id": uint64(20),
+	}))
+	var userLogin UserLogin
+	NoError(t, slc.FindByQueryBuilder(ctx, tx, NewQueryBuilder("user_logins").Eq("user_session_id", uint64(20)), &userLogin))
+	Equal(t, userLogin.ID, uint64(1))
+	Equal(t, userLogin.UserSessionID, uint64(20))
+	NoError(t, tx.Commit())
+}
+
+func TestQueryBuilderDiagnostics(t *testing.T) {
+	t.Run("debug string", func(t *testing.T) {
+		builder := NewQueryBuilder("user_logins").
+			In("user_id", []uint64{1}).
+			Eq("name", "rapidash").
+			OrderDesc("id").
+			ForUpdate()
+		Equal(t, builder.DebugString(),
+			"table:user_logins where:[`user_id` IN (1) AND `name` = \"rapidash\"] order:[`id` DESC] lock:FOR UPDATE")
+	})
+	t.Run("no matching index", func(t *testing.T) {
+		slc := NewSecondLevelCache(userLoginType(), nil, TableOption{})
+		slc.indexes["id"] = &Index{Type: IndexTypePrimaryKey, Columns: []string{"id"}}
+		slc.indexes["user_id:user_session_id"] = &Index{Type: IndexTypeUniqueKey, Columns: []string{"user_id", "user_session_id"}}
+		builder := NewQueryBuilder("user_logins").Eq("name", "rapidash")
+		_, err := builder.BuildWithIndex(slc.valueFactory, slc.indexes, slc.typ)
+		if !xerrors.Is(err, ErrNoMatchingIndex) {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		if !strings.Contains(err.Error(), "user_logins has no index for (name). known indexes are (id) (user_id,user_session_id)") {
+			t.Fatalf("unexpected error message %s", err)
+		}
+	})
+}
+
+func TestQueryBuilderLimitOffset(t *testing.T) {
+	r, err := New()
+	NoError(t, err)
+	cacheServer := newMemoryCacheServer()
+	r.cacheServer = cacheServer
+	slc := NewSecondLevelCache(userLoginType(), cacheServer, r.tableOption("user_logins"))
+	slc.primaryKey = NewPrimaryKey(slc.opt, "user_logins", []string{"id"}, slc.typ)
+	slc.indexes["id"] = slc.primaryKey
+	slc.indexes["user_id"] = NewKey(slc.opt, "user_logins", []string{"user_id"}, slc.typ)
+	r.secondLevelCaches.set("user_logins", slc)
+
+	tx, err := r.Begin(&execRecorder{})
+	NoError(t, err)
+	primaryKeys := []server.CacheKey{}
+	for id := uint64(1); id <= 3; id++ {
+		key, err := slc.cacheKeyByPrimaryKeyValue(slc.valueFactory.CreateUint64Value(id))
+		NoError(t, err)
+		_, value, err := slc.encode(&UserLogin{ID: id, UserID: 10, Name: "rapidash"})
+		NoError(t, err)
+		NoError(t, slc.setPrimaryKey(context.Background(), tx, key, value))
+		primaryKeys = append(primaryKeys, key)
+	}
+	NoError(t, slc.fillKey(context.Background(), tx, &CacheKey{key: "r/slc/user_logins/idx/user_id#10", typ: server.CacheKeyTypeSLC}, primaryKeys))
+	NoError(t, tx.Commit())
+
+	find := func(t *testing.T, builder *QueryBuilder) []uint64 {
+		tx, err := r.Begin(&execRecorder{})
+		NoError(t, err)
+		var userLogins UserLogins
+		NoError(t, tx.FindByQueryBuilder(builder, &userLogins))
+		NoError(t, tx.Commit())
+		ids := []uint64{}
+		for _, userLogin := range userLogins {
+			ids = append(ids, userLogin.ID)
+		}
+		return ids
+	}
+	t.Run("order and limit", func(t *testing.T) {
+		Equal(t, find(t, NewQueryBuilder("user_logins").Eq("user_id", uint64(10)).OrderDesc("id").Limit(2)), []uint64{3, 2})
+	})
+	t.Run("offset", func(t *testing.T) {
+		Equal(t, find(t, NewQueryBuilder("user_logins").Eq("user_id", uint64(10)).OrderAsc("id").Offset(1).Limit(1)), []uint64{2})
+		Equal(t, find(t, NewQueryBuilder("user_logins").Eq("user_id", uint64(10)).Offset(5)), []uint64{})
+	})
+	t.Run("unknown order column", func(t *testing.T) {
+		tx, err := r.Begin(&execRecorder{})
+		NoError(t, err)
+		defer func() { NoError(t, tx.Rollback()) }()
+		var userLogins UserLogins
+		err = tx.FindByQueryBuilder(NewQueryBuilder("user_logins").Eq("user_id", uint64(10)).OrderAsc("unknown"), &userLogins)
+		Equal(t, xerrors.Is(err, ErrUnknownColumnName), true)
+	})
+	t.Run("debug string", func(t *testing.T) {
+		builder := NewQueryBuilder("user_logins").Eq("user_id", uint64(10)).Limit(2).Offset(1)
+		Equal(t, builder.DebugString(), "table:user_logins where:[`user_id` = 10] limit:2 offset:1")
+	})
+}
+
+func TestINChunk(t *testing.T) {
+	t.Run("split builder", func(t *testing.T) {
+		factory := NewValueFactory()
+		builder := NewQueryBuilder("user_logins").
+			In("user_id", []uint64{1, 2, 3, 1, 4, 5}).
+			Eq("login_param_id", uint64(1))
+		Equal(t, builder.isINChunkRequired(factory, 5), false)
+		Equal(t, builder.isINChunkRequired(factory, 2), true)
+		builders := builder.chunkedBuilders(factory, 2)
+		Equal(t, len(builders), 3)
+		Equal(t, builders[0].Query(), "`user_id` IN (?,?) AND `login_param_id` = ?")
+		Equal(t, builders[2].Query(), "`user_id` IN (?) AND `login_param_id` = ?")
+	})
+	t.Run("find by chunked query", func(t *testing.T) {
+		NoError(t, initUserLoginTable(conn))
+		NoError(t, initCache(conn, CacheServerTypeMemcached))
+		size := 2
+		slc := NewSecondLevelCache(userLoginType(), cache.cacheServer, TableOption{
+			inChunkSize:   &size,
+			defaultOrders: []*OrderCondition{{column: "user_id", isAsc: true}},
+		})
+		NoError(t, slc.cacheServer.Flush(context.Background()))
+		NoError(t, slc.WarmUp(conn))
+		for _, source := range []string{"db", "cache"} {
+			t.Run(source, func(t *testing.T) {
+				txConn, err := conn.Begin()
+				NoError(t, err)
+				tx, err := cache.Begin(txConn)
+				NoError(t, err)
+				builder := NewQueryBuilder("user_logins").
+					In("user_id", []uint64{5, 4, 3, 2, 1, 1}).
+					Eq("login_param_id", uint64(1))
+				var userLogins UserLogins
+				NoError(t, slc.FindByQueryBuilder(context.Background(), tx, builder, &userLogins))
+				Equal(t, len(userLogins), 5)
+				for idx, userLogin := range userLogins {
+					Equal(t, userLogin.UserID, uint64(idx+1))
+				}
+				NoError(t, tx.Commit())
+			})
+		}
+	})
+}
+
+func TestDecodeHook(t *testing.T) {
+	slc := NewSecondLevelCache(userLoginType(), nil, TableOption{
+		decodeHook: func(value *StructValue) error {
+			value.SetValue("name", NewStringValue(strings.ToUpper(value.String("name"))))
+			return value.Error()
+		},
+	})
+	enc := NewStructEncoder(userLoginType(), slc.valueFactory)
+	NoError(t, defaultUserLogin().EncodeRapidash(enc))
+	values := NewStructSliceValue()
+	values.Append(enc.value)
+	hookedValues, err := slc.applyDecodeHook(values)
+	NoError(t, err)
+	var userLogins UserLogins
+	NoError(t, userLogins.DecodeRapidash(hookedValues))
+	Equal(t, userLogins[0].Name, "RAPIDASH1")
+	Equal(t, values.values[0].String("name"), "rapidash1")
+}
+
+func TestEncodeHook(t *testing.T) {
+	slc := NewSecondLevelCache(userLoginType(), nil, TableOption{
+		encodeHook: func(value *StructValue) error {
+			if value.ValueByColumn("name") == nil {
+				return nil
+			}
+			value.SetValue("name", NewStringValue(strings.TrimSpace(value.String("name"))))
+			return value.Error()
+		},
+	})
+	t.Run("encode", func(t *testing.T) {
+		userLogin := defaultUserLogin()
+		userLogin.Name = " rapidash "
+		content, value, err := slc.encode(userLogin)
+		NoError(t, err)
+		Equal(t, value.String("name"), "rapidash")
+		dec := NewDecoder(userLoginType(), &bytes.Buffer{}, slc.valueFactory)
+		dec.SetBuffer(content)
+		decodedValue, err := dec.Decode()
+		NoError(t, err)
+		Equal(t, decodedValue.String("name"), "rapidash")
+	})
+	t.Run("update map", func(t *testing.T) {
+		updateMap, err := slc.applyEncodeHookToUpdateMap(map[string]interface{}{
+			"name":    " rapidash ",
+			"user_id": uint64(1),
+		})
+		NoError(t, err)
+		Equal(t, updateMap["name"], "rapidash")
+		Equal(t, updateMap["user_id"], uint64(1))
+	})
+}
+
+func TestSessionVariables(t *testing.T) {
+	vars := map[string]string{"max_execution_time": "500", "sort_buffer_size": "262144"}
+	NoError(t, validateSessionVariables(vars))
+	Equal(t,
+		withOptimizerHint("SELECT `id` FROM `users` WHERE `id` = ?", sessionVariableHint(vars)),
+		"SELECT /*+ SET_VAR(max_execution_time=500) SET_VAR(sort_buffer_size=262144) */ `id` FROM `users` WHERE `id` = ?",
+	)
+	Equal(t, withOptimizerHint("SELECT * FROM `users`", sessionVariableHint(nil)), "SELECT * FROM `users`")
+	if err := validateSessionVariables(map[string]string{"max_execution_time": "1 */ DROP"}); !xerrors.Is(err, ErrInvalidSessionVariable) {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	r, err := New(SecondLevelCacheMaxExecutionTime(time.Second))
+	NoError(t, err)
+	opt := r.tableOption("users")
+	Equal(t, opt.SessionVariables()["max_execution_time"], "1000")
+}
+
+func TestQueryBuilderAnnotation(t *testing.T) {
+	slc := NewSecondLevelCache(userLoginType(), nil, TableOption{
+		sessionVariables: map[string]string{"max_execution_time": "500"},
+	})
+	builder := NewQueryBuilder("user_logins").
+		Eq("id", uint64(1)).
+		Comment("rapidash: user-service /login */").
+		Hint("NO_INDEX_MERGE(user_logins)")
+	sql, _ := builder.SelectSQL(slc.valueFactory, slc.typ)
+	if !strings.HasPrefix(sql, "/* rapidash: user-service /login * / */ SELECT /*+ NO_INDEX_MERGE(user_logins) */ `id`") {
+		t.Fatalf("unexpected sql %s", sql)
+	}
+	if !strings.HasPrefix(slc.fallbackQuery(sql), "/* rapidash: user-service /login * / */ SELECT /*+ SET_VAR(max_execution_time=500) NO_INDEX_MERGE(user_logins) */ `id`") {
+		t.Fatalf("unexpected sql %s", slc.fallbackQuery(sql))
+	}
+	sql, _ = builder.UpdateSQL(slc.valueFactory, map[string]interface{}{"name": "rapidash"})
+	Equal(t, sql, "/* rapidash: user-service /login * / */ UPDATE /*+ NO_INDEX_MERGE(user_logins) */ `user_logins` SET `name` = ? WHERE `id` = ?")
+	sql, _ = builder.DeleteSQL(slc.valueFactory)
+	Equal(t, sql, "/* rapidash: user-service /login * / */ DELETE /*+ NO_INDEX_MERGE(user_logins) */ FROM `user_logins` WHERE `id` = ?")
+}
+
+func TestQueryBuilderUseIndex(t *testing.T) {
+	ddl := "CREATE TABLE `user_logins` (" +
+		"`id` bigint(20) unsigned NOT NULL," +
+		"`user_id` bigint(20) unsigned NOT NULL," +
+		"`user_session_id` bigint(20) unsigned NOT NULL," +
+		"`login_param_id` bigint(20) unsigned NOT NULL," +
+		"`name` varchar(255) NOT NULL," +
+		"PRIMARY KEY (`id`)," +
+		"KEY `idx_user_login_param` (`user_id`, `login_param_id`)," +
+		"KEY `idx_login_param_user` (`login_param_id`, `user_id`)" +
+		") ENGINE=InnoDB"
+	slc := NewSecondLevelCache(userLoginType(), nil, TableOption{})
+	NoError(t, slc.setupIndexes(ddl))
+	build := func(builder *QueryBuilder) (*Queries, error) {
+		return builder.BuildWithIndex(slc.valueFactory, slc.indexes, slc.typ)
+	}
+	t.Run("index matching conditions in order", func(t *testing.T) {
+		queries, err := build(NewQueryBuilder("user_logins").Eq("login_param_id", uint64(2)).Eq("user_id", uint64(1)))
+		NoError(t, err)
+		Equal(t, queries.At(0).Index().Columns, []string{"login_param_id", "user_id"})
+		queries.cacheMissQueries = queries.queries
+		sql, _ := queries.CacheMissQueriesToSQL(slc.typ)
+		if strings.Contains(sql, "INDEX(") {
+			t.Fatalf("unexpected sql %s", sql)
+		}
+	})
+	t.Run("forced index", func(t *testing.T) {
+		queries, err := build(NewQueryBuilder("user_logins").Eq("login_param_id", uint64(2)).Eq("user_id", uint64(1)).UseIndex("user_id:login_param_id"))
+		NoError(t, err)
+		Equal(t, queries.At(0).Index().Columns, []string{"user_id", "login_param_id"})
+		Equal(t, queries.At(0).cacheKey.String(), "r/slc/user_logins/idx/user_id#1&login_param_id#2")
+		queries.cacheMissQueries = queries.queries
+		sql, _ := queries.CacheMissQueriesToSQL(slc.typ)
+		if !strings.HasPrefix(sql, "SELECT /*+ INDEX(user_logins idx_user_login_param) */ `id`") {
+			t.Fatalf("unexpected sql %s", sql)
+		}
+	})
+	t.Run("forced index by IN query", func(t *testing.T) {
+		queries, err := build(NewQueryBuilder("user_logins").In("login_param_id", []uint64{2, 3}).Eq("user_id", uint64(1)).UseIndex("user_id:login_param_id"))
+		NoError(t, err)
+		Equal(t, queries.Len(), 2)
+		Equal(t, queries.At(1).cacheKey.String(), "r/slc/user_logins/idx/user_id#1&login_param_id#3")
+	})
+	t.Run("unknown index", func(t *testing.T) {
+		_, err := build(NewQueryBuilder("user_logins").Eq("user_id", uint64(1)).UseIndex("user_id:name"))
+		Equal(t, xerrors.Is(err, ErrUnknownIndex), true)
+	})
+	t.Run("index not matching conditions", func(t *testing.T) {
+		_, err := build(NewQueryBuilder("user_logins").Eq("login_param_id", uint64(2)).Eq("user_id", uint64(1)).UseIndex("user_id"))
+		Equal(t, xerrors.Is(err, ErrNoMatchingIndex), true)
+	})
+}
+
+func TestReadRepair(t *testing.T) {
+	NoError(t, initUserLoginTable(conn))
+	NoError(t, initCache(conn, CacheServerTypeMemcached))
+	slc := NewSecondLevelCache(userLoginType(), cache.cacheServer, TableOption{})
+	NoError(t, slc.cacheServer.Flush(context.Background()))
+	NoError(t, slc.WarmUp(conn))
+	find := func() {
+		tx, err := cache.Begin(conn)
+		NoError(t, err)
+		var userLogin UserLogin
+		builder := NewQueryBuilder("user_logins").Eq("id", uint64(1))
+		NoError(t, slc.FindByQueryBuilder(context.Background(), tx, builder, &userLogin))
+		Equal(t, userLogin.ID, uint64(1))
+		NoError(t, tx.Commit())
+	}
+	find()
+	key := "r/slc/user_logins/id#1"
+	cacheKey := &CacheKey{key: key, hash: NewStringValue(key).Hash()}
+	NoError(t, slc.cacheServer.Set(context.Background(), &server.CacheStoreRequest{Key: cacheKey, Value: []byte{0xc1}}))
+	find()
+	Equal(t, slc.ReadRepairCount(), uint64(1))
+	find()
+	Equal(t, slc.ReadRepairCount(), uint64(1))
+}
+
+func TestQueryInfo(t *testing.T) {
+	NoError(t, initUserLoginTable(conn))
+	NoError(t, initCache(conn, CacheServerTypeMemcached))
+	slc := NewSecondLevelCache(userLoginType(), cache.cacheServer, TableOption{})
+	NoError(t, slc.cacheServer.Flush(context.Background()))
+	NoError(t, slc.WarmUp(conn))
+	find := func() *QueryInfo {
+		tx, err := cache.Begin(conn)
+		NoError(t, err)
+		var info QueryInfo
+		var userLogins UserLogins
+		builder := NewQueryBuilder("user_logins").In("id", []uint64{1, 2, 1000}).WithInfo(&info)
+		NoError(t, slc.FindByQueryBuilder(context.Background(), tx, builder, &userLogins))
+		NoError(t, tx.Commit())
+		Equal(t, info.Rows, len(userLogins))
+		return &info
+	}
+	info := find()
+	Equal(t, info.CacheRows, 0)
+	Equal(t, info.DBRows, info.Rows)
+	Equal(t, info.MissedKeys, 3)
+
+	info = find()
+	Equal(t, info.CacheRows, info.Rows)
+	Equal(t, info.DBRows, 0)
+	Equal(t, info.MissedKeys, 0)
+}
+
+func TestSetLastInsertID(t *testing.T) {
+	newCache := func(typ *Struct) *SecondLevelCache {
+		return &SecondLevelCache{
+			typ:          typ,
+			primaryKey:   &Index{Columns: []string{"id"}},
+			valueFactory: NewValueFactory(),
+		}
+	}
+	overflowedID := int64(-2) // 18446744073709551614 as unsigned BIGINT
+	t.Run("uint64 column", func(t *testing.T) {
+		c := newCache(NewStruct("users").FieldUint64("id"))
+		value := &StructValue{typ: c.typ, fields: map[string]*Value{}}
+		NoError(t, c.setLastInsertID(value, overflowedID))
+		Equal(t, value.Uint64("id"), uint64(18446744073709551614))
+	})
+	t.Run("int64 column", func(t *testing.T) {
+		c := newCache(NewStruct("users").FieldInt64("id"))
+		value := &StructValue{typ: c.typ, fields: map[string]*Value{}}
+		NoError(t, c.setLastInsertID(value, 1))
+		Equal(t, value.Int64("id"), int64(1))
+		value = &StructValue{typ: c.typ, fields: map[string]*Value{}}
+		err := c.setLastInsertID(value, overflowedID)
+		Error(t, err)
+		Equal(t, xerrors.Is(err, ErrInt64Overflow), true)
+	})
+}
+
+func TestValidateUnknownColumn(t *testing.T) {
+	slc := NewSecondLevelCache(userLoginType(), nil, TableOption{})
+	t.Run("typo", func(t *testing.T) {
+		builder := NewQueryBuilder("user_logins").Eq("usr_id", uint64(1))
+		_, err := builder.BuildWithIndex(slc.valueFactory, slc.indexes, slc.typ)
+		Error(t, err)
+		Equal(t, xerrors.Is(err, ErrUnknownColumnName), true)
+		if !strings.Contains(err.Error(), "did you mean user_id ?") {
+			t.Fatalf("unexpected error %s", err)
+		}
+	})
+	t.Run("no similar column", func(t *testing.T) {
+		builder := NewQueryBuilder("user_logins").In("unknown", []uint64{1, 2})
+		_, err := builder.BuildWithIndex(slc.valueFactory, slc.indexes, slc.typ)
+		Error(t, err)
+		Equal(t, xerrors.Is(err, ErrUnknownColumnName), true)
+		if strings.Contains(err.Error(), "did you mean") {
+			t.Fatalf("unexpected error %s", err)
+		}
+	})
+}
+
+func TestFindByNegativeConditions(t *testing.T) {
+	r, err := New(
+		SecondLevelCacheTableWriteThroughOnCreate("user_logins", true),
+		SecondLevelCacheTablePrimaryKeyGenerator("user_logins", NewSequencePrimaryKeyGenerator(100)),
+	)
+	NoError(t, err)
+	cacheServer := newMemoryCacheServer()
+	r.cacheServer = cacheServer
+	slc := NewSecondLevelCache(userLoginType(), cacheServer, r.tableOption("user_logins"))
+	slc.primaryKey = NewPrimaryKey(slc.opt, "user_logins", []string{"id"}, slc.typ)
+	slc.indexes["id"] = slc.primaryKey
+	slc.indexes["user_id"] = NewKey(slc.opt, "user_logins", []string{"user_id"}, slc.typ)
+	r.secondLevelCaches.set("user_logins", slc)
+	ctx := context.Background()
+
+	indexKey, err := slc.indexes["user_id"].CacheKey(&StructValue{
+		typ:    slc.typ,
+		fields: map[string]*Value{"user_id": slc.valueFactory.CreateUint64Value(1)},
+	})
+	NoError(t, err)
+	// index list is deleted by create, so fill it as if primary keys are found by database
+	fillIndexKey := func(t *testing.T, ids ...uint64) {
+		primaryKeys := make([]server.CacheKey, 0, len(ids))
+		for _, id := range ids {
+			primaryKey, err := slc.primaryKey.CacheKey(&StructValue{
+				typ:    slc.typ,
+				fields: map[string]*Value{"id": slc.valueFactory.CreateUint64Value(id)},
+			})
+			NoError(t, err)
+			primaryKeys = append(primaryKeys, primaryKey)
+		}
+		tx, err := r.Begin(&execRecorder{})
+		NoError(t, err)
+		NoError(t, slc.fillKey(ctx, tx, indexKey, primaryKeys))
+		NoError(t, tx.Commit())
+	}
+	tx, err := r.Begin(&execRecorder{})
+	NoError(t, err)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := slc.Create(ctx, tx, &UserLogin{UserID: 1, UserSessionID: uint64(i), Name: "rapidash", CreatedAt: &now, UpdatedAt: &now})
+		NoError(t, err)
+	}
+	NoError(t, tx.Commit())
+	fillIndexKey(t, 100, 101, 102)
+
+	// execRecorder fails every query, so values must be found by cache
+	find := func(t *testing.T, builder *QueryBuilder) (UserLogins, error) {
+		tx, err := r.Begin(&execRecorder{})
+		NoError(t, err)
+		defer func() { NoError(t, tx.Rollback()) }()
+		var userLogins UserLogins
+		if err := slc.FindByQueryBuilder(ctx, tx, builder, &userLogins); err != nil {
+			return nil, err
+		}
+		return userLogins, nil
+	}
+	t.Run("neq", func(t *testing.T) {
+		info := &QueryInfo{}
+		userLogins, err := find(t, NewQueryBuilder("user_logins").Eq("user_id", uint64(1)).Neq("user_session_id", uint64(1)).WithInfo(info))
+		NoError(t, err)
+		Equal(t, len(userLogins), 2)
+		Equal(t, userLogins[0].UserSessionID, uint64(0))
+		Equal(t, userLogins[1].UserSessionID, uint64(2))
+		Equal(t, info.Rows, 2)
+		Equal(t, info.CacheRows, 3)
+		Equal(t, info.DBRows, 0)
+	})
+	t.Run("not in", func(t *testing.T) {
+		userLogins, err := find(t, NewQueryBuilder("user_logins").Eq("user_id", uint64(1)).NotIn("user_session_id", []uint64{0, 2}))
+		NoError(t, err)
+		Equal(t, len(userLogins), 1)
+		Equal(t, userLogins[0].UserSessionID, uint64(1))
+	})
+	t.Run("empty not in", func(t *testing.T) {
+		builder := NewQueryBuilder("user_logins").Eq("user_id", uint64(1)).NotIn("user_session_id", []uint64{})
+		userLogins, err := find(t, builder)
+		NoError(t, err)
+		Equal(t, len(userLogins), 3)
+		sql, args := NewQueryBuilder("user_logins").Eq("user_id", uint64(1)).NotIn("user_session_id", []uint64{}).SelectSQL(slc.valueFactory, slc.typ)
+		Equal(t, strings.HasSuffix(sql, "WHERE `user_id` = ? AND 1 = 1"), true)
+		Equal(t, len(args), 1)
+		condition := &NOTINCondition{column: "user_session_id", rawValues: []uint64{}}
+		condition.Build(slc.valueFactory)
+		if condition.Value() != nil {
+			t.Fatal("expected nil value")
+		}
+		Equal(t, condition.Compare(nil), true)
+	})
+	t.Run("not in null", func(t *testing.T) {
+		condition := &NOTINCondition{column: "user_session_id", rawValues: []uint64{1}}
+		condition.Build(slc.valueFactory)
+		Equal(t, condition.Compare(nil), false)
+	})
+	t.Run("neq null", func(t *testing.T) {
+		userLogins, err := find(t, NewQueryBuilder("user_logins").Eq("user_id", uint64(1)).Neq("created_at", nil))
+		NoError(t, err)
+		Equal(t, len(userLogins), 3)
+	})
+	t.Run("not index coverable", func(t *testing.T) {
+		_, err := find(t, NewQueryBuilder("user_logins").Eq("name", "rapidash").Neq("user_session_id", uint64(1)))
+		Error(t, err)
+	})
+}
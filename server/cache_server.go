@@ -2,6 +2,7 @@ package server
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"net"
 	"sync"
@@ -20,6 +21,9 @@ var (
 
 	ErrSetTimeout            = xerrors.New("timeout must be 1 or more")
 	ErrSetMaxIdleConnections = xerrors.New("maxIdle must be 1 or more")
+
+	// ErrIncrNotSupported is returned by Incr if cache server doesn't implement Incrementer
+	ErrIncrNotSupported = xerrors.New("cache server doesn't support incr")
 )
 
 const buffered = 8 // arbitrary buffered channel size, for readability
@@ -39,6 +43,8 @@ func resumableError(err error) bool {
 
 type MemcachedClient struct {
 	client *Client
+	// ctx is context of the current operation. it is set to copy of client by withContext
+	ctx context.Context
 }
 
 type StringCacheKey string
@@ -108,9 +114,14 @@ func (typ *CacheKeyType) UnmarshalJSON(bytes []byte) error {
 	return nil
 }
 
+// Hasher returns hash used to choose server node. custom CacheKey can keep its own hash function
+type Hasher interface {
+	Hash() uint32
+}
+
 type CacheKey interface {
+	Hasher
 	String() string
-	Hash() uint32
 	Addr() net.Addr
 	LockKey() CacheKey
 	Type() CacheKeyType
@@ -118,16 +129,31 @@ type CacheKey interface {
 
 type CacheServer interface {
 	GetClient() *Client
-	Get(CacheKey) (*CacheGetResponse, error)
-	GetMulti([]CacheKey) (*Iterator, error)
-	Set(*CacheStoreRequest) error
-	Add(CacheKey, []byte, time.Duration) error
-	Delete(CacheKey) error
-	Flush() error
+	Get(context.Context, CacheKey) (*CacheGetResponse, error)
+	GetMulti(context.Context, []CacheKey) (*Iterator, error)
+	Set(context.Context, *CacheStoreRequest) error
+	Add(context.Context, CacheKey, []byte, time.Duration) error
+	Delete(context.Context, CacheKey) error
+	Flush(context.Context) error
 	SetTimeout(time.Duration) error
 	SetMaxIdleConnections(int) error
 }
 
+// Incrementer is optional interface of CacheServer that increments counter atomically.
+// Custom cache server registered by RegisterCacheServer doesn't need to implement it unless rate limit is used
+type Incrementer interface {
+	Incr(context.Context, CacheKey, uint64, time.Duration) (uint64, error)
+}
+
+// Incr increments key by delta if s implements Incrementer, otherwise returns ErrIncrNotSupported
+func Incr(ctx context.Context, s CacheServer, key CacheKey, delta uint64, expiration time.Duration) (uint64, error) {
+	incrementer, ok := s.(Incrementer)
+	if !ok {
+		return 0, xerrors.Errorf("%T: %w", s, ErrIncrNotSupported)
+	}
+	return incrementer.Incr(ctx, key, delta, expiration)
+}
+
 type CacheGetResponse struct {
 	Value []byte
 	Flags uint32
@@ -154,6 +180,9 @@ type conn struct {
 	rw   *bufio.ReadWriter
 	addr net.Addr
 	c    *Client
+
+	// authenticated is true if AUTH by CredentialsProvider succeeded
+	authenticated bool
 }
 
 // Client is a memcache client.
@@ -174,6 +203,14 @@ type Client struct {
 	slcSelector *Selector
 	llcSelector *Selector
 
+	credentialsProvider CredentialsProvider
+
+	getMultiBatch GetMultiBatchPolicy
+
+	redisCompareAndSwap bool
+
+	cluster *redisCluster
+
 	lk       sync.Mutex
 	freeconn map[string][]*conn
 }
@@ -307,6 +344,9 @@ func (c *Client) RemoveLastLevelCacheServers(servers ...string) error {
 }
 
 func (c *Client) getAddr(key CacheKey) (net.Addr, error) {
+	if c.cluster != nil {
+		return c.cluster.pickServer(key), nil
+	}
 	switch key.Type() {
 	case CacheKeyTypeSLC:
 		return c.slcSelector.PickServer(key)
@@ -316,6 +356,15 @@ func (c *Client) getAddr(key CacheKey) (net.Addr, error) {
 	return nil, xerrors.Errorf("cannot pick server by %s", key.String())
 }
 
+// PickServer returns address of cache server for key
+func (c *Client) PickServer(key CacheKey) (net.Addr, error) {
+	addr, err := c.getAddr(key)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get addr: %w", err)
+	}
+	return addr, nil
+}
+
 func (c *Client) withKeyAddr(key CacheKey, fn func(net.Addr) error) (err error) {
 	if !legalKey(key.String()) {
 		return ErrMalformedKey
@@ -356,6 +405,16 @@ func (c *Client) getFreeConn(addr net.Addr) (cn *conn, ok bool) {
 	return cn, true
 }
 
+// closeFreeConns closes idle connections to addr, so that next connections are established again
+func (c *Client) closeFreeConns(addr net.Addr) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	for _, cn := range c.freeconn[addr.String()] {
+		cn.nc.Close()
+	}
+	delete(c.freeconn, addr.String())
+}
+
 func (c *Client) netTimeout() time.Duration {
 	return c.timeout
 }
@@ -364,12 +423,28 @@ func (c *Client) getMaxIdleConns() int {
 	return c.maxIdleConns
 }
 
-func (c *Client) dial(addr net.Addr) (net.Conn, error) {
-	nc, err := net.DialTimeout(addr.Network(), addr.String(), c.netTimeout())
+// timeoutContext returns socket timeout shortened to deadline of ctx
+func (c *Client) timeoutContext(ctx context.Context) time.Duration {
+	timeout := c.netTimeout()
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); remaining < timeout {
+			return remaining
+		}
+	}
+	return timeout
+}
+
+func (c *Client) dial(ctx context.Context, addr net.Addr) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: c.netTimeout()}
+	nc, err := dialer.DialContext(ctx, addr.Network(), addr.String())
 	if err == nil {
 		return nc, nil
 	}
 
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+
 	if ne, ok := err.(net.Error); ok && ne.Timeout() {
 		return nil, &ConnectTimeoutError{addr}
 	}
@@ -377,15 +452,19 @@ func (c *Client) dial(addr net.Addr) (net.Conn, error) {
 	return nil, err
 }
 
-func (c *Client) getConn(addr net.Addr) (*conn, error) {
+// getConn returns connection to addr. Deadline of the connection is the earlier of socket timeout and deadline of ctx
+func (c *Client) getConn(ctx context.Context, addr net.Addr) (*conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	cn, ok := c.getFreeConn(addr)
 	if ok {
-		if err := cn.extendDeadline(); err != nil {
+		if err := cn.extendDeadline(ctx); err != nil {
 			return nil, err
 		}
 		return cn, nil
 	}
-	nc, err := c.dial(addr)
+	nc, err := c.dial(ctx, addr)
 	if err != nil {
 		return nil, err
 	}
@@ -395,7 +474,7 @@ func (c *Client) getConn(addr net.Addr) (*conn, error) {
 		rw:   bufio.NewReadWriter(bufio.NewReader(nc), bufio.NewWriter(nc)),
 		c:    c,
 	}
-	if err := cn.extendDeadline(); err != nil {
+	if err := cn.extendDeadline(ctx); err != nil {
 		return nil, err
 	}
 	return cn, nil
@@ -406,8 +485,8 @@ func (cn *conn) release() {
 	cn.c.putFreeConn(cn.addr, cn)
 }
 
-func (cn *conn) extendDeadline() error {
-	return cn.nc.SetDeadline(time.Now().Add(cn.c.netTimeout()))
+func (cn *conn) extendDeadline(ctx context.Context) error {
+	return cn.nc.SetDeadline(time.Now().Add(cn.c.timeoutContext(ctx)))
 }
 
 // condRelease releases this connection if the error pointed to by err
@@ -1,11 +1,13 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
 	"os"
 	"testing"
+	"time"
 	"unsafe"
 )
 
@@ -655,3 +657,17 @@ func TestAddSecondLevelCacheServers(t *testing.T) {
 		})
 	}
 }
+
+func TestClientTimeoutContext(t *testing.T) {
+	client := &Client{timeout: time.Second}
+	Equal(t, client.timeoutContext(context.Background()), time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if timeout := client.timeoutContext(ctx); timeout > 100*time.Millisecond {
+		t.Fatalf("timeout must be shortened to deadline of context. but %s", timeout)
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := client.getConn(canceled, cacheAddrServer1)
+	Equal(t, err, context.Canceled)
+}
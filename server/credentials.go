@@ -0,0 +1,112 @@
+package server
+
+import (
+	"net"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+	"golang.org/x/xerrors"
+)
+
+// Credentials authenticates connection to cache server. Username is empty for AUTH by password ( or token ) only
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// CredentialsProvider returns credentials for new connection to addr.
+// It is called whenever connection is established, so short-lived tokens ( e.g. IAM auth ) are refreshed without restarting
+type CredentialsProvider interface {
+	Credentials(addr net.Addr) (*Credentials, error)
+}
+
+// CredentialsProviderFunc is function implements CredentialsProvider
+type CredentialsProviderFunc func(addr net.Addr) (*Credentials, error)
+
+func (f CredentialsProviderFunc) Credentials(addr net.Addr) (*Credentials, error) {
+	return f(addr)
+}
+
+// SetCredentialsProvider sets provider used to authenticate new connections. It is supported by redis only
+func (c *Client) SetCredentialsProvider(provider CredentialsProvider) {
+	c.credentialsProvider = provider
+}
+
+// isRedisAuthError returns true if server rejected connection because it isn't authenticated or credentials are expired
+func isRedisAuthError(err error) bool {
+	var rerr redis.Error
+	if !xerrors.As(err, &rerr) {
+		return false
+	}
+	msg := string(rerr)
+	for _, prefix := range []string{"NOAUTH", "WRONGPASS", "ERR invalid password", "ERR invalid username-password pair"} {
+		if strings.HasPrefix(msg, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// auth sends AUTH by credentials from provider to new connection
+func (c *RedisClient) auth(cn *conn) error {
+	credentials, err := c.client.credentialsProvider.Credentials(cn.addr)
+	if err != nil {
+		return xerrors.Errorf("failed to get credentials for %s: %w", cn.addr, err)
+	}
+	args := []interface{}{}
+	if credentials.Username != "" {
+		args = append(args, credentials.Username)
+	}
+	args = append(args, credentials.Password)
+	if _, err := c.getRedisConn(cn).Do("auth", args...); err != nil {
+		return xerrors.Errorf("failed to authenticate %s: %w", cn.addr, err)
+	}
+	return nil
+}
+
+// getConn returns connection authenticated by CredentialsProvider if it is set
+func (c *RedisClient) getConn(addr net.Addr) (*conn, error) {
+	cn, err := c.client.getConn(c.context(), addr)
+	if err != nil {
+		return nil, err
+	}
+	if cn.authenticated || c.client.credentialsProvider == nil {
+		return cn, nil
+	}
+	if err := c.auth(cn); err != nil {
+		cn.nc.Close()
+		return nil, err
+	}
+	cn.authenticated = true
+	return cn, nil
+}
+
+// withConn runs fn by connection to addr. On Redis Cluster, MOVED and ASK redirects are followed
+func (c *RedisClient) withConn(addr net.Addr, fn func(redis.Conn) error) error {
+	err := c.withAuthenticatedConn(addr, fn)
+	if c.client.cluster == nil {
+		return err
+	}
+	return c.followRedirects(err, fn)
+}
+
+// withAuthenticatedConn runs fn by connection to addr. If server rejects the connection by AUTH error ( e.g. expired token ),
+// idle connections to addr are discarded and fn is retried once by connection authenticated with refetched credentials
+func (c *RedisClient) withAuthenticatedConn(addr net.Addr, fn func(redis.Conn) error) error {
+	err := c.withConnOnce(addr, fn)
+	if c.client.credentialsProvider == nil || !isRedisAuthError(err) {
+		return err
+	}
+	c.client.closeFreeConns(addr)
+	return c.withConnOnce(addr, fn)
+}
+
+func (c *RedisClient) withConnOnce(addr net.Addr, fn func(redis.Conn) error) (err error) {
+	cn, err := c.getConn(addr)
+	if err != nil {
+		return err
+	}
+	defer cn.condRelease(&err)
+
+	return fn(c.getRedisConn(cn))
+}
@@ -0,0 +1,177 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// authRedisServer is minimal redis server accepts AUTH by token and GET
+type authRedisServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	token    string
+}
+
+func newAuthRedisServer(t *testing.T, token string) *authRedisServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	s := &authRedisServer{listener: listener, token: token}
+	go func() {
+		for {
+			nc, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(nc)
+		}
+	}()
+	return s
+}
+
+func (s *authRedisServer) setToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+}
+
+func (s *authRedisServer) currentToken() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token
+}
+
+func (s *authRedisServer) serve(nc net.Conn) {
+	defer nc.Close()
+	rw := bufio.NewReadWriter(bufio.NewReader(nc), bufio.NewWriter(nc))
+	authenticatedBy := ""
+	for {
+		args, err := readRedisCommand(rw.Reader)
+		if err != nil {
+			return
+		}
+		switch {
+		case strings.EqualFold(args[0], "auth"):
+			if args[len(args)-1] != s.currentToken() {
+				fmt.Fprint(rw, "-WRONGPASS invalid username-password pair\r\n")
+				break
+			}
+			authenticatedBy = args[len(args)-1]
+			fmt.Fprint(rw, "+OK\r\n")
+		case authenticatedBy != s.currentToken():
+			fmt.Fprint(rw, "-NOAUTH Authentication required.\r\n")
+		case strings.EqualFold(args[0], "get"):
+			fmt.Fprintf(rw, "$%d\r\nvalue\r\n", len("value"))
+		default:
+			fmt.Fprintf(rw, "-ERR unknown command '%s'\r\n", args[0])
+		}
+		if err := rw.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func readRedisCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func TestRedisCredentialsProvider(t *testing.T) {
+	s := newAuthRedisServer(t, "token1")
+	defer s.listener.Close()
+
+	selector, err := NewSelector(s.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	cacheServer := NewRedisBySelectors(selector, selector)
+	if err := cacheServer.SetTimeout(time.Second); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := cacheServer.SetMaxIdleConnections(2); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	var (
+		mu       sync.Mutex
+		token    = "token1"
+		fetched  int
+		fetchErr error
+	)
+	cacheServer.GetClient().SetCredentialsProvider(CredentialsProviderFunc(func(addr net.Addr) (*Credentials, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		fetched++
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		return &Credentials{Username: "default", Password: token}, nil
+	}))
+	key := &TestSlcCacheKey{key: "key"}
+
+	t.Run("authenticate new connection", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			res, err := cacheServer.Get(context.Background(), key)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			Equal(t, string(res.Value), "value")
+		}
+		Equal(t, fetched, 1)
+	})
+	t.Run("refetch credentials by auth error", func(t *testing.T) {
+		s.setToken("token2")
+		mu.Lock()
+		token = "token2"
+		mu.Unlock()
+		res, err := cacheServer.Get(context.Background(), key)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		Equal(t, string(res.Value), "value")
+		Equal(t, fetched, 2)
+	})
+	t.Run("failed to get credentials", func(t *testing.T) {
+		s.setToken("token3")
+		mu.Lock()
+		fetchErr = xerrors.New("token service unavailable")
+		mu.Unlock()
+		if _, err := cacheServer.Get(context.Background(), key); !xerrors.Is(err, fetchErr) {
+			t.Fatalf("unexpected error %+v", err)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package server
+
+import "net"
+
+// GetMultiBatchPolicy splits keys of GetMulti for each server node into batches,
+// so single request doesn't exceed limits of server or block other requests on the connection for long.
+// Batches are sent concurrently by different connections and their results are merged. Zero means no limit
+type GetMultiBatchPolicy struct {
+	MaxKeys int
+	// MaxBytes is total bytes of keys in a batch
+	MaxBytes int
+}
+
+// SetGetMultiBatchPolicy sets policy to split GetMulti into batches
+func (c *Client) SetGetMultiBatchPolicy(policy GetMultiBatchPolicy) {
+	c.getMultiBatch = policy
+}
+
+type getMultiBatch struct {
+	addr net.Addr
+	keys []string
+}
+
+func (p GetMultiBatchPolicy) split(addr net.Addr, keys []string) []*getMultiBatch {
+	batches := []*getMultiBatch{}
+	batch := &getMultiBatch{addr: addr}
+	size := 0
+	for _, key := range keys {
+		if len(batch.keys) > 0 &&
+			((p.MaxKeys > 0 && len(batch.keys) >= p.MaxKeys) || (p.MaxBytes > 0 && size+len(key) > p.MaxBytes)) {
+			batches = append(batches, batch)
+			batch = &getMultiBatch{addr: addr}
+			size = 0
+		}
+		batch.keys = append(batch.keys, key)
+		size += len(key)
+	}
+	return append(batches, batch)
+}
+
+// getMultiBatches groups keys by server node and splits them by GetMultiBatchPolicy
+func (c *Client) getMultiBatches(keys []CacheKey) ([]*getMultiBatch, error) {
+	keyMap := make(map[net.Addr][]string, len(keys))
+	for _, key := range keys {
+		k := key.String()
+		if !legalKey(k) {
+			return nil, ErrMalformedKey
+		}
+		addr, err := c.getAddr(key)
+		if err != nil {
+			return nil, err
+		}
+		keyMap[addr] = append(keyMap[addr], k)
+	}
+	batches := make([]*getMultiBatch, 0, len(keyMap))
+	for addr, keys := range keyMap {
+		batches = append(batches, c.getMultiBatch.split(addr, keys)...)
+	}
+	return batches, nil
+}
+
+// getMultiByBatches calls get for each batch concurrently and returns the last error
+func getMultiByBatches(batches []*getMultiBatch, get func(net.Addr, []string) error) error {
+	if len(batches) == 1 {
+		return get(batches[0].addr, batches[0].keys)
+	}
+	ch := make(chan error, buffered)
+	for _, batch := range batches {
+		go func(batch *getMultiBatch) {
+			ch <- get(batch.addr, batch.keys)
+		}(batch)
+	}
+	var err error
+	for range batches {
+		if ge := <-ch; ge != nil {
+			err = ge
+		}
+	}
+	return err
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+
+	"golang.org/x/xerrors"
+)
+
+func TestGetMultiBatchPolicy(t *testing.T) {
+	addr, err := getAddr("127.0.0.1:11211")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	keys := []string{"a", "bb", "ccc", "dddd", "e"}
+	split := func(policy GetMultiBatchPolicy) [][]string {
+		batches := [][]string{}
+		for _, batch := range policy.split(addr, keys) {
+			Equal(t, batch.addr, addr)
+			batches = append(batches, batch.keys)
+		}
+		return batches
+	}
+	t.Run("no limit", func(t *testing.T) {
+		Equal(t, split(GetMultiBatchPolicy{}), [][]string{keys})
+	})
+	t.Run("max keys", func(t *testing.T) {
+		Equal(t, split(GetMultiBatchPolicy{MaxKeys: 2}), [][]string{{"a", "bb"}, {"ccc", "dddd"}, {"e"}})
+	})
+	t.Run("max bytes", func(t *testing.T) {
+		Equal(t, split(GetMultiBatchPolicy{MaxBytes: 5}), [][]string{{"a", "bb"}, {"ccc"}, {"dddd", "e"}})
+		// key larger than MaxBytes is sent alone
+		Equal(t, split(GetMultiBatchPolicy{MaxBytes: 3}), [][]string{{"a", "bb"}, {"ccc"}, {"dddd"}, {"e"}})
+	})
+	t.Run("max keys and bytes", func(t *testing.T) {
+		Equal(t, split(GetMultiBatchPolicy{MaxKeys: 1, MaxBytes: 100}), [][]string{{"a"}, {"bb"}, {"ccc"}, {"dddd"}, {"e"}})
+	})
+}
+
+func TestGetMultiByBatches(t *testing.T) {
+	selector, err := NewSelector("127.0.0.1:11211", "127.0.0.1:11212")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	client := &Client{slcSelector: selector}
+	client.SetGetMultiBatchPolicy(GetMultiBatchPolicy{MaxKeys: 3})
+	keys := []CacheKey{}
+	keyMap := map[string]CacheKey{}
+	for i := 0; i < 20; i++ {
+		key := &TestSlcCacheKey{key: fmt.Sprintf("key%02d", i), hash: uint32(i * 7919)}
+		keys = append(keys, key)
+		keyMap[key.String()] = key
+	}
+	batches, err := client.getMultiBatches(keys)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	var mu sync.Mutex
+	found := []string{}
+	if err := getMultiByBatches(batches, func(addr net.Addr, keys []string) error {
+		if len(keys) > 3 {
+			return xerrors.Errorf("too many keys %d", len(keys))
+		}
+		for _, key := range keys {
+			expected, err := client.getAddr(keyMap[key])
+			if err != nil {
+				return err
+			}
+			if expected.String() != addr.String() {
+				return xerrors.Errorf("%s is sent to %s instead of %s", key, addr, expected)
+			}
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		found = append(found, keys...)
+		return nil
+	}); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	Equal(t, len(found), len(keys))
+	sort.Strings(found)
+	Equal(t, found[0], "key00")
+
+	t.Run("return error of batch", func(t *testing.T) {
+		err := getMultiByBatches(batches, func(addr net.Addr, keys []string) error {
+			if keys[0] == found[0] {
+				return ErrCacheMiss
+			}
+			return nil
+		})
+		Equal(t, xerrors.Is(err, ErrCacheMiss), true)
+	})
+}
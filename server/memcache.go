@@ -19,6 +19,7 @@ limitations under the License.
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net"
@@ -42,6 +43,18 @@ func (c *MemcachedClient) GetClient() *Client {
 	return c.client
 }
 
+// withContext returns copy of client running operations by ctx
+func (c *MemcachedClient) withContext(ctx context.Context) *MemcachedClient {
+	return &MemcachedClient{client: c.client, ctx: ctx}
+}
+
+func (c *MemcachedClient) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
 func (c *MemcachedClient) SetTimeout(timeout time.Duration) error {
 	if timeout == time.Duration(0) {
 		return ErrSetTimeout
@@ -58,8 +71,8 @@ func (c *MemcachedClient) SetMaxIdleConnections(maxIdle int) error {
 	return nil
 }
 
-func (c *MemcachedClient) Get(key CacheKey) (*CacheGetResponse, error) {
-	item, err := c.get(key)
+func (c *MemcachedClient) Get(ctx context.Context, key CacheKey) (*CacheGetResponse, error) {
+	item, err := c.withContext(ctx).get(key)
 	if err == ErrMemcacheCacheMiss {
 		return nil, ErrCacheMiss
 	}
@@ -73,8 +86,8 @@ func (c *MemcachedClient) Get(key CacheKey) (*CacheGetResponse, error) {
 	}, nil
 }
 
-func (c *MemcachedClient) GetMulti(keys []CacheKey) (*Iterator, error) {
-	itemMap, err := c.getMulti(keys)
+func (c *MemcachedClient) GetMulti(ctx context.Context, keys []CacheKey) (*Iterator, error) {
+	itemMap, err := c.withContext(ctx).getMulti(keys)
 	if err != nil {
 		return nil, xerrors.Errorf("failed to get caches: %w", err)
 	}
@@ -94,7 +107,8 @@ func (c *MemcachedClient) GetMulti(keys []CacheKey) (*Iterator, error) {
 	return iter, nil
 }
 
-func (c *MemcachedClient) Set(req *CacheStoreRequest) error {
+func (c *MemcachedClient) Set(ctx context.Context, req *CacheStoreRequest) error {
+	c = c.withContext(ctx)
 	item := &Item{
 		Key:        req.Key,
 		Flags:      req.Key.Hash(),
@@ -114,8 +128,8 @@ func (c *MemcachedClient) Set(req *CacheStoreRequest) error {
 	return nil
 }
 
-func (c *MemcachedClient) Add(key CacheKey, value []byte, expiration time.Duration) error {
-	if err := c.onItem(
+func (c *MemcachedClient) Add(ctx context.Context, key CacheKey, value []byte, expiration time.Duration) error {
+	if err := c.withContext(ctx).onItem(
 		&Item{
 			Key:        key,
 			Value:      value,
@@ -128,8 +142,32 @@ func (c *MemcachedClient) Add(key CacheKey, value []byte, expiration time.Durati
 	return nil
 }
 
-func (c *MemcachedClient) Delete(key CacheKey) error {
-	if err := c.delete(key); err != nil {
+// Incr atomically increments key by delta.
+// If key doesn't exist, it is created by delta with expiration.
+func (c *MemcachedClient) Incr(ctx context.Context, key CacheKey, delta uint64, expiration time.Duration) (uint64, error) {
+	c = c.withContext(ctx)
+	for {
+		value, err := c.Increment(key, delta)
+		if err == nil {
+			return value, nil
+		}
+		if err != ErrMemcacheCacheMiss {
+			return 0, xerrors.Errorf("failed to increment value of %s: %w", key, err)
+		}
+		initial := []byte(strconv.FormatUint(delta, 10))
+		if err := c.Add(ctx, key, initial, expiration); err != nil {
+			if xerrors.Is(err, ErrMemcacheNotStored) {
+				// other process added key at the same time
+				continue
+			}
+			return 0, xerrors.Errorf("failed to add initial value: %w", err)
+		}
+		return delta, nil
+	}
+}
+
+func (c *MemcachedClient) Delete(ctx context.Context, key CacheKey) error {
+	if err := c.withContext(ctx).delete(key); err != nil {
 		if err == ErrMemcacheCacheMiss {
 			// ignore cache miss
 			return nil
@@ -139,8 +177,8 @@ func (c *MemcachedClient) Delete(key CacheKey) error {
 	return nil
 }
 
-func (c *MemcachedClient) Flush() error {
-	if err := c.FlushAll(); err != nil {
+func (c *MemcachedClient) Flush(ctx context.Context) error {
+	if err := c.withContext(ctx).FlushAll(); err != nil {
 		return xerrors.Errorf("failed to flush cache: %w", err)
 	}
 	return nil
@@ -193,7 +231,7 @@ func (c *MemcachedClient) onItem(item *Item, fn func(*MemcachedClient, *bufio.Re
 	if err != nil {
 		return err
 	}
-	cn, err := c.client.getConn(addr)
+	cn, err := c.client.getConn(c.context(), addr)
 	if err != nil {
 		return err
 	}
@@ -238,7 +276,7 @@ func (c *MemcachedClient) Touch(key CacheKey, seconds int32) (err error) {
 }
 
 func (c *MemcachedClient) withAddrRw(addr net.Addr, fn func(*bufio.ReadWriter) error) (err error) {
-	cn, err := c.client.getConn(addr)
+	cn, err := c.client.getConn(c.context(), addr)
 	if err != nil {
 		return err
 	}
@@ -329,42 +367,16 @@ func (c *MemcachedClient) getMulti(keys []CacheKey) (map[string]*Item, error) {
 		m[it.Key.String()] = it
 	}
 
-	keyMap := make(map[net.Addr][]string, len(keys))
-	for _, key := range keys {
-		k := key.String()
-		if !legalKey(k) {
-			return nil, ErrMalformedKey
-		}
-		addr, err := c.client.getAddr(key)
-		if err != nil {
-			return nil, err
-		}
-		keyMap[addr] = append(keyMap[addr], k)
-	}
-
-	addrNum := len(keyMap)
-	if addrNum == 1 {
-		for addr, keys := range keyMap {
-			if err := c.getFromAddr(addr, keys, addItemToMap); err != nil {
-				return nil, err
-			}
-			return m, nil
-		}
-	}
-	ch := make(chan error, buffered)
-	for addr, keys := range keyMap {
-		go func(addr net.Addr, keys []string) {
-			ch <- c.getFromAddr(addr, keys, addItemToMap)
-		}(addr, keys)
+	batches, err := c.client.getMultiBatches(keys)
+	if err != nil {
+		return nil, err
 	}
-
-	var err error
-	for range keyMap {
-		if ge := <-ch; ge != nil {
-			err = ge
-		}
+	if err := getMultiByBatches(batches, func(addr net.Addr, keys []string) error {
+		return c.getFromAddr(addr, keys, addItemToMap)
+	}); err != nil {
+		return nil, err
 	}
-	return m, err
+	return m, nil
 }
 
 // parseGetResponse reads a GET response from r and calls cb for each
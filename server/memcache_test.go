@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -39,6 +40,7 @@ func MemcacheTestSetup() {
 	for i := 1; i <= 3; i++ {
 		value := fmt.Sprintf("value%d", i)
 		if err := memcachedCacheServer.Set(
+			context.Background(),
 			&CacheStoreRequest{
 				Key: &TestSlcCacheKey{
 					key: fmt.Sprintf("key%d", i),
@@ -53,7 +55,7 @@ func MemcacheTestSetup() {
 }
 
 func MemcacheTestTeardown() {
-	if err := memcachedCacheServer.Flush(); err != nil {
+	if err := memcachedCacheServer.Flush(context.Background()); err != nil {
 		panic(err)
 	}
 }
@@ -174,7 +176,7 @@ func TestMemcachedGet(t *testing.T) {
 		tt := tt
 		t.Run(fmt.Sprintf("TestMemcachedGet:%v\n", i), func(t *testing.T) {
 			tt.expected.Value = *(*[]byte)(unsafe.Pointer(&tt.cacheValue))
-			actual, err := memcachedCacheServer.Get(tt.cacheKey)
+			actual, err := memcachedCacheServer.Get(context.Background(), tt.cacheKey)
 			if tt.cacheKey.key == "cachemiss" {
 				Equal(t, tt.expectedError, err)
 			} else {
@@ -235,7 +237,7 @@ func TestMemcachedGetMulti(t *testing.T) {
 				tt.expected.SetError(i, tt.cacheErrors[i])
 			}
 
-			actual, err := memcachedCacheServer.GetMulti(tt.cacheKeys)
+			actual, err := memcachedCacheServer.GetMulti(context.Background(), tt.cacheKeys)
 			if tt.cacheKeys[0].String() == string(0x00) {
 				if !xerrors.Is(err, tt.expectedError) {
 					t.Fatalf("%+v", err)
@@ -289,25 +291,25 @@ func TestMemcachedSet(t *testing.T) {
 		tt := tt
 		t.Run(fmt.Sprintf("TestMemcachedSet:%v\n", i), func(t *testing.T) {
 			tt.cacheStoreRequest.Value = *(*[]byte)(unsafe.Pointer(&tt.requestValue))
-			err := memcachedCacheServer.Set(tt.cacheStoreRequest)
+			err := memcachedCacheServer.Set(context.Background(), tt.cacheStoreRequest)
 			if tt.cacheStoreRequest.Key.String() == string(0x00) {
 				Equal(t, tt.expectedError.Error(), err.Error())
 			} else {
 				Equal(t, tt.expectedError, err)
 
-				reply, err := memcachedCacheServer.Get(tt.cacheStoreRequest.Key)
+				reply, err := memcachedCacheServer.Get(context.Background(), tt.cacheStoreRequest.Key)
 				if err != nil {
 					t.Fatalf("%+v", err)
 				}
 				Equal(t, tt.expectedGetValue, *(*string)(unsafe.Pointer(&reply.Value)))
 
 				tt.cacheStoreRequest.CasID = reply.CasID
-				err = memcachedCacheServer.Set(tt.cacheStoreRequest)
+				err = memcachedCacheServer.Set(context.Background(), tt.cacheStoreRequest)
 				Equal(t, tt.expectedError, err)
 
 				tt.cacheStoreRequest.CasID = reply.CasID
 				tt.expectedError = xerrors.Errorf("failed set value to %s: %w", tt.cacheStoreRequest.Key.String(), ErrMemcacheCASConflict)
-				err = memcachedCacheServer.Set(tt.cacheStoreRequest)
+				err = memcachedCacheServer.Set(context.Background(), tt.cacheStoreRequest)
 				Equal(t, tt.expectedError.Error(), err.Error())
 			}
 		})
@@ -340,13 +342,13 @@ func TestMemcachedAdd(t *testing.T) {
 	for i, tt := range tests {
 		tt := tt
 		t.Run(fmt.Sprintf("TestMemcachedAdd:%v\n", i), func(t *testing.T) {
-			err := memcachedCacheServer.Add(tt.key, *(*[]byte)(unsafe.Pointer(&tt.value)), tt.expiration)
+			err := memcachedCacheServer.Add(context.Background(), tt.key, *(*[]byte)(unsafe.Pointer(&tt.value)), tt.expiration)
 			if tt.key.String() == string(0x00) {
 				Equal(t, tt.expectedError.Error(), err.Error())
 			} else {
 				Equal(t, tt.expectedError, err)
 
-				reply, err := memcachedCacheServer.Get(tt.key)
+				reply, err := memcachedCacheServer.Get(context.Background(), tt.key)
 				if err != nil {
 					t.Fatalf("%+v", err)
 				}
@@ -376,17 +378,17 @@ func TestMemcachedDelete(t *testing.T) {
 		tt := tt
 		t.Run(fmt.Sprintf("TestMemcachedDelete:%v\n", i), func(t *testing.T) {
 			tt.cacheStoreRequest.Value = *(*[]byte)(unsafe.Pointer(&tt.requestValue))
-			err := memcachedCacheServer.Set(tt.cacheStoreRequest)
+			err := memcachedCacheServer.Set(context.Background(), tt.cacheStoreRequest)
 			if err != nil {
 				t.Fatalf("%+v", err)
 			}
 
 			// fisrt time
-			err = memcachedCacheServer.Delete(tt.cacheStoreRequest.Key)
+			err = memcachedCacheServer.Delete(context.Background(), tt.cacheStoreRequest.Key)
 			Equal(t, tt.expected, err)
 
 			// second time
-			err = memcachedCacheServer.Delete(tt.cacheStoreRequest.Key)
+			err = memcachedCacheServer.Delete(context.Background(), tt.cacheStoreRequest.Key)
 			Equal(t, tt.expected, err)
 		})
 	}
@@ -403,7 +405,7 @@ func TestMemcachedFlush(t *testing.T) {
 	for i, tt := range tests {
 		tt := tt
 		t.Run(fmt.Sprintf("TestMemcachedFlush:%v\n", i), func(t *testing.T) {
-			err := memcachedCacheServer.Flush()
+			err := memcachedCacheServer.Flush(context.Background())
 			Equal(t, tt.expected, err)
 		})
 	}
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"context"
+	"hash/fnv"
 	"net"
 	"sync"
 	"time"
@@ -12,10 +14,26 @@ import (
 var (
 	ErrRedisCacheMiss = xerrors.New("redis: cache miss")
 	ErrRedisNotStored = xerrors.New("redis: item not stored")
+	// ErrRedisCASConflict means that value was modified since it was read
+	ErrRedisCASConflict = xerrors.New("redis: compare-and-swap conflict")
 )
 
+// redisCasID returns version of value.
+// redis doesn't have cas unique, so fnv hash of value is used instead.
+// It cannot tell value changed and changed back to the same bytes ( ABA ), so it is used only if enabled by SetRedisCompareAndSwap
+func redisCasID(value []byte) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(value)
+	if id := h.Sum64(); id != 0 {
+		return id
+	}
+	return 1
+}
+
 type RedisClient struct {
 	client *Client
+	// ctx is context of the current operation. it is set to copy of client by withContext
+	ctx context.Context
 }
 
 func NewRedisBySelectors(slcSelector *Selector, llcSelector *Selector) CacheServer {
@@ -27,6 +45,31 @@ func (c *RedisClient) GetClient() *Client {
 	return c.client
 }
 
+// SetRedisCompareAndSwap enables CasID of values got from redis.
+// If it is disabled, CasID is always 0 and Set never compares value
+func (c *Client) SetRedisCompareAndSwap(enabled bool) {
+	c.redisCompareAndSwap = enabled
+}
+
+func (c *RedisClient) casID(value []byte) uint64 {
+	if !c.client.redisCompareAndSwap {
+		return 0
+	}
+	return redisCasID(value)
+}
+
+// withContext returns copy of client running operations by ctx
+func (c *RedisClient) withContext(ctx context.Context) *RedisClient {
+	return &RedisClient{client: c.client, ctx: ctx}
+}
+
+func (c *RedisClient) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
 func (c *RedisClient) SetTimeout(timeout time.Duration) error {
 	if timeout == time.Duration(0) {
 		return ErrSetTimeout
@@ -43,8 +86,8 @@ func (c *RedisClient) SetMaxIdleConnections(maxIdle int) error {
 	return nil
 }
 
-func (c *RedisClient) Get(key CacheKey) (*CacheGetResponse, error) {
-	item, err := c.get(key)
+func (c *RedisClient) Get(ctx context.Context, key CacheKey) (*CacheGetResponse, error) {
+	item, err := c.withContext(ctx).get(key)
 
 	if err == ErrRedisCacheMiss {
 		return nil, ErrCacheMiss
@@ -56,11 +99,12 @@ func (c *RedisClient) Get(key CacheKey) (*CacheGetResponse, error) {
 
 	return &CacheGetResponse{
 		Value: item.Value,
+		CasID: c.casID(item.Value),
 	}, nil
 }
 
-func (c *RedisClient) GetMulti(keys []CacheKey) (*Iterator, error) {
-	itemMap, err := c.getMulti(keys)
+func (c *RedisClient) GetMulti(ctx context.Context, keys []CacheKey) (*Iterator, error) {
+	itemMap, err := c.withContext(ctx).getMulti(keys)
 	if err != nil {
 		return nil, xerrors.Errorf("failed to get caches: %w", err)
 	}
@@ -71,6 +115,7 @@ func (c *RedisClient) GetMulti(keys []CacheKey) (*Iterator, error) {
 			if len(item.Value) != 0 {
 				iter.SetContent(idx, &CacheGetResponse{
 					Value: item.Value,
+					CasID: c.casID(item.Value),
 				})
 			} else {
 				iter.SetError(idx, ErrCacheMiss)
@@ -82,7 +127,8 @@ func (c *RedisClient) GetMulti(keys []CacheKey) (*Iterator, error) {
 	return iter, nil
 }
 
-func (c *RedisClient) Set(req *CacheStoreRequest) error {
+func (c *RedisClient) Set(ctx context.Context, req *CacheStoreRequest) error {
+	c = c.withContext(ctx)
 	item := &Item{
 		Key:        req.Key,
 		Flags:      req.Key.Hash(),
@@ -90,6 +136,12 @@ func (c *RedisClient) Set(req *CacheStoreRequest) error {
 		casid:      req.CasID,
 		Expiration: int32(req.Expiration / time.Second),
 	}
+	if req.CasID != 0 {
+		if err := c.onItem(item, (*RedisClient).cas); err != nil {
+			return xerrors.Errorf("failed set value to %s: %w", req.Key, err)
+		}
+		return nil
+	}
 
 	if err := c.onItem(
 		item,
@@ -101,8 +153,8 @@ func (c *RedisClient) Set(req *CacheStoreRequest) error {
 	return nil
 }
 
-func (c *RedisClient) Add(key CacheKey, value []byte, expiration time.Duration) error {
-	if err := c.onItem(
+func (c *RedisClient) Add(ctx context.Context, key CacheKey, value []byte, expiration time.Duration) error {
+	if err := c.withContext(ctx).onItem(
 		&Item{
 			Key:        key,
 			Value:      value,
@@ -116,8 +168,38 @@ func (c *RedisClient) Add(key CacheKey, value []byte, expiration time.Duration)
 	return nil
 }
 
-func (c *RedisClient) Delete(key CacheKey) error {
-	if err := c.delete(key); err != nil {
+// redisIncrScript increments KEYS[1] by ARGV[1], and sets expiration ARGV[2] ( milliseconds ) if the key doesn't have it.
+// They are done by one script, so key is never left without expiration
+var redisIncrScript = redis.NewScript(1, `
+local value = redis.call('incrby', KEYS[1], ARGV[1])
+if tonumber(ARGV[2]) > 0 and redis.call('pttl', KEYS[1]) == -1 then
+  redis.call('pexpire', KEYS[1], ARGV[2])
+end
+return value
+`)
+
+// Incr atomically increments key by delta.
+// If key doesn't exist, it is created by delta with expiration.
+func (c *RedisClient) Incr(ctx context.Context, key CacheKey, delta uint64, expiration time.Duration) (uint64, error) {
+	c = c.withContext(ctx)
+	var value uint64
+	if err := c.client.withKeyAddr(key, func(addr net.Addr) error {
+		return c.withConn(addr, func(rc redis.Conn) error {
+			v, err := redis.Uint64(redisIncrScript.Do(rc, key, delta, int64(expiration/time.Millisecond)))
+			if err != nil {
+				return err
+			}
+			value = v
+			return nil
+		})
+	}); err != nil {
+		return 0, xerrors.Errorf("failed to increment value of %s: %w", key, err)
+	}
+	return value, nil
+}
+
+func (c *RedisClient) Delete(ctx context.Context, key CacheKey) error {
+	if err := c.withContext(ctx).delete(key); err != nil {
 		if err == ErrRedisCacheMiss {
 			// ignore cache miss
 			return nil
@@ -127,7 +209,16 @@ func (c *RedisClient) Delete(key CacheKey) error {
 	return nil
 }
 
-func (c *RedisClient) Flush() error {
+func (c *RedisClient) Flush(ctx context.Context) error {
+	c = c.withContext(ctx)
+	if c.client.cluster != nil {
+		for _, addr := range c.client.cluster.nodes() {
+			if err := c.flushAllFromAddr(addr); err != nil {
+				return xerrors.Errorf("failed to flush redis cluster node: %w", err)
+			}
+		}
+		return nil
+	}
 	if err := c.client.slcSelector.Each(c.flushAllFromAddr); err != nil {
 		return xerrors.Errorf("failed to flush second level cache: %w", err)
 	}
@@ -159,42 +250,16 @@ func (c *RedisClient) getMulti(keys []CacheKey) (map[string]*Item, error) {
 		m[it.Key.String()] = it
 	}
 
-	keyMap := make(map[net.Addr][]string, len(keys))
-	for _, key := range keys {
-		k := key.String()
-		if !legalKey(k) {
-			return nil, ErrMalformedKey
-		}
-		addr, err := c.client.getAddr(key)
-		if err != nil {
-			return nil, err
-		}
-		keyMap[addr] = append(keyMap[addr], k)
-	}
-
-	addrNum := len(keyMap)
-	if addrNum == 1 {
-		for addr, keys := range keyMap {
-			if err := c.getFromAddr(addr, keys, addItemToMap); err != nil {
-				return nil, err
-			}
-			return m, nil
-		}
-	}
-	ch := make(chan error, buffered)
-	for addr, keys := range keyMap {
-		go func(addr net.Addr, keys []string) {
-			ch <- c.getFromAddr(addr, keys, addItemToMap)
-		}(addr, keys)
+	batches, err := c.client.getMultiBatches(keys)
+	if err != nil {
+		return nil, err
 	}
-
-	var err error
-	for range keyMap {
-		if ge := <-ch; ge != nil {
-			err = ge
-		}
+	if err := getMultiByBatches(batches, func(addr net.Addr, keys []string) error {
+		return c.getFromAddr(addr, keys, addItemToMap)
+	}); err != nil {
+		return nil, err
 	}
-	return m, err
+	return m, nil
 }
 
 func (c *RedisClient) set(rc redis.Conn, item *Item) error {
@@ -207,35 +272,73 @@ func (c *RedisClient) add(rc redis.Conn, item *Item) error {
 	return res
 }
 
-func (c *RedisClient) delete(key CacheKey) error {
-	return c.client.withKeyAddr(key, func(addr net.Addr) (e error) {
-		cn, err := c.client.getConn(addr)
-		if err != nil {
-			return err
+// cas sets value only if current value has the same version as item.casid by WATCH/MULTI/EXEC
+func (c *RedisClient) cas(rc redis.Conn, item *Item) error {
+	if !legalKey(item.Key.String()) {
+		return ErrMalformedKey
+	}
+	if _, err := rc.Do("watch", item.Key); err != nil {
+		return err
+	}
+	value, err := redis.Bytes(rc.Do("get", item.Key))
+	if err != nil {
+		_, _ = rc.Do("unwatch")
+		if err == redis.ErrNil {
+			return ErrRedisCacheMiss
 		}
-		defer cn.condRelease(&err)
+		return err
+	}
+	if redisCasID(value) != item.casid {
+		_, _ = rc.Do("unwatch")
+		return ErrRedisCASConflict
+	}
+	if err := rc.Send("multi"); err != nil {
+		return err
+	}
+	args := []interface{}{item.Key, item.Value}
+	if item.Expiration != 0 {
+		args = append(args, "px", item.Expiration)
+	}
+	if err := rc.Send("set", args...); err != nil {
+		return err
+	}
+	reply, err := rc.Do("exec")
+	if err != nil {
+		return err
+	}
+	if reply == nil {
+		return ErrRedisCASConflict
+	}
+	return nil
+}
 
-		rc := c.getRedisConn(cn)
+func (c *RedisClient) delete(key CacheKey) error {
+	return c.client.withKeyAddr(key, func(addr net.Addr) error {
+		return c.withConn(addr, func(rc redis.Conn) error {
+			reply, err := rc.Do("del", key)
+			if isRedisAuthError(err) {
+				return err
+			}
 
-		reply, err := rc.Do("del", key)
+			status, ok := reply.(int64)
+			if ok && status == 0 {
+				return ErrRedisCacheMiss
+			}
+			return nil
+		})
+	})
+}
 
-		status, ok := reply.(int64)
-		if ok && status == 0 {
-			return ErrRedisCacheMiss
-		}
-		return nil
+func (c *RedisClient) getFromAddr(addr net.Addr, keys []string, cb func(*Item)) error {
+	return c.withConn(addr, func(rc redis.Conn) error {
+		return c.getFromConn(rc, keys, cb)
 	})
 }
 
-func (c *RedisClient) getFromAddr(addr net.Addr, keys []string, cb func(*Item)) (err error) {
-	cn, err := c.client.getConn(addr)
-	if err != nil {
-		return err
+func (c *RedisClient) getFromConn(rc redis.Conn, keys []string, cb func(*Item)) (err error) {
+	if c.client.cluster != nil && len(keys) > 1 {
+		return c.getFromClusterConn(rc, keys, cb)
 	}
-	defer cn.condRelease(&err)
-
-	rc := c.getRedisConn(cn)
-
 	replies := make([]*Item, len(keys))
 	for i, key := range keys {
 		replies[i] = new(Item)
@@ -271,20 +374,13 @@ func (c *RedisClient) getFromAddr(addr net.Addr, keys []string, cb func(*Item))
 	return nil
 }
 
-func (c *RedisClient) flushAllFromAddr(addr net.Addr) (err error) {
-	cn, err := c.client.getConn(addr)
-	if err != nil {
-		return err
-	}
-	defer cn.condRelease(&err)
-
-	rc := c.getRedisConn(cn)
-
-	if _, err := rc.Do("FLUSHALL"); err != nil {
-		return err
-	}
-
-	return nil
+func (c *RedisClient) flushAllFromAddr(addr net.Addr) error {
+	return c.withConn(addr, func(rc redis.Conn) error {
+		if _, err := rc.Do("FLUSHALL"); err != nil {
+			return err
+		}
+		return nil
+	})
 }
 
 func (c *RedisClient) onItem(item *Item, fn func(*RedisClient, redis.Conn, *Item) error) (err error) {
@@ -293,19 +389,9 @@ func (c *RedisClient) onItem(item *Item, fn func(*RedisClient, redis.Conn, *Item
 		return err
 	}
 
-	cn, err := c.client.getConn(addr)
-	if err != nil {
-		return err
-	}
-	defer cn.condRelease(&err)
-
-	rc := c.getRedisConn(cn)
-
-	if err = fn(c, rc, item); err != nil {
-		return err
-	}
-
-	return nil
+	return c.withConn(addr, func(rc redis.Conn) error {
+		return fn(c, rc, item)
+	})
 }
 
 func (c *RedisClient) populateOne(conn redis.Conn, verb string, item *Item) error {
@@ -333,7 +419,8 @@ func (c *RedisClient) populateOne(conn redis.Conn, verb string, item *Item) erro
 }
 
 func (c *RedisClient) getRedisConn(cn *conn) redis.Conn {
-	return redis.NewConn(cn.nc, c.client.timeout, c.client.timeout)
+	timeout := c.client.timeoutContext(c.context())
+	return redis.NewConn(cn.nc, timeout, timeout)
 }
 
 func parseGetRedisResponse(replies []*Item, cb func(*Item)) {
@@ -0,0 +1,309 @@
+package server
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gomodule/redigo/redis"
+	"golang.org/x/xerrors"
+)
+
+const (
+	redisClusterSlots = 16384
+	// maxRedisRedirects is the number of MOVED/ASK redirects followed by single command
+	maxRedisRedirects = 5
+)
+
+var (
+	ErrRedisClusterNoNode = xerrors.New("redis cluster: no node is available")
+)
+
+// redisCluster keeps which node serves each hash slot of Redis Cluster.
+// Slots are loaded by CLUSTER SLOTS at the first command and updated by MOVED redirects
+type redisCluster struct {
+	mu     sync.RWMutex
+	seeds  []net.Addr
+	slots  []net.Addr
+	loaded bool
+	load   func(net.Addr) ([]*redisClusterSlotRange, error)
+}
+
+type redisClusterSlotRange struct {
+	start int
+	end   int
+	addr  net.Addr
+}
+
+// NewRedisCluster creates client of Redis Cluster. addrs are seed nodes to discover the cluster
+func NewRedisCluster(addrs ...string) (CacheServer, error) {
+	selector, err := NewSelector(addrs...)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create selector: %w", err)
+	}
+	if len(selector.ring.addrs) == 0 {
+		return nil, ErrRedisClusterNoNode
+	}
+	cluster := &redisCluster{
+		seeds: selector.ring.addrs,
+		slots: make([]net.Addr, redisClusterSlots),
+	}
+	client := &Client{slcSelector: selector, llcSelector: selector, cluster: cluster}
+	redisClient := &RedisClient{client: client}
+	cluster.load = redisClient.clusterSlots
+	return redisClient, nil
+}
+
+// redisClusterSlot returns hash slot of key. If key has hash tag ( e.g. {user1}.name ), only the tag is hashed
+func redisClusterSlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	return int(crc16([]byte(key)) % redisClusterSlots)
+}
+
+// crc16 is CRC16-CCITT ( XMODEM ) used by Redis Cluster
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func (c *redisCluster) pickServer(key CacheKey) net.Addr {
+	c.loadSlots()
+	slot := redisClusterSlot(key.String())
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if addr := c.slots[slot]; addr != nil {
+		return addr
+	}
+	// node serving slot is unknown yet, so it is found by MOVED redirect from seed node
+	return c.seeds[slot%len(c.seeds)]
+}
+
+// loadSlots loads slots by CLUSTER SLOTS from seed nodes. It is retried by next command if every seed node fails
+func (c *redisCluster) loadSlots() {
+	c.mu.RLock()
+	loaded := c.loaded
+	c.mu.RUnlock()
+	if loaded || c.load == nil {
+		return
+	}
+	for _, seed := range c.seeds {
+		ranges, err := c.load(seed)
+		if err != nil {
+			continue
+		}
+		c.mu.Lock()
+		for _, r := range ranges {
+			for slot := r.start; slot <= r.end && slot < redisClusterSlots; slot++ {
+				c.slots[slot] = r.addr
+			}
+		}
+		c.loaded = true
+		c.mu.Unlock()
+		return
+	}
+}
+
+func (c *redisCluster) setSlot(slot int, addr net.Addr) {
+	if slot < 0 || slot >= redisClusterSlots {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.slots[slot] = addr
+}
+
+// nodes returns master nodes serving slots, or seed nodes if slots aren't loaded
+func (c *redisCluster) nodes() []net.Addr {
+	c.loadSlots()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	nodes := []net.Addr{}
+	found := map[string]struct{}{}
+	for _, addr := range c.slots {
+		if addr == nil {
+			continue
+		}
+		if _, exists := found[addr.String()]; exists {
+			continue
+		}
+		found[addr.String()] = struct{}{}
+		nodes = append(nodes, addr)
+	}
+	if len(nodes) == 0 {
+		return c.seeds
+	}
+	return nodes
+}
+
+func (c *RedisClient) clusterSlots(seed net.Addr) ([]*redisClusterSlotRange, error) {
+	var ranges []*redisClusterSlotRange
+	if err := c.withAuthenticatedConn(seed, func(rc redis.Conn) error {
+		reply, err := redis.Values(rc.Do("cluster", "slots"))
+		if err != nil {
+			return err
+		}
+		parsed, err := parseRedisClusterSlots(reply, seed)
+		if err != nil {
+			return err
+		}
+		ranges = parsed
+		return nil
+	}); err != nil {
+		return nil, xerrors.Errorf("failed to get cluster slots from %s: %w", seed, err)
+	}
+	return ranges, nil
+}
+
+// parseRedisClusterSlots parses reply of CLUSTER SLOTS. Empty host means the same host as seed
+func parseRedisClusterSlots(reply []interface{}, seed net.Addr) ([]*redisClusterSlotRange, error) {
+	ranges := []*redisClusterSlotRange{}
+	for _, entry := range reply {
+		values, err := redis.Values(entry, nil)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid slot range: %w", err)
+		}
+		if len(values) < 3 {
+			return nil, xerrors.Errorf("invalid slot range %v", values)
+		}
+		start, err := redis.Int(values[0], nil)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid start of slot range: %w", err)
+		}
+		end, err := redis.Int(values[1], nil)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid end of slot range: %w", err)
+		}
+		master, err := redis.Values(values[2], nil)
+		if err != nil || len(master) < 2 {
+			return nil, xerrors.Errorf("invalid master node of slot range %d-%d", start, end)
+		}
+		host, err := redis.String(master[0], nil)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid host of master node: %w", err)
+		}
+		port, err := redis.Int(master[1], nil)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid port of master node: %w", err)
+		}
+		if host == "" {
+			if seedHost, _, err := net.SplitHostPort(seed.String()); err == nil {
+				host = seedHost
+			}
+		}
+		addr, err := getAddr(net.JoinHostPort(host, strconv.Itoa(port)))
+		if err != nil {
+			return nil, xerrors.Errorf("failed to get addr of master node: %w", err)
+		}
+		ranges = append(ranges, &redisClusterSlotRange{start: start, end: end, addr: addr})
+	}
+	return ranges, nil
+}
+
+type redisRedirect struct {
+	asking bool
+	slot   int
+	addr   string
+}
+
+// parseRedisRedirect parses MOVED or ASK error ( e.g. MOVED 3999 127.0.0.1:6381 )
+func parseRedisRedirect(err error) (*redisRedirect, bool) {
+	var rerr redis.Error
+	if !xerrors.As(err, &rerr) {
+		return nil, false
+	}
+	fields := strings.Fields(string(rerr))
+	if len(fields) != 3 || (fields[0] != "MOVED" && fields[0] != "ASK") {
+		return nil, false
+	}
+	slot, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, false
+	}
+	return &redisRedirect{asking: fields[0] == "ASK", slot: slot, addr: fields[2]}, true
+}
+
+// followRedirects runs fn again by node in MOVED or ASK error.
+// MOVED updates node of the slot, but ASK is only for the next command because slot is being migrated
+func (c *RedisClient) followRedirects(err error, fn func(redis.Conn) error) error {
+	for redirects := 0; redirects < maxRedisRedirects; redirects++ {
+		redirect, ok := parseRedisRedirect(err)
+		if !ok {
+			return err
+		}
+		addr, addrErr := getAddr(redirect.addr)
+		if addrErr != nil {
+			return xerrors.Errorf("failed to redirect to %s: %w", redirect.addr, addrErr)
+		}
+		if !redirect.asking {
+			c.client.cluster.setSlot(redirect.slot, addr)
+			err = c.withAuthenticatedConn(addr, fn)
+			continue
+		}
+		err = c.withAuthenticatedConn(addr, func(rc redis.Conn) error {
+			if _, err := rc.Do("asking"); err != nil {
+				return err
+			}
+			return fn(rc)
+		})
+	}
+	return err
+}
+
+// getFromClusterConn gets values by pipelined GET, because MGET fails if keys belong to different slots on the same node.
+// Keys redirected to other node are got one by one, so that single GET follows the redirect
+func (c *RedisClient) getFromClusterConn(rc redis.Conn, keys []string, cb func(*Item)) error {
+	for _, key := range keys {
+		if err := rc.Send("get", key); err != nil {
+			return err
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return err
+	}
+	redirected := []string{}
+	for _, key := range keys {
+		value, err := redis.Bytes(rc.Receive())
+		if err == redis.ErrNil {
+			continue
+		}
+		if redirect, ok := parseRedisRedirect(err); ok {
+			if !redirect.asking {
+				if addr, err := getAddr(redirect.addr); err == nil {
+					c.client.cluster.setSlot(redirect.slot, addr)
+				}
+			}
+			redirected = append(redirected, key)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		cb(&Item{Key: StringCacheKey(key), Value: value})
+	}
+	for _, key := range redirected {
+		item, err := c.get(StringCacheKey(key))
+		if err == ErrRedisCacheMiss {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		cb(item)
+	}
+	return nil
+}
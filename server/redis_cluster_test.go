@@ -0,0 +1,102 @@
+package server
+
+import (
+	"net"
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+	"golang.org/x/xerrors"
+)
+
+func TestRedisClusterSlot(t *testing.T) {
+	Equal(t, crc16([]byte("123456789")), uint16(0x31C3))
+	Equal(t, redisClusterSlot("foo"), 12182)
+	Equal(t, redisClusterSlot("bar"), 5061)
+	t.Run("hash tag", func(t *testing.T) {
+		Equal(t, redisClusterSlot("{user1000}.following"), redisClusterSlot("user1000"))
+		Equal(t, redisClusterSlot("{user1000}.followers"), redisClusterSlot("{user1000}.following"))
+	})
+	t.Run("empty hash tag", func(t *testing.T) {
+		Equal(t, redisClusterSlot("foo{}{bar}"), int(crc16([]byte("foo{}{bar}"))%redisClusterSlots))
+	})
+}
+
+func TestParseRedisRedirect(t *testing.T) {
+	t.Run("moved", func(t *testing.T) {
+		redirect, ok := parseRedisRedirect(redis.Error("MOVED 3999 127.0.0.1:6381"))
+		Equal(t, ok, true)
+		Equal(t, *redirect, redisRedirect{slot: 3999, addr: "127.0.0.1:6381"})
+	})
+	t.Run("ask", func(t *testing.T) {
+		redirect, ok := parseRedisRedirect(xerrors.Errorf("failed to get: %w", redis.Error("ASK 3999 127.0.0.1:6381")))
+		Equal(t, ok, true)
+		Equal(t, *redirect, redisRedirect{asking: true, slot: 3999, addr: "127.0.0.1:6381"})
+	})
+	t.Run("other error", func(t *testing.T) {
+		_, ok := parseRedisRedirect(redis.Error("ERR unknown command"))
+		Equal(t, ok, false)
+		_, ok = parseRedisRedirect(nil)
+		Equal(t, ok, false)
+	})
+}
+
+func TestRedisClusterSlots(t *testing.T) {
+	seed, err := getAddr("127.0.0.1:7000")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	node, err := getAddr("127.0.0.1:7001")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	ranges, err := parseRedisClusterSlots([]interface{}{
+		[]interface{}{int64(0), int64(8191), []interface{}{[]byte(""), int64(7000), []byte("id0")}},
+		[]interface{}{int64(8192), int64(16383), []interface{}{[]byte("127.0.0.1"), int64(7001), []byte("id1")}},
+	}, seed)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	Equal(t, len(ranges), 2)
+	Equal(t, ranges[0].addr.String(), seed.String())
+	Equal(t, ranges[1].addr.String(), node.String())
+
+	loaded := 0
+	cluster := &redisCluster{
+		seeds: []net.Addr{seed},
+		slots: make([]net.Addr, redisClusterSlots),
+		load: func(net.Addr) ([]*redisClusterSlotRange, error) {
+			loaded++
+			return ranges, nil
+		},
+	}
+	client := &Client{cluster: cluster}
+	t.Run("pick node by slot", func(t *testing.T) {
+		addr, err := client.getAddr(StringCacheKey("bar"))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		Equal(t, addr.String(), seed.String())
+		addr, err = client.getAddr(StringCacheKey("foo"))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		Equal(t, addr.String(), node.String())
+		Equal(t, loaded, 1)
+	})
+	t.Run("group keys by node", func(t *testing.T) {
+		batches, err := client.getMultiBatches([]CacheKey{StringCacheKey("foo"), StringCacheKey("bar"), StringCacheKey("{bar}.name")})
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		Equal(t, len(batches), 2)
+	})
+	t.Run("moved", func(t *testing.T) {
+		cluster.setSlot(redisClusterSlot("foo"), seed)
+		addr, err := client.getAddr(StringCacheKey("foo"))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		Equal(t, addr.String(), seed.String())
+		Equal(t, len(cluster.nodes()), 2)
+	})
+}
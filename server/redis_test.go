@@ -1,11 +1,14 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"testing"
 	"time"
 	"unsafe"
 
+	"github.com/gomodule/redigo/redis"
 	"golang.org/x/xerrors"
 )
 
@@ -39,6 +42,7 @@ func RedisTestSetup() {
 	for i := 1; i <= 3; i++ {
 		value := fmt.Sprintf("value%d", i)
 		if err := redisCacheServer.Set(
+			context.Background(),
 			&CacheStoreRequest{
 				Key: &TestSlcCacheKey{
 					key: fmt.Sprintf("key%d", i),
@@ -52,7 +56,7 @@ func RedisTestSetup() {
 }
 
 func RedisTestTeardown() {
-	if err := redisCacheServer.Flush(); err != nil {
+	if err := redisCacheServer.Flush(context.Background()); err != nil {
 		panic(err)
 	}
 }
@@ -147,6 +151,17 @@ func TestRedisSetMaxIdleConnections(t *testing.T) {
 	}
 }
 
+func TestRedisCompareAndSwap(t *testing.T) {
+	client := &RedisClient{client: &Client{}}
+	value := []byte("value")
+	Equal(t, uint64(0), client.casID(value))
+	client.GetClient().SetRedisCompareAndSwap(true)
+	Equal(t, redisCasID(value), client.casID(value))
+	if client.casID(value) == 0 {
+		t.Fatal("cas id must not be 0 if compare-and-swap is enabled")
+	}
+}
+
 func TestRedisGet(t *testing.T) {
 	tests := []struct {
 		cacheKey      *TestSlcCacheKey
@@ -173,7 +188,7 @@ func TestRedisGet(t *testing.T) {
 		tt := tt
 		t.Run(fmt.Sprintf("TestRedisGet:%v\n", i), func(t *testing.T) {
 			tt.expected.Value = *(*[]byte)(unsafe.Pointer(&tt.cacheValue))
-			actual, err := redisCacheServer.Get(tt.cacheKey)
+			actual, err := redisCacheServer.Get(context.Background(), tt.cacheKey)
 			if tt.cacheKey.key == "cachemiss" {
 				Equal(t, tt.expectedError, err)
 			} else {
@@ -234,7 +249,7 @@ func TestRedisGetMulti(t *testing.T) {
 				tt.expected.SetError(i, tt.cacheErrors[i])
 			}
 
-			actual, err := redisCacheServer.GetMulti(tt.cacheKeys)
+			actual, err := redisCacheServer.GetMulti(context.Background(), tt.cacheKeys)
 			if err == nil {
 				for actual.Next() {
 					tt.expected.Next()
@@ -286,13 +301,13 @@ func TestRedisSet(t *testing.T) {
 		tt := tt
 		t.Run(fmt.Sprintf("TesRedisSet:%v\n", i), func(t *testing.T) {
 			tt.cacheStoreRequest.Value = *(*[]byte)(unsafe.Pointer(&tt.requestValue))
-			err := redisCacheServer.Set(tt.cacheStoreRequest)
+			err := redisCacheServer.Set(context.Background(), tt.cacheStoreRequest)
 			if tt.cacheStoreRequest.Key.String() == string(0x00) {
 				Equal(t, tt.expectedError.Error(), err.Error())
 			} else {
 				Equal(t, tt.expectedError, err)
 
-				reply, err := redisCacheServer.Get(tt.cacheStoreRequest.Key)
+				reply, err := redisCacheServer.Get(context.Background(), tt.cacheStoreRequest.Key)
 				if err != nil {
 					t.Fatalf("%+v", err)
 				}
@@ -328,13 +343,13 @@ func TestRedisAdd(t *testing.T) {
 	for i, tt := range tests {
 		tt := tt
 		t.Run(fmt.Sprintf("TestRedisAdd:%v\n", i), func(t *testing.T) {
-			err := redisCacheServer.Add(tt.key, *(*[]byte)(unsafe.Pointer(&tt.value)), tt.expiration)
+			err := redisCacheServer.Add(context.Background(), tt.key, *(*[]byte)(unsafe.Pointer(&tt.value)), tt.expiration)
 			if tt.key.String() == string(0x00) {
 				Equal(t, tt.expectedError.Error(), err.Error())
 			} else {
 				Equal(t, tt.expectedError, err)
 
-				reply, err := redisCacheServer.Get(tt.key)
+				reply, err := redisCacheServer.Get(context.Background(), tt.key)
 				if err != nil {
 					t.Fatalf("%+v", err)
 				}
@@ -344,6 +359,39 @@ func TestRedisAdd(t *testing.T) {
 	}
 }
 
+func TestRedisIncr(t *testing.T) {
+	key := &TestSlcCacheKey{key: "TestRedisIncr"}
+	ctx := context.Background()
+	value, err := Incr(ctx, redisCacheServer, key, 2, time.Minute)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	Equal(t, value, uint64(2))
+	value, err = Incr(ctx, redisCacheServer, key, 3, time.Minute)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	Equal(t, value, uint64(5))
+
+	t.Run("expiration is set with increment", func(t *testing.T) {
+		client := redisCacheServer.(*RedisClient)
+		if err := client.client.withKeyAddr(key, func(addr net.Addr) error {
+			return client.withConn(addr, func(rc redis.Conn) error {
+				ttl, err := redis.Int64(rc.Do("pttl", key))
+				if err != nil {
+					return err
+				}
+				if ttl <= 0 || ttl > int64(time.Minute/time.Millisecond) {
+					t.Fatalf("unexpected ttl %d", ttl)
+				}
+				return nil
+			})
+		}); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	})
+}
+
 func TestRedisDelete(t *testing.T) {
 	tests := []struct {
 		cacheStoreRequest *CacheStoreRequest
@@ -364,17 +412,17 @@ func TestRedisDelete(t *testing.T) {
 		tt := tt
 		t.Run(fmt.Sprintf("TestRedisDelete:%v\n", i), func(t *testing.T) {
 			tt.cacheStoreRequest.Value = *(*[]byte)(unsafe.Pointer(&tt.requestValue))
-			err := redisCacheServer.Set(tt.cacheStoreRequest)
+			err := redisCacheServer.Set(context.Background(), tt.cacheStoreRequest)
 			if err != nil {
 				t.Fatalf("%+v", err)
 			}
 
 			// fisrt time
-			err = redisCacheServer.Delete(tt.cacheStoreRequest.Key)
+			err = redisCacheServer.Delete(context.Background(), tt.cacheStoreRequest.Key)
 			Equal(t, tt.expected, err)
 
 			// second time
-			err = redisCacheServer.Delete(tt.cacheStoreRequest.Key)
+			err = redisCacheServer.Delete(context.Background(), tt.cacheStoreRequest.Key)
 			Equal(t, tt.expected, err)
 		})
 	}
@@ -391,7 +439,7 @@ func TestRedisFlush(t *testing.T) {
 	for i, tt := range tests {
 		tt := tt
 		t.Run(fmt.Sprintf("TestRedisFlush:%v\n", i), func(t *testing.T) {
-			err := redisCacheServer.Flush()
+			err := redisCacheServer.Flush(context.Background())
 			Equal(t, tt.expected, err)
 		})
 	}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// RetryPolicy controls retry of cache server operations failed by transient errors.
+// Add and Incr are never retried, and Set is not retried if it is compare-and-swap,
+// because the first attempt may be applied to the server even if it returned an error.
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts including the first one
+	Attempts int
+	// Backoff returns the interval before n-th retry ( 1 origin ). no interval if nil
+	Backoff func(n int) time.Duration
+	// Retryable classifies err as transient. IsTransientError is used if nil
+	Retryable func(error) bool
+}
+
+// ExponentialBackoff doubles interval from base for each retry up to max
+func ExponentialBackoff(base, max time.Duration) func(int) time.Duration {
+	return func(n int) time.Duration {
+		interval := base
+		for i := 1; i < n && interval < max; i++ {
+			interval *= 2
+		}
+		if interval > max {
+			return max
+		}
+		return interval
+	}
+}
+
+// IsTransientError returns true if err is caused by closed connection or timeout
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if xerrors.Is(err, io.EOF) || xerrors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var cte *ConnectTimeoutError
+	if xerrors.As(err, &cte) {
+		return true
+	}
+	var ne net.Error
+	if xerrors.As(err, &ne) {
+		return ne.Timeout()
+	}
+	return false
+}
+
+type retryCacheServer struct {
+	CacheServer
+	policy RetryPolicy
+}
+
+// NewRetryCacheServer wraps CacheServer to retry Get/GetMulti/Set/Delete by policy
+func NewRetryCacheServer(s CacheServer, policy RetryPolicy) CacheServer {
+	if policy.Retryable == nil {
+		policy.Retryable = IsTransientError
+	}
+	return &retryCacheServer{CacheServer: s, policy: policy}
+}
+
+// retry calls fn until it succeeds or fails by non transient error. It stops when ctx is done
+func (s *retryCacheServer) retry(ctx context.Context, fn func() error) error {
+	var err error
+	for n := 0; n < s.policy.Attempts || n == 0; n++ {
+		if n > 0 && s.policy.Backoff != nil {
+			timer := time.NewTimer(s.policy.Backoff(n))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return xerrors.Errorf("canceled retry after %d attempts: %w", n, err)
+			case <-timer.C:
+			}
+		}
+		err = fn()
+		if err == nil || !s.policy.Retryable(err) || ctx.Err() != nil {
+			return err
+		}
+	}
+	return xerrors.Errorf("failed after %d attempts: %w", s.policy.Attempts, err)
+}
+
+func (s *retryCacheServer) Get(ctx context.Context, key CacheKey) (res *CacheGetResponse, e error) {
+	e = s.retry(ctx, func() error {
+		r, err := s.CacheServer.Get(ctx, key)
+		res = r
+		return err
+	})
+	return
+}
+
+func (s *retryCacheServer) GetMulti(ctx context.Context, keys []CacheKey) (iter *Iterator, e error) {
+	e = s.retry(ctx, func() error {
+		i, err := s.CacheServer.GetMulti(ctx, keys)
+		iter = i
+		return err
+	})
+	return
+}
+
+func (s *retryCacheServer) Set(ctx context.Context, req *CacheStoreRequest) error {
+	if req.CasID != 0 {
+		return s.CacheServer.Set(ctx, req)
+	}
+	return s.retry(ctx, func() error {
+		return s.CacheServer.Set(ctx, req)
+	})
+}
+
+func (s *retryCacheServer) Delete(ctx context.Context, key CacheKey) error {
+	return s.retry(ctx, func() error {
+		return s.CacheServer.Delete(ctx, key)
+	})
+}
+
+func (s *retryCacheServer) Incr(ctx context.Context, key CacheKey, delta uint64, expiration time.Duration) (uint64, error) {
+	return Incr(ctx, s.CacheServer, key, delta, expiration)
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+type flakyCacheServer struct {
+	CacheServer
+	failures int
+	calls    int
+}
+
+func (s *flakyCacheServer) Get(ctx context.Context, key CacheKey) (*CacheGetResponse, error) {
+	s.calls++
+	if s.calls <= s.failures {
+		return nil, xerrors.Errorf("failed to get cache: %w", io.EOF)
+	}
+	return &CacheGetResponse{Value: []byte("value")}, nil
+}
+
+func (s *flakyCacheServer) Set(ctx context.Context, req *CacheStoreRequest) error {
+	s.calls++
+	if s.calls <= s.failures {
+		return io.EOF
+	}
+	return nil
+}
+
+func (s *flakyCacheServer) Delete(ctx context.Context, key CacheKey) error {
+	s.calls++
+	return ErrCacheMiss
+}
+
+func TestRetryCacheServer(t *testing.T) {
+	key := StringCacheKey("key")
+	t.Run("retry transient error", func(t *testing.T) {
+		flaky := &flakyCacheServer{failures: 2}
+		s := NewRetryCacheServer(flaky, RetryPolicy{Attempts: 3})
+		res, err := s.Get(context.Background(), key)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		Equal(t, string(res.Value), "value")
+		Equal(t, flaky.calls, 3)
+	})
+	t.Run("give up after attempts", func(t *testing.T) {
+		flaky := &flakyCacheServer{failures: 3}
+		s := NewRetryCacheServer(flaky, RetryPolicy{Attempts: 2})
+		_, err := s.Get(context.Background(), key)
+		Equal(t, xerrors.Is(err, io.EOF), true)
+		Equal(t, flaky.calls, 2)
+	})
+	t.Run("don't retry not transient error", func(t *testing.T) {
+		flaky := &flakyCacheServer{}
+		s := NewRetryCacheServer(flaky, RetryPolicy{Attempts: 3})
+		Equal(t, s.Delete(context.Background(), key), ErrCacheMiss)
+		Equal(t, flaky.calls, 1)
+	})
+	t.Run("don't retry compare and swap", func(t *testing.T) {
+		flaky := &flakyCacheServer{failures: 1}
+		s := NewRetryCacheServer(flaky, RetryPolicy{Attempts: 3})
+		Equal(t, s.Set(context.Background(), &CacheStoreRequest{Key: key, CasID: 1}), io.EOF)
+		Equal(t, flaky.calls, 1)
+		Equal(t, s.Set(context.Background(), &CacheStoreRequest{Key: key}), nil)
+		Equal(t, flaky.calls, 2)
+	})
+	t.Run("stop by canceled context", func(t *testing.T) {
+		flaky := &flakyCacheServer{failures: 3}
+		s := NewRetryCacheServer(flaky, RetryPolicy{Attempts: 3, Backoff: ExponentialBackoff(time.Hour, time.Hour)})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := s.Get(ctx, key)
+		Equal(t, xerrors.Is(err, io.EOF), true)
+		Equal(t, flaky.calls, 1)
+	})
+	t.Run("incr is not supported", func(t *testing.T) {
+		s := NewRetryCacheServer(&flakyCacheServer{}, RetryPolicy{Attempts: 3})
+		_, err := Incr(context.Background(), s, key, 1, time.Minute)
+		Equal(t, xerrors.Is(err, ErrIncrNotSupported), true)
+	})
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+	Equal(t, backoff(1), 10*time.Millisecond)
+	Equal(t, backoff(2), 20*time.Millisecond)
+	Equal(t, backoff(3), 40*time.Millisecond)
+	Equal(t, backoff(4), 50*time.Millisecond)
+}
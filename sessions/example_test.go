@@ -0,0 +1,58 @@
+package sessions_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"go.knocknote.io/rapidash"
+	"go.knocknote.io/rapidash/sessions"
+	"golang.org/x/xerrors"
+)
+
+func ExampleStore_Middleware() {
+	cache, err := rapidash.New(rapidash.ServerAddrs([]string{"localhost:11211"}))
+	if err != nil {
+		panic(err)
+	}
+	store, err := sessions.NewStore(cache, sessions.TTL(time.Hour), sessions.RollingExpiration(true))
+	if err != nil {
+		panic(err)
+	}
+	type counter struct {
+		Count int `json:"count"`
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		session := sessions.FromContext(r.Context())
+		var c counter
+		if err := session.Get(&c); err != nil && !xerrors.Is(err, sessions.ErrSessionNotFound) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		c.Count++
+		if err := session.Save(&c); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, "count: %d", c.Count)
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		// issue new session id on login to prevent session fixation
+		if err := sessions.FromContext(r.Context()).Regenerate(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+	handler := store.Middleware(mux)
+
+	// request without cookie is bound to new session id
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	cookie := rec.Result().Cookies()[0]
+	fmt.Println(cookie.Name, len(cookie.Value), cookie.Secure, cookie.HttpOnly)
+
+	// Output:
+	// rapidash_session 64 true true
+}
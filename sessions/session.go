@@ -0,0 +1,431 @@
+package sessions
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.knocknote.io/rapidash"
+	"golang.org/x/xerrors"
+)
+
+var (
+	ErrSessionNotFound      = xerrors.New("session is not found")
+	ErrInvalidEncryptionKey = xerrors.New("encryption key must be 16, 24 or 32 bytes")
+	ErrInvalidSessionValue  = xerrors.New("invalid session value")
+	ErrInvalidSessionID     = xerrors.New("invalid session id")
+)
+
+const (
+	DefaultTTL        = 24 * time.Hour
+	DefaultCookieName = "rapidash_session"
+)
+
+// idSize is byte size of session id. id is encoded as hex string of twice its length
+const idSize = 32
+
+// Serializer converts session value to bytes stored in the last level cache
+type Serializer interface {
+	Serialize(interface{}) ([]byte, error)
+	Deserialize([]byte, interface{}) error
+}
+
+type JSONSerializer struct{}
+
+func (JSONSerializer) Serialize(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONSerializer) Deserialize(b []byte, v interface{}) error {
+	return json.Unmarshal(b, v)
+}
+
+type OptionFunc func(*Store)
+
+func TTL(ttl time.Duration) OptionFunc {
+	return func(s *Store) {
+		s.ttl = ttl
+	}
+}
+
+// RollingExpiration extends TTL of session every time it is read
+func RollingExpiration(enabled bool) OptionFunc {
+	return func(s *Store) {
+		s.rolling = enabled
+	}
+}
+
+// EncryptionKey encrypts session value by AES-GCM
+func EncryptionKey(key []byte) OptionFunc {
+	return func(s *Store) {
+		s.encryptionKey = key
+	}
+}
+
+func SessionSerializer(serializer Serializer) OptionFunc {
+	return func(s *Store) {
+		s.serializer = serializer
+	}
+}
+
+// Tag set tag of the last level cache for session values
+func Tag(tag string) OptionFunc {
+	return func(s *Store) {
+		s.tag = tag
+	}
+}
+
+func CookieName(name string) OptionFunc {
+	return func(s *Store) {
+		s.cookieName = name
+	}
+}
+
+// SecureCookie sets Secure attribute of session cookie. it is enabled by default
+func SecureCookie(enabled bool) OptionFunc {
+	return func(s *Store) {
+		s.secureCookie = enabled
+	}
+}
+
+// Store is a session store over the last level cache
+type Store struct {
+	cache         *rapidash.Rapidash
+	ttl           time.Duration
+	rolling       bool
+	encryptionKey []byte
+	aead          cipher.AEAD
+	serializer    Serializer
+	tag           string
+	cookieName    string
+	secureCookie  bool
+	// exists reports whether session of id is stored. it is replaced by tests
+	exists func(id string) (bool, error)
+}
+
+func NewStore(cache *rapidash.Rapidash, opts ...OptionFunc) (*Store, error) {
+	s := &Store{
+		cache:        cache,
+		ttl:          DefaultTTL,
+		serializer:   JSONSerializer{},
+		cookieName:   DefaultCookieName,
+		secureCookie: true,
+	}
+	s.exists = s.existsInCache
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.encryptionKey != nil {
+		block, err := aes.NewCipher(s.encryptionKey)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to create cipher: %w", ErrInvalidEncryptionKey)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to create gcm: %w", err)
+		}
+		s.aead = aead
+	}
+	return s, nil
+}
+
+// NewID returns random session id
+func NewID() (string, error) {
+	b := make([]byte, idSize)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", xerrors.Errorf("failed to read random bytes: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// isValidID reports whether id has the format of id returned by NewID
+func isValidID(id string) bool {
+	if len(id) != idSize*2 {
+		return false
+	}
+	for _, c := range id {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Store) cacheKey(id string) string {
+	return fmt.Sprintf("session/%s", id)
+}
+
+func (s *Store) encrypt(content []byte) ([]byte, error) {
+	if s.aead == nil {
+		return content, nil
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, xerrors.Errorf("failed to read nonce: %w", err)
+	}
+	return s.aead.Seal(nonce, nonce, content, nil), nil
+}
+
+func (s *Store) decrypt(content []byte) ([]byte, error) {
+	if s.aead == nil {
+		return content, nil
+	}
+	nonceSize := s.aead.NonceSize()
+	if len(content) < nonceSize {
+		return nil, xerrors.Errorf("too short encrypted value: %w", ErrInvalidSessionValue)
+	}
+	plain, err := s.aead.Open(nil, content[:nonceSize], content[nonceSize:], nil)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decrypt value (%s): %w", err.Error(), ErrInvalidSessionValue)
+	}
+	return plain, nil
+}
+
+func (s *Store) encode(v interface{}) ([]byte, error) {
+	content, err := s.serializer.Serialize(v)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to serialize session value: %w", err)
+	}
+	encrypted, err := s.encrypt(content)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to encrypt session value: %w", err)
+	}
+	return encrypted, nil
+}
+
+func (s *Store) decode(content []byte, v interface{}) error {
+	decrypted, err := s.decrypt(content)
+	if err != nil {
+		return xerrors.Errorf("failed to decrypt session value: %w", err)
+	}
+	if err := s.serializer.Deserialize(decrypted, v); err != nil {
+		return xerrors.Errorf("failed to deserialize session value: %w", err)
+	}
+	return nil
+}
+
+// Get finds session value by id. If session doesn't exist, returns ErrSessionNotFound
+func (s *Store) Get(id string, v interface{}) error {
+	if !isValidID(id) {
+		return xerrors.Errorf("failed to find session: %w", ErrInvalidSessionID)
+	}
+	tx, err := s.cache.Begin()
+	if err != nil {
+		return xerrors.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.RollbackUnlessCommitted()
+	}()
+	var content []byte
+	if err := tx.FindWithTag(s.tag, s.cacheKey(id), rapidash.BytesPtr(&content)); err != nil {
+		if rapidash.IsCacheMiss(err) {
+			return xerrors.Errorf("failed to find session %s: %w", id, ErrSessionNotFound)
+		}
+		return xerrors.Errorf("failed to find session: %w", err)
+	}
+	if err := s.decode(content, v); err != nil {
+		return xerrors.Errorf("failed to decode session: %w", err)
+	}
+	if s.rolling {
+		if err := tx.UpdateWithTagAndExpiration(s.tag, s.cacheKey(id), rapidash.Bytes(content), s.ttl); err != nil {
+			return xerrors.Errorf("failed to extend session: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return xerrors.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// Save stores session value with TTL
+func (s *Store) Save(id string, v interface{}) error {
+	if !isValidID(id) {
+		return xerrors.Errorf("failed to save session: %w", ErrInvalidSessionID)
+	}
+	content, err := s.encode(v)
+	if err != nil {
+		return xerrors.Errorf("failed to encode session: %w", err)
+	}
+	tx, err := s.cache.Begin()
+	if err != nil {
+		return xerrors.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.RollbackUnlessCommitted()
+	}()
+	if err := tx.UpdateWithTagAndExpiration(s.tag, s.cacheKey(id), rapidash.Bytes(content), s.ttl); err != nil {
+		return xerrors.Errorf("failed to save session: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return xerrors.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// Destroy deletes session. It is not error if session doesn't exist
+func (s *Store) Destroy(id string) error {
+	if !isValidID(id) {
+		return xerrors.Errorf("failed to destroy session: %w", ErrInvalidSessionID)
+	}
+	tx, err := s.cache.Begin()
+	if err != nil {
+		return xerrors.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.RollbackUnlessCommitted()
+	}()
+	var content []byte
+	if err := tx.FindWithTag(s.tag, s.cacheKey(id), rapidash.BytesPtr(&content)); err != nil {
+		if rapidash.IsCacheMiss(err) {
+			return nil
+		}
+		return xerrors.Errorf("failed to find session: %w", err)
+	}
+	if err := tx.DeleteWithTag(s.tag, s.cacheKey(id)); err != nil {
+		return xerrors.Errorf("failed to destroy session: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return xerrors.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) existsInCache(id string) (bool, error) {
+	tx, err := s.cache.Begin()
+	if err != nil {
+		return false, xerrors.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.RollbackUnlessCommitted()
+	}()
+	var content []byte
+	if err := tx.FindWithTag(s.tag, s.cacheKey(id), rapidash.BytesPtr(&content)); err != nil {
+		if rapidash.IsCacheMiss(err) {
+			return false, nil
+		}
+		return false, xerrors.Errorf("failed to find session: %w", err)
+	}
+	return true, nil
+}
+
+// move moves session value of oldID to newID. It is not error if session of oldID doesn't exist
+func (s *Store) move(oldID, newID string) error {
+	tx, err := s.cache.Begin()
+	if err != nil {
+		return xerrors.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.RollbackUnlessCommitted()
+	}()
+	var content []byte
+	if err := tx.FindWithTag(s.tag, s.cacheKey(oldID), rapidash.BytesPtr(&content)); err != nil {
+		if rapidash.IsCacheMiss(err) {
+			return nil
+		}
+		return xerrors.Errorf("failed to find session: %w", err)
+	}
+	if err := tx.UpdateWithTagAndExpiration(s.tag, s.cacheKey(newID), rapidash.Bytes(content), s.ttl); err != nil {
+		return xerrors.Errorf("failed to save session: %w", err)
+	}
+	if err := tx.DeleteWithTag(s.tag, s.cacheKey(oldID)); err != nil {
+		return xerrors.Errorf("failed to delete old session: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return xerrors.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) setCookie(w http.ResponseWriter, id string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.cookieName,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   int(s.ttl / time.Second),
+		Secure:   s.secureCookie,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// Session is a session bound to id by Middleware
+type Session struct {
+	ID    string
+	store *Store
+	w     http.ResponseWriter
+}
+
+func (s *Session) Get(v interface{}) error {
+	return s.store.Get(s.ID, v)
+}
+
+func (s *Session) Save(v interface{}) error {
+	return s.store.Save(s.ID, v)
+}
+
+func (s *Session) Destroy() error {
+	return s.store.Destroy(s.ID)
+}
+
+// Regenerate moves session value to new id and sends it by cookie.
+// It must be called when privilege of the session changes ( e.g. the user logs in ) to prevent session fixation
+func (s *Session) Regenerate() error {
+	id, err := NewID()
+	if err != nil {
+		return xerrors.Errorf("failed to create session id: %w", err)
+	}
+	if err := s.store.move(s.ID, id); err != nil {
+		return xerrors.Errorf("failed to move session: %w", err)
+	}
+	s.ID = id
+	s.store.setCookie(s.w, id)
+	return nil
+}
+
+type contextKey struct{}
+
+// FromContext returns session set by Middleware
+func FromContext(ctx context.Context) *Session {
+	session, _ := ctx.Value(contextKey{}).(*Session)
+	return session
+}
+
+// sessionID returns session id in cookie if the server issued it, otherwise returns new id
+func (s *Store) sessionID(r *http.Request) (string, error) {
+	if cookie, err := r.Cookie(s.cookieName); err == nil && isValidID(cookie.Value) {
+		exists, err := s.exists(cookie.Value)
+		if err != nil {
+			return "", xerrors.Errorf("failed to find session: %w", err)
+		}
+		if exists {
+			return cookie.Value, nil
+		}
+	}
+	id, err := NewID()
+	if err != nil {
+		return "", xerrors.Errorf("failed to create session id: %w", err)
+	}
+	return id, nil
+}
+
+// Middleware binds session id in cookie to request context.
+// id not issued by the server ( e.g. malformed or expired id ) is replaced by new id
+func (s *Store) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := s.sessionID(r)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		s.setCookie(w, id)
+		ctx := context.WithValue(r.Context(), contextKey{}, &Session{ID: id, store: s, w: w})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
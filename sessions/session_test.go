@@ -0,0 +1,97 @@
+package sessions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/xerrors"
+)
+
+type userSession struct {
+	UserID uint64 `json:"user_id"`
+	Name   string `json:"name"`
+}
+
+func TestEncodeSession(t *testing.T) {
+	t.Run("plain", func(t *testing.T) {
+		store, err := NewStore(nil)
+		NoError(t, err)
+		content, err := store.encode(&userSession{UserID: 1, Name: "alice"})
+		NoError(t, err)
+		Equal(t, string(content), `{"user_id":1,"name":"alice"}`)
+		var v userSession
+		NoError(t, store.decode(content, &v))
+		Equal(t, v, userSession{UserID: 1, Name: "alice"})
+	})
+	t.Run("encrypted", func(t *testing.T) {
+		store, err := NewStore(nil, EncryptionKey([]byte("0123456789abcdef")))
+		NoError(t, err)
+		content, err := store.encode(&userSession{UserID: 1, Name: "alice"})
+		NoError(t, err)
+		var v userSession
+		NoError(t, store.decode(content, &v))
+		Equal(t, v, userSession{UserID: 1, Name: "alice"})
+
+		other, err := NewStore(nil, EncryptionKey([]byte("fedcba9876543210")))
+		NoError(t, err)
+		if err := other.decode(content, &v); !xerrors.Is(err, ErrInvalidSessionValue) {
+			t.Fatalf("expected invalid session value error. but got %+v", err)
+		}
+	})
+	t.Run("invalid key", func(t *testing.T) {
+		if _, err := NewStore(nil, EncryptionKey([]byte("short"))); !xerrors.Is(err, ErrInvalidEncryptionKey) {
+			t.Fatalf("expected invalid encryption key error. but got %+v", err)
+		}
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	store, err := NewStore(nil, CookieName("sid"))
+	NoError(t, err)
+	issued, err := NewID()
+	NoError(t, err)
+	store.exists = func(id string) (bool, error) {
+		return id == issued, nil
+	}
+	var id string
+	handler := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = FromContext(r.Context()).ID
+	}))
+	t.Run("new session", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		Equal(t, len(id), 64)
+		cookie := rec.Result().Cookies()[0]
+		Equal(t, cookie.Value, id)
+		Equal(t, cookie.Secure, true)
+		Equal(t, cookie.HttpOnly, true)
+	})
+	t.Run("existing session", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: "sid", Value: issued})
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		Equal(t, id, issued)
+	})
+	t.Run("unknown session", func(t *testing.T) {
+		unknown, err := NewID()
+		NoError(t, err)
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: "sid", Value: unknown})
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if id == unknown {
+			t.Fatal("unknown session id must be replaced")
+		}
+		Equal(t, rec.Result().Cookies()[0].Value, id)
+	})
+	t.Run("malformed session", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: "sid", Value: "session_id"})
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		Equal(t, len(id), 64)
+		if err := store.Get("session_id", &userSession{}); !xerrors.Is(err, ErrInvalidSessionID) {
+			t.Fatalf("unexpected error: %+v", err)
+		}
+	})
+}
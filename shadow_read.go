@@ -0,0 +1,153 @@
+package rapidash
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+
+	"golang.org/x/xerrors"
+)
+
+// ShadowReadStat is result of comparing values read from cache in background with values read from database
+type ShadowReadStat struct {
+	Table      string
+	Reads      uint64
+	Mismatches uint64
+	CacheRows  uint64
+	DBRows     uint64
+	Errors     uint64
+}
+
+// HitRate returns ratio of rows found by cache in shadow reads
+func (s *ShadowReadStat) HitRate() float64 {
+	total := s.CacheRows + s.DBRows
+	if total == 0 {
+		return 0
+	}
+	return float64(s.CacheRows) / float64(total)
+}
+
+type shadowReadCounter struct {
+	reads      uint64
+	mismatches uint64
+	cacheRows  uint64
+	dbRows     uint64
+	errors     uint64
+}
+
+// ShadowReadStat returns nil if SecondLevelCacheTableShadowRead isn't enabled for the table
+func (c *SecondLevelCache) ShadowReadStat() *ShadowReadStat {
+	if !c.opt.ShadowRead() {
+		return nil
+	}
+	return &ShadowReadStat{
+		Table:      c.typ.tableName,
+		Reads:      atomic.LoadUint64(&c.shadowReads.reads),
+		Mismatches: atomic.LoadUint64(&c.shadowReads.mismatches),
+		CacheRows:  atomic.LoadUint64(&c.shadowReads.cacheRows),
+		DBRows:     atomic.LoadUint64(&c.shadowReads.dbRows),
+		Errors:     atomic.LoadUint64(&c.shadowReads.errors),
+	}
+}
+
+// ShadowReadStats returns ShadowReadStat of tables enabled SecondLevelCacheTableShadowRead
+func (r *Rapidash) ShadowReadStats() []*ShadowReadStat {
+	stats := []*ShadowReadStat{}
+	r.secondLevelCaches.Range(func(key, value interface{}) bool {
+		if stat := value.(*SecondLevelCache).ShadowReadStat(); stat != nil {
+			stats = append(stats, stat)
+		}
+		return true
+	})
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Table < stats[j].Table
+	})
+	return stats
+}
+
+// isShadowRead returns true if builder should be read from database and compared with cache in background
+func (c *SecondLevelCache) isShadowRead(tx *Tx, builder *QueryBuilder) bool {
+	if !c.opt.ShadowRead() || c.cacheOnly || c.db == nil {
+		return false
+	}
+	if builder.isIgnoreCache || builder.lockOpt != nil || builder.sqlCondition != nil {
+		return false
+	}
+	// values written in the transaction aren't visible from other connection
+	return len(tx.pendingQueries) == 0
+}
+
+// shadowRead reads values of builder by cache in background and compares them with dbValues.
+// builder is released after reading
+func (c *SecondLevelCache) shadowRead(tx *Tx, builder *QueryBuilder, dbValues *StructSliceValue) {
+	expected := encodeShadowValues(dbValues)
+	go func() {
+		defer builder.Release()
+		ctx, cancel := context.WithTimeout(context.Background(), backgroundTimeout)
+		defer cancel()
+		if err := c.compareShadowRead(ctx, tx.r, builder, expected); err != nil {
+			atomic.AddUint64(&c.shadowReads.errors, 1)
+			log.Warn(fmt.Sprintf("failed to shadow read %s: %+v", c.typ.tableName, err))
+		}
+	}()
+}
+
+func (c *SecondLevelCache) compareShadowRead(ctx context.Context, r *Rapidash, builder *QueryBuilder, expected []string) (e error) {
+	tx, err := r.BeginWithOptionContext(ctx, TxOption{ReadOnly: true}, c.db)
+	if err != nil {
+		return xerrors.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.RollbackUnlessCommitted(); err != nil {
+			e = xerrors.Errorf("failed to rollback: %w", err)
+		}
+	}()
+	info := &QueryInfo{}
+	builder.WithInfo(info)
+	values, err := c.findValuesByQueryBuilder(ctx, tx, builder)
+	if err != nil {
+		return xerrors.Errorf("failed to find values by query builder: %w", err)
+	}
+	if err := tx.CommitContext(ctx); err != nil {
+		return xerrors.Errorf("failed to commit: %w", err)
+	}
+	atomic.AddUint64(&c.shadowReads.reads, 1)
+	atomic.AddUint64(&c.shadowReads.cacheRows, uint64(info.CacheRows))
+	atomic.AddUint64(&c.shadowReads.dbRows, uint64(info.DBRows))
+	found := encodeShadowValues(values)
+	if !equalShadowValues(expected, found) {
+		atomic.AddUint64(&c.shadowReads.mismatches, 1)
+		log.Warn(fmt.Sprintf("shadow read mismatch [select * from %s where %s]: database %v cache %v",
+			c.typ.tableName, builder.Query(), expected, found))
+	}
+	return nil
+}
+
+// encodeShadowValues returns sorted logs of values, because order of values found by cache may differ from database
+func encodeShadowValues(values *StructSliceValue) []string {
+	if values == nil {
+		return []string{}
+	}
+	encoded := make([]string, 0, values.Len())
+	for _, value := range values.values {
+		if value == nil {
+			continue
+		}
+		encoded = append(encoded, value.EncodeLog())
+	}
+	sort.Strings(encoded)
+	return encoded
+}
+
+func equalShadowValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+package rapidash
+
+import (
+	"database/sql"
+	"sync/atomic"
+	"testing"
+)
+
+func TestShadowRead(t *testing.T) {
+	r, err := New(SecondLevelCacheTableShadowRead("user_logins", true))
+	NoError(t, err)
+	slc := NewSecondLevelCache(userLoginType(), nil, r.tableOption("user_logins"))
+	tx, err := r.Begin()
+	NoError(t, err)
+	t.Run("database is required", func(t *testing.T) {
+		Equal(t, slc.isShadowRead(tx, NewQueryBuilder("user_logins").Eq("id", uint64(1))), false)
+	})
+	slc.db = &sql.DB{}
+	t.Run("shadow read query", func(t *testing.T) {
+		Equal(t, slc.isShadowRead(tx, NewQueryBuilder("user_logins").Eq("id", uint64(1))), true)
+		Equal(t, slc.isShadowRead(tx, NewQueryBuilder("user_logins").Eq("id", uint64(1)).ForUpdate()), false)
+		Equal(t, slc.isShadowRead(tx, NewQueryBuilder("user_logins").SQL("id = ?", 1)), false)
+	})
+	t.Run("disabled table", func(t *testing.T) {
+		other := NewSecondLevelCache(userLoginType(), nil, r.tableOption("users"))
+		other.db = &sql.DB{}
+		Equal(t, other.isShadowRead(tx, NewQueryBuilder("users").Eq("id", uint64(1))), false)
+		if other.ShadowReadStat() != nil {
+			t.Fatal("stat of disabled table must be nil")
+		}
+	})
+	t.Run("stat", func(t *testing.T) {
+		atomic.AddUint64(&slc.shadowReads.reads, 2)
+		atomic.AddUint64(&slc.shadowReads.cacheRows, 3)
+		atomic.AddUint64(&slc.shadowReads.dbRows, 1)
+		stat := slc.ShadowReadStat()
+		Equal(t, stat.Table, "user_logins")
+		Equal(t, stat.Reads, uint64(2))
+		Equal(t, stat.HitRate(), 0.75)
+		Equal(t, (&ShadowReadStat{}).HitRate(), float64(0))
+	})
+}
+
+func TestQueryBuilderClone(t *testing.T) {
+	builder := NewQueryBuilder("user_logins").
+		Eq("user_id", uint64(1)).
+		In("login_param_id", []uint64{1, 2}).
+		Gte("id", uint64(10)).
+		OrderDesc("id")
+	cloned := builder.clone()
+	if cloned == nil {
+		t.Fatal("failed to clone builder")
+	}
+	Equal(t, cloned.Query(), builder.Query())
+	Equal(t, cloned.conditions.Len(), builder.conditions.Len())
+	if cloned.inCondition == nil || cloned.inCondition == builder.inCondition {
+		t.Fatal("in condition must be copied")
+	}
+	Equal(t, len(cloned.orderConditions), 1)
+	if NewQueryBuilder("user_logins").SQL("id = ?", 1).clone() != nil {
+		t.Fatal("builder with raw sql must not be cloned")
+	}
+}
+
+func TestCompareShadowValues(t *testing.T) {
+	Equal(t, len(encodeShadowValues(nil)), 0)
+	Equal(t, equalShadowValues([]string{"a", "b"}, []string{"a", "b"}), true)
+	Equal(t, equalShadowValues([]string{"a", "b"}, []string{"a"}), false)
+	Equal(t, equalShadowValues([]string{"a", "b"}, []string{"a", "c"}), false)
+}
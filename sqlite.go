@@ -0,0 +1,192 @@
+package rapidash
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// There is no SHOW CREATE TABLE in SQLite, so WarmUp builds MySQL compatible DDL from pragma functions.
+// Queries built by rapidash ( backquoted identifiers and ? placeholders ) are accepted by SQLite as they are,
+// but locking reads ( FOR UPDATE ) aren't supported
+
+type sqliteColumn struct {
+	name       string
+	typ        string
+	notNull    bool
+	primaryKey int
+}
+
+type sqliteIndex struct {
+	name    string
+	unique  bool
+	origin  string
+	columns []string
+}
+
+// isSQLite reports whether conn is opened by SQLite driver ( e.g. mattn/go-sqlite3, modernc.org/sqlite )
+func isSQLite(conn *sql.DB) bool {
+	typ := reflect.TypeOf(conn.Driver())
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return strings.Contains(strings.ToLower(typ.PkgPath()+"."+typ.Name()), "sqlite")
+}
+
+func showCreateTableSQLite(conn *sql.DB, tableName string) (string, error) {
+	columns, err := sqliteColumns(conn, tableName)
+	if err != nil {
+		return "", xerrors.Errorf("failed to get columns of %s: %w", tableName, err)
+	}
+	if len(columns) == 0 {
+		return "", xerrors.Errorf("%s is not found in sqlite_master: %w", tableName, sql.ErrNoRows)
+	}
+	indexes, err := sqliteIndexes(conn, tableName)
+	if err != nil {
+		return "", xerrors.Errorf("failed to get indexes of %s: %w", tableName, err)
+	}
+	return sqliteTableDDL(tableName, columns, indexes), nil
+}
+
+func sqliteColumns(conn *sql.DB, tableName string) ([]*sqliteColumn, error) {
+	rows, err := conn.Query("SELECT name, type, \"notnull\", pk FROM pragma_table_info(?) ORDER BY cid", tableName)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to query pragma_table_info: %w", err)
+	}
+	defer rows.Close()
+	columns := []*sqliteColumn{}
+	for rows.Next() {
+		var column sqliteColumn
+		if err := rows.Scan(&column.name, &column.typ, &column.notNull, &column.primaryKey); err != nil {
+			return nil, xerrors.Errorf("failed to scan pragma_table_info: %w", err)
+		}
+		columns = append(columns, &column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, xerrors.Errorf("failed to read pragma_table_info: %w", err)
+	}
+	return columns, nil
+}
+
+func sqliteIndexes(conn *sql.DB, tableName string) ([]*sqliteIndex, error) {
+	rows, err := conn.Query("SELECT name, \"unique\", origin FROM pragma_index_list(?) ORDER BY seq", tableName)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to query pragma_index_list: %w", err)
+	}
+	indexes := []*sqliteIndex{}
+	for rows.Next() {
+		var index sqliteIndex
+		if err := rows.Scan(&index.name, &index.unique, &index.origin); err != nil {
+			rows.Close()
+			return nil, xerrors.Errorf("failed to scan pragma_index_list: %w", err)
+		}
+		indexes = append(indexes, &index)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, xerrors.Errorf("failed to read pragma_index_list: %w", err)
+	}
+	for _, index := range indexes {
+		columns, err := sqliteIndexColumns(conn, index.name)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to get columns of index %s: %w", index.name, err)
+		}
+		index.columns = columns
+	}
+	return indexes, nil
+}
+
+func sqliteIndexColumns(conn *sql.DB, indexName string) ([]string, error) {
+	rows, err := conn.Query("SELECT name FROM pragma_index_info(?) ORDER BY seqno", indexName)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to query pragma_index_info: %w", err)
+	}
+	defer rows.Close()
+	columns := []string{}
+	for rows.Next() {
+		var column sql.NullString
+		if err := rows.Scan(&column); err != nil {
+			return nil, xerrors.Errorf("failed to scan pragma_index_info: %w", err)
+		}
+		if !column.Valid {
+			// index on expression cannot be used for cache
+			return nil, nil
+		}
+		columns = append(columns, column.String)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, xerrors.Errorf("failed to read pragma_index_info: %w", err)
+	}
+	return columns, nil
+}
+
+// sqliteColumnType keeps declared type if MySQL has the same type, otherwise returns type of its affinity
+func sqliteColumnType(declared string) string {
+	typ := strings.ToLower(strings.TrimSpace(declared))
+	dataType := typ
+	if idx := strings.IndexAny(dataType, "( "); idx >= 0 {
+		dataType = dataType[:idx]
+	}
+	if _, exists := intColumnSizes[dataType]; exists {
+		return typ
+	}
+	for _, types := range []map[string]struct{}{stringDataTypes, bytesDataTypes, timeDataTypes} {
+		if _, exists := types[dataType]; exists {
+			return typ
+		}
+	}
+	switch dataType {
+	case "float", "double":
+		return typ
+	}
+	// https://www.sqlite.org/datatype3.html#determination_of_column_affinity
+	switch {
+	case strings.Contains(typ, "int") && strings.Contains(typ, "unsigned"):
+		return "bigint unsigned"
+	case strings.Contains(typ, "int"):
+		return "bigint"
+	case strings.Contains(typ, "char"), strings.Contains(typ, "clob"), strings.Contains(typ, "text"):
+		return "text"
+	case typ == "", strings.Contains(typ, "blob"):
+		return "blob"
+	case strings.Contains(typ, "real"), strings.Contains(typ, "floa"), strings.Contains(typ, "doub"):
+		return "double"
+	}
+	return "decimal"
+}
+
+func sqliteTableDDL(tableName string, columns []*sqliteColumn, indexes []*sqliteIndex) string {
+	defs := []string{}
+	primaryKeys := make([]string, len(columns))
+	for _, column := range columns {
+		def := fmt.Sprintf("`%s` %s", column.name, sqliteColumnType(column.typ))
+		if column.notNull || column.primaryKey > 0 {
+			def += " NOT NULL"
+		}
+		defs = append(defs, def)
+		if column.primaryKey > 0 {
+			primaryKeys[column.primaryKey-1] = fmt.Sprintf("`%s`", column.name)
+		}
+	}
+	if primaryKey := strings.Join(primaryKeys, ","); strings.Trim(primaryKey, ",") != "" {
+		defs = append(defs, fmt.Sprintf("PRIMARY KEY (%s)", strings.Trim(primaryKey, ",")))
+	}
+	for _, index := range indexes {
+		if index.origin == "pk" || len(index.columns) == 0 {
+			continue
+		}
+		keys := make([]string, 0, len(index.columns))
+		for _, column := range index.columns {
+			keys = append(keys, fmt.Sprintf("`%s`", column))
+		}
+		keyType := "KEY"
+		if index.unique {
+			keyType = "UNIQUE KEY"
+		}
+		defs = append(defs, fmt.Sprintf("%s `%s` (%s)", keyType, index.name, strings.Join(keys, ",")))
+	}
+	return fmt.Sprintf("CREATE TABLE `%s` (\n  %s\n)", tableName, strings.Join(defs, ",\n  "))
+}
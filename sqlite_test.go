@@ -0,0 +1,43 @@
+package rapidash
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestSQLiteTableDDL(t *testing.T) {
+	ddl := sqliteTableDDL("user_logins", []*sqliteColumn{
+		{name: "id", typ: "INTEGER", primaryKey: 1},
+		{name: "user_id", typ: "bigint unsigned", notNull: true},
+		{name: "user_session_id", typ: "UNSIGNED BIG INT", notNull: true},
+		{name: "login_param_id", typ: "INT", notNull: true},
+		{name: "name", typ: "VARCHAR(255)"},
+		{name: "created_at", typ: "DATETIME", notNull: true},
+		{name: "updated_at", typ: ""},
+	}, []*sqliteIndex{
+		{name: "sqlite_autoindex_user_logins_1", unique: true, origin: "u", columns: []string{"user_id", "user_session_id"}},
+		{name: "idx_login_param", origin: "c", columns: []string{"login_param_id"}},
+		{name: "idx_expression", origin: "c"},
+	})
+	slc := NewSecondLevelCache(userLoginType(), nil, TableOption{})
+	NoError(t, slc.setupIndexes(ddl))
+	names := []string{}
+	for name := range slc.indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	Equal(t, names, []string{"id", "login_param_id", "user_id", "user_id:user_session_id"})
+	Equal(t, slc.indexes["user_id:user_session_id"].Type, IndexTypeUniqueKey)
+
+	columns, err := columnSchemasFromDDL(ddl)
+	NoError(t, err)
+	types := map[string]string{}
+	for _, column := range columns {
+		types[column.name] = column.columnType
+	}
+	Equal(t, types["user_id"], "bigint(20) unsigned")
+	Equal(t, types["user_session_id"], "bigint(20) unsigned")
+	Equal(t, types["login_param_id"], "int(11)")
+	Equal(t, types["name"], "varchar(255)")
+	Equal(t, types["updated_at"], "blob binary")
+}
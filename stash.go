@@ -0,0 +1,196 @@
+package rapidash
+
+import (
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+type StashOverflowPolicyType int
+
+const (
+	// StashOverflowPolicyError returns ErrStashSizeExceeded when stash exceeds max size ( default )
+	StashOverflowPolicyError StashOverflowPolicyType = iota
+	// StashOverflowPolicyEvict evicts values that aren't modified by transaction when stash exceeds max size.
+	// ErrStashSizeExceeded is still returned if stash exceeds max size after eviction
+	StashOverflowPolicyEvict
+)
+
+const (
+	// approximate memory usage for map entry and header of Value/StructValue
+	stashEntryOverhead = 48
+	valueOverhead      = 64
+)
+
+type StashStats struct {
+	// approximate bytes of stashed values
+	Size                 int
+	MaxSize              int
+	Values               int
+	UniqueKeys           int
+	Keys                 int
+	LastLevelCacheValues int
+	Evicted              int
+}
+
+func (v *Value) approximateSize() int {
+	if v == nil {
+		return 0
+	}
+	size := valueOverhead + len(v.stringValue) + len(v.bytesValue)
+	for _, value := range v.sliceValue {
+		size += value.approximateSize()
+	}
+	if v.structValue != nil {
+		size += v.structValue.approximateSize()
+	}
+	return size
+}
+
+func (v *StructValue) approximateSize() int {
+	if v == nil {
+		return 0
+	}
+	size := valueOverhead
+	for column, value := range v.fields {
+		size += len(column) + value.approximateSize()
+	}
+	return size
+}
+
+func cacheKeySize(key server.CacheKey) int {
+	if key == nil {
+		return 0
+	}
+	return stashEntryOverhead + len(key.String())
+}
+
+func cacheKeysSize(keys []server.CacheKey) int {
+	size := 0
+	for _, key := range keys {
+		size += cacheKeySize(key)
+	}
+	return size
+}
+
+// reserveStash applies overflow policy before stash grows by delta bytes
+func (tx *Tx) reserveStash(delta int) error {
+	maxSize := tx.r.opt.maxStashSize
+	if maxSize <= 0 || delta <= 0 || tx.stash.size+delta <= maxSize {
+		return nil
+	}
+	if tx.r.opt.stashOverflowPolicy == StashOverflowPolicyEvict {
+		tx.evictStash()
+		if tx.stash.size+delta <= maxSize {
+			return nil
+		}
+	}
+	return xerrors.Errorf("stash size %d bytes exceeds %d bytes: %w", tx.stash.size+delta, maxSize, ErrStashSizeExceeded)
+}
+
+// evictStash removes values read from cache server. values modified by transaction must be kept
+func (tx *Tx) evictStash() {
+	for key := range tx.stash.primaryKeyToValue {
+		if tx.isModifiedKey(key) {
+			continue
+		}
+		tx.unstashValue(key)
+		tx.stash.evicted++
+	}
+	for key, primaryKey := range tx.stash.uniqueKeyToPrimaryKey {
+		if tx.isModifiedKey(key) {
+			continue
+		}
+		tx.stash.size -= stashEntryOverhead + len(key) + cacheKeySize(primaryKey)
+		tx.stash.evicted++
+		delete(tx.stash.uniqueKeyToPrimaryKey, key)
+	}
+	for key, primaryKeys := range tx.stash.keyToPrimaryKeys {
+		if tx.isModifiedKey(key) {
+			continue
+		}
+		tx.stash.size -= stashEntryOverhead + len(key) + cacheKeysSize(primaryKeys)
+		tx.stash.evicted++
+		delete(tx.stash.keyToPrimaryKeys, key)
+	}
+}
+
+// stashValue stashes value and recomputes its size.
+// Size accounted at last stash is used for old value because value may be updated in place after stashed
+func (tx *Tx) stashValue(key string, value *StructValue) error {
+	size := stashEntryOverhead + len(key) + value.approximateSize()
+	delta := size - tx.stash.valueSizes[key]
+	if err := tx.reserveStash(delta); err != nil {
+		return xerrors.Errorf("failed to reserve stash for %s: %w", key, err)
+	}
+	tx.stash.primaryKeyToValue[key] = value
+	tx.stash.valueSizes[key] = size
+	tx.stash.size += delta
+	return nil
+}
+
+func (tx *Tx) unstashValue(key string) {
+	tx.stash.size -= tx.stash.valueSizes[key]
+	delete(tx.stash.valueSizes, key)
+	delete(tx.stash.primaryKeyToValue, key)
+}
+
+func (tx *Tx) stashPrimaryKey(key string, primaryKey server.CacheKey) error {
+	delta := stashEntryOverhead + len(key) + cacheKeySize(primaryKey)
+	if old, exists := tx.stash.uniqueKeyToPrimaryKey[key]; exists {
+		delta -= stashEntryOverhead + len(key) + cacheKeySize(old)
+	}
+	if err := tx.reserveStash(delta); err != nil {
+		return xerrors.Errorf("failed to reserve stash for %s: %w", key, err)
+	}
+	tx.stash.uniqueKeyToPrimaryKey[key] = primaryKey
+	tx.stash.size += delta
+	return nil
+}
+
+func (tx *Tx) stashPrimaryKeys(key string, primaryKeys []server.CacheKey) error {
+	delta := stashEntryOverhead + len(key) + cacheKeysSize(primaryKeys)
+	if old, exists := tx.stash.keyToPrimaryKeys[key]; exists {
+		delta -= stashEntryOverhead + len(key) + cacheKeysSize(old)
+	}
+	if err := tx.reserveStash(delta); err != nil {
+		return xerrors.Errorf("failed to reserve stash for %s: %w", key, err)
+	}
+	tx.stash.keyToPrimaryKeys[key] = primaryKeys
+	tx.stash.size += delta
+	return nil
+}
+
+func (tx *Tx) stashBytes(key string, content []byte) error {
+	delta := stashEntryOverhead + len(key) + len(content)
+	if old, exists := tx.stash.lastLevelCacheKeyToBytes[key]; exists {
+		delta -= stashEntryOverhead + len(key) + len(old)
+	}
+	if err := tx.reserveStash(delta); err != nil {
+		return xerrors.Errorf("failed to reserve stash for %s: %w", key, err)
+	}
+	tx.stash.lastLevelCacheKeyToBytes[key] = content
+	tx.stash.size += delta
+	return nil
+}
+
+func (tx *Tx) unstashBytes(key string) {
+	old, exists := tx.stash.lastLevelCacheKeyToBytes[key]
+	if !exists {
+		return
+	}
+	tx.stash.size -= stashEntryOverhead + len(key) + len(old)
+	delete(tx.stash.lastLevelCacheKeyToBytes, key)
+}
+
+// StashStats returns approximate memory usage of values stashed by transaction
+func (tx *Tx) StashStats() StashStats {
+	return StashStats{
+		Size:                 tx.stash.size,
+		MaxSize:              tx.r.opt.maxStashSize,
+		Values:               len(tx.stash.primaryKeyToValue),
+		UniqueKeys:           len(tx.stash.uniqueKeyToPrimaryKey),
+		Keys:                 len(tx.stash.keyToPrimaryKeys),
+		LastLevelCacheValues: len(tx.stash.lastLevelCacheKeyToBytes),
+		Evicted:              tx.stash.evicted,
+	}
+}
@@ -0,0 +1,60 @@
+package rapidash
+
+import (
+	"testing"
+
+	"golang.org/x/xerrors"
+)
+
+func TestStashSize(t *testing.T) {
+	newValue := func(name string) *StructValue {
+		return &StructValue{fields: map[string]*Value{"name": NewStringValue(name)}}
+	}
+	t.Run("error policy", func(t *testing.T) {
+		r, err := New(MaxStashSize(500))
+		NoError(t, err)
+		tx, err := r.Begin()
+		NoError(t, err)
+		NoError(t, tx.stashValue("r/slc/users/id#1", newValue("rapidash")))
+		size := tx.StashStats().Size
+		if size == 0 {
+			t.Fatal("stash size must be accounted")
+		}
+		NoError(t, tx.stashValue("r/slc/users/id#1", newValue("rapidash")))
+		Equal(t, tx.StashStats().Size, size)
+
+		value := tx.stash.primaryKeyToValue["r/slc/users/id#1"]
+		value.fields["name"] = NewStringValue("rapidash_updated")
+		NoError(t, tx.stashValue("r/slc/users/id#1", value))
+		Equal(t, tx.StashStats().Size, size+len("_updated"))
+		value.fields["name"] = NewStringValue("rapidash")
+		NoError(t, tx.stashValue("r/slc/users/id#1", value))
+		Equal(t, tx.StashStats().Size, size)
+		if err := tx.stashBytes("r/llc/key", make([]byte, 500)); !xerrors.Is(err, ErrStashSizeExceeded) {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		Equal(t, tx.StashStats().LastLevelCacheValues, 0)
+		tx.releaseValues()
+		Equal(t, tx.StashStats().Size, 0)
+	})
+	t.Run("evict policy", func(t *testing.T) {
+		r, err := New(MaxStashSize(500), StashOverflowPolicy(StashOverflowPolicyEvict))
+		NoError(t, err)
+		tx, err := r.Begin()
+		NoError(t, err)
+		tx.pendingQueries["r/slc/users/id#2"] = &PendingQuery{QueryLog: &QueryLog{Command: string(SLCCommandUpdate)}}
+		NoError(t, tx.stashValue("r/slc/users/id#1", newValue("read")))
+		NoError(t, tx.stashValue("r/slc/users/id#2", newValue("modified")))
+		NoError(t, tx.stashBytes("r/llc/key", make([]byte, 200)))
+		stats := tx.StashStats()
+		Equal(t, stats.Values, 1)
+		Equal(t, stats.Evicted, 1)
+		if _, exists := tx.stash.primaryKeyToValue["r/slc/users/id#2"]; !exists {
+			t.Fatal("modified value must not be evicted")
+		}
+		if err := tx.stashBytes("r/llc/other_key", make([]byte, 200)); !xerrors.Is(err, ErrStashSizeExceeded) {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		Equal(t, tx.StashStats().LastLevelCacheValues, 1)
+	})
+}
@@ -0,0 +1,129 @@
+package rapidash
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.knocknote.io/rapidash/server"
+)
+
+// StatsCollector receives events of SecondLevelCache and cache server to build metrics.
+// Methods are called synchronously from transactions, so implementation should be cheap and goroutine safe
+type StatsCollector interface {
+	// OnCacheHit is called for each query found in cache. index is columns of index joined by ':',
+	// or cluster and count for keys of ClusterKey and CountCache
+	OnCacheHit(table, index string)
+	// OnCacheMiss is called for each query not found in cache
+	OnCacheMiss(table, index string)
+	// OnDBQuery is called after query or execution of statement to the database
+	OnDBQuery(table string, duration time.Duration)
+	// OnCacheServerOp is called after each operation to cache server. op is get, get_multi, set, add, delete, incr or flush.
+	// err of get for missing key is server.ErrCacheMiss
+	OnCacheServerOp(op string, duration time.Duration, err error)
+}
+
+const (
+	clusterKeyIndex = "cluster"
+	countKeyIndex   = "count"
+)
+
+func (c *SecondLevelCache) observeQueries(queries *Queries) {
+	if c.opt.stats == nil {
+		return
+	}
+	misses := map[*Query]struct{}{}
+	for _, query := range queries.cacheMissQueries {
+		misses[query] = struct{}{}
+	}
+	for _, query := range queries.queries {
+		index := strings.Join(query.columns, ":")
+		if _, exists := misses[query]; exists {
+			c.opt.stats.OnCacheMiss(c.typ.tableName, index)
+		} else {
+			c.opt.stats.OnCacheHit(c.typ.tableName, index)
+		}
+	}
+}
+
+func (c *SecondLevelCache) observeClusterKey(hit bool) {
+	if c.opt.stats == nil {
+		return
+	}
+	if hit {
+		c.opt.stats.OnCacheHit(c.typ.tableName, clusterKeyIndex)
+	} else {
+		c.opt.stats.OnCacheMiss(c.typ.tableName, clusterKeyIndex)
+	}
+}
+
+func (c *SecondLevelCache) observeDBQuery(start time.Time) {
+	if c.opt.stats != nil {
+		c.opt.stats.OnDBQuery(c.typ.tableName, time.Since(start))
+	}
+}
+
+type statsCacheServer struct {
+	server.CacheServer
+	stats StatsCollector
+}
+
+func (r *Rapidash) withStats(s server.CacheServer) server.CacheServer {
+	if r.opt.stats == nil {
+		return s
+	}
+	return &statsCacheServer{CacheServer: s, stats: r.opt.stats}
+}
+
+func (s *statsCacheServer) observe(op string, start time.Time, err error) {
+	s.stats.OnCacheServerOp(op, time.Since(start), err)
+}
+
+func (s *statsCacheServer) Get(ctx context.Context, key server.CacheKey) (*server.CacheGetResponse, error) {
+	start := time.Now()
+	res, err := s.CacheServer.Get(ctx, key)
+	s.observe("get", start, err)
+	return res, err
+}
+
+func (s *statsCacheServer) GetMulti(ctx context.Context, keys []server.CacheKey) (*server.Iterator, error) {
+	start := time.Now()
+	iter, err := s.CacheServer.GetMulti(ctx, keys)
+	s.observe("get_multi", start, err)
+	return iter, err
+}
+
+func (s *statsCacheServer) Set(ctx context.Context, req *server.CacheStoreRequest) error {
+	start := time.Now()
+	err := s.CacheServer.Set(ctx, req)
+	s.observe("set", start, err)
+	return err
+}
+
+func (s *statsCacheServer) Add(ctx context.Context, key server.CacheKey, value []byte, expiration time.Duration) error {
+	start := time.Now()
+	err := s.CacheServer.Add(ctx, key, value, expiration)
+	s.observe("add", start, err)
+	return err
+}
+
+func (s *statsCacheServer) Delete(ctx context.Context, key server.CacheKey) error {
+	start := time.Now()
+	err := s.CacheServer.Delete(ctx, key)
+	s.observe("delete", start, err)
+	return err
+}
+
+func (s *statsCacheServer) Incr(ctx context.Context, key server.CacheKey, delta uint64, expiration time.Duration) (uint64, error) {
+	start := time.Now()
+	value, err := server.Incr(ctx, s.CacheServer, key, delta, expiration)
+	s.observe("incr", start, err)
+	return value, err
+}
+
+func (s *statsCacheServer) Flush(ctx context.Context) error {
+	start := time.Now()
+	err := s.CacheServer.Flush(ctx)
+	s.observe("flush", start, err)
+	return err
+}
@@ -0,0 +1,100 @@
+package rapidash
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.knocknote.io/rapidash/server"
+	"golang.org/x/xerrors"
+)
+
+type testStatsCollector struct {
+	mu      sync.Mutex
+	hits    map[string]int
+	misses  map[string]int
+	queries map[string]int
+	ops     []string
+	errs    []error
+}
+
+func newTestStatsCollector() *testStatsCollector {
+	return &testStatsCollector{
+		hits:    map[string]int{},
+		misses:  map[string]int{},
+		queries: map[string]int{},
+	}
+}
+
+func (c *testStatsCollector) OnCacheHit(table, index string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hits[table+"/"+index]++
+}
+
+func (c *testStatsCollector) OnCacheMiss(table, index string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.misses[table+"/"+index]++
+}
+
+func (c *testStatsCollector) OnDBQuery(table string, duration time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queries[table]++
+}
+
+func (c *testStatsCollector) OnCacheServerOp(op string, duration time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ops = append(c.ops, op)
+	c.errs = append(c.errs, err)
+}
+
+func TestStats(t *testing.T) {
+	collector := newTestStatsCollector()
+	r, err := New(Stats(collector))
+	NoError(t, err)
+	slc := NewSecondLevelCache(userLoginType(), nil, r.tableOption("user_logins"))
+	t.Run("hit and miss of queries", func(t *testing.T) {
+		hit := &Query{columns: []string{"user_id", "user_session_id"}}
+		miss := &Query{columns: []string{"id"}}
+		slc.observeQueries(&Queries{
+			queries:          []*Query{hit, miss},
+			cacheMissQueries: []*Query{miss},
+		})
+		Equal(t, collector.hits["user_logins/user_id:user_session_id"], 1)
+		Equal(t, collector.misses["user_logins/id"], 1)
+	})
+	t.Run("cluster key", func(t *testing.T) {
+		slc.observeClusterKey(true)
+		slc.observeClusterKey(false)
+		Equal(t, collector.hits["user_logins/cluster"], 1)
+		Equal(t, collector.misses["user_logins/cluster"], 1)
+	})
+	t.Run("db query", func(t *testing.T) {
+		slc.observeDBQuery(time.Now())
+		Equal(t, collector.queries["user_logins"], 1)
+	})
+	t.Run("cache server", func(t *testing.T) {
+		s := r.withStats(newMemoryCacheServer())
+		key := server.StringCacheKey("stats_test")
+		NoError(t, s.Set(context.Background(), &server.CacheStoreRequest{Key: key, Value: []byte("v")}))
+		_, err := s.Get(context.Background(), key)
+		NoError(t, err)
+		NoError(t, s.Delete(context.Background(), key))
+		_, err = s.Get(context.Background(), key)
+		Equal(t, xerrors.Is(err, server.ErrCacheMiss), true)
+		Equal(t, collector.ops, []string{"set", "get", "delete", "get"})
+		Equal(t, xerrors.Is(collector.errs[3], server.ErrCacheMiss), true)
+	})
+	t.Run("disabled", func(t *testing.T) {
+		r, err := New()
+		NoError(t, err)
+		s := newMemoryCacheServer()
+		if r.withStats(s) != server.CacheServer(s) {
+			t.Fatal("cache server must not be wrapped without collector")
+		}
+	})
+}
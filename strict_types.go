@@ -0,0 +1,59 @@
+package rapidash
+
+import (
+	"strings"
+
+	"github.com/blastrain/vitess-sqlparser/sqlparser"
+	"golang.org/x/xerrors"
+)
+
+// columnSchemasFromDDL builds column schemas from column definitions of CREATE TABLE statement
+func columnSchemasFromDDL(ddl string) ([]*columnSchema, error) {
+	stmt, err := sqlparser.Parse(ddl)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot parse ddl %s: %w", ddl, err)
+	}
+	createTable, ok := stmt.(*sqlparser.CreateTable)
+	if !ok {
+		return nil, xerrors.Errorf("%s is not CREATE TABLE statement", ddl)
+	}
+	columns := make([]*columnSchema, 0, len(createTable.Columns))
+	for _, column := range createTable.Columns {
+		columnType := strings.ToLower(column.Type)
+		dataType := columnType
+		if idx := strings.IndexAny(dataType, "( "); idx >= 0 {
+			dataType = dataType[:idx]
+		}
+		columns = append(columns, &columnSchema{
+			name:       column.Name,
+			dataType:   dataType,
+			columnType: columnType,
+		})
+	}
+	return columns, nil
+}
+
+// strictTypeMismatches compares every Struct field with column definitions of ddl
+func (c *SecondLevelCache) strictTypeMismatches(ddl string) ([]*SchemaMismatch, error) {
+	columns, err := columnSchemasFromDDL(ddl)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get columns: %w", err)
+	}
+	return fieldMismatches(c.typ, columns), nil
+}
+
+// validateStrictTypes returns error reporting all mismatched fields instead of failing at scanning values
+func (c *SecondLevelCache) validateStrictTypes(ddl string) error {
+	mismatches, err := c.strictTypeMismatches(ddl)
+	if err != nil {
+		return xerrors.Errorf("failed to compare types: %w", err)
+	}
+	if len(mismatches) == 0 {
+		return nil
+	}
+	reports := make([]string, 0, len(mismatches))
+	for _, mismatch := range mismatches {
+		reports = append(reports, mismatch.String())
+	}
+	return xerrors.Errorf("%s: %w", strings.Join(reports, ", "), ErrInvalidColumnType)
+}
@@ -0,0 +1,74 @@
+package rapidash
+
+import (
+	"testing"
+
+	"golang.org/x/xerrors"
+)
+
+func TestStrictTypes(t *testing.T) {
+	ddl := func(userIDType string) string {
+		return "CREATE TABLE `user_logins` (" +
+			"`id` bigint(20) unsigned NOT NULL AUTO_INCREMENT," +
+			"`user_id` " + userIDType + " NOT NULL," +
+			"`user_session_id` bigint(20) unsigned NOT NULL," +
+			"`login_param_id` bigint(20) unsigned NOT NULL," +
+			"`name` varchar(255) NOT NULL," +
+			"`created_at` datetime NOT NULL," +
+			"`updated_at` datetime NOT NULL," +
+			"PRIMARY KEY (`id`)" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"
+	}
+	r, err := New(SecondLevelCacheTableStrictTypes("user_logins", true))
+	NoError(t, err)
+	opt := r.tableOption("user_logins")
+	Equal(t, opt.StrictTypes(), true)
+	slc := NewSecondLevelCache(userLoginType(), newMemoryCacheServer(), opt)
+
+	t.Run("match", func(t *testing.T) {
+		NoError(t, slc.validateStrictTypes(ddl("bigint(20) unsigned")))
+	})
+	t.Run("mismatch", func(t *testing.T) {
+		mismatches, err := slc.strictTypeMismatches(ddl("varchar(64)"))
+		NoError(t, err)
+		Equal(t, len(mismatches), 1)
+		Equal(t, mismatches[0].Column, "user_id")
+		Equal(t, mismatches[0].Expected, "uint64")
+		Equal(t, mismatches[0].Actual, "varchar(64)")
+		err = slc.validateStrictTypes(ddl("varchar(64)"))
+		Error(t, err)
+		Equal(t, xerrors.Is(err, ErrInvalidColumnType), true)
+	})
+	t.Run("missing column", func(t *testing.T) {
+		slc := NewSecondLevelCache(userLoginType().FieldString("password"), newMemoryCacheServer(), opt)
+		mismatches, err := slc.strictTypeMismatches(ddl("bigint(20) unsigned"))
+		NoError(t, err)
+		Equal(t, len(mismatches), 1)
+		Equal(t, mismatches[0].Type, SchemaMismatchMissingColumn)
+	})
+	t.Run("unsigned column into wider signed field", func(t *testing.T) {
+		slc := NewSecondLevelCache(NewStruct("user_logins").FieldInt64("user_id"), newMemoryCacheServer(), opt)
+		mismatches, err := slc.strictTypeMismatches(ddl("int(10) unsigned"))
+		NoError(t, err)
+		Equal(t, len(mismatches), 0)
+		mismatches, err = slc.strictTypeMismatches(ddl("bigint(20) unsigned"))
+		NoError(t, err)
+		Equal(t, len(mismatches), 1)
+	})
+	t.Run("signed column into unsigned field", func(t *testing.T) {
+		mismatches, err := slc.strictTypeMismatches(ddl("int(11)"))
+		NoError(t, err)
+		Equal(t, len(mismatches), 1)
+		Equal(t, mismatches[0].Type, SchemaMismatchColumnType)
+	})
+	t.Run("config", func(t *testing.T) {
+		enabled := true
+		cfg := &TableConfig{StrictTypes: &enabled}
+		r, err := New(cfg.Options("user_logins")...)
+		NoError(t, err)
+		opt := r.tableOption("user_logins")
+		Equal(t, opt.StrictTypes(), true)
+		other := r.tableOption("users")
+		Equal(t, other.StrictTypes(), false)
+	})
+}
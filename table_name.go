@@ -0,0 +1,61 @@
+package rapidash
+
+// TableNameResolver resolves physical table name ( e.g. user_logins_tenant42 ) to the table name warmed up by WarmUpSecondLevelCache.
+// It returns tableName as it is if tableName isn't physical table of other table
+type TableNameResolver func(tableName string) string
+
+// WithTableName returns SecondLevelCache for physical table that has the same schema with c.
+// It shares index metadata and options with c, but SQL and cache keys use tableName
+func (c *SecondLevelCache) WithTableName(tableName string) *SecondLevelCache {
+	typ := *c.typ
+	typ.tableName = tableName
+	typ.templates = &sqlTemplates{}
+	cache := NewSecondLevelCache(&typ, c.cacheServer, *c.opt)
+	cache.opt = c.opt
+	cache.db = c.db
+	cache.indexColumns = c.indexColumns
+	cache.excludedIndexes = c.excludedIndexes
+	cache.schemaHash = c.schemaHash
+	for name, index := range c.indexes {
+		cache.indexes[name] = index.withTable(tableName)
+		if index == c.primaryKey {
+			cache.primaryKey = cache.indexes[name]
+		}
+	}
+	if cache.primaryKey == nil && c.primaryKey != nil {
+		cache.primaryKey = c.primaryKey.withTable(tableName)
+	}
+	cache.setupIndexStats()
+	return cache
+}
+
+func (i *Index) withTable(tableName string) *Index {
+	index := *i
+	index.Table = tableName
+	return &index
+}
+
+func (r *Rapidash) resolveTableName(tableName string) string {
+	if r.opt.tableNameResolver == nil {
+		return tableName
+	}
+	return r.opt.tableNameResolver(tableName)
+}
+
+// secondLevelCache returns SecondLevelCache for tableName.
+// SecondLevelCache for physical table is created from the resolved table at the first access
+func (r *Rapidash) secondLevelCache(tableName string) (*SecondLevelCache, bool) {
+	if c, exists := r.secondLevelCaches.get(tableName); exists {
+		return c, true
+	}
+	resolvedName := r.resolveTableName(tableName)
+	if resolvedName == tableName {
+		return nil, false
+	}
+	base, exists := r.secondLevelCaches.get(resolvedName)
+	if !exists {
+		return nil, false
+	}
+	c, _ := r.secondLevelCaches.LoadOrStore(tableName, base.WithTableName(tableName))
+	return c.(*SecondLevelCache), true
+}
@@ -0,0 +1,48 @@
+package rapidash
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithTableName(t *testing.T) {
+	slc := NewSecondLevelCache(userLoginType(), nil, TableOption{})
+	slc.primaryKey = NewPrimaryKey(slc.opt, "user_logins", []string{"id"}, slc.typ)
+	slc.indexes["id"] = slc.primaryKey
+	slc.indexes["user_id"] = NewKey(slc.opt, "user_logins", []string{"user_id"}, slc.typ)
+	slc.setupIndexStats()
+
+	r := &Rapidash{
+		secondLevelCaches: NewSecondLevelCacheMap(),
+		ignoreCaches:      map[string]struct{}{},
+		opt: Option{
+			tableNameResolver: func(tableName string) string {
+				if strings.HasPrefix(tableName, "user_logins_") {
+					return "user_logins"
+				}
+				return tableName
+			},
+		},
+	}
+	r.secondLevelCaches.set("user_logins", slc)
+
+	c, exists := r.secondLevelCache("user_logins_tenant42")
+	Equal(t, exists, true)
+	Equal(t, c.typ.tableName, "user_logins_tenant42")
+	Equal(t, c.opt, slc.opt)
+	Equal(t, c.primaryKey.Table, "user_logins_tenant42")
+	Equal(t, c.primaryKey, c.indexes["id"])
+	Equal(t, c.indexes["user_id"].Table, "user_logins_tenant42")
+	Equal(t, slc.typ.tableName, "user_logins")
+	Equal(t, slc.primaryKey.Table, "user_logins")
+
+	cached, _ := r.secondLevelCache("user_logins_tenant42")
+	Equal(t, cached, c)
+
+	_, exists = r.secondLevelCache("user_sessions")
+	Equal(t, exists, false)
+
+	r.ignoreCaches["user_logins"] = struct{}{}
+	tx := &Tx{r: r}
+	Equal(t, tx.isIgnoreCacheTable("user_logins_tenant42"), true)
+}
@@ -0,0 +1,150 @@
+package rapidash
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultTTLTuningInterval = time.Minute
+	ttlTuningMinSamples      = 100
+	// tables whose ratio of writes to accesses is over writeHeavyRatio get shorter expiration
+	writeHeavyRatio = 0.5
+	// tables whose ratio of writes to accesses is under readHeavyRatio get longer expiration
+	readHeavyRatio = 0.1
+)
+
+// TTLTuning is bounds of expiration adjusted by observed hit ratio and write rate of table
+type TTLTuning struct {
+	MinExpiration time.Duration `yaml:"min_expiration"`
+	MaxExpiration time.Duration `yaml:"max_expiration"`
+	Interval      time.Duration `yaml:"interval"`
+}
+
+// TTLStat is expiration chosen by TTLTuning and ratios observed in the last interval
+type TTLStat struct {
+	Table      string
+	Expiration time.Duration
+	HitRate    float64
+	WriteRate  float64
+	TunedAt    time.Time
+}
+
+type ttlTuner struct {
+	tuning  TTLTuning
+	hits    uint64
+	misses  uint64
+	writes  uint64
+	mu      sync.Mutex
+	ttl     time.Duration
+	started time.Time
+	stat    TTLStat
+}
+
+func newTTLTuner(tuning TTLTuning, expiration time.Duration, now time.Time) *ttlTuner {
+	if tuning.Interval <= 0 {
+		tuning.Interval = defaultTTLTuningInterval
+	}
+	t := &ttlTuner{tuning: tuning, started: now}
+	t.ttl = t.bound(expiration)
+	t.stat.Expiration = t.ttl
+	return t
+}
+
+func (t *ttlTuner) bound(ttl time.Duration) time.Duration {
+	if t.tuning.MaxExpiration > 0 && (ttl <= 0 || ttl > t.tuning.MaxExpiration) {
+		return t.tuning.MaxExpiration
+	}
+	if ttl < t.tuning.MinExpiration {
+		return t.tuning.MinExpiration
+	}
+	return ttl
+}
+
+func (t *ttlTuner) observeRead(hits, misses int) {
+	atomic.AddUint64(&t.hits, uint64(hits))
+	atomic.AddUint64(&t.misses, uint64(misses))
+}
+
+func (t *ttlTuner) observeWrite() {
+	atomic.AddUint64(&t.writes, 1)
+}
+
+// expiration returns current expiration, and tunes it if interval passed since the last tuning.
+// expiration is doubled for read heavy tables and halved for write heavy tables
+func (t *ttlTuner) expiration(now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if now.Sub(t.started) < t.tuning.Interval {
+		return t.ttl
+	}
+	samples := atomic.LoadUint64(&t.hits) + atomic.LoadUint64(&t.misses) + atomic.LoadUint64(&t.writes)
+	if samples < ttlTuningMinSamples {
+		return t.ttl
+	}
+	hits := atomic.SwapUint64(&t.hits, 0)
+	misses := atomic.SwapUint64(&t.misses, 0)
+	writes := atomic.SwapUint64(&t.writes, 0)
+	total := hits + misses + writes
+	t.started = now
+
+	writeRate := float64(writes) / float64(total)
+	switch {
+	case writeRate >= writeHeavyRatio:
+		t.ttl = t.bound(t.ttl / 2)
+	case writeRate <= readHeavyRatio:
+		t.ttl = t.bound(t.ttl * 2)
+	}
+	var hitRate float64
+	if hits+misses > 0 {
+		hitRate = float64(hits) / float64(hits+misses)
+	}
+	t.stat = TTLStat{
+		Expiration: t.ttl,
+		HitRate:    hitRate,
+		WriteRate:  writeRate,
+		TunedAt:    now,
+	}
+	return t.ttl
+}
+
+func (c *SecondLevelCache) observeRead(hits, misses int) {
+	if c.ttlTuner != nil {
+		c.ttlTuner.observeRead(hits, misses)
+	}
+}
+
+func (c *SecondLevelCache) observeWrite() {
+	if c.ttlTuner != nil {
+		c.ttlTuner.observeWrite()
+	}
+}
+
+// TTLStat returns nil if SecondLevelCacheTableTTLTuning isn't set for the table
+func (c *SecondLevelCache) TTLStat() *TTLStat {
+	if c.ttlTuner == nil {
+		return nil
+	}
+	c.ttlTuner.mu.Lock()
+	defer c.ttlTuner.mu.Unlock()
+	stat := c.ttlTuner.stat
+	stat.Table = c.typ.tableName
+	return &stat
+}
+
+// TTLStats returns expiration chosen for tables set SecondLevelCacheTableTTLTuning
+func (r *Rapidash) TTLStats() []*TTLStat {
+	stats := []*TTLStat{}
+	r.secondLevelCaches.Range(func(key, value interface{}) bool {
+		if stat := value.(*SecondLevelCache).TTLStat(); stat != nil {
+			stats = append(stats, stat)
+		}
+		return true
+	})
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Table < stats[j].Table
+	})
+	return stats
+}
@@ -0,0 +1,69 @@
+package rapidash
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLTuning(t *testing.T) {
+	clock := &testClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	r, err := New(
+		ClockSource(clock),
+		SecondLevelCacheTableExpiration("user_logins", time.Hour),
+		SecondLevelCacheTableTTLTuning("user_logins", TTLTuning{
+			MinExpiration: 10 * time.Minute,
+			MaxExpiration: 3 * time.Hour,
+			Interval:      time.Minute,
+		}),
+	)
+	NoError(t, err)
+	slc := NewSecondLevelCache(userLoginType(), nil, r.tableOption("user_logins"))
+	r.secondLevelCaches.set("user_logins", slc)
+	Equal(t, slc.expiration(), time.Hour)
+
+	t.Run("not enough samples", func(t *testing.T) {
+		slc.observeRead(10, 0)
+		clock.advance(time.Minute)
+		Equal(t, slc.expiration(), time.Hour)
+	})
+	t.Run("read heavy", func(t *testing.T) {
+		slc.observeRead(90, 10)
+		Equal(t, slc.expiration(), 2*time.Hour)
+		// interval hasn't passed since the last tuning
+		slc.observeRead(100, 0)
+		Equal(t, slc.expiration(), 2*time.Hour)
+		clock.advance(time.Minute)
+		Equal(t, slc.expiration(), 3*time.Hour)
+		stats := r.TTLStats()
+		Equal(t, len(stats), 1)
+		Equal(t, stats[0].Table, "user_logins")
+		Equal(t, stats[0].Expiration, 3*time.Hour)
+		Equal(t, stats[0].HitRate, float64(1))
+		Equal(t, stats[0].TunedAt, clock.Now())
+	})
+	t.Run("write heavy", func(t *testing.T) {
+		for i := 0; i < 6; i++ {
+			slc.observeRead(40, 10)
+			for j := 0; j < 50; j++ {
+				slc.observeWrite()
+			}
+			clock.advance(time.Minute)
+			slc.expiration()
+		}
+		Equal(t, slc.expiration(), 10*time.Minute)
+		stat := slc.TTLStat()
+		Equal(t, stat.HitRate, 0.8)
+		Equal(t, stat.WriteRate, 0.5)
+	})
+	t.Run("ttl source has priority", func(t *testing.T) {
+		slc.opt.ttlSource = testTTLSource{"user_logins": time.Second}
+		defer func() { slc.opt.ttlSource = nil }()
+		Equal(t, slc.expiration(), time.Second)
+	})
+	t.Run("disabled", func(t *testing.T) {
+		other := NewSecondLevelCache(userLoginType(), nil, r.tableOption("users"))
+		if other.TTLStat() != nil {
+			t.Fatal("stat of disabled table must be nil")
+		}
+	})
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
+	"go.knocknote.io/rapidash/server"
 	"golang.org/x/xerrors"
 )
 
@@ -327,6 +330,88 @@ func TestBegin(t *testing.T) {
 	})
 }
 
+func TestBeginWithOption(t *testing.T) {
+	t.Run("repeatable read", func(t *testing.T) {
+		tx, err := cache.BeginWithOption(TxOption{}, nil)
+		NoError(t, err)
+		Equal(t, tx.isStashAvailable("r/slc/user_logins/id#1"), true)
+	})
+	t.Run("read committed", func(t *testing.T) {
+		tx, err := cache.BeginWithOption(TxOption{IsolationAdaptation: IsolationAdaptationReadCommitted}, nil)
+		NoError(t, err)
+		tx.pendingQueries["r/slc/user_logins/id#1"] = &PendingQuery{QueryLog: &QueryLog{Command: string(SLCCommandSet)}}
+		tx.pendingQueries["r/slc/user_logins/id#2"] = &PendingQuery{QueryLog: &QueryLog{Command: string(SLCCommandUpdate)}}
+		Equal(t, tx.isStashAvailable("r/slc/user_logins/id#1"), false)
+		Equal(t, tx.isStashAvailable("r/slc/user_logins/id#2"), true)
+		Equal(t, tx.isStashAvailable("r/slc/user_logins/id#3"), false)
+	})
+}
+
+func TestConcurrentTxUse(t *testing.T) {
+	r, err := New()
+	NoError(t, err)
+	cacheServer := newMemoryCacheServer()
+	r.cacheServer = cacheServer
+	r.lastLevelCache = NewLastLevelCache(cacheServer, r.opt.llcOpt)
+	tx, err := r.Begin()
+	NoError(t, err)
+
+	t.Run("reentrant in callback", func(t *testing.T) {
+		NoError(t, tx.enter())
+		var v int
+		if err := tx.guard.runCallback(func() error { return tx.Find("key", IntPtr(&v)) }); xerrors.Is(err, ErrConcurrentTxUse) {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		Equal(t, tx.guard.inUse(), true)
+		if err := tx.Find("key", IntPtr(&v)); !xerrors.Is(err, ErrConcurrentTxUse) {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		tx.leave()
+		Equal(t, tx.guard.inUse(), false)
+	})
+	t.Run("other goroutine", func(t *testing.T) {
+		NoError(t, tx.enter())
+		errs := make(chan error)
+		go func() {
+			var v int
+			errs <- tx.Find("key", IntPtr(&v))
+		}()
+		if err := <-errs; !xerrors.Is(err, ErrConcurrentTxUse) {
+			t.Fatalf("unexpected error %+v", err)
+		}
+		tx.leave()
+	})
+	t.Run("concurrent goroutines", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 10; j++ {
+					if err := tx.Create(fmt.Sprintf("key%d", j), Int(j)); err != nil && !xerrors.Is(err, ErrConcurrentTxUse) {
+						t.Errorf("unexpected error %+v", err)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		Equal(t, tx.guard.inUse(), false)
+	})
+	NoError(t, tx.Rollback())
+	t.Run("use in before commit callback", func(t *testing.T) {
+		tx, err := r.Begin()
+		NoError(t, err)
+		tx.BeforeCommitCallback(func([]*QueryLog) error {
+			var v int
+			if err := tx.Find("key", IntPtr(&v)); xerrors.Is(err, ErrConcurrentTxUse) {
+				return err
+			}
+			return nil
+		})
+		NoError(t, tx.Commit())
+	})
+}
+
 func TestTx_CreateByTableContext(t *testing.T) {
 	t.Run("already committed", func(t *testing.T) {
 		txConn, err := conn.Begin()
@@ -471,6 +556,8 @@ func TestTx_FindByQueryBuilderContext(t *testing.T) {
 		var userLogins UserLogins
 		if err := tx.FindByQueryBuilderContext(context.Background(), builder, &userLogins); err == nil {
 			t.Fatal("err is nil\n")
+		} else if !xerrors.Is(err, ErrTableNotWarmedUp) {
+			t.Fatalf("unexpected type err: %+v", err)
 		}
 	})
 	t.Run("find ignore table", func(t *testing.T) {
@@ -688,6 +775,32 @@ func TestTx_DeleteByQueryBuilder(t *testing.T) {
 	NoError(t, tx.Commit())
 }
 
+func TestTx_RowsByQueryBuilder(t *testing.T) {
+	NoError(t, initUserLoginTable(conn))
+	txConn, err := conn.Begin()
+	NoError(t, err)
+	tx, err := cache.Begin(txConn)
+	NoError(t, err)
+	defer func() { NoError(t, tx.RollbackUnlessCommitted()) }()
+
+	builder := NewQueryBuilder("user_logins").Eq("user_id", uint64(1)).Eq("user_session_id", uint64(1))
+	updateMap := map[string]interface{}{"login_param_id": uint64(12)}
+	affected, err := tx.UpdateRowsByQueryBuilder(builder, updateMap)
+	NoError(t, err)
+	Equal(t, affected, int64(1))
+
+	builder = NewQueryBuilder("user_logins").Eq("user_id", uint64(1)).Eq("user_session_id", uint64(1))
+	affected, err = tx.UpdateRowsByQueryBuilder(builder, updateMap)
+	NoError(t, err)
+	Equal(t, affected, int64(0))
+
+	builder = NewQueryBuilder("user_logins").Eq("user_id", uint64(1)).Eq("user_session_id", uint64(1))
+	affected, err = tx.DeleteRowsByQueryBuilder(builder)
+	NoError(t, err)
+	Equal(t, affected, int64(1))
+	NoError(t, tx.Commit())
+}
+
 func TestTx_DeleteByQueryBuilderContext(t *testing.T) {
 	t.Run("already committed", func(t *testing.T) {
 		txConn, err := conn.Begin()
@@ -767,3 +880,139 @@ func TestTx_DeleteByQueryBuilderContext(t *testing.T) {
 		}
 	})
 }
+
+func TestSetCacheEnabled(t *testing.T) {
+	r := &Rapidash{ignoreCaches: map[string]struct{}{}}
+	tx := &Tx{r: r}
+	Equal(t, tx.isIgnoreCacheTable("user_logins"), false)
+	r.SetCacheEnabled(false)
+	Equal(t, r.IsCacheEnabled(), false)
+	builder := NewQueryBuilder("user_logins").Eq("id", uint64(1))
+	tx.enabledIgnoreCacheIfExistsTable(builder)
+	Equal(t, builder.isIgnoreCache, true)
+	NoError(t, tx.Create("key", Int(1)))
+	var v int
+	err := tx.Find("key", IntPtr(&v))
+	Error(t, err)
+	Equal(t, xerrors.Is(err, ErrNotFound), true)
+	NoError(t, tx.Delete("key"))
+	r.SetCacheEnabled(true)
+	Equal(t, tx.isIgnoreCacheTable("user_logins"), false)
+}
+
+func TestReadOnlyTx(t *testing.T) {
+	r := &Rapidash{ignoreCaches: map[string]struct{}{}}
+	tx := &Tx{r: r, opt: TxOption{ReadOnly: true}, snapshot: true}
+	Equal(t, tx.isIgnoreCacheTable("user_logins"), true)
+	Equal(t, xerrors.Is(tx.Create("key", Int(1)), ErrReadOnlyTransaction), true)
+	Equal(t, xerrors.Is(tx.Delete("key"), ErrReadOnlyTransaction), true)
+	err := tx.UpdateByQueryBuilder(NewQueryBuilder("user_logins").Eq("id", uint64(1)), map[string]interface{}{"name": "x"})
+	Equal(t, xerrors.Is(err, ErrReadOnlyTransaction), true)
+	err = tx.DeleteByQueryBuilder(NewQueryBuilder("user_logins").Eq("id", uint64(1)))
+	Equal(t, xerrors.Is(err, ErrReadOnlyTransaction), true)
+}
+
+type contextCacheServer struct {
+	*memoryCacheServer
+}
+
+func (s *contextCacheServer) Get(ctx context.Context, key server.CacheKey) (*server.CacheGetResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.memoryCacheServer.Get(ctx, key)
+}
+
+func (s *contextCacheServer) Set(ctx context.Context, req *server.CacheStoreRequest) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.memoryCacheServer.Set(ctx, req)
+}
+
+func (s *contextCacheServer) Add(ctx context.Context, key server.CacheKey, value []byte, expiration time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.memoryCacheServer.Add(ctx, key, value, expiration)
+}
+
+func (s *contextCacheServer) Delete(ctx context.Context, key server.CacheKey) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.memoryCacheServer.Delete(ctx, key)
+}
+
+func (s *contextCacheServer) Flush(ctx context.Context) error {
+	return ctx.Err()
+}
+
+func TestTxContext(t *testing.T) {
+	r, err := New()
+	NoError(t, err)
+	cacheServer := &contextCacheServer{memoryCacheServer: newMemoryCacheServer()}
+	r.cacheServer = cacheServer
+	r.lastLevelCache = NewLastLevelCache(cacheServer, r.opt.llcOpt)
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	t.Run("find", func(t *testing.T) {
+		tx, err := r.Begin()
+		NoError(t, err)
+		var v int
+		if err := tx.FindContext(canceled, "find", IntPtr(&v)); err == nil {
+			t.Fatal("expected error by canceled context")
+		}
+		if _, err := tx.FindWithVersionContext(canceled, "find", IntPtr(&v)); err == nil {
+			t.Fatal("expected error by canceled context")
+		}
+		NoError(t, tx.Rollback())
+	})
+	t.Run("commit", func(t *testing.T) {
+		tx, err := r.Begin()
+		NoError(t, err)
+		if err := tx.CreateContext(canceled, "create", Int(1)); err == nil {
+			t.Fatal("expected error by canceled context")
+		}
+		NoError(t, tx.CreateContext(context.Background(), "commit", Int(1)))
+		if err := tx.CommitCacheOnlyContext(canceled); err == nil {
+			t.Fatal("expected error by canceled context")
+		}
+
+		tx, err = r.Begin()
+		NoError(t, err)
+		NoError(t, tx.CreateContext(context.Background(), "create", Int(1)))
+		NoError(t, tx.CommitCacheOnlyContext(context.Background()))
+		var v int
+		tx, err = r.Begin()
+		NoError(t, err)
+		_, err = tx.FindWithVersionContext(context.Background(), "create", IntPtr(&v))
+		NoError(t, err)
+		Equal(t, v, 1)
+		NoError(t, tx.UpdateContext(context.Background(), "create", Int(3)))
+		NoError(t, tx.DeleteContext(context.Background(), "create"))
+		NoError(t, tx.CommitCacheOnlyContext(context.Background()))
+	})
+	t.Run("rollback", func(t *testing.T) {
+		tx, err := r.Begin()
+		NoError(t, err)
+		NoError(t, tx.CreateContext(context.Background(), "rollback", Int(1)))
+		if err := tx.RollbackCacheOnlyContext(canceled); err == nil {
+			t.Fatal("expected error by canceled context")
+		}
+	})
+	t.Run("recover", func(t *testing.T) {
+		queries := []*QueryLog{{Key: "r/llc/key"}}
+		if err := r.RecoverContext(canceled, queries); err == nil {
+			t.Fatal("expected error by canceled context")
+		}
+		NoError(t, r.RecoverContext(context.Background(), queries))
+	})
+	t.Run("flush", func(t *testing.T) {
+		if err := r.FlushContext(canceled); err == nil {
+			t.Fatal("expected error by canceled context")
+		}
+		NoError(t, r.FlushContext(context.Background()))
+	})
+}
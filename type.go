@@ -4,7 +4,8 @@ import (
 	"bytes"
 	"encoding/hex"
 	"fmt"
-	"hash/crc32"
+	"math"
+	"reflect"
 	"sort"
 	"strconv"
 	"strings"
@@ -118,8 +119,15 @@ var (
 )
 
 type Struct struct {
-	tableName string
-	fields    map[string]*StructField
+	tableName    string
+	fields       map[string]*StructField
+	aliases      map[string]string
+	columnMapper func(string) string
+	timeBuckets  map[string]time.Duration
+	templates    *sqlTemplates
+	failFast     bool
+	keyTransform map[string]KeyTransform
+	cipher       *columnCipher
 }
 
 type StructField struct {
@@ -129,6 +137,9 @@ type StructField struct {
 	index         int
 	subtype       TypeID
 	subtypeStruct *Struct
+	encryption    EncryptionMode
+	defaultValue  *Value
+	notNull       bool
 }
 
 type ValueFactory struct {
@@ -149,6 +160,7 @@ type ValueFactory struct {
 	bytesValuePool         sync.Pool
 	timeValuePool          sync.Pool
 	defaultValueCreatorMap map[TypeID]func() *Value
+	stats                  poolCounter
 }
 
 func NewValueFactory() *ValueFactory {
@@ -156,81 +168,97 @@ func NewValueFactory() *ValueFactory {
 	f = &ValueFactory{
 		intValuePool: sync.Pool{
 			New: func() interface{} {
+				f.stats.alloc()
 				return NewIntValue(0)
 			},
 		},
 		int8ValuePool: sync.Pool{
 			New: func() interface{} {
+				f.stats.alloc()
 				return NewInt8Value(0)
 			},
 		},
 		int16ValuePool: sync.Pool{
 			New: func() interface{} {
+				f.stats.alloc()
 				return NewInt16Value(0)
 			},
 		},
 		int32ValuePool: sync.Pool{
 			New: func() interface{} {
+				f.stats.alloc()
 				return NewInt32Value(0)
 			},
 		},
 		int64ValuePool: sync.Pool{
 			New: func() interface{} {
+				f.stats.alloc()
 				return NewInt64Value(0)
 			},
 		},
 		uintValuePool: sync.Pool{
 			New: func() interface{} {
+				f.stats.alloc()
 				return NewUintValue(0)
 			},
 		},
 		uint8ValuePool: sync.Pool{
 			New: func() interface{} {
+				f.stats.alloc()
 				return NewUint8Value(0)
 			},
 		},
 		uint16ValuePool: sync.Pool{
 			New: func() interface{} {
+				f.stats.alloc()
 				return NewUint16Value(0)
 			},
 		},
 		uint32ValuePool: sync.Pool{
 			New: func() interface{} {
+				f.stats.alloc()
 				return NewUint32Value(0)
 			},
 		},
 		uint64ValuePool: sync.Pool{
 			New: func() interface{} {
+				f.stats.alloc()
 				return NewUint64Value(0)
 			},
 		},
 		float32ValuePool: sync.Pool{
 			New: func() interface{} {
+				f.stats.alloc()
 				return NewFloat32Value(0)
 			},
 		},
 		float64ValuePool: sync.Pool{
 			New: func() interface{} {
+				f.stats.alloc()
 				return NewFloat64Value(0)
 			},
 		},
 		boolValuePool: sync.Pool{
 			New: func() interface{} {
+				f.stats.alloc()
 				return NewBoolValue(false)
 			},
 		},
 		stringValuePool: sync.Pool{
 			New: func() interface{} {
+				f.stats.alloc()
 				return NewStringValue("")
 			},
 		},
 		bytesValuePool: sync.Pool{
 			New: func() interface{} {
+				f.stats.alloc()
 				return NewBytesValue([]byte{})
 			},
 		},
 		timeValuePool: sync.Pool{
 			New: func() interface{} {
+				f.stats.alloc()
 				return NewTimeValue(time.Now())
 			},
 		},
@@ -460,6 +488,57 @@ func (f *ValueFactory) CreateValue(v interface{}) *Value {
 		return f.CreateTimePtrValue(v)
 	default:
 	}
+	return f.createValueByReflect(v)
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// createValueByReflect creates value from named types ( e.g. type UserID uint64 ) or pointer of them
+func (f *ValueFactory) createValueByReflect(v interface{}) *Value {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return nilValue
+		}
+		return f.CreateValue(rv.Elem().Interface())
+	case reflect.Int:
+		return f.CreateIntValue(int(rv.Int()))
+	case reflect.Int8:
+		return f.CreateInt8Value(int8(rv.Int()))
+	case reflect.Int16:
+		return f.CreateInt16Value(int16(rv.Int()))
+	case reflect.Int32:
+		return f.CreateInt32Value(int32(rv.Int()))
+	case reflect.Int64:
+		return f.CreateInt64Value(rv.Int())
+	case reflect.Uint:
+		return f.CreateUintValue(uint(rv.Uint()))
+	case reflect.Uint8:
+		return f.CreateUint8Value(uint8(rv.Uint()))
+	case reflect.Uint16:
+		return f.CreateUint16Value(uint16(rv.Uint()))
+	case reflect.Uint32:
+		return f.CreateUint32Value(uint32(rv.Uint()))
+	case reflect.Uint64:
+		return f.CreateUint64Value(rv.Uint())
+	case reflect.Float32:
+		return f.CreateFloat32Value(float32(rv.Float()))
+	case reflect.Float64:
+		return f.CreateFloat64Value(rv.Float())
+	case reflect.Bool:
+		return f.CreateBoolValue(rv.Bool())
+	case reflect.String:
+		return f.CreateStringValue(rv.String())
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return f.CreateBytesValue(rv.Bytes())
+		}
+	case reflect.Struct:
+		if rv.Type().ConvertibleTo(timeType) {
+			return f.CreateTimeValue(rv.Convert(timeType).Interface().(time.Time))
+		}
+	}
 	return nil
 }
 
@@ -628,11 +707,63 @@ func (f *ValueFactory) CreateUniqueValues(v interface{}) []*Value {
 		return values
 	default:
 	}
-	return nil
+	return f.createUniqueValuesByReflect(v)
+}
+
+// createUniqueValuesByReflect creates values from slice of named types or pointers. nil elements are ignored
+func (f *ValueFactory) createUniqueValuesByReflect(v interface{}) []*Value {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil
+	}
+	uniqueMap := map[interface{}]struct{}{}
+	values := make([]*Value, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		elem := rv.Index(i)
+		for elem.IsValid() && (elem.Kind() == reflect.Interface || elem.Kind() == reflect.Ptr) {
+			if elem.IsNil() {
+				elem = reflect.Value{}
+				break
+			}
+			elem = elem.Elem()
+		}
+		if !elem.IsValid() {
+			continue
+		}
+		key := elem.Interface()
+		if elem.Kind() == reflect.Slice {
+			key = fmt.Sprint(key)
+		}
+		if _, exists := uniqueMap[key]; exists {
+			continue
+		}
+		value := f.CreateValue(elem.Interface())
+		if value == nil {
+			for _, value := range values {
+				value.Release()
+			}
+			return nil
+		}
+		uniqueMap[key] = struct{}{}
+		values = append(values, value)
+	}
+	return values
+}
+
+func (f *ValueFactory) getValue(pool *sync.Pool) *Value {
+	f.stats.get()
+	value := pool.Get().(*Value)
+	value.poolStats = &f.stats
+	return value
+}
+
+// PoolStats returns usage of pools of values
+func (f *ValueFactory) PoolStats() PoolStats {
+	return f.stats.snapshot()
 }
 
 func (f *ValueFactory) CreateIntValue(v int) *Value {
-	value := f.intValuePool.Get().(*Value)
+	value := f.getValue(&f.intValuePool)
 	value.Set(v)
 	value.IsNil = false
 	value.valuePool = &f.intValuePool
@@ -640,7 +771,7 @@ func (f *ValueFactory) CreateIntValue(v int) *Value {
 }
 
 func (f *ValueFactory) CreateInt8Value(v int8) *Value {
-	value := f.int8ValuePool.Get().(*Value)
+	value := f.getValue(&f.int8ValuePool)
 	value.Set(v)
 	value.IsNil = false
 	value.valuePool = &f.int8ValuePool
@@ -648,7 +779,7 @@ func (f *ValueFactory) CreateInt8Value(v int8) *Value {
 }
 
 func (f *ValueFactory) CreateInt16Value(v int16) *Value {
-	value := f.int16ValuePool.Get().(*Value)
+	value := f.getValue(&f.int16ValuePool)
 	value.Set(v)
 	value.IsNil = false
 	value.valuePool = &f.int16ValuePool
@@ -656,7 +787,7 @@ func (f *ValueFactory) CreateInt16Value(v int16) *Value {
 }
 
 func (f *ValueFactory) CreateInt32Value(v int32) *Value {
-	value := f.int32ValuePool.Get().(*Value)
+	value := f.getValue(&f.int32ValuePool)
 	value.Set(v)
 	value.IsNil = false
 	value.valuePool = &f.int32ValuePool
@@ -664,7 +795,7 @@ func (f *ValueFactory) CreateInt32Value(v int32) *Value {
 }
 
 func (f *ValueFactory) CreateInt64Value(v int64) *Value {
-	value := f.int64ValuePool.Get().(*Value)
+	value := f.getValue(&f.int64ValuePool)
 	value.Set(v)
 	value.IsNil = false
 	value.valuePool = &f.int64ValuePool
@@ -672,7 +803,7 @@ func (f *ValueFactory) CreateInt64Value(v int64) *Value {
 }
 
 func (f *ValueFactory) CreateUintValue(v uint) *Value {
-	value := f.uintValuePool.Get().(*Value)
+	value := f.getValue(&f.uintValuePool)
 	value.Set(v)
 	value.IsNil = false
 	value.valuePool = &f.uintValuePool
@@ -680,7 +811,7 @@ func (f *ValueFactory) CreateUintValue(v uint) *Value {
 }
 
 func (f *ValueFactory) CreateUint8Value(v uint8) *Value {
-	value := f.uint8ValuePool.Get().(*Value)
+	value := f.getValue(&f.uint8ValuePool)
 	value.Set(v)
 	value.IsNil = false
 	value.valuePool = &f.uint8ValuePool
@@ -688,7 +819,7 @@ func (f *ValueFactory) CreateUint8Value(v uint8) *Value {
 }
 
 func (f *ValueFactory) CreateUint16Value(v uint16) *Value {
-	value := f.uint16ValuePool.Get().(*Value)
+	value := f.getValue(&f.uint16ValuePool)
 	value.Set(v)
 	value.IsNil = false
 	value.valuePool = &f.uint16ValuePool
@@ -696,7 +827,7 @@ func (f *ValueFactory) CreateUint16Value(v uint16) *Value {
 }
 
 func (f *ValueFactory) CreateUint32Value(v uint32) *Value {
-	value := f.uint32ValuePool.Get().(*Value)
+	value := f.getValue(&f.uint32ValuePool)
 	value.Set(v)
 	value.IsNil = false
 	value.valuePool = &f.uint32ValuePool
@@ -704,7 +835,7 @@ func (f *ValueFactory) CreateUint32Value(v uint32) *Value {
 }
 
 func (f *ValueFactory) CreateUint64Value(v uint64) *Value {
-	value := f.uint64ValuePool.Get().(*Value)
+	value := f.getValue(&f.uint64ValuePool)
 	value.Set(v)
 	value.IsNil = false
 	value.valuePool = &f.uint64ValuePool
@@ -712,7 +843,7 @@ func (f *ValueFactory) CreateUint64Value(v uint64) *Value {
 }
 
 func (f *ValueFactory) CreateFloat32Value(v float32) *Value {
-	value := f.float32ValuePool.Get().(*Value)
+	value := f.getValue(&f.float32ValuePool)
 	value.Set(v)
 	value.IsNil = false
 	value.valuePool = &f.float32ValuePool
@@ -720,7 +851,7 @@ func (f *ValueFactory) CreateFloat32Value(v float32) *Value {
 }
 
 func (f *ValueFactory) CreateFloat64Value(v float64) *Value {
-	value := f.float64ValuePool.Get().(*Value)
+	value := f.getValue(&f.float64ValuePool)
 	value.Set(v)
 	value.IsNil = false
 	value.valuePool = &f.float64ValuePool
@@ -728,7 +859,7 @@ func (f *ValueFactory) CreateFloat64Value(v float64) *Value {
 }
 
 func (f *ValueFactory) CreateBoolValue(v bool) *Value {
-	value := f.boolValuePool.Get().(*Value)
+	value := f.getValue(&f.boolValuePool)
 	value.Set(v)
 	value.IsNil = false
 	value.valuePool = &f.boolValuePool
@@ -736,7 +867,7 @@ func (f *ValueFactory) CreateBoolValue(v bool) *Value {
 }
 
 func (f *ValueFactory) CreateStringValue(v string) *Value {
-	value := f.stringValuePool.Get().(*Value)
+	value := f.getValue(&f.stringValuePool)
 	value.Set(v)
 	value.IsNil = false
 	value.valuePool = &f.stringValuePool
@@ -744,7 +875,7 @@ func (f *ValueFactory) CreateStringValue(v string) *Value {
 }
 
 func (f *ValueFactory) CreateBytesValue(v []byte) *Value {
-	value := f.bytesValuePool.Get().(*Value)
+	value := f.getValue(&f.bytesValuePool)
 	value.Set(v)
 	value.IsNil = false
 	value.valuePool = &f.bytesValuePool
@@ -752,7 +883,7 @@ func (f *ValueFactory) CreateBytesValue(v []byte) *Value {
 }
 
 func (f *ValueFactory) CreateTimeValue(v time.Time) *Value {
-	value := f.timeValuePool.Get().(*Value)
+	value := f.getValue(&f.timeValuePool)
 	value.Set(v)
 	value.IsNil = false
 	value.valuePool = &f.timeValuePool
@@ -760,7 +891,7 @@ func (f *ValueFactory) CreateTimeValue(v time.Time) *Value {
 }
 
 func (f *ValueFactory) CreateIntPtrValue(v *int) *Value {
-	value := f.intValuePool.Get().(*Value)
+	value := f.getValue(&f.intValuePool)
 	if v == nil {
 		value.Set(0)
 		value.IsNil = true
@@ -773,7 +904,7 @@ func (f *ValueFactory) CreateIntPtrValue(v *int) *Value {
 }
 
 func (f *ValueFactory) CreateInt8PtrValue(v *int8) *Value {
-	value := f.int8ValuePool.Get().(*Value)
+	value := f.getValue(&f.int8ValuePool)
 	if v == nil {
 		value.Set(int8(0))
 		value.IsNil = true
@@ -786,7 +917,7 @@ func (f *ValueFactory) CreateInt8PtrValue(v *int8) *Value {
 }
 
 func (f *ValueFactory) CreateInt16PtrValue(v *int16) *Value {
-	value := f.int16ValuePool.Get().(*Value)
+	value := f.getValue(&f.int16ValuePool)
 	if v == nil {
 		value.Set(int16(0))
 		value.IsNil = true
@@ -799,7 +930,7 @@ func (f *ValueFactory) CreateInt16PtrValue(v *int16) *Value {
 }
 
 func (f *ValueFactory) CreateInt32PtrValue(v *int32) *Value {
-	value := f.int32ValuePool.Get().(*Value)
+	value := f.getValue(&f.int32ValuePool)
 	if v == nil {
 		value.Set(int32(0))
 		value.IsNil = true
@@ -812,7 +943,7 @@ func (f *ValueFactory) CreateInt32PtrValue(v *int32) *Value {
 }
 
 func (f *ValueFactory) CreateInt64PtrValue(v *int64) *Value {
-	value := f.int64ValuePool.Get().(*Value)
+	value := f.getValue(&f.int64ValuePool)
 	if v == nil {
 		value.Set(int64(0))
 		value.IsNil = true
@@ -825,7 +956,7 @@ func (f *ValueFactory) CreateInt64PtrValue(v *int64) *Value {
 }
 
 func (f *ValueFactory) CreateUintPtrValue(v *uint) *Value {
-	value := f.uintValuePool.Get().(*Value)
+	value := f.getValue(&f.uintValuePool)
 	if v == nil {
 		value.Set(uint(0))
 		value.IsNil = true
@@ -838,7 +969,7 @@ func (f *ValueFactory) CreateUintPtrValue(v *uint) *Value {
 }
 
 func (f *ValueFactory) CreateUint8PtrValue(v *uint8) *Value {
-	value := f.uint8ValuePool.Get().(*Value)
+	value := f.getValue(&f.uint8ValuePool)
 	if v == nil {
 		value.Set(uint8(0))
 		value.IsNil = true
@@ -851,7 +982,7 @@ func (f *ValueFactory) CreateUint8PtrValue(v *uint8) *Value {
 }
 
 func (f *ValueFactory) CreateUint16PtrValue(v *uint16) *Value {
-	value := f.uint16ValuePool.Get().(*Value)
+	value := f.getValue(&f.uint16ValuePool)
 	if v == nil {
 		value.Set(uint16(0))
 		value.IsNil = true
@@ -864,7 +995,7 @@ func (f *ValueFactory) CreateUint16PtrValue(v *uint16) *Value {
 }
 
 func (f *ValueFactory) CreateUint32PtrValue(v *uint32) *Value {
-	value := f.uint32ValuePool.Get().(*Value)
+	value := f.getValue(&f.uint32ValuePool)
 	if v == nil {
 		value.Set(uint32(0))
 		value.IsNil = true
@@ -877,7 +1008,7 @@ func (f *ValueFactory) CreateUint32PtrValue(v *uint32) *Value {
 }
 
 func (f *ValueFactory) CreateUint64PtrValue(v *uint64) *Value {
-	value := f.uint64ValuePool.Get().(*Value)
+	value := f.getValue(&f.uint64ValuePool)
 	if v == nil {
 		value.Set(uint64(0))
 		value.IsNil = true
@@ -890,7 +1021,7 @@ func (f *ValueFactory) CreateUint64PtrValue(v *uint64) *Value {
 }
 
 func (f *ValueFactory) CreateFloat32PtrValue(v *float32) *Value {
-	value := f.float32ValuePool.Get().(*Value)
+	value := f.getValue(&f.float32ValuePool)
 	if v == nil {
 		value.Set(float32(0))
 		value.IsNil = true
@@ -903,7 +1034,7 @@ func (f *ValueFactory) CreateFloat32PtrValue(v *float32) *Value {
 }
 
 func (f *ValueFactory) CreateFloat64PtrValue(v *float64) *Value {
-	value := f.float64ValuePool.Get().(*Value)
+	value := f.getValue(&f.float64ValuePool)
 	if v == nil {
 		value.Set(float64(0))
 		value.IsNil = true
@@ -916,7 +1047,7 @@ func (f *ValueFactory) CreateFloat64PtrValue(v *float64) *Value {
 }
 
 func (f *ValueFactory) CreateBoolPtrValue(v *bool) *Value {
-	value := f.boolValuePool.Get().(*Value)
+	value := f.getValue(&f.boolValuePool)
 	if v == nil {
 		value.Set(false)
 		value.IsNil = true
@@ -929,7 +1060,7 @@ func (f *ValueFactory) CreateBoolPtrValue(v *bool) *Value {
 }
 
 func (f *ValueFactory) CreateStringPtrValue(v *string) *Value {
-	value := f.stringValuePool.Get().(*Value)
+	value := f.getValue(&f.stringValuePool)
 	if v == nil {
 		value.Set("")
 		value.IsNil = true
@@ -942,7 +1073,7 @@ func (f *ValueFactory) CreateStringPtrValue(v *string) *Value {
 }
 
 func (f *ValueFactory) CreateBytesPtrValue(v *[]byte) *Value {
-	value := f.bytesValuePool.Get().(*Value)
+	value := f.getValue(&f.bytesValuePool)
 	if v == nil {
 		value.Set([]byte{})
 		value.IsNil = true
@@ -955,7 +1086,7 @@ func (f *ValueFactory) CreateBytesPtrValue(v *[]byte) *Value {
 }
 
 func (f *ValueFactory) CreateTimePtrValue(v *time.Time) *Value {
-	value := f.timeValuePool.Get().(*Value)
+	value := f.getValue(&f.timeValuePool)
 	if v == nil {
 		value.Set(time.Time{})
 		value.IsNil = true
@@ -1002,6 +1133,7 @@ type Value struct {
 	RawValue     func() interface{}
 	scan         func(interface{}) error
 	valuePool    *sync.Pool
+	poolStats    *poolCounter
 }
 
 func (v *Value) Scan(src interface{}) error {
@@ -1065,9 +1197,13 @@ func (Values) Bools(string) []bool        { return nil }
 func (Values) Strings(string) []string    { return nil }
 func (Values) Times(string) []time.Time   { return nil }
 func (Values) Error() error               { return nil }
+func (Values) Errors() []*FieldError      { return nil }
 
 func (v *Value) Release() {
 	if v.valuePool != nil {
+		if v.poolStats != nil {
+			v.poolStats.put()
+		}
 		v.valuePool.Put(v)
 	}
 }
@@ -1158,10 +1294,10 @@ func NewIntValue(v int) *Value {
 			if rvalue.IsNil {
 				return nilStr
 			}
-			return fmt.Sprint(rvalue.intValue)
+			return formatIntKey(int64(rvalue.intValue))
 		},
 		Hash: func() uint32 {
-			return crc32.ChecksumIEEE([]byte(fmt.Sprint(rvalue.intValue)))
+			return hashKey(formatIntKey(int64(rvalue.intValue)))
 		},
 		RawValue: func() interface{} {
 			if rvalue.IsNil {
@@ -1259,10 +1395,10 @@ func NewInt8Value(v int8) *Value {
 			if rvalue.IsNil {
 				return nilStr
 			}
-			return fmt.Sprint(rvalue.int8Value)
+			return formatIntKey(int64(rvalue.int8Value))
 		},
 		Hash: func() uint32 {
-			return crc32.ChecksumIEEE([]byte(fmt.Sprint(rvalue.int8Value)))
+			return hashKey(formatIntKey(int64(rvalue.int8Value)))
 		},
 		RawValue: func() interface{} {
 			if rvalue.IsNil {
@@ -1360,10 +1496,10 @@ func NewInt16Value(v int16) *Value {
 			if rvalue.IsNil {
 				return nilStr
 			}
-			return fmt.Sprint(rvalue.int16Value)
+			return formatIntKey(int64(rvalue.int16Value))
 		},
 		Hash: func() uint32 {
-			return crc32.ChecksumIEEE([]byte(fmt.Sprint(rvalue.int16Value)))
+			return hashKey(formatIntKey(int64(rvalue.int16Value)))
 		},
 		RawValue: func() interface{} {
 			if rvalue.IsNil {
@@ -1461,10 +1597,10 @@ func NewInt32Value(v int32) *Value {
 			if rvalue.IsNil {
 				return nilStr
 			}
-			return fmt.Sprint(rvalue.int32Value)
+			return formatIntKey(int64(rvalue.int32Value))
 		},
 		Hash: func() uint32 {
-			return crc32.ChecksumIEEE([]byte(fmt.Sprint(rvalue.int32Value)))
+			return hashKey(formatIntKey(int64(rvalue.int32Value)))
 		},
 		RawValue: func() interface{} {
 			if rvalue.IsNil {
@@ -1562,10 +1698,10 @@ func NewInt64Value(v int64) *Value {
 			if rvalue.IsNil {
 				return nilStr
 			}
-			return fmt.Sprint(rvalue.int64Value)
+			return formatIntKey(int64(rvalue.int64Value))
 		},
 		Hash: func() uint32 {
-			return crc32.ChecksumIEEE([]byte(fmt.Sprint(rvalue.int64Value)))
+			return hashKey(formatIntKey(int64(rvalue.int64Value)))
 		},
 		RawValue: func() interface{} {
 			if rvalue.IsNil {
@@ -1600,6 +1736,9 @@ func NewInt64Value(v int64) *Value {
 			case uint32:
 				rvalue.int64Value = int64(v)
 			case uint64:
+				if v > math.MaxInt64 {
+					return xerrors.Errorf("failed to scan %d as int64: %w", v, ErrInt64Overflow)
+				}
 				rvalue.int64Value = int64(v)
 			case []byte:
 				i, err := strconv.ParseInt(string(v), 10, 64)
@@ -1663,10 +1802,10 @@ func NewUintValue(v uint) *Value {
 			if rvalue.IsNil {
 				return nilStr
 			}
-			return fmt.Sprint(rvalue.uintValue)
+			return formatUintKey(uint64(rvalue.uintValue))
 		},
 		Hash: func() uint32 {
-			return crc32.ChecksumIEEE([]byte(fmt.Sprint(rvalue.uintValue)))
+			return hashKey(formatUintKey(uint64(rvalue.uintValue)))
 		},
 		RawValue: func() interface{} {
 			if rvalue.IsNil {
@@ -1758,10 +1897,10 @@ func NewUint8Value(v uint8) *Value {
 			if rvalue.IsNil {
 				return nilStr
 			}
-			return fmt.Sprint(rvalue.uint8Value)
+			return formatUintKey(uint64(rvalue.uint8Value))
 		},
 		Hash: func() uint32 {
-			return crc32.ChecksumIEEE([]byte(fmt.Sprint(rvalue.uint8Value)))
+			return hashKey(formatUintKey(uint64(rvalue.uint8Value)))
 		},
 		RawValue: func() interface{} {
 			if rvalue.IsNil {
@@ -1859,10 +1998,10 @@ func NewUint16Value(v uint16) *Value {
 			if rvalue.IsNil {
 				return nilStr
 			}
-			return fmt.Sprint(rvalue.uint16Value)
+			return formatUintKey(uint64(rvalue.uint16Value))
 		},
 		Hash: func() uint32 {
-			return crc32.ChecksumIEEE([]byte(fmt.Sprint(rvalue.uint16Value)))
+			return hashKey(formatUintKey(uint64(rvalue.uint16Value)))
 		},
 		RawValue: func() interface{} {
 			if rvalue.IsNil {
@@ -1960,10 +2099,10 @@ func NewUint32Value(v uint32) *Value {
 			if rvalue.IsNil {
 				return nilStr
 			}
-			return fmt.Sprint(rvalue.uint32Value)
+			return formatUintKey(uint64(rvalue.uint32Value))
 		},
 		Hash: func() uint32 {
-			return crc32.ChecksumIEEE([]byte(fmt.Sprint(rvalue.uint32Value)))
+			return hashKey(formatUintKey(uint64(rvalue.uint32Value)))
 		},
 		RawValue: func() interface{} {
 			if rvalue.IsNil {
@@ -2061,10 +2200,10 @@ func NewUint64Value(v uint64) *Value {
 			if rvalue.IsNil {
 				return nilStr
 			}
-			return fmt.Sprint(rvalue.uint64Value)
+			return formatUintKey(uint64(rvalue.uint64Value))
 		},
 		Hash: func() uint32 {
-			return crc32.ChecksumIEEE([]byte(fmt.Sprint(rvalue.uint64Value)))
+			return hashKey(formatUintKey(uint64(rvalue.uint64Value)))
 		},
 		RawValue: func() interface{} {
 			if rvalue.IsNil {
@@ -2162,10 +2301,10 @@ func NewFloat32Value(v float32) *Value {
 			if rvalue.IsNil {
 				return nilStr
 			}
-			return fmt.Sprint(rvalue.float32Value)
+			return formatFloatKey(float64(rvalue.float32Value), 32)
 		},
 		Hash: func() uint32 {
-			return crc32.ChecksumIEEE([]byte(fmt.Sprint(rvalue.float32Value)))
+			return hashKey(formatFloatKey(float64(rvalue.float32Value), 32))
 		},
 		RawValue: func() interface{} {
 			if rvalue.IsNil {
@@ -2241,10 +2380,10 @@ func NewFloat64Value(v float64) *Value {
 			if rvalue.IsNil {
 				return nilStr
 			}
-			return fmt.Sprint(rvalue.float64Value)
+			return formatFloatKey(rvalue.float64Value, 64)
 		},
 		Hash: func() uint32 {
-			return crc32.ChecksumIEEE([]byte(fmt.Sprint(rvalue.float64Value)))
+			return hashKey(formatFloatKey(rvalue.float64Value, 64))
 		},
 		RawValue: func() interface{} {
 			if rvalue.IsNil {
@@ -2320,7 +2459,7 @@ func NewBoolValue(v bool) *Value {
 			if rvalue.IsNil {
 				return nilStr
 			}
-			return fmt.Sprint(rvalue.boolValue)
+			return formatBoolKey(rvalue.boolValue)
 		},
 		Hash: func() uint32 {
 			if rvalue.boolValue {
@@ -2341,39 +2480,68 @@ func NewBoolValue(v bool) *Value {
 				return nil
 			}
 			rvalue.IsNil = false
-			switch v := src.(type) {
-			case int:
-				rvalue.boolValue = v == 1
-			case int8:
-				rvalue.boolValue = v == 1
-			case int16:
-				rvalue.boolValue = v == 1
-			case int32:
-				rvalue.boolValue = v == 1
-			case int64:
-				rvalue.boolValue = v == 1
-			case uint:
-				rvalue.boolValue = v == 1
-			case uint8:
-				rvalue.boolValue = v == 1
-			case uint16:
-				rvalue.boolValue = v == 1
-			case uint32:
-				rvalue.boolValue = v == 1
-			case uint64:
-				rvalue.boolValue = v == 1
-			case bool:
-				rvalue.boolValue = v
-			case []byte:
-				// string(v[0]) is "1", but v[0] is 49
-				rvalue.boolValue = v[0] == 49
+			v, err := scanBool(src)
+			if err != nil {
+				return xerrors.Errorf("failed to scan bool: %w", err)
 			}
+			rvalue.boolValue = v
 			return nil
 		},
 	}
 	return rvalue
 }
 
+// scanBool converts value of BOOL ( tinyint(1) ) or BIT(1) column returned by driver to bool
+func scanBool(src interface{}) (bool, error) {
+	switch v := src.(type) {
+	case int:
+		return v != 0, nil
+	case int8:
+		return v != 0, nil
+	case int16:
+		return v != 0, nil
+	case int32:
+		return v != 0, nil
+	case int64:
+		return v != 0, nil
+	case uint:
+		return v != 0, nil
+	case uint8:
+		return v != 0, nil
+	case uint16:
+		return v != 0, nil
+	case uint32:
+		return v != 0, nil
+	case uint64:
+		return v != 0, nil
+	case float32:
+		return v != 0, nil
+	case float64:
+		return v != 0, nil
+	case bool:
+		return v, nil
+	case []byte:
+		// BIT(1) is returned as 0x00 or 0x01, and tinyint(1) is returned as text like "1" by text protocol
+		if len(v) == 1 && (v[0] == 0 || v[0] == 1) {
+			return v[0] == 1, nil
+		}
+		return parseBool(string(v))
+	case string:
+		return parseBool(v)
+	}
+	return false, xerrors.Errorf("%T: %w", src, ErrInvalidDecodeType)
+}
+
+func parseBool(s string) (bool, error) {
+	if v, err := strconv.ParseBool(s); err == nil {
+		return v, nil
+	}
+	if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return v != 0, nil
+	}
+	return false, xerrors.Errorf("%q: %w", s, ErrInvalidDecodeType)
+}
+
 func NewStringValue(v string) *Value {
 	var rvalue *Value
 	rvalue = &Value{
@@ -2420,7 +2588,7 @@ func NewStringValue(v string) *Value {
 			return strconv.Quote(rvalue.stringValue)
 		},
 		Hash: func() uint32 {
-			return crc32.ChecksumIEEE([]byte(rvalue.stringValue))
+			return hashKey(rvalue.stringValue)
 		},
 		RawValue: func() interface{} {
 			if rvalue.IsNil {
@@ -2497,7 +2665,7 @@ func NewBytesValue(v []byte) *Value {
 			return string(rvalue.bytesValue)
 		},
 		Hash: func() uint32 {
-			return crc32.ChecksumIEEE(rvalue.bytesValue)
+			return hashKeyBytes(rvalue.bytesValue)
 		},
 		RawValue: func() interface{} {
 			if rvalue.IsNil {
@@ -2572,10 +2740,10 @@ func NewTimeValue(v time.Time) *Value {
 			if rvalue.IsNil {
 				return nilStr
 			}
-			return fmt.Sprint(rvalue.timeValue.Unix())
+			return formatIntKey(rvalue.timeValue.Unix())
 		},
 		Hash: func() uint32 {
-			return crc32.ChecksumIEEE([]byte(fmt.Sprint(rvalue.timeValue.Unix())))
+			return hashKey(formatIntKey(rvalue.timeValue.Unix()))
 		},
 		RawValue: func() interface{} {
 			if rvalue.IsNil {
@@ -2604,6 +2772,17 @@ func ValuesToValue(v []*Value) *Value {
 	var rvalue *Value
 	rvalue = &Value{
 		sliceValue: v,
+		String: func() string {
+			values := make([]string, 0, len(rvalue.sliceValue))
+			for _, v := range rvalue.sliceValue {
+				if v == nil || v.String == nil {
+					values = append(values, nilStr)
+					continue
+				}
+				values = append(values, v.String())
+			}
+			return "[" + strings.Join(values, ",") + "]"
+		},
 		encode: func(enc *msgpack.Encoder) error {
 			if err := enc.EncodeArrayHeader(len(rvalue.sliceValue)); err != nil {
 				return xerrors.Errorf("failed to encode array header: %w", err)
@@ -2629,6 +2808,13 @@ func StructSliceValueToValue(v *StructSliceValue) *Value {
 	var rvalue *Value
 	rvalue = &Value{
 		sliceValue: values,
+		String: func() string {
+			values := make([]string, 0, len(rvalue.sliceValue))
+			for _, v := range rvalue.sliceValue {
+				values = append(values, v.structValue.EncodeLog())
+			}
+			return "[" + strings.Join(values, ",") + "]"
+		},
 		encode: func(enc *msgpack.Encoder) error {
 			if err := enc.EncodeArrayHeader(len(rvalue.sliceValue)); err != nil {
 				return xerrors.Errorf("failed to encode array header: %w", err)
@@ -2648,6 +2834,9 @@ func StructValueToValue(v *StructValue) *Value {
 	var rvalue *Value
 	rvalue = &Value{
 		structValue: v,
+		String: func() string {
+			return rvalue.structValue.EncodeLog()
+		},
 		encode: func(enc *msgpack.Encoder) error {
 			if err := rvalue.structValue.encode(enc); err != nil {
 				return xerrors.Errorf("failed to encode struct: %w", err)
@@ -2668,6 +2857,26 @@ func NewStructSliceValue() *StructSliceValue {
 	}
 }
 
+// NewStructSliceValueWithCapacity creates StructSliceValue that can hold capacity values without growing.
+func NewStructSliceValueWithCapacity(capacity int) *StructSliceValue {
+	if capacity < 0 {
+		capacity = 0
+	}
+	return &StructSliceValue{
+		values: make([]*StructValue, 0, capacity),
+	}
+}
+
+// Reserve grows capacity so that n more values can be appended without reallocation.
+func (v *StructSliceValue) Reserve(n int) {
+	if n <= 0 || cap(v.values)-len(v.values) >= n {
+		return
+	}
+	values := make([]*StructValue, len(v.values), len(v.values)+n)
+	copy(values, v.values)
+	v.values = values
+}
+
 func (v *StructSliceValue) Release() {
 	for _, value := range v.values {
 		value.Release()
@@ -2898,6 +3107,14 @@ func (v *StructSliceValue) Error() error {
 	return nil
 }
 
+func (v *StructSliceValue) Errors() []*FieldError {
+	errs := []*FieldError{}
+	for _, value := range v.values {
+		errs = append(errs, value.Errors()...)
+	}
+	return errs
+}
+
 func (v *StructSliceValue) Append(value *StructValue) {
 	if value == nil {
 		return
@@ -2910,16 +3127,25 @@ func (v *StructSliceValue) AppendSlice(slice *StructSliceValue) {
 }
 
 func (v *StructSliceValue) Sort(orders []*OrderCondition) {
-	for _, order := range orders {
-		column := order.column
-		isAsc := order.isAsc
-		sort.SliceStable(v.values, func(i, j int) bool {
-			if isAsc {
-				return v.values[i].fields[column].LT(v.values[j].fields[column])
-			}
-			return v.values[i].fields[column].GT(v.values[j].fields[column])
-		})
+	if len(orders) == 0 || len(v.values) < 2 {
+		return
 	}
+	sort.SliceStable(v.values, func(i, j int) bool {
+		if v.values[i] == nil || v.values[j] == nil {
+			return v.values[j] == nil && v.values[i] != nil
+		}
+		for _, order := range orders {
+			left := v.values[i].fields[order.column]
+			right := v.values[j].fields[order.column]
+			if left.LT(right) {
+				return order.isAsc
+			}
+			if left.GT(right) {
+				return !order.isAsc
+			}
+		}
+		return false
+	})
 }
 
 func (v *StructSliceValue) Filter(condition Condition) *StructSliceValue {
@@ -2933,9 +3159,10 @@ func (v *StructSliceValue) Filter(condition Condition) *StructSliceValue {
 }
 
 type StructValue struct {
-	typ       *Struct
-	fields    map[string]*Value
-	decodeErr error
+	typ        *Struct
+	fields     map[string]*Value
+	decodeErr  error
+	decodeErrs []*FieldError
 }
 
 var (
@@ -3061,7 +3288,7 @@ func (v *StructValue) encode(enc *msgpack.Encoder) error {
 				return xerrors.Errorf("failed to encode: %w", err)
 			}
 		} else {
-			if err := encodeDefaultValue(v.typ.fields[column].typ, enc); err != nil {
+			if err := v.typ.fields[column].encodeDefault(enc); err != nil {
 				return xerrors.Errorf("failed to encode default value: %w", err)
 			}
 		}
@@ -3114,284 +3341,284 @@ func (v *StructValue) At(int) Decoder {
 }
 
 func (v *StructValue) Int(column string) int {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return 0
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return 0
 	}
 	if value.typ != IntType {
-		v.decodeErr = xerrors.Errorf("%s.%s type is %s but required int: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is %s but required int: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return 0
 	}
 	return value.intValue
 }
 
 func (v *StructValue) Int8(column string) int8 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return 0
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return 0
 	}
 	if value.typ != Int8Type {
-		v.decodeErr = xerrors.Errorf("%s.%s type is %s but required int8: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is %s but required int8: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return 0
 	}
 	return value.int8Value
 }
 
 func (v *StructValue) Int16(column string) int16 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return 0
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return 0
 	}
 	if value.typ != Int16Type {
-		v.decodeErr = xerrors.Errorf("%s.%s type is %s but required int16: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is %s but required int16: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return 0
 	}
 	return value.int16Value
 }
 
 func (v *StructValue) Int32(column string) int32 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return 0
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return 0
 	}
 	if value.typ != Int32Type {
-		v.decodeErr = xerrors.Errorf("%s.%s type is %s but required int32: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is %s but required int32: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return 0
 	}
 	return value.int32Value
 }
 
 func (v *StructValue) Int64(column string) int64 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return 0
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return 0
 	}
 	if value.typ != Int64Type {
-		v.decodeErr = xerrors.Errorf("%s.%s type is %s but required int64: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is %s but required int64: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return 0
 	}
 	return value.int64Value
 }
 
 func (v *StructValue) Uint(column string) uint {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return 0
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return 0
 	}
 	if value.typ != UintType {
-		v.decodeErr = xerrors.Errorf("%s.%s type is %s but required uint: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is %s but required uint: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return 0
 	}
 	return value.uintValue
 }
 
 func (v *StructValue) Uint8(column string) uint8 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return 0
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return 0
 	}
 	if value.typ != Uint8Type {
-		v.decodeErr = xerrors.Errorf("%s.%s type is %s but required uint8: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is %s but required uint8: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return 0
 	}
 	return value.uint8Value
 }
 
 func (v *StructValue) Uint16(column string) uint16 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return 0
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return 0
 	}
 	if value.typ != Uint16Type {
-		v.decodeErr = xerrors.Errorf("%s.%s type is %s but required uint16: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is %s but required uint16: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return 0
 	}
 	return value.uint16Value
 }
 
 func (v *StructValue) Uint32(column string) uint32 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return 0
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return 0
 	}
 	if value.typ != Uint32Type {
-		v.decodeErr = xerrors.Errorf("%s.%s type is %s but required uint32: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is %s but required uint32: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return 0
 	}
 	return value.uint32Value
 }
 
 func (v *StructValue) Uint64(column string) uint64 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return 0
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return 0
 	}
 	if value.typ != Uint64Type {
-		v.decodeErr = xerrors.Errorf("%s.%s type is %s but required uint64: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is %s but required uint64: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return 0
 	}
 	return value.uint64Value
 }
 
 func (v *StructValue) Float32(column string) float32 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return 0
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return 0
 	}
 	if value.typ != Float32Type {
-		v.decodeErr = xerrors.Errorf("%s.%s type is %s but required float32: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is %s but required float32: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return 0
 	}
 	return value.float32Value
 }
 
 func (v *StructValue) Float64(column string) float64 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return 0
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return 0
 	}
 	if value.typ != Float64Type {
-		v.decodeErr = xerrors.Errorf("%s.%s type is %s but required float64: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is %s but required float64: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return 0
 	}
 	return value.float64Value
 }
 
 func (v *StructValue) Bool(column string) bool {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return false
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return false
 	}
 	if value.typ != BoolType {
-		v.decodeErr = xerrors.Errorf("%s.%s type is %s but required bool: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is %s but required bool: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return false
 	}
 	return value.boolValue
 }
 
 func (v *StructValue) String(column string) string {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return ""
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return ""
 	}
 	if value.typ != StringType {
-		v.decodeErr = xerrors.Errorf("%s.%s type is %s but required string: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is %s but required string: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return ""
 	}
-	return value.stringValue
+	return v.decrypt(column, value.stringValue)
 }
 
 func (v *StructValue) Bytes(column string) []byte {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return []byte{}
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return []byte{}
 	}
 	if value.typ != BytesType {
-		v.decodeErr = xerrors.Errorf("%s.%s type is %s but required []byte: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is %s but required []byte: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return []byte{}
 	}
 	return value.bytesValue
 }
 
 func (v *StructValue) Time(column string) time.Time {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return time.Time{}
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return time.Time{}
 	}
 	if value.typ != TimeType {
-		v.decodeErr = xerrors.Errorf("%s.%s type is %s but required time.Time: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is %s but required time.Time: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return time.Time{}
 	}
 	return value.timeValue
 }
 
 func (v *StructValue) Ints(column string) []int {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return []int{}
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return []int{}
 	}
 	s := value.sliceValue
@@ -3403,12 +3630,12 @@ func (v *StructValue) Ints(column string) []int {
 }
 
 func (v *StructValue) Int8s(column string) []int8 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return []int8{}
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return []int8{}
 	}
 	s := value.sliceValue
@@ -3420,12 +3647,12 @@ func (v *StructValue) Int8s(column string) []int8 {
 }
 
 func (v *StructValue) Int16s(column string) []int16 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return []int16{}
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return []int16{}
 	}
 	s := value.sliceValue
@@ -3437,12 +3664,12 @@ func (v *StructValue) Int16s(column string) []int16 {
 }
 
 func (v *StructValue) Int32s(column string) []int32 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return []int32{}
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return []int32{}
 	}
 	s := value.sliceValue
@@ -3454,12 +3681,12 @@ func (v *StructValue) Int32s(column string) []int32 {
 }
 
 func (v *StructValue) Int64s(column string) []int64 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return []int64{}
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return []int64{}
 	}
 	s := value.sliceValue
@@ -3471,12 +3698,12 @@ func (v *StructValue) Int64s(column string) []int64 {
 }
 
 func (v *StructValue) Uints(column string) []uint {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return []uint{}
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return []uint{}
 	}
 	s := value.sliceValue
@@ -3488,12 +3715,12 @@ func (v *StructValue) Uints(column string) []uint {
 }
 
 func (v *StructValue) Uint8s(column string) []uint8 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return []uint8{}
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return []uint8{}
 	}
 	s := value.sliceValue
@@ -3505,12 +3732,12 @@ func (v *StructValue) Uint8s(column string) []uint8 {
 }
 
 func (v *StructValue) Uint16s(column string) []uint16 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return []uint16{}
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return []uint16{}
 	}
 	s := value.sliceValue
@@ -3522,12 +3749,12 @@ func (v *StructValue) Uint16s(column string) []uint16 {
 }
 
 func (v *StructValue) Uint32s(column string) []uint32 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return []uint32{}
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return []uint32{}
 	}
 	s := value.sliceValue
@@ -3539,12 +3766,12 @@ func (v *StructValue) Uint32s(column string) []uint32 {
 }
 
 func (v *StructValue) Uint64s(column string) []uint64 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return []uint64{}
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return []uint64{}
 	}
 	s := value.sliceValue
@@ -3556,12 +3783,12 @@ func (v *StructValue) Uint64s(column string) []uint64 {
 }
 
 func (v *StructValue) Float32s(column string) []float32 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return []float32{}
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return []float32{}
 	}
 	s := value.sliceValue
@@ -3573,12 +3800,12 @@ func (v *StructValue) Float32s(column string) []float32 {
 }
 
 func (v *StructValue) Float64s(column string) []float64 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return []float64{}
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return []float64{}
 	}
 	s := value.sliceValue
@@ -3590,12 +3817,12 @@ func (v *StructValue) Float64s(column string) []float64 {
 }
 
 func (v *StructValue) Bools(column string) []bool {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return []bool{}
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return []bool{}
 	}
 	s := value.sliceValue
@@ -3607,12 +3834,12 @@ func (v *StructValue) Bools(column string) []bool {
 }
 
 func (v *StructValue) Strings(column string) []string {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return []string{}
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return []string{}
 	}
 	s := value.sliceValue
@@ -3624,12 +3851,12 @@ func (v *StructValue) Strings(column string) []string {
 }
 
 func (v *StructValue) Times(column string) []time.Time {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return []time.Time{}
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return []time.Time{}
 	}
 	s := value.sliceValue
@@ -3641,51 +3868,51 @@ func (v *StructValue) Times(column string) []time.Time {
 }
 
 func (v *StructValue) Slice(column string, unmarshaler Unmarshaler) {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return
 	}
 	if err := unmarshaler.DecodeRapidash(Values(value.sliceValue)); err != nil {
-		v.decodeErr = xerrors.Errorf("failed to decode slice value: %w", err)
+		v.addDecodeError(column, xerrors.Errorf("failed to decode slice value: %w", err))
 	}
 }
 
 func (v *StructValue) Struct(column string, unmarshaler Unmarshaler) {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return
 	}
 	if value.structValue == nil {
 		return
 	}
 	if err := unmarshaler.DecodeRapidash(value.structValue); err != nil {
-		v.decodeErr = xerrors.Errorf("failed to decode struct value: %w", err)
+		v.addDecodeError(column, xerrors.Errorf("failed to decode struct value: %w", err))
 	}
 }
 
 func (v *StructValue) IntPtr(column string) *int {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return nil
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return nil
 	}
 	if value.IsNil {
 		return nil
 	}
 	if value.typ != IntType {
-		v.decodeErr = xerrors.Errorf("%s.%s type is *%s but required *int: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is *%s but required *int: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return nil
 	}
 	i := value.intValue
@@ -3693,20 +3920,20 @@ func (v *StructValue) IntPtr(column string) *int {
 }
 
 func (v *StructValue) Int8Ptr(column string) *int8 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return nil
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return nil
 	}
 	if value.IsNil {
 		return nil
 	}
 	if value.typ != Int8Type {
-		v.decodeErr = xerrors.Errorf("%s.%s type is *%s but required *int8: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is *%s but required *int8: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return nil
 	}
 	i := value.int8Value
@@ -3714,20 +3941,20 @@ func (v *StructValue) Int8Ptr(column string) *int8 {
 }
 
 func (v *StructValue) Int16Ptr(column string) *int16 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return nil
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return nil
 	}
 	if value.IsNil {
 		return nil
 	}
 	if value.typ != Int16Type {
-		v.decodeErr = xerrors.Errorf("%s.%s type is *%s but required *int16: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is *%s but required *int16: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return nil
 	}
 	i := value.int16Value
@@ -3735,20 +3962,20 @@ func (v *StructValue) Int16Ptr(column string) *int16 {
 }
 
 func (v *StructValue) Int32Ptr(column string) *int32 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return nil
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return nil
 	}
 	if value.IsNil {
 		return nil
 	}
 	if value.typ != Int32Type {
-		v.decodeErr = xerrors.Errorf("%s.%s type is *%s but required *int32: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is *%s but required *int32: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return nil
 	}
 	i := value.int32Value
@@ -3756,20 +3983,20 @@ func (v *StructValue) Int32Ptr(column string) *int32 {
 }
 
 func (v *StructValue) Int64Ptr(column string) *int64 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return nil
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return nil
 	}
 	if value.IsNil {
 		return nil
 	}
 	if value.typ != Int64Type {
-		v.decodeErr = xerrors.Errorf("%s.%s type is *%s but required *int64: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is *%s but required *int64: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return nil
 	}
 	i := value.int64Value
@@ -3777,20 +4004,20 @@ func (v *StructValue) Int64Ptr(column string) *int64 {
 }
 
 func (v *StructValue) UintPtr(column string) *uint {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return nil
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return nil
 	}
 	if value.IsNil {
 		return nil
 	}
 	if value.typ != UintType {
-		v.decodeErr = xerrors.Errorf("%s.%s type is *%s but required *uint: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is *%s but required *uint: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return nil
 	}
 	u := value.uintValue
@@ -3798,20 +4025,20 @@ func (v *StructValue) UintPtr(column string) *uint {
 }
 
 func (v *StructValue) Uint8Ptr(column string) *uint8 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return nil
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return nil
 	}
 	if value.IsNil {
 		return nil
 	}
 	if value.typ != Uint8Type {
-		v.decodeErr = xerrors.Errorf("%s.%s type is *%s but required *uint8",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is *%s but required *uint8",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return nil
 	}
 	u := value.uint8Value
@@ -3819,20 +4046,20 @@ func (v *StructValue) Uint8Ptr(column string) *uint8 {
 }
 
 func (v *StructValue) Uint16Ptr(column string) *uint16 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return nil
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return nil
 	}
 	if value.IsNil {
 		return nil
 	}
 	if value.typ != Uint16Type {
-		v.decodeErr = xerrors.Errorf("%s.%s type is *%s but required *uint16: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is *%s but required *uint16: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return nil
 	}
 	u := value.uint16Value
@@ -3840,20 +4067,20 @@ func (v *StructValue) Uint16Ptr(column string) *uint16 {
 }
 
 func (v *StructValue) Uint32Ptr(column string) *uint32 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return nil
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return nil
 	}
 	if value.IsNil {
 		return nil
 	}
 	if value.typ != Uint32Type {
-		v.decodeErr = xerrors.Errorf("%s.%s type is *%s but required *uint32: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is *%s but required *uint32: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return nil
 	}
 	u := value.uint32Value
@@ -3861,20 +4088,20 @@ func (v *StructValue) Uint32Ptr(column string) *uint32 {
 }
 
 func (v *StructValue) Uint64Ptr(column string) *uint64 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return nil
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return nil
 	}
 	if value.IsNil {
 		return nil
 	}
 	if value.typ != Uint64Type {
-		v.decodeErr = xerrors.Errorf("%s.%s type is *%s but required *uint64: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is *%s but required *uint64: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return nil
 	}
 	u := value.uint64Value
@@ -3882,20 +4109,20 @@ func (v *StructValue) Uint64Ptr(column string) *uint64 {
 }
 
 func (v *StructValue) Float32Ptr(column string) *float32 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return nil
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return nil
 	}
 	if value.IsNil {
 		return nil
 	}
 	if value.typ != Float32Type {
-		v.decodeErr = xerrors.Errorf("%s.%s type is *%s but required *float32: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is *%s but required *float32: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return nil
 	}
 	f := value.float32Value
@@ -3903,20 +4130,20 @@ func (v *StructValue) Float32Ptr(column string) *float32 {
 }
 
 func (v *StructValue) Float64Ptr(column string) *float64 {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return nil
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return nil
 	}
 	if value.IsNil {
 		return nil
 	}
 	if value.typ != Float64Type {
-		v.decodeErr = xerrors.Errorf("%s.%s type is *%s but required *float64: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is *%s but required *float64: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return nil
 	}
 	f := value.float64Value
@@ -3924,20 +4151,20 @@ func (v *StructValue) Float64Ptr(column string) *float64 {
 }
 
 func (v *StructValue) BoolPtr(column string) *bool {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return nil
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return nil
 	}
 	if value.IsNil {
 		return nil
 	}
 	if value.typ != BoolType {
-		v.decodeErr = xerrors.Errorf("%s.%s type is *%s but required *bool: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is *%s but required *bool: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return nil
 	}
 	b := value.boolValue
@@ -3945,41 +4172,54 @@ func (v *StructValue) BoolPtr(column string) *bool {
 }
 
 func (v *StructValue) StringPtr(column string) *string {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return nil
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return nil
 	}
 	if value.IsNil {
 		return nil
 	}
 	if value.typ != StringType {
-		v.decodeErr = xerrors.Errorf("%s.%s type is *%s but required *string: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is *%s but required *string: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return nil
 	}
-	s := value.stringValue
+	s := v.decrypt(column, value.stringValue)
 	return &s
 }
 
+func (v *StructValue) decrypt(column string, s string) string {
+	field, exists := v.typ.encryptedField(column)
+	if !exists {
+		return s
+	}
+	decrypted, err := field.decrypt(v.typ.cipher, s)
+	if err != nil {
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, err))
+		return ""
+	}
+	return decrypted
+}
+
 func (v *StructValue) BytesPtr(column string) *[]byte {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return nil
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return nil
 	}
 	if value.IsNil {
 		return nil
 	}
 	if value.typ != BytesType {
-		v.decodeErr = xerrors.Errorf("%s.%s type is *%s but required *[]byte: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is *%s but required *[]byte: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return nil
 	}
 	b := value.bytesValue
@@ -3987,20 +4227,20 @@ func (v *StructValue) BytesPtr(column string) *[]byte {
 }
 
 func (v *StructValue) TimePtr(column string) *time.Time {
-	if v.decodeErr != nil {
+	if v.failFast() {
 		return nil
 	}
-	value, exists := v.fields[column]
+	value, exists := v.fields[v.typ.columnName(column)]
 	if !exists {
-		v.decodeErr = xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s: %w", v.typ.tableName, column, ErrUnknownColumnName))
 		return nil
 	}
 	if value.IsNil {
 		return nil
 	}
 	if value.typ != TimeType {
-		v.decodeErr = xerrors.Errorf("%s.%s type is *%s but required *time.Time: %w",
-			v.typ.tableName, column, value.typ, ErrInvalidDecodeType)
+		v.addDecodeError(column, xerrors.Errorf("%s.%s type is *%s but required *time.Time: %w",
+			v.typ.tableName, column, value.typ, ErrInvalidDecodeType))
 		return nil
 	}
 	t := value.timeValue
@@ -4011,8 +4251,43 @@ func (v *StructValue) Error() error {
 	return v.decodeErr
 }
 
+// Errors returns errors of all columns failed to decode
+func (v *StructValue) Errors() []*FieldError {
+	return v.decodeErrs
+}
+
+func (v *StructValue) addDecodeError(column string, err error) {
+	if v.decodeErr == nil {
+		v.decodeErr = err
+	}
+	v.decodeErrs = append(v.decodeErrs, &FieldError{Table: v.typ.tableName, Column: column, Err: err})
+}
+
+// failFast returns true if getters should return zero value without decoding because of previous error
+func (v *StructValue) failFast() bool {
+	return v.typ.failFast && v.decodeErr != nil
+}
+
+func (v *StructValue) SetValue(column string, value *Value) {
+	v.fields[v.typ.columnName(column)] = value
+}
+
+func (v *StructValue) copy() *StructValue {
+	if v == nil {
+		return nil
+	}
+	fields := make(map[string]*Value, len(v.fields))
+	for column, value := range v.fields {
+		fields[column] = value
+	}
+	return &StructValue{
+		typ:    v.typ,
+		fields: fields,
+	}
+}
+
 func (v *StructValue) ValueByColumn(column string) *Value {
-	return v.fields[column]
+	return v.fields[v.typ.columnName(column)]
 }
 
 func (sf *StructField) ScanValue(factory *ValueFactory) *Value {
@@ -4021,9 +4296,135 @@ func (sf *StructField) ScanValue(factory *ValueFactory) *Value {
 
 func NewStruct(tableName string) *Struct {
 	return &Struct{
-		tableName: tableName,
-		fields:    map[string]*StructField{},
+		tableName:   tableName,
+		fields:      map[string]*StructField{},
+		aliases:     map[string]string{},
+		timeBuckets: map[string]time.Duration{},
+		templates:   &sqlTemplates{},
+	}
+}
+
+// NewStructFrom creates Struct for tableName that has the same fields as base
+func NewStructFrom(base *Struct, tableName string) *Struct {
+	return NewStruct(tableName).Merge(base)
+}
+
+// Merge appends fields of other that s doesn't have. index of existing fields never changes
+func (s *Struct) Merge(other *Struct) *Struct {
+	if other == nil {
+		return s
+	}
+	for _, field := range other.sortedFields() {
+		if _, exists := s.fields[field.column]; exists {
+			continue
+		}
+		subtypeStruct := field.subtypeStruct
+		if subtypeStruct == other {
+			subtypeStruct = s
+		}
+		s.fields[field.column] = &StructField{
+			typ:           field.typ,
+			kind:          field.kind,
+			column:        field.column,
+			index:         len(s.fields),
+			subtype:       field.subtype,
+			subtypeStruct: subtypeStruct,
+			encryption:    field.encryption,
+			defaultValue:  field.defaultValue,
+			notNull:       field.notNull,
+		}
+	}
+	for name, column := range other.aliases {
+		if _, exists := s.aliases[name]; exists {
+			continue
+		}
+		s.aliases[name] = column
+	}
+	if s.columnMapper == nil {
+		s.columnMapper = other.columnMapper
+	}
+	for column, bucket := range other.timeBuckets {
+		if _, exists := s.timeBuckets[column]; exists {
+			continue
+		}
+		s.timeBuckets[column] = bucket
+	}
+	for column, transform := range other.keyTransform {
+		if s.keyTransformByColumn(column) != nil {
+			continue
+		}
+		s.KeyTransform(column, transform)
+	}
+	return s
+}
+
+// FailFast makes getters of Decoder return zero value without decoding after the first error.
+// By default, getters keep decoding other columns, so Errors() reports every mismatched column
+func (s *Struct) FailFast() *Struct {
+	s.failFast = true
+	return s
+}
+
+// Alias makes name usable as column in Encoder/Decoder. SQL and cache key always use column
+func (s *Struct) Alias(name, column string) *Struct {
+	s.aliases[name] = column
+	return s
+}
+
+// FieldAlias declares that oldColumn is renamed to column.
+// Encoder/Decoder accept both names and value is always stored as column.
+// If oldColumn is declared as field, it is renamed keeping its position, so cached values encoded before the rename are still decoded
+func (s *Struct) FieldAlias(column, oldColumn string) *Struct {
+	field, declared := s.fields[oldColumn]
+	if _, exists := s.fields[column]; !exists && declared {
+		delete(s.fields, oldColumn)
+		field.column = column
+		s.fields[column] = field
+		if bucket, exists := s.timeBuckets[oldColumn]; exists {
+			delete(s.timeBuckets, oldColumn)
+			s.timeBuckets[column] = bucket
+		}
+		if transform, exists := s.keyTransform[oldColumn]; exists {
+			delete(s.keyTransform, oldColumn)
+			s.keyTransform[column] = transform
+		}
+		for name, aliased := range s.aliases {
+			if aliased == oldColumn {
+				s.aliases[name] = column
+			}
+		}
+	}
+	return s.Alias(oldColumn, column)
+}
+
+// ColumnMapper converts name given to Encoder/Decoder into column name ( e.g. UserID => user_id )
+func (s *Struct) ColumnMapper(mapper func(string) string) *Struct {
+	s.columnMapper = mapper
+	return s
+}
+
+func (s *Struct) columnName(name string) string {
+	if s == nil {
+		return name
+	}
+	if _, exists := s.fields[name]; exists {
+		return name
+	}
+	if column, exists := s.aliases[name]; exists {
+		return column
+	}
+	if s.columnMapper != nil {
+		return s.columnMapper(name)
+	}
+	return name
+}
+
+// nextFieldIndex keeps position of column declared again, so order of columns doesn't depend on map iteration
+func (s *Struct) nextFieldIndex(column string) int {
+	if field, exists := s.fields[column]; exists {
+		return field.index
 	}
+	return len(s.fields)
 }
 
 func (s *Struct) sortedFields() []*StructField {
@@ -4032,7 +4433,10 @@ func (s *Struct) sortedFields() []*StructField {
 		fields = append(fields, field)
 	}
 	sort.Slice(fields, func(i, j int) bool {
-		return fields[i].index < fields[j].index
+		if fields[i].index != fields[j].index {
+			return fields[i].index < fields[j].index
+		}
+		return fields[i].column < fields[j].column
 	})
 	return fields
 }
@@ -4042,7 +4446,7 @@ func (s *Struct) addNewField(column string, typ TypeID, kind TypeKind) *Struct {
 		typ:    typ,
 		kind:   kind,
 		column: column,
-		index:  len(s.fields),
+		index:  s.nextFieldIndex(column),
 	}
 	s.fields[column] = field
 	return s
@@ -4112,11 +4516,92 @@ func (s *Struct) FieldTime(column string) *Struct {
 	return s.addNewField(column, TimeType, TimeKind)
 }
 
+// FieldTimeBucket adds time field whose value is truncated by bucket ( aligned to UTC ) in cache key of index.
+// Values in the same bucket share one cache key, and they are filtered by query condition after lookup.
+func (s *Struct) FieldTimeBucket(column string, bucket time.Duration) *Struct {
+	if s.timeBuckets == nil {
+		s.timeBuckets = map[string]time.Duration{}
+	}
+	s.timeBuckets[column] = bucket
+	return s.FieldTime(column)
+}
+
+func (s *Struct) timeBucket(column string) time.Duration {
+	if s == nil {
+		return 0
+	}
+	return s.timeBuckets[column]
+}
+
+func (s *Struct) FieldIntAs(name, column string) *Struct {
+	return s.FieldInt(column).Alias(name, column)
+}
+
+func (s *Struct) FieldInt8As(name, column string) *Struct {
+	return s.FieldInt8(column).Alias(name, column)
+}
+
+func (s *Struct) FieldInt16As(name, column string) *Struct {
+	return s.FieldInt16(column).Alias(name, column)
+}
+
+func (s *Struct) FieldInt32As(name, column string) *Struct {
+	return s.FieldInt32(column).Alias(name, column)
+}
+
+func (s *Struct) FieldInt64As(name, column string) *Struct {
+	return s.FieldInt64(column).Alias(name, column)
+}
+
+func (s *Struct) FieldUintAs(name, column string) *Struct {
+	return s.FieldUint(column).Alias(name, column)
+}
+
+func (s *Struct) FieldUint8As(name, column string) *Struct {
+	return s.FieldUint8(column).Alias(name, column)
+}
+
+func (s *Struct) FieldUint16As(name, column string) *Struct {
+	return s.FieldUint16(column).Alias(name, column)
+}
+
+func (s *Struct) FieldUint32As(name, column string) *Struct {
+	return s.FieldUint32(column).Alias(name, column)
+}
+
+func (s *Struct) FieldUint64As(name, column string) *Struct {
+	return s.FieldUint64(column).Alias(name, column)
+}
+
+func (s *Struct) FieldFloat32As(name, column string) *Struct {
+	return s.FieldFloat32(column).Alias(name, column)
+}
+
+func (s *Struct) FieldFloat64As(name, column string) *Struct {
+	return s.FieldFloat64(column).Alias(name, column)
+}
+
+func (s *Struct) FieldBoolAs(name, column string) *Struct {
+	return s.FieldBool(column).Alias(name, column)
+}
+
+func (s *Struct) FieldStringAs(name, column string) *Struct {
+	return s.FieldString(column).Alias(name, column)
+}
+
+func (s *Struct) FieldBytesAs(name, column string) *Struct {
+	return s.FieldBytes(column).Alias(name, column)
+}
+
+func (s *Struct) FieldTimeAs(name, column string) *Struct {
+	return s.FieldTime(column).Alias(name, column)
+}
+
 func (s *Struct) FieldSlice(column string, typ TypeID) *Struct {
 	field := &StructField{
 		typ:     SliceType,
 		column:  column,
-		index:   len(s.fields),
+		index:   s.nextFieldIndex(column),
 		subtype: typ,
 	}
 	s.fields[column] = field
@@ -4127,7 +4612,7 @@ func (s *Struct) FieldStructSlice(column string, structType *Struct) *Struct {
 	field := &StructField{
 		typ:           SliceType,
 		column:        column,
-		index:         len(s.fields),
+		index:         s.nextFieldIndex(column),
 		subtype:       StructType,
 		subtypeStruct: structType,
 	}
@@ -4139,7 +4624,7 @@ func (s *Struct) FieldSelfStructSlice(column string) *Struct {
 	field := &StructField{
 		typ:           SliceType,
 		column:        column,
-		index:         len(s.fields),
+		index:         s.nextFieldIndex(column),
 		subtype:       StructType,
 		subtypeStruct: s,
 	}
@@ -4151,7 +4636,7 @@ func (s *Struct) FieldStruct(column string, structType *Struct) *Struct {
 	field := &StructField{
 		typ:           StructType,
 		column:        column,
-		index:         len(s.fields),
+		index:         s.nextFieldIndex(column),
 		subtype:       StructType,
 		subtypeStruct: structType,
 	}
@@ -4163,7 +4648,7 @@ func (s *Struct) FieldSelfStruct(column string) *Struct {
 	field := &StructField{
 		typ:           StructType,
 		column:        column,
-		index:         len(s.fields),
+		index:         s.nextFieldIndex(column),
 		subtype:       StructType,
 		subtypeStruct: s,
 	}
@@ -4175,6 +4660,41 @@ func (s *Struct) Cast(coder Coder) Type {
 	return &StructCoder{typ: s, value: coder}
 }
 
+// TableName returns table name of s
+func (s *Struct) TableName() string {
+	return s.tableName
+}
+
+// Fields returns declared fields in order of columns
+func (s *Struct) Fields() []*StructField {
+	return s.sortedFields()
+}
+
+// Column returns column name of field
+func (sf *StructField) Column() string {
+	return sf.column
+}
+
+// Type returns type of field. Nullable field has the same type as non-null one
+func (sf *StructField) Type() TypeID {
+	return sf.typ
+}
+
+// Kind returns kind of scalar field
+func (sf *StructField) Kind() TypeKind {
+	return sf.kind
+}
+
+// Subtype returns type of elements if field is SliceType
+func (sf *StructField) Subtype() TypeID {
+	return sf.subtype
+}
+
+// Struct returns type of StructType field, or type of elements of struct slice
+func (sf *StructField) Struct() *Struct {
+	return sf.subtypeStruct
+}
+
 func (s *Struct) Columns() []string {
 	fields := s.sortedFields()
 	columns := make([]string, len(fields))
@@ -0,0 +1,270 @@
+package rapidash
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+func TestStructMerge(t *testing.T) {
+	base := NewStruct("").
+		FieldUint64("id").
+		FieldTime("created_at").
+		FieldTime("updated_at")
+	s := NewStructFrom(base, "user_items").
+		FieldUint64("user_id").
+		FieldUint64("item_id")
+	Equal(t, s.tableName, "user_items")
+	Equal(t, s.Columns(), []string{"id", "created_at", "updated_at", "user_id", "item_id"})
+	Equal(t, base.Columns(), []string{"id", "created_at", "updated_at"})
+
+	audit := NewStruct("").
+		FieldString("updated_by").
+		FieldUint64("id")
+	s.Merge(audit)
+	Equal(t, s.Columns(), []string{"id", "created_at", "updated_at", "user_id", "item_id", "updated_by"})
+	Equal(t, s.fields["id"].index, 0)
+	Equal(t, s.fields["updated_by"].index, 5)
+}
+
+func TestStructAlias(t *testing.T) {
+	s := NewStruct("user_items").
+		FieldUint64As("ID", "id").
+		FieldStringAs("Name", "item_name").
+		FieldUint64("user_id").
+		ColumnMapper(func(name string) string {
+			if name == "UserID" {
+				return "user_id"
+			}
+			return name
+		})
+	enc := NewStructEncoder(s, NewValueFactory())
+	enc.Uint64("ID", 1)
+	enc.String("Name", "rapidash")
+	enc.Uint64("UserID", 2)
+	NoError(t, enc.Error())
+	Equal(t, enc.value.fields["item_name"].RawValue(), "rapidash")
+
+	v := enc.value
+	Equal(t, v.Uint64("ID"), uint64(1))
+	Equal(t, v.String("item_name"), "rapidash")
+	Equal(t, v.String("Name"), "rapidash")
+	Equal(t, v.Uint64("UserID"), uint64(2))
+	NoError(t, v.Error())
+}
+
+func TestStructFieldAlias(t *testing.T) {
+	factory := NewValueFactory()
+	old := NewStruct("user_items").
+		FieldUint64("id").
+		FieldString("item_name").
+		FieldUint64("user_id")
+	enc := NewStructEncoder(old, factory)
+	enc.Uint64("id", 1)
+	enc.String("item_name", "rapidash")
+	enc.Uint64("user_id", 2)
+	content, err := enc.Encode()
+	NoError(t, err)
+
+	s := NewStruct("user_items").
+		FieldUint64("id").
+		FieldString("item_name").
+		FieldUint64("user_id").
+		FieldAlias("name", "item_name")
+	Equal(t, s.Columns(), []string{"id", "name", "user_id"})
+
+	t.Run("decode value encoded before rename", func(t *testing.T) {
+		dec := NewDecoder(s, &bytes.Buffer{}, factory)
+		dec.SetBuffer(content)
+		v, err := dec.Decode()
+		NoError(t, err)
+		Equal(t, v.String("name"), "rapidash")
+		Equal(t, v.String("item_name"), "rapidash")
+		NoError(t, v.Error())
+	})
+	t.Run("encode by old column", func(t *testing.T) {
+		enc := NewStructEncoder(s, factory)
+		enc.String("item_name", "renamed")
+		NoError(t, enc.Error())
+		Equal(t, enc.value.fields["name"].RawValue(), "renamed")
+		_, exists := enc.value.fields["item_name"]
+		Equal(t, exists, false)
+	})
+	t.Run("declared after alias", func(t *testing.T) {
+		s := NewStruct("user_items").
+			FieldAlias("name", "item_name").
+			FieldString("name")
+		Equal(t, s.Columns(), []string{"name"})
+		Equal(t, s.columnName("item_name"), "name")
+	})
+}
+
+func TestStructValueErrors(t *testing.T) {
+	newValue := func(s *Struct) *StructValue {
+		enc := NewStructEncoder(s, NewValueFactory())
+		enc.Uint64("id", 1)
+		enc.String("name", "rapidash")
+		enc.Uint64("user_id", 2)
+		NoError(t, enc.Error())
+		return enc.value
+	}
+	t.Run("aggregate errors", func(t *testing.T) {
+		v := newValue(NewStruct("user_items").FieldUint64("id").FieldString("name").FieldUint64("user_id"))
+		Equal(t, v.String("id"), "")
+		Equal(t, v.Int("name"), 0)
+		Equal(t, v.Uint64("user_id"), uint64(2))
+		Equal(t, v.Bool("unknown"), false)
+		Equal(t, xerrors.Is(v.Error(), ErrInvalidDecodeType), true)
+		errs := v.Errors()
+		Equal(t, len(errs), 3)
+		Equal(t, errs[0].Table, "user_items")
+		Equal(t, errs[0].Column, "id")
+		Equal(t, errs[1].Column, "name")
+		Equal(t, errs[2].Column, "unknown")
+		Equal(t, xerrors.Is(errs[2], ErrUnknownColumnName), true)
+
+		slice := &StructSliceValue{values: []*StructValue{v, newValue(v.typ)}}
+		Equal(t, len(slice.Errors()), 3)
+	})
+	t.Run("fail fast", func(t *testing.T) {
+		v := newValue(NewStruct("user_items").FieldUint64("id").FieldString("name").FieldUint64("user_id").FailFast())
+		Equal(t, v.String("id"), "")
+		Equal(t, v.Uint64("user_id"), uint64(0))
+		Equal(t, len(v.Errors()), 1)
+	})
+}
+
+func TestFieldTimeBucket(t *testing.T) {
+	s := NewStruct("user_logins").
+		FieldUint64("id").
+		FieldTimeBucket("login_date", 24*time.Hour)
+	key := NewKey(&TableOption{}, "user_logins", []string{"login_date"}, s)
+	morning := time.Date(2019, 1, 1, 9, 0, 0, 0, time.UTC)
+	night := time.Date(2019, 1, 1, 21, 0, 0, 0, time.UTC)
+	nextDay := time.Date(2019, 1, 2, 9, 0, 0, 0, time.UTC)
+	cacheKey := func(v time.Time) string {
+		value := &StructValue{fields: map[string]*Value{"login_date": NewTimeValue(v)}}
+		k, err := key.CacheKey(value)
+		NoError(t, err)
+		return k.String()
+	}
+	Equal(t, cacheKey(morning), cacheKey(night))
+	if cacheKey(morning) == cacheKey(nextDay) {
+		t.Fatal("different bucket must have different cache key")
+	}
+
+	uniqueKey := NewUniqueKey(&TableOption{}, "user_logins", []string{"login_date"}, s)
+	Equal(t, len(uniqueKey.timeBuckets), 0)
+
+	condition, args := timeBucketCondition("login_date", 24*time.Hour, []*Value{
+		NewTimeValue(morning), NewTimeValue(night), NewTimeValue(nextDay),
+	})
+	Equal(t, condition, "((`login_date` >= ? AND `login_date` < ?) OR (`login_date` >= ? AND `login_date` < ?))")
+	Equal(t, args, []interface{}{
+		time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+		time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+		time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC),
+	})
+}
+
+func TestStructSliceValueSort(t *testing.T) {
+	newValue := func(id, week int) *StructValue {
+		return &StructValue{fields: map[string]*Value{
+			"id":   NewIntValue(id),
+			"week": NewIntValue(week),
+		}}
+	}
+	values := NewStructSliceValueWithCapacity(4)
+	values.Append(newValue(1, 2))
+	values.Append(newValue(2, 1))
+	values.Append(newValue(3, 2))
+	values.Append(newValue(4, 1))
+	values.Sort([]*OrderCondition{
+		{column: "week", isAsc: true},
+		{column: "id", isAsc: false},
+	})
+	ids := []int{}
+	for _, value := range values.values {
+		ids = append(ids, value.fields["id"].RawValue().(int))
+	}
+	Equal(t, ids, []int{4, 2, 3, 1})
+
+	values.Reserve(10)
+	Equal(t, values.Len(), 4)
+	if cap(values.values) < 14 {
+		t.Fatal("cannot reserve capacity")
+	}
+}
+
+type testUserID uint64
+
+func TestCreateUniqueValuesByReflect(t *testing.T) {
+	factory := NewValueFactory()
+	t.Run("named type", func(t *testing.T) {
+		values := factory.CreateUniqueValues([]testUserID{1, 2, 2, 3})
+		Equal(t, len(values), 3)
+		Equal(t, values[0].RawValue(), uint64(1))
+	})
+	t.Run("pointer", func(t *testing.T) {
+		a, b := testUserID(1), uint64(2)
+		values := factory.CreateUniqueValues([]interface{}{&a, &b, nil, &a})
+		Equal(t, len(values), 2)
+		Equal(t, values[1].RawValue(), uint64(2))
+	})
+	t.Run("unsupported type", func(t *testing.T) {
+		if values := factory.CreateUniqueValues([]struct{}{{}}); values != nil {
+			t.Fatal("unsupported type must be nil")
+		}
+	})
+	t.Run("in condition", func(t *testing.T) {
+		builder := NewQueryBuilder("user_logins").In("user_id", []testUserID{1, 2})
+		builder.Build(factory)
+		NoError(t, builder.validateCondition(userLoginType()))
+	})
+}
+
+func TestInt64ValueScanOverflow(t *testing.T) {
+	value := NewInt64Value(0)
+	NoError(t, value.Scan(uint64(math.MaxInt64)))
+	Equal(t, value.RawValue(), int64(math.MaxInt64))
+	err := value.Scan(uint64(math.MaxInt64) + 1)
+	Error(t, err)
+	Equal(t, xerrors.Is(err, ErrInt64Overflow), true)
+}
+
+func TestBoolValueScan(t *testing.T) {
+	for _, tc := range []struct {
+		src      interface{}
+		expected bool
+	}{
+		{src: int64(1), expected: true},
+		{src: int64(0), expected: false},
+		{src: int64(2), expected: true},
+		{src: uint8(1), expected: true},
+		{src: true, expected: true},
+		{src: []byte{0x01}, expected: true},
+		{src: []byte{0x00}, expected: false},
+		{src: []byte("1"), expected: true},
+		{src: []byte("0"), expected: false},
+		{src: []byte("true"), expected: true},
+		{src: []byte("FALSE"), expected: false},
+		{src: "127", expected: true},
+	} {
+		value := NewBoolValue(false)
+		NoError(t, value.Scan(tc.src))
+		if value.RawValue() != tc.expected {
+			t.Fatalf("failed to scan %#v: %v", tc.src, value.RawValue())
+		}
+	}
+	value := NewBoolValue(false)
+	NoError(t, value.Scan(nil))
+	Equal(t, value.IsNil, true)
+	err := value.Scan([]byte("yes"))
+	Equal(t, xerrors.Is(err, ErrInvalidDecodeType), true)
+	err = value.Scan(time.Time{})
+	Equal(t, xerrors.Is(err, ErrInvalidDecodeType), true)
+}
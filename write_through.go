@@ -0,0 +1,59 @@
+package rapidash
+
+import (
+	"context"
+
+	"golang.org/x/xerrors"
+)
+
+// isWriteThroughOnCreate returns true if cache can be built from inserted value.
+// Values of omitted columns without declared default are decided by database ( e.g. DEFAULT CURRENT_TIMESTAMP ),
+// so such value is only invalidated
+func (c *SecondLevelCache) isWriteThroughOnCreate(value *StructValue) bool {
+	if !c.opt.WriteThroughOnCreate() {
+		return false
+	}
+	for _, column := range c.typ.Columns() {
+		if value.fields[column] == nil && c.typ.fields[column].defaultValue == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// writeThroughOnCreate sets inserted value and its unique keys.
+// index lists are deleted like other write paths, because appending to them loses primary keys written by other processes at the same time
+func (c *SecondLevelCache) writeThroughOnCreate(ctx context.Context, tx *Tx, value *StructValue) error {
+	for _, column := range c.typ.Columns() {
+		if value.fields[column] == nil {
+			value.fields[column] = c.typ.fields[column].defaultValue
+		}
+	}
+	primaryKey, err := c.primaryKey.CacheKey(value)
+	if err != nil {
+		return xerrors.Errorf("failed to get cache key: %w", err)
+	}
+	if err := c.setPrimaryKey(ctx, tx, primaryKey, value); err != nil {
+		return xerrors.Errorf("failed to set primary key: %w", err)
+	}
+	for _, index := range c.indexes {
+		if index.Type == IndexTypePrimaryKey {
+			continue
+		}
+		key, err := index.CacheKey(value)
+		if err != nil {
+			return xerrors.Errorf("failed to get cache key: %w", err)
+		}
+		if index.Type == IndexTypeUniqueKey {
+			delete(tx.stash.oldKey, key.String())
+			if err := c.setUniqueKey(ctx, tx, key, primaryKey); err != nil {
+				return xerrors.Errorf("failed to set unique key: %w", err)
+			}
+			continue
+		}
+		if err := c.deleteOldKey(ctx, tx, key); err != nil {
+			return xerrors.Errorf("failed to delete old key: %w", err)
+		}
+	}
+	return nil
+}
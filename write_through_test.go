@@ -0,0 +1,71 @@
+package rapidash
+
+import (
+	"context"
+	"testing"
+
+	"go.knocknote.io/rapidash/server"
+)
+
+func TestWriteThroughOnCreate(t *testing.T) {
+	r, err := New(
+		SecondLevelCacheTableWriteThroughOnCreate("user_logins", true),
+		SecondLevelCacheTablePrimaryKeyGenerator("user_logins", NewSequencePrimaryKeyGenerator(100)),
+	)
+	NoError(t, err)
+	cacheServer := newMemoryCacheServer()
+	r.cacheServer = cacheServer
+	slc := NewSecondLevelCache(userLoginType(), cacheServer, r.tableOption("user_logins"))
+	slc.primaryKey = NewPrimaryKey(slc.opt, "user_logins", []string{"id"}, slc.typ)
+	slc.indexes["id"] = slc.primaryKey
+	slc.indexes["user_session_id"] = NewUniqueKey(slc.opt, "user_logins", []string{"user_session_id"}, slc.typ)
+	slc.indexes["user_id"] = NewKey(slc.opt, "user_logins", []string{"user_id"}, slc.typ)
+	r.secondLevelCaches.set("user_logins", slc)
+	ctx := context.Background()
+
+	// index list of user_id = 2 is already cached
+	tx, err := r.Begin(&execRecorder{})
+	NoError(t, err)
+	NoError(t, slc.fillKey(context.Background(), tx, &CacheKey{key: "r/slc/user_logins/idx/user_id#2", typ: server.CacheKeyTypeSLC}, []server.CacheKey{
+		&CacheKey{key: "r/slc/user_logins/id#50", typ: server.CacheKeyTypeSLC},
+	}))
+	NoError(t, tx.Commit())
+
+	tx, err = r.Begin(&execRecorder{})
+	NoError(t, err)
+	id, err := slc.Create(ctx, tx, &UserLogin{UserID: 1, UserSessionID: 10, Name: "rapidash"})
+	NoError(t, err)
+	Equal(t, id, int64(100))
+	_, err = slc.Create(ctx, tx, &UserLogin{UserID: 2, UserSessionID: 11, Name: "rapidash"})
+	NoError(t, err)
+	NoError(t, tx.Commit())
+
+	t.Run("set value and unique key", func(t *testing.T) {
+		_, exists := cacheServer.values["r/slc/user_logins/id#100"]
+		Equal(t, exists, true)
+		_, exists = cacheServer.values["r/slc/user_logins/uq/user_session_id#10"]
+		Equal(t, exists, true)
+	})
+	t.Run("delete index list", func(t *testing.T) {
+		_, exists := cacheServer.values["r/slc/user_logins/idx/user_id#1"]
+		Equal(t, exists, false)
+		_, exists = cacheServer.values["r/slc/user_logins/idx/user_id#2"]
+		Equal(t, exists, false)
+	})
+	t.Run("find created value by cache", func(t *testing.T) {
+		tx, err := r.Begin(&execRecorder{})
+		NoError(t, err)
+		var userLogin UserLogin
+		NoError(t, tx.FindByQueryBuilder(NewQueryBuilder("user_logins").Eq("user_session_id", uint64(10)), &userLogin))
+		Equal(t, userLogin.ID, uint64(100))
+		Equal(t, userLogin.Name, "rapidash")
+		NoError(t, tx.Commit())
+	})
+	t.Run("disabled", func(t *testing.T) {
+		_, value, err := slc.encode(&UserLogin{ID: 1, UserID: 1})
+		NoError(t, err)
+		Equal(t, slc.isWriteThroughOnCreate(value), true)
+		slc.opt.writeThrough = nil
+		Equal(t, slc.isWriteThroughOnCreate(value), false)
+	})
+}